@@ -0,0 +1,156 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// backfill reconstructs snapshots of rule results back to a date, so that trends are available as soon as an instance is deployed
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/history"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	configPaths      triage.ConfigPaths
+	persistBackend   = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
+	persistPath      = flag.String("persist-path", "", "Where to persist cache to (automatic)")
+	reposOverride    = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
+	gitHubTokenFile  = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile  = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+	historyRetention = flag.Duration("history-retention", 90*24*time.Hour, "How long to keep snapshots of rule results")
+
+	// backfill specific
+	since      = flag.String("since", "", "date to reconstruct snapshots from, such as 2023-01-01")
+	collection = flag.String("collection", "", "only backfill the rules of this collection")
+)
+
+func init() {
+	flag.Var(&configPaths, "config", "configuration file or directory, which may be repeated to merge several")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(configPaths) == 0 {
+		klog.Exitf("--config is required")
+	}
+
+	start, err := time.Parse("2006-01-02", *since)
+	if err != nil {
+		klog.Exitf("--since must be a date, such as 2023-01-01: %v", err)
+	}
+
+	now := time.Now()
+	if !start.Before(now) {
+		klog.Exitf("--since must be in the past")
+	}
+
+	if *persistBackend == "memory" {
+		klog.Exitf("--persist-backend=memory would discard the snapshots on exit")
+	}
+
+	ctx := context.Background()
+
+	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	if err := c.Initialize(); err != nil {
+		klog.Exitf("persist initialize from %s: %v", c, err)
+	}
+
+	cfg := triage.Config{
+		Cache:        c,
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+		ClosedAge:    now.Sub(start),
+	}
+
+	if *reposOverride != "" {
+		cfg.Repos = strings.Split(*reposOverride, ",")
+	}
+
+	tp, err := triage.New(cfg)
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	if err := tp.LoadFiles(configPaths); err != nil {
+		klog.Exitf("load %s: %v", configPaths.String(), err)
+	}
+
+	sts, err := tp.ListCollections()
+	if err != nil {
+		klog.Exitf("collections: %v", err)
+	}
+
+	h := history.New(history.Config{Cache: c, Retention: *historyRetention})
+	seen := map[string]bool{}
+	found := false
+
+	for _, s := range sts {
+		if *collection != "" && s.ID != *collection {
+			continue
+		}
+		found = true
+
+		for _, id := range s.RuleIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			r, err := tp.LookupRule(id)
+			if err != nil {
+				klog.Exitf("rule: %v", err)
+			}
+
+			cs, err := tp.BackfillRule(ctx, r, start, now)
+			if err != nil {
+				klog.Exitf("rule %q: %v", id, err)
+			}
+
+			n, err := h.Backfill(s.ID, id, cs, start, now)
+			if err != nil {
+				klog.Exitf("backfill %q: %v", id, err)
+			}
+			fmt.Printf("%s/%s: %d conversations, %d days of snapshots stored\n", s.ID, id, len(cs), n)
+		}
+	}
+
+	if !found {
+		klog.Exitf("collection %q is undefined", *collection)
+	}
+
+	if err := c.Flush(); err != nil {
+		klog.Exitf("flush: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cache inspects the persistent cache: cache ls [prefix], cache show <key>, cache rm <key>...
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	// shared with server
+	persistBackend = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
+	persistPath    = flag.String("persist-path", "", "Where to persist cache to (automatic)")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		klog.Exitf("usage: cache [flags] ls [prefix] | show <key> | rm <key>...")
+	}
+
+	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	if err := c.Initialize(); err != nil {
+		klog.Exitf("persist initialize from %s: %v", c, err)
+	}
+
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "ls":
+		prefix := ""
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+		err = list(c, prefix)
+	case "show":
+		if len(args) != 1 {
+			klog.Exitf("usage: cache show <key>")
+		}
+		err = show(c, args[0])
+	case "rm":
+		if len(args) == 0 {
+			klog.Exitf("usage: cache rm <key>...")
+		}
+		err = remove(c, args)
+	default:
+		klog.Exitf("unknown command %q: expected ls, show or rm", cmd)
+	}
+
+	if err != nil {
+		klog.Exitf("%s: %v", args[0], err)
+	}
+}
+
+// list prints the keys which start with a prefix, with their sizes and ages
+func list(c persist.Cacher, prefix string) error {
+	ks, err := c.Keys()
+	if err != nil {
+		return err
+	}
+	sort.Slice(ks, func(i, j int) bool { return ks[i].Key < ks[j].Key })
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tSIZE\tAGE")
+	total := 0
+	for _, k := range ks {
+		if !strings.HasPrefix(k.Key, prefix) {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", k.Key, size(k.Size), time.Since(k.Saved).Round(time.Second))
+		total += k.Size
+	}
+	tw.Flush()
+	fmt.Printf("total: %s\n", size(total))
+	return nil
+}
+
+// show prints a cached value as JSON
+func show(c persist.Cacher, key string) error {
+	bl := c.Get(key, time.Time{})
+	if bl == nil {
+		return fmt.Errorf("%q is not cached", key)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bl)
+}
+
+// remove deletes keys
+func remove(c persist.Cacher, keys []string) error {
+	for _, k := range keys {
+		if err := c.Delete(k); err != nil {
+			return fmt.Errorf("delete %q: %w", k, err)
+		}
+		fmt.Printf("deleted %s\n", k)
+	}
+	return nil
+}
+
+// size returns a human readable number of bytes
+func size(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fM", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fK", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
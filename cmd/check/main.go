@@ -0,0 +1,183 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// check evaluates rules once, and exits non-zero if any match more items than their thresholds allow
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+
+	"k8s.io/klog/v2"
+)
+
+// stringList is a flag which may be repeated
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	configPaths     triage.ConfigPaths
+	persistBackend  = flag.String("persist-backend", "memory", "Cache persistence backend (disk, mysql, cloudsql)")
+	persistPath     = flag.String("persist-path", "", "Where to persist cache to (automatic)")
+	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+
+	// check specific
+	rules  stringList
+	maxes  stringList
+	maxAge = flag.Duration("max-age", 0, "use cached data which is newer than this, rather than fetching it")
+)
+
+func init() {
+	flag.Var(&configPaths, "config", "configuration file or directory, which may be repeated to merge several")
+	flag.Var(&rules, "rule", "rule to check against its configured max, which may be repeated (default: every rule with a max)")
+	flag.Var(&maxes, "max", "threshold in the form of <rule>=<max>, overriding the configured max, which may be repeated")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(configPaths) == 0 {
+		klog.Exitf("--config is required")
+	}
+
+	overrides := []triage.Threshold{}
+	for _, m := range maxes {
+		t, err := triage.ParseThreshold(m)
+		if err != nil {
+			klog.Exitf("--max: %v", err)
+		}
+		overrides = append(overrides, t)
+	}
+
+	ctx := context.Background()
+
+	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	if err := c.Initialize(); err != nil {
+		klog.Exitf("persist initialize from %s: %v", c, err)
+	}
+
+	cfg := triage.Config{
+		Cache:        c,
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+	}
+
+	if *reposOverride != "" {
+		cfg.Repos = strings.Split(*reposOverride, ",")
+	}
+
+	tp, err := triage.New(cfg)
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	if err := tp.LoadFiles(configPaths); err != nil {
+		klog.Exitf("load %s: %v", configPaths.String(), err)
+	}
+
+	// Rules with a --max do not need a configured max
+	ids := []string{}
+	for _, id := range rules {
+		if !overridden(id, overrides) {
+			ids = append(ids, id)
+		}
+	}
+
+	ts := []triage.Threshold{}
+	if len(ids) > 0 || (len(rules) == 0 && len(overrides) == 0) {
+		ts, err = tp.Thresholds(ids)
+		if err != nil {
+			klog.Exitf("thresholds: %v", err)
+		}
+	}
+
+	ts = dedup(append(ts, overrides...))
+	if len(ts) == 0 {
+		klog.Exitf("no thresholds to check: set max on a rule, or pass --max")
+	}
+
+	rs, err := tp.CheckThresholds(ctx, ts, time.Now().Add(-*maxAge))
+	if err != nil {
+		klog.Exitf("check: %v", err)
+	}
+
+	failed := 0
+	for _, r := range rs {
+		status := "ok"
+		if r.Exceeded() {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%-4s  %s (%s): %d items, max %d\n", status, r.RuleID, r.Name, r.Count, r.Max)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d of %d thresholds exceeded\n", failed, len(rs))
+		os.Exit(1)
+	}
+}
+
+// overridden returns true if a rule has a threshold passed via --max
+func overridden(id string, ts []triage.Threshold) bool {
+	for _, t := range ts {
+		if t.RuleID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// dedup returns thresholds with one per rule, where later thresholds replace earlier ones
+func dedup(ts []triage.Threshold) []triage.Threshold {
+	pos := map[string]int{}
+	out := []triage.Threshold{}
+	for _, t := range ts {
+		if i, ok := pos[t.RuleID]; ok {
+			out[i] = t
+			continue
+		}
+		pos[t.RuleID] = len(out)
+		out = append(out, t)
+	}
+	return out
+}
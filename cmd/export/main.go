@@ -0,0 +1,127 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// export evaluates a collection once, and writes its results without starting a web server
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/export"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	configPaths     triage.ConfigPaths
+	persistBackend  = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
+	persistPath     = flag.String("persist-path", "", "Where to persist cache to (automatic)")
+	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+
+	// export specific
+	collection = flag.String("collection", "", "collection to export")
+	format     = flag.String("format", export.JSON, "output format: "+strings.Join(export.Formats, ", "))
+	output     = flag.String("output", "", "file to write to, instead of stdout")
+)
+
+func init() {
+	flag.Var(&configPaths, "config", "configuration file or directory, which may be repeated to merge several")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(configPaths) == 0 {
+		klog.Exitf("--config is required")
+	}
+
+	if *collection == "" {
+		klog.Exitf("--collection is required")
+	}
+
+	if err := export.CheckFormat(*format); err != nil {
+		klog.Exitf("--format: %v", err)
+	}
+
+	ctx := context.Background()
+
+	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	if err := c.Initialize(); err != nil {
+		klog.Exitf("persist initialize from %s: %v", c, err)
+	}
+
+	cfg := triage.Config{
+		Cache:        c,
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+	}
+
+	if *reposOverride != "" {
+		cfg.Repos = strings.Split(*reposOverride, ",")
+	}
+
+	tp, err := triage.New(cfg)
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	if err := tp.LoadFiles(configPaths); err != nil {
+		klog.Exitf("load %s: %v", configPaths.String(), err)
+	}
+
+	s, err := tp.LookupCollection(*collection)
+	if err != nil {
+		klog.Exitf("collection: %v", err)
+	}
+
+	r, err := tp.ExecuteCollection(ctx, s, time.Now())
+	if err != nil {
+		klog.Exitf("execute: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			klog.Exitf("create: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := export.Write(w, *format, r, time.Now()); err != nil {
+		klog.Exitf("write %s: %v", *format, err)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// freeze renders all collections to static HTML and JSON, for hosting a dashboard without running a server
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/site"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/google/triage-party/pkg/updater"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	configPaths     triage.ConfigPaths
+	persistBackend  = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
+	persistPath     = flag.String("persist-path", "", "Where to persist cache to (automatic)")
+	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+	siteDir         = flag.String("site", "site/", "path to site files")
+	thirdPartyDir   = flag.String("3p", "third_party/", "path to 3rd party files")
+	siteName        = flag.String("name", "", "override site name from config file")
+
+	// freeze specific
+	outDir = flag.String("out", "public", "directory to write the static site to")
+	prefix = flag.String("prefix", "", "path the static site is hosted under, such as /my-project for GitHub Pages")
+)
+
+func init() {
+	flag.Var(&configPaths, "config", "configuration file or directory, which may be repeated to merge several")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(configPaths) == 0 {
+		klog.Exitf("--config is required")
+	}
+
+	ctx := context.Background()
+
+	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	if err := c.Initialize(); err != nil {
+		klog.Exitf("persist initialize from %s: %v", c, err)
+	}
+
+	cfg := triage.Config{
+		Cache:        c,
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+	}
+
+	if *reposOverride != "" {
+		cfg.Repos = strings.Split(*reposOverride, ",")
+	}
+
+	tp, err := triage.New(cfg)
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	if err := tp.LoadFiles(configPaths); err != nil {
+		klog.Exitf("load %s: %v", configPaths.String(), err)
+	}
+
+	u := updater.New(updater.Config{Party: tp})
+	if _, err := u.RunOnce(ctx, true); err != nil {
+		klog.Exitf("update: %v", err)
+	}
+
+	sn := *siteName
+	if sn == "" {
+		if sn = tp.Name(); sn == "" {
+			sn = "Triage Party"
+		}
+	}
+
+	s := site.New(&site.Config{
+		BaseDirectory: *siteDir,
+		Updater:       u,
+		Party:         tp,
+		Prefix:        strings.TrimSuffix(*prefix, "/"),
+		ReadOnly:      true,
+		// Pages are rendered from freshly fetched data
+		WarnAge: 24 * time.Hour,
+		Name:    sn,
+	})
+
+	written, err := s.Freeze(*outDir)
+	if err != nil {
+		klog.Exitf("freeze: %v", err)
+	}
+
+	for src, dst := range map[string]string{
+		filepath.Join(*siteDir, "static"): filepath.Join(*outDir, "static"),
+		*thirdPartyDir:                    filepath.Join(*outDir, "third_party"),
+	} {
+		if err := copyDir(src, dst); err != nil {
+			klog.Exitf("copy %s: %v", src, err)
+		}
+	}
+
+	klog.Infof("wrote %d pages and results to %s", len(written), *outDir)
+}
+
+// copyDir recursively copies the files within src to dst
+func copyDir(src string, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// impact compares two configurations: which rules changed, and how many conversations would move between rules
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	persistBackend  = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
+	persistPath     = flag.String("persist-path", "", "Where to persist cache to (automatic)")
+	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+
+	// impact specific
+	oldPaths triage.ConfigPaths
+	newPaths triage.ConfigPaths
+	maxAge   = flag.Duration("max-age", 0, "only use cached data newer than this (0 to use any cached data)")
+	showURLs = flag.Bool("urls", false, "list the conversations which move between rules")
+)
+
+func init() {
+	flag.Var(&oldPaths, "old", "current configuration file or directory, which may be repeated to merge several")
+	flag.Var(&newPaths, "new", "proposed configuration file or directory, which may be repeated to merge several")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(oldPaths) == 0 || len(newPaths) == 0 {
+		klog.Exitf("--old and --new are required")
+	}
+
+	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	if err := c.Initialize(); err != nil {
+		klog.Exitf("persist initialize from %s: %v", c, err)
+	}
+
+	cfg := triage.Config{
+		Cache:        c,
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+	}
+
+	if *reposOverride != "" {
+		cfg.Repos = strings.Split(*reposOverride, ",")
+	}
+
+	prev := load(cfg, oldPaths)
+	next := load(cfg, newPaths)
+
+	cs, err := triage.DiffConfigs(prev, next)
+	if err != nil {
+		klog.Exitf("diff: %v", err)
+	}
+
+	if len(cs) == 0 {
+		fmt.Println("No changes to settings, collections or rules")
+		return
+	}
+
+	fmt.Println("Changes:")
+	for _, ch := range cs {
+		name := ch.Kind
+		if ch.ID != "" {
+			name = fmt.Sprintf("%s %q", ch.Kind, ch.ID)
+		}
+		if len(ch.Fields) > 0 {
+			fmt.Printf("  %s %s: %s\n", name, ch.Change, strings.Join(ch.Fields, ", "))
+		} else {
+			fmt.Printf("  %s %s\n", name, ch.Change)
+		}
+	}
+
+	newerThan := time.Time{}
+	if *maxAge > 0 {
+		newerThan = time.Now().Add(-*maxAge)
+	}
+
+	is, ms, err := triage.Impact(context.Background(), prev, next, newerThan)
+	if err != nil {
+		klog.Exitf("impact: %v", err)
+	}
+
+	fmt.Println()
+	if len(is) == 0 {
+		fmt.Println("No conversations change rules")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tBEFORE\tAFTER\tADDED\tREMOVED")
+	for _, i := range is {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t+%d\t-%d\n", i.ID, i.Before, i.After, len(i.Added), len(i.Removed))
+	}
+	tw.Flush()
+
+	fmt.Println()
+	fmt.Println("Moves:")
+	for _, m := range ms {
+		fmt.Printf("  %s -> %s: %d\n", ruleName(m.From), ruleName(m.To), len(m.URLs))
+		if *showURLs {
+			for _, u := range m.URLs {
+				fmt.Printf("    %s\n", u)
+			}
+		}
+	}
+}
+
+// load returns a party with a configuration loaded
+func load(cfg triage.Config, paths triage.ConfigPaths) *triage.Party {
+	tp, err := triage.New(cfg)
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	if err := tp.LoadFiles(paths); err != nil {
+		klog.Exitf("load %s: %v", paths.String(), err)
+	}
+	return tp
+}
+
+// ruleName describes a rule ID, where an empty ID means that no rule matches
+func ruleName(id string) string {
+	if id == "" {
+		return "(no rule)"
+	}
+	return id
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Generates a starter Triage Party configuration by inspecting a repository
+//
+// ** Basic example:
+//
+// go run main.go --github-token-file ~/.token --repo kubernetes/minikube --out config.yaml
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+
+	// init specific
+	repo    = flag.String("repo", "", "repository to inspect, as org/name for GitHub, or a full URL")
+	outPath = flag.String("out", "", "where to write the configuration (defaults to stdout)")
+	force   = flag.Bool("force", false, "overwrite --out if it already exists")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if *repo == "" {
+		klog.Exitf("--repo is required")
+	}
+
+	url := *repo
+	if !strings.Contains(url, "://") {
+		url = "https://github.com/" + strings.Trim(url, "/")
+	}
+
+	if *outPath != "" && !*force {
+		if _, err := os.Stat(*outPath); err == nil {
+			klog.Exitf("%s already exists: pass --force to overwrite it", *outPath)
+		}
+	}
+
+	c, err := persist.FromEnv("triage-party", "memory", "")
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	tp, err := triage.New(triage.Config{
+		Cache:        c,
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, constants.GitHubTokenEnvVar),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, constants.GitLabTokenEnvVar),
+	})
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	rp, err := tp.ProfileRepo(context.Background(), url)
+	if err != nil {
+		klog.Exitf("inspect %s: %v", url, err)
+	}
+	klog.Infof("profile: %+v", rp)
+
+	bs, err := triage.StarterConfig(*rp)
+	if err != nil {
+		klog.Exitf("generate: %v", err)
+	}
+
+	// Loading what we generated catches any mistakes before a server does
+	if err := tp.Load(bytes.NewReader(bs)); err != nil {
+		klog.Exitf("generated an invalid configuration: %v", err)
+	}
+
+	if *outPath == "" {
+		fmt.Print(string(bs))
+		return
+	}
+
+	if err := ioutil.WriteFile(*outPath, bs, 0o644); err != nil {
+		klog.Exitf("write: %v", err)
+	}
+	fmt.Printf("wrote %s: start a server with --config %s\n", *outPath, *outPath)
+}
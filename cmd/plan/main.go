@@ -0,0 +1,124 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// plan estimates how many API requests a configuration makes, and compares it against the token's rate limit
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	configPaths     triage.ConfigPaths
+	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+
+	// plan specific
+	refresh = flag.Duration("refresh", 60*time.Minute, "how often collections are refreshed, as with the server's --max-refresh")
+)
+
+func init() {
+	flag.Var(&configPaths, "config", "configuration file or directory, which may be repeated to merge several")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(configPaths) == 0 {
+		klog.Exitf("--config is required")
+	}
+
+	if *refresh <= 0 {
+		klog.Exitf("--refresh must be positive")
+	}
+
+	cfg := triage.Config{
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+	}
+
+	if *reposOverride != "" {
+		cfg.Repos = strings.Split(*reposOverride, ",")
+	}
+
+	tp, err := triage.New(cfg)
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	if err := tp.LoadFiles(configPaths); err != nil {
+		klog.Exitf("load %s: %v", configPaths.String(), err)
+	}
+
+	rp, err := tp.PlanRequests(context.Background(), *refresh)
+	if err != nil {
+		klog.Exitf("plan: %v", err)
+	}
+
+	printPlan(rp)
+}
+
+// printPlan writes a per-repository table of estimates, followed by a comparison against the rate limit
+func printPlan(rp *triage.RequestPlan) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tOPEN\tCLOSED\tUPDATED/DAY\tPAGES\tCOMMENTS\tTIMELINES\tREVIEWS\tCOLD START\tPER REFRESH\tPER HOUR")
+	for _, e := range rp.Repos {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			e.URL, e.Open, e.Closed, e.UpdatedPerDay, e.ListPages, e.Comments, e.Timelines, e.Reviews, e.ColdStart, e.PerRefresh, e.PerHour)
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t\t\t\t\t\t%d\t%d\t%d\n", rp.ColdStart, rp.PerRefresh, rp.PerHour)
+	tw.Flush()
+
+	fmt.Println()
+	if rp.Rate.Limit == 0 {
+		fmt.Printf("Refreshing every %s needs ~%d requests per hour (rate limit unknown)\n", rp.Refresh, rp.PerHour)
+		return
+	}
+
+	fmt.Printf("Refreshing every %s needs ~%d of %d requests per hour (%.0f%%)\n",
+		rp.Refresh, rp.PerHour, rp.Rate.Limit, float64(rp.PerHour)*100/float64(rp.Rate.Limit))
+
+	if rp.ColdStart > rp.Rate.Remaining {
+		fmt.Printf("Warning: a cold start needs ~%d requests, but only %d remain until %s\n",
+			rp.ColdStart, rp.Rate.Remaining, rp.Rate.Reset.Format(time.RFC3339))
+	}
+
+	switch {
+	case rp.PerHour <= rp.Rate.Limit:
+		return
+	case rp.MinRefresh == 0:
+		fmt.Println("Warning: updated items alone exceed the rate limit; reduce the repositories or closed lookback")
+	default:
+		fmt.Printf("Warning: exceeds the rate limit; refresh no more often than every %s\n", rp.MinRefresh)
+	}
+}
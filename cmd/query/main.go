@@ -0,0 +1,174 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// query evaluates an ad-hoc rule once, and prints its matches
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/export"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+
+	"k8s.io/klog/v2"
+)
+
+// textFormat prints one line per match
+const textFormat = "text"
+
+// stringList is a flag which may be repeated
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	configPaths     triage.ConfigPaths
+	persistBackend  = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
+	persistPath     = flag.String("persist-path", "", "Where to persist cache to (automatic)")
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+
+	// query specific
+	repos    stringList
+	filters  stringList
+	itemType = flag.String("type", "", "only match this type: issue, pull_request or alert")
+	maxAge   = flag.Duration("max-age", time.Hour, "use cached data which is newer than this, rather than fetching it")
+	format   = flag.String("format", textFormat, "output format: "+textFormat+", "+strings.Join(export.Formats, ", "))
+)
+
+func init() {
+	flag.Var(&configPaths, "config", "optional configuration file or directory, for settings, member groups and filter macros")
+	flag.Var(&repos, "repo", "repository to query, as org/name or a URL, which may be repeated. Defaults to the configured repos")
+	flag.Var(&filters, "filter", "filter as written in a rule, such as 'label: bug' or 'updated: +30d', which may be repeated")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(filters) == 0 {
+		klog.Exitf("--filter is required")
+	}
+
+	if *itemType != "" && *itemType != hubbub.Issue && *itemType != hubbub.PullRequest && *itemType != hubbub.Alert {
+		klog.Exitf("--type must be %s, %s or %s", hubbub.Issue, hubbub.PullRequest, hubbub.Alert)
+	}
+
+	if *format != textFormat {
+		if err := export.CheckFormat(*format); err != nil {
+			klog.Exitf("--format: %v", err)
+		}
+	}
+
+	q := triage.Query{Type: *itemType}
+	for _, r := range repos {
+		if !strings.Contains(r, "://") {
+			r = "https://github.com/" + r
+		}
+		q.Repos = append(q.Repos, r)
+	}
+
+	for _, s := range filters {
+		f, err := triage.ParseFilter(s)
+		if err != nil {
+			klog.Exitf("--filter: %v", err)
+		}
+		q.Filters = append(q.Filters, f)
+	}
+
+	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	if err := c.Initialize(); err != nil {
+		klog.Exitf("persist initialize from %s: %v", c, err)
+	}
+
+	tp, err := triage.New(triage.Config{
+		Cache:        c,
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+	})
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	if err := tp.LoadQuery(configPaths, q); err != nil {
+		klog.Exitf("load: %v", err)
+	}
+
+	s, err := tp.LookupCollection(triage.QueryID)
+	if err != nil {
+		klog.Exitf("collection: %v", err)
+	}
+
+	r, err := tp.ExecuteCollection(context.Background(), s, time.Now().Add(-*maxAge))
+	if err != nil {
+		klog.Exitf("execute: %v", err)
+	}
+
+	if *format != textFormat {
+		if err := export.Write(os.Stdout, *format, r, time.Now()); err != nil {
+			klog.Exitf("write %s: %v", *format, err)
+		}
+		return
+	}
+
+	printText(r)
+}
+
+// printText prints a line per match, and how many there were
+func printText(r *triage.CollectionResult) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	n := 0
+	for _, rr := range r.RuleResults {
+		for _, co := range rr.Items {
+			kind := "issue"
+			if co.Type == hubbub.PullRequest {
+				kind = "pr"
+			}
+			fmt.Fprintf(tw, "%s/%s#%d\t%s\t%s\t%s\t%s\n", co.Organization, co.Project, co.ID, kind, toDays(time.Since(co.Created)), co.Title, co.URL)
+			n++
+		}
+	}
+	tw.Flush()
+	fmt.Printf("%d matches\n", n)
+}
+
+func toDays(d time.Duration) string {
+	return fmt.Sprintf("%0.1fd", d.Hours()/24)
+}
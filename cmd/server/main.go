@@ -29,6 +29,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -37,13 +38,27 @@ import (
 	"time"
 
 	"github.com/google/slowjam/pkg/stacklog"
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/action"
+	"github.com/google/triage-party/pkg/api"
+	"github.com/google/triage-party/pkg/apitoken"
+	"github.com/google/triage-party/pkg/audit"
+	"github.com/google/triage-party/pkg/cluster"
 	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/export"
+	"github.com/google/triage-party/pkg/federation"
+	"github.com/google/triage-party/pkg/history"
+	"github.com/google/triage-party/pkg/jira"
+	"github.com/google/triage-party/pkg/live"
 	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/report"
 
+	"google.golang.org/grpc"
 	"k8s.io/klog/v2"
 
 	"github.com/google/triage-party/pkg/persist"
 	"github.com/google/triage-party/pkg/site"
+	"github.com/google/triage-party/pkg/sso"
 	"github.com/google/triage-party/pkg/triage"
 	"github.com/google/triage-party/pkg/updater"
 )
@@ -53,27 +68,86 @@ var (
 	gitHubAPIURL = flag.String("github-api-url", "", "GitHub API url to connect.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the URL does not have the suffix \"/api/v3/\", it will be added automatically.")
 
 	// shared with tester
-	configPath     = flag.String("config", "", "configuration path (defaults to searching for config.yaml)")
+	configPaths    triage.ConfigPaths
 	persistBackend = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
 	persistPath    = flag.String("persist-path", "", "Where to persist cache to (automatic)")
 
+	clustered       = flag.Bool("cluster", false, "divide fetching between replicas sharing a mysql, postgres or cloudsql persist backend, making changes from a single leader")
+	clusterID       = flag.String("cluster-id", "", "unique ID of this replica within the cluster (defaults to hostname and process ID)")
+	clusterInterval = flag.Duration("cluster-heartbeat", 15*time.Second, "how often replicas announce themselves: a replica which misses 3 is considered gone")
+
 	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
-	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
-	gitLabTokenFile = flag.String("gitlab-token-file", "", "github token secret file, also settable via "+constants.GitLabTokenEnvVar)
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file or secret manager reference (vault://, gcpsm://, awssm://), also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file or secret manager reference (vault://, gcpsm://, awssm://), also settable via "+constants.GitLabTokenEnvVar)
+	bitbucketURL    = flag.String("bitbucket-server-url", "", "URL of an on-premise Bitbucket Server or Data Center to read pull requests from, such as https://bitbucket.example.com")
+	bitbucketToken  = flag.String("bitbucket-server-token-file", "", "bitbucket server HTTP access token secret file or secret manager reference, also settable via "+constants.BitbucketServerTokenEnvVar)
+	sourceHutToken  = flag.String("sourcehut-token-file", "", "todo.sr.ht personal access token secret file or secret manager reference, also settable via "+constants.SourceHutTokenEnvVar)
+	launchpadToken  = flag.String("launchpad-token-file", "", "launchpad OAuth token secret file (consumer-key:token:token-secret), also settable via "+constants.LaunchpadTokenEnvVar+". Public bugs are read anonymously without one")
+	secretInterval  = flag.Duration("secret-refresh-interval", 5*time.Minute, "how often to re-read token files and secret manager references, to pick up rotated tokens (0 to disable)")
+
+	// actions
+	postComments      = flag.Bool("post-comments", false, "post the comment of matching rules to issues and PRs")
+	autoAssign        = flag.Bool("auto-assign", false, "assign unassigned items on every refresh, for rules with an auto-assign policy")
+	closeStale        = flag.Bool("close-stale", false, "apply the stale policies of rules: warn about, and then close inactive items")
+	escalate          = flag.Bool("escalate", false, "notify people about items which remain in rules with an escalation chain")
+	readOnly          = flag.Bool("read-only", false, "disable all write actions and administrative pages, for instances exposed publicly")
+	siteActions       = flag.Bool("site-actions", false, "let authenticated users change items from collection pages and the API, such as editing labels. Requires --oidc-issuer, --user-header or API tokens to identify users")
+	userActionLimit   = flag.Int("action-user-limit", 100, "maximum number of items each user may change per minute through the site (0 for unlimited)")
+	globalActionLimit = flag.Int("action-global-limit", 500, "maximum number of items all users may change per minute through the site (0 for unlimited)")
+	confirmAbove      = flag.Int("action-confirm-above", 20, "bulk actions on more items than this must be confirmed by typing the number of items (0 to disable)")
+	jiraUser          = flag.String("jira-user", "", "Jira user to create issues as, also settable via "+constants.JiraUserEnvVar)
+	jiraTokenFile     = flag.String("jira-token-file", "", "Jira API token secret file, also settable via "+constants.JiraTokenEnvVar)
 
 	// server specific
 	siteDir       = flag.String("site", "site/", "path to site files")
 	thirdPartyDir = flag.String("3p", "third_party/", "path to 3rd party files")
 	dryRun        = flag.Bool("dry-run", false, "run queries, don't start a server")
 	port          = flag.Int("port", 8080, "port to run server at")
+	grpcPort      = flag.Int("grpc-port", 0, "port to serve the gRPC API at (0 to disable)")
 	siteName      = flag.String("name", "", "override site name from config file")
 	numbers       = flag.String("nums", "", "only display results for these comma-delimited issue/PR numbers (debug)")
+	userHeader    = flag.String("user-header", "", "request header set by an authenticating proxy to the user's login, such as X-Forwarded-User. Required for access policies.")
+
+	// single sign-on
+	oidcIssuer           = flag.String("oidc-issuer", "", "OpenID Connect issuer URL to require logins from, such as https://accounts.google.com")
+	oidcClientID         = flag.String("oidc-client-id", "", "OpenID Connect client ID")
+	oidcClientSecretFile = flag.String("oidc-client-secret-file", "", "OpenID Connect client secret file, also settable via "+constants.OIDCClientSecretEnvVar)
+	oidcRedirectURL      = flag.String("oidc-redirect-url", "", "externally visible URL of /auth/callback, such as https://triage.example.com/auth/callback")
+	oidcUserClaim        = flag.String("oidc-user-claim", "", "ID token claim to use as the user's login (defaults to preferred_username, then email)")
+	oidcGroupsClaim      = flag.String("oidc-groups-claim", "groups", "ID token claim listing the user's groups")
+	oidcScopes           = flag.String("oidc-scopes", "", "additional scopes to request, comma separated, such as groups")
+	sessionKeyFile       = flag.String("session-key-file", "", "secret file used to sign login sessions, also settable via "+constants.SessionKeyEnvVar)
+
+	tenants = flag.String("tenants", "", "serve multiple configurations, as comma-separated name=path pairs. Each is served under /name/, with its own cache namespace.")
+
+	exportTo       = flag.String("export-to", "", "export evaluated conversations as JSONL to this directory or http(s) URL")
+	exportInterval = flag.Duration("export-interval", 60*time.Minute, "Minimum time between exports of a collection")
+
+	historyGranularity = flag.Duration("history-granularity", 60*time.Minute, "Minimum time between snapshots of rule results (0 to snapshot every refresh)")
+	historyRetention   = flag.Duration("history-retention", 90*24*time.Hour, "How long to keep snapshots of rule results")
+
+	federationInterval = flag.Duration("federation-interval", 5*time.Minute, "How often to poll federated instances for their summaries")
+
+	liveUpdates = flag.Bool("live-updates", true, "push rule count and row changes to open collection pages over server-sent events")
+
+	smtpAddr         = flag.String("smtp-addr", "", "SMTP server to mail reports through, as host:port")
+	smtpFrom         = flag.String("smtp-from", "", "address to mail reports from")
+	smtpUser         = flag.String("smtp-user", "", "user to authenticate to the SMTP server as")
+	smtpPasswordFile = flag.String("smtp-password-file", "", "SMTP password secret file, also settable via "+constants.SMTPPasswordEnvVar)
+
+	reloadInterval = flag.Duration("reload-interval", 15*time.Second, "how often to check the configuration for changes, reloading it if needed (0 to disable)")
 
 	maxRefresh = flag.Duration("max-refresh", 60*time.Minute, "Maximum time between collection runs")
 	minRefresh = flag.Duration("min-refresh", 60*time.Second, "Minimum time between collection runs")
 	warnAge    = flag.Duration("warn-age", 90*time.Minute, "Warn when the results are older than this")
+	readyAge   = flag.Duration("ready-max-age", 6*time.Hour, "Fail /readyz when the oldest results are older than this (0 to ignore)")
+	tokenWarn  = flag.Duration("token-expiry-warning", 7*24*time.Hour, "Warn on every page when a provider token expires within this long")
 )
 
+func init() {
+	flag.Var(&configPaths, "config", "configuration file or directory, which may be repeated to merge several, with later ones taking precedence (defaults to searching for config.yaml)")
+}
+
 func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
@@ -81,22 +155,21 @@ func main() {
 	st := stacklog.MustStartFromEnv("STACKLOG_PATH")
 	defer st.Stop()
 
-	cp := *configPath
-	if cp == "" {
-		cp = os.Getenv("CONFIG_PATH")
+	cps := configPaths
+	if len(cps) == 0 {
+		if err := cps.Set(os.Getenv("CONFIG_PATH")); err != nil {
+			klog.Exitf("CONFIG_PATH: %v", err)
+		}
 	}
-	if cp == "" {
-		cp = findPath("config/config.yaml")
-		klog.Warningf("--config and CONFIG_PATH were empty, falling back to %s", cp)
+	// Explicit configuration sources are defaults for every tenant
+	base := cps
+	if len(cps) == 0 {
+		cps = triage.ConfigPaths{findPath("config/config.yaml")}
+		klog.Warningf("--config and CONFIG_PATH were empty, falling back to %s", cps)
 	}
 
 	ctx := context.Background()
 
-	f, err := os.Open(findPath(cp))
-	if err != nil {
-		klog.Exitf("open %s: %v", cp, err)
-	}
-
 	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
 	if err != nil {
 		klog.Exitf("unable to create persistence layer: %v", err)
@@ -106,6 +179,20 @@ func main() {
 		klog.Exitf("persist initialize for %s: %v", c, err)
 	}
 
+	var cl *cluster.Cluster
+	if *clustered && !*dryRun {
+		switch c.(type) {
+		case *persist.Disk, *persist.Memory:
+			klog.Exitf("--cluster requires a persist backend shared by every replica, not %s", c)
+		}
+		cl = cluster.New(cluster.Config{Cache: c, ID: *clusterID, Interval: *clusterInterval})
+		if err := cl.Heartbeat(); err != nil {
+			klog.Exitf("cluster heartbeat: %v", err)
+		}
+		klog.Infof("joined cluster as %s, with members: %v", cl.ID(), cl.Members())
+		go cl.Run(ctx)
+	}
+
 	var debugNums []int
 	for _, n := range strings.Split(*numbers, ",") {
 		i, err := strconv.Atoi(n)
@@ -118,22 +205,165 @@ func main() {
 		Cache:        c,
 		DebugNumbers: debugNums,
 		GitHubAPIURL: *gitHubAPIURL,
-		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
-		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+	}
+	if cl != nil {
+		cfg.Owns = cl.Owns
+		// Every collection is refreshed at least this often, so the owner of a repository will have stored newer data
+		cfg.ShardMaxAge = *maxRefresh
+	}
+
+	if gh := provider.WatchToken(ctx, *gitHubTokenFile, constants.GitHubTokenEnvVar, *secretInterval); gh.Value() != "" {
+		cfg.GitHubTokenSource = gh
+		if provider.GitHubTokenType(gh.Value()) == provider.FineGrainedToken {
+			klog.Infof("using a fine-grained GitHub token: it must be granted read access to the issues, pull requests and metadata of every configured repository")
+		}
+	}
+	if gl := provider.WatchToken(ctx, *gitLabTokenFile, constants.GitLabTokenEnvVar, *secretInterval); gl.Value() != "" {
+		cfg.GitLabTokenSource = gl
+	}
+	if sh := provider.WatchToken(ctx, *sourceHutToken, constants.SourceHutTokenEnvVar, *secretInterval); sh.Value() != "" {
+		cfg.SourceHutTokenSource = sh
+	}
+	if lp := provider.ReadToken(*launchpadToken, constants.LaunchpadTokenEnvVar); lp != "" {
+		cfg.LaunchpadToken = lp
+	}
+	if *bitbucketURL != "" {
+		cfg.BitbucketServerURL = *bitbucketURL
+		if bb := provider.WatchToken(ctx, *bitbucketToken, constants.BitbucketServerTokenEnvVar, *secretInterval); bb.Value() != "" {
+			cfg.BitbucketServerTokenSource = bb
+		}
 	}
 
 	if *reposOverride != "" {
 		cfg.Repos = strings.Split(*reposOverride, ",")
 	}
 
+	ts := []tenant{{ConfigPaths: cps}}
+	if *tenants != "" {
+		ts, err = parseTenants(*tenants, base)
+		if err != nil {
+			klog.Exitf("tenants: %v", err)
+		}
+	}
+
+	// Token stores are created before the authenticator, which lets requests bearing a valid token skip the login
+	tokens := map[string]*apitoken.Store{}
+	for _, t := range ts {
+		tokens[t.Name] = apitoken.New(apitoken.Config{Cache: tenantCache(c, t.Name)})
+	}
+
+	var auth *sso.Authenticator
+	if *oidcIssuer != "" {
+		auth = newAuthenticator(ctx, ts, tokens)
+	}
+
+	var first *site.Handlers
+	sites := []*site.Handlers{}
+	for _, t := range ts {
+		tcfg := cfg
+		tcfg.Cache = tenantCache(c, t.Name)
+		prefix := ""
+		if t.Name != "" {
+			prefix = "/" + t.Name
+		}
+
+		s, u := newSite(ctx, tcfg, t.ConfigPaths, prefix, auth, tokens[t.Name], cl)
+		if first == nil {
+			first = s
+		}
+		sites = append(sites, s)
+
+		if *dryRun {
+			klog.Infof("Updating %s ...", t.ConfigPaths)
+			if _, err := u.RunOnce(ctx, true); err != nil {
+				klog.Exitf("run failed: %v", err)
+			}
+			continue
+		}
+
+		klog.Infof("Starting update loop for %q: %+v", prefix, u)
+		go func() {
+			if err := u.Loop(ctx); err == nil {
+				klog.Exitf("loop failed: %v", err)
+			}
+		}()
+
+		mux := http.NewServeMux()
+		registerRoutes(mux, s)
+		if prefix == "" {
+			http.Handle("/", mux)
+		} else {
+			http.Handle(prefix+"/", http.StripPrefix(prefix, mux))
+		}
+	}
+
+	if *dryRun {
+		os.Exit(0)
+	}
+
+	http.Handle("/third_party/", http.StripPrefix("/third_party/", http.FileServer(http.Dir(findPath(*thirdPartyDir)))))
+	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(filepath.Join(findPath(*siteDir), "static")))))
+
+	if len(ts) > 1 || ts[0].Name != "" {
+		http.HandleFunc("/healthz", first.Healthz())
+		http.HandleFunc("/threadz", first.Threadz())
+		http.HandleFunc("/readyz", site.ReadyzAll(sites))
+		http.HandleFunc("/health", first.Healthz())
+		http.HandleFunc("/threads", first.Threadz())
+		http.HandleFunc("/", tenantIndex(ts))
+	}
+
+	var handler http.Handler = http.DefaultServeMux
+	if auth != nil {
+		http.HandleFunc(sso.LoginPath, auth.Login())
+		http.HandleFunc(sso.CallbackPath, auth.Callback())
+		http.HandleFunc(sso.LogoutPath, auth.Logout())
+		handler = auth.Require(handler)
+	}
+
+	if *grpcPort > 0 {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *grpcPort))
+		if err != nil {
+			klog.Exitf("grpc listen: %v", err)
+		}
+
+		gs := grpc.NewServer()
+		api.RegisterTriagePartyServer(gs, site.NewGRPCServer(sites))
+		klog.Infof("Serving gRPC API at %s", lis.Addr())
+		go func() {
+			if err := gs.Serve(lis); err != nil {
+				klog.Exitf("grpc serve: %v", err)
+			}
+		}()
+	}
+
+	listenAddr := fmt.Sprintf(":%s", os.Getenv("PORT"))
+	if listenAddr == ":" {
+		listenAddr = fmt.Sprintf(":%d", *port)
+	}
+
+	fmt.Printf("\n\n*** teaparty is listening at %s ... ***\n\n", listenAddr)
+	err = http.ListenAndServe(listenAddr, handler)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// newSite loads a configuration, and returns the site and updater serving it
+func newSite(ctx context.Context, cfg triage.Config, cps []string, prefix string, auth *sso.Authenticator, tokens *apitoken.Store, cl *cluster.Cluster) (*site.Handlers, *updater.Updater) {
 	klog.Infof("triage runtime config: %+v", cfg)
 	tp, err := triage.New(cfg)
 	if err != nil {
 		klog.Exitf("new config: %v", err)
 	}
 
-	if err := tp.Load(f); err != nil {
-		klog.Exitf("load from %s: %v", cp, err)
+	paths := []string{}
+	for _, cp := range cps {
+		paths = append(paths, findPath(cp))
+	}
+
+	if err := tp.LoadFiles(paths); err != nil {
+		klog.Exitf("load from %s: %v", paths, err)
 	}
 
 	ts, err := tp.ListRules()
@@ -141,7 +371,7 @@ func main() {
 		klog.Exitf("list rules: %v", err)
 	}
 
-	klog.Infof("Loaded %d rules", len(ts))
+	klog.Infof("Loaded %d rules from %s", len(ts), paths)
 
 	// Establish site name based on the first available
 	// of: CLI parameter, settings file, or default from repo names.
@@ -152,59 +382,205 @@ func main() {
 		}
 	}
 
+	c := cfg.Cache
+	al := audit.New(audit.Config{Cache: c, OnDuty: tp.OnDutyMember})
+	acfg := action.Config{
+		Party:         tp,
+		Cache:         c,
+		Audit:         al,
+		PostComments:  *postComments && !*dryRun && !*readOnly,
+		StalePolicies: *closeStale && !*dryRun && !*readOnly,
+		AutoAssign:    *autoAssign && !*dryRun && !*readOnly,
+		Escalate:      *escalate && !*dryRun && !*readOnly,
+	}
+	if js := tp.Settings().Jira; js.URL != "" {
+		ju := *jiraUser
+		if ju == "" {
+			ju = os.Getenv(constants.JiraUserEnvVar)
+		}
+		acfg.Jira = jira.New(jira.Config{
+			URL:   js.URL,
+			User:  ju,
+			Token: provider.ReadToken(*jiraTokenFile, constants.JiraTokenEnvVar),
+		})
+	}
+
+	a := action.New(acfg)
+	h := history.New(history.Config{Cache: c, Granularity: *historyGranularity, Retention: *historyRetention})
+	hooks := []updater.Hook{
+		leaderOnly(cl, a.PostRuleComments),
+		leaderOnly(cl, a.ApplyStalePolicies),
+		leaderOnly(cl, a.ApplyAutoAssign),
+		leaderOnly(cl, a.ApplyEscalations),
+		leaderOnly(cl, h.Record),
+	}
+
+	if *exportTo != "" {
+		sink, err := export.NewSink(*exportTo)
+		if err != nil {
+			klog.Exitf("export sink: %v", err)
+		}
+		e := export.New(export.Config{Sink: sink, Interval: *exportInterval})
+		hooks = append(hooks, leaderOnly(cl, e.Export))
+	}
+
+	var lh *live.Hub
+	if *liveUpdates {
+		lh = live.New()
+		hooks = append(hooks, lh.Publish)
+	}
+
 	u := updater.New(updater.Config{
 		Party:      tp,
 		MinRefresh: *minRefresh,
 		MaxRefresh: *maxRefresh,
+		Hooks:      hooks,
 	})
 
-	if *dryRun {
-		klog.Infof("Updating ...")
-		if _, err := u.RunOnce(ctx, true); err != nil {
-			klog.Exitf("run failed: %v", err)
-		}
-		os.Exit(0)
+	if err := report.Check(tp); err != nil {
+		klog.Exitf("reports: %v", err)
 	}
+	var mailer *report.Mailer
+	if *smtpAddr != "" {
+		mailer = report.NewMailer(report.MailConfig{
+			Addr:     *smtpAddr,
+			From:     *smtpFrom,
+			User:     *smtpUser,
+			Password: provider.ReadToken(*smtpPasswordFile, constants.SMTPPasswordEnvVar),
+		})
+	}
+	rcfg := report.Config{Party: tp, Updater: u, History: h, Audit: al, Mailer: mailer}
+	if cl != nil {
+		rcfg.Leader = cl.Leader
+	}
+	go report.New(rcfg).Run(ctx)
 
-	klog.Infof("Starting update loop: %+v", u)
+	fed := federation.New(federation.Config{Party: tp, Interval: *federationInterval})
+	go fed.Run(ctx)
 
-	go func() {
-		if err := u.Loop(ctx); err == nil {
-			klog.Exitf("loop failed: %v", err)
-		}
-	}()
+	if *reloadInterval > 0 && !*dryRun {
+		go tp.Watch(ctx, *reloadInterval, func() { u.Reevaluate(ctx) })
+	}
+
+	if *userHeader == "" && auth == nil && hasAccessPolicies(tp) {
+		klog.Warningf("access policies are configured, but neither --user-header nor --oidc-issuer are set: restricted collections will be hidden from everyone")
+	}
+	if *siteActions && *userHeader == "" && auth == nil {
+		klog.Warningf("--site-actions is set, but neither --user-header nor --oidc-issuer are set: only API tokens may make changes")
+	}
 
 	s := site.New(&site.Config{
 		BaseDirectory: findPath(*siteDir),
 		Updater:       u,
 		Party:         tp,
-		WarnAge:       *warnAge,
-		Name:          sn,
+		Actions:       a,
+		Audit:         al,
+		Access:        access.New(access.Config{Party: tp}),
+		Tokens:        tokens,
+		History:       h,
+		Federation:    fed,
+		SSO:           auth,
+		Live:          lh,
+		UserHeader:    *userHeader,
+		Prefix:        prefix,
+		ReadOnly:      *readOnly,
+		AllowActions:  *siteActions && !*dryRun,
+		RateLimits: site.RateLimits{
+			PerUser:      *userActionLimit,
+			Global:       *globalActionLimit,
+			ConfirmAbove: *confirmAbove,
+		},
+		WarnAge:            *warnAge,
+		Name:               sn,
+		Cache:              c,
+		ReadyMaxAge:        *readyAge,
+		TokenExpiryWarning: *tokenWarn,
 	})
+	return s, u
+}
 
-	http.Handle("/third_party/", http.StripPrefix("/third_party/", http.FileServer(http.Dir(findPath(*thirdPartyDir)))))
-	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(filepath.Join(findPath(*siteDir), "static")))))
-	http.HandleFunc("/s/", s.Collection())
-	http.HandleFunc("/k/", s.Kanban())
-	http.HandleFunc("/healthz", s.Healthz())
-	http.HandleFunc("/threadz", s.Threadz())
-
-	// In case the previous handlers are removed by errant security systems
-	http.HandleFunc("/health", s.Healthz())
-	http.HandleFunc("/threads", s.Threadz())
-
-	http.HandleFunc("/", s.Root())
+// leaderOnly runs a hook on the leader alone, when replicas share a cache, so that its changes are not repeated by each
+func leaderOnly(cl *cluster.Cluster, h updater.Hook) updater.Hook {
+	if cl == nil {
+		return h
+	}
+	return func(ctx context.Context, r *triage.CollectionResult) {
+		if cl.Leader() {
+			h(ctx, r)
+		}
+	}
+}
 
-	listenAddr := fmt.Sprintf(":%s", os.Getenv("PORT"))
-	if listenAddr == ":" {
-		listenAddr = fmt.Sprintf(":%d", *port)
+// newAuthenticator returns an OIDC authenticator configured by flags
+func newAuthenticator(ctx context.Context, ts []tenant, tokens map[string]*apitoken.Store) *sso.Authenticator {
+	cfg := sso.Config{
+		Issuer:       *oidcIssuer,
+		ClientID:     *oidcClientID,
+		ClientSecret: provider.ReadToken(*oidcClientSecretFile, constants.OIDCClientSecretEnvVar),
+		RedirectURL:  *oidcRedirectURL,
+		UserClaim:    *oidcUserClaim,
+		GroupsClaim:  *oidcGroupsClaim,
+		VerifyToken:  tokenVerifier(tokens),
+	}
+	for _, t := range ts {
+		if t.Name != "" {
+			cfg.Prefixes = append(cfg.Prefixes, "/"+t.Name)
+		}
+	}
+	if *oidcScopes != "" {
+		cfg.Scopes = strings.Split(*oidcScopes, ",")
+	}
+	if *sessionKeyFile != "" || os.Getenv(constants.SessionKeyEnvVar) != "" {
+		cfg.SessionKey = []byte(provider.ReadToken(*sessionKeyFile, constants.SessionKeyEnvVar))
 	}
 
-	fmt.Printf("\n\n*** teaparty is listening at %s ... ***\n\n", listenAddr)
-	err = http.ListenAndServe(listenAddr, nil)
+	a, err := sso.New(ctx, cfg)
 	if err != nil {
-		panic(err)
+		klog.Exitf("oidc: %v", err)
 	}
+	return a
+}
+
+// registerRoutes registers the handlers of a site
+func registerRoutes(mux *http.ServeMux, s *site.Handlers) {
+	mux.HandleFunc("/s/", s.Collection())
+	mux.HandleFunc("/k/", s.Kanban())
+	mux.HandleFunc("/action/jira", s.CreateJiraIssue())
+	mux.HandleFunc("/action/labels", s.EditLabels())
+	mux.HandleFunc("/action/assign", s.AutoAssign())
+	mux.HandleFunc("/action/respond", s.PostResponse())
+	mux.HandleFunc("/action/milestone", s.SetMilestone())
+	mux.HandleFunc("/action/triage-state", s.SetTriageState())
+	mux.HandleFunc("/action/review", s.RequestReviewers())
+	mux.HandleFunc("/action/lock", s.Lock())
+	mux.HandleFunc("/action/duplicate", s.CloseAsDuplicate())
+	mux.HandleFunc("/action/not-similar", s.MarkNotSimilar())
+	mux.HandleFunc("/admin/audit", s.Audit())
+	mux.HandleFunc("/admin/tokens", s.Tokens())
+	mux.HandleFunc("/admin/ratelimits", s.RateLimits())
+	mux.HandleFunc("/public", s.Public())
+	mux.HandleFunc("/grafana/", s.Grafana())
+	mux.HandleFunc("/stats", s.Stats())
+	mux.HandleFunc("/graphql", s.GraphQL())
+	mux.HandleFunc("/diff/", s.Diff())
+	mux.HandleFunc("/live/", s.Live())
+	mux.HandleFunc("/duplicates/", s.Duplicates())
+	mux.HandleFunc("/release/", s.Release())
+	mux.HandleFunc("/reviewers/", s.Reviewers())
+	mux.HandleFunc("/similar/", s.Similar())
+	mux.HandleFunc("/metrics", s.Metrics())
+	mux.HandleFunc(federation.SummaryPath, s.Summary())
+	mux.HandleFunc("/rollup", s.Rollup())
+	mux.HandleFunc("/healthz", s.Healthz())
+	mux.HandleFunc("/healthz/token", s.TokenHealthz())
+	mux.HandleFunc("/threadz", s.Threadz())
+	mux.HandleFunc("/readyz", s.Readyz())
+
+	// In case the previous handlers are removed by errant security systems
+	mux.HandleFunc("/health", s.Healthz())
+	mux.HandleFunc("/threads", s.Threadz())
+
+	mux.HandleFunc("/", s.Root())
 }
 
 // calculates a user-friendly site name based on repositories
@@ -251,3 +627,21 @@ func findPath(p string) string {
 
 	return p
 }
+
+// hasAccessPolicies returns whether any access policy is configured
+func hasAccessPolicies(tp *triage.Party) bool {
+	if tp.Settings().Access != nil {
+		return true
+	}
+
+	cols, err := tp.ListCollections()
+	if err != nil {
+		return false
+	}
+	for _, c := range cols {
+		if c.Access != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/triage-party/pkg/apitoken"
+	"github.com/google/triage-party/pkg/persist"
+	"k8s.io/klog/v2"
+)
+
+// tenantNameRe matches valid tenant names, which are used in URLs and cache keys. Cache namespaces end with "-",
+// so names may not contain it: otherwise the namespace of "a" would include that of "a-b".
+var tenantNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+
+// tenant is a configuration served under its own path prefix
+type tenant struct {
+	Name        string
+	ConfigPaths []string
+}
+
+// parseTenants parses a comma-separated list of name=path pairs, whose configurations are merged over any base paths
+func parseTenants(s string, base []string) ([]tenant, error) {
+	ts := []tenant{}
+	seen := map[string]bool{}
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("%q is not in the form of name=path", kv)
+		}
+
+		name := parts[0]
+		if !tenantNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid tenant name %q: must match %s", name, tenantNameRe)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate tenant name %q", name)
+		}
+		seen[name] = true
+		paths := append(append([]string{}, base...), parts[1])
+		ts = append(ts, tenant{Name: name, ConfigPaths: paths})
+	}
+	return ts, nil
+}
+
+var tenantIndexTmpl = template.Must(template.New("tenants").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Triage Party</title><link rel="stylesheet" href="/third_party/bulma/bulma.min.css"></head>
+<body><section class="section"><ul>
+{{ range . }}<li><a href="/{{ .Name }}/">{{ .Name }}</a></li>
+{{ end }}</ul></section></body>
+</html>`))
+
+// tenantCache returns the cache of a tenant, which is namespaced unless it is served at the root
+func tenantCache(c persist.Cacher, name string) persist.Cacher {
+	if name == "" {
+		return c
+	}
+	return persist.NewNamespace(c, name)
+}
+
+// tokenVerifier returns whether a request bears an API token issued by the tenant it is addressed to
+func tokenVerifier(stores map[string]*apitoken.Store) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		name := ""
+		if _, ok := stores[""]; !ok {
+			name = strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		}
+
+		s := stores[name]
+		if s == nil {
+			return false
+		}
+		return s.Verify(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) != nil
+	}
+}
+
+// tenantIndex lists the tenants being served
+func tenantIndex(ts []tenant) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := tenantIndexTmpl.Execute(w, ts); err != nil {
+			klog.Errorf("tmpl: %v", err)
+		}
+	}
+}
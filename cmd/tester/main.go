@@ -19,7 +19,6 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -37,7 +36,7 @@ var (
 	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
 
 	// shared with server
-	configPath      = flag.String("config", "", "configuration path")
+	configPaths     triage.ConfigPaths
 	persistBackend  = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
 	persistPath     = flag.String("persist-path", "", "Where to persist cache to (automatic)")
 	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
@@ -48,13 +47,19 @@ var (
 	// tester specific
 	collection = flag.String("collection", "", "collection")
 	rule       = flag.String("rule", "", "rule")
+	record     = flag.String("record", "", "record provider responses to this directory, for later use with --replay")
+	replay     = flag.String("replay", "", "serve provider responses recorded by --record from this directory, without network access")
 )
 
+func init() {
+	flag.Var(&configPaths, "config", "configuration file or directory, which may be repeated to merge several")
+}
+
 func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
 
-	if *configPath == "" {
+	if len(configPaths) == 0 {
 		klog.Exitf("--config is required")
 	}
 
@@ -62,13 +67,17 @@ func main() {
 		klog.Exitf("--collection or --rule is required")
 	}
 
-	ctx := context.Background()
+	if *record != "" && *replay != "" {
+		klog.Exitf("--record and --replay are mutually exclusive")
+	}
 
-	f, err := os.Open(*configPath)
-	if err != nil {
-		klog.Exitf("open %s: %v", *configPath, err)
+	// A persistent cache would hide requests from the recorder, and mix live data into replays
+	if (*record != "" || *replay != "") && *persistBackend == "" {
+		*persistBackend = "memory"
 	}
 
+	ctx := context.Background()
+
 	c, err := persist.FromEnv("triage-party", *persistBackend, *persistPath)
 	if err != nil {
 		klog.Exitf("unable to create persistence layer: %v", err)
@@ -92,6 +101,8 @@ func main() {
 		GitHubAPIURL: *gitHubAPIURL,
 		GitHubToken:  provider.ReadToken(*gitHubTokenFile, "GITHUB_TOKEN"),
 		GitLabToken:  provider.ReadToken(*gitLabTokenFile, "GITLAB_TOKEN"),
+		Record:       *record,
+		Replay:       *replay,
 	}
 
 	if *reposOverride != "" {
@@ -104,8 +115,8 @@ func main() {
 		klog.Exitf("new: %v", err)
 	}
 
-	if err := tp.Load(f); err != nil {
-		klog.Exitf("load %s: %v", *configPath, err)
+	if err := tp.LoadFiles(configPaths); err != nil {
+		klog.Exitf("load %s: %v", configPaths.String(), err)
 	}
 
 	if *collection != "" {
@@ -0,0 +1,192 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Validates a Triage Party configuration, without starting a server
+//
+// ** Basic example:
+//
+// go run main.go --github-token-file ~/.token --config ../../config/config.yaml
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/report"
+	"github.com/google/triage-party/pkg/triage"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// custom GitHub API URLs
+	gitHubAPIURL = flag.String("github-api-url", "", "base URL for GitHub API.  Please set this when you use GitHub Enterprise. This often is your GitHub Enterprise hostname. If the base URL does not have the suffix \"/api/v3/\", it will be added automatically.")
+
+	// shared with server
+	configPaths     triage.ConfigPaths
+	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
+	gitHubTokenFile = flag.String("github-token-file", "", "github token secret file, also settable via "+constants.GitHubTokenEnvVar)
+	gitLabTokenFile = flag.String("gitlab-token-file", "", "gitlab token secret file, also settable via "+constants.GitLabTokenEnvVar)
+
+	// validate specific
+	offline = flag.Bool("offline", false, "skip checking that repositories can be read with the configured tokens")
+	strict  = flag.Bool("strict", false, "fail if there are any warnings, such as unused or shadowed rules")
+)
+
+func init() {
+	flag.Var(&configPaths, "config", "configuration file or directory, which may be repeated to merge several")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(configPaths) == 0 {
+		klog.Exitf("--config is required")
+	}
+
+	// Validation is a one-off: nothing needs to persist
+	c, err := persist.FromEnv("triage-party", "memory", "")
+	if err != nil {
+		klog.Exitf("unable to create persistence layer: %v", err)
+	}
+
+	cfg := triage.Config{
+		Cache:        c,
+		GitHubAPIURL: *gitHubAPIURL,
+		GitHubToken:  provider.ReadToken(*gitHubTokenFile, constants.GitHubTokenEnvVar),
+		GitLabToken:  provider.ReadToken(*gitLabTokenFile, constants.GitLabTokenEnvVar),
+	}
+
+	// Parsing requires a provider, even though nothing will be fetched
+	if *offline && cfg.GitHubToken == "" && cfg.GitLabToken == "" {
+		cfg.GitHubToken = "offline"
+	}
+
+	if *reposOverride != "" {
+		cfg.Repos = strings.Split(*reposOverride, ",")
+	}
+
+	tp, err := triage.New(cfg)
+	if err != nil {
+		klog.Exitf("new: %v", err)
+	}
+
+	if err := tp.LoadFiles(configPaths); err != nil {
+		fmt.Printf("FAIL: %s: %v\n", configPaths.String(), err)
+		os.Exit(1)
+	}
+
+	for _, w := range tp.Warnings() {
+		fmt.Printf("WARNING: %s\n", w)
+	}
+	if *strict && len(tp.Warnings()) > 0 {
+		fmt.Printf("FAIL: %d warnings in strict mode\n", len(tp.Warnings()))
+		os.Exit(1)
+	}
+
+	if err := report.Check(tp); err != nil {
+		fmt.Printf("FAIL: reports: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := printCollections(tp); err != nil {
+		fmt.Printf("FAIL: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !*offline {
+		if failed := checkRepos(context.Background(), tp); failed > 0 {
+			fmt.Printf("FAIL: %d repositories are unreachable\n", failed)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("OK: %s is valid\n", configPaths.String())
+}
+
+// printCollections shows what each collection would query
+func printCollections(tp *triage.Party) error {
+	cols, err := tp.ListCollections()
+	if err != nil {
+		return fmt.Errorf("list collections: %w", err)
+	}
+
+	for _, col := range cols {
+		fmt.Printf("collection %q (%s)\n", col.ID, col.Name)
+
+		for _, id := range col.RuleIDs {
+			r, err := tp.LookupRule(id)
+			if err != nil {
+				return fmt.Errorf("%s: %w", col.ID, err)
+			}
+
+			t := string(r.Type)
+			if t == "" {
+				t = "issues and PRs"
+			}
+			fmt.Printf("  rule %q (%s): %s in %s\n", id, r.Name, t, strings.Join(r.Repos, ", "))
+
+			fs, err := yaml.Marshal(r.Filters)
+			if err != nil {
+				return fmt.Errorf("marshal filters: %w", err)
+			}
+			for _, line := range strings.Split(strings.TrimSpace(string(fs)), "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// checkRepos checks that every repository used by a rule can be read, and returns the number that can not
+func checkRepos(ctx context.Context, tp *triage.Party) int {
+	rules, err := tp.ListRules()
+	if err != nil {
+		klog.Exitf("list rules: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, r := range rules {
+		for _, repo := range r.Repos {
+			seen[repo] = true
+		}
+	}
+
+	repos := []string{}
+	for r := range seen {
+		repos = append(repos, r)
+	}
+	sort.Strings(repos)
+
+	failed := 0
+	for _, r := range repos {
+		if err := tp.CheckRepo(ctx, r); err != nil {
+			fmt.Printf("unreachable: %s: %v\n", r, err)
+			failed++
+			continue
+		}
+		fmt.Printf("reachable: %s\n", r)
+	}
+	return failed
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package access decides who may see collections and act on their items
+package access
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// Level is a kind of access
+type Level int
+
+const (
+	// View allows seeing a collection
+	View Level = iota
+	// Act allows performing write actions on items within a collection
+	Act
+	// Admin allows access to administrative pages, such as the audit log
+	Admin
+)
+
+// defaultTTL is how long group memberships are cached by default
+const defaultTTL = 10 * time.Minute
+
+// Config is how to configure a new access checker
+type Config struct {
+	Party *triage.Party
+	// TTL is how long group memberships are cached for
+	TTL time.Duration
+}
+
+// Checker checks access policies
+type Checker struct {
+	party *triage.Party
+	ttl   time.Duration
+
+	mu          sync.Mutex
+	memberships map[string]membership
+}
+
+// membership is a cached group membership lookup
+type membership struct {
+	member  bool
+	expires time.Time
+}
+
+// New returns a new access checker
+func New(cfg Config) *Checker {
+	ttl := cfg.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	return &Checker{party: cfg.Party, ttl: ttl, memberships: map[string]membership{}}
+}
+
+// Identity is who is making a request
+type Identity struct {
+	// User is the login of the user, or empty if anonymous
+	User string
+	// IdPGroups are the groups asserted by an identity provider
+	IdPGroups []string
+}
+
+// Allowed returns whether an identity has a level of access to a collection.
+// An empty collection ID checks the site-wide policy. Only authenticated identities may act,
+// and administration is only allowed to those a policy names.
+func (c *Checker) Allowed(ctx context.Context, id Identity, collectionID string, l Level) bool {
+	if l >= Act && id.anonymous() {
+		return false
+	}
+
+	ap := c.policy(collectionID)
+	if ap == nil {
+		return l < Admin
+	}
+
+	ps := principals(ap, l)
+	if l == Admin && ps.Empty() {
+		return false
+	}
+	return c.includes(ctx, id, ps)
+}
+
+// anonymous returns whether an identity was not authenticated
+func (id Identity) anonymous() bool {
+	return id.User == "" && len(id.IdPGroups) == 0
+}
+
+// policy returns the access policy for a collection
+func (c *Checker) policy(id string) *triage.AccessPolicy {
+	if id != "" {
+		if col, err := c.party.LookupCollection(id); err == nil && col.Access != nil {
+			return col.Access
+		}
+	}
+	return c.party.Settings().Access
+}
+
+// principals returns who has a level of access, falling back to lower levels if unset
+func principals(ap *triage.AccessPolicy, l Level) triage.Principals {
+	if l >= Admin && !ap.Admin.Empty() {
+		return ap.Admin
+	}
+	if l >= Act && !ap.Act.Empty() {
+		return ap.Act
+	}
+	return ap.View
+}
+
+// includes returns whether an identity is within a set of principals
+func (c *Checker) includes(ctx context.Context, id Identity, ps triage.Principals) bool {
+	if ps.Empty() {
+		return true
+	}
+
+	for _, g := range ps.IdPGroups {
+		for _, ig := range id.IdPGroups {
+			if g == ig {
+				return true
+			}
+		}
+	}
+
+	user := id.User
+	if user == "" {
+		return false
+	}
+
+	for _, u := range ps.Users {
+		if strings.EqualFold(u, user) {
+			return true
+		}
+	}
+
+	for _, g := range ps.Groups {
+		ok, err := c.isMember(ctx, g, user)
+		if err != nil {
+			klog.Errorf("membership of %s in %s: %v", user, g, err)
+			continue
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isMember returns whether a user is a member of a group, caching the result
+func (c *Checker) isMember(ctx context.Context, group string, user string) (bool, error) {
+	key := group + ":" + strings.ToLower(user)
+
+	c.mu.Lock()
+	m, ok := c.memberships[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(m.expires) {
+		return m.member, nil
+	}
+
+	host, name := splitGroup(group)
+	p := c.party.Provider(host)
+	if p == nil {
+		return false, fmt.Errorf("no provider configured for %s", host)
+	}
+
+	member, _, err := p.GroupsIsMember(ctx, name, user)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.memberships[key] = membership{member: member, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return member, nil
+}
+
+// splitGroup splits a group into a provider host and group name, such as gitlab.com/group/subgroup
+func splitGroup(g string) (string, string) {
+	parts := strings.SplitN(g, "/", 2)
+	if len(parts) == 2 && strings.Contains(parts[0], ".") {
+		return parts[0], parts[1]
+	}
+	return constants.GitHubProviderHost, g
+}
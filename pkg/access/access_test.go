@@ -0,0 +1,127 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package access
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipals(t *testing.T) {
+	view := triage.Principals{Groups: []string{"org"}}
+	act := triage.Principals{Groups: []string{"org/triagers"}}
+	admin := triage.Principals{Users: []string{"root"}}
+
+	ap := &triage.AccessPolicy{View: view}
+	assert.Equal(t, view, principals(ap, View))
+	assert.Equal(t, view, principals(ap, Act))
+	assert.Equal(t, view, principals(ap, Admin))
+
+	ap = &triage.AccessPolicy{View: view, Act: act, Admin: admin}
+	assert.Equal(t, view, principals(ap, View))
+	assert.Equal(t, act, principals(ap, Act))
+	assert.Equal(t, admin, principals(ap, Admin))
+}
+
+func TestSplitGroup(t *testing.T) {
+	host, name := splitGroup("kubernetes/sig-cli")
+	assert.Equal(t, "github.com", host)
+	assert.Equal(t, "kubernetes/sig-cli", name)
+
+	host, name = splitGroup("gitlab.com/org/group")
+	assert.Equal(t, "gitlab.com", host)
+	assert.Equal(t, "org/group", name)
+
+	host, name = splitGroup("kubernetes")
+	assert.Equal(t, "github.com", host)
+	assert.Equal(t, "kubernetes", name)
+}
+
+func TestIncludesIdPGroups(t *testing.T) {
+	c := New(Config{})
+	ps := triage.Principals{IdPGroups: []string{"engineering"}}
+
+	assert.True(t, c.includes(context.Background(), Identity{IdPGroups: []string{"sales", "engineering"}}, ps))
+	assert.False(t, c.includes(context.Background(), Identity{IdPGroups: []string{"sales"}}, ps))
+	assert.False(t, c.includes(context.Background(), Identity{}, ps))
+}
+
+// newParty returns a party loaded from a configuration with an access policy
+func newParty(t *testing.T, policy string) *triage.Party {
+	c, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("memory: %v", err)
+	}
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	p, err := triage.New(triage.Config{Cache: c, GitHubToken: "unused"})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	cfg := `
+settings:
+  name: test
+  repos: [https://github.com/org/repo]
+` + policy + `
+collections:
+  - id: daily
+    name: Daily
+    rules: [open]
+rules:
+  open:
+    name: Open
+    filters:
+      - state: open
+`
+	if err := p.Load(strings.NewReader(cfg)); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return p
+}
+
+func TestAllowedAnonymous(t *testing.T) {
+	ctx := context.Background()
+	c := New(Config{Party: newParty(t, "")})
+
+	assert.True(t, c.Allowed(ctx, Identity{}, "daily", View))
+	assert.False(t, c.Allowed(ctx, Identity{}, "daily", Act), "anonymous users may not act")
+	assert.True(t, c.Allowed(ctx, Identity{User: "alice"}, "daily", Act))
+	assert.True(t, c.Allowed(ctx, Identity{IdPGroups: []string{"engineering"}}, "daily", Act))
+}
+
+func TestAllowedAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	c := New(Config{Party: newParty(t, "")})
+	assert.False(t, c.Allowed(ctx, Identity{User: "alice"}, "", Admin), "no policy names an admin")
+
+	c = New(Config{Party: newParty(t, `
+  access:
+    admin:
+      users: [alice]
+`)})
+	assert.True(t, c.Allowed(ctx, Identity{User: "alice"}, "", Admin))
+	assert.False(t, c.Allowed(ctx, Identity{User: "bob"}, "", Admin))
+	assert.False(t, c.Allowed(ctx, Identity{}, "", Admin))
+	assert.True(t, c.Allowed(ctx, Identity{}, "", View), "an empty view policy includes everyone")
+}
@@ -0,0 +1,254 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package action performs write operations on issues and pull requests
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/audit"
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/jira"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+)
+
+// errAlert is returned when asked to change a security alert, which are read-only
+var errAlert = errors.New("security alerts can not be commented on or edited")
+
+// errEpic is returned when asked to change a GitLab epic, which are read-only
+var errEpic = errors.New("epics can not be commented on or edited")
+
+// Config is how to configure a new action executor
+type Config struct {
+	Party *triage.Party
+	Cache persist.Cacher
+	Jira  *jira.Client
+	// Audit records every change made, if set
+	Audit *audit.Log
+
+	// PostComments enables posting rule comments to matching items
+	PostComments bool
+	// StalePolicies enables warning about and closing stale items
+	StalePolicies bool
+	// AutoAssign enables assigning items on every refresh for rules with an auto-assign policy
+	AutoAssign bool
+	// Escalate enables notifying people about items which remain in rules with an escalation chain
+	Escalate bool
+}
+
+// Executor performs actions on behalf of Triage Party users
+type Executor struct {
+	party *triage.Party
+	cache persist.Cacher
+	jira  *jira.Client
+	audit *audit.Log
+
+	postComments  bool
+	stalePolicies bool
+	autoAssign    bool
+	escalate      bool
+
+	mu    sync.Mutex
+	turns map[string]int
+}
+
+// New returns a new action executor
+func New(cfg Config) *Executor {
+	return &Executor{
+		party:         cfg.Party,
+		cache:         cfg.Cache,
+		jira:          cfg.Jira,
+		audit:         cfg.Audit,
+		postComments:  cfg.PostComments,
+		stalePolicies: cfg.StalePolicies,
+		autoAssign:    cfg.AutoAssign,
+		escalate:      cfg.Escalate,
+		turns:         map[string]int{},
+	}
+}
+
+// lookup returns the conversation and search parameters addressing it
+func (e *Executor) lookup(rawURL string) (*hubbub.Conversation, provider.SearchParams, error) {
+	co := e.party.LookupConversation(rawURL)
+	if co == nil {
+		return nil, provider.SearchParams{}, fmt.Errorf("unknown conversation: %q", rawURL)
+	}
+
+	sp, err := searchParams(co)
+	return co, sp, err
+}
+
+// searchParams returns search parameters addressing a conversation
+func searchParams(co *hubbub.Conversation) (provider.SearchParams, error) {
+	sp := provider.SearchParams{}
+	repo, err := parseItemURL(co.URL)
+	if err != nil {
+		return sp, fmt.Errorf("parse: %w", err)
+	}
+
+	sp.Repo = repo
+	sp.IssueNumber = co.ID
+	return sp, nil
+}
+
+// provider returns the provider responsible for a repository
+func (e *Executor) provider(sp provider.SearchParams) (provider.Provider, error) {
+	p := e.party.Provider(sp.Repo.Host)
+	if p == nil {
+		return nil, fmt.Errorf("no provider configured for %s", sp.Repo.Host)
+	}
+	return p, nil
+}
+
+// stateKey returns the cache key of what an action remembers about an item for a rule, such as when it was warned of being stale
+func stateKey(sp provider.SearchParams, ruleID string, kind string) string {
+	return fmt.Sprintf("%s-%d-%s-%s", hubbub.RepoKey(sp.Repo), sp.IssueNumber, ruleID, kind)
+}
+
+// state returns what an action remembers about an item for a rule. Keys did not always name hosts other than GitHub,
+// so the older key is read too, so that upgrading does not repeat actions.
+func (e *Executor) state(sp provider.SearchParams, ruleID string, kind string) *persist.Blob {
+	key := stateKey(sp, ruleID, kind)
+	if b := e.cache.Get(key, time.Time{}); b != nil {
+		return b
+	}
+
+	legacy := fmt.Sprintf("%s-%s-%d-%s-%s", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, ruleID, kind)
+	if legacy == key {
+		return nil
+	}
+	return e.cache.Get(legacy, time.Time{})
+}
+
+// comment adds a comment to a conversation
+func (e *Executor) comment(ctx context.Context, co *hubbub.Conversation, sp provider.SearchParams, body string) (*provider.IssueComment, error) {
+	p, err := e.provider(sp)
+	if err != nil {
+		return nil, err
+	}
+
+	c, _, err := e.postComment(ctx, p, co, sp, body)
+	return c, err
+}
+
+// postComment adds a comment to a conversation using a provider
+func (e *Executor) postComment(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams, body string) (c *provider.IssueComment, resp *provider.Response, err error) {
+	if co.Type == hubbub.Alert {
+		return nil, nil, errAlert
+	}
+	if co.Type == hubbub.Epic {
+		return nil, nil, errEpic
+	}
+
+	if co.Type == hubbub.PullRequest {
+		c, resp, err = p.PullRequestsCreateComment(ctx, sp, body)
+	} else {
+		c, resp, err = p.IssuesCreateComment(ctx, sp, body)
+	}
+	e.audit.Record(ctx, "comment", co.URL, body, err)
+	return c, resp, err
+}
+
+// editComment replaces the body of an existing comment
+func (e *Executor) editComment(ctx context.Context, co *hubbub.Conversation, sp provider.SearchParams, id int64, body string) (*provider.IssueComment, error) {
+	p, err := e.provider(sp)
+	if err != nil {
+		return nil, err
+	}
+
+	if co.Type == hubbub.Alert {
+		return nil, errAlert
+	}
+	if co.Type == hubbub.Epic {
+		return nil, errEpic
+	}
+
+	var c *provider.IssueComment
+	if co.Type == hubbub.PullRequest {
+		c, _, err = p.PullRequestsEditComment(ctx, sp, id, body)
+	} else {
+		c, _, err = p.IssuesEditComment(ctx, sp, id, body)
+	}
+	e.audit.Record(ctx, "edit comment", co.URL, body, err)
+	return c, err
+}
+
+// parseItemURL returns the repository an issue or PR URL refers to, for example:
+//
+// https://github.com/kubernetes/minikube/issues/7179
+// https://gitlab.com/org/group/project/-/merge_requests/12
+// https://bitbucket.example.com/projects/KEY/repos/project/pull-requests/12
+// https://todo.sr.ht/~owner/tracker/12
+// https://bugs.launchpad.net/ubuntu/+source/systemd/+bug/12
+func parseItemURL(rawURL string) (r provider.Repo, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == constants.SourceHutProviderHost && len(parts) == 3 {
+		if _, err := strconv.Atoi(parts[2]); err != nil {
+			return r, fmt.Errorf("no item number in %q", rawURL)
+		}
+		return provider.Repo{Host: u.Host, Organization: parts[0], Project: parts[1]}, nil
+	}
+
+	if br, ok := provider.BitbucketServerRepo(u); ok {
+		if _, err := strconv.Atoi(parts[len(parts)-1]); err != nil {
+			return br, fmt.Errorf("no item number in %q", rawURL)
+		}
+		return br, nil
+	}
+
+	if lr, ok := provider.LaunchpadRepo(u); ok {
+		if _, err := strconv.Atoi(parts[len(parts)-1]); err != nil {
+			return lr, fmt.Errorf("no item number in %q", rawURL)
+		}
+		return lr, nil
+	}
+
+	for i, p := range parts {
+		if p != "-" && p != "issues" && p != "pull" {
+			continue
+		}
+
+		switch i {
+		case 2:
+			r = provider.Repo{Host: u.Host, Organization: parts[0], Project: parts[1]}
+		case 3:
+			r = provider.Repo{Host: u.Host, Organization: parts[0], Group: parts[1], Project: parts[2]}
+		default:
+			return r, fmt.Errorf("unexpected path in %q", rawURL)
+		}
+
+		if _, err := strconv.Atoi(parts[len(parts)-1]); err != nil {
+			return r, fmt.Errorf("no item number in %q", rawURL)
+		}
+		return r, nil
+	}
+
+	return r, fmt.Errorf("%q does not refer to an issue or pull request", rawURL)
+}
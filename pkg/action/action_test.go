@@ -0,0 +1,158 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseItemURL(t *testing.T) {
+	r, err := parseItemURL("https://github.com/kubernetes/minikube/issues/7179")
+	assert.Nil(t, err)
+	assert.Equal(t, "github.com", r.Host)
+	assert.Equal(t, "kubernetes", r.Organization)
+	assert.Equal(t, "minikube", r.Project)
+
+	r, err = parseItemURL("https://github.com/kubernetes/minikube/pull/42")
+	assert.Nil(t, err)
+	assert.Equal(t, "minikube", r.Project)
+
+	r, err = parseItemURL("https://gitlab.com/org/group/project/-/merge_requests/12")
+	assert.Nil(t, err)
+	assert.Equal(t, "gitlab.com", r.Host)
+	assert.Equal(t, "org", r.Organization)
+	assert.Equal(t, "group", r.Group)
+	assert.Equal(t, "project", r.Project)
+
+	r, err = parseItemURL("https://bitbucket.example.com/projects/KEY/repos/project/pull-requests/12")
+	assert.Nil(t, err)
+	assert.Equal(t, "bitbucket.example.com", r.Host)
+	assert.Equal(t, "KEY", r.Organization)
+	assert.Equal(t, "project", r.Project)
+
+	r, err = parseItemURL("https://todo.sr.ht/~owner/tracker/7")
+	assert.Nil(t, err)
+	assert.Equal(t, provider.Repo{Host: "todo.sr.ht", Organization: "~owner", Project: "tracker"}, r)
+
+	r, err = parseItemURL("https://bugs.launchpad.net/ubuntu/+source/systemd/+bug/12")
+	assert.Nil(t, err)
+	assert.Equal(t, provider.Repo{Host: "bugs.launchpad.net", Organization: "ubuntu", Group: "+source", Project: "systemd"}, r)
+
+	_, err = parseItemURL("https://github.com/kubernetes/minikube")
+	assert.NotNil(t, err)
+}
+
+func TestLeastLoaded(t *testing.T) {
+	members := []string{"a", "b", "c"}
+	assert.Equal(t, "a", leastLoaded(members, map[string]int{}))
+	assert.Equal(t, "c", leastLoaded(members, map[string]int{"a": 2, "b": 1}))
+	assert.Equal(t, "b", leastLoaded(members, map[string]int{"a": 1, "c": 1}))
+}
+
+func TestOwnersFor(t *testing.T) {
+	rules, err := parseCodeOwners([]byte(`# comment
+*       @org/core
+*.go    @gopher
+/docs/  @writer docs@example.com
+cmd/**/main.go @cli # trailing comment
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"org/core"}, ownersFor(rules, "README.md"))
+	assert.Equal(t, []string{"gopher"}, ownersFor(rules, "pkg/hubbub/hubbub.go"))
+	assert.Equal(t, []string{"writer"}, ownersFor(rules, "docs/config.md"))
+	assert.Equal(t, []string{"org/core"}, ownersFor(rules, "site/docs/index.md"))
+	assert.Equal(t, []string{"cli"}, ownersFor(rules, "cmd/server/main.go"))
+	assert.Equal(t, []string{"cli"}, ownersFor(rules, "cmd/main.go"))
+}
+
+func TestDescribeEdit(t *testing.T) {
+	locked := true
+	assert.Equal(t, "+label:bug -label:triage/needs-info milestone:v1.9", describeEdit(provider.IssueEdit{
+		AddLabels:    []string{"bug"},
+		RemoveLabels: []string{"triage/needs-info"},
+		Milestone:    "v1.9",
+	}))
+	assert.Equal(t, `state:closed locked:"too heated"`, describeEdit(provider.IssueEdit{State: "closed", Locked: &locked, LockReason: "too heated"}))
+}
+
+func TestRankCandidates(t *testing.T) {
+	load := map[string]int{"alice": 3, "bob": 1}
+	got := rankCandidates([]string{"alice", "org/core", "bob", "carol", "dave"}, "dave", load)
+	assert.Equal(t, []Candidate{{Login: "carol"}, {Login: "bob", Load: 1}, {Login: "alice", Load: 3}}, got)
+}
+
+func TestStateEdit(t *testing.T) {
+	ss := []hubbub.TriageState{
+		{Name: "new", Next: []string{"accepted"}},
+		{Name: "needs-info", Label: "triage/needs-information"},
+		{Name: "accepted", Label: "triage/accepted"},
+	}
+	names := []string{"triage/needs-information", "kind/bug"}
+	co := &hubbub.Conversation{Labels: []*provider.Label{{Name: &names[0]}, {Name: &names[1]}}}
+
+	ie := stateEdit(ss, co, ss[2])
+	assert.Equal(t, []string{"triage/accepted"}, ie.AddLabels)
+	assert.Equal(t, []string{"triage/needs-information"}, ie.RemoveLabels)
+
+	ie = stateEdit(ss, co, ss[0])
+	assert.Empty(t, ie.AddLabels)
+	assert.Equal(t, []string{"triage/needs-information"}, ie.RemoveLabels)
+}
+
+func TestDueLevels(t *testing.T) {
+	levels := []triage.EscalationLevel{{After: "3d"}, {After: "7d"}, {After: "14d"}}
+	day := 24 * time.Hour
+
+	assert.Empty(t, dueLevels(levels, 2*day, 0))
+	assert.Equal(t, []int{0}, dueLevels(levels, 4*day, 0))
+	assert.Equal(t, []int{0, 1}, dueLevels(levels, 8*day, 0))
+	assert.Equal(t, []int{1}, dueLevels(levels, 8*day, 1))
+	assert.Empty(t, dueLevels(levels, 30*day, 3))
+}
+
+func TestNotifyLogins(t *testing.T) {
+	alice, bob := "alice", "bob"
+	co := &hubbub.Conversation{Assignees: []*provider.User{{Login: &alice}, {Login: &bob}}}
+
+	assert.Equal(t, []string{"alice", "bob", "lead"}, notifyLogins([]string{triage.AssigneeTarget, "lead", "alice"}, co))
+	assert.Empty(t, notifyLogins([]string{triage.AssigneeTarget}, &hubbub.Conversation{}))
+}
+
+func TestState(t *testing.T) {
+	m, err := persist.NewMemory(persist.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, m.Initialize())
+	e := New(Config{Cache: m})
+
+	gh := provider.SearchParams{Repo: provider.Repo{Host: "github.com", Organization: "org", Project: "app"}, IssueNumber: 3}
+	gl := provider.SearchParams{Repo: provider.Repo{Host: "gitlab.com", Organization: "org", Project: "app"}, IssueNumber: 3}
+	assert.Equal(t, "org-app-3-old-stale", stateKey(gh, "old", "stale"))
+	assert.Equal(t, "gitlab.com-org-app-3-old-stale", stateKey(gl, "old", "stale"))
+
+	// State recorded before keys named the host is still found
+	assert.Nil(t, m.Set("org-app-3-old-stale", &persist.Blob{Created: time.Now()}))
+	assert.NotNil(t, e.state(gl, "old", "stale"))
+
+	assert.Nil(t, m.Set(stateKey(gl, "new", "stale"), &persist.Blob{Created: time.Now()}))
+	assert.NotNil(t, e.state(gl, "new", "stale"))
+	assert.Nil(t, e.state(gh, "new", "stale"))
+}
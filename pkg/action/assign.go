@@ -0,0 +1,138 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// ApplyAutoAssign assigns unassigned items in rules with an auto-assign policy
+func (e *Executor) ApplyAutoAssign(ctx context.Context, r *triage.CollectionResult) {
+	if !e.autoAssign || r == nil {
+		return
+	}
+
+	for _, rr := range r.RuleResults {
+		if rr.Rule.AutoAssign == nil {
+			continue
+		}
+
+		if _, err := e.AutoAssign(ctx, rr.Rule, rr.Items); err != nil {
+			klog.Errorf("%q auto-assign: %v", rr.Rule.ID, err)
+		}
+	}
+}
+
+// AutoAssign assigns unassigned open items to members, as described by the rules auto-assign policy
+func (e *Executor) AutoAssign(ctx context.Context, rule triage.Rule, items []*hubbub.Conversation) ([]Result, error) {
+	if rule.AutoAssign == nil {
+		return nil, fmt.Errorf("rule %q has no auto-assign policy", rule.ID)
+	}
+
+	members := rule.AutoAssign.Members
+	if len(members) == 0 {
+		members = e.party.Settings().Members
+	}
+	members = e.present(members)
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no members to assign")
+	}
+
+	urls := []string{}
+	for _, co := range items {
+		if len(co.Assignees) > 0 || (co.State != constants.OpenState && co.State != constants.OpenedState) {
+			continue
+		}
+		urls = append(urls, co.URL)
+	}
+
+	load := map[string]int{}
+	if rule.AutoAssign.Strategy == triage.LoadStrategy {
+		load = openAssignments(e.party.Conversations())
+	}
+
+	return e.bulk(ctx, "auto-assign", urls, func(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams) (*provider.Response, error) {
+		var who string
+		if rule.AutoAssign.Strategy == triage.LoadStrategy {
+			who = leastLoaded(members, load)
+		} else {
+			who = e.nextInTurn(rule.ID, members)
+		}
+
+		klog.Infof("assigning %s to %s", co.URL, who)
+		resp, err := e.edit(ctx, p, co, sp, provider.IssueEdit{AddAssignees: []string{who}})
+		if err == nil {
+			load[who]++
+		}
+		return resp, err
+	}), nil
+}
+
+// present returns the members who are not out of the office today
+func (e *Executor) present(members []string) []string {
+	now := time.Now()
+	ps := []string{}
+	for _, m := range members {
+		if e.party.Away(m, now) {
+			klog.V(1).Infof("%s is out of the office", m)
+			continue
+		}
+		ps = append(ps, m)
+	}
+	return ps
+}
+
+// nextInTurn returns the next member for a rule, in round-robin order
+func (e *Executor) nextInTurn(ruleID string, members []string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	i := e.turns[ruleID] % len(members)
+	e.turns[ruleID] = i + 1
+	return members[i]
+}
+
+// openAssignments counts open items by assignee
+func openAssignments(cs []*hubbub.Conversation) map[string]int {
+	load := map[string]int{}
+	for _, co := range cs {
+		if co.State != constants.OpenState && co.State != constants.OpenedState {
+			continue
+		}
+		for _, a := range co.Assignees {
+			load[a.GetLogin()]++
+		}
+	}
+	return load
+}
+
+// leastLoaded returns the member with the fewest assignments, preferring earlier members on ties
+func leastLoaded(members []string, load map[string]int) string {
+	best := members[0]
+	for _, m := range members[1:] {
+		if load[m] < load[best] {
+			best = m
+		}
+	}
+	return best
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// minRateRemaining is how much API quota bulk actions leave untouched
+const minRateRemaining = 50
+
+// Result is the outcome of an action on a single item
+type Result struct {
+	URL   string `json:"url"`
+	Error string `json:"error,omitempty"`
+}
+
+// itemFunc performs an action on a single item
+type itemFunc func(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams) (*provider.Response, error)
+
+// bulk performs an action on a list of items, stopping early if the API rate limit runs low
+func (e *Executor) bulk(ctx context.Context, name string, urls []string, f itemFunc) []Result {
+	rs := []Result{}
+	halted := ""
+
+	for _, url := range urls {
+		if halted != "" {
+			rs = append(rs, Result{URL: url, Error: halted})
+			continue
+		}
+
+		resp, err := e.one(ctx, url, f)
+		if err != nil {
+			klog.Errorf("%s on %s: %v", name, url, err)
+			rs = append(rs, Result{URL: url, Error: err.Error()})
+		} else {
+			klog.Infof("%s on %s: ok", name, url)
+			rs = append(rs, Result{URL: url})
+		}
+
+		if resp != nil && resp.Rate.Limit > 0 && resp.Rate.Remaining < minRateRemaining {
+			halted = fmt.Sprintf("skipped: API rate limit nearly exhausted (%d remaining, resets at %s)", resp.Rate.Remaining, resp.Rate.Reset)
+			klog.Warningf("halting %s: %s", name, halted)
+		}
+	}
+
+	return rs
+}
+
+// one performs an action on a single item
+func (e *Executor) one(ctx context.Context, url string, f itemFunc) (*provider.Response, error) {
+	co, sp, err := e.lookup(url)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := e.provider(sp)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(ctx, p, co, sp)
+}
+
+// edit applies an edit to a conversation
+func (e *Executor) edit(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams, ie provider.IssueEdit) (resp *provider.Response, err error) {
+	if co.Type == hubbub.Alert {
+		return nil, errAlert
+	}
+	if co.Type == hubbub.Epic {
+		return nil, errEpic
+	}
+
+	if co.Type == hubbub.PullRequest {
+		resp, err = p.PullRequestsEdit(ctx, sp, ie)
+	} else {
+		resp, err = p.IssuesEdit(ctx, sp, ie)
+	}
+	e.audit.Record(ctx, "edit", co.URL, describeEdit(ie), err)
+	return resp, err
+}
+
+// describeEdit returns a short, human readable description of an edit
+func describeEdit(ie provider.IssueEdit) string {
+	ds := []string{}
+	for _, l := range ie.AddLabels {
+		ds = append(ds, "+label:"+l)
+	}
+	for _, l := range ie.RemoveLabels {
+		ds = append(ds, "-label:"+l)
+	}
+	for _, a := range ie.AddAssignees {
+		ds = append(ds, "+assignee:"+a)
+	}
+	if ie.Milestone != "" {
+		ds = append(ds, "milestone:"+ie.Milestone)
+	}
+	if ie.State != "" {
+		ds = append(ds, "state:"+ie.State)
+	}
+	if ie.Locked != nil {
+		if *ie.Locked {
+			ds = append(ds, fmt.Sprintf("locked:%q", ie.LockReason))
+		} else {
+			ds = append(ds, "unlocked")
+		}
+	}
+	return strings.Join(ds, " ")
+}
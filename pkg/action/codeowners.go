@@ -0,0 +1,171 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// codeOwnersPaths are where CODEOWNERS files are looked for, in order
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// ownerRule is a single line of a CODEOWNERS file
+type ownerRule struct {
+	re     *regexp.Regexp
+	owners []string
+}
+
+// parseCodeOwners parses a CODEOWNERS file. Owners are returned without a leading @, and e-mail owners are ignored.
+func parseCodeOwners(data []byte) ([]ownerRule, error) {
+	rules := []ownerRule{}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		re, err := codeOwnersRegexp(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", fields[0], err)
+		}
+
+		owners := []string{}
+		for _, o := range fields[1:] {
+			if !strings.HasPrefix(o, "@") {
+				continue
+			}
+			owners = append(owners, strings.TrimPrefix(o, "@"))
+		}
+		rules = append(rules, ownerRule{re: re, owners: owners})
+	}
+	return rules, s.Err()
+}
+
+// codeOwnersRegexp converts a gitignore-style CODEOWNERS pattern into a regular expression
+func codeOwnersRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+		case pattern[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		}
+	}
+
+	if dirOnly {
+		sb.WriteString("/.*$")
+	} else {
+		sb.WriteString("(/.*)?$")
+	}
+	return regexp.Compile(sb.String())
+}
+
+// ownersFor returns the owners of a path. As with GitHub, the last matching rule wins.
+func ownersFor(rules []ownerRule, path string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].re.MatchString(path) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// codeOwners returns the owners of the files changed by a pull request
+func codeOwners(ctx context.Context, p provider.Provider, sp provider.SearchParams) ([]string, *provider.Response, error) {
+	rules, resp, err := codeOwnerRules(ctx, p, sp)
+	if err != nil {
+		return nil, resp, err
+	}
+	return changedFileOwners(ctx, p, sp, rules)
+}
+
+// codeOwnerRules returns the rules of a repository's CODEOWNERS file
+func codeOwnerRules(ctx context.Context, p provider.Provider, sp provider.SearchParams) ([]ownerRule, *provider.Response, error) {
+	var data []byte
+	var resp *provider.Response
+	var err error
+
+	for _, path := range codeOwnersPaths {
+		data, resp, err = p.ReposGetFile(ctx, sp, path)
+		if err == nil {
+			break
+		}
+		klog.V(1).Infof("no %s in %s/%s: %v", path, sp.Repo.Organization, sp.Repo.Project, err)
+	}
+
+	if data == nil {
+		return nil, resp, fmt.Errorf("no CODEOWNERS file found")
+	}
+
+	rules, err := parseCodeOwners(data)
+	if err != nil {
+		return nil, resp, fmt.Errorf("parse CODEOWNERS: %w", err)
+	}
+	return rules, resp, nil
+}
+
+// changedFileOwners returns the owners of the files changed by a pull request, according to a set of rules
+func changedFileOwners(ctx context.Context, p provider.Provider, sp provider.SearchParams, rules []ownerRule) ([]string, *provider.Response, error) {
+	files, resp, err := p.PullRequestsListFiles(ctx, sp)
+	if err != nil {
+		return nil, resp, fmt.Errorf("list files: %w", err)
+	}
+
+	seen := map[string]bool{}
+	owners := []string{}
+	for _, f := range files {
+		for _, o := range ownersFor(rules, f) {
+			if seen[o] {
+				continue
+			}
+			seen[o] = true
+			owners = append(owners, o)
+		}
+	}
+	return owners, resp, nil
+}
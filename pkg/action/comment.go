@@ -0,0 +1,93 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// PostRuleComments comments on items matching rules which define a comment.
+// Each item receives one comment per rule, which is updated if the rendered text changes.
+func (e *Executor) PostRuleComments(ctx context.Context, r *triage.CollectionResult) {
+	if !e.postComments || r == nil {
+		return
+	}
+
+	for _, rr := range r.RuleResults {
+		if rr.Rule.Comment == "" {
+			continue
+		}
+
+		t, err := template.New(rr.Rule.ID).Parse(rr.Rule.Comment)
+		if err != nil {
+			klog.Errorf("%q comment template: %v", rr.Rule.ID, err)
+			continue
+		}
+
+		for _, co := range rr.Items {
+			if err := e.postRuleComment(ctx, rr.Rule, t, co); err != nil {
+				klog.Errorf("%q comment on %s: %v", rr.Rule.ID, co.URL, err)
+			}
+		}
+	}
+}
+
+// postRuleComment creates or updates the comment for a single rule & item
+func (e *Executor) postRuleComment(ctx context.Context, rule triage.Rule, t *template.Template, co *hubbub.Conversation) error {
+	var bs bytes.Buffer
+	if err := t.Execute(&bs, co); err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+	body := bs.String()
+
+	sp, err := searchParams(co)
+	if err != nil {
+		return err
+	}
+
+	key := stateKey(sp, rule.ID, "rule-comment")
+
+	var c *provider.IssueComment
+	if prev := e.state(sp, rule.ID, "rule-comment"); prev != nil && len(prev.IssueComments) > 0 {
+		pc := prev.IssueComments[0]
+		if pc.GetBody() == body {
+			return nil
+		}
+
+		klog.Infof("updating %q comment on %s", rule.ID, co.URL)
+		c, err = e.editComment(ctx, co, sp, pc.GetID(), body)
+	} else {
+		klog.Infof("posting %q comment on %s", rule.ID, co.URL)
+		c, err = e.comment(ctx, co, sp, body)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Remember what we rendered, as providers may normalize the body they return
+	c.Body = &body
+	return e.cache.Set(key, &persist.Blob{Created: time.Now(), IssueComments: []*provider.IssueComment{c}})
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// CloseAsDuplicate closes a list of items, commenting that each is a duplicate of a canonical item
+func (e *Executor) CloseAsDuplicate(ctx context.Context, urls []string, canonical string) ([]Result, error) {
+	cco, _, err := e.lookup(canonical)
+	if err != nil {
+		return nil, fmt.Errorf("canonical: %w", err)
+	}
+
+	for _, u := range urls {
+		if u == canonical {
+			return nil, fmt.Errorf("%s can not be a duplicate of itself", u)
+		}
+	}
+
+	ie := provider.IssueEdit{State: constants.ClosedState}
+	return e.bulk(ctx, "close as duplicate", urls, func(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams) (*provider.Response, error) {
+		if _, resp, err := e.postComment(ctx, p, co, sp, duplicateComment(co, cco)); err != nil {
+			return resp, fmt.Errorf("comment: %w", err)
+		}
+		return e.edit(ctx, p, co, sp, ie)
+	}), nil
+}
+
+// duplicateComment returns a comment marking an item as a duplicate, in the form GitHub recognizes
+func duplicateComment(co *hubbub.Conversation, canonical *hubbub.Conversation) string {
+	if co.Organization == canonical.Organization && co.Project == canonical.Project {
+		return fmt.Sprintf("Duplicate of #%d", canonical.ID)
+	}
+	return fmt.Sprintf("Duplicate of %s", canonical.URL)
+}
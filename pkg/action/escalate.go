@@ -0,0 +1,188 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+const defaultEscalation = "This item has been in %q for more than %s."
+
+// webhookClient posts escalations to chat channels
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+// ApplyEscalations notifies people in turn about items which remain in rules with an escalation chain
+func (e *Executor) ApplyEscalations(ctx context.Context, r *triage.CollectionResult) {
+	if !e.escalate || r == nil {
+		return
+	}
+
+	for _, rr := range r.RuleResults {
+		if len(rr.Rule.Escalation) == 0 {
+			continue
+		}
+
+		for _, co := range rr.Items {
+			if err := e.applyEscalation(ctx, rr.Rule, co); err != nil {
+				klog.Errorf("%q escalation on %s: %v", rr.Rule.ID, co.URL, err)
+			}
+		}
+	}
+}
+
+// applyEscalation fires the levels of an escalation chain which are due for a single item, once each
+func (e *Executor) applyEscalation(ctx context.Context, rule triage.Rule, co *hubbub.Conversation) error {
+	if co.State != constants.OpenState && co.State != constants.OpenedState {
+		return nil
+	}
+
+	params, err := searchParams(co)
+	if err != nil {
+		return err
+	}
+
+	key := stateKey(params, rule.ID, "escalation")
+
+	// The chain is timed from when the item was first seen in the rule
+	b := e.state(params, rule.ID, "escalation")
+	if b == nil {
+		return e.cache.Set(key, &persist.Blob{Created: time.Now()})
+	}
+
+	fired := b.EscalationLevel
+	for _, i := range dueLevels(rule.Escalation, time.Since(b.Created), fired) {
+		l := rule.Escalation[i]
+		klog.Infof("escalating %s to level %d of %q (in rule since %s)", co.URL, i+1, rule.ID, b.Created)
+		if err := e.notify(ctx, rule, l, co); err != nil {
+			return fmt.Errorf("level %d: %w", i+1, err)
+		}
+
+		fired = i + 1
+		if err := e.cache.Set(key, &persist.Blob{Created: b.Created, EscalationLevel: fired}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dueLevels returns the indexes of the levels which are due for an item in a rule for a duration, skipping those already fired
+func dueLevels(levels []triage.EscalationLevel, in time.Duration, fired int) []int {
+	due := []int{}
+	for i := fired; i < len(levels); i++ {
+		if in < levels[i].Duration() {
+			break
+		}
+		due = append(due, i)
+	}
+	return due
+}
+
+// notify mentions the people of an escalation level on an item, and posts to its webhook
+func (e *Executor) notify(ctx context.Context, rule triage.Rule, l triage.EscalationLevel, co *hubbub.Conversation) error {
+	msg := l.Message
+	if msg == "" {
+		name := rule.Name
+		if name == "" {
+			name = rule.ID
+		}
+		msg = fmt.Sprintf(defaultEscalation, name, l.After)
+	}
+
+	mentions := []string{}
+	for _, u := range notifyLogins(l.Notify, co) {
+		mentions = append(mentions, "@"+u)
+	}
+
+	if len(mentions) > 0 {
+		params, err := searchParams(co)
+		if err != nil {
+			return err
+		}
+		if _, err := e.comment(ctx, co, params, fmt.Sprintf("%s: %s", strings.Join(mentions, " "), msg)); err != nil {
+			return fmt.Errorf("comment: %w", err)
+		}
+	}
+
+	if l.Webhook != "" {
+		err := postWebhook(ctx, l.Webhook, fmt.Sprintf("<%s|%s>: %s", co.URL, co.Title, msg))
+		e.audit.Record(ctx, "escalate", co.URL, msg, err)
+		if err != nil {
+			return fmt.Errorf("webhook: %w", err)
+		}
+	}
+	return nil
+}
+
+// notifyLogins returns the logins to mention for an item, replacing the assignee target with its assignees
+func notifyLogins(notify []string, co *hubbub.Conversation) []string {
+	logins := []string{}
+	seen := map[string]bool{}
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			logins = append(logins, u)
+			seen[u] = true
+		}
+	}
+
+	for _, n := range notify {
+		if n != triage.AssigneeTarget {
+			add(n)
+			continue
+		}
+		for _, a := range co.Assignees {
+			add(a.GetLogin())
+		}
+	}
+	return logins
+}
+
+// postWebhook posts a Slack-compatible message to an incoming webhook
+func postWebhook(ctx context.Context, url string, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		rb, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, rb)
+	}
+	return nil
+}
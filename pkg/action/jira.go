@@ -0,0 +1,99 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/jira"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// defaultJiraIssueType is used if no issue type is configured
+const defaultJiraIssueType = "Task"
+
+// JiraEnabled returns whether Jira tickets can be created
+func (e *Executor) JiraEnabled() bool {
+	js := e.party.Settings().Jira
+	return e.jira != nil && js.URL != "" && (js.Project != "" || len(js.Projects) > 0)
+}
+
+// CreateJiraIssue creates a Jira issue for a conversation, and links back to it with a comment
+func (e *Executor) CreateJiraIssue(ctx context.Context, url string) (*jira.Issue, error) {
+	if !e.JiraEnabled() {
+		return nil, fmt.Errorf("jira is not configured")
+	}
+
+	co, sp, err := e.lookup(url)
+	if err != nil {
+		return nil, err
+	}
+
+	js := e.party.Settings().Jira
+	project := jiraProject(js, co)
+	if project == "" {
+		return nil, fmt.Errorf("no jira project configured for %s", co.URL)
+	}
+
+	ji, err := e.jira.CreateIssue(ctx, jira.NewIssue{
+		Project:     project,
+		Type:        jiraIssueType(js, co),
+		Summary:     co.Title,
+		Description: fmt.Sprintf("Created from %s (opened by %s)", co.URL, co.Author.GetLogin()),
+	})
+	if err != nil {
+		e.audit.Record(ctx, "create jira issue", co.URL, project, err)
+		return nil, fmt.Errorf("create jira issue: %w", err)
+	}
+	klog.Infof("created jira issue %s for %s", ji.Key, co.URL)
+	e.audit.Record(ctx, "create jira issue", co.URL, ji.URL, nil)
+
+	if _, err := e.comment(ctx, co, sp, fmt.Sprintf("Tracked in Jira as [%s](%s)", ji.Key, ji.URL)); err != nil {
+		return ji, fmt.Errorf("comment: %w", err)
+	}
+	return ji, nil
+}
+
+// jiraProject returns the Jira project key for a conversation
+func jiraProject(js triage.JiraSettings, co *hubbub.Conversation) string {
+	best := ""
+	project := js.Project
+	for repo, p := range js.Projects {
+		repo = strings.TrimSuffix(repo, "/")
+		if strings.HasPrefix(co.URL, repo+"/") && len(repo) > len(best) {
+			best = repo
+			project = p
+		}
+	}
+	return project
+}
+
+// jiraIssueType returns the Jira issue type for a conversation based on its labels
+func jiraIssueType(js triage.JiraSettings, co *hubbub.Conversation) string {
+	for _, l := range co.Labels {
+		if t, ok := js.IssueTypes[l.GetName()]; ok {
+			return t
+		}
+	}
+
+	if js.IssueType != "" {
+		return js.IssueType
+	}
+	return defaultJiraIssueType
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// lockReasons are the lock reasons GitHub accepts
+var lockReasons = map[string]bool{"": true, "off-topic": true, "too heated": true, "resolved": true, "spam": true}
+
+// SetLocked locks or unlocks the conversation on a list of items.
+// Converting issues into discussions is not offered, as GitHub has no API for it.
+func (e *Executor) SetLocked(ctx context.Context, urls []string, locked bool, reason string) ([]Result, error) {
+	if !lockReasons[reason] {
+		return nil, fmt.Errorf("invalid lock reason: %q", reason)
+	}
+
+	name := "unlock"
+	if locked {
+		name = "lock"
+	}
+
+	ie := provider.IssueEdit{Locked: &locked, LockReason: reason}
+	return e.bulk(ctx, name, urls, func(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams) (*provider.Response, error) {
+		return e.edit(ctx, p, co, sp, ie)
+	}), nil
+}
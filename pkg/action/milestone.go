@@ -0,0 +1,35 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// SetMilestone moves a list of items into a milestone, by title
+func (e *Executor) SetMilestone(ctx context.Context, urls []string, title string) ([]Result, error) {
+	if title == "" {
+		return nil, fmt.Errorf("no milestone given")
+	}
+
+	ie := provider.IssueEdit{Milestone: title}
+	return e.bulk(ctx, fmt.Sprintf("set milestone %q", title), urls, func(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams) (*provider.Response, error) {
+		return e.edit(ctx, p, co, sp, ie)
+	}), nil
+}
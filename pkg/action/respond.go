@@ -0,0 +1,48 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// PostResponse posts a canned response to a list of items
+func (e *Executor) PostResponse(ctx context.Context, urls []string, id string) ([]Result, error) {
+	r, err := e.party.LookupResponse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New(id).Parse(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%q template: %w", id, err)
+	}
+
+	return e.bulk(ctx, fmt.Sprintf("respond %q", id), urls, func(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams) (*provider.Response, error) {
+		var bs bytes.Buffer
+		if err := t.Execute(&bs, co); err != nil {
+			return nil, fmt.Errorf("execute: %w", err)
+		}
+
+		_, resp, err := e.postComment(ctx, p, co, sp, bs.String())
+		return resp, err
+	}), nil
+}
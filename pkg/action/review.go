@@ -0,0 +1,58 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// RequestReviewers requests reviews on a list of pull requests.
+// If no reviewers are given, they are resolved from the repository's CODEOWNERS file.
+func (e *Executor) RequestReviewers(ctx context.Context, urls []string, reviewers []string) ([]Result, error) {
+	return e.bulk(ctx, "request reviewers", urls, func(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams) (*provider.Response, error) {
+		if co.Type != hubbub.PullRequest {
+			return nil, fmt.Errorf("not a pull request")
+		}
+
+		rs := reviewers
+		if len(rs) == 0 {
+			owners, resp, err := codeOwners(ctx, p, sp)
+			if err != nil {
+				return resp, err
+			}
+
+			// Authors can not review their own pull requests
+			rs = []string{}
+			for _, o := range owners {
+				if o != co.Author.GetLogin() {
+					rs = append(rs, o)
+				}
+			}
+
+			if len(rs) == 0 {
+				return resp, fmt.Errorf("no code owners found")
+			}
+		}
+
+		resp, err := p.PullRequestsRequestReviewers(ctx, sp, rs)
+		e.audit.Record(ctx, "request reviewers", co.URL, strings.Join(rs, " "), err)
+		return resp, err
+	}), nil
+}
@@ -0,0 +1,30 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/triage-party/pkg/audit"
+)
+
+// MarkNotSimilar marks every pair of a list of items as never similar
+func (e *Executor) MarkNotSimilar(ctx context.Context, urls []string) error {
+	err := e.party.ExcludeSimilar(urls, audit.Actor(ctx))
+	e.audit.Record(ctx, "not-similar", urls[0], fmt.Sprintf("not similar to %s", strings.Join(urls[1:], ", ")), err)
+	return err
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultStaleWarning = "This item has had no activity for %s, and will be closed in %s unless there is further activity."
+	defaultStaleClose   = "Closing this item due to inactivity. Feel free to reopen it if it is still relevant."
+
+	// staleSlack is how long after our warning further updates are ignored, as the warning itself updates the item
+	staleSlack = 5 * time.Minute
+)
+
+// ApplyStalePolicies warns about, and later closes, inactive items in rules with a stale policy
+func (e *Executor) ApplyStalePolicies(ctx context.Context, r *triage.CollectionResult) {
+	if !e.stalePolicies || r == nil {
+		return
+	}
+
+	for _, rr := range r.RuleResults {
+		if rr.Rule.Stale == nil && len(rr.Rule.Overrides) == 0 {
+			continue
+		}
+
+		for _, co := range rr.Items {
+			if err := e.applyStalePolicy(ctx, rr.Rule, co); err != nil {
+				klog.Errorf("%q stale policy on %s: %v", rr.Rule.ID, co.URL, err)
+			}
+		}
+	}
+}
+
+// applyStalePolicy applies a stale policy to a single item
+func (e *Executor) applyStalePolicy(ctx context.Context, rule triage.Rule, co *hubbub.Conversation) error {
+	params, err := searchParams(co)
+	if err != nil {
+		return err
+	}
+
+	sp := rule.ForRepo(params.Repo).Stale
+	if sp == nil {
+		return nil
+	}
+
+	if co.State != constants.OpenState && co.State != constants.OpenedState {
+		return nil
+	}
+
+	for _, l := range co.Labels {
+		for _, x := range sp.ExemptLabels {
+			if l.GetName() == x {
+				return nil
+			}
+		}
+	}
+
+	warnAfter, _, _ := hubbub.ParseDuration(sp.WarnAfter)
+	closeAfter, _, _ := hubbub.ParseDuration(sp.CloseAfter)
+	key := stateKey(params, rule.ID, "stale")
+
+	warned := time.Time{}
+	if b := e.state(params, rule.ID, "stale"); b != nil && len(b.IssueComments) > 0 {
+		warned = b.Created
+	}
+
+	// Activity since the warning resets the clock
+	if !warned.IsZero() && co.Updated.After(warned.Add(staleSlack)) {
+		klog.Infof("%s has seen activity since the stale warning at %s", co.URL, warned)
+		return e.cache.Set(key, &persist.Blob{Created: time.Now()})
+	}
+
+	if warned.IsZero() {
+		if time.Since(co.Updated) < warnAfter {
+			return nil
+		}
+
+		msg := sp.WarnMessage
+		if msg == "" {
+			msg = fmt.Sprintf(defaultStaleWarning, sp.WarnAfter, sp.CloseAfter)
+		}
+
+		klog.Infof("posting stale warning on %s (updated %s)", co.URL, co.Updated)
+		c, err := e.comment(ctx, co, params, msg)
+		if err != nil {
+			return fmt.Errorf("warn: %w", err)
+		}
+		return e.cache.Set(key, &persist.Blob{Created: time.Now(), IssueComments: []*provider.IssueComment{c}})
+	}
+
+	if time.Since(warned) < closeAfter {
+		return nil
+	}
+
+	msg := sp.CloseMessage
+	if msg == "" {
+		msg = defaultStaleClose
+	}
+
+	klog.Infof("closing stale item %s (warned %s)", co.URL, warned)
+	if _, err := e.comment(ctx, co, params, msg); err != nil {
+		return fmt.Errorf("comment: %w", err)
+	}
+
+	p, err := e.provider(params)
+	if err != nil {
+		return err
+	}
+
+	if _, err := e.edit(ctx, p, co, params, provider.IssueEdit{State: constants.ClosedState}); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return e.cache.Set(key, &persist.Blob{Created: time.Now()})
+}
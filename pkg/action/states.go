@@ -0,0 +1,66 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// SetTriageState moves a list of items into a triage state, if the state machine allows it
+func (e *Executor) SetTriageState(ctx context.Context, urls []string, name string) ([]Result, error) {
+	ss := e.party.TriageStates()
+	to, ok := hubbub.LookupTriageState(ss, name)
+	if !ok {
+		return nil, fmt.Errorf("unknown triage state: %q", name)
+	}
+
+	return e.bulk(ctx, fmt.Sprintf("set triage state %q", name), urls, func(ctx context.Context, p provider.Provider, co *hubbub.Conversation, sp provider.SearchParams) (*provider.Response, error) {
+		if !hubbub.CanTransition(ss, co.TriageState, to.Name) {
+			return nil, fmt.Errorf("%q may not move to %q", co.TriageState, to.Name)
+		}
+
+		ie := stateEdit(ss, co, to)
+		if len(ie.AddLabels) == 0 && len(ie.RemoveLabels) == 0 {
+			return nil, nil
+		}
+		return e.edit(ctx, p, co, sp, ie)
+	}), nil
+}
+
+// stateEdit returns the label changes which move a conversation into a triage state
+func stateEdit(ss []hubbub.TriageState, co *hubbub.Conversation, to hubbub.TriageState) provider.IssueEdit {
+	has := map[string]bool{}
+	for _, l := range co.Labels {
+		has[l.GetName()] = true
+	}
+
+	ie := provider.IssueEdit{}
+	for _, s := range ss {
+		if s.Label == "" {
+			continue
+		}
+		if s.Name == to.Name && !has[s.Label] {
+			ie.AddLabels = append(ie.AddLabels, s.Label)
+		}
+		if s.Name != to.Name && has[s.Label] {
+			ie.RemoveLabels = append(ie.RemoveLabels, s.Label)
+		}
+	}
+	return ie
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"k8s.io/klog/v2"
+)
+
+// Suggestion is the reviewer suggested for an unreviewed pull request
+type Suggestion struct {
+	URL     string    `json:"url"`
+	ID      int       `json:"id"`
+	Repo    string    `json:"repo"`
+	Title   string    `json:"title"`
+	Author  string    `json:"author"`
+	Created time.Time `json:"created"`
+
+	// Reviewer is the least-loaded qualified reviewer, or empty if nobody qualifies
+	Reviewer string `json:"reviewer"`
+	// Candidates are the qualified reviewers, least-loaded first
+	Candidates []Candidate `json:"candidates"`
+	// CodeOwners is true if the candidates own the changed files, rather than being any member
+	CodeOwners bool `json:"code_owners"`
+}
+
+// Candidate is a qualified reviewer, and the number of review requests they have outstanding
+type Candidate struct {
+	Login string `json:"login"`
+	Load  int    `json:"load"`
+}
+
+// SuggestReviewers suggests the least-loaded qualified reviewer for each open pull request which awaits one.
+// Code owners of the changed files are preferred, falling back to the project members. Members who are out of the office are never suggested.
+func (e *Executor) SuggestReviewers(ctx context.Context, items []*hubbub.Conversation) []*Suggestion {
+	load := reviewLoad(e.party.Conversations())
+	rules := map[string][]ownerRule{}
+	ss := []*Suggestion{}
+
+	for _, co := range items {
+		if co.Type != hubbub.PullRequest || co.ReviewState != hubbub.Unreviewed || len(co.ReviewRequests) > 0 {
+			continue
+		}
+		if co.State != constants.OpenState && co.State != constants.OpenedState {
+			continue
+		}
+
+		s := &Suggestion{
+			URL:     co.URL,
+			ID:      co.ID,
+			Repo:    co.Organization + "/" + co.Project,
+			Title:   co.Title,
+			Author:  co.Author.GetLogin(),
+			Created: co.Created,
+		}
+
+		owners, err := e.changedOwners(ctx, co, rules)
+		if err != nil {
+			klog.V(1).Infof("no code owners for %s: %v", co.URL, err)
+		}
+
+		s.Candidates = rankCandidates(e.present(owners), s.Author, load)
+		s.CodeOwners = len(s.Candidates) > 0
+		if !s.CodeOwners {
+			s.Candidates = rankCandidates(e.present(e.party.Settings().Members), s.Author, load)
+		}
+
+		// Spread suggestions out, as each one adds to the reviewer's load once requested
+		if len(s.Candidates) > 0 {
+			s.Reviewer = s.Candidates[0].Login
+			load[s.Reviewer]++
+		}
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+// changedOwners returns the code owners of a pull request, fetching each repository's rules once
+func (e *Executor) changedOwners(ctx context.Context, co *hubbub.Conversation, rules map[string][]ownerRule) ([]string, error) {
+	sp, err := searchParams(co)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := e.provider(sp)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", sp.Repo.Host, sp.Repo.Organization, sp.Repo.Project)
+	rs, ok := rules[key]
+	if !ok {
+		rs, _, err = codeOwnerRules(ctx, p, sp)
+		rules[key] = rs
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rs) == 0 {
+		return nil, nil
+	}
+
+	owners, _, err := changedFileOwners(ctx, p, sp, rs)
+	return owners, err
+}
+
+// reviewLoad counts outstanding review requests on open pull requests by reviewer
+func reviewLoad(cs []*hubbub.Conversation) map[string]int {
+	load := map[string]int{}
+	for _, co := range cs {
+		if co.State != constants.OpenState && co.State != constants.OpenedState {
+			continue
+		}
+		for login := range co.ReviewRequests {
+			load[login]++
+		}
+	}
+	return load
+}
+
+// rankCandidates orders reviewers by load, leaving out the author and teams, which can not be load-balanced
+func rankCandidates(logins []string, author string, load map[string]int) []Candidate {
+	cs := []Candidate{}
+	for _, l := range logins {
+		if l == author || strings.Contains(l, "/") {
+			continue
+		}
+		cs = append(cs, Candidate{Login: l, Load: load[l]})
+	}
+
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].Load < cs[j].Load })
+	return cs
+}
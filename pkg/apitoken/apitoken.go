@@ -0,0 +1,159 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apitoken issues and verifies tokens for programmatic access
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+)
+
+const (
+	// ReadScope allows viewing collections
+	ReadScope = "read"
+	// WriteScope additionally allows write actions
+	WriteScope = "write"
+
+	// secretPrefix makes tokens easy to recognize, for instance by secret scanners
+	secretPrefix = "tp_"
+
+	// cacheKey is where tokens are stored within the persistence backend
+	cacheKey = "api-tokens"
+)
+
+// Config is how to configure a new token store
+type Config struct {
+	Cache persist.Cacher
+}
+
+// Store issues and verifies tokens
+type Store struct {
+	cache persist.Cacher
+	mu    sync.Mutex
+}
+
+// New returns a new token store
+func New(cfg Config) *Store {
+	return &Store{cache: cfg.Cache}
+}
+
+// load returns all issued tokens. Tokens are read from the backend rather than memory, so that tokens
+// issued or revoked by other replicas take effect immediately.
+func (s *Store) load() []*persist.APIToken {
+	if b := s.cache.GetFresh(cacheKey); b != nil {
+		return b.APITokens
+	}
+	return nil
+}
+
+// save stores all issued tokens
+func (s *Store) save(ts []*persist.APIToken) error {
+	return s.cache.Set(cacheKey, &persist.Blob{Created: time.Now(), APITokens: ts})
+}
+
+// hash returns the stored form of a secret
+func hash(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// Issue creates a new token, returning its secret. The secret can not be retrieved later.
+func (s *Store) Issue(name string, owner string, scope string) (string, *persist.APIToken, error) {
+	if scope != ReadScope && scope != WriteScope {
+		return "", nil, fmt.Errorf("invalid scope %q: must be %q or %q", scope, ReadScope, WriteScope)
+	}
+	if strings.TrimSpace(name) == "" {
+		return "", nil, fmt.Errorf("name is required")
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("rand: %w", err)
+	}
+	secret := secretPrefix + hex.EncodeToString(b)
+
+	// The ID is shown and logged, so it is drawn separately rather than revealing part of the secret
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("rand: %w", err)
+	}
+
+	t := &persist.APIToken{
+		ID:      hex.EncodeToString(id),
+		Name:    name,
+		Owner:   owner,
+		Scope:   scope,
+		Created: time.Now(),
+		Hash:    hash(secret),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.save(append(s.load(), t)); err != nil {
+		return "", nil, fmt.Errorf("save: %w", err)
+	}
+	return secret, t, nil
+}
+
+// Revoke deletes a token by ID
+func (s *Store) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := []*persist.APIToken{}
+	found := false
+	for _, t := range s.load() {
+		if t.ID == id {
+			found = true
+			continue
+		}
+		ts = append(ts, t)
+	}
+
+	if !found {
+		return fmt.Errorf("token %q not found", id)
+	}
+	return s.save(ts)
+}
+
+// List returns all issued tokens
+func (s *Store) List() []*persist.APIToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Verify returns the token for a secret, or nil if it is unknown
+func (s *Store) Verify(secret string) *persist.APIToken {
+	if !strings.HasPrefix(secret, secretPrefix) {
+		return nil
+	}
+
+	h := hash(secret)
+	for _, t := range s.List() {
+		if t.Hash == h {
+			return t
+		}
+	}
+	return nil
+}
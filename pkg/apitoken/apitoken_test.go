@@ -0,0 +1,82 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitoken
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssue(t *testing.T) {
+	c, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("memory: %v", err)
+	}
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	s := New(Config{Cache: c})
+
+	secret, at, err := s.Issue("ci", "alice", WriteScope)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", at.Owner)
+	assert.Len(t, at.ID, 8)
+	assert.False(t, strings.Contains(secret, at.ID), "the ID %s must not reveal the secret %s", at.ID, secret)
+
+	assert.Equal(t, at, s.Verify(secret))
+	assert.Nil(t, s.Verify(secret+"0"))
+
+	assert.NoError(t, s.Revoke(at.ID))
+	assert.Nil(t, s.Verify(secret))
+}
+
+func TestReplicas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apitoken")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Two replicas sharing a backend, each with its own memory
+	replicas := []*Store{}
+	for i := 0; i < 2; i++ {
+		c, err := persist.NewDisk(persist.Config{Path: dir})
+		if err != nil {
+			t.Fatalf("disk: %v", err)
+		}
+		if err := c.Initialize(); err != nil {
+			t.Fatalf("initialize: %v", err)
+		}
+		replicas = append(replicas, New(Config{Cache: c}))
+	}
+	a, b := replicas[0], replicas[1]
+
+	secret, at, err := a.Issue("ci", "alice", ReadScope)
+	assert.NoError(t, err)
+	assert.Equal(t, at.ID, b.Verify(secret).ID)
+
+	other, _, err := b.Issue("bot", "alice", ReadScope)
+	assert.NoError(t, err)
+	assert.Len(t, a.List(), 2)
+
+	assert.NoError(t, a.Revoke(at.ID))
+	assert.Nil(t, b.Verify(secret))
+	assert.NotNil(t, b.Verify(other))
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records changes made through Triage Party
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+	"k8s.io/klog/v2"
+)
+
+// SystemActor is recorded for changes which were not requested by a user, such as rule comments
+const SystemActor = "triage-party"
+
+type actorKey struct{}
+
+// WithActor returns a context which records changes as made by actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns who is making changes within a context
+func Actor(ctx context.Context) string {
+	if a, ok := ctx.Value(actorKey{}).(string); ok && a != "" {
+		return a
+	}
+	return SystemActor
+}
+
+// Config is how to configure a new audit log
+type Config struct {
+	Cache persist.Cacher
+	// OnDuty returns who is on triage duty at a time, if anyone
+	OnDuty func(time.Time) string
+}
+
+// Log is an audit log, stored in the persistence backend as one key per entry, so that replicas
+// sharing a backend never overwrite each other's entries
+type Log struct {
+	cache  persist.Cacher
+	onDuty func(time.Time) string
+}
+
+// New returns a new audit log
+func New(cfg Config) *Log {
+	return &Log{cache: cfg.Cache, onDuty: cfg.OnDuty}
+}
+
+// dayPrefix returns the cache key prefix for a day of audit entries
+func dayPrefix(t time.Time) string {
+	return fmt.Sprintf("audit-%s-", t.UTC().Format("2006-01-02"))
+}
+
+// entryKey returns a unique cache key for an audit entry
+func entryKey(t time.Time) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("rand: %w", err)
+	}
+	return fmt.Sprintf("%s%d-%s", dayPrefix(t), t.UnixNano(), hex.EncodeToString(b)), nil
+}
+
+// Record records a change. A nil log records nothing.
+func (l *Log) Record(ctx context.Context, action string, target string, diff string, err error) {
+	if l == nil {
+		return
+	}
+
+	e := &persist.AuditEntry{
+		Time:   time.Now(),
+		Actor:  Actor(ctx),
+		Action: action,
+		Target: target,
+		Diff:   diff,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if l.onDuty != nil {
+		e.OnDuty = l.onDuty(e.Time)
+	}
+
+	key, kerr := entryKey(e.Time)
+	if kerr == nil {
+		kerr = l.cache.Set(key, &persist.Blob{Created: e.Time, AuditEntries: []*persist.AuditEntry{e}})
+	}
+	if kerr != nil {
+		klog.Errorf("audit %s %s by %s: %v", action, target, e.Actor, kerr)
+	}
+}
+
+// Entries returns audit entries from the last number of days, newest first
+func (l *Log) Entries(days int) []*persist.AuditEntry {
+	// Keys lists what the backend has stored, so wait for writes which are still in flight
+	if err := l.cache.Flush(); err != nil {
+		klog.Errorf("flush: %v", err)
+	}
+
+	ks, err := l.cache.Keys()
+	if err != nil {
+		klog.Errorf("keys: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	prefixes := []string{}
+	for i := 0; i < days; i++ {
+		prefixes = append(prefixes, dayPrefix(now.AddDate(0, 0, -i)))
+	}
+
+	es := []*persist.AuditEntry{}
+	for _, k := range ks {
+		for _, p := range prefixes {
+			if !strings.HasPrefix(k.Key, p) {
+				continue
+			}
+			// Entries are never changed once recorded, so a copy held in memory is current
+			if b := l.cache.Get(k.Key, time.Time{}); b != nil {
+				es = append(es, b.AuditEntries...)
+			}
+			break
+		}
+	}
+
+	sort.SliceStable(es, func(i, j int) bool { return es[i].Time.After(es[j].Time) })
+	return es
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	c, err := persist.New(persist.Config{Type: "memory"})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Initialize())
+
+	l := New(Config{Cache: c})
+	l.Record(context.Background(), "comment", "https://github.com/o/p/issues/1", "hello", nil)
+	l.Record(WithActor(context.Background(), "alice"), "edit", "https://github.com/o/p/issues/2", "+label:bug", fmt.Errorf("forbidden"))
+
+	es := l.Entries(1)
+	assert.Equal(t, 2, len(es))
+	assert.Equal(t, "alice", es[0].Actor)
+	assert.Equal(t, "forbidden", es[0].Error)
+	assert.Equal(t, SystemActor, es[1].Actor)
+	assert.Equal(t, "hello", es[1].Diff)
+}
+
+func TestReplicas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Two replicas sharing a backend, each with its own memory
+	logs := []*Log{}
+	for i := 0; i < 2; i++ {
+		c, err := persist.NewDisk(persist.Config{Path: dir})
+		assert.Nil(t, err)
+		assert.Nil(t, c.Initialize())
+		logs = append(logs, New(Config{Cache: c}))
+	}
+
+	logs[0].Record(context.Background(), "comment", "https://github.com/o/p/issues/1", "first", nil)
+	logs[1].Record(context.Background(), "comment", "https://github.com/o/p/issues/1", "second", nil)
+	logs[0].Record(context.Background(), "comment", "https://github.com/o/p/issues/1", "third", nil)
+
+	for _, l := range logs {
+		es := l.Entries(1)
+		assert.Equal(t, 3, len(es))
+		if len(es) == 3 {
+			assert.Equal(t, "third", es[0].Diff)
+			assert.Equal(t, "first", es[2].Diff)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cluster divides work between replicas which share a persist backend
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+	"k8s.io/klog/v2"
+)
+
+// keyPrefix is the prefix of the keys each replica announces itself with
+const keyPrefix = "cluster-member-"
+
+// expireAfter is how many heartbeats a replica may miss before its work is taken over by others
+const expireAfter = 3
+
+// Config is cluster configuration
+type Config struct {
+	// Cache is the backend shared by every replica
+	Cache persist.Cacher
+	// ID identifies this replica, defaulting to its hostname and process ID
+	ID string
+	// Interval is how often this replica announces itself, and looks for others
+	Interval time.Duration
+}
+
+// Cluster tracks the replicas sharing a persist backend, electing a leader and sharding repositories between them
+type Cluster struct {
+	cache    persist.Cacher
+	id       string
+	interval time.Duration
+
+	mu sync.RWMutex
+	// members are the IDs of live replicas, sorted, including this one
+	members []string
+}
+
+// New returns a cluster of one, until Heartbeat finds other replicas
+func New(cfg Config) *Cluster {
+	id := cfg.ID
+	if id == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "replica"
+		}
+		id = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return &Cluster{
+		cache:    cfg.Cache,
+		id:       id,
+		interval: cfg.Interval,
+		members:  []string{id},
+	}
+}
+
+// ID returns the ID of this replica
+func (c *Cluster) ID() string {
+	return c.id
+}
+
+// Heartbeat announces this replica, and refreshes which replicas are live
+func (c *Cluster) Heartbeat() error {
+	now := time.Now()
+	if err := c.cache.Set(keyPrefix+c.id, &persist.Blob{Created: now}); err != nil {
+		return fmt.Errorf("set: %w", err)
+	}
+
+	ks, err := c.cache.Keys()
+	if err != nil {
+		return fmt.Errorf("keys: %w", err)
+	}
+
+	cutoff := now.Add(-expireAfter * c.interval)
+	ms := []string{c.id}
+	for _, k := range ks {
+		if !strings.HasPrefix(k.Key, keyPrefix) {
+			continue
+		}
+		id := strings.TrimPrefix(k.Key, keyPrefix)
+		if id == c.id {
+			continue
+		}
+		// Liveness is judged by when a heartbeat was created, as not every backend refreshes when overwritten keys were saved
+		if c.cache.Get(k.Key, cutoff) == nil {
+			// Tidy up after replicas which have gone away
+			if c.cache.Get(k.Key, now.Add(-10*expireAfter*c.interval)) == nil {
+				if err := c.cache.Delete(k.Key); err != nil {
+					klog.Warningf("delete %s: %v", k.Key, err)
+				}
+			}
+			continue
+		}
+		ms = append(ms, id)
+	}
+	sort.Strings(ms)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if strings.Join(ms, ",") != strings.Join(c.members, ",") {
+		klog.Infof("cluster members changed from %v to %v (this replica: %s, leader: %s)", c.members, ms, c.id, ms[0])
+	}
+	c.members = ms
+	return nil
+}
+
+// Run announces this replica every interval, until the context is done
+func (c *Cluster) Run(ctx context.Context) {
+	if err := c.Heartbeat(); err != nil {
+		klog.Errorf("heartbeat: %v", err)
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Heartbeat(); err != nil {
+				klog.Errorf("heartbeat: %v", err)
+			}
+		}
+	}
+}
+
+// Members returns the IDs of live replicas, sorted
+func (c *Cluster) Members() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string{}, c.members...)
+}
+
+// Leader returns whether this replica is the leader, which is the live replica with the lowest ID
+func (c *Cluster) Leader() bool {
+	return c.Members()[0] == c.id
+}
+
+// Owner returns which replica is responsible for a key, such as a repository URL.
+// Rendezvous hashing is used, so that only the keys of a replica which joins or leaves change hands.
+func (c *Cluster) Owner(key string) string {
+	owner := ""
+	var best uint64
+	for _, m := range c.Members() {
+		h := fnv.New64a()
+		h.Write([]byte(m))
+		h.Write([]byte{0})
+		h.Write([]byte(key))
+		if w := mix(h.Sum64()); owner == "" || w > best {
+			owner = m
+			best = w
+		}
+	}
+	return owner
+}
+
+// mix scrambles the bits of a hash, as FNV alone barely distinguishes keys which differ only in their first bytes
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// Owns returns whether this replica is responsible for a key
+func (c *Cluster) Owns(key string) bool {
+	return c.Owner(key) == c.id
+}
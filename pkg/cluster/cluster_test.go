@@ -0,0 +1,136 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCache(t *testing.T) persist.Cacher {
+	c, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	return c
+}
+
+func TestHeartbeat(t *testing.T) {
+	c := newCache(t)
+	a := New(Config{Cache: c, ID: "a", Interval: time.Minute})
+	b := New(Config{Cache: c, ID: "b", Interval: time.Minute})
+
+	assert.True(t, b.Leader(), "a replica alone leads")
+
+	assert.NoError(t, a.Heartbeat())
+	assert.NoError(t, b.Heartbeat())
+	assert.NoError(t, a.Heartbeat())
+	assert.Equal(t, []string{"a", "b"}, a.Members())
+	assert.Equal(t, []string{"a", "b"}, b.Members())
+	assert.True(t, a.Leader())
+	assert.False(t, b.Leader())
+
+	// a stops announcing itself
+	assert.NoError(t, c.Set(keyPrefix+"a", &persist.Blob{Created: time.Now().Add(-expireAfter * time.Hour)}))
+	assert.NoError(t, b.Heartbeat())
+	assert.Equal(t, []string{"b"}, b.Members())
+	assert.True(t, b.Leader(), "the next replica takes over")
+}
+
+// firstSaved reports keys as saved when they were first written, as MySQL did before overwrites refreshed it
+type firstSaved struct {
+	persist.Cacher
+	saved map[string]time.Time
+}
+
+func (f *firstSaved) Set(key string, b *persist.Blob) error {
+	if _, ok := f.saved[key]; !ok {
+		f.saved[key] = time.Now()
+	}
+	return f.Cacher.Set(key, b)
+}
+
+func (f *firstSaved) Keys() ([]persist.KeyInfo, error) {
+	ks, err := f.Cacher.Keys()
+	for i := range ks {
+		ks[i].Saved = f.saved[ks[i].Key]
+	}
+	return ks, err
+}
+
+func TestHeartbeatStaleSaved(t *testing.T) {
+	c := &firstSaved{Cacher: newCache(t), saved: map[string]time.Time{}}
+	a := New(Config{Cache: c, ID: "a", Interval: time.Minute})
+	b := New(Config{Cache: c, ID: "b", Interval: time.Minute})
+
+	assert.NoError(t, a.Heartbeat())
+	assert.NoError(t, b.Heartbeat())
+
+	// Both replicas have been announcing themselves for a day
+	for k := range c.saved {
+		c.saved[k] = time.Now().Add(-24 * time.Hour)
+	}
+	assert.NoError(t, a.Heartbeat())
+	assert.NoError(t, b.Heartbeat())
+	assert.Equal(t, []string{"a", "b"}, a.Members())
+	assert.Equal(t, []string{"a", "b"}, b.Members())
+}
+
+func TestOwner(t *testing.T) {
+	c := newCache(t)
+	rs := []*Cluster{}
+	for _, id := range []string{"a", "b", "c"} {
+		r := New(Config{Cache: c, ID: id, Interval: time.Minute})
+		assert.NoError(t, r.Heartbeat())
+		rs = append(rs, r)
+	}
+	for _, r := range rs {
+		assert.NoError(t, r.Heartbeat())
+	}
+
+	owned := map[string]int{}
+	before := map[string]string{}
+	for i := 0; i < 300; i++ {
+		repo := fmt.Sprintf("https://github.com/org/repo%d", i)
+		n := 0
+		for _, r := range rs {
+			if r.Owns(repo) {
+				n++
+				owned[r.ID()]++
+			}
+		}
+		assert.Equal(t, 1, n, "%s has one owner", repo)
+		before[repo] = rs[0].Owner(repo)
+	}
+	for _, r := range rs {
+		assert.True(t, owned[r.ID()] > 50, "%s owns %d of 300 repos", r.ID(), owned[r.ID()])
+	}
+
+	// c leaves: only its repositories change hands
+	assert.NoError(t, c.Delete(keyPrefix+"c"))
+	assert.NoError(t, rs[0].Heartbeat())
+	for repo, owner := range before {
+		if owner != "c" {
+			assert.Equal(t, owner, rs[0].Owner(repo), repo)
+		}
+	}
+}
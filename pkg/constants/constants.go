@@ -24,8 +24,17 @@ const (
 	CreatedAtSortOption = "created_at"
 	DescDirectionOption = "desc"
 
-	GitHubTokenEnvVar = "GITHUB_TOKEN"
-	GitLabTokenEnvVar = "GITLAB_TOKEN"
+	GitHubTokenEnvVar          = "GITHUB_TOKEN"
+	GitLabTokenEnvVar          = "GITLAB_TOKEN"
+	BitbucketServerTokenEnvVar = "BITBUCKET_SERVER_TOKEN"
+	SourceHutTokenEnvVar       = "SOURCEHUT_TOKEN"
+	LaunchpadTokenEnvVar       = "LAUNCHPAD_TOKEN"
+	JiraTokenEnvVar            = "JIRA_TOKEN"
+	JiraUserEnvVar             = "JIRA_USER"
+
+	OIDCClientSecretEnvVar = "OIDC_CLIENT_SECRET"
+	SessionKeyEnvVar       = "SESSION_KEY"
+	SMTPPasswordEnvVar     = "SMTP_PASSWORD"
 
 	GitHubProviderName = "github"
 	GitLabProviderName = "gitlab"
@@ -35,6 +44,8 @@ const (
 	GitLabRateLimitRemainingHeader = "RateLimit-Remaining"
 	GitLabRateLimitResetHeader     = "RateLimit-Reset"
 
-	GitHubProviderHost = "github.com"
-	GitLabProviderHost = "gitlab.com"
+	GitHubProviderHost    = "github.com"
+	GitLabProviderHost    = "gitlab.com"
+	SourceHutProviderHost = "todo.sr.ht"
+	LaunchpadProviderHost = "bugs.launchpad.net"
 )
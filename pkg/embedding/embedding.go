@@ -0,0 +1,121 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package embedding fetches text embeddings from an external service
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/google/triage-party/pkg/secret"
+)
+
+// Config is how to configure a new embedding client
+type Config struct {
+	// URL is an endpoint which accepts OpenAI-style embedding requests
+	URL string
+	// Model is passed to the service, if set
+	Model string
+	// Token is an optional API token secret reference, sent as a bearer token
+	Token string
+}
+
+// Client fetches embeddings from an external service
+type Client struct {
+	url    string
+	model  string
+	token  string
+	client *http.Client
+}
+
+// New returns a new embedding client
+func New(cfg Config) *Client {
+	return &Client{
+		url:    cfg.URL,
+		model:  cfg.Model,
+		token:  cfg.Token,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+type request struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type response struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns an embedding vector for each text, in order
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(request{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.token != "" {
+		token, err := secret.Read(ctx, c.token)
+		if err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rb, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("post %s: %s: %s", c.url, resp.Status, rb)
+	}
+
+	r := &response{}
+	if err := json.Unmarshal(rb, r); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	if len(r.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(r.Data), len(texts))
+	}
+
+	vs := make([][]float64, len(texts))
+	for _, d := range r.Data {
+		if d.Index < 0 || d.Index >= len(vs) || vs[d.Index] != nil {
+			return nil, fmt.Errorf("unexpected embedding index: %d", d.Index)
+		}
+		vs[d.Index] = d.Embedding
+	}
+	return vs, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, "small", req.Model)
+
+		// Always two embeddings, out of order, as services may respond
+		resp := map[string]interface{}{"data": []map[string]interface{}{
+			{"index": 1, "embedding": []float64{0, 2}},
+			{"index": 0, "embedding": []float64{1, 0}},
+		}}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(Config{URL: srv.URL, Model: "small"})
+	vs, err := c.Embed(context.Background(), []string{"a", "bb"})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]float64{{1, 0}, {0, 2}}, vs)
+
+	_, err = c.Embed(context.Background(), []string{"a"})
+	assert.NotNil(t, err)
+}
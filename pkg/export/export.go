@@ -0,0 +1,176 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export streams evaluated conversations to external data warehouses
+package export
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// Record is a flattened rule membership, suitable for loading into BigQuery
+type Record struct {
+	Exported   time.Time `json:"exported"`
+	Collection string    `json:"collection"`
+	Rule       string    `json:"rule"`
+
+	URL          string    `json:"url"`
+	Organization string    `json:"organization"`
+	Project      string    `json:"project"`
+	ID           int       `json:"id"`
+	Type         string    `json:"type"`
+	State        string    `json:"state"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	Created      time.Time `json:"created"`
+	Updated      time.Time `json:"updated"`
+	ClosedAt     time.Time `json:"closed_at"`
+	Milestone    string    `json:"milestone"`
+	ReviewState  string    `json:"review_state"`
+
+	Labels    []string `json:"labels"`
+	Assignees []string `json:"assignees"`
+	Tags      []string `json:"tags"`
+
+	ReactionsTotal  int `json:"reactions_total"`
+	CommentsTotal   int `json:"comments_total"`
+	CommentersTotal int `json:"commenters_total"`
+
+	LatestMemberResponse time.Time `json:"latest_member_response"`
+	FirstMemberResponse  time.Time `json:"first_member_response"`
+	CurrentHoldHours     float64   `json:"current_hold_hours"`
+	AccumulatedHoldHours float64   `json:"accumulated_hold_hours"`
+}
+
+// Sink is a destination for exported records
+type Sink interface {
+	String() string
+	Write(ctx context.Context, collection string, rs []*Record) error
+}
+
+// Config is how to configure a new exporter
+type Config struct {
+	Sink Sink
+	// Interval is the minimum time between exports of a collection
+	Interval time.Duration
+}
+
+// Exporter periodically exports collection results to a sink
+type Exporter struct {
+	sink     Sink
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// New returns a new exporter
+func New(cfg Config) *Exporter {
+	return &Exporter{
+		sink:     cfg.Sink,
+		interval: cfg.Interval,
+		last:     map[string]time.Time{},
+	}
+}
+
+// Export writes the results of a collection to the sink, if the interval has passed
+func (e *Exporter) Export(ctx context.Context, r *triage.CollectionResult) {
+	if r == nil || r.Collection == nil || r.RuleResults == nil {
+		return
+	}
+
+	id := r.Collection.ID
+	e.mu.Lock()
+	if time.Since(e.last[id]) < e.interval {
+		e.mu.Unlock()
+		return
+	}
+	e.last[id] = time.Now()
+	e.mu.Unlock()
+
+	rs := Records(r, time.Now())
+	if err := e.sink.Write(ctx, id, rs); err != nil {
+		klog.Errorf("export %q to %s: %v", id, e.sink, err)
+		return
+	}
+	klog.Infof("exported %d %q records to %s", len(rs), id, e.sink)
+}
+
+// Records flattens a collection result into one record per rule membership
+func Records(r *triage.CollectionResult, now time.Time) []*Record {
+	rs := []*Record{}
+	for _, rr := range r.RuleResults {
+		for _, co := range rr.Items {
+			rec := record(co)
+			rec.Exported = now
+			rec.Collection = r.Collection.ID
+			rec.Rule = rr.Rule.ID
+			rs = append(rs, rec)
+		}
+	}
+	return rs
+}
+
+func record(co *hubbub.Conversation) *Record {
+	r := &Record{
+		URL:          co.URL,
+		Organization: co.Organization,
+		Project:      co.Project,
+		ID:           co.ID,
+		Type:         co.Type,
+		State:        co.State,
+		Title:        co.Title,
+		Author:       co.Author.GetLogin(),
+		Created:      co.Created,
+		Updated:      co.Updated,
+		ClosedAt:     co.ClosedAt,
+		Milestone:    co.Milestone.GetTitle(),
+		ReviewState:  co.ReviewState,
+
+		Labels:    []string{},
+		Assignees: []string{},
+		Tags:      []string{},
+
+		ReactionsTotal:  co.ReactionsTotal,
+		CommentsTotal:   co.CommentsTotal,
+		CommentersTotal: co.CommentersTotal,
+
+		LatestMemberResponse: co.LatestMemberResponse,
+		FirstMemberResponse:  co.FirstMemberResponse,
+		CurrentHoldHours:     co.CurrentHoldTime.Hours(),
+		AccumulatedHoldHours: co.AccumulatedHoldTime.Hours(),
+	}
+
+	for _, l := range co.Labels {
+		r.Labels = append(r.Labels, l.GetName())
+	}
+
+	for _, a := range co.Assignees {
+		r.Assignees = append(r.Assignees, a.GetLogin())
+	}
+
+	for t := range co.Tags {
+		r.Tags = append(r.Tags, t.ID)
+	}
+	sort.Strings(r.Tags)
+
+	return r
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/triage"
+)
+
+// Formats which a collection result may be written in
+const (
+	JSON     = "json"
+	CSV      = "csv"
+	Markdown = "md"
+)
+
+// Formats are the supported formats
+var Formats = []string{JSON, CSV, Markdown}
+
+// csvHeader are the columns of CSV output
+var csvHeader = []string{
+	"collection", "rule", "url", "organization", "project", "id", "type", "state", "title", "author",
+	"created", "updated", "closed_at", "milestone", "review_state", "labels", "assignees", "tags",
+	"reactions_total", "comments_total", "commenters_total",
+	"latest_member_response", "first_member_response", "current_hold_hours", "accumulated_hold_hours",
+}
+
+// CheckFormat returns an error if a format is not supported
+func CheckFormat(f string) error {
+	for _, ff := range Formats {
+		if f == ff {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, expected one of %v", f, Formats)
+}
+
+// Write writes a collection result in a format
+func Write(w io.Writer, format string, r *triage.CollectionResult, now time.Time) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(Records(r, now))
+	case CSV:
+		return writeCSV(w, Records(r, now))
+	case Markdown:
+		return writeMarkdown(w, r, now)
+	default:
+		return CheckFormat(format)
+	}
+}
+
+// writeCSV writes records as CSV, with a header row
+func writeCSV(w io.Writer, rs []*Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range rs {
+		row := []string{
+			r.Collection, r.Rule, r.URL, r.Organization, r.Project, strconv.Itoa(r.ID), r.Type, r.State, r.Title, r.Author,
+			csvTime(r.Created), csvTime(r.Updated), csvTime(r.ClosedAt), r.Milestone, r.ReviewState,
+			strings.Join(r.Labels, ";"), strings.Join(r.Assignees, ";"), strings.Join(r.Tags, ";"),
+			strconv.Itoa(r.ReactionsTotal), strconv.Itoa(r.CommentsTotal), strconv.Itoa(r.CommentersTotal),
+			csvTime(r.LatestMemberResponse), csvTime(r.FirstMemberResponse),
+			strconv.FormatFloat(r.CurrentHoldHours, 'f', 1, 64), strconv.FormatFloat(r.AccumulatedHoldHours, 'f', 1, 64),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvTime formats a time for CSV, leaving unset times empty
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// writeMarkdown writes a collection result as a Markdown table per rule
+func writeMarkdown(w io.Writer, r *triage.CollectionResult, now time.Time) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Collection.Name)
+	fmt.Fprintf(&b, "Generated %s\n", now.UTC().Format("2006-01-02 15:04 MST"))
+
+	for _, rr := range r.RuleResults {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", rr.Rule.Name, len(rr.Items))
+		if len(rr.Items) == 0 {
+			b.WriteString("No items.\n")
+			continue
+		}
+
+		b.WriteString("| Item | Title | Author | Age | Labels |\n")
+		b.WriteString("|------|-------|--------|-----|--------|\n")
+		for _, co := range rr.Items {
+			ls := []string{}
+			for _, l := range co.Labels {
+				ls = append(ls, l.GetName())
+			}
+			fmt.Fprintf(&b, "| [%s/%s#%d](%s) | %s | %s | %.0fd | %s |\n",
+				co.Organization, co.Project, co.ID, co.URL, mdEscape(co.Title), co.Author.GetLogin(),
+				now.Sub(co.Created).Hours()/24, mdEscape(strings.Join(ls, ", ")))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdEscape escapes text for use within a Markdown table cell
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(s)
+}
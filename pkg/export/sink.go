@@ -0,0 +1,110 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NewSink returns a sink for a destination: either an http(s) URL, or a local directory
+func NewSink(dest string) (Sink, error) {
+	if strings.HasPrefix(dest, "http://") || strings.HasPrefix(dest, "https://") {
+		return &HTTP{url: dest, client: &http.Client{Timeout: time.Minute}}, nil
+	}
+
+	dir := strings.TrimPrefix(dest, "file://")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+	return &Dir{path: dir}, nil
+}
+
+// jsonl encodes records as newline-delimited JSON
+func jsonl(rs []*Record) ([]byte, error) {
+	var bs bytes.Buffer
+	enc := json.NewEncoder(&bs)
+	for _, r := range rs {
+		if err := enc.Encode(r); err != nil {
+			return nil, err
+		}
+	}
+	return bs.Bytes(), nil
+}
+
+// Dir writes one JSONL file per export into a directory, such as a mounted bucket
+type Dir struct {
+	path string
+}
+
+func (d *Dir) String() string {
+	return d.path
+}
+
+// Write writes records to a new file
+func (d *Dir) Write(ctx context.Context, collection string, rs []*Record) error {
+	bs, err := jsonl(rs)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.jsonl", collection, time.Now().UTC().Format("20060102T150405Z"))
+	return ioutil.WriteFile(filepath.Join(d.path, name), bs, 0o644)
+}
+
+// HTTP posts newline-delimited JSON to a URL
+type HTTP struct {
+	url    string
+	client *http.Client
+}
+
+func (h *HTTP) String() string {
+	return h.url
+}
+
+// Write posts records to the URL
+func (h *HTTP) Write(ctx context.Context, collection string, rs []*Record) error {
+	bs, err := jsonl(rs)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(bs))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("X-Triage-Party-Collection", collection)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s returned %s: %s", h.url, resp.Status, body)
+	}
+	return nil
+}
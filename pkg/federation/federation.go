@@ -0,0 +1,275 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package federation rolls up the backlogs of several Triage Party instances
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/secret"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// SummaryPath is where each instance serves its summary
+const SummaryPath = "/summary"
+
+// Summary is the backlog of an instance
+type Summary struct {
+	Name      string    `json:"name"`
+	Generated time.Time `json:"generated"`
+	// SLOHours is how long items may wait for a member response
+	SLOHours float64 `json:"slo_hours"`
+	Open     int     `json:"open"`
+	// Breaches are open items which have waited longer than the SLO for a member response
+	Breaches     int                  `json:"breaches"`
+	OldestBreach *Breach              `json:"oldest_breach,omitempty"`
+	Collections  []*CollectionSummary `json:"collections"`
+}
+
+// CollectionSummary is the backlog of a collection
+type CollectionSummary struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Open     int    `json:"open"`
+	Breaches int    `json:"breaches"`
+}
+
+// Breach is an item which has waited longer than the SLO for a member response
+type Breach struct {
+	// Instance is the name of the instance the item was found on, when rolled up
+	Instance     string  `json:"instance,omitempty"`
+	Collection   string  `json:"collection"`
+	URL          string  `json:"url"`
+	Title        string  `json:"title"`
+	WaitingHours float64 `json:"waiting_hours"`
+}
+
+// Summarize summarizes the backlog of collection results
+func Summarize(name string, results []*triage.CollectionResult, slo time.Duration) *Summary {
+	s := &Summary{Name: name, Generated: time.Now(), SLOHours: slo.Hours()}
+	seen := map[string]bool{}
+
+	for _, cr := range results {
+		cs := &CollectionSummary{ID: cr.Collection.ID, Name: cr.Collection.Name}
+		inCollection := map[string]bool{}
+
+		for _, rr := range cr.RuleResults {
+			for _, co := range rr.Items {
+				if inCollection[co.URL] || !open(co) {
+					continue
+				}
+				inCollection[co.URL] = true
+				cs.Open++
+
+				breached := co.Tags[tag.Recv] && co.CurrentHoldTime > slo
+				if breached {
+					cs.Breaches++
+				}
+
+				if seen[co.URL] {
+					continue
+				}
+				seen[co.URL] = true
+				s.Open++
+				if !breached {
+					continue
+				}
+				s.Breaches++
+				if s.OldestBreach == nil || co.CurrentHoldTime.Hours() > s.OldestBreach.WaitingHours {
+					s.OldestBreach = &Breach{Collection: cr.Collection.Name, URL: co.URL, Title: co.Title, WaitingHours: co.CurrentHoldTime.Hours()}
+				}
+			}
+		}
+		s.Collections = append(s.Collections, cs)
+	}
+	return s
+}
+
+func open(co *hubbub.Conversation) bool {
+	return co.State == constants.OpenState || co.State == constants.OpenedState
+}
+
+// Instance is the last known state of a federated instance
+type Instance struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Summary is the last successfully fetched summary, if any
+	Summary *Summary  `json:"summary,omitempty"`
+	Checked time.Time `json:"checked"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Rollup is the combined backlog of all federated instances
+type Rollup struct {
+	Instances    []*Instance `json:"instances"`
+	Open         int         `json:"open"`
+	Breaches     int         `json:"breaches"`
+	OldestBreach *Breach     `json:"oldest_breach,omitempty"`
+}
+
+// Config is how to configure a new federation
+type Config struct {
+	Party *triage.Party
+	// Interval is how often instances are polled
+	Interval time.Duration
+}
+
+// Federation periodically polls other instances
+type Federation struct {
+	party    *triage.Party
+	interval time.Duration
+	client   *http.Client
+
+	mu        sync.Mutex
+	instances map[string]*Instance
+}
+
+// New returns a new federation
+func New(cfg Config) *Federation {
+	return &Federation{
+		party:     cfg.Party,
+		interval:  cfg.Interval,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		instances: map[string]*Instance{},
+	}
+}
+
+// Run polls every instance, then again each interval until the context is cancelled
+func (f *Federation) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		f.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the summary of every configured instance
+func (f *Federation) poll(ctx context.Context) {
+	for _, fi := range f.party.Settings().Federation {
+		s, err := f.fetch(ctx, fi)
+
+		f.mu.Lock()
+		in := f.instances[fi.Name]
+		if in == nil || in.URL != fi.URL {
+			in = &Instance{Name: fi.Name, URL: fi.URL}
+			f.instances[fi.Name] = in
+		}
+		in.Checked = time.Now()
+		in.Error = ""
+		if err != nil {
+			klog.Warningf("federation %s: %v", fi.Name, err)
+			in.Error = err.Error()
+		} else {
+			in.Summary = s
+		}
+		f.mu.Unlock()
+	}
+}
+
+// fetch fetches the summary of an instance
+func (f *Federation) fetch(ctx context.Context, fi triage.FederatedInstance) (*Summary, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(fi.URL, "/")+SummaryPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if fi.Token != "" {
+		token, err := secret.Read(ctx, fi.Token)
+		if err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", req.URL, resp.Status)
+	}
+
+	s := &Summary{}
+	if err := json.NewDecoder(resp.Body).Decode(s); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return s, nil
+}
+
+// Rollup returns the combined backlog of the configured instances, those with the most breaches first
+func (f *Federation) Rollup() *Rollup {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ins := []*Instance{}
+	for _, fi := range f.party.Settings().Federation {
+		in := f.instances[fi.Name]
+		if in == nil || in.URL != fi.URL {
+			in = &Instance{Name: fi.Name, URL: fi.URL}
+		}
+		ins = append(ins, in)
+	}
+	return rollup(ins)
+}
+
+// rollup combines the summaries of instances
+func rollup(ins []*Instance) *Rollup {
+	r := &Rollup{Instances: ins}
+	for _, in := range ins {
+		s := in.Summary
+		if s == nil {
+			continue
+		}
+		r.Open += s.Open
+		r.Breaches += s.Breaches
+		if s.OldestBreach != nil && (r.OldestBreach == nil || s.OldestBreach.WaitingHours > r.OldestBreach.WaitingHours) {
+			b := *s.OldestBreach
+			b.Instance = in.Name
+			r.OldestBreach = &b
+		}
+	}
+
+	sort.SliceStable(r.Instances, func(i, j int) bool { return breaches(r.Instances[i]) > breaches(r.Instances[j]) })
+	return r
+}
+
+// breaches returns how many breaches an instance last reported
+func breaches(in *Instance) int {
+	if in.Summary == nil {
+		return 0
+	}
+	return in.Summary.Breaches
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/stretchr/testify/assert"
+)
+
+func conversation(url string, state string, waiting time.Duration, recv bool) *hubbub.Conversation {
+	return &hubbub.Conversation{URL: url, State: state, CurrentHoldTime: waiting, Tags: map[tag.Tag]bool{tag.Recv: recv}}
+}
+
+func TestSummarize(t *testing.T) {
+	a := conversation("a", constants.OpenState, 72*time.Hour, true)
+	b := conversation("b", constants.OpenState, 100*time.Hour, false)
+	c := conversation("c", constants.OpenState, 96*time.Hour, true)
+	d := conversation("d", constants.ClosedState, 200*time.Hour, true)
+
+	results := []*triage.CollectionResult{
+		{
+			Collection: &triage.Collection{ID: "daily", Name: "Daily"},
+			RuleResults: []*triage.RuleResult{
+				{Items: []*hubbub.Conversation{a, b, d}},
+				{Items: []*hubbub.Conversation{a}},
+			},
+		},
+		{
+			Collection:  &triage.Collection{ID: "weekly", Name: "Weekly"},
+			RuleResults: []*triage.RuleResult{{Items: []*hubbub.Conversation{a, c}}},
+		},
+	}
+
+	s := Summarize("team", results, 48*time.Hour)
+	assert.Equal(t, 3, s.Open)
+	assert.Equal(t, 2, s.Breaches)
+	assert.Equal(t, "c", s.OldestBreach.URL)
+	assert.Equal(t, "Weekly", s.OldestBreach.Collection)
+	assert.Equal(t, &CollectionSummary{ID: "daily", Name: "Daily", Open: 2, Breaches: 1}, s.Collections[0])
+	assert.Equal(t, &CollectionSummary{ID: "weekly", Name: "Weekly", Open: 2, Breaches: 2}, s.Collections[1])
+}
+
+func TestRollup(t *testing.T) {
+	r := rollup([]*Instance{
+		{Name: "down", Error: "timeout"},
+		{Name: "a", Summary: &Summary{Open: 5, Breaches: 1, OldestBreach: &Breach{URL: "x", WaitingHours: 50}}},
+		{Name: "b", Summary: &Summary{Open: 3, Breaches: 2, OldestBreach: &Breach{URL: "y", WaitingHours: 90}}},
+	})
+
+	assert.Equal(t, 8, r.Open)
+	assert.Equal(t, 3, r.Breaches)
+	assert.Equal(t, &Breach{Instance: "b", URL: "y", WaitingHours: 90}, r.OldestBreach)
+	assert.Equal(t, []string{"b", "a", "down"}, []string{r.Instances[0].Name, r.Instances[1].Name, r.Instances[2].Name})
+}
@@ -0,0 +1,322 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history records snapshots of rule results, for trends, reports and diffs
+package history
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// Config is how to configure a new history store
+type Config struct {
+	Cache persist.Cacher
+	// Granularity is the minimum time between snapshots of a rule
+	Granularity time.Duration
+	// Retention is how long snapshots are kept
+	Retention time.Duration
+}
+
+// Store is a history of rule results, stored in the persistence backend as one blob per rule per day
+type Store struct {
+	cache       persist.Cacher
+	granularity time.Duration
+	retention   time.Duration
+	mu          sync.Mutex
+}
+
+// New returns a new history store
+func New(cfg Config) *Store {
+	return &Store{
+		cache:       cfg.Cache,
+		granularity: cfg.Granularity,
+		retention:   cfg.Retention,
+	}
+}
+
+// dayKey returns the cache key for a day of snapshots of a rule
+func dayKey(rule string, t time.Time) string {
+	return fmt.Sprintf("history-%s-%s", rule, t.UTC().Format("2006-01-02"))
+}
+
+// Record is an updater hook, which snapshots the rules of a collection result
+func (s *Store) Record(ctx context.Context, r *triage.CollectionResult) {
+	if s == nil || r == nil {
+		return
+	}
+
+	for _, rr := range r.RuleResults {
+		t := rr.Created
+		if t.IsZero() {
+			t = r.Created
+		}
+
+		sn := &persist.Snapshot{
+			Time:       t,
+			Collection: r.Collection.ID,
+			Rule:       rr.Rule.ID,
+			Count:      len(rr.Items),
+			Items:      []string{},
+		}
+		for _, co := range rr.Items {
+			sn.Items = append(sn.Items, co.URL)
+		}
+		sort.Strings(sn.Items)
+
+		if err := s.add(sn); err != nil {
+			klog.Errorf("history for %q: %v", sn.Rule, err)
+		}
+	}
+}
+
+// add stores a snapshot, unless the rule was snapshotted within the granularity
+func (s *Store) add(sn *persist.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := dayKey(sn.Rule, sn.Time)
+	ss := []*persist.Snapshot{}
+	if b := s.cache.Get(key, time.Time{}); b != nil {
+		ss = b.Snapshots
+	} else if s.retention > 0 {
+		// The first snapshot of a day expires the days which have fallen out of retention
+		s.expire(sn.Rule, sn.Time.Add(-s.retention).AddDate(0, 0, -1))
+	}
+
+	if latest := s.latest(sn.Rule, sn.Time); latest != nil && sn.Time.Sub(latest.Time) < s.granularity {
+		return nil
+	}
+
+	ss = append(ss, sn)
+	return s.cache.Set(key, &persist.Blob{Created: sn.Time, Snapshots: ss})
+}
+
+// expire deletes the snapshots of a rule from days at or before a time
+func (s *Store) expire(rule string, t time.Time) {
+	ks, err := s.cache.Keys()
+	if err != nil {
+		klog.Warningf("expire %s: %v", rule, err)
+		return
+	}
+
+	prefix := fmt.Sprintf("history-%s-", rule)
+	cutoff := t.UTC().Format("2006-01-02")
+	for _, k := range ks {
+		if !strings.HasPrefix(k.Key, prefix) {
+			continue
+		}
+		// Rules whose IDs share a prefix, such as "bugs" and "bugs-p1", have keys which are not dates here
+		day := strings.TrimPrefix(k.Key, prefix)
+		if _, err := time.Parse("2006-01-02", day); err != nil || day > cutoff {
+			continue
+		}
+		if err := s.cache.Delete(k.Key); err != nil {
+			klog.Warningf("expire %s: %v", k.Key, err)
+		}
+	}
+}
+
+// latest returns the latest snapshot of a rule at or before a time, looking back as far as the previous day
+func (s *Store) latest(rule string, t time.Time) *persist.Snapshot {
+	for _, day := range []time.Time{t, t.AddDate(0, 0, -1)} {
+		b := s.cache.Get(dayKey(rule, day), time.Time{})
+		if b == nil {
+			continue
+		}
+		for i := len(b.Snapshots) - 1; i >= 0; i-- {
+			if !b.Snapshots[i].Time.After(t) {
+				return b.Snapshots[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Snapshots returns the snapshots of a rule between two times, oldest first
+func (s *Store) Snapshots(rule string, since time.Time, until time.Time) []*persist.Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.retention > 0 && until.Sub(since) > s.retention {
+		since = until.Add(-s.retention)
+	}
+
+	ss := []*persist.Snapshot{}
+	for day := since.UTC().Truncate(24 * time.Hour); !day.After(until); day = day.AddDate(0, 0, 1) {
+		b := s.cache.Get(dayKey(rule, day), time.Time{})
+		if b == nil {
+			continue
+		}
+		for _, sn := range b.Snapshots {
+			if !sn.Time.Before(since) && !sn.Time.After(until) {
+				ss = append(ss, sn)
+			}
+		}
+	}
+	return ss
+}
+
+// At returns the latest snapshot of a rule at or before a time, within a day of it
+func (s *Store) At(rule string, t time.Time) *persist.Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest(rule, t)
+}
+
+// Stays returns how long items remained in a rule between two times: the stays of items which left it, and of those still in it.
+// Items which were already in the rule at the first snapshot are ignored, as when they entered is unknown.
+func (s *Store) Stays(rule string, since time.Time, until time.Time) ([]time.Duration, []time.Duration) {
+	return stays(s.Snapshots(rule, since, until))
+}
+
+func stays(ss []*persist.Snapshot) ([]time.Duration, []time.Duration) {
+	left := []time.Duration{}
+	staying := []time.Duration{}
+	if len(ss) == 0 {
+		return left, staying
+	}
+
+	entered := map[string]time.Time{}
+	unknown := map[string]bool{}
+
+	for i, sn := range ss {
+		in := map[string]bool{}
+		for _, url := range sn.Items {
+			in[url] = true
+			if _, ok := entered[url]; !ok {
+				entered[url] = sn.Time
+				unknown[url] = i == 0
+			}
+		}
+
+		for url, t := range entered {
+			if in[url] {
+				continue
+			}
+			if !unknown[url] {
+				left = append(left, sn.Time.Sub(t))
+			}
+			delete(entered, url)
+			delete(unknown, url)
+		}
+	}
+
+	last := ss[len(ss)-1].Time
+	for url, t := range entered {
+		if !unknown[url] {
+			staying = append(staying, last.Sub(t))
+		}
+	}
+
+	sort.Slice(left, func(i, j int) bool { return left[i] < left[j] })
+	sort.Slice(staying, func(i, j int) bool { return staying[i] < staying[j] })
+	return left, staying
+}
+
+// backfill returns daily snapshots of a rule at midnight UTC, assuming conversations were in it from when they were created until they were closed
+func backfill(collection string, rule string, cs []*hubbub.Conversation, since time.Time, until time.Time) []*persist.Snapshot {
+	ss := []*persist.Snapshot{}
+	for day := since.UTC().Truncate(24 * time.Hour); !day.After(until); day = day.AddDate(0, 0, 1) {
+		sn := &persist.Snapshot{Time: day, Collection: collection, Rule: rule, Items: []string{}}
+		for _, co := range cs {
+			if co.Created.After(day) {
+				continue
+			}
+			if !co.ClosedAt.IsZero() && !co.ClosedAt.After(day) {
+				continue
+			}
+			sn.Items = append(sn.Items, co.URL)
+		}
+		sort.Strings(sn.Items)
+		sn.Count = len(sn.Items)
+		ss = append(ss, sn)
+	}
+	return ss
+}
+
+// Backfill stores daily snapshots of a rule reconstructed from conversations, for days which have no snapshots yet. It returns how many days were stored.
+func (s *Store) Backfill(collection string, rule string, cs []*hubbub.Conversation, since time.Time, until time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.retention > 0 && until.Sub(since) > s.retention {
+		since = until.Add(-s.retention)
+	}
+
+	stored := 0
+	for _, sn := range backfill(collection, rule, cs, since, until) {
+		key := dayKey(rule, sn.Time)
+		if b := s.cache.Get(key, time.Time{}); b != nil && len(b.Snapshots) > 0 {
+			continue
+		}
+		if err := s.cache.Set(key, &persist.Blob{Created: sn.Time, Snapshots: []*persist.Snapshot{sn}}); err != nil {
+			return stored, fmt.Errorf("set %s: %w", key, err)
+		}
+		stored++
+	}
+	return stored, nil
+}
+
+// Trend is a linear fit of how the number of items in a rule changes over time
+type Trend struct {
+	// PerDay is how many items the rule gains each day, negative when it is shrinking
+	PerDay float64
+	// Current is the fitted count at the latest snapshot
+	Current float64
+	// Samples is how many snapshots the fit is based on
+	Samples int
+}
+
+// minTrendSpan is the shortest period of snapshots which a trend is fitted to
+const minTrendSpan = 24 * time.Hour
+
+// Trend fits a trend to the snapshots of a rule between two times, returning nil if there are too few
+func (s *Store) Trend(rule string, since time.Time, until time.Time) *Trend {
+	return fit(s.Snapshots(rule, since, until))
+}
+
+// fit fits a least-squares line to snapshot counts
+func fit(ss []*persist.Snapshot) *Trend {
+	if len(ss) < 2 || ss[len(ss)-1].Time.Sub(ss[0].Time) < minTrendSpan {
+		return nil
+	}
+
+	start := ss[0].Time
+	var sx, sy, sxx, sxy float64
+	for _, sn := range ss {
+		x := sn.Time.Sub(start).Hours() / 24
+		y := float64(sn.Count)
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+
+	n := float64(len(ss))
+	slope := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+	intercept := (sy - slope*sx) / n
+	last := ss[len(ss)-1].Time.Sub(start).Hours() / 24
+
+	return &Trend{PerDay: slope, Current: intercept + slope*last, Samples: len(ss)}
+}
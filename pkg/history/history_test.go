@@ -0,0 +1,144 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/stretchr/testify/assert"
+)
+
+func result(t time.Time, urls ...string) *triage.CollectionResult {
+	rr := &triage.RuleResult{Rule: triage.Rule{ID: "bugs"}, Created: t}
+	for _, u := range urls {
+		rr.Items = append(rr.Items, &hubbub.Conversation{URL: u})
+	}
+	return &triage.CollectionResult{Collection: &triage.Collection{ID: "daily"}, RuleResults: []*triage.RuleResult{rr}, Created: t}
+}
+
+func TestRecord(t *testing.T) {
+	c, err := persist.New(persist.Config{Type: "memory"})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Initialize())
+
+	s := New(Config{Cache: c, Granularity: time.Hour, Retention: 30 * 24 * time.Hour})
+	start := time.Now().Add(-48 * time.Hour)
+
+	s.Record(context.Background(), result(start, "b", "a"))
+	// Within the granularity, so ignored
+	s.Record(context.Background(), result(start.Add(10*time.Minute), "a"))
+	s.Record(context.Background(), result(start.Add(2*time.Hour), "a"))
+	s.Record(context.Background(), result(start.Add(25*time.Hour), "a", "c"))
+
+	ss := s.Snapshots("bugs", start, time.Now())
+	assert.Equal(t, 3, len(ss))
+	assert.Equal(t, []string{"a", "b"}, ss[0].Items)
+	assert.Equal(t, 2, ss[0].Count)
+	assert.Equal(t, "daily", ss[0].Collection)
+	assert.Equal(t, []string{"a", "c"}, ss[2].Items)
+
+	assert.Equal(t, []string{"a"}, s.At("bugs", start.Add(3*time.Hour)).Items)
+	assert.Nil(t, s.At("bugs", start.Add(-time.Hour)))
+}
+
+func TestExpire(t *testing.T) {
+	c, err := persist.New(persist.Config{Type: "memory"})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Initialize())
+
+	s := New(Config{Cache: c, Granularity: time.Hour, Retention: 2 * 24 * time.Hour})
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	other := result(start, "x")
+	other.RuleResults[0].Rule.ID = "bugs-p1"
+
+	s.Record(context.Background(), other)
+	s.Record(context.Background(), result(start, "a"))
+	s.Record(context.Background(), result(start.AddDate(0, 0, 1), "a"))
+	// Nothing is recorded for several days, such as while the instance was down
+	s.Record(context.Background(), result(start.AddDate(0, 0, 5), "a"))
+
+	ks, err := c.Keys()
+	assert.Nil(t, err)
+	keys := []string{}
+	for _, k := range ks {
+		keys = append(keys, k.Key)
+	}
+	assert.ElementsMatch(t, []string{"history-bugs-2020-06-06", "history-bugs-p1-2020-06-01"}, keys)
+}
+
+func TestStays(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int, urls ...string) *persist.Snapshot {
+		return &persist.Snapshot{Time: start.Add(time.Duration(h) * time.Hour), Items: urls}
+	}
+
+	left, staying := stays([]*persist.Snapshot{
+		at(0, "a"),
+		at(1, "a", "b"),
+		at(3, "b", "c"),
+		at(6, "c", "d"),
+	})
+	// a entered before the first snapshot, so is ignored
+	assert.Equal(t, []time.Duration{5 * time.Hour}, left)
+	assert.Equal(t, []time.Duration{0, 3 * time.Hour}, staying)
+}
+
+func TestFit(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d int, count int) *persist.Snapshot {
+		return &persist.Snapshot{Time: start.AddDate(0, 0, d), Count: count}
+	}
+
+	tr := fit([]*persist.Snapshot{day(0, 10), day(1, 12), day(2, 14), day(3, 16)})
+	assert.InDelta(t, 2.0, tr.PerDay, 0.001)
+	assert.InDelta(t, 16.0, tr.Current, 0.001)
+	assert.Equal(t, 4, tr.Samples)
+
+	// Too short a span to fit
+	assert.Nil(t, fit([]*persist.Snapshot{day(0, 10)}))
+	assert.Nil(t, fit([]*persist.Snapshot{day(0, 10), {Time: start.Add(time.Hour), Count: 20}}))
+}
+
+func TestBackfill(t *testing.T) {
+	c, err := persist.New(persist.Config{Type: "memory"})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Initialize())
+
+	s := New(Config{Cache: c, Retention: 30 * 24 * time.Hour})
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cs := []*hubbub.Conversation{
+		{URL: "a", Created: day.Add(-time.Hour)},
+		{URL: "b", Created: day.Add(time.Hour), ClosedAt: day.Add(50 * time.Hour)},
+	}
+
+	// Days which already have snapshots are kept
+	s.Record(context.Background(), result(day.Add(25*time.Hour), "x"))
+
+	n, err := s.Backfill("daily", "bugs", cs, day, day.Add(72*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	ss := s.Snapshots("bugs", day, day.Add(72*time.Hour))
+	assert.Equal(t, 4, len(ss))
+	assert.Equal(t, []string{"a"}, ss[0].Items)
+	assert.Equal(t, []string{"x"}, ss[1].Items)
+	assert.Equal(t, []string{"a", "b"}, ss[2].Items)
+	assert.Equal(t, 1, ss[3].Count)
+}
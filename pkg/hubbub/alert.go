@@ -0,0 +1,140 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/logu"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+	"k8s.io/klog/v2"
+)
+
+// Alert is a type representing a security alert
+const Alert = "alert"
+
+// SearchAlerts searches for security alerts
+func (h *Engine) SearchAlerts(ctx context.Context, sp provider.SearchParams) ([]*Conversation, time.Time, error) {
+	sp.Filters = openByDefault(sp)
+	klog.V(1).Infof("Gathering raw data for %s/%s alerts %v - newer than %s",
+		sp.Repo.Organization, sp.Repo.Project, sp.Filters, logu.STime(sp.NewerThan))
+
+	sp.State = constants.OpenState
+	if NeedsClosed(sp.Filters) {
+		sp.State = ""
+	}
+
+	age := time.Now()
+	cs := []*Conversation{}
+	for _, kind := range provider.AlertKinds {
+		as, ts, err := h.cachedAlerts(ctx, sp, kind)
+		if err != nil {
+			// Alerts are often disabled, or not visible to the token
+			klog.Warningf("%s/%s %s alerts: %v", sp.Repo.Organization, sp.Repo.Project, kind, err)
+			continue
+		}
+		if ts.Before(age) {
+			age = ts
+		}
+
+		for _, a := range as {
+			labels := alertLabels(a)
+			if !preFetchMatch(a, labels, sp.Filters, h.calendar) {
+				continue
+			}
+
+			co := alertConversation(sp.Repo, a, labels, ts)
+			if !postFetchMatch(co, sp.Filters, h.calendar) || !postEventsMatch(co, sp.Filters, h.calendar) {
+				continue
+			}
+			cs = append(cs, co)
+		}
+	}
+
+	return cs, age, nil
+}
+
+func (h *Engine) cachedAlerts(ctx context.Context, sp provider.SearchParams, kind string) ([]*provider.Alert, time.Time, error) {
+	state := sp.State
+	if state == "" {
+		state = "all"
+	}
+	sp.SearchKey = fmt.Sprintf("%s-%s-alerts-%s", RepoKey(sp.Repo), kind, state)
+
+	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.Alerts, x.Created, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s", sp.SearchKey, logu.STime(sp.NewerThan))
+	start := time.Now()
+	as, resp, err := h.provider(sp.Repo.Host).AlertsList(ctx, sp, kind)
+	if err != nil {
+		if x := h.cache.Get(sp.SearchKey, time.Time{}); x != nil {
+			klog.Warningf("Retrieving stale results for %s due to error: %v", sp.SearchKey, err)
+			return x.Alerts, x.Created, nil
+		}
+		return nil, start, err
+	}
+	h.logRate(sp.Repo.Host, resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &persist.Blob{Alerts: as}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+	return as, start, nil
+}
+
+// alertLabels returns labels describing an alert, so that label filters may select by kind and severity
+func alertLabels(a *provider.Alert) []*provider.Label {
+	names := []string{a.Kind}
+	if a.Severity != "" {
+		names = append(names, "severity/"+a.Severity)
+	}
+	if a.Resolution != "" {
+		names = append(names, "resolution/"+a.Resolution)
+	}
+
+	ls := []*provider.Label{}
+	for _, n := range names {
+		n := n
+		ls = append(ls, &provider.Label{Name: &n})
+	}
+	return ls
+}
+
+// alertConversation returns a conversation representing a security alert
+func alertConversation(r provider.Repo, a *provider.Alert, labels []*provider.Label, age time.Time) *Conversation {
+	return &Conversation{
+		ID:              a.Number,
+		Organization:    r.Organization,
+		Project:         r.Project,
+		URL:             a.HTMLURL,
+		Title:           a.Title,
+		Type:            Alert,
+		State:           a.State,
+		Created:         a.CreatedAt,
+		Updated:         a.UpdatedAt,
+		ClosedAt:        a.ClosedAt,
+		Seen:            age,
+		Labels:          labels,
+		Reactions:       map[string]int{},
+		LatestResponses: map[string]time.Time{},
+		Tags:            map[tag.Tag]bool{},
+	}
+}
@@ -73,6 +73,8 @@ func (h *Engine) analyzeIssueWorker(ctx context.Context, jobs chan *provider.Iss
 }
 
 func (h *Engine) analyzeIssue(ctx context.Context, i *provider.Issue, sp provider.SearchParams, age time.Time, latestIssueUpdate time.Time) *Conversation {
+	sp.Repo = itemRepo(sp.Repo, i.GetHTMLURL())
+
 	// Workaround API inconsistency: issues use a list of labels, prs a list of label pointers
 	labels := []*provider.Label{}
 	for _, l := range i.Labels {
@@ -80,11 +82,17 @@ func (h *Engine) analyzeIssue(ctx context.Context, i *provider.Issue, sp provide
 		labels = append(labels, l)
 	}
 
-	if !preFetchMatch(i, labels, sp.Filters) {
+	if !preFetchMatch(i, labels, sp.Filters, h.calendar) {
 		klog.V(1).Infof("#%d - %q did not match item filter: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
 		return nil
 	}
 
+	// Listings cached before a transfer still include the issue in its old repository
+	if to := h.transferredTo(i.GetHTMLURL()); to != "" {
+		klog.V(1).Infof("#%d - %q has been transferred to %s", i.GetNumber(), i.GetTitle(), to)
+		return nil
+	}
+
 	klog.V(1).Infof("#%d - %q made it past pre-fetch: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
 
 	fetchComments := false
@@ -109,8 +117,16 @@ func (h *Engine) analyzeIssue(ctx context.Context, i *provider.Issue, sp provide
 	if len(co.Similar) > 0 {
 		co.Tags[tag.Similar] = true
 	}
+	co.FixedBy = h.FindFixes(co)
+	if len(co.FixedBy) > 0 {
+		co.Tags[tag.FixPending] = true
+	}
+	h.setHierarchy(co)
+	h.setBlockers(co)
+	h.setAreas(ctx, sp, co, false)
+	h.setScore(ctx, co)
 
-	if !postFetchMatch(co, sp.Filters) {
+	if !postFetchMatch(co, sp.Filters, h.calendar) {
 		klog.V(1).Infof("#%d - %q did not match post-fetch filter: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
 		return nil
 	}
@@ -133,6 +149,11 @@ func (h *Engine) analyzeIssue(ctx context.Context, i *provider.Issue, sp provide
 	}
 
 	h.addEvents(ctx, sp, co, timeline)
+	h.setTriageState(co)
+	if to := h.trackTransfer(co); to != "" {
+		klog.V(1).Infof("#%d - %q has been transferred to %s", i.GetNumber(), i.GetTitle(), to)
+		return nil
+	}
 
 	// Some labels are judged by linked PR state. Ensure that they are updated to the same timestamp.
 	fetchReviews := false
@@ -143,7 +164,7 @@ func (h *Engine) analyzeIssue(ctx context.Context, i *provider.Issue, sp provide
 	sp.Fetch = fetchReviews
 	co.PullRequestRefs = h.updateLinkedPRs(ctx, sp, co)
 
-	if !postEventsMatch(co, sp.Filters) {
+	if !postEventsMatch(co, sp.Filters, h.calendar) {
 		klog.V(1).Infof("#%d - %q did not match post-events filter: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
 		return nil
 	}
@@ -199,7 +220,9 @@ func (h *Engine) analyzePRWorker(ctx context.Context, jobs chan *provider.PullRe
 }
 
 func (h *Engine) analyzePR(ctx context.Context, pr *provider.PullRequest, sp provider.SearchParams, age time.Time) *Conversation {
-	if !preFetchMatch(pr, pr.Labels, sp.Filters) {
+	sp.Repo = itemRepo(sp.Repo, pr.GetHTMLURL())
+
+	if !preFetchMatch(pr, pr.Labels, sp.Filters, h.calendar) {
 		return nil
 	}
 
@@ -258,17 +281,24 @@ func (h *Engine) analyzePR(ctx context.Context, pr *provider.PullRequest, sp pro
 
 	co := h.PRSummary(ctx, sp, pr, comments, timeline, reviews)
 	co.Labels = pr.Labels
+	h.setTriageState(co)
 	co.Similar = h.FindSimilar(co)
 	if len(co.Similar) > 0 {
 		co.Tags[tag.Similar] = true
 	}
+	h.setBlockers(co)
+	h.setAreas(ctx, sp, co, NeedsAreas(sp.Filters) && !sp.NewerThan.IsZero())
+	h.setMergeReady(ctx, sp, co, reviews, NeedsMergeability(sp.Filters) && !sp.NewerThan.IsZero())
+	h.setCommitsBehind(ctx, sp, co, pr, NeedsCommitsBehind(sp.Filters) && !sp.NewerThan.IsZero())
+	h.setUnresolvedThreads(ctx, sp, co, NeedsUnresolvedThreads(sp.Filters) && !sp.NewerThan.IsZero())
+	h.setScore(ctx, co)
 
-	if !postFetchMatch(co, sp.Filters) {
+	if !postFetchMatch(co, sp.Filters, h.calendar) {
 		klog.V(4).Infof("PR #%d did not pass postFetchMatch with filter: %v", pr.GetNumber(), sp.Filters)
 		return nil
 	}
 
-	if !postEventsMatch(co, sp.Filters) {
+	if !postEventsMatch(co, sp.Filters, h.calendar) {
 		klog.V(1).Infof("#%d - %q did not match post-events filter: %s", pr.GetNumber(), pr.GetTitle(), sp.Filters)
 		return nil
 	}
@@ -353,7 +383,7 @@ func needTimeline(i provider.IItem, fs []provider.Filter, pr bool, hidden bool)
 				}
 			}
 		}
-		if f.Prioritized != "" {
+		if f.Prioritized != "" || f.TriageStateChanged != "" {
 			return true
 		}
 	}
@@ -0,0 +1,135 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// Area is a part of a project, such as a SIG, as declared by the labels of its OWNERS files
+type Area struct {
+	// Label is the label of the area, such as sig/node
+	Label string
+	// Organization and Project are the repository whose OWNERS files declare the area
+	Organization string
+	Project      string
+	// Dirs are the directories whose OWNERS files declare the area, where "" is the repository root
+	Dirs      []string
+	Approvers []string
+	Reviewers []string
+}
+
+// covers returns whether a path is within one of the directories of an area
+func (a Area) covers(path string) bool {
+	for _, d := range a.Dirs {
+		if d == "" || strings.HasPrefix(path, d+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsAreas returns whether the filters require the areas of pull requests, which cost a request each
+func NeedsAreas(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.AreaRegex() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// setAreas sets the areas of a conversation from its labels and, if fetched, the paths a pull request changes
+func (h *Engine) setAreas(ctx context.Context, sp provider.SearchParams, co *Conversation, fetchFiles bool) {
+	if len(h.areas) == 0 {
+		return
+	}
+
+	found := map[string]bool{}
+	for _, a := range h.areas {
+		for _, l := range co.Labels {
+			if sameLabel(l.GetName(), a.Label) {
+				found[a.Label] = true
+			}
+		}
+	}
+
+	if co.Type == PullRequest {
+		sp.IssueNumber = co.ID
+		sp.Fetch = fetchFiles
+		files, err := h.cachedPullRequestFiles(ctx, sp)
+		if err != nil {
+			klog.Errorf("files: %v", err)
+		}
+
+		for _, a := range h.areas {
+			if !strings.EqualFold(a.Organization, co.Organization) || !strings.EqualFold(a.Project, co.Project) {
+				continue
+			}
+			for _, f := range files {
+				if a.covers(f) {
+					found[a.Label] = true
+					break
+				}
+			}
+		}
+	}
+
+	co.Areas = []string{}
+	for l := range found {
+		co.Areas = append(co.Areas, l)
+	}
+	sort.Strings(co.Areas)
+}
+
+func (h *Engine) cachedPullRequestFiles(ctx context.Context, sp provider.SearchParams) ([]string, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%d-pr-files", RepoKey(sp.Repo), sp.IssueNumber)
+
+	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.PullRequestFiles, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s (fetch=%v)", sp.SearchKey, sp.NewerThan, sp.Fetch)
+	if !sp.Fetch {
+		return nil, nil
+	}
+	return h.updatePullRequestFiles(ctx, sp)
+}
+
+func (h *Engine) updatePullRequestFiles(ctx context.Context, sp provider.SearchParams) ([]string, error) {
+	klog.V(1).Infof("Downloading files for %s/%s #%d", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	start := time.Now()
+
+	files, resp, err := h.provider(sp.Repo.Host).PullRequestsListFiles(ctx, sp)
+	if err != nil {
+		return nil, err
+	}
+	h.logRate(sp.Repo.Host, resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &persist.Blob{PullRequestFiles: files}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+
+	klog.V(1).Infof("Downloaded %d files for #%d in %s", len(files), sp.IssueNumber, time.Since(start))
+	return files, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+	"k8s.io/klog/v2"
+)
+
+// maxBehindAge is how long how far a PR is behind is cached for, as base branches move on without the PR being updated
+const maxBehindAge = 12 * time.Hour
+
+// NeedsCommitsBehind returns whether the filters require how far pull requests are behind, which costs a request each
+func NeedsCommitsBehind(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.CommitsBehind != "" {
+			return true
+		}
+		if f.TagRegex() != nil {
+			if ok, _ := matchTag(map[tag.Tag]bool{tag.Behind: true}, f.TagRegex(), false); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setCommitsBehind sets how many commits an open PR is behind its base branch, if known
+func (h *Engine) setCommitsBehind(ctx context.Context, sp provider.SearchParams, co *Conversation, pr *provider.PullRequest, fetch bool) {
+	if co.Type != PullRequest || co.State == "closed" || co.State == "merged" {
+		return
+	}
+
+	sp.IssueNumber = co.ID
+	sp.Fetch = fetch
+	if oldest := time.Now().Add(-maxBehindAge); sp.NewerThan.Before(oldest) {
+		sp.NewerThan = oldest
+	}
+
+	n, ok, err := h.cachedCommitsBehind(ctx, sp, pr)
+	if err != nil {
+		klog.Errorf("commits behind: %v", err)
+	}
+	if !ok {
+		return
+	}
+
+	co.CommitsBehind = &n
+	if n > 0 {
+		co.Tags[tag.Behind] = true
+	}
+}
+
+func (h *Engine) cachedCommitsBehind(ctx context.Context, sp provider.SearchParams, pr *provider.PullRequest) (int, bool, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%d-pr-behind", RepoKey(sp.Repo), sp.IssueNumber)
+
+	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.CommitsBehind, true, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s (fetch=%v)", sp.SearchKey, sp.NewerThan, sp.Fetch)
+	if !sp.Fetch {
+		return 0, false, nil
+	}
+	return h.updateCommitsBehind(ctx, sp, pr)
+}
+
+func (h *Engine) updateCommitsBehind(ctx context.Context, sp provider.SearchParams, pr *provider.PullRequest) (int, bool, error) {
+	klog.V(1).Infof("Comparing %s/%s #%d to its base", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+
+	n, resp, err := h.provider(sp.Repo.Host).PullRequestsCommitsBehind(ctx, sp, pr)
+	if err != nil {
+		return 0, false, err
+	}
+	h.logRate(sp.Repo.Host, resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &persist.Blob{CommitsBehind: n}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+	return n, true, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/tag"
+)
+
+// BlockingKeyword is an item which another says it is blocked by, such as "blocked by #3402"
+const BlockingKeyword = "blocking-keyword"
+
+var (
+	// blockingRelRe parses blocking references, like "blocked by #3402" or "depends on org/repo#3402"
+	blockingRelRe = regexp.MustCompile(`(?i)\b(?:blocked\s+by|depends\s+on):?\s+(?:([\w.-]+)/([\w.-]+))?#(\d+)\b`)
+
+	// blockingAbsRe parses blocking references by URL, like "depends on https://github.com/org/repo/pull/3402"
+	blockingAbsRe = regexp.MustCompile(`(?i)\b(?:blocked\s+by|depends\s+on):?\s+https?://[^/\s]+/([\w.-]+)/([\w.-]+)/(?:-/)?(?:issues|pull|merge_requests)/(\d+)\b`)
+)
+
+// blockingRefs returns the items which a body says it is blocked by, other than the item itself
+func blockingRefs(co *Conversation, body string) []*RelatedConversation {
+	refs := []*RelatedConversation{}
+	for _, rc := range findRefs(co.Organization, co.Project, body, blockingRelRe, blockingAbsRe) {
+		if refKey(rc.Organization, rc.Project, rc.ID) == refKey(co.Organization, co.Project, co.ID) {
+			continue
+		}
+		rc.Reason = BlockingKeyword
+		refs = append(refs, rc)
+	}
+	return refs
+}
+
+// addBlockers records the items a body says a conversation is blocked by
+func addBlockers(co *Conversation, body string) {
+	seen := map[string]bool{}
+	for _, rc := range co.BlockedBy {
+		seen[refKey(rc.Organization, rc.Project, rc.ID)] = true
+	}
+
+	for _, rc := range blockingRefs(co, body) {
+		if k := refKey(rc.Organization, rc.Project, rc.ID); !seen[k] {
+			seen[k] = true
+			co.BlockedBy = append(co.BlockedBy, rc)
+		}
+	}
+}
+
+// setBlockers updates the blockers of a conversation with their latest known state, and tags it if any may still be open
+func (h *Engine) setBlockers(co *Conversation) {
+	for i, rc := range co.BlockedBy {
+		url, ok := h.refs.Load(refKey(rc.Organization, rc.Project, rc.ID))
+		if ok {
+			if bc := h.cachedConversation(url.(string)); bc != nil {
+				nrc := makeRelated(bc)
+				nrc.Reason = BlockingKeyword
+				co.BlockedBy[i] = nrc
+				continue
+			}
+		}
+
+		if rc.URL == "" {
+			rc.URL = blockerURL(co, rc)
+		}
+	}
+
+	if co.Blocked() {
+		co.Tags[tag.Blocked] = true
+	} else {
+		delete(co.Tags, tag.Blocked)
+	}
+}
+
+// blockerURL guesses the URL of a blocker which has not been seen, based on the URL of the item it blocks
+func blockerURL(co *Conversation, rc *RelatedConversation) string {
+	// "https://github.com/kubernetes/minikube/issues/7179"
+	parts := strings.Split(co.URL, "/")
+	if len(parts) < 3 {
+		return ""
+	}
+
+	base := strings.Join(parts[:3], "/")
+	if strings.Contains(co.URL, "/-/") {
+		return fmt.Sprintf("%s/%s/%s/-/issues/%d", base, rc.Organization, rc.Project, rc.ID)
+	}
+	return fmt.Sprintf("%s/%s/%s/issues/%d", base, rc.Organization, rc.Project, rc.ID)
+}
+
+// Blocked returns whether any of the items a conversation is blocked by are open, or of unknown state
+func (co *Conversation) Blocked() bool {
+	for _, rc := range co.BlockedBy {
+		if rc.State == "" || rc.State == constants.OpenState || rc.State == constants.OpenedState {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockingRefs(t *testing.T) {
+	co := &Conversation{Organization: "org", Project: "repo", ID: 5}
+	addBlockers(co, "Blocked by #12, and depends on: other/Repo#7.\nAlso depends on https://github.com/org/repo/pull/9 and blocked by #5\n```\nblocked by #99\n```")
+	addBlockers(co, "still blocked by #12")
+
+	keys := []string{}
+	for _, rc := range co.BlockedBy {
+		keys = append(keys, refKey(rc.Organization, rc.Project, rc.ID))
+	}
+	assert.Equal(t, []string{"org/repo#12", "other/repo#7", "org/repo#9"}, keys)
+
+	assert.True(t, co.Blocked())
+	for _, rc := range co.BlockedBy {
+		rc.State = "closed"
+	}
+	assert.False(t, co.Blocked())
+}
@@ -17,21 +17,36 @@ package hubbub
 import (
 	"fmt"
 
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/provider"
 )
 
+// RepoKey is the part of cache keys identifying a repository, or a GitLab group
+func RepoKey(r provider.Repo) string {
+	key := fmt.Sprintf("%s-%s", r.Organization, r.Project)
+	if r.AllProjects {
+		key = fmt.Sprintf("%s-%s-*", r.Organization, r.Group)
+	}
+
+	// A repository may have the same name on GitHub and another host, such as while migrating between them
+	if r.Host != "" && r.Host != constants.GitHubProviderHost {
+		key = r.Host + "-" + key
+	}
+	return key
+}
+
 // issueSearchKey is the cache key used for issues
 func issueSearchKey(sp provider.SearchParams) string {
 	if sp.UpdateAge > 0 {
-		return fmt.Sprintf("%s-%s-%s-issues-within-%.1fh", sp.Repo.Organization, sp.Repo.Project, sp.State, sp.UpdateAge.Hours())
+		return fmt.Sprintf("%s-%s-issues-within-%.1fh", RepoKey(sp.Repo), sp.State, sp.UpdateAge.Hours())
 	}
-	return fmt.Sprintf("%s-%s-%s-issues", sp.Repo.Organization, sp.Repo.Project, sp.State)
+	return fmt.Sprintf("%s-%s-issues", RepoKey(sp.Repo), sp.State)
 }
 
 // prSearchKey is the cache key used for prs
 func prSearchKey(sp provider.SearchParams) string {
 	if sp.UpdateAge > 0 {
-		return fmt.Sprintf("%s-%s-%s-prs-within-%.1fh", sp.Repo.Organization, sp.Repo.Project, sp.State, sp.UpdateAge.Hours())
+		return fmt.Sprintf("%s-%s-prs-within-%.1fh", RepoKey(sp.Repo), sp.State, sp.UpdateAge.Hours())
 	}
-	return fmt.Sprintf("%s-%s-%s-prs", sp.Repo.Organization, sp.Repo.Project, sp.State)
+	return fmt.Sprintf("%s-%s-prs", RepoKey(sp.Repo), sp.State)
 }
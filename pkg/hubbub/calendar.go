@@ -0,0 +1,271 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateFormat is the format of holiday dates
+const DateFormat = "2006-01-02"
+
+// businessRegexp matches business durations, such as +2bd (business days) or -4bh (business hours)
+var businessRegexp = regexp.MustCompile(`^([<>+-]?)(\d+)b([dh])$`)
+
+// Calendar describes working time, so that durations such as +2bd skip weekends
+type Calendar struct {
+	// Location is the time zone working hours are in
+	Location *time.Location
+	// Start and End are the working hours of a day, as offsets from midnight
+	Start time.Duration
+	End   time.Duration
+	// Days are the working days of the week
+	Days map[time.Weekday]bool
+	// Holidays are dates which are not worked, formatted as DateFormat
+	Holidays map[string]bool
+	// Absences are the dates each member is out of the office, formatted as DateFormat
+	Absences map[string]map[string]bool
+}
+
+// DefaultCalendar returns a calendar of full days, Monday to Friday, in UTC
+func DefaultCalendar() *Calendar {
+	return &Calendar{
+		Location: time.UTC,
+		Start:    0,
+		End:      24 * time.Hour,
+		Days: map[time.Weekday]bool{
+			time.Monday:    true,
+			time.Tuesday:   true,
+			time.Wednesday: true,
+			time.Thursday:  true,
+			time.Friday:    true,
+		},
+		Holidays: map[string]bool{},
+		Absences: map[string]map[string]bool{},
+	}
+}
+
+// weekdays maps abbreviated day names to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// NewCalendar returns a calendar for a time zone name, working hours such as "09:00" and "17:00", and day names such as "mon".
+// Empty values keep the defaults of DefaultCalendar.
+func NewCalendar(tz string, start string, end string, days []string) (*Calendar, error) {
+	c := DefaultCalendar()
+
+	if tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("timezone: %w", err)
+		}
+		c.Location = loc
+	}
+
+	var err error
+	if start != "" {
+		if c.Start, err = parseClock(start); err != nil {
+			return nil, fmt.Errorf("start: %w", err)
+		}
+	}
+	if end != "" {
+		if c.End, err = parseClock(end); err != nil {
+			return nil, fmt.Errorf("end: %w", err)
+		}
+	}
+	if c.End <= c.Start {
+		return nil, fmt.Errorf("end (%s) must be after start (%s)", end, start)
+	}
+
+	if len(days) > 0 {
+		c.Days = map[time.Weekday]bool{}
+		for _, d := range days {
+			wd, ok := weekdays[strings.ToLower(d)[:min(3, len(d))]]
+			if !ok {
+				return nil, fmt.Errorf("unknown day %q", d)
+			}
+			c.Days[wd] = true
+		}
+	}
+	return c, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// parseClock parses a time of day, such as 09:30 or 24:00
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%q is not formatted as HH:MM", s)
+	}
+
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("hours: %w", err)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("minutes: %w", err)
+	}
+
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+	if h < 0 || m < 0 || m > 59 || d > 24*time.Hour {
+		return 0, fmt.Errorf("%q is not a time of day", s)
+	}
+	return d, nil
+}
+
+// DayLength is the working time within a working day
+func (c *Calendar) DayLength() time.Duration {
+	return c.End - c.Start
+}
+
+// working returns whether a day is worked
+func (c *Calendar) working(day time.Time) bool {
+	return c.Days[day.Weekday()] && !c.Holidays[day.Format(DateFormat)]
+}
+
+// AddAbsence marks a member as out of the office on a date, formatted as DateFormat
+func (c *Calendar) AddAbsence(login string, date string) {
+	if c.Absences[login] == nil {
+		c.Absences[login] = map[string]bool{}
+	}
+	c.Absences[login][date] = true
+}
+
+// Away returns whether a member is out of the office at a time
+func (c *Calendar) Away(login string, t time.Time) bool {
+	if c == nil {
+		return false
+	}
+	return c.Absences[login][t.In(c.Location).Format(DateFormat)]
+}
+
+// Present returns the members of a set who are not out of the office at a time
+func (c *Calendar) Present(users map[string]bool, t time.Time) map[string]bool {
+	present := map[string]bool{}
+	for u := range users {
+		if !c.Away(u, t) {
+			present[u] = true
+		}
+	}
+	return present
+}
+
+// String describes the calendar
+func (c *Calendar) String() string {
+	days := []string{}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if c.Days[d] {
+			days = append(days, d.String()[:3])
+		}
+	}
+
+	holidays := []string{}
+	for h := range c.Holidays {
+		holidays = append(holidays, h)
+	}
+	sort.Strings(holidays)
+
+	away := []string{}
+	for login, dates := range c.Absences {
+		for d := range dates {
+			away = append(away, login+"@"+d)
+		}
+	}
+	sort.Strings(away)
+
+	return fmt.Sprintf("%s %s-%s %v holidays=%v away=%v", c.Location, c.Start, c.End, days, holidays, away)
+}
+
+// Elapsed returns the working time between two times
+func (c *Calendar) Elapsed(from time.Time, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+
+	from = from.In(c.Location)
+	to = to.In(c.Location)
+
+	total := time.Duration(0)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, c.Location)
+	for !day.After(to) {
+		if c.working(day) {
+			start := day.Add(c.Start)
+			end := day.Add(c.End)
+			if from.After(start) {
+				start = from
+			}
+			if to.Before(end) {
+				end = to
+			}
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+// parseBusinessDuration parses a business duration, returning the working time it represents
+func (c *Calendar) parseBusinessDuration(ds string) (time.Duration, bool, bool, bool) {
+	m := businessRegexp.FindStringSubmatch(ds)
+	if m == nil {
+		return 0, false, false, false
+	}
+
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return 0, false, false, false
+	}
+
+	d := time.Duration(n) * time.Hour
+	if m[3] == "d" {
+		d = time.Duration(n) * c.DayLength()
+	}
+
+	within := m[1] == "-" || m[1] == "<"
+	over := m[1] == "+" || m[1] == ">"
+	return d, within, over, true
+}
+
+// businessWallClock returns a generous estimate of the wall-clock time a business duration may span
+func businessWallClock(n int64, unit string) time.Duration {
+	days := n
+	if unit == "h" {
+		// Working days may be as short as a few hours
+		days = n/4 + 1
+	}
+	// Allow for weekends and holidays
+	return time.Duration(days*7/5+7) * 24 * time.Hour
+}
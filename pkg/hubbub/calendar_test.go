@@ -0,0 +1,36 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAway(t *testing.T) {
+	c := DefaultCalendar()
+	c.AddAbsence("alice", "2020-12-24")
+
+	day := time.Date(2020, 12, 24, 15, 0, 0, 0, time.UTC)
+	assert.True(t, c.Away("alice", day))
+	assert.False(t, c.Away("alice", day.AddDate(0, 0, 1)))
+	assert.False(t, c.Away("bob", day))
+	assert.Equal(t, map[string]bool{"bob": true}, c.Present(map[string]bool{"alice": true, "bob": true}, day))
+
+	var none *Calendar
+	assert.False(t, none.Away("alice", day))
+}
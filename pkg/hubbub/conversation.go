@@ -60,6 +60,28 @@ type Conversation struct {
 	LatestAssigneeResponse time.Time `json:"latest_assignee_response"`
 	LatestMemberResponse   time.Time `json:"latest_member_response"`
 
+	// FirstMemberResponse is when a member first responded to an item authored by a non-member
+	FirstMemberResponse time.Time `json:"first_member_response"`
+
+	// Review latency of pull requests
+	ReviewRequested time.Time `json:"review_requested"`
+	FirstReview     time.Time `json:"first_review"`
+	FirstReviewer   string    `json:"first_reviewer"`
+	Approved        time.Time `json:"approved"`
+	Approver        string    `json:"approver"`
+	Merged          time.Time `json:"merged"`
+
+	// ReadyForReview is when a draft PR was last marked ready for review
+	ReadyForReview time.Time `json:"ready_for_review"`
+	// LatestForcePush is when the PR branch was last force-pushed
+	LatestForcePush time.Time `json:"latest_force_push"`
+
+	// ReviewRequests is when each currently requested reviewer was asked for a review
+	ReviewRequests map[string]time.Time `json:"review_requests"`
+
+	// LatestResponses is when each commenter last responded
+	LatestResponses map[string]time.Time `json:"latest_responses"`
+
 	AccumulatedHoldTime time.Duration `json:"accumulated_hold_time"`
 	CurrentHoldTime     time.Duration `json:"current_hold_time"`
 
@@ -82,6 +104,10 @@ type Conversation struct {
 	ClosedAt              time.Time      `json:"closed_at"`
 	ClosedBy              *provider.User `json:"closed_by"`
 
+	// TasksCompleted and TasksTotal count the markdown task list items in the body
+	TasksCompleted int `json:"tasks_completed"`
+	TasksTotal     int `json:"tasks_total"`
+
 	TimelineTotal int `json:"timeline_total"`
 	ReviewsTotal  int `json:"reviews_total"`
 
@@ -92,6 +118,44 @@ type Conversation struct {
 
 	// Similar issues to this one
 	Similar []*RelatedConversation `json:"similar"`
+	// FixedBy are open pull requests which appear to address this issue
+	FixedBy []*RelatedConversation `json:"fixed_by,omitempty"`
+
+	// MergeableState is whether a PR can be merged, as reported by the provider, such as clean, unstable or dirty
+	MergeableState string `json:"mergeable_state,omitempty"`
+	// MergeReady is whether a PR would merge cleanly as is, and auto-merge is not enabled
+	MergeReady bool `json:"merge_ready,omitempty"`
+
+	// UnansweredMentions are the watched maintainers and teams recently mentioned, who have not responded since
+	UnansweredMentions []string `json:"unanswered_mentions,omitempty"`
+
+	// TransferredAt is when this issue was transferred from another repository, if it was
+	TransferredAt time.Time `json:"transferred_at,omitempty"`
+	// TransferredFrom is the URL this issue had before it was transferred, if it has been seen
+	TransferredFrom string `json:"transferred_from,omitempty"`
+
+	// CommitsBehind is how many commits a PR is behind its base branch, if known
+	CommitsBehind *int `json:"commits_behind,omitempty"`
+	// UnresolvedThreads is how many review threads of a PR are unresolved, if known
+	UnresolvedThreads *int `json:"unresolved_threads,omitempty"`
+
+	// TriageState is the name of the configured triage state this item is in, such as needs-info
+	TriageState string `json:"triage_state,omitempty"`
+	// TriageStateChanged is when this item entered its triage state
+	TriageStateChanged time.Time `json:"triage_state_changed,omitempty"`
+
+	// Score is the priority given by an external scoring service, if configured
+	Score *Score `json:"score,omitempty"`
+
+	// Areas are the labels of the OWNERS areas this item belongs to, such as sig/node
+	Areas []string `json:"areas,omitempty"`
+
+	// BlockedBy are the items this one says it is blocked by, or depends on
+	BlockedBy []*RelatedConversation `json:"blocked_by,omitempty"`
+
+	// Parent is the issue which tracks this one as a sub-issue, and Children are the sub-issues this one tracks
+	Parent   *RelatedConversation   `json:"parent,omitempty"`
+	Children []*RelatedConversation `json:"children,omitempty"`
 
 	Milestone *provider.Milestone `json:"milestone"`
 }
@@ -112,6 +176,10 @@ type RelatedConversation struct {
 	Updated     time.Time      `json:"updated"`
 	Seen        time.Time      `json:"seen"`
 	ReviewState string         `json:"review_state"`
+	// Reason is why the conversation is related, such as ClosingKeyword
+	Reason string `json:"reason,omitempty"`
+	// Score is how similar the conversation is, from 0 to 1, if it was found by similarity
+	Score float64 `json:"score,omitempty"`
 }
 
 func makeRelated(c *Conversation) *RelatedConversation {
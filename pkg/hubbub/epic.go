@@ -0,0 +1,187 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/logu"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// Epic is a type representing a GitLab epic, which groups issues across the projects of a group
+const Epic = "epic"
+
+// SearchEpics searches for the epics of a GitLab group
+func (h *Engine) SearchEpics(ctx context.Context, sp provider.SearchParams) ([]*Conversation, time.Time, error) {
+	if !sp.Repo.AllProjects {
+		return nil, time.Now(), fmt.Errorf("%s/%s is not a GitLab group: only groups have epics", sp.Repo.Organization, sp.Repo.Project)
+	}
+
+	sp.Filters = openByDefault(sp)
+	klog.V(1).Infof("Gathering raw data for %s epics %v - newer than %s", RepoKey(sp.Repo), sp.Filters, logu.STime(sp.NewerThan))
+
+	sp.State = constants.OpenedState
+	if NeedsClosed(sp.Filters) {
+		sp.State = ""
+	}
+
+	es, age, err := h.cachedEpics(ctx, sp)
+	if err != nil {
+		return nil, age, err
+	}
+
+	cs := []*Conversation{}
+	for _, e := range es {
+		if co := h.analyzeEpic(ctx, e, sp, age); co != nil {
+			cs = append(cs, co)
+		}
+	}
+	return cs, age, nil
+}
+
+// analyzeEpic returns a conversation for an epic, with its child issues, if it matches the filters
+func (h *Engine) analyzeEpic(ctx context.Context, e *provider.Issue, sp provider.SearchParams, age time.Time) *Conversation {
+	if !preFetchMatch(e, e.Labels, sp.Filters, h.calendar) {
+		klog.V(1).Infof("epic &%d - %q did not match item filter: %v", e.GetNumber(), e.GetTitle(), sp.Filters)
+		return nil
+	}
+
+	fetch := !sp.NewerThan.IsZero()
+	sp.IssueNumber = e.GetNumber()
+	sp.NewerThan = h.mtime(e)
+
+	sp.Fetch = fetch && needComments(e, sp.Filters) && e.GetComments() > 0
+	comments, err := h.cachedEpicComments(ctx, sp, e)
+	if err != nil {
+		klog.Errorf("epic comments: %v", err)
+	}
+
+	// Child issues are the rollup which makes an epic worth triaging, so they are always fetched
+	sp.Fetch = fetch
+	children, ok, err := h.cachedEpicIssues(ctx, sp)
+	if err != nil {
+		klog.Errorf("epic issues: %v", err)
+	}
+	if ok {
+		h.indexSubIssues(e, children)
+	}
+
+	co := h.IssueSummary(e, comments, age)
+	co.Type = Epic
+	co.Labels = e.Labels
+	h.setHierarchy(co)
+	h.setScore(ctx, co)
+
+	if !postFetchMatch(co, sp.Filters, h.calendar) || !postEventsMatch(co, sp.Filters, h.calendar) {
+		klog.V(1).Infof("epic &%d - %q did not match post-fetch filter: %v", e.GetNumber(), e.GetTitle(), sp.Filters)
+		return nil
+	}
+	return co
+}
+
+func (h *Engine) cachedEpics(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, time.Time, error) {
+	state := sp.State
+	if state == "" {
+		state = "all"
+	}
+	sp.SearchKey = fmt.Sprintf("%s-%s-epics", RepoKey(sp.Repo), state)
+
+	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.Issues, x.Created, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s", sp.SearchKey, logu.STime(sp.NewerThan))
+	start := time.Now()
+	es, resp, err := h.provider(sp.Repo.Host).EpicsList(ctx, sp)
+	if err != nil {
+		if x := h.cache.Get(sp.SearchKey, time.Time{}); x != nil {
+			klog.Warningf("Retrieving stale results for %s due to error: %v", sp.SearchKey, err)
+			return x.Issues, x.Created, nil
+		}
+		return nil, start, err
+	}
+	h.logRate(sp.Repo.Host, resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &persist.Blob{Issues: es}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+	return es, start, nil
+}
+
+func (h *Engine) cachedEpicComments(ctx context.Context, sp provider.SearchParams, e *provider.Issue) ([]*provider.IssueComment, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%d-epic-comments", RepoKey(sp.Repo), sp.IssueNumber)
+
+	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.IssueComments, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s (fetch=%v)", sp.SearchKey, logu.STime(sp.NewerThan), sp.Fetch)
+	if !sp.Fetch {
+		return nil, nil
+	}
+
+	cs, resp, err := h.provider(sp.Repo.Host).EpicsListComments(ctx, sp, e)
+	if err != nil {
+		return nil, err
+	}
+	h.logRate(sp.Repo.Host, resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &persist.Blob{IssueComments: cs}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+	return cs, nil
+}
+
+// cachedEpicIssues returns the child issues of an epic, and whether they are known
+func (h *Engine) cachedEpicIssues(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, bool, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%d-epic-issues", RepoKey(sp.Repo), sp.IssueNumber)
+
+	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.Issues, true, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s (fetch=%v)", sp.SearchKey, logu.STime(sp.NewerThan), sp.Fetch)
+	if !sp.Fetch {
+		return nil, false, nil
+	}
+
+	is, resp, err := h.provider(sp.Repo.Host).EpicsListIssues(ctx, sp)
+	if err != nil {
+		return nil, false, err
+	}
+	h.logRate(sp.Repo.Host, resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &persist.Blob{Issues: is}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+	return is, true, nil
+}
+
+// ChildrenClosed returns how many of the children of a conversation are closed
+func (co *Conversation) ChildrenClosed() int {
+	n := 0
+	for _, c := range co.Children {
+		if c.State == constants.ClosedState {
+			n++
+		}
+	}
+	return n
+}
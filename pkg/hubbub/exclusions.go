@@ -0,0 +1,106 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+	"k8s.io/klog/v2"
+)
+
+// exclusionsKey is the cache key of the pairs of items which are never similar
+const exclusionsKey = "similarity-exclusions"
+
+// pairKey returns an order-independent key for a pair of URLs
+func pairKey(a string, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return a + " " + b
+}
+
+// loadExclusions loads the pairs of items which are never similar
+func (h *Engine) loadExclusions() {
+	h.exclusionOnce.Do(func() {
+		h.exclusionMu.Lock()
+		defer h.exclusionMu.Unlock()
+
+		h.exclusions = map[string]bool{}
+		if h.cache == nil {
+			return
+		}
+		if b := h.cache.Get(exclusionsKey, time.Time{}); b != nil {
+			for _, x := range b.SimilarityExclusions {
+				h.exclusions[pairKey(x.A, x.B)] = true
+			}
+		}
+	})
+}
+
+// excludedPair returns whether two items have been marked as never similar
+func (h *Engine) excludedPair(a string, b string) bool {
+	h.loadExclusions()
+
+	h.exclusionMu.RLock()
+	defer h.exclusionMu.RUnlock()
+	return h.exclusions[pairKey(a, b)]
+}
+
+// excludedTitle returns whether a title is never similar to anything
+func (h *Engine) excludedTitle(title string) bool {
+	for _, re := range h.excludeTitles {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludeSimilar marks every pair of a list of items as never similar, persisting it across restarts.
+// Similar lists reflect the change once the items are next analyzed.
+func (h *Engine) ExcludeSimilar(urls []string, actor string) error {
+	if len(urls) < 2 {
+		return fmt.Errorf("at least two items are required")
+	}
+
+	h.loadExclusions()
+	h.exclusionMu.Lock()
+	defer h.exclusionMu.Unlock()
+
+	xs := []*persist.SimilarityExclusion{}
+	if b := h.cache.Get(exclusionsKey, time.Time{}); b != nil {
+		xs = b.SimilarityExclusions
+	}
+
+	now := time.Now()
+	for i, a := range urls {
+		for _, b := range urls[i+1:] {
+			k := pairKey(a, b)
+			if a == b || h.exclusions[k] {
+				continue
+			}
+			h.exclusions[k] = true
+			xs = append(xs, &persist.SimilarityExclusion{A: a, B: b, Actor: actor, Time: now})
+		}
+	}
+
+	if err := h.cache.Set(exclusionsKey, &persist.Blob{Created: now, SimilarityExclusions: xs}); err != nil {
+		return fmt.Errorf("set: %w", err)
+	}
+	klog.Infof("%s marked %v as never similar", actor, urls)
+	return nil
+}
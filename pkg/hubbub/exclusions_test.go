@@ -0,0 +1,41 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludeSimilar(t *testing.T) {
+	c, err := persist.New(persist.Config{Type: "memory"})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Initialize())
+
+	e := New(Config{Cache: c, ExcludeTitles: []string{`^Weekly flaky test report`}})
+	assert.Nil(t, e.ExcludeSimilar([]string{"a", "b", "c"}, "alice"))
+	assert.True(t, e.excludedPair("b", "a"))
+	assert.True(t, e.excludedPair("a", "c"))
+	assert.False(t, e.excludedPair("a", "d"))
+	assert.NotNil(t, e.ExcludeSimilar([]string{"a"}, "alice"))
+
+	// Exclusions persist across engines
+	assert.True(t, New(Config{Cache: c}).excludedPair("c", "b"))
+
+	assert.True(t, e.excludedTitle("Weekly flaky test report: 2026-10-12"))
+	assert.False(t, e.excludedTitle("Flaky test in weekly job"))
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/triage-party/pkg/provider"
+)
+
+const (
+	// maxBodyTerms is how many words of a body are compared
+	maxBodyTerms = 500
+	// bodyKeyTerms is how many of the rarest words of a body are used to find items with similar bodies
+	bodyKeyTerms = 10
+)
+
+// commentRe matches HTML comments, which issue templates are full of
+var commentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// SimilarityWeights are how much the title, body and labels of items count for when comparing them
+type SimilarityWeights struct {
+	Title  float64
+	Body   float64
+	Labels float64
+}
+
+// titleOnly returns whether only titles are compared
+func (w SimilarityWeights) titleOnly() bool {
+	return w.Body == 0 && w.Labels == 0
+}
+
+// fieldDoc is what is compared of an item besides its title
+type fieldDoc struct {
+	// title is the normalized title of the item
+	title  string
+	terms  []string
+	labels map[string]bool
+}
+
+// bodyTerms returns the normalized words of a body, without code, comments or common words
+func bodyTerms(body string) []string {
+	body = codeRe.ReplaceAllString(body, " ")
+	body = commentRe.ReplaceAllString(body, " ")
+
+	terms := []string{}
+	for _, word := range strings.Fields(body) {
+		word = strings.ToLower(nonLetter.ReplaceAllString(word, ""))
+		if len(word) < 2 || removeWords[word] {
+			continue
+		}
+		terms = append(terms, word)
+		if len(terms) == maxBodyTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// weightsFor returns the similarity weights for an item, by URL
+func (h *Engine) weightsFor(url string) SimilarityWeights {
+	best := ""
+	w := h.weights
+	for repo, pw := range h.projectWeights {
+		repo = strings.TrimSuffix(repo, "/")
+		if strings.HasPrefix(url, repo+"/") && len(repo) > len(best) {
+			best = repo
+			w = pw
+		}
+	}
+	return w
+}
+
+// weighted returns whether anything besides titles is ever compared
+func (h *Engine) weighted() bool {
+	if !h.weights.titleOnly() {
+		return true
+	}
+	for _, w := range h.projectWeights {
+		if !w.titleOnly() {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFields indexes the body and labels of an item
+func (h *Engine) updateFields(rawTitle string, url string, body string, labels []*provider.Label) {
+	if h.MinSimilarity == 0 || !h.weighted() || h.excludedTitle(rawTitle) {
+		return
+	}
+
+	d := &fieldDoc{title: normalizeTitle(rawTitle), terms: bodyTerms(body), labels: map[string]bool{}}
+	for _, l := range labels {
+		d.labels[l.GetName()] = true
+	}
+
+	h.fieldMu.Lock()
+	defer h.fieldMu.Unlock()
+
+	if old, ok := h.fieldDocs[url]; ok {
+		for _, t := range uniqueTerms(old.terms) {
+			h.bodyURLs[t] = without(h.bodyURLs[t], url)
+			h.bodyTermDocs[t]--
+		}
+		h.bodyDocs--
+	}
+
+	for _, t := range uniqueTerms(d.terms) {
+		h.bodyURLs[t] = append(h.bodyURLs[t], url)
+		h.bodyTermDocs[t]++
+	}
+	h.bodyDocs++
+	h.fieldDocs[url] = d
+}
+
+// uniqueTerms returns words without repeats
+func uniqueTerms(ts []string) []string {
+	seen := map[string]bool{}
+	us := []string{}
+	for _, t := range ts {
+		if !seen[t] {
+			seen[t] = true
+			us = append(us, t)
+		}
+	}
+	return us
+}
+
+// bodyMatches returns the normalized titles of items which share one of the rarest words of an item's body, by URL
+func (h *Engine) bodyMatches(url string) map[string]string {
+	h.fieldMu.Lock()
+	defer h.fieldMu.Unlock()
+
+	ms := map[string]string{}
+	d, ok := h.fieldDocs[url]
+	if !ok {
+		return ms
+	}
+
+	ts := uniqueTerms(d.terms)
+	sort.SliceStable(ts, func(i, j int) bool { return h.bodyTermDocs[ts[i]] < h.bodyTermDocs[ts[j]] })
+	if len(ts) > bodyKeyTerms {
+		ts = ts[:bodyKeyTerms]
+	}
+
+	for _, t := range ts {
+		// Words in most bodies are template boilerplate, and match everything
+		if n := h.bodyTermDocs[t]; n > 2 && n > h.bodyDocs/2 {
+			continue
+		}
+		for _, u := range h.bodyURLs[t] {
+			if u != url {
+				ms[u] = h.fieldDocs[u].title
+			}
+		}
+	}
+	return ms
+}
+
+// fieldSimilarity returns how similar two items are, weighing their titles, bodies and labels
+func (h *Engine) fieldSimilarity(title string, url string, otherTitle string, otherURL string) float64 {
+	ts := h.similarity(title, otherTitle)
+	w := h.weightsFor(url)
+	if w.titleOnly() {
+		return ts
+	}
+
+	h.fieldMu.Lock()
+	defer h.fieldMu.Unlock()
+
+	total := w.Title
+	sum := w.Title * ts
+
+	a, b := h.fieldDocs[url], h.fieldDocs[otherURL]
+	if a != nil && b != nil {
+		// Fields which either item lacks are left out, rather than counted as different
+		if w.Body > 0 && len(a.terms) > 0 && len(b.terms) > 0 {
+			total += w.Body
+			sum += w.Body * cosine(tfidf(a.terms, h.bodyTermDocs, h.bodyDocs), tfidf(b.terms, h.bodyTermDocs, h.bodyDocs))
+		}
+		if w.Labels > 0 && len(a.labels) > 0 && len(b.labels) > 0 {
+			total += w.Labels
+			sum += w.Labels * labelOverlap(a.labels, b.labels)
+		}
+	}
+
+	if total == 0 {
+		return ts
+	}
+	return sum / total
+}
+
+// labelOverlap returns the Jaccard index of two sets of labels
+func labelOverlap(a map[string]bool, b map[string]bool) float64 {
+	both := 0
+	for l := range a {
+		if b[l] {
+			both++
+		}
+	}
+	return float64(both) / float64(len(a)+len(b)-both)
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyTerms(t *testing.T) {
+	body := "<!-- Please describe the problem -->\n**Steps to reproduce:**\n1. Run `tp`\n```\nsome output\n```\nIt crashes!"
+	assert.Equal(t, []string{"steps", "reproduce", "run", "tp", "crashes"}, bodyTerms(body))
+}
+
+func TestFieldSimilarity(t *testing.T) {
+	e := New(Config{
+		MinSimilarity:  0.5,
+		ProjectWeights: map[string]SimilarityWeights{"https://github.com/o/templated/": {Title: 1, Body: 2, Labels: 1}},
+	})
+	assert.Equal(t, SimilarityWeights{Title: 1}, e.weightsFor("https://github.com/o/other/issues/1"))
+
+	bug := []*provider.Label{{Name: github.String("kind/bug")}}
+	e.updateFields("Problem", "https://github.com/o/templated/issues/1", "### What happened?\nThe kanban view renders blank columns\n### What did you expect?\nIt to work", bug)
+	e.updateFields("Help", "https://github.com/o/templated/issues/2", "### What happened?\nBlank columns rendered in kanban view\n### What did you expect?\nIt to work", bug)
+	e.updateFields("Issue", "https://github.com/o/templated/issues/3", "### What happened?\nLogin loops forever\n### What did you expect?\nIt to work", nil)
+
+	assert.Equal(t, map[string]string{"https://github.com/o/templated/issues/2": "help"}, e.bodyMatches("https://github.com/o/templated/issues/1"))
+
+	alike := e.fieldSimilarity("problem", "https://github.com/o/templated/issues/1", "help", "https://github.com/o/templated/issues/2")
+	unlike := e.fieldSimilarity("problem", "https://github.com/o/templated/issues/1", "issue", "https://github.com/o/templated/issues/3")
+	assert.Greater(t, alike, 0.5)
+	assert.Less(t, unlike, 0.5)
+}
@@ -0,0 +1,182 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// Reasons a pull request is thought to address an issue
+const (
+	// ClosingKeyword is a pull request which says it closes the issue, such as "fixes #3402"
+	ClosingKeyword = "closing-keyword"
+	// SimilarTitle is a pull request with a title similar to the issue
+	SimilarTitle = "similar-title"
+)
+
+var (
+	// closingRelRe parses closing references, like "fixes #3402" or "closes org/repo#3402"
+	closingRelRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+(?:([\w.-]+)/([\w.-]+))?#(\d+)\b`)
+
+	// closingAbsRe parses closing references by URL, like "fixes https://github.com/org/repo/issues/3402"
+	closingAbsRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+https?://[^/\s]+/([\w.-]+)/([\w.-]+)/(?:-/)?issues/(\d+)\b`)
+)
+
+// refKey returns the key an issue is indexed by
+func refKey(org string, project string, id int) string {
+	return strings.ToLower(fmt.Sprintf("%s/%s#%d", org, project, id))
+}
+
+// closingRefs returns the keys of issues which a pull request body says it closes
+func closingRefs(org string, project string, body string) []string {
+	keys := []string{}
+	for _, rc := range findRefs(org, project, body, closingRelRe, closingAbsRe) {
+		keys = append(keys, refKey(rc.Organization, rc.Project, rc.ID))
+	}
+	return keys
+}
+
+// findRefs returns the items referenced by matches of regular expressions capturing an optional organization, project, and number
+func findRefs(org string, project string, body string, res ...*regexp.Regexp) []*RelatedConversation {
+	body = codeRe.ReplaceAllString(body, "<code></code>")
+
+	refs := []*RelatedConversation{}
+	seen := map[string]bool{}
+	for _, re := range res {
+		for _, m := range re.FindAllStringSubmatch(body, -1) {
+			o, p := m[1], m[2]
+			if o == "" {
+				o, p = org, project
+			}
+			id, err := strconv.Atoi(m[3])
+			if err != nil {
+				continue
+			}
+			if k := refKey(o, p, id); !seen[k] {
+				seen[k] = true
+				refs = append(refs, &RelatedConversation{Organization: o, Project: p, ID: id})
+			}
+		}
+	}
+	return refs
+}
+
+// pullRequestRef returns a reference to a pull request, without creating a conversation for it
+func pullRequestRef(pr *provider.PullRequest, reason string) *RelatedConversation {
+	// "https://github.com/kubernetes/minikube/pull/7179"
+	parts := strings.Split(pr.GetHTMLURL(), "/")
+	rc := &RelatedConversation{
+		ID:      pr.GetNumber(),
+		URL:     pr.GetHTMLURL(),
+		Title:   pr.GetTitle(),
+		Author:  pr.GetUser(),
+		Type:    PullRequest,
+		State:   pr.GetState(),
+		Created: pr.GetCreatedAt(),
+		Updated: pr.GetUpdatedAt(),
+		Reason:  reason,
+	}
+	if len(parts) > 4 {
+		rc.Organization = parts[3]
+		rc.Project = parts[4]
+	}
+	return rc
+}
+
+// updateFixes indexes which open pull requests may address which issues, meant for background use
+func (h *Engine) updateFixes(prs []*provider.PullRequest) {
+	h.fixMu.Lock()
+	defer h.fixMu.Unlock()
+
+	for _, pr := range prs {
+		url := pr.GetHTMLURL()
+		open := pr.GetState() == constants.OpenState || pr.GetState() == constants.OpenedState
+
+		for _, k := range h.fixKeys[url] {
+			delete(h.fixes[k], url)
+			if len(h.fixes[k]) == 0 {
+				delete(h.fixes, k)
+			}
+		}
+		delete(h.fixKeys, url)
+		delete(h.openPRs, url)
+
+		if !open {
+			continue
+		}
+
+		h.openPRs[url] = pullRequestRef(pr, SimilarTitle)
+		rc := pullRequestRef(pr, ClosingKeyword)
+		for _, k := range closingRefs(rc.Organization, rc.Project, pr.GetBody()) {
+			if h.fixes[k] == nil {
+				h.fixes[k] = map[string]*RelatedConversation{}
+			}
+			h.fixes[k][url] = rc
+			h.fixKeys[url] = append(h.fixKeys[url], k)
+		}
+	}
+}
+
+// FindFixes locates open pull requests which appear to address an issue
+func (h *Engine) FindFixes(co *Conversation) []*RelatedConversation {
+	if co.Type != Issue {
+		return nil
+	}
+
+	h.fixMu.Lock()
+	defer h.fixMu.Unlock()
+
+	found := []*RelatedConversation{}
+	added := map[string]bool{}
+	for url, rc := range h.fixes[refKey(co.Organization, co.Project, co.ID)] {
+		found = append(found, rc)
+		added[url] = true
+	}
+
+	if h.MinSimilarity > 0 && !h.excludedTitle(co.Title) {
+		if tres, ok := h.similarTitles.Load(normalizeTitle(co.Title)); ok {
+			for _, ot := range tres.([]string) {
+				ures, ok := h.titleToURLs.Load(ot)
+				if !ok {
+					continue
+				}
+				for _, url := range ures.([]string) {
+					rc := h.openPRs[url]
+					if rc == nil || added[url] || !h.inScope(co.Organization, co.Project, rc.Organization, rc.Project) || h.excludedPair(co.URL, url) {
+						continue
+					}
+					found = append(found, rc)
+					added[url] = true
+				}
+			}
+		}
+	}
+
+	// Closing keywords first, as they are certain
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Reason != found[j].Reason {
+			return found[i].Reason == ClosingKeyword
+		}
+		return found[i].ID < found[j].ID
+	})
+	return found
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosingRefs(t *testing.T) {
+	body := "Fixes #12, closes other/Repo#7 and\nresolves: https://github.com/org/repo/issues/9.\n\nSee #3, fixes #12 again\n```\nfixes #99\n```"
+	assert.Equal(t, []string{"org/repo#12", "other/repo#7", "org/repo#9"}, closingRefs("org", "repo", body))
+	assert.Empty(t, closingRefs("org", "repo", "prefixes #4, mentions #5"))
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// NeedsHierarchy returns whether the filters require sub-issue relationships
+func NeedsHierarchy(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.HasParent != "" || f.HasChildren != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// issueRelated returns a reference to an issue, without creating a conversation for it
+func issueRelated(i *provider.Issue) *RelatedConversation {
+	// "https://github.com/kubernetes/minikube/issues/7179"
+	parts := strings.Split(i.GetHTMLURL(), "/")
+	rc := &RelatedConversation{
+		ID:      i.GetNumber(),
+		URL:     i.GetHTMLURL(),
+		Title:   i.GetTitle(),
+		Author:  i.GetUser(),
+		Type:    Issue,
+		State:   i.GetState(),
+		Created: i.GetCreatedAt(),
+		Updated: i.GetUpdatedAt(),
+	}
+	if len(parts) > 4 {
+		rc.Organization = parts[3]
+		rc.Project = parts[4]
+	}
+	return rc
+}
+
+func (h *Engine) cachedSubIssues(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%d-sub-issues", RepoKey(sp.Repo), sp.IssueNumber)
+
+	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.Issues, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s (fetch=%v)", sp.SearchKey, sp.NewerThan, sp.Fetch)
+	if !sp.Fetch {
+		return nil, nil
+	}
+	return h.updateSubIssues(ctx, sp)
+}
+
+func (h *Engine) updateSubIssues(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, error) {
+	sp.ListOptions = provider.ListOptions{
+		PerPage: 100,
+	}
+
+	var all []*provider.Issue
+	for {
+		is, resp, err := h.provider(sp.Repo.Host).IssuesListSubIssues(ctx, sp)
+		if err != nil {
+			return nil, err
+		}
+		h.logRate(sp.Repo.Host, resp.Rate)
+
+		all = append(all, is...)
+		if resp.NextPage == 0 || sp.ListOptions.Page == resp.NextPage {
+			break
+		}
+		sp.ListOptions.Page = resp.NextPage
+	}
+
+	if err := h.cache.Set(sp.SearchKey, &persist.Blob{Issues: all}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+
+	return all, nil
+}
+
+// updateHierarchy indexes the sub-issues of open issues, so that parents and children can be found before matching
+func (h *Engine) updateHierarchy(ctx context.Context, sp provider.SearchParams, is []*provider.Issue) {
+	fetch := !sp.NewerThan.IsZero()
+	for _, i := range is {
+		if i.GetState() != constants.OpenState && i.GetState() != constants.OpenedState {
+			continue
+		}
+
+		sp.IssueNumber = i.GetNumber()
+		sp.NewerThan = h.mtime(i)
+		sp.Fetch = fetch
+
+		subs, err := h.cachedSubIssues(ctx, sp)
+		if err != nil {
+			klog.Errorf("sub-issues: %v", err)
+			continue
+		}
+		h.indexSubIssues(i, subs)
+	}
+}
+
+// indexSubIssues records which issues a parent tracks, replacing those previously recorded
+func (h *Engine) indexSubIssues(parent *provider.Issue, subs []*provider.Issue) {
+	h.hierMu.Lock()
+	defer h.hierMu.Unlock()
+
+	prc := issueRelated(parent)
+	pk := refKey(prc.Organization, prc.Project, prc.ID)
+
+	for _, c := range h.children[pk] {
+		delete(h.parents, refKey(c.Organization, c.Project, c.ID))
+	}
+	delete(h.children, pk)
+
+	if len(subs) == 0 {
+		return
+	}
+
+	cs := []*RelatedConversation{}
+	for _, s := range subs {
+		rc := issueRelated(s)
+		h.parents[refKey(rc.Organization, rc.Project, rc.ID)] = prc
+		cs = append(cs, rc)
+	}
+	h.children[pk] = cs
+}
+
+// setHierarchy sets the parent and children of an issue, as last indexed
+func (h *Engine) setHierarchy(co *Conversation) {
+	h.hierMu.Lock()
+	defer h.hierMu.Unlock()
+
+	k := refKey(co.Organization, co.Project, co.ID)
+	co.Parent = h.parents[k]
+	co.Children = h.children[k]
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func testIssue(id int) *provider.Issue {
+	url := fmt.Sprintf("https://github.com/google/triage-party/issues/%d", id)
+	return &provider.Issue{Number: &id, HTMLURL: &url}
+}
+
+func TestIndexSubIssues(t *testing.T) {
+	e := New(Config{})
+	e.indexSubIssues(testIssue(1), []*provider.Issue{testIssue(2), testIssue(3)})
+
+	parent := &Conversation{Organization: "google", Project: "triage-party", ID: 1}
+	e.setHierarchy(parent)
+	assert.Nil(t, parent.Parent)
+	assert.Len(t, parent.Children, 2)
+
+	child := &Conversation{Organization: "google", Project: "triage-party", ID: 3}
+	e.setHierarchy(child)
+	assert.Equal(t, 1, child.Parent.ID)
+
+	// Sub-issues which are removed from the parent no longer have it as a parent
+	e.indexSubIssues(testIssue(1), []*provider.Issue{testIssue(2)})
+	e.setHierarchy(child)
+	assert.Nil(t, child.Parent)
+}
+
+func TestChildrenClosed(t *testing.T) {
+	co := &Conversation{Children: []*RelatedConversation{{State: "closed"}, {State: "opened"}, {State: "closed"}}}
+	assert.Equal(t, 2, co.ChildrenClosed())
+}
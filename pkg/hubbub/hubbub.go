@@ -16,12 +16,14 @@
 package hubbub
 
 import (
+	"regexp"
 	"sync"
 	"time"
 
 	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/persist"
 	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/quota"
 	"k8s.io/klog/v2"
 )
 
@@ -32,6 +34,26 @@ type Config struct {
 
 	// MinSimilarity is how close two items need to be to each other to be called similar
 	MinSimilarity float64
+	// SimilarityAlgorithm is how titles are compared, defaulting to DiceSimilarity
+	SimilarityAlgorithm string
+	// MaxSimilar is how many similar items to list per item, or 0 for no limit
+	MaxSimilar int
+	// Embedder provides vectors for EmbeddingSimilarity
+	Embedder Embedder
+	// EmbedBodies includes bodies as well as titles in the text which is embedded
+	EmbedBodies bool
+	// ExcludeTitles are patterns of titles which are never similar to anything, such as recurring reports
+	ExcludeTitles []string
+	// SimilarityScope is which repositories similar items may be found in, defaulting to AllRepos
+	SimilarityScope string
+	// TraceWeight is how much matching stack traces count for against titles, from 0 (ignored) to 1
+	TraceWeight float64
+	// Weights are how much titles, bodies and labels count for when comparing items, defaulting to titles alone
+	Weights SimilarityWeights
+	// ProjectWeights override Weights for items within repositories, by repository URL
+	ProjectWeights map[string]SimilarityWeights
+	// SimilarityInterval is the least time between recomputing the similarity tables from the same corpus
+	SimilarityInterval time.Duration
 
 	// The furthest we will query back for information on closed issues
 	MaxClosedUpdateAge time.Duration
@@ -46,9 +68,34 @@ type Config struct {
 	// Members are which specific users to consider as members
 	Members []string
 
+	// Calendar is the working time used by business durations, such as +2bd
+	Calendar *Calendar
+
+	// Areas are the parts of projects declared by OWNERS files, such as SIGs
+	Areas []Area
+
+	// Scorer gives conversations a priority score, if set
+	Scorer Scorer
+
+	// Mentions configures which @-mentions are expected to be answered
+	Mentions Mentions
+
+	// TriageStates are the named steps of triage, and which may follow which
+	TriageStates []TriageState
+
 	// Providers
 	GitHub provider.Provider
 	GitLab provider.Provider
+
+	// BitbucketServer serves repositories on BitbucketServerHost
+	BitbucketServer     provider.Provider
+	BitbucketServerHost string
+
+	SourceHut provider.Provider
+	Launchpad provider.Provider
+
+	// Quota records the rate limits reported by providers, and may be nil
+	Quota *quota.Log
 }
 
 // Engine is the search engine interface for hubbub
@@ -58,6 +105,40 @@ type Engine struct {
 	// Must be settable from config
 	MinSimilarity float64
 
+	similarityAlgorithm string
+	maxSimilar          int
+	similarityScope     string
+	embedder            Embedder
+	embedBodies         bool
+	traceWeight         float64
+	weights             SimilarityWeights
+	projectWeights      map[string]SimilarityWeights
+
+	excludeTitles []*regexp.Regexp
+
+	// pairs of URLs which are never similar, loaded from the cache on first use
+	exclusionOnce sync.Once
+	exclusionMu   sync.RWMutex
+	exclusions    map[string]bool
+
+	// embedding vectors by normalized title
+	vectors sync.Map
+
+	// stack traces by URL, and the URLs of items by trace signature
+	traceMu   sync.Mutex
+	traces    map[string]*trace
+	traceURLs map[string][]string
+
+	// bodies & labels by URL, the URLs of items by body word, and body word document frequencies
+	fieldMu      sync.Mutex
+	fieldDocs    map[string]*fieldDoc
+	bodyURLs     map[string][]string
+	bodyTermDocs map[string]int
+	bodyDocs     int
+
+	// corpora waiting for their similarity tables to be updated
+	similarQueue *similarityQueue
+
 	// The furthest we will query back for information on closed issues
 	MaxClosedUpdateAge time.Duration
 
@@ -66,18 +147,60 @@ type Engine struct {
 	titleToURLs   sync.Map
 	similarTitles sync.Map
 
+	// open pull requests by URL, by the keys of the issues they say they close, and the reverse
+	fixMu   sync.Mutex
+	openPRs map[string]*RelatedConversation
+	fixes   map[string]map[string]*RelatedConversation
+	fixKeys map[string][]string
+
+	// parents by the keys of the sub-issues they track, and sub-issues by the keys of their parents
+	hierMu   sync.Mutex
+	parents  map[string]*RelatedConversation
+	children map[string][]*RelatedConversation
+
+	// term document frequencies of titles, for TF-IDF similarity
+	termMu   sync.Mutex
+	termDocs map[string]int
+	docs     int
+
 	memberRoles map[string]bool
 	members     map[string]bool
 
+	calendar *Calendar
+
+	areas        []Area
+	triageStates []TriageState
+	mentions     Mentions
+
+	// scores by URL, as of when each conversation was last updated
+	scorer  Scorer
+	scoreMu sync.Mutex
+	scores  map[string]scored
+
 	// Data source providers
 	github provider.Provider
 	gitlab provider.Provider
 
+	bitbucket     provider.Provider
+	bitbucketHost string
+
+	sourcehut provider.Provider
+	launchpad provider.Provider
+
+	quota *quota.Log
+
 	// Workaround because GitHub doesn't update issues if cross-references occur
 	updated sync.Map
 
 	// indexes used for similarity matching & conversation caching
 	seen sync.Map
+	// URLs of seen conversations by their keys, for resolving references
+	refs sync.Map
+
+	// URLs of issues by where they originated, and the URLs transferred issues moved to
+	transferMu sync.Mutex
+	origins    map[string]string
+	transfers  map[string]string
 }
 
 // ConversationsTotal returns the number of conversations we've seen so far
@@ -91,10 +214,30 @@ func (e *Engine) ConversationsTotal() int {
 	return t
 }
 
+// Conversations returns all conversations we've seen so far
+func (e *Engine) Conversations() []*Conversation {
+	cs := []*Conversation{}
+	e.seen.Range(func(key, value interface{}) bool {
+		cs = append(cs, value.(*Conversation))
+		return true
+	})
+
+	return cs
+}
+
 func (e *Engine) provider(hostname string) provider.Provider {
 	if hostname == constants.GitLabProviderHost {
 		return e.gitlab
 	}
+	if hostname == constants.SourceHutProviderHost {
+		return e.sourcehut
+	}
+	if hostname == constants.LaunchpadProviderHost {
+		return e.launchpad
+	}
+	if hostname != "" && hostname == e.bitbucketHost {
+		return e.bitbucket
+	}
 	return e.github
 }
 
@@ -102,15 +245,69 @@ func New(cfg Config) *Engine {
 	e := &Engine{
 		cache: cfg.Cache,
 
-		MaxClosedUpdateAge: cfg.MaxClosedUpdateAge,
-		MinSimilarity:      cfg.MinSimilarity,
-		debug:              cfg.DebugNumbers,
+		MaxClosedUpdateAge:  cfg.MaxClosedUpdateAge,
+		MinSimilarity:       cfg.MinSimilarity,
+		similarityAlgorithm: cfg.SimilarityAlgorithm,
+		maxSimilar:          cfg.MaxSimilar,
+		similarityScope:     cfg.SimilarityScope,
+		embedder:            cfg.Embedder,
+		embedBodies:         cfg.EmbedBodies,
+		traceWeight:         cfg.TraceWeight,
+		traces:              map[string]*trace{},
+		traceURLs:           map[string][]string{},
+		similarQueue:        newSimilarityQueue(cfg.SimilarityInterval),
+		weights:             cfg.Weights,
+		projectWeights:      cfg.ProjectWeights,
+		fieldDocs:           map[string]*fieldDoc{},
+		bodyURLs:            map[string][]string{},
+		bodyTermDocs:        map[string]int{},
+		termDocs:            map[string]int{},
+		openPRs:             map[string]*RelatedConversation{},
+		fixes:               map[string]map[string]*RelatedConversation{},
+		fixKeys:             map[string][]string{},
+		parents:             map[string]*RelatedConversation{},
+		children:            map[string][]*RelatedConversation{},
+		debug:               cfg.DebugNumbers,
 
 		memberRoles: map[string]bool{},
 		members:     map[string]bool{},
 
+		calendar:     cfg.Calendar,
+		areas:        cfg.Areas,
+		triageStates: cfg.TriageStates,
+		mentions:     cfg.Mentions,
+		scorer:       cfg.Scorer,
+		scores:       map[string]scored{},
+		origins:      map[string]string{},
+		transfers:    map[string]string{},
+
 		github: cfg.GitHub,
 		gitlab: cfg.GitLab,
+
+		bitbucket:     cfg.BitbucketServer,
+		bitbucketHost: cfg.BitbucketServerHost,
+
+		sourcehut: cfg.SourceHut,
+		launchpad: cfg.Launchpad,
+
+		quota: cfg.Quota,
+	}
+
+	for _, t := range cfg.ExcludeTitles {
+		re, err := regexp.Compile(t)
+		if err != nil {
+			klog.Errorf("invalid title exclusion %q: %v", t, err)
+			continue
+		}
+		e.excludeTitles = append(e.excludeTitles, re)
+	}
+
+	if e.weights == (SimilarityWeights{}) {
+		e.weights = SimilarityWeights{Title: 1}
+	}
+
+	if e.calendar == nil {
+		e.calendar = DefaultCalendar()
 	}
 
 	klog.Infof("considering users as members: %v", cfg.Members)
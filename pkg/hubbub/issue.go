@@ -24,7 +24,6 @@ import (
 	"github.com/google/triage-party/pkg/persist"
 	"github.com/google/triage-party/pkg/provider"
 
-	"github.com/google/go-github/v33/github"
 	"github.com/google/triage-party/pkg/logu"
 	"gopkg.in/yaml.v2"
 	"k8s.io/klog/v2"
@@ -37,7 +36,7 @@ func (h *Engine) cachedIssues(ctx context.Context, sp provider.SearchParams) ([]
 	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
 		// Normally the similarity tables are only updated when fresh data is encountered.
 		if sp.NewerThan.IsZero() {
-			go h.updateSimilarIssues(sp.SearchKey, x.Issues)
+			h.queueSimilarity(sp.SearchKey, &similarityJob{issues: x.Issues})
 		}
 
 		return x.Issues, x.Created, nil
@@ -69,6 +68,7 @@ func (h *Engine) updateIssues(ctx context.Context, sp provider.SearchParams) ([]
 	}
 
 	var allIssues []*provider.Issue
+	var similar []*provider.Issue
 
 	for {
 		if sp.UpdateAge == 0 {
@@ -87,15 +87,13 @@ func (h *Engine) updateIssues(ctx context.Context, sp provider.SearchParams) ([]
 		pr := h.provider(sp.Repo.Host)
 		is, resp, err := pr.IssuesListByRepo(ctx, sp)
 
-		if _, ok := err.(*github.RateLimitError); ok {
-			klog.Errorf("oh snap! I reached the GitHub search API limit: %v", err)
-		}
+		logRateLimited(sp.Repo.Host, err)
 
 		if err != nil {
 			return is, start, err
 		}
 
-		h.logRate(resp.Rate)
+		h.logRate(sp.Repo.Host, resp.Rate)
 
 		for _, i := range is {
 			if i.IsPullRequest() {
@@ -106,7 +104,7 @@ func (h *Engine) updateIssues(ctx context.Context, sp provider.SearchParams) ([]
 			allIssues = append(allIssues, i)
 		}
 
-		go h.updateSimilarIssues(sp.SearchKey, is)
+		similar = append(similar, is...)
 
 		if resp.NextPage == 0 {
 			break
@@ -114,6 +112,8 @@ func (h *Engine) updateIssues(ctx context.Context, sp provider.SearchParams) ([]
 		sp.IssueListByRepoOptions.Page = resp.NextPage
 	}
 
+	h.queueSimilarity(sp.SearchKey, &similarityJob{issues: similar})
+
 	if err := h.cache.Set(sp.SearchKey, &persist.Blob{Issues: allIssues}); err != nil {
 		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
 	}
@@ -123,7 +123,7 @@ func (h *Engine) updateIssues(ctx context.Context, sp provider.SearchParams) ([]
 }
 
 func (h *Engine) cachedIssueComments(ctx context.Context, sp provider.SearchParams) ([]*provider.IssueComment, time.Time, error) {
-	sp.SearchKey = fmt.Sprintf("%s-%s-%d-issue-comments", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	sp.SearchKey = fmt.Sprintf("%s-%d-issue-comments", RepoKey(sp.Repo), sp.IssueNumber)
 
 	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
 		return x.IssueComments, x.Created, nil
@@ -165,7 +165,7 @@ func (h *Engine) updateIssueComments(ctx context.Context, sp provider.SearchPara
 		if err != nil {
 			return cs, start, err
 		}
-		h.logRate(resp.Rate)
+		h.logRate(sp.Repo.Host, resp.Rate)
 
 		allComments = append(allComments, cs...)
 		if resp.NextPage == 0 {
@@ -234,8 +234,20 @@ func (h *Engine) cachedConversation(url string) *Conversation {
 	return result.(*Conversation)
 }
 
+// LookupConversation returns a cached conversation by URL, or nil if unseen. Transferred issues are found by their old URL.
+func (h *Engine) LookupConversation(url string) *Conversation {
+	if co := h.cachedConversation(url); co != nil {
+		return co
+	}
+	if to := h.transferredTo(url); to != "" {
+		return h.cachedConversation(to)
+	}
+	return nil
+}
+
 func (h *Engine) updateConversationCache(url string, co *Conversation) {
 	h.seen.Store(url, co)
+	h.refs.Store(refKey(co.Organization, co.Project, co.ID), url)
 }
 
 // IssueSummary returns a cached conversation for an issue
@@ -69,6 +69,7 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 		LatestAuthorResponse: i.GetCreatedAt(),
 		Milestone:            i.GetMilestone(),
 		Reactions:            map[string]int{},
+		LatestResponses:      map[string]time.Time{},
 		LastCommentAuthor:    i.GetUser(),
 		LastCommentBody:      i.GetBody(),
 		Tags:                 map[tag.Tag]bool{},
@@ -83,6 +84,8 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 	co.Organization = urlParts[3]
 	co.Project = urlParts[4]
 	h.parseRefs(i.GetBody(), co, i.GetUpdatedAt())
+	addBlockers(co, i.GetBody())
+	co.TasksCompleted, co.TasksTotal = countTasks(i.GetBody())
 
 	if i.GetAssignee() != nil {
 		co.Assignees = append(co.Assignees, i.GetAssignee())
@@ -104,6 +107,7 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 
 	for _, c := range cs {
 		h.parseRefs(c.Body, co, c.Updated)
+		addBlockers(co, c.Body)
 		if h.debug[co.ID] {
 			klog.Errorf("debug conversation comment: %s", formatStruct(c))
 		}
@@ -138,11 +142,16 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 			co.LatestAssigneeResponse = c.Created
 		}
 
+		co.LatestResponses[c.User.GetLogin()] = c.Created
+
 		if h.isMember(c.User.GetLogin(), c.AuthorAssoc) && !isBot(c.User) {
 			if !co.LatestMemberResponse.After(co.LatestAuthorResponse) && !authorIsMember {
 				co.AccumulatedHoldTime += c.Created.Sub(co.LatestAuthorResponse)
 			}
 			co.LatestMemberResponse = c.Created
+			if co.FirstMemberResponse.IsZero() && !authorIsMember {
+				co.FirstMemberResponse = c.Created
+			}
 			if !seenMemberComment {
 				co.Tags[tag.Commented] = true
 				seenMemberComment = true
@@ -167,6 +176,8 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 		}
 	}
 
+	h.setMentions(co, i, cs)
+
 	if co.Milestone != nil && co.Milestone.GetState() == "open" {
 		co.Tags[tag.OpenMilestone] = true
 	}
@@ -248,6 +259,21 @@ func (h *Engine) isMember(user string, role string) bool {
 	return false
 }
 
+// LatestResponseBy returns when any of a set of users last responded, or when the item was created if its author is not one of them
+func (co *Conversation) LatestResponseBy(users map[string]bool) time.Time {
+	latest := time.Time{}
+	if !users[co.Author.GetLogin()] {
+		latest = co.Created
+	}
+
+	for u, t := range co.LatestResponses {
+		if users[u] && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
 // UpdateIssueRefs updates referenced issues within a conversation, adding it if necessary
 func (co *Conversation) UpdateIssueRefs(rc *RelatedConversation) {
 	for i, ex := range co.IssueRefs {
@@ -350,3 +376,25 @@ func (h *Engine) parseRefs(text string, co *Conversation, t time.Time) {
 		seen[fmt.Sprintf("%s/%d", rc.Project, rc.ID)] = true
 	}
 }
+
+// itemRepo returns the repository an item is in, which for GitLab groups is the project in its URL
+func itemRepo(r provider.Repo, rawURL string) provider.Repo {
+	if !r.AllProjects {
+		return r
+	}
+
+	// "https://gitlab.com/org/subgroup/project/-/issues/7"
+	path := strings.SplitN(strings.TrimPrefix(rawURL, "https://"+r.Host+"/"), "/-/", 2)[0]
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		klog.Errorf("unable to find the project of %s", rawURL)
+		return r
+	}
+
+	return provider.Repo{
+		Host:         r.Host,
+		Organization: parts[0],
+		Group:        strings.Join(parts[1:len(parts)-1], "/"),
+		Project:      parts[len(parts)-1],
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemRepo(t *testing.T) {
+	group := provider.Repo{Host: "gitlab.com", Organization: "org", AllProjects: true}
+
+	assert.Equal(t, provider.Repo{Host: "gitlab.com", Organization: "org", Project: "app"},
+		itemRepo(group, "https://gitlab.com/org/app/-/issues/7"))
+	assert.Equal(t, provider.Repo{Host: "gitlab.com", Organization: "org", Group: "team/sub", Project: "app"},
+		itemRepo(group, "https://gitlab.com/org/team/sub/app/-/merge_requests/3"))
+
+	project := provider.Repo{Host: "gitlab.com", Organization: "org", Project: "app"}
+	assert.Equal(t, project, itemRepo(project, "https://gitlab.com/org/other/-/issues/1"))
+}
+
+func TestRepoKey(t *testing.T) {
+	assert.Equal(t, "org-app", RepoKey(provider.Repo{Host: "github.com", Organization: "org", Project: "app"}))
+	assert.Equal(t, "gitlab.com-org-app", RepoKey(provider.Repo{Host: "gitlab.com", Organization: "org", Project: "app"}))
+	assert.Equal(t, "gitlab.com-org-team-*", RepoKey(provider.Repo{Host: "gitlab.com", Organization: "org", Group: "team", AllProjects: true}))
+}
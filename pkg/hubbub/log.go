@@ -15,18 +15,33 @@
 package hubbub
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/google/go-github/v33/github"
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/provider"
 	"github.com/hokaccha/go-prettyjson"
 
 	"k8s.io/klog/v2"
 )
 
-func (h *Engine) logRate(r provider.Rate) {
-	msg := fmt.Sprintf("GitHub API hourly quota remaining: %d of %d, resets at %s", r.Remaining, r.Limit, r.Reset)
+func (h *Engine) logRate(host string, r provider.Rate) {
+	if host == "" {
+		host = constants.GitHubProviderHost
+	}
+	if h.quota != nil {
+		h.quota.Observe(host, r)
+	}
+
+	// Providers which do not report a quota, such as Bitbucket Server, leave it empty
+	if r.Limit == 0 && r.Remaining == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("%s API hourly quota remaining: %d of %d, resets at %s", host, r.Remaining, r.Limit, r.Reset)
 
 	if r.Remaining < 25 {
 		klog.Error(msg)
@@ -43,6 +58,19 @@ func (h *Engine) logRate(r provider.Rate) {
 	}
 }
 
+// logRateLimited explains errors caused by hitting a rate limit
+func logRateLimited(host string, err error) {
+	var rle *github.RateLimitError
+	var ale *github.AbuseRateLimitError
+	var sle *provider.SecondaryRateLimitError
+	switch {
+	case errors.As(err, &rle):
+		klog.Errorf("oh snap! We reached the %s API limit: %v", host, err)
+	case errors.As(err, &ale), errors.As(err, &sle):
+		klog.Errorf("%s asked us to slow down, so requests are paused: %v", host, err)
+	}
+}
+
 func formatStruct(x interface{}) string {
 	s, err := prettyjson.Marshal(x)
 	if err == nil {
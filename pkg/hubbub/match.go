@@ -28,7 +28,7 @@ import (
 )
 
 // Check if an item matches the filters, pre-comment fetch
-func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Filter) bool {
+func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Filter, cal *Calendar) bool {
 	for _, f := range fs {
 
 		if f.State != "" && f.State != "all" {
@@ -43,29 +43,36 @@ func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Fil
 			}
 		}
 
+		if f.Authors() != nil {
+			if f.Authors()[i.GetUser().GetLogin()] == f.AuthorNegate() {
+				klog.V(2).Infof("#%d author %s does not meet %s", i.GetNumber(), i.GetUser().GetLogin(), f.RawAuthor)
+				return false
+			}
+		}
+
 		if f.Closed != "" {
-			if ok := matchDuration(i.GetClosedAt(), f.Closed); !ok {
+			if ok := matchDuration(i.GetClosedAt(), f.Closed, cal); !ok {
 				klog.V(2).Infof("#%d closed at %s does not meet %s", i.GetNumber(), i.GetClosedAt(), f.Closed)
 				return false
 			}
 		}
 
 		if f.Updated != "" {
-			if ok := matchDuration(i.GetUpdatedAt(), f.Updated); !ok {
+			if ok := matchDuration(i.GetUpdatedAt(), f.Updated, cal); !ok {
 				klog.V(2).Infof("#%d update at %s does not meet %s", i.GetNumber(), i.GetUpdatedAt(), f.Updated)
 				return false
 			}
 		}
 
 		if f.Responded != "" {
-			if ok := matchDuration(i.GetUpdatedAt(), f.Responded); !ok {
+			if ok := matchDuration(i.GetUpdatedAt(), f.Responded, cal); !ok {
 				klog.V(2).Infof("#%d update at %s does not meet responded %s", i.GetNumber(), i.GetUpdatedAt(), f.Responded)
 				return false
 			}
 		}
 
 		if f.Created != "" {
-			if ok := matchDuration(i.GetCreatedAt(), f.Created); !ok {
+			if ok := matchDuration(i.GetCreatedAt(), f.Created, cal); !ok {
 				klog.V(2).Infof("#%d Created at %s does not meet %s", i.GetNumber(), i.GetCreatedAt(), f.Created)
 				return false
 			}
@@ -116,13 +123,22 @@ func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Fil
 }
 
 // Check if an issue matches the summarized version
-func postFetchMatch(co *Conversation, fs []provider.Filter) bool {
+func postFetchMatch(co *Conversation, fs []provider.Filter, cal *Calendar) bool {
 	for _, f := range fs {
 		klog.V(2).Infof("post-fetch matching item #%d against filter: %+v", co.ID, f)
 
 		if f.Responded != "" {
-			if ok := matchDuration(co.LatestMemberResponse, f.Responded); !ok {
-				klog.V(4).Infof("#%d did not pass matchDuration: %s vs %s", co.ID, co.LatestMemberResponse, f.Responded)
+			if _, _, over := ParseDuration(f.Responded); over && !expectingResponse(co, f, cal) {
+				klog.V(2).Infof("#%d is not expecting a response: everyone who would is out of the office", co.ID)
+				return false
+			}
+
+			responded := co.LatestMemberResponse
+			if f.Responders() != nil {
+				responded = co.LatestResponseBy(f.Responders())
+			}
+			if ok := matchDuration(responded, f.Responded, cal); !ok {
+				klog.V(4).Infof("#%d did not pass matchDuration: %s vs %s", co.ID, responded, f.Responded)
 				return false
 			}
 		}
@@ -172,13 +188,75 @@ func postFetchMatch(co *Conversation, fs []provider.Filter) bool {
 				return false
 			}
 		}
+		if f.TasksComplete != "" {
+			if co.TasksTotal == 0 || !matchRange(co.TasksPercent(), f.TasksComplete) {
+				klog.V(2).Infof("#%d did not pass tasks-complete matchRange: %d/%d vs %s", co.ID, co.TasksCompleted, co.TasksTotal, f.TasksComplete)
+				return false
+			}
+		}
+		if f.ReviewRequested != "" || f.Reviewers() != nil {
+			if !matchReviewRequests(co, f, cal) {
+				klog.V(2).Infof("#%d did not pass review requests: %v vs %s", co.ID, co.ReviewRequests, f.ReviewRequested)
+				return false
+			}
+		}
+		if f.HasParent != "" && (co.Parent != nil) != (f.HasParent == "true") {
+			klog.V(2).Infof("#%d did not pass has-parent: %v vs %s", co.ID, co.Parent != nil, f.HasParent)
+			return false
+		}
+		if f.HasChildren != "" && (len(co.Children) > 0) != (f.HasChildren == "true") {
+			klog.V(2).Infof("#%d did not pass has-children: %d vs %s", co.ID, len(co.Children), f.HasChildren)
+			return false
+		}
+		if f.Score != "" {
+			if co.Score == nil || !matchRange(co.Score.Value, f.Score) {
+				klog.V(2).Infof("#%d did not pass score matchRange: %v vs %s", co.ID, co.Score, f.Score)
+				return false
+			}
+		}
+		if f.ScoreLabelRegex() != nil {
+			labels := []string{}
+			if co.Score != nil {
+				labels = co.Score.Labels
+			}
+			if ok := matchArea(labels, f.ScoreLabelRegex(), f.ScoreLabelNegate()); !ok {
+				klog.V(2).Infof("#%d did not pass score-label: %v vs %s", co.ID, labels, f.ScoreLabelRegex())
+				return false
+			}
+		}
+		if f.AreaRegex() != nil {
+			if ok := matchArea(co.Areas, f.AreaRegex(), f.AreaNegate()); !ok {
+				klog.V(2).Infof("#%d did not pass area: %v vs %s", co.ID, co.Areas, f.AreaRegex())
+				return false
+			}
+		}
+		if f.CommitsBehind != "" {
+			if co.CommitsBehind == nil || !matchRange(float64(*co.CommitsBehind), f.CommitsBehind) {
+				klog.V(2).Infof("#%d did not pass commits-behind matchRange: %v vs %s", co.ID, co.CommitsBehind, f.CommitsBehind)
+				return false
+			}
+		}
+		if f.UnresolvedThreads != "" {
+			if co.UnresolvedThreads == nil || !matchRange(float64(*co.UnresolvedThreads), f.UnresolvedThreads) {
+				klog.V(2).Infof("#%d did not pass unresolved-threads matchRange: %v vs %s", co.ID, co.UnresolvedThreads, f.UnresolvedThreads)
+				return false
+			}
+		}
+		if f.MergeReady != "" && co.MergeReady != (f.MergeReady == "true") {
+			klog.V(2).Infof("#%d did not pass merge-ready: %v vs %s", co.ID, co.MergeReady, f.MergeReady)
+			return false
+		}
+		if f.Blocked != "" && co.Blocked() != (f.Blocked == "true") {
+			klog.V(2).Infof("#%d did not pass blocked: %v vs %s", co.ID, co.Blocked(), f.Blocked)
+			return false
+		}
 
 	}
 	return true
 }
 
 // Check if an issue matches the summarized version, after events have been loaded
-func postEventsMatch(co *Conversation, fs []provider.Filter) bool {
+func postEventsMatch(co *Conversation, fs []provider.Filter, cal *Calendar) bool {
 	for _, f := range fs {
 		if f.TagRegex() != nil {
 			if ok, _ := matchTag(co.Tags, f.TagRegex(), f.TagNegate()); !ok {
@@ -188,18 +266,32 @@ func postEventsMatch(co *Conversation, fs []provider.Filter) bool {
 		}
 
 		if f.Prioritized != "" {
-			if ok := matchDuration(co.Prioritized, f.Prioritized); !ok {
+			if ok := matchDuration(co.Prioritized, f.Prioritized, cal); !ok {
 				klog.V(4).Infof("#%d did not pass prioritized duration: %s vs %s", co.ID, co.LatestMemberResponse, f.Prioritized)
 				return false
 			}
 		}
+
+		if f.TriageStateRegex() != nil {
+			if ok := matchNegateRegex(co.TriageState, f.TriageStateRegex(), f.TriageStateNegate()); !ok {
+				klog.V(4).Infof("#%d did not pass triage-state: %q vs %s", co.ID, co.TriageState, f.TriageStateRegex())
+				return false
+			}
+		}
+
+		if f.TriageStateChanged != "" {
+			if co.TriageState == "" || !matchDuration(co.TriageStateChanged, f.TriageStateChanged, cal) {
+				klog.V(4).Infof("#%d did not pass triage-state-changed duration: %s vs %s", co.ID, co.TriageStateChanged, f.TriageStateChanged)
+				return false
+			}
+		}
 	}
 	return true
 }
 
 func matchLabel(labels []*provider.Label, re *regexp.Regexp, negate bool) bool {
 	for _, l := range labels {
-		if re.MatchString(*l.Name) {
+		if re.MatchString(l.GetName()) || re.MatchString(normalizeLabel(l.GetName())) {
 			return !negate
 		}
 	}
@@ -207,6 +299,26 @@ func matchLabel(labels []*provider.Label, re *regexp.Regexp, negate bool) bool {
 	return negate
 }
 
+// normalizeLabel returns a label named as it would be on GitHub, so that GitLab scoped labels such as priority::high match priority/high
+func normalizeLabel(name string) string {
+	return strings.ReplaceAll(name, "::", "/")
+}
+
+// sameLabel returns whether two labels are the same once normalized
+func sameLabel(a string, b string) bool {
+	return a == b || normalizeLabel(a) == normalizeLabel(b)
+}
+
+// matchArea matches a list of areas against a negatable regex
+func matchArea(areas []string, re *regexp.Regexp, negate bool) bool {
+	for _, a := range areas {
+		if re.MatchString(a) {
+			return !negate
+		}
+	}
+	return negate
+}
+
 // matchNegateRegex matches a value against a negatable regex
 func matchNegateRegex(value string, re *regexp.Regexp, negate bool) bool {
 	if value == "" && re.String() != "" && re.String() != "^$" {
@@ -231,6 +343,16 @@ func matchTag(tags map[tag.Tag]bool, re *regexp.Regexp, negate bool) (bool, tag.
 }
 
 func ParseDuration(ds string) (time.Duration, bool, bool) {
+	// Business durations depend on the calendar, so return an upper bound of the wall-clock time they may span
+	if m := businessRegexp.FindStringSubmatch(ds); m != nil {
+		n, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil {
+			klog.Errorf("unable to parse duration: %s", m[2])
+			return 0, false, false
+		}
+		return businessWallClock(n, m[3]), m[1] == "-" || m[1] == "<", m[1] == "+" || m[1] == ">"
+	}
+
 	// fscking stdlib
 	matches := dayRegexp.FindStringSubmatch(ds)
 	if len(matches) > 0 {
@@ -272,18 +394,57 @@ func ParseDuration(ds string) (time.Duration, bool, bool) {
 	return d, within, over
 }
 
-func matchDuration(t time.Time, ds string) bool {
+// expectingResponse returns false if everyone who is expected to respond to an item is out of the office:
+// the responders of a filter if set, or otherwise the assignees of the item
+func expectingResponse(co *Conversation, f provider.Filter, cal *Calendar) bool {
+	now := time.Now()
+	if f.Responders() != nil {
+		return len(cal.Present(f.Responders(), now)) > 0
+	}
+
+	if len(co.Assignees) == 0 {
+		return true
+	}
+
+	for _, a := range co.Assignees {
+		if !cal.Away(a.GetLogin(), now) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchReviewRequests returns true if any outstanding review request matches the filter
+func matchReviewRequests(co *Conversation, f provider.Filter, cal *Calendar) bool {
+	for login, t := range co.ReviewRequests {
+		if f.Reviewers() != nil && !f.Reviewers()[login] {
+			continue
+		}
+		if f.ReviewRequested == "" || matchDuration(t, f.ReviewRequested, cal) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchDuration(t time.Time, ds string, cal *Calendar) bool {
 	if t.IsZero() {
 		klog.Warningf("matchDuration against zero time for %s (returning false)", ds)
 		return false
 	}
 
 	d, within, over := ParseDuration(ds)
+	since := time.Since(t)
+
+	if bd, bwithin, bover, ok := cal.parseBusinessDuration(ds); ok {
+		d, within, over = bd, bwithin, bover
+		since = cal.Elapsed(t, time.Now())
+	}
 
-	if within && time.Since(t) < d {
+	if within && since < d {
 		return true
 	}
-	if over && time.Since(t) > d {
+	if over && since > d {
 		return true
 	}
 	return false
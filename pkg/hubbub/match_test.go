@@ -0,0 +1,33 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchLabel(t *testing.T) {
+	names := []string{"priority::high", "kind/bug"}
+	labels := []*provider.Label{{Name: &names[0]}, {Name: &names[1]}}
+
+	assert.True(t, matchLabel(labels, regexp.MustCompile("^priority/high$"), false))
+	assert.True(t, matchLabel(labels, regexp.MustCompile("^priority::high$"), false))
+	assert.False(t, matchLabel(labels, regexp.MustCompile("^priority/low$"), false))
+	assert.True(t, sameLabel("triage::accepted", "triage/accepted"))
+}
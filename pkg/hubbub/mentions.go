@@ -0,0 +1,100 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+)
+
+// mentionRe matches @-mentions of users, or of teams such as @org/team
+var mentionRe = regexp.MustCompile(`(?:^|[^\w@/` + "`" + `])@([\w-]+(?:/[\w.-]+)?)`)
+
+// Mentions configures which @-mentions are expected to be answered
+type Mentions struct {
+	// Within is how recently a mention must have been made to be considered
+	Within time.Duration
+	// Responders are who may answer a mention, by the lowercased login or team mentioned
+	Responders map[string][]string
+}
+
+// findMentions returns the handles mentioned in a body
+func findMentions(body string) []string {
+	body = codeRe.ReplaceAllString(body, " ")
+	found := []string{}
+	for _, m := range mentionRe.FindAllStringSubmatch(body, -1) {
+		found = append(found, strings.ToLower(m[1]))
+	}
+	return found
+}
+
+// setMentions tags a conversation where a watched maintainer or team was recently mentioned, and has not responded since
+func (h *Engine) setMentions(co *Conversation, i provider.IItem, cs []*provider.Comment) {
+	if len(h.mentions.Responders) == 0 {
+		return
+	}
+
+	// latest mention of each watched handle
+	mentioned := map[string]time.Time{}
+	add := func(body string, author string, at time.Time) {
+		for _, m := range findMentions(body) {
+			if _, ok := h.mentions.Responders[m]; !ok || strings.EqualFold(m, author) {
+				continue
+			}
+			if at.After(mentioned[m]) {
+				mentioned[m] = at
+			}
+		}
+	}
+
+	add(i.GetBody(), i.GetUser().GetLogin(), i.GetCreatedAt())
+	for _, c := range cs {
+		add(c.Body, c.User.GetLogin(), c.Created)
+	}
+
+	responded := map[string]time.Time{}
+	for login, t := range co.LatestResponses {
+		responded[strings.ToLower(login)] = t
+	}
+
+	oldest := time.Now().Add(-h.mentions.Within)
+	co.UnansweredMentions = nil
+	for m, at := range mentioned {
+		if h.mentions.Within > 0 && at.Before(oldest) {
+			continue
+		}
+
+		answered := false
+		for _, r := range h.mentions.Responders[m] {
+			if responded[strings.ToLower(r)].After(at) {
+				answered = true
+				break
+			}
+		}
+		if !answered {
+			co.UnansweredMentions = append(co.UnansweredMentions, m)
+		}
+	}
+
+	if len(co.UnansweredMentions) > 0 {
+		sort.Strings(co.UnansweredMentions)
+		co.Tags[tag.MentionUnanswered] = true
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindMentions(t *testing.T) {
+	got := findMentions("cc @Alice and @kubernetes/sig-node-reviewers, not me@example.com or `@bob`\n```\n@carol\n```")
+	assert.Equal(t, []string{"alice", "kubernetes/sig-node-reviewers"}, got)
+}
+
+func TestSetMentions(t *testing.T) {
+	now := time.Now()
+	logins := []string{"author", "alice", "bob", "dave"}
+	body := "@alice @bob @org/team @carol"
+	created := now.Add(-48 * time.Hour)
+	i := &provider.Issue{Body: &body, User: &provider.User{Login: &logins[0]}, CreatedAt: &created}
+
+	h := &Engine{mentions: Mentions{Within: 7 * 24 * time.Hour, Responders: map[string][]string{
+		"alice":    {"alice"},
+		"bob":      {"bob"},
+		"org/team": {"dave"},
+	}}}
+
+	cs := []*provider.Comment{
+		{User: &provider.User{Login: &logins[1]}, Body: "looking", Created: now.Add(-24 * time.Hour)},
+		{User: &provider.User{Login: &logins[0]}, Body: "thanks! @Alice one more thing", Created: now.Add(-12 * time.Hour)},
+		{User: &provider.User{Login: &logins[3]}, Body: "on it", Created: now.Add(-6 * time.Hour)},
+	}
+	co := &Conversation{Tags: map[tag.Tag]bool{}, LatestResponses: map[string]time.Time{}}
+	for _, c := range cs {
+		co.LatestResponses[c.User.GetLogin()] = c.Created
+	}
+
+	h.setMentions(co, i, cs)
+	assert.Equal(t, []string{"alice", "bob"}, co.UnansweredMentions)
+	assert.True(t, co.Tags[tag.MentionUnanswered])
+
+	h.mentions.Within = time.Hour
+	co = &Conversation{Tags: map[tag.Tag]bool{}, LatestResponses: co.LatestResponses}
+	h.setMentions(co, i, cs)
+	assert.Empty(t, co.UnansweredMentions)
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+	"k8s.io/klog/v2"
+)
+
+// cleanMergeableStates are the mergeable states of PRs which have no conflicts and whose checks have passed
+var cleanMergeableStates = map[string]bool{
+	"clean":     true,
+	"has_hooks": true,
+}
+
+// NeedsMergeability returns whether the filters require the mergeability of pull requests, which costs a request each
+func NeedsMergeability(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.MergeReady != "" {
+			return true
+		}
+		if f.TagRegex() != nil {
+			if ok, _ := matchTag(map[tag.Tag]bool{tag.MergeReady: true}, f.TagRegex(), false); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// changesRequested returns whether any reviewer's latest review requests changes
+func changesRequested(reviews []*provider.PullRequestReview) bool {
+	latest := map[string]string{}
+	for _, r := range reviews {
+		if r.GetState() != Approved && r.GetState() != ChangesRequested {
+			continue
+		}
+		latest[strings.ToLower(r.GetUser().GetLogin())] = r.GetState()
+	}
+
+	for _, st := range latest {
+		if st == ChangesRequested {
+			return true
+		}
+	}
+	return false
+}
+
+// setMergeReady tags an open PR which would merge cleanly as is: approved, with no changes requested, no conflicts, passing checks, and no auto-merge
+func (h *Engine) setMergeReady(ctx context.Context, sp provider.SearchParams, co *Conversation, reviews []*provider.PullRequestReview, fetch bool) {
+	if co.Type != PullRequest || co.State == "closed" || co.State == "merged" {
+		return
+	}
+
+	sp.IssueNumber = co.ID
+	sp.Fetch = fetch
+	pr, _, err := h.cachedPR(ctx, sp)
+	if err != nil {
+		klog.Errorf("pr: %v", err)
+	}
+
+	// GitHub computes mergeability in the background, so ask again until it is known
+	if pr.GetMergeableState() == "unknown" && fetch {
+		pr, _, err = h.updatePR(ctx, sp)
+		if err != nil {
+			klog.Errorf("pr: %v", err)
+		}
+	}
+
+	if pr == nil {
+		return
+	}
+
+	co.MergeableState = pr.GetMergeableState()
+	if co.ReviewState != Approved || changesRequested(reviews) {
+		return
+	}
+	if !pr.GetMergeable() || !cleanMergeableStates[co.MergeableState] {
+		return
+	}
+	if co.Tags[tag.AutoMerge] || co.Tags[tag.Draft] {
+		return
+	}
+
+	co.MergeReady = true
+	co.Tags[tag.MergeReady] = true
+}
@@ -24,7 +24,6 @@ import (
 	"github.com/google/triage-party/pkg/persist"
 	"github.com/google/triage-party/pkg/provider"
 
-	"github.com/google/go-github/v33/github"
 	"github.com/google/triage-party/pkg/tag"
 	"k8s.io/klog/v2"
 )
@@ -46,7 +45,7 @@ func (h *Engine) cachedPRs(ctx context.Context, sp provider.SearchParams) ([]*pr
 	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
 		// Normally the similarity tables are only updated when fresh data is encountered.
 		if sp.NewerThan.IsZero() {
-			go h.updateSimilarPullRequests(sp.SearchKey, x.PullRequests)
+			h.queueSimilarity(sp.SearchKey, &similarityJob{prs: x.PullRequests})
 		}
 		return x.PullRequests, x.Created, nil
 	}
@@ -88,12 +87,10 @@ func (h *Engine) updatePRs(ctx context.Context, sp provider.SearchParams) ([]*pr
 		pr := h.provider(sp.Repo.Host)
 		prs, resp, err := pr.PullRequestsList(ctx, sp)
 		if err != nil {
-			if _, ok := err.(*github.RateLimitError); ok {
-				klog.Errorf("oh snap! We reached the GitHub search API limit: %v", err)
-			}
+			logRateLimited(sp.Repo.Host, err)
 			return prs, start, err
 		}
-		h.logRate(resp.Rate)
+		h.logRate(sp.Repo.Host, resp.Rate)
 
 		for _, pr := range prs {
 			// Because PR searches do not support opt.Since
@@ -109,14 +106,14 @@ func (h *Engine) updatePRs(ctx context.Context, sp provider.SearchParams) ([]*pr
 			allPRs = append(allPRs, pr)
 		}
 
-		go h.updateSimilarPullRequests(sp.SearchKey, prs)
-
 		if resp.NextPage == 0 || resp.NextPage == sp.PullRequestListOptions.Page || foundOldest {
 			break
 		}
 		sp.PullRequestListOptions.Page = resp.NextPage
 	}
 
+	h.queueSimilarity(sp.SearchKey, &similarityJob{prs: allPRs})
+
 	if err := h.cache.Set(sp.SearchKey, &persist.Blob{PullRequests: allPRs}); err != nil {
 		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
 	}
@@ -127,7 +124,7 @@ func (h *Engine) updatePRs(ctx context.Context, sp provider.SearchParams) ([]*pr
 }
 
 func (h *Engine) cachedPR(ctx context.Context, sp provider.SearchParams) (*provider.PullRequest, time.Time, error) {
-	sp.SearchKey = fmt.Sprintf("%s-%s-%d-pr", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	sp.SearchKey = fmt.Sprintf("%s-%d-pr", RepoKey(sp.Repo), sp.IssueNumber)
 
 	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
 		return x.PullRequests[0], x.Created, nil
@@ -160,7 +157,7 @@ func (h *Engine) updatePR(ctx context.Context, sp provider.SearchParams) (*provi
 		return pr, start, err
 	}
 
-	h.logRate(resp.Rate)
+	h.logRate(sp.Repo.Host, resp.Rate)
 	h.updateMtime(pr, pr.GetUpdatedAt())
 
 	if err := h.cache.Set(sp.SearchKey, &persist.Blob{PullRequests: []*provider.PullRequest{pr}}); err != nil {
@@ -171,7 +168,7 @@ func (h *Engine) updatePR(ctx context.Context, sp provider.SearchParams) (*provi
 }
 
 func (h *Engine) cachedReviewComments(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequestComment, time.Time, error) {
-	sp.SearchKey = fmt.Sprintf("%s-%s-%d-pr-comments", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	sp.SearchKey = fmt.Sprintf("%s-%d-pr-comments", RepoKey(sp.Repo), sp.IssueNumber)
 
 	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
 		return x.PullRequestComments, x.Created, nil
@@ -245,7 +242,7 @@ func (h *Engine) updateReviewComments(ctx context.Context, sp provider.SearchPar
 			return cs, start, err
 		}
 
-		h.logRate(resp.Rate)
+		h.logRate(sp.Repo.Host, resp.Rate)
 
 		klog.V(2).Infof("Received %d review comments", len(cs))
 		for _, c := range cs {
@@ -275,6 +272,7 @@ func (h *Engine) createPRSummary(ctx context.Context, sp provider.SearchParams,
 
 	co.ReviewState = reviewState(pr, timeline, reviews)
 	co.Tags[reviewStateTag(co.ReviewState)] = true
+	reviewTimes(co, pr, timeline, reviews)
 
 	if pr.GetDraft() {
 		co.Tags[tag.Draft] = true
@@ -28,7 +28,7 @@ import (
 )
 
 func (h *Engine) cachedReviews(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequestReview, time.Time, error) {
-	sp.SearchKey = fmt.Sprintf("%s-%s-%d-pr-reviews", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	sp.SearchKey = fmt.Sprintf("%s-%d-pr-reviews", RepoKey(sp.Repo), sp.IssueNumber)
 
 	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
 		return x.Reviews, x.Created, nil
@@ -58,7 +58,7 @@ func (h *Engine) updateReviews(ctx context.Context, sp provider.SearchParams) ([
 			return cs, start, err
 		}
 
-		h.logRate(resp.Rate)
+		h.logRate(sp.Repo.Host, resp.Rate)
 
 		allReviews = append(allReviews, cs...)
 		if resp.NextPage == 0 {
@@ -135,8 +135,14 @@ func reviewState(pr provider.IItem, timeline []*provider.Timeline, reviews []*pr
 		state = NewCommits
 	}
 
-	if state == Approved && lastReview.Before(lastPushTime) {
-		state = PushedAfterApproval
+	if lastReview.Before(lastPushTime) {
+		switch state {
+		case Approved:
+			state = PushedAfterApproval
+		case ChangesRequested, Commented:
+			// A force-push does not always leave a commit event behind, but the reviewed commits are gone
+			state = NewCommits
+		}
 	}
 
 	return state
@@ -165,3 +171,75 @@ func reviewStateTag(st string) tag.Tag {
 	}
 	return tag.Tag{}
 }
+
+// reviewTimes records when a review was first requested, first submitted, approved and merged
+func reviewTimes(co *Conversation, pr *provider.PullRequest, timeline []*provider.Timeline, reviews []*provider.PullRequestReview) {
+	co.ReviewRequested = pr.GetCreatedAt()
+	for _, t := range timeline {
+		if t.GetEvent() == "review_requested" {
+			co.ReviewRequested = t.GetCreatedAt()
+			break
+		}
+	}
+
+	// Drafts are not ready for review until they say so
+	if co.ReadyForReview.After(co.ReviewRequested) {
+		co.ReviewRequested = co.ReadyForReview
+	}
+
+	co.ReviewRequests = reviewRequests(co, pr, timeline)
+	co.Merged = pr.GetMergedAt()
+	author := pr.GetUser().GetLogin()
+
+	for _, r := range reviews {
+		u := r.GetUser()
+		at := r.GetSubmittedAt()
+		if at.IsZero() || u.GetLogin() == author || isBot(u) {
+			continue
+		}
+
+		if co.FirstReview.IsZero() {
+			co.FirstReview = at
+			co.FirstReviewer = u.GetLogin()
+		}
+
+		if r.GetState() == Approved && (co.Merged.IsZero() || !at.After(co.Merged)) {
+			co.Approved = at
+			co.Approver = u.GetLogin()
+		}
+	}
+
+	if !co.LatestForcePush.IsZero() && co.LatestForcePush.After(lastReviewed(reviews)) {
+		co.Tags[tag.ForcePushed] = true
+	}
+}
+
+// lastReviewed returns when the most recent review was submitted
+func lastReviewed(reviews []*provider.PullRequestReview) time.Time {
+	last := time.Time{}
+	for _, r := range reviews {
+		if r.GetSubmittedAt().After(last) {
+			last = r.GetSubmittedAt()
+		}
+	}
+	return last
+}
+
+// reviewRequests returns when each outstanding reviewer was most recently asked for a review
+func reviewRequests(co *Conversation, pr *provider.PullRequest, timeline []*provider.Timeline) map[string]time.Time {
+	reqs := map[string]time.Time{}
+	for _, u := range pr.RequestedReviewers {
+		reqs[u.GetLogin()] = co.ReviewRequested
+	}
+
+	for _, t := range timeline {
+		if t.GetEvent() != "review_requested" {
+			continue
+		}
+		login := t.GetRequestedReviewer().GetLogin()
+		if _, ok := reqs[login]; ok {
+			reqs[login] = t.GetCreatedAt()
+		}
+	}
+	return reqs
+}
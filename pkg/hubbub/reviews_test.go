@@ -0,0 +1,82 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewRequests(t *testing.T) {
+	created := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	requested := "review_requested"
+	logins := []string{"alice", "bob", "carol"}
+	alice := &provider.User{Login: &logins[0]}
+	bob := &provider.User{Login: &logins[1]}
+	carol := &provider.User{Login: &logins[2]}
+	first, second, third := created.Add(24*time.Hour), created.Add(48*time.Hour), created.Add(72*time.Hour)
+
+	pr := &provider.PullRequest{RequestedReviewers: []*provider.User{alice, bob}}
+	timeline := []*provider.Timeline{
+		{Event: &requested, CreatedAt: &first, RequestedReviewer: alice},
+		{Event: &requested, CreatedAt: &second, RequestedReviewer: carol},
+		{Event: &requested, CreatedAt: &third, RequestedReviewer: alice},
+	}
+
+	co := &Conversation{ReviewRequested: created}
+	got := reviewRequests(co, pr, timeline)
+	assert.Equal(t, map[string]time.Time{
+		"alice": third,
+		"bob":   created,
+	}, got)
+}
+
+func TestReviewStateForcePush(t *testing.T) {
+	reviewed := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	pushed := reviewed.Add(time.Hour)
+	forcePush := "head_ref_force_pushed"
+	timeline := []*provider.Timeline{{Event: &forcePush, CreatedAt: &pushed}}
+
+	tests := []struct {
+		review string
+		want   string
+	}{
+		{Approved, PushedAfterApproval},
+		{ChangesRequested, NewCommits},
+		{Commented, NewCommits},
+	}
+
+	for _, tc := range tests {
+		state := tc.review
+		reviews := []*provider.PullRequestReview{{State: &state, SubmittedAt: &reviewed}}
+		assert.Equal(t, tc.want, reviewState(&provider.PullRequest{}, timeline, reviews), tc.review)
+	}
+}
+
+func TestChangesRequested(t *testing.T) {
+	logins := []string{"alice", "bob"}
+	states := []string{Approved, ChangesRequested, Commented}
+	review := func(login int, state int) *provider.PullRequestReview {
+		return &provider.PullRequestReview{User: &provider.User{Login: &logins[login]}, State: &states[state]}
+	}
+
+	assert.False(t, changesRequested(nil))
+	assert.True(t, changesRequested([]*provider.PullRequestReview{review(0, 0), review(1, 1)}))
+	assert.False(t, changesRequested([]*provider.PullRequestReview{review(1, 1), review(1, 0), review(0, 0)}))
+	assert.True(t, changesRequested([]*provider.PullRequestReview{review(1, 1), review(1, 2)}))
+}
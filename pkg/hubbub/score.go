@@ -0,0 +1,71 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Score is the priority an external service gives a conversation
+type Score struct {
+	Value  float64  `json:"score"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Scorer returns a score for a conversation
+type Scorer interface {
+	Score(ctx context.Context, co *Conversation) (*Score, error)
+}
+
+// scored is the score of a conversation as of when it was last updated
+type scored struct {
+	updated time.Time
+	score   *Score
+}
+
+// setScore sets the score of a conversation, asking the scorer only if it is new or has been updated since
+func (h *Engine) setScore(ctx context.Context, co *Conversation) {
+	if h.scorer == nil {
+		return
+	}
+
+	h.scoreMu.Lock()
+	last, ok := h.scores[co.URL]
+	h.scoreMu.Unlock()
+
+	if ok && last.updated.Equal(co.Updated) {
+		co.Score = last.score
+		return
+	}
+
+	// Don't send the previous score along
+	co.Score = nil
+	s, err := h.scorer.Score(ctx, co)
+	if err != nil {
+		klog.Errorf("score %s: %v", co.URL, err)
+		if ok {
+			co.Score = last.score
+		}
+		return
+	}
+
+	co.Score = s
+	h.scoreMu.Lock()
+	h.scores[co.URL] = scored{updated: co.Updated, score: s}
+	h.scoreMu.Unlock()
+}
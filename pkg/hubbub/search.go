@@ -160,6 +160,10 @@ func (h *Engine) SearchIssues(ctx context.Context, sp provider.SearchParams) ([]
 		}
 	}
 
+	if NeedsHierarchy(sp.Filters) {
+		h.updateHierarchy(ctx, sp, is)
+	}
+
 	filtered := h.analyzeIssueMatches(ctx, is, sp, age, latestIssueUpdate)
 	klog.Infof("issue search took %s, returning %d items: %+v", time.Since(start), len(filtered), sp)
 	return filtered, age, nil
@@ -15,13 +15,13 @@
 package hubbub
 
 import (
+	"context"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/triage-party/pkg/provider"
-
-	"github.com/imjasonmiller/godice"
 	"k8s.io/klog/v2"
 )
 
@@ -90,8 +90,17 @@ func normalizeTitle(t string) string {
 func (h *Engine) updateSimilarIssues(key string, is []*provider.Issue) {
 	start := time.Now()
 	klog.V(1).Infof("Updating similarity table from issue cache %q (%d items)", key, len(is))
+
+	ts := []embedText{}
+	for _, i := range is {
+		ts = append(ts, h.embedTextOf(i.GetTitle(), i.GetBody()))
+	}
+	h.embed(context.Background(), ts)
+
 	for _, i := range is {
 		h.updateSimilarityTables(i.GetTitle(), i.GetHTMLURL())
+		h.updateTraces(i.GetTitle(), i.GetHTMLURL(), i.GetBody())
+		h.updateFields(i.GetTitle(), i.GetHTMLURL(), i.GetBody(), i.Labels)
 	}
 	klog.V(1).Infof("%q took %s to update", key, time.Since(start))
 }
@@ -100,14 +109,24 @@ func (h *Engine) updateSimilarIssues(key string, is []*provider.Issue) {
 func (h *Engine) updateSimilarPullRequests(key string, prs []*provider.PullRequest) {
 	start := time.Now()
 	klog.V(1).Infof("Updating similarity table from PR cache %q (%d items)", key, len(prs))
+	h.updateFixes(prs)
+
+	ts := []embedText{}
+	for _, i := range prs {
+		ts = append(ts, h.embedTextOf(i.GetTitle(), i.GetBody()))
+	}
+	h.embed(context.Background(), ts)
+
 	for _, i := range prs {
 		h.updateSimilarityTables(i.GetTitle(), i.GetHTMLURL())
+		h.updateTraces(i.GetTitle(), i.GetHTMLURL(), i.GetBody())
+		h.updateFields(i.GetTitle(), i.GetHTMLURL(), i.GetBody(), i.Labels)
 	}
 	klog.V(1).Infof("%q took %s to update", key, time.Since(start))
 }
 
 func (h *Engine) updateSimilarityTables(rawTitle, url string) {
-	if h.MinSimilarity == 0 {
+	if h.MinSimilarity == 0 || h.excludedTitle(rawTitle) {
 		return
 	}
 
@@ -132,6 +151,8 @@ func (h *Engine) updateSimilarityTables(rawTitle, url string) {
 		return
 	}
 
+	h.addTerms(title)
+
 	// Update us -> them title similarity
 	similarTo := []string{}
 
@@ -144,7 +165,7 @@ func (h *Engine) updateSimilarityTables(rawTitle, url string) {
 			return true
 		}
 
-		if godice.CompareString(title, otherTitle) > h.MinSimilarity {
+		if h.similarity(title, otherTitle) > h.MinSimilarity {
 			klog.V(4).Infof("%q is similar to %q", rawTitle, otherTitle)
 			similarTo = append(similarTo, otherTitle)
 		}
@@ -165,24 +186,55 @@ func (h *Engine) updateSimilarityTables(rawTitle, url string) {
 
 // FindSimilar locates similar conversations to this one
 func (h *Engine) FindSimilar(co *Conversation) []*RelatedConversation {
-	if h.MinSimilarity == 0 {
+	if h.MinSimilarity == 0 || h.excludedTitle(co.Title) {
 		return nil
 	}
 
-	simco := []*RelatedConversation{}
 	title := normalizeTitle(co.Title)
-	similarURLs := []string{}
 	klog.V(4).Infof("finding similar items to #%d (%s)", co.ID, co.Type)
 
-	tres, ok := h.similarTitles.Load(title)
-	if !ok {
-		return nil
+	similarURLs := []string{}
+	scores := map[string]float64{}
+
+	if tres, ok := h.similarTitles.Load(title); ok {
+		for _, ot := range tres.([]string) {
+			ures, ok := h.titleToURLs.Load(ot)
+			if !ok {
+				continue
+			}
+			for _, url := range ures.([]string) {
+				// May happen if we've seen a URL with different titles
+				if _, ok := scores[url]; ok {
+					continue
+				}
+				scores[url] = h.score(title, co.URL, ot, url)
+				if scores[url] > h.MinSimilarity {
+					similarURLs = append(similarURLs, url)
+				}
+			}
+		}
 	}
 
-	for _, ot := range tres.([]string) {
-		ures, ok := h.titleToURLs.Load(ot)
-		if ok {
-			similarURLs = append(similarURLs, ures.([]string)...)
+	// Items with matching stack traces or bodies, whatever their titles
+	others := h.traceMatches(co.URL)
+	if h.weightsFor(co.URL).Body > 0 {
+		for url, ot := range h.bodyMatches(co.URL) {
+			others[url] = ot
+		}
+	}
+	ourls := []string{}
+	for url := range others {
+		ourls = append(ourls, url)
+	}
+	sort.Strings(ourls)
+
+	for _, url := range ourls {
+		if _, ok := scores[url]; ok {
+			continue
+		}
+		scores[url] = h.score(title, co.URL, others[url], url)
+		if scores[url] > h.MinSimilarity {
+			similarURLs = append(similarURLs, url)
 		}
 	}
 
@@ -190,32 +242,33 @@ func (h *Engine) FindSimilar(co *Conversation) []*RelatedConversation {
 		return nil
 	}
 
+	// Most similar first, so that the limit keeps the best matches
+	sort.SliceStable(similarURLs, func(i, j int) bool { return scores[similarURLs[i]] > scores[similarURLs[j]] })
 	klog.V(4).Infof("#%d %q is similar to %v", co.ID, co.Title, similarURLs)
 
-	added := map[string]bool{}
-
+	simco := []*RelatedConversation{}
 	for _, url := range similarURLs {
 		// We found ourselves with a different title
 		if url == co.URL {
 			continue
 		}
 
-		// May happen if we've seen a URL with different titles
-		if added[url] {
-			continue
-		}
-
 		oco := h.cachedConversation(url)
 		if oco == nil {
 			continue
 		}
 
-		if oco.Type != co.Type {
+		if oco.Type != co.Type || !h.inScope(co.Organization, co.Project, oco.Organization, oco.Project) || h.excludedPair(co.URL, url) {
 			continue
 		}
 
-		simco = append(simco, makeRelated(oco))
-		added[url] = true
+		rc := makeRelated(oco)
+		rc.Score = scores[url]
+		simco = append(simco, rc)
+
+		if h.maxSimilar > 0 && len(simco) >= h.maxSimilar {
+			break
+		}
 	}
 	return simco
 }
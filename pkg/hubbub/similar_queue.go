@@ -0,0 +1,123 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// similarityJob is the latest data for a corpus whose similarity tables need to be recomputed
+type similarityJob struct {
+	issues []*provider.Issue
+	prs    []*provider.PullRequest
+}
+
+// similarityQueue deduplicates similarity recomputation by corpus, and spaces out recomputing each corpus
+type similarityQueue struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*similarityJob
+	order   []string
+	lastRun map[string]time.Time
+
+	once sync.Once
+	wake chan struct{}
+}
+
+func newSimilarityQueue(interval time.Duration) *similarityQueue {
+	return &similarityQueue{
+		interval: interval,
+		pending:  map[string]*similarityJob{},
+		lastRun:  map[string]time.Time{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// add queues a corpus, replacing any data queued for it which has not been processed yet
+func (q *similarityQueue) add(key string, j *similarityJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.pending[key]; ok {
+		klog.V(2).Infof("similarity for %q is already queued, replacing its data", key)
+	} else {
+		q.order = append(q.order, key)
+	}
+	q.pending[key] = j
+}
+
+// next returns the first corpus which is due, or how long until one is, or 0 if none are queued
+func (q *similarityQueue) next(now time.Time) (string, *similarityJob, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	wait := time.Duration(0)
+	for i, key := range q.order {
+		due := q.lastRun[key].Add(q.interval)
+		if !due.After(now) {
+			j := q.pending[key]
+			delete(q.pending, key)
+			q.order = append(q.order[:i:i], q.order[i+1:]...)
+			q.lastRun[key] = now
+			return key, j, 0
+		}
+		if d := due.Sub(now); wait == 0 || d < wait {
+			wait = d
+		}
+	}
+	return "", nil, wait
+}
+
+// queueSimilarity schedules the similarity tables to be updated from a corpus, in the background
+func (h *Engine) queueSimilarity(key string, j *similarityJob) {
+	h.similarQueue.add(key, j)
+	h.similarQueue.once.Do(func() { go h.similarityWorker() })
+
+	select {
+	case h.similarQueue.wake <- struct{}{}:
+	default:
+	}
+}
+
+// similarityWorker updates the similarity tables one corpus at a time, as they become due
+func (h *Engine) similarityWorker() {
+	q := h.similarQueue
+	for {
+		key, j, wait := q.next(time.Now())
+		if j == nil {
+			if wait == 0 {
+				<-q.wake
+				continue
+			}
+			select {
+			case <-q.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		if j.issues != nil {
+			h.updateSimilarIssues(key, j.issues)
+		}
+		if j.prs != nil {
+			h.updateSimilarPullRequests(key, j.prs)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarityQueue(t *testing.T) {
+	q := newSimilarityQueue(10 * time.Minute)
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	key, j, wait := q.next(now)
+	assert.Nil(t, j)
+	assert.Equal(t, time.Duration(0), wait)
+
+	// Only the latest data for a corpus is processed
+	q.add("a", &similarityJob{issues: []*provider.Issue{{}}})
+	q.add("b", &similarityJob{})
+	q.add("a", &similarityJob{issues: []*provider.Issue{{}, {}}})
+
+	key, j, _ = q.next(now)
+	assert.Equal(t, "a", key)
+	assert.Len(t, j.issues, 2)
+	key, _, _ = q.next(now)
+	assert.Equal(t, "b", key)
+
+	// A corpus is not processed again until the interval has passed
+	q.add("a", &similarityJob{})
+	_, j, wait = q.next(now.Add(time.Minute))
+	assert.Nil(t, j)
+	assert.Equal(t, 9*time.Minute, wait)
+
+	key, j, _ = q.next(now.Add(10 * time.Minute))
+	assert.Equal(t, "a", key)
+	assert.NotNil(t, j)
+}
@@ -0,0 +1,280 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/imjasonmiller/godice"
+	"k8s.io/klog/v2"
+)
+
+// Algorithms which titles may be compared with
+const (
+	// DiceSimilarity compares the character bigrams of titles
+	DiceSimilarity = "dice"
+	// TokenSimilarity is the overlap between the words of titles
+	TokenSimilarity = "tokens"
+	// TFIDFSimilarity is the cosine similarity of titles, with words weighted by how rare they are
+	TFIDFSimilarity = "tfidf"
+	// EmbeddingSimilarity is the cosine similarity of vectors from an external embedding service
+	EmbeddingSimilarity = "embeddings"
+)
+
+// SimilarityAlgorithms are the supported similarity algorithms
+var SimilarityAlgorithms = []string{DiceSimilarity, TokenSimilarity, TFIDFSimilarity, EmbeddingSimilarity}
+
+// Embedder returns an embedding vector for each text, in order
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+const (
+	// embedBatch is how many texts are sent to the embedding service at once
+	embedBatch = 64
+	// maxEmbedBody is how many characters of a body are embedded
+	maxEmbedBody = 4000
+)
+
+// Scopes which similar items may be found in
+const (
+	// AllRepos finds similar items in any searched repository, as duplicates are often filed against the wrong one
+	AllRepos = "all"
+	// SameOrganization finds similar items in repositories of the same organization
+	SameOrganization = "organization"
+	// SameRepo finds similar items in the same repository
+	SameRepo = "repo"
+)
+
+// SimilarityScopes are the supported similarity scopes
+var SimilarityScopes = []string{AllRepos, SameOrganization, SameRepo}
+
+// CheckSimilarityAlgorithm returns an error if an algorithm is not supported
+func CheckSimilarityAlgorithm(a string) error {
+	if a == "" {
+		return nil
+	}
+	for _, s := range SimilarityAlgorithms {
+		if a == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown similarity algorithm %q, expected one of %v", a, SimilarityAlgorithms)
+}
+
+// CheckSimilarityScope returns an error if a scope is not supported
+func CheckSimilarityScope(s string) error {
+	if s == "" {
+		return nil
+	}
+	for _, ss := range SimilarityScopes {
+		if s == ss {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown similarity scope %q, expected one of %v", s, SimilarityScopes)
+}
+
+// inScope returns whether an item in one repository may be listed as similar to an item in another
+func (h *Engine) inScope(org string, project string, otherOrg string, otherProject string) bool {
+	switch h.similarityScope {
+	case SameRepo:
+		return org == otherOrg && project == otherProject
+	case SameOrganization:
+		return org == otherOrg
+	default:
+		return true
+	}
+}
+
+// similarity returns how similar two normalized titles are, from 0 to 1
+func (h *Engine) similarity(a string, b string) float64 {
+	switch h.similarityAlgorithm {
+	case TokenSimilarity:
+		return tokenOverlap(strings.Fields(a), strings.Fields(b))
+	case TFIDFSimilarity:
+		return h.tfidfCosine(strings.Fields(a), strings.Fields(b))
+	case EmbeddingSimilarity:
+		va, aok := h.vectors.Load(a)
+		vb, bok := h.vectors.Load(b)
+		if aok && bok {
+			return denseCosine(va.([]float64), vb.([]float64))
+		}
+		// Fall back for titles which could not be embedded
+		return godice.CompareString(a, b)
+	default:
+		return godice.CompareString(a, b)
+	}
+}
+
+// embedText is a title, along with what is embedded for it
+type embedText struct {
+	title string
+	text  string
+}
+
+// embed fetches embeddings for titles which do not have one yet
+func (h *Engine) embed(ctx context.Context, ts []embedText) {
+	if h.similarityAlgorithm != EmbeddingSimilarity || h.embedder == nil || h.MinSimilarity == 0 {
+		return
+	}
+
+	todo := []embedText{}
+	seen := map[string]bool{}
+	for _, t := range ts {
+		t.title = normalizeTitle(t.title)
+		if seen[t.title] {
+			continue
+		}
+		seen[t.title] = true
+		if _, ok := h.vectors.Load(t.title); !ok {
+			todo = append(todo, t)
+		}
+	}
+
+	for start := 0; start < len(todo); start += embedBatch {
+		end := start + embedBatch
+		if end > len(todo) {
+			end = len(todo)
+		}
+
+		texts := []string{}
+		for _, t := range todo[start:end] {
+			texts = append(texts, t.text)
+		}
+
+		vs, err := h.embedder.Embed(ctx, texts)
+		if err != nil {
+			klog.Errorf("embed %d texts: %v", len(texts), err)
+			return
+		}
+		for i, t := range todo[start:end] {
+			h.vectors.Store(t.title, vs[i])
+		}
+	}
+}
+
+// embedTextOf returns what is embedded for an item
+func (h *Engine) embedTextOf(title string, body string) embedText {
+	if !h.embedBodies || body == "" {
+		return embedText{title: title, text: title}
+	}
+	if r := []rune(body); len(r) > maxEmbedBody {
+		body = string(r[:maxEmbedBody])
+	}
+	return embedText{title: title, text: title + "\n\n" + body}
+}
+
+// denseCosine returns the cosine similarity of two vectors
+func denseCosine(a []float64, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	dot, na, nb := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// tokenOverlap returns the Jaccard index of two sets of words
+func tokenOverlap(a []string, b []string) float64 {
+	as := map[string]bool{}
+	for _, w := range a {
+		as[w] = true
+	}
+	bs := map[string]bool{}
+	for _, w := range b {
+		bs[w] = true
+	}
+
+	both := 0
+	for w := range as {
+		if bs[w] {
+			both++
+		}
+	}
+
+	either := len(as) + len(bs) - both
+	if either == 0 {
+		return 0
+	}
+	return float64(both) / float64(either)
+}
+
+// addTerms records the words of a newly seen title, for document frequencies
+func (h *Engine) addTerms(title string) {
+	if h.similarityAlgorithm != TFIDFSimilarity {
+		return
+	}
+
+	h.termMu.Lock()
+	defer h.termMu.Unlock()
+
+	h.docs++
+	seen := map[string]bool{}
+	for _, w := range strings.Fields(title) {
+		if !seen[w] {
+			seen[w] = true
+			h.termDocs[w]++
+		}
+	}
+}
+
+// tfidfCosine returns the cosine similarity of the TF-IDF vectors of two sets of words
+func (h *Engine) tfidfCosine(a []string, b []string) float64 {
+	h.termMu.Lock()
+	defer h.termMu.Unlock()
+	return cosine(tfidf(a, h.termDocs, h.docs), tfidf(b, h.termDocs, h.docs))
+}
+
+// tfidf returns the TF-IDF weights of words, given how many of a number of documents contain each word
+func tfidf(words []string, termDocs map[string]int, docs int) map[string]float64 {
+	v := map[string]float64{}
+	for _, w := range words {
+		v[w]++
+	}
+	for w, tf := range v {
+		// Smoothed, so that words in every title still count for a little
+		v[w] = tf * (math.Log(float64(docs+1)/float64(termDocs[w]+1)) + 1)
+	}
+	return v
+}
+
+// cosine returns the cosine similarity of two sparse vectors
+func cosine(a map[string]float64, b map[string]float64) float64 {
+	dot, na, nb := 0.0, 0.0, 0.0
+	for k, x := range a {
+		na += x * x
+		dot += x * b[k]
+	}
+	for _, y := range b {
+		nb += y * y
+	}
+
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
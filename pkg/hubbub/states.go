@@ -0,0 +1,207 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+	"k8s.io/klog/v2"
+)
+
+// relabelWindow is how close together removing one state label and adding another must be to count as a single transition
+const relabelWindow = time.Minute
+
+// TriageState is a named step of triage, such as needs-info, which items are in when they have its label
+type TriageState struct {
+	Name string
+	// Label marks items in this state. Items without any state label are in the state without one, if any.
+	Label string
+	// Next are the names of the states which items may move to from this one
+	Next []string
+}
+
+// CheckTriageStates returns an error if a list of triage states can not be used
+func CheckTriageStates(ss []TriageState) error {
+	names := map[string]bool{}
+	labels := map[string]bool{}
+	unlabeled := ""
+
+	for _, s := range ss {
+		if s.Name == "" {
+			return fmt.Errorf("state name is required")
+		}
+		if names[s.Name] {
+			return fmt.Errorf("duplicate state %q", s.Name)
+		}
+		names[s.Name] = true
+
+		if s.Label == "" {
+			if unlabeled != "" {
+				return fmt.Errorf("states %q and %q both lack a label: only one state may be for unlabeled items", unlabeled, s.Name)
+			}
+			unlabeled = s.Name
+			continue
+		}
+		if labels[s.Label] {
+			return fmt.Errorf("state %q: label %q is used by another state", s.Name, s.Label)
+		}
+		labels[s.Label] = true
+	}
+
+	for _, s := range ss {
+		for _, n := range s.Next {
+			if !names[n] {
+				return fmt.Errorf("state %q: next state %q is undefined", s.Name, n)
+			}
+		}
+	}
+	return nil
+}
+
+// LookupTriageState returns the triage state with a name
+func LookupTriageState(ss []TriageState, name string) (TriageState, bool) {
+	for _, s := range ss {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return TriageState{}, false
+}
+
+// CanTransition returns whether items may move from one triage state to another
+func CanTransition(ss []TriageState, from string, to string) bool {
+	if from == "" || from == to {
+		return true
+	}
+
+	s, ok := LookupTriageState(ss, from)
+	if !ok {
+		return true
+	}
+	for _, n := range s.Next {
+		if n == to {
+			return true
+		}
+	}
+	return false
+}
+
+// unlabeledTriageState returns the name of the state for items without a state label, if any
+func unlabeledTriageState(ss []TriageState) string {
+	for _, s := range ss {
+		if s.Label == "" {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// triageStateForLabel returns the name of the state a label marks, if any
+func triageStateForLabel(ss []TriageState, label string) string {
+	if label == "" {
+		return ""
+	}
+	for _, s := range ss {
+		if sameLabel(s.Label, label) {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// setTriageState sets the triage state of a conversation from its labels
+func (h *Engine) setTriageState(co *Conversation) {
+	if len(h.triageStates) == 0 {
+		return
+	}
+
+	found := []string{}
+	for _, s := range h.triageStates {
+		for _, l := range co.Labels {
+			if s.Label != "" && sameLabel(l.GetName(), s.Label) {
+				found = append(found, s.Name)
+			}
+		}
+	}
+
+	if len(found) > 1 {
+		klog.V(1).Infof("#%d has labels of several triage states: %v", co.ID, found)
+		co.Tags[tag.StateConflict] = true
+	}
+
+	if len(found) > 0 {
+		co.TriageState = found[0]
+	} else {
+		co.TriageState = unlabeledTriageState(h.triageStates)
+	}
+
+	if co.TriageStateChanged.IsZero() && co.TriageState != "" {
+		co.TriageStateChanged = co.Created
+	}
+}
+
+// stateTracker follows the triage state of a conversation through its timeline
+type stateTracker struct {
+	states []TriageState
+
+	current string
+	// removed is the state whose label was last removed, and when
+	removed   string
+	removedAt time.Time
+}
+
+// newStateTracker returns a tracker starting in the state for unlabeled items
+func newStateTracker(ss []TriageState) *stateTracker {
+	return &stateTracker{states: ss, current: unlabeledTriageState(ss)}
+}
+
+// event updates the triage state of a conversation from a timeline event
+func (st *stateTracker) event(co *Conversation, t *provider.Timeline) {
+	if len(st.states) == 0 {
+		return
+	}
+
+	name := triageStateForLabel(st.states, t.GetLabel().GetName())
+	if name == "" {
+		return
+	}
+
+	switch t.GetEvent() {
+	case "labeled":
+		from := st.current
+		// Labels are often swapped by removing the old one first
+		if st.removed != "" && t.GetCreatedAt().Sub(st.removedAt) <= relabelWindow {
+			from = st.removed
+		}
+		if !CanTransition(st.states, from, name) {
+			klog.V(1).Infof("#%d moved from triage state %q to %q, which is not allowed", co.ID, from, name)
+			co.Tags[tag.InvalidTransition] = true
+		}
+		st.current = name
+		st.removed = ""
+		co.TriageStateChanged = t.GetCreatedAt()
+	case "unlabeled":
+		if name != st.current {
+			return
+		}
+		st.removed = name
+		st.removedAt = t.GetCreatedAt()
+		st.current = unlabeledTriageState(st.states)
+		co.TriageStateChanged = t.GetCreatedAt()
+	}
+}
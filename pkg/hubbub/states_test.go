@@ -0,0 +1,96 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/stretchr/testify/assert"
+)
+
+var testStates = []TriageState{
+	{Name: "new", Next: []string{"needs-info", "accepted"}},
+	{Name: "needs-info", Label: "triage/needs-information", Next: []string{"accepted"}},
+	{Name: "accepted", Label: "triage/accepted", Next: []string{"scheduled"}},
+	{Name: "scheduled", Label: "triage/scheduled"},
+}
+
+func TestCheckTriageStates(t *testing.T) {
+	assert.Nil(t, CheckTriageStates(testStates))
+	assert.NotNil(t, CheckTriageStates([]TriageState{{Name: "a"}, {Name: "b"}}))
+	assert.NotNil(t, CheckTriageStates([]TriageState{{Name: "a", Label: "x"}, {Name: "b", Label: "x"}}))
+	assert.NotNil(t, CheckTriageStates([]TriageState{{Name: "a", Next: []string{"b"}}}))
+	assert.NotNil(t, CheckTriageStates([]TriageState{{Name: "a"}, {Name: "a", Label: "x"}}))
+}
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(testStates, "new", "needs-info"))
+	assert.True(t, CanTransition(testStates, "accepted", "accepted"))
+	assert.True(t, CanTransition(testStates, "", "scheduled"))
+	assert.False(t, CanTransition(testStates, "new", "scheduled"))
+	assert.False(t, CanTransition(testStates, "scheduled", "new"))
+}
+
+func TestTriageStateTimeline(t *testing.T) {
+	created := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	labeled, unlabeled := "labeled", "unlabeled"
+	needsInfo, accepted, scheduled := "triage/needs-information", "triage/accepted", "triage/scheduled"
+	first, swapped, skipped := created.Add(24*time.Hour), created.Add(48*time.Hour), created.Add(72*time.Hour)
+
+	ev := func(event *string, label *string, at time.Time) *provider.Timeline {
+		return &provider.Timeline{Event: event, CreatedAt: &at, Label: &provider.Label{Name: label}}
+	}
+
+	tests := []struct {
+		name     string
+		timeline []*provider.Timeline
+		invalid  bool
+		changed  time.Time
+	}{
+		{"none", nil, false, time.Time{}},
+		{"allowed", []*provider.Timeline{ev(&labeled, &needsInfo, first)}, false, first},
+		{"swapped", []*provider.Timeline{ev(&labeled, &needsInfo, first), ev(&unlabeled, &needsInfo, swapped), ev(&labeled, &accepted, swapped)}, false, swapped},
+		{"skipped", []*provider.Timeline{ev(&labeled, &needsInfo, first), ev(&labeled, &scheduled, skipped)}, true, skipped},
+	}
+
+	for _, tc := range tests {
+		co := &Conversation{Tags: map[tag.Tag]bool{}}
+		st := newStateTracker(testStates)
+		for _, e := range tc.timeline {
+			st.event(co, e)
+		}
+		assert.Equal(t, tc.invalid, co.Tags[tag.InvalidTransition], tc.name)
+		assert.Equal(t, tc.changed, co.TriageStateChanged, tc.name)
+	}
+}
+
+func TestSetTriageState(t *testing.T) {
+	created := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	names := []string{"triage/accepted", "triage/scheduled", "kind/bug"}
+	h := &Engine{triageStates: testStates}
+
+	co := &Conversation{Created: created, Tags: map[tag.Tag]bool{}, Labels: []*provider.Label{{Name: &names[2]}}}
+	h.setTriageState(co)
+	assert.Equal(t, "new", co.TriageState)
+	assert.Equal(t, created, co.TriageStateChanged)
+
+	co = &Conversation{Created: created, Tags: map[tag.Tag]bool{}, Labels: []*provider.Label{{Name: &names[1]}, {Name: &names[0]}}}
+	h.setTriageState(co)
+	assert.Equal(t, "accepted", co.TriageState)
+	assert.True(t, co.Tags[tag.StateConflict])
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import "regexp"
+
+// taskRe matches markdown task list items, such as "- [x] write docs"
+var taskRe = regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+[.)])\s+\[([ xX])\]\s`)
+
+// countTasks returns how many of the task list items in a body are completed, and how many there are
+func countTasks(body string) (int, int) {
+	body = codeRe.ReplaceAllString(body, "")
+
+	done := 0
+	ms := taskRe.FindAllStringSubmatch(body, -1)
+	for _, m := range ms {
+		if m[1] != " " {
+			done++
+		}
+	}
+	return done, len(ms)
+}
+
+// TasksPercent returns the percentage of task list items which are completed, or -1 if there are none
+func (co *Conversation) TasksPercent() float64 {
+	if co.TasksTotal == 0 {
+		return -1
+	}
+	return float64(co.TasksCompleted) * 100 / float64(co.TasksTotal)
+}
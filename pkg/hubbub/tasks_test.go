@@ -0,0 +1,40 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountTasks(t *testing.T) {
+	tests := []struct {
+		body  string
+		done  int
+		total int
+	}{
+		{"no tasks here", 0, 0},
+		{"- [x] design\n- [ ] implement\n* [X] review\n1. [ ] release", 2, 4},
+		{"  - [x] nested\n- [] not a task\n-[ ] nor this", 1, 1},
+		{"```\n- [ ] in a code block\n```\n- [x] outside", 1, 1},
+	}
+
+	for _, tc := range tests {
+		done, total := countTasks(tc.body)
+		assert.Equal(t, tc.done, done, tc.body)
+		assert.Equal(t, tc.total, total, tc.body)
+	}
+}
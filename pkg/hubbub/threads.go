@@ -0,0 +1,102 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+	"k8s.io/klog/v2"
+)
+
+// maxThreadsAge is how long review thread counts are cached for, as resolving a thread does not always update the PR
+const maxThreadsAge = 6 * time.Hour
+
+// NeedsUnresolvedThreads returns whether the filters require the review threads of pull requests, which costs a request each
+func NeedsUnresolvedThreads(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.UnresolvedThreads != "" {
+			return true
+		}
+		if f.TagRegex() != nil {
+			if ok, _ := matchTag(map[tag.Tag]bool{tag.Unresolved: true}, f.TagRegex(), false); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setUnresolvedThreads sets how many review threads of an open PR are unresolved, if known
+func (h *Engine) setUnresolvedThreads(ctx context.Context, sp provider.SearchParams, co *Conversation, fetch bool) {
+	if co.Type != PullRequest || co.State == "closed" || co.State == "merged" {
+		return
+	}
+
+	sp.IssueNumber = co.ID
+	sp.Fetch = fetch
+	if sp.NewerThan.Before(co.Updated) {
+		sp.NewerThan = co.Updated
+	}
+	if oldest := time.Now().Add(-maxThreadsAge); sp.NewerThan.Before(oldest) {
+		sp.NewerThan = oldest
+	}
+
+	n, ok, err := h.cachedUnresolvedThreads(ctx, sp)
+	if err != nil {
+		klog.Errorf("unresolved threads: %v", err)
+	}
+	if !ok {
+		return
+	}
+
+	co.UnresolvedThreads = &n
+	if n > 0 {
+		co.Tags[tag.Unresolved] = true
+	}
+}
+
+func (h *Engine) cachedUnresolvedThreads(ctx context.Context, sp provider.SearchParams) (int, bool, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%d-pr-threads", RepoKey(sp.Repo), sp.IssueNumber)
+
+	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.UnresolvedThreads, true, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s (fetch=%v)", sp.SearchKey, sp.NewerThan, sp.Fetch)
+	if !sp.Fetch {
+		return 0, false, nil
+	}
+	return h.updateUnresolvedThreads(ctx, sp)
+}
+
+func (h *Engine) updateUnresolvedThreads(ctx context.Context, sp provider.SearchParams) (int, bool, error) {
+	klog.V(1).Infof("Listing review threads of %s/%s #%d", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+
+	n, resp, err := h.provider(sp.Repo.Host).PullRequestsUnresolvedThreads(ctx, sp)
+	if err != nil {
+		return 0, false, err
+	}
+	h.logRate(sp.Repo.Host, resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &persist.Blob{UnresolvedThreads: n}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+	return n, true, nil
+}
@@ -28,7 +28,7 @@ import (
 )
 
 func (h *Engine) cachedTimeline(ctx context.Context, sp provider.SearchParams) ([]*provider.Timeline, error) {
-	sp.SearchKey = fmt.Sprintf("%s-%s-%d-timeline", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	sp.SearchKey = fmt.Sprintf("%s-%d-timeline", RepoKey(sp.Repo), sp.IssueNumber)
 	klog.V(1).Infof("Need timeline for %s as of %s", sp.SearchKey, sp.NewerThan)
 
 	if x := h.cache.Get(sp.SearchKey, sp.NewerThan); x != nil {
@@ -56,7 +56,7 @@ func (h *Engine) updateTimeline(ctx context.Context, sp provider.SearchParams) (
 		if err != nil {
 			return nil, err
 		}
-		h.logRate(resp.Rate)
+		h.logRate(sp.Repo.Host, resp.Rate)
 
 		for _, ev := range evs {
 			h.updateMtimeLong(sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, ev.GetCreatedAt())
@@ -92,15 +92,38 @@ func (h *Engine) addEvents(ctx context.Context, sp provider.SearchParams, co *Co
 	}
 
 	thisRepo := fmt.Sprintf("%s/%s", co.Organization, co.Project)
+	draft := false
+	autoMerge := false
+	st := newStateTracker(h.triageStates)
 
 	for _, t := range timeline {
 		if h.debug[co.ID] {
 			klog.Errorf("debug timeline event %q: %s", t.GetEvent(), formatStruct(t))
 		}
 
+		switch t.GetEvent() {
+		case "convert_to_draft":
+			draft = true
+		case "ready_for_review":
+			draft = false
+			co.ReadyForReview = t.GetCreatedAt()
+		case "auto_merge_enabled", "auto_squash_enabled", "auto_rebase_enabled":
+			autoMerge = true
+		case "auto_merge_disabled", "merged":
+			autoMerge = false
+		case "deployed":
+			co.Tags[tag.Deployed] = true
+		case "head_ref_force_pushed":
+			co.LatestForcePush = t.GetCreatedAt()
+		case "transferred":
+			co.TransferredAt = t.GetCreatedAt()
+			co.Tags[tag.Transferred] = true
+		}
+
 		if t.GetEvent() == "labeled" && t.GetLabel().GetName() == priority {
 			co.Prioritized = t.GetCreatedAt()
 		}
+		st.event(co, t)
 
 		if t.GetEvent() == "cross-referenced" {
 			if assignedTo[t.GetActor().GetLogin()] {
@@ -142,6 +165,16 @@ func (h *Engine) addEvents(ctx context.Context, sp provider.SearchParams, co *Co
 			}
 		}
 	}
+
+	if draft {
+		co.Tags[tag.Draft] = true
+	} else if !co.ReadyForReview.IsZero() {
+		co.Tags[tag.ReadyForReview] = true
+	}
+
+	if autoMerge {
+		co.Tags[tag.AutoMerge] = true
+	}
 }
 
 func (h *Engine) prRef(ctx context.Context, sp provider.SearchParams, pr provider.IItem) *RelatedConversation {
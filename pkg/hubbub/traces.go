@@ -0,0 +1,213 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultTraceWeight is how much matching stack traces count for, when both items have one
+const DefaultTraceWeight = 0.8
+
+// minTraceSignatures is how many signatures a body needs to be considered to have a trace
+const minTraceSignatures = 2
+
+var (
+	// frameRes match stack frames, capturing the function (and file, where it is all that identifies a frame)
+	frameRes = []*regexp.Regexp{
+		// Java, Kotlin & JavaScript: "at com.example.Foo.bar(Foo.java:12)", "at render (app.js:1:2)"
+		regexp.MustCompile(`^\s*at\s+([\w$.<>\[\]/]+)\s*\(`),
+		// Go: "github.com/org/repo/pkg.(*T).Method(0xc000, ...)" or "main.main()"
+		regexp.MustCompile(`^\s*((?:[\w.-]+/)*[\w-]+\.(?:\(\*?\w+\)\.)?[\w.]+)\(.*\)\s*$`),
+		// Python: File "/path/to/app.py", line 12, in handler
+		regexp.MustCompile(`^\s*File "(?:[^"]*/)?([^"/]+)", line \d+, in (\S+)`),
+		// Ruby: "from /path/to/app.rb:12:in `handler'"
+		regexp.MustCompile("^\\s*(?:from\\s+)?(?:\\S*/)?(\\S+\\.rb):\\d+:in [`']([^']+)'"),
+	}
+
+	// exceptionRe matches the type of an exception or panic, like "java.lang.NullPointerException: ..."
+	exceptionRe = regexp.MustCompile(`^\s*(?:Caused by:\s+|Exception in thread "[^"]*"\s+)?((?:[\w$]+\.)*[\w$]*(?:Exception|Error)|panic):`)
+
+	// errorLineRe matches lines within code blocks which report an error
+	errorLineRe = regexp.MustCompile(`(?i)\b(?:error|exception|panic|fatal|failed|segmentation fault)\b`)
+
+	// Variable parts of error lines
+	hexRe    = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	quotedRe = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	pathRe   = regexp.MustCompile(`(?:[\w.-]*/)+[\w.-]+`)
+	numberRe = regexp.MustCompile(`\d+`)
+	spaceRe  = regexp.MustCompile(`\s+`)
+)
+
+// trace is what is known about the stack traces and error output of an item
+type trace struct {
+	// title is the normalized title of the item
+	title string
+	sigs  map[string]bool
+}
+
+// traceSignatures returns the normalized stack frames, exception types and error lines found in a body
+func traceSignatures(body string) []string {
+	sigs := []string{}
+	seen := map[string]bool{}
+	add := func(s string) {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			sigs = append(sigs, s)
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		if m := exceptionRe.FindStringSubmatch(line); m != nil {
+			add("exception:" + m[1])
+			continue
+		}
+		for _, re := range frameRes {
+			if m := re.FindStringSubmatch(line); m != nil {
+				add("frame:" + strings.Join(m[1:], ":"))
+				break
+			}
+		}
+	}
+
+	// Error output without a trace, such as a failed command
+	for _, block := range codeRe.FindAllString(body, -1) {
+		for _, line := range strings.Split(strings.Trim(block, "`"), "\n") {
+			if errorLineRe.MatchString(line) {
+				add("error:" + normalizeErrorLine(line))
+			}
+		}
+	}
+
+	return sigs
+}
+
+// normalizeErrorLine removes the parts of an error line which vary between reports, such as addresses and paths
+func normalizeErrorLine(l string) string {
+	l = hexRe.ReplaceAllString(l, "")
+	l = quotedRe.ReplaceAllString(l, `""`)
+	l = pathRe.ReplaceAllString(l, "/")
+	l = numberRe.ReplaceAllString(l, "")
+	l = strings.ToLower(strings.TrimSpace(spaceRe.ReplaceAllString(l, " ")))
+	if r := []rune(l); len(r) > 200 {
+		l = string(r[:200])
+	}
+	return l
+}
+
+// updateTraces indexes the stack traces in the body of an item
+func (h *Engine) updateTraces(rawTitle string, url string, body string) {
+	if h.MinSimilarity == 0 || h.traceWeight == 0 || h.excludedTitle(rawTitle) {
+		return
+	}
+
+	sigs := traceSignatures(body)
+	if len(sigs) < minTraceSignatures {
+		sigs = nil
+	}
+
+	h.traceMu.Lock()
+	defer h.traceMu.Unlock()
+
+	if old, ok := h.traces[url]; ok {
+		for s := range old.sigs {
+			h.traceURLs[s] = without(h.traceURLs[s], url)
+		}
+		delete(h.traces, url)
+	}
+
+	if len(sigs) == 0 {
+		return
+	}
+
+	t := &trace{title: normalizeTitle(rawTitle), sigs: map[string]bool{}}
+	for _, s := range sigs {
+		t.sigs[s] = true
+		h.traceURLs[s] = append(h.traceURLs[s], url)
+	}
+	h.traces[url] = t
+}
+
+// without returns a list of strings without one of them
+func without(ss []string, s string) []string {
+	out := ss[:0]
+	for _, x := range ss {
+		if x != s {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// traceMatches returns the normalized titles of items which share part of a stack trace with an item, by URL
+func (h *Engine) traceMatches(url string) map[string]string {
+	h.traceMu.Lock()
+	defer h.traceMu.Unlock()
+
+	ms := map[string]string{}
+	t, ok := h.traces[url]
+	if !ok {
+		return ms
+	}
+
+	for s := range t.sigs {
+		for _, u := range h.traceURLs[s] {
+			if u != url {
+				ms[u] = h.traces[u].title
+			}
+		}
+	}
+	return ms
+}
+
+// traceSimilarity returns the overlap between the stack traces of two items, or 0 if either has none
+func (h *Engine) traceSimilarity(a string, b string) float64 {
+	h.traceMu.Lock()
+	defer h.traceMu.Unlock()
+
+	ta, aok := h.traces[a]
+	tb, bok := h.traces[b]
+	if !aok || !bok {
+		return 0
+	}
+
+	both := 0
+	for s := range ta.sigs {
+		if tb.sigs[s] {
+			both++
+		}
+	}
+	return float64(both) / float64(len(ta.sigs)+len(tb.sigs)-both)
+}
+
+// score returns how similar two items are, weighing matching stack traces separately from their other fields
+func (h *Engine) score(title string, url string, otherTitle string, otherURL string) float64 {
+	s := h.fieldSimilarity(title, url, otherTitle, otherURL)
+	if h.traceWeight == 0 {
+		return s
+	}
+
+	t := h.traceSimilarity(url, otherURL)
+	if t == 0 {
+		return s
+	}
+
+	// Traces only add evidence: differing traces in reports with alike titles are common too
+	if c := h.traceWeight*t + (1-h.traceWeight)*s; c > s {
+		return c
+	}
+	return s
+}
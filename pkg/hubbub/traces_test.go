@@ -0,0 +1,65 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceSignatures(t *testing.T) {
+	goPanic := "Crashes on startup\n\n```\npanic: runtime error: invalid memory address or nil pointer dereference\n" +
+		"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a5b2c]\n\ngoroutine 1 [running]:\n" +
+		"github.com/google/triage-party/pkg/hubbub.(*Engine).FindSimilar(0xc0000b6000, 0x0)\n" +
+		"\t/home/user/src/pkg/hubbub/similar.go:185 +0x2c\nmain.main()\n\t/home/user/src/cmd/server/main.go:120 +0x1d\n```\n"
+
+	assert.Equal(t, []string{
+		"exception:panic",
+		"frame:github.com/google/triage-party/pkg/hubbub.(*Engine).FindSimilar",
+		"frame:main.main",
+		"error:panic: runtime error: invalid memory address or nil pointer dereference",
+	}, traceSignatures(goPanic))
+
+	java := "Exception in thread \"main\" java.lang.NullPointerException: oops\n" +
+		"\tat com.example.Foo.bar(Foo.java:12)\n\tat com.example.Main.main(Main.java:3)\n"
+	assert.Equal(t, []string{
+		"exception:java.lang.NullPointerException",
+		"frame:com.example.Foo.bar",
+		"frame:com.example.Main.main",
+	}, traceSignatures(java))
+
+	python := "Traceback (most recent call last):\n  File \"/srv/app/handler.py\", line 12, in handle\n" +
+		"KeyError: 'name'\n"
+	assert.Equal(t, []string{"frame:handler.py:handle", "exception:KeyError"}, traceSignatures(python))
+
+	assert.Empty(t, traceSignatures("It would be nice to have a dark mode. See main.go for details."))
+}
+
+func TestTraceScore(t *testing.T) {
+	e := New(Config{MinSimilarity: 0.75, TraceWeight: DefaultTraceWeight})
+	trace := "```\npanic: boom\n\nmain.run(0x1)\nmain.main()\n```"
+	e.updateTraces("Server exits when the cache is cold", "a", "On a fresh start:\n"+trace)
+	e.updateTraces("Panic after upgrading to 1.4", "b", "Upgrade went badly\n"+trace)
+	e.updateTraces("Add a dark mode", "c", "Please")
+
+	assert.Equal(t, map[string]string{"b": "panic after upgrading"}, e.traceMatches("a"))
+	assert.Greater(t, e.score("server exits when cache cold", "a", "panic after upgrading", "b"), 0.75)
+	assert.Less(t, e.score("server exits when cache cold", "a", "dark mode", "c"), 0.75)
+
+	// A body which no longer has a trace is removed from the index
+	e.updateTraces("Panic after upgrading to 1.4", "b", "Never mind")
+	assert.Empty(t, e.traceMatches("a"))
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+
+	"k8s.io/klog/v2"
+)
+
+// originKey identifies an issue across repositories, as transferred issues keep their author and creation time
+func originKey(co *Conversation) string {
+	return fmt.Sprintf("%s@%d", co.Author.GetLogin(), co.Created.Unix())
+}
+
+// trackTransfer links a transferred issue with the URL it was transferred from, once both have been seen.
+// It returns the URL a conversation was transferred to, if it is the source of a transfer.
+func (h *Engine) trackTransfer(co *Conversation) string {
+	if co.Type != Issue || co.Created.IsZero() {
+		return ""
+	}
+
+	key := originKey(co)
+
+	h.transferMu.Lock()
+	defer h.transferMu.Unlock()
+
+	prev, ok := h.origins[key]
+	if !ok || prev == co.URL {
+		h.origins[key] = co.URL
+		return ""
+	}
+
+	from, to := prev, co.URL
+	if co.TransferredAt.IsZero() {
+		// Only the destination of a transfer has a transferred event, so this may be a stale listing of the source
+		dest := h.cachedConversation(prev)
+		if dest == nil || dest.TransferredAt.IsZero() {
+			return ""
+		}
+		from, to = co.URL, prev
+	}
+
+	if h.transfers[from] != to {
+		klog.Infof("%s was transferred to %s", from, to)
+	}
+	h.transfers[from] = to
+	h.origins[key] = to
+	h.seen.Delete(from)
+
+	if dest := h.cachedConversation(to); dest != nil {
+		dest.TransferredFrom = from
+	}
+	if to == co.URL {
+		co.TransferredFrom = from
+		return ""
+	}
+	return to
+}
+
+// transferredTo returns the URL an issue was transferred to, if it is known to have been
+func (h *Engine) transferredTo(url string) string {
+	h.transferMu.Lock()
+	defer h.transferMu.Unlock()
+
+	// Issues may be transferred more than once
+	seen := map[string]bool{}
+	to := ""
+	for next, ok := h.transfers[url]; ok && !seen[next]; next, ok = h.transfers[next] {
+		seen[next] = true
+		to = next
+	}
+	return to
+}
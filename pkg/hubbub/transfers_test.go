@@ -0,0 +1,57 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackTransfer(t *testing.T) {
+	login := "alice"
+	created := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	conversation := func(url string) *Conversation {
+		return &Conversation{Type: Issue, URL: url, Author: &provider.User{Login: &login}, Created: created}
+	}
+
+	oldURL := "https://github.com/google/triage-party/issues/1"
+	newURL := "https://github.com/google/other/issues/7"
+
+	for _, destFirst := range []bool{false, true} {
+		e := New(Config{})
+		src := conversation(oldURL)
+		dest := conversation(newURL)
+		dest.TransferredAt = created.Add(time.Hour)
+
+		e.updateConversationCache(src.URL, src)
+		e.updateConversationCache(dest.URL, dest)
+
+		if destFirst {
+			assert.Equal(t, "", e.trackTransfer(dest))
+			assert.Equal(t, newURL, e.trackTransfer(src))
+		} else {
+			assert.Equal(t, "", e.trackTransfer(src))
+			assert.Equal(t, "", e.trackTransfer(dest))
+		}
+
+		assert.Equal(t, oldURL, dest.TransferredFrom)
+		assert.Equal(t, newURL, e.transferredTo(oldURL))
+		assert.Equal(t, dest, e.LookupConversation(oldURL))
+		assert.Len(t, e.Conversations(), 1)
+	}
+}
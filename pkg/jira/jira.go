@@ -0,0 +1,146 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jira is a minimal client for the Jira REST API
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config is how to configure a new Jira client
+type Config struct {
+	// URL is the base URL of the Jira instance, such as https://example.atlassian.net
+	URL string
+	// User is the account name (usually an e-mail address) to authenticate as
+	User string
+	// Token is an API token or password for User
+	Token string
+}
+
+// Client is a Jira REST client
+type Client struct {
+	url   string
+	user  string
+	token string
+	http  *http.Client
+}
+
+// New returns a new Jira client
+func New(cfg Config) *Client {
+	return &Client{
+		url:   strings.TrimSuffix(cfg.URL, "/"),
+		user:  cfg.User,
+		token: cfg.Token,
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Issue is a newly created Jira issue
+type Issue struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+	URL string `json:"url"`
+}
+
+// NewIssue describes an issue to create
+type NewIssue struct {
+	Project     string
+	Type        string
+	Summary     string
+	Description string
+	Labels      []string
+}
+
+type createRequest struct {
+	Fields createFields `json:"fields"`
+}
+
+type createFields struct {
+	Project     keyRef   `json:"project"`
+	IssueType   nameRef  `json:"issuetype"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+type keyRef struct {
+	Key string `json:"key"`
+}
+
+type nameRef struct {
+	Name string `json:"name"`
+}
+
+// CreateIssue creates a new issue
+// https://developer.atlassian.com/cloud/jira/platform/rest/v2/api-group-issues/#api-rest-api-2-issue-post
+func (c *Client) CreateIssue(ctx context.Context, ni NewIssue) (*Issue, error) {
+	if c.url == "" {
+		return nil, fmt.Errorf("jira URL is not configured")
+	}
+
+	body, err := json.Marshal(createRequest{
+		Fields: createFields{
+			Project:     keyRef{Key: ni.Project},
+			IssueType:   nameRef{Name: ni.Type},
+			Summary:     ni.Summary,
+			Description: ni.Description,
+			Labels:      ni.Labels,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/rest/api/2/issue", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.token)
+	} else if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rb, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jira returned %s: %s", resp.Status, rb)
+	}
+
+	i := &Issue{}
+	if err := json.Unmarshal(rb, i); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	i.URL = fmt.Sprintf("%s/browse/%s", c.url, i.Key)
+	return i, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package live notifies open pages of changes to the results of collections
+package live
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// bufferSize is how many events a subscriber may fall behind by before events to it are dropped
+const bufferSize = 16
+
+// Event describes how the results of a collection changed
+type Event struct {
+	Collection string    `json:"collection"`
+	Created    time.Time `json:"created"`
+	Total      int       `json:"total"`
+	Rules      []*Rule   `json:"rules"`
+}
+
+// Rule describes how the results of a rule changed
+type Rule struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+	// Added, Removed and Updated are the URLs of items which entered the rule, left it, or changed within it
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+}
+
+// Hub passes events to the subscribers of each collection
+type Hub struct {
+	mu sync.Mutex
+	// last is when each item within each rule of each collection was last updated
+	last map[string]map[string]map[string]time.Time
+	subs map[chan *Event]string
+}
+
+// New returns a new hub
+func New() *Hub {
+	return &Hub{
+		last: map[string]map[string]map[string]time.Time{},
+		subs: map[chan *Event]string{},
+	}
+}
+
+// Publish passes how a collection result differs from the previous one to its subscribers. It is an updater hook.
+func (h *Hub) Publish(_ context.Context, r *triage.CollectionResult) {
+	if r.Collection == nil {
+		return
+	}
+	id := r.Collection.ID
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// The first result is described as adding every item, so that pages opened before a restart catch up
+	e, items := diff(id, h.last[id], r)
+	h.last[id] = items
+
+	for ch, sid := range h.subs {
+		if sid != id {
+			continue
+		}
+		select {
+		case ch <- e:
+		default:
+			klog.Warningf("live subscriber to %q is falling behind, dropping event", id)
+		}
+	}
+}
+
+// Subscribe returns the events of a collection, and a function to call once they are no longer wanted
+func (h *Hub) Subscribe(id string) (<-chan *Event, func()) {
+	ch := make(chan *Event, bufferSize)
+
+	h.mu.Lock()
+	h.subs[ch] = id
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// diff returns how a collection result differs from the previous items of its rules, and the items of its rules
+func diff(id string, prev map[string]map[string]time.Time, r *triage.CollectionResult) (*Event, map[string]map[string]time.Time) {
+	e := &Event{Collection: id, Created: r.Created, Total: r.Total}
+	items := map[string]map[string]time.Time{}
+
+	for _, rr := range r.RuleResults {
+		cur := map[string]time.Time{}
+		for _, co := range rr.Items {
+			cur[co.URL] = co.Updated
+		}
+		items[rr.Rule.ID] = cur
+
+		er := &Rule{ID: rr.Rule.ID, Total: len(rr.Items)}
+		old := prev[rr.Rule.ID]
+		for url, t := range cur {
+			ot, ok := old[url]
+			if !ok {
+				er.Added = append(er.Added, url)
+			} else if !ot.Equal(t) {
+				er.Updated = append(er.Updated, url)
+			}
+		}
+		for url := range old {
+			if _, ok := cur[url]; !ok {
+				er.Removed = append(er.Removed, url)
+			}
+		}
+
+		sort.Strings(er.Added)
+		sort.Strings(er.Removed)
+		sort.Strings(er.Updated)
+		e.Rules = append(e.Rules, er)
+	}
+	return e, items
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package live
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/stretchr/testify/assert"
+)
+
+func result(id string, items ...*hubbub.Conversation) *triage.CollectionResult {
+	return &triage.CollectionResult{
+		Collection:  &triage.Collection{ID: id},
+		Total:       len(items),
+		RuleResults: []*triage.RuleResult{{Rule: triage.Rule{ID: "r"}, Items: items}},
+	}
+}
+
+func TestPublish(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := &hubbub.Conversation{URL: "a", Updated: t0}
+	b := &hubbub.Conversation{URL: "b", Updated: t0}
+	b2 := &hubbub.Conversation{URL: "b", Updated: t0.Add(time.Hour)}
+	c := &hubbub.Conversation{URL: "c", Updated: t0}
+
+	h := New()
+	events, cancel := h.Subscribe("daily")
+	defer cancel()
+	other, cancelOther := h.Subscribe("weekly")
+	defer cancelOther()
+
+	ctx := context.Background()
+	h.Publish(ctx, result("daily", a, b))
+	e := <-events
+	assert.Equal(t, []string{"a", "b"}, e.Rules[0].Added)
+	assert.Equal(t, 2, e.Rules[0].Total)
+
+	h.Publish(ctx, result("daily", b2, c))
+	e = <-events
+	want := &Rule{ID: "r", Total: 2, Added: []string{"c"}, Removed: []string{"a"}, Updated: []string{"b"}}
+	assert.Equal(t, want, e.Rules[0])
+
+	select {
+	case e := <-other:
+		t.Errorf("unexpected event for another collection: %+v", e)
+	default:
+	}
+}
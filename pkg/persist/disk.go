@@ -116,3 +116,61 @@ func (d *Disk) Get(key string, t time.Time) *Blob {
 	setMem(d.memcache, key, &bl)
 	return &bl
 }
+
+// GetFresh returns a thing from disk, bypassing memory and the disk cache
+func (d *Disk) GetFresh(key string) *Blob {
+	r, err := d.dv.ReadStream(key, true)
+	if os.IsNotExist(err) {
+		d.memcache.Delete(key)
+		return nil
+	}
+	if err != nil {
+		klog.Errorf("disk read failed for %q: %v", key, err)
+		return nil
+	}
+	defer r.Close()
+
+	var bl Blob
+	if err := gob.NewDecoder(r).Decode(&bl); err != nil {
+		klog.Errorf("decode failed for %q: %v", key, err)
+		return nil
+	}
+
+	setMem(d.memcache, key, &bl)
+	return &bl
+}
+
+// Keys lists the keys on disk, with their file sizes and modification times
+func (d *Disk) Keys() ([]KeyInfo, error) {
+	ks := []KeyInfo{}
+	for k := range d.dv.Keys(nil) {
+		fi, err := os.Stat(filepath.Join(d.path, k))
+		if err != nil {
+			return nil, fmt.Errorf("stat: %w", err)
+		}
+		ks = append(ks, KeyInfo{Key: k, Size: int(fi.Size()), Saved: fi.ModTime()})
+	}
+	return ks, nil
+}
+
+// Delete removes a key from memory and disk
+func (d *Disk) Delete(key string) error {
+	d.memcache.Delete(key)
+	if !d.dv.Has(key) {
+		return nil
+	}
+	return d.dv.Erase(key)
+}
+
+// Ping returns an error if the cache directory is missing
+func (d *Disk) Ping() error {
+	if _, err := os.Stat(d.path); err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	return nil
+}
+
+// Flush does nothing, as disk is written immediately
+func (d *Disk) Flush() error {
+	return nil
+}
@@ -16,6 +16,9 @@
 package persist
 
 import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -41,7 +44,9 @@ func (m *Memory) String() string {
 }
 
 func (m *Memory) Initialize() error {
-	m.cache = createMem()
+	// Unlike the in-memory layer of other backends, this is the only copy, so nothing is expired:
+	// history, audit entries and API tokens must be kept for longer than memExpiration.
+	m.cache = cache.New(cache.NoExpiration, memCleanupInterval)
 	return nil
 }
 
@@ -56,6 +61,32 @@ func (m *Memory) Get(key string, t time.Time) *Blob {
 	return getMem(m.cache, key, t)
 }
 
+// GetFresh returns a thing, as memory is the backend
+func (m *Memory) GetFresh(key string) *Blob {
+	return getMem(m.cache, key, time.Time{})
+}
+
+// Keys lists the keys within memory, with their encoded sizes
+func (m *Memory) Keys() ([]KeyInfo, error) {
+	return memKeys(m.cache)
+}
+
+// Delete removes a key from memory
+func (m *Memory) Delete(key string) error {
+	m.cache.Delete(key)
+	return nil
+}
+
+// Ping always succeeds, as memory is always reachable
+func (m *Memory) Ping() error {
+	return nil
+}
+
+// Flush does nothing, as memory is written immediately
+func (m *Memory) Flush() error {
+	return nil
+}
+
 func createMem() *cache.Cache {
 	return cache.New(memExpiration, memCleanupInterval)
 }
@@ -69,6 +100,22 @@ func setMem(c *cache.Cache, key string, th *Blob) {
 	c.Set(key, th, 0)
 }
 
+func memKeys(c *cache.Cache) ([]KeyInfo, error) {
+	ks := []KeyInfo{}
+	for k, v := range c.Items() {
+		bl, ok := v.Object.(*Blob)
+		if !ok {
+			continue
+		}
+		var bs bytes.Buffer
+		if err := gob.NewEncoder(&bs).Encode(bl); err != nil {
+			return nil, fmt.Errorf("encode %q: %w", k, err)
+		}
+		ks = append(ks, KeyInfo{Key: k, Size: bs.Len(), Saved: bl.Created})
+	}
+	return ks, nil
+}
+
 func getMem(c *cache.Cache, key string, t time.Time) *Blob {
 	x, ok := c.Get(key)
 
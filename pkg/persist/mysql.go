@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"encoding/gob"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -49,6 +50,8 @@ type MySQL struct {
 	memcache *cache.Cache
 	db       *sqlx.DB
 	path     string
+	// pending tracks writes which are still in flight
+	pending sync.WaitGroup
 }
 
 // NewMySQL returns a new MySQL cache
@@ -89,7 +92,9 @@ func (m *MySQL) Set(key string, th *Blob) error {
 
 	setMem(m.memcache, key, th)
 
+	m.pending.Add(1)
 	go func() {
+		defer m.pending.Done()
 		b := new(bytes.Buffer)
 		ge := gob.NewEncoder(b)
 
@@ -99,7 +104,7 @@ func (m *MySQL) Set(key string, th *Blob) error {
 
 		_, err := m.db.Exec(`
 			INSERT INTO persist2 (k, v, saved) VALUES (?, ?, ?)
-			ON DUPLICATE KEY UPDATE k=VALUES(k), v=VALUES(v)`, key, b.Bytes(), time.Now())
+			ON DUPLICATE KEY UPDATE k=VALUES(k), v=VALUES(v), saved=VALUES(saved)`, key, b.Bytes(), time.Now())
 
 		if err != nil {
 			klog.Errorf("insert failed: %v", err)
@@ -122,6 +127,35 @@ func (m *MySQL) Get(key string, t time.Time) *Blob {
 		klog.Infof("get(%q) took %s", key, time.Since(start))
 	}()
 
+	bl := m.read(key)
+	if bl == nil {
+		return nil
+	}
+
+	if bl.Created.Before(t) {
+		klog.Warningf("found %s in db, but it was older than %s", key, t)
+		return nil
+	}
+
+	setMem(m.memcache, key, bl)
+	return bl
+}
+
+// GetFresh returns a Item from the database, bypassing memory, after in-flight writes are stored
+func (m *MySQL) GetFresh(key string) *Blob {
+	m.pending.Wait()
+	bl := m.read(key)
+	if bl == nil {
+		m.memcache.Delete(key)
+		return nil
+	}
+
+	setMem(m.memcache, key, bl)
+	return bl
+}
+
+// read decodes a Item from the database
+func (m *MySQL) read(key string) *Blob {
 	var mi sqlItem
 	err := m.db.Get(&mi, `SELECT * FROM persist2 WHERE k = ? LIMIT 1`, key)
 	if err == sql.ErrNoRows {
@@ -140,12 +174,43 @@ func (m *MySQL) Get(key string, t time.Time) *Blob {
 		klog.Errorf("decode failed for %s (saved %s, bytes: %d): %v", mi.Key, mi.Saved, len(mi.Value), err)
 		return nil
 	}
+	return &bl
+}
+
+// Keys lists the keys within the database
+func (m *MySQL) Keys() ([]KeyInfo, error) {
+	var rows []struct {
+		Key   string    `db:"k"`
+		Size  int       `db:"size"`
+		Saved time.Time `db:"saved"`
+	}
+	if err := m.db.Select(&rows, `SELECT k, LENGTH(v) AS size, saved FROM persist2 ORDER BY k`); err != nil {
+		return nil, fmt.Errorf("select: %w", err)
+	}
 
-	if bl.Created.Before(t) {
-		klog.Warningf("found %s in db, but it was older than %s", key, t)
-		return nil
+	ks := []KeyInfo{}
+	for _, r := range rows {
+		ks = append(ks, KeyInfo{Key: r.Key, Size: r.Size, Saved: r.Saved})
+	}
+	return ks, nil
+}
+
+// Delete removes a key from memory and the database
+func (m *MySQL) Delete(key string) error {
+	m.memcache.Delete(key)
+	if _, err := m.db.Exec(`DELETE FROM persist2 WHERE k = ?`, key); err != nil {
+		return fmt.Errorf("delete: %w", err)
 	}
+	return nil
+}
 
-	setMem(m.memcache, key, &bl)
-	return &bl
+// Ping returns an error if the database can not be reached
+func (m *MySQL) Ping() error {
+	return m.db.Ping()
+}
+
+// Flush waits for in-flight writes to the database
+func (m *MySQL) Flush() error {
+	m.pending.Wait()
+	return nil
 }
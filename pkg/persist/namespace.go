@@ -0,0 +1,87 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persist
+
+import (
+	"strings"
+	"time"
+)
+
+// Namespace is a cache which prefixes keys, so that multiple sites may share a backend
+type Namespace struct {
+	c      Cacher
+	prefix string
+}
+
+// NewNamespace returns a cache which stores keys within a namespace of an initialized cache
+func NewNamespace(c Cacher, ns string) *Namespace {
+	return &Namespace{c: c, prefix: ns + "-"}
+}
+
+func (n *Namespace) String() string {
+	return n.prefix + n.c.String()
+}
+
+// Initialize does nothing, as the underlying cache is shared
+func (n *Namespace) Initialize() error {
+	return nil
+}
+
+// Set stores a thing within the namespace
+func (n *Namespace) Set(key string, bl *Blob) error {
+	return n.c.Set(n.prefix+key, bl)
+}
+
+// Get returns a thing within the namespace older than a timestamp
+func (n *Namespace) Get(key string, t time.Time) *Blob {
+	return n.c.Get(n.prefix+key, t)
+}
+
+// GetFresh returns a thing within the namespace from the underlying backend
+func (n *Namespace) GetFresh(key string) *Blob {
+	return n.c.GetFresh(n.prefix + key)
+}
+
+// Keys lists the keys within the namespace, without its prefix
+func (n *Namespace) Keys() ([]KeyInfo, error) {
+	all, err := n.c.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	ks := []KeyInfo{}
+	for _, k := range all {
+		if strings.HasPrefix(k.Key, n.prefix) {
+			k.Key = strings.TrimPrefix(k.Key, n.prefix)
+			ks = append(ks, k)
+		}
+	}
+	return ks, nil
+}
+
+// Delete removes a key within the namespace
+func (n *Namespace) Delete(key string) error {
+	return n.c.Delete(n.prefix + key)
+}
+
+// Ping checks the underlying cache
+func (n *Namespace) Ping() error {
+	return n.c.Ping()
+}
+
+// Flush flushes the underlying cache
+func (n *Namespace) Flush() error {
+	return n.c.Flush()
+}
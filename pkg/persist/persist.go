@@ -44,6 +44,24 @@ type Blob struct {
 	IssueComments       []*provider.IssueComment
 	Timeline            []*provider.Timeline
 	Reviews             []*provider.PullRequestReview
+	Alerts              []*provider.Alert
+	// Paths changed by a pull request
+	PullRequestFiles []string
+	// How many commits a pull request is behind its base branch
+	CommitsBehind int
+	// How many review threads of a pull request are unresolved
+	UnresolvedThreads int
+	// How many levels of an escalation chain have fired for an item
+	EscalationLevel int
+
+	// Changes made through Triage Party
+	AuditEntries []*AuditEntry
+	// Tokens issued for programmatic access
+	APITokens []*APIToken
+	// Historical rule results
+	Snapshots []*Snapshot
+	// Pairs of items which are never similar
+	SimilarityExclusions []*SimilarityExclusion
 
 	// Provider specific fields, used by other tramps
 	GHPullRequest         *github.PullRequest
@@ -53,16 +71,82 @@ type Blob struct {
 	GHIssue               *github.Issue
 }
 
+// AuditEntry records a change made through Triage Party
+type AuditEntry struct {
+	Time time.Time
+	// Actor is who requested the change
+	Actor string
+	// OnDuty is who was on triage duty at the time
+	OnDuty string
+	Action string
+	// Target is the URL of the changed item
+	Target string
+	// Diff describes the change
+	Diff  string
+	Error string
+}
+
+// APIToken is a token issued for programmatic access
+type APIToken struct {
+	ID   string
+	Name string
+	// Owner is the user the token acts as
+	Owner string
+	// Scope is either "read" or "write"
+	Scope   string
+	Created time.Time
+	// Hash is the SHA-256 of the secret, which is never stored
+	Hash string
+}
+
+// Snapshot records the items matched by a rule at a point in time
+type Snapshot struct {
+	Time       time.Time
+	Collection string
+	Rule       string
+	Count      int
+	// Items are the URLs of matching items
+	Items []string
+}
+
+// SimilarityExclusion marks two items as never similar
+type SimilarityExclusion struct {
+	// A and B are the URLs of the items
+	A     string
+	B     string
+	Actor string
+	Time  time.Time
+}
+
 // Cacher is the cache interface we support
 type Cacher interface {
 	String() string
 
 	Set(string, *Blob) error
 	Get(string, time.Time) *Blob
+	// GetFresh returns a key as stored in the backend, bypassing memory, so that writes by other replicas are seen
+	GetFresh(string) *Blob
+
+	// Keys lists what is stored, for inspection
+	Keys() ([]KeyInfo, error)
+	Delete(string) error
+
+	// Ping returns an error if the backend can not be reached
+	Ping() error
+	// Flush waits for pending writes to be stored, before a program exits
+	Flush() error
 
 	Initialize() error
 }
 
+// KeyInfo describes a stored key
+type KeyInfo struct {
+	Key string
+	// Size is how many bytes the stored value takes
+	Size  int
+	Saved time.Time
+}
+
 func New(cfg Config) (Cacher, error) {
 	gob.Register(&Blob{})
 	switch cfg.Type {
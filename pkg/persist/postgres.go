@@ -108,6 +108,34 @@ func (m *Postgres) Get(key string, t time.Time) *Blob {
 		klog.Infof("get(%q) took %s", key, time.Since(start))
 	}()
 
+	bl := m.read(key)
+	if bl == nil {
+		return nil
+	}
+
+	if bl.Created.Before(t) {
+		klog.Warningf("found %s in db, but it was older than %s", key, t)
+		return nil
+	}
+
+	setMem(m.memcache, key, bl)
+	return bl
+}
+
+// GetFresh returns a Item from the database, bypassing memory
+func (m *Postgres) GetFresh(key string) *Blob {
+	bl := m.read(key)
+	if bl == nil {
+		m.memcache.Delete(key)
+		return nil
+	}
+
+	setMem(m.memcache, key, bl)
+	return bl
+}
+
+// read decodes a Item from the database
+func (m *Postgres) read(key string) *Blob {
 	var mi sqlItem
 	err := m.db.Get(&mi, `SELECT * FROM persist2 WHERE k = $1 LIMIT 1`, key)
 	if err == sql.ErrNoRows {
@@ -126,12 +154,42 @@ func (m *Postgres) Get(key string, t time.Time) *Blob {
 		klog.Errorf("decode failed for %s (saved %s, bytes: %d): %v", mi.Key, mi.Saved, len(mi.Value), err)
 		return nil
 	}
+	return &bl
+}
+
+// Keys lists the keys within the database
+func (m *Postgres) Keys() ([]KeyInfo, error) {
+	var rows []struct {
+		Key   string    `db:"k"`
+		Size  int       `db:"size"`
+		Saved time.Time `db:"saved"`
+	}
+	if err := m.db.Select(&rows, `SELECT k, OCTET_LENGTH(v) AS size, saved FROM persist2 ORDER BY k`); err != nil {
+		return nil, fmt.Errorf("select: %w", err)
+	}
 
-	if bl.Created.Before(t) {
-		klog.Warningf("found %s in db, but it was older than %s", key, t)
-		return nil
+	ks := []KeyInfo{}
+	for _, r := range rows {
+		ks = append(ks, KeyInfo{Key: r.Key, Size: r.Size, Saved: r.Saved})
+	}
+	return ks, nil
+}
+
+// Delete removes a key from memory and the database
+func (m *Postgres) Delete(key string) error {
+	m.memcache.Delete(key)
+	if _, err := m.db.Exec(`DELETE FROM persist2 WHERE k = $1`, key); err != nil {
+		return fmt.Errorf("delete: %w", err)
 	}
+	return nil
+}
 
-	setMem(m.memcache, key, &bl)
-	return &bl
+// Ping returns an error if the database can not be reached
+func (m *Postgres) Ping() error {
+	return m.db.Ping()
+}
+
+// Flush does nothing, as the database is written immediately
+func (m *Postgres) Flush() error {
+	return nil
 }
@@ -0,0 +1,134 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of security alerts
+const (
+	DependabotAlert     = "dependabot"
+	CodeScanningAlert   = "code-scanning"
+	SecretScanningAlert = "secret-scanning"
+)
+
+// AlertKinds are the kinds of security alerts which may be listed
+var AlertKinds = []string{DependabotAlert, CodeScanningAlert, SecretScanningAlert}
+
+// Alert is a security alert, such as a vulnerable dependency or a leaked secret
+type Alert struct {
+	Kind     string `json:"kind"`
+	Number   int    `json:"number"`
+	HTMLURL  string `json:"html_url"`
+	Title    string `json:"title"`
+	Severity string `json:"severity,omitempty"`
+
+	// State is open or closed, and Resolution is why a closed alert was closed, such as fixed or dismissed
+	State      string `json:"state"`
+	Resolution string `json:"resolution,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ClosedAt  time.Time `json:"closed_at,omitempty"`
+}
+
+// Alerts implement IItem, so that they may be matched by the same filters as issues
+
+func (a *Alert) GetAssignee() *User {
+	return nil
+}
+
+func (a *Alert) GetAuthorAssociation() string {
+	return ""
+}
+
+func (a *Alert) GetBody() string {
+	return ""
+}
+
+func (a *Alert) GetComments() int {
+	return 0
+}
+
+func (a *Alert) GetMilestone() *Milestone {
+	return nil
+}
+
+func (a *Alert) GetUser() *User {
+	return nil
+}
+
+func (a *Alert) GetID() int64 {
+	return int64(a.GetNumber())
+}
+
+func (a *Alert) GetURL() string {
+	return a.GetHTMLURL()
+}
+
+func (a *Alert) GetNumber() int {
+	if a == nil {
+		return 0
+	}
+	return a.Number
+}
+
+func (a *Alert) GetHTMLURL() string {
+	if a == nil {
+		return ""
+	}
+	return a.HTMLURL
+}
+
+func (a *Alert) GetTitle() string {
+	if a == nil {
+		return ""
+	}
+	return a.Title
+}
+
+func (a *Alert) GetState() string {
+	if a == nil {
+		return ""
+	}
+	return a.State
+}
+
+func (a *Alert) GetCreatedAt() time.Time {
+	if a == nil {
+		return time.Time{}
+	}
+	return a.CreatedAt
+}
+
+func (a *Alert) GetUpdatedAt() time.Time {
+	if a == nil {
+		return time.Time{}
+	}
+	return a.UpdatedAt
+}
+
+func (a *Alert) GetClosedAt() time.Time {
+	if a == nil {
+		return time.Time{}
+	}
+	return a.ClosedAt
+}
+
+func (a *Alert) String() string {
+	return fmt.Sprintf("%s alert #%d: %s", a.Kind, a.Number, a.Title)
+}
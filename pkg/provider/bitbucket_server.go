@@ -0,0 +1,836 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"golang.org/x/oauth2"
+)
+
+// errNoBitbucketIssues is returned when modifying issues, as Bitbucket Server tracks issues in Jira instead
+var errNoBitbucketIssues = errors.New("Bitbucket Server has no issue tracker")
+
+// BitbucketServerProvider reads pull requests from the REST API of an on-premise Bitbucket Server or Data Center
+type BitbucketServerProvider struct {
+	client *http.Client
+	base   *url.URL
+}
+
+// NewBitbucketServer returns a provider for the Bitbucket Server at baseURL, authenticating with an HTTP access token
+func NewBitbucketServer(baseURL string, token string) (Provider, error) {
+	return NewBitbucketServerFromSource(baseURL, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+}
+
+// NewBitbucketServerFromSource returns a Bitbucket Server provider which authenticates using tokens from a source, such as a rotated secret
+func NewBitbucketServerFromSource(baseURL string, ts oauth2.TokenSource) (Provider, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("url: %v", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("url: %q has no host", baseURL)
+	}
+	if _, err := ts.Token(); err != nil {
+		return nil, fmt.Errorf("token: %v", err)
+	}
+	return &BitbucketServerProvider{client: oauth2.NewClient(context.Background(), ts), base: u}, nil
+}
+
+// BitbucketServerHost returns the hostname a Bitbucket Server URL serves repositories from
+func BitbucketServerHost(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// BitbucketServerRepo returns the repository a Bitbucket Server URL refers to, such as
+// https://bitbucket.example.com/projects/KEY/repos/slug/pull-requests/12, or
+// https://bitbucket.example.com/users/name/repos/slug for a personal repository
+func BitbucketServerRepo(u *url.URL) (Repo, bool) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	// The server may be served from a context path, such as /bitbucket
+	for i := 0; i+3 < len(parts); i++ {
+		if parts[i+2] != "repos" {
+			continue
+		}
+		switch parts[i] {
+		case "projects":
+			return Repo{Host: u.Host, Organization: parts[i+1], Project: parts[i+3]}, true
+		case "users":
+			return Repo{Host: u.Host, Organization: "~" + parts[i+1], Project: parts[i+3]}, true
+		}
+	}
+	return Repo{}, false
+}
+
+type bbLinks struct {
+	Self []struct {
+		Href string `json:"href"`
+	} `json:"self"`
+}
+
+func (l bbLinks) href() string {
+	if len(l.Self) == 0 {
+		return ""
+	}
+	return l.Self[0].Href
+}
+
+type bbUser struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	DisplayName  string  `json:"displayName"`
+	EmailAddress string  `json:"emailAddress"`
+	Links        bbLinks `json:"links"`
+}
+
+// bbParticipant is an author, reviewer or participant of a pull request
+type bbParticipant struct {
+	User               bbUser `json:"user"`
+	Role               string `json:"role"`
+	Approved           bool   `json:"approved"`
+	Status             string `json:"status"`
+	LastReviewedCommit string `json:"lastReviewedCommit"`
+}
+
+type bbRef struct {
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+}
+
+type bbPullRequest struct {
+	ID          int             `json:"id"`
+	Version     int             `json:"version"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	State       string          `json:"state"`
+	Draft       bool            `json:"draft"`
+	CreatedDate int64           `json:"createdDate"`
+	UpdatedDate int64           `json:"updatedDate"`
+	ClosedDate  int64           `json:"closedDate"`
+	FromRef     bbRef           `json:"fromRef"`
+	ToRef       bbRef           `json:"toRef"`
+	Author      bbParticipant   `json:"author"`
+	Reviewers   []bbParticipant `json:"reviewers"`
+	Links       bbLinks         `json:"links"`
+	Properties  struct {
+		MergeResult struct {
+			Outcome string `json:"outcome"`
+		} `json:"mergeResult"`
+		CommentCount  int `json:"commentCount"`
+		OpenTaskCount int `json:"openTaskCount"`
+	} `json:"properties"`
+}
+
+type bbComment struct {
+	ID          int64        `json:"id"`
+	Version     int          `json:"version"`
+	Text        string       `json:"text"`
+	Author      bbUser       `json:"author"`
+	CreatedDate int64        `json:"createdDate"`
+	UpdatedDate int64        `json:"updatedDate"`
+	Comments    []*bbComment `json:"comments"`
+}
+
+type bbActivity struct {
+	ID            int64           `json:"id"`
+	CreatedDate   int64           `json:"createdDate"`
+	User          bbUser          `json:"user"`
+	Action        string          `json:"action"`
+	CommentAction string          `json:"commentAction"`
+	Comment       *bbComment      `json:"comment"`
+	CommentAnchor json.RawMessage `json:"commentAnchor"`
+	FromHash      string          `json:"fromHash"`
+}
+
+// bbPage is a page of results from a paged API
+type bbPage struct {
+	Values        []json.RawMessage `json:"values"`
+	IsLastPage    bool              `json:"isLastPage"`
+	NextPageStart int               `json:"nextPageStart"`
+}
+
+// bitbucketReviewState maps the status of a Bitbucket Server reviewer to a GitHub review state.
+// Reviewers who have not yet approved or asked for changes have not submitted a review.
+func bitbucketReviewState(status string) string {
+	switch status {
+	case "APPROVED":
+		return "APPROVED"
+	case "NEEDS_WORK":
+		return "CHANGES_REQUESTED"
+	default:
+		return ""
+	}
+}
+
+// bitbucketTimelineEvent maps a pull request activity to a GitHub timeline event
+func bitbucketTimelineEvent(action string) string {
+	switch action {
+	case "RESCOPED":
+		// Bitbucket Server does not say whether commits were added or replaced, so treat them as a push
+		return "head_ref_force_pushed"
+	case "MERGED":
+		return "merged"
+	case "DECLINED":
+		return "closed"
+	case "REOPENED":
+		return "reopened"
+	default:
+		return ""
+	}
+}
+
+// bitbucketMergeableState maps the merge outcome of a pull request to GitHub's mergeable states
+func bitbucketMergeableState(outcome string) (bool, string) {
+	switch outcome {
+	case "CLEAN":
+		return true, "clean"
+	case "CONFLICTED":
+		return false, "dirty"
+	default:
+		return false, "unknown"
+	}
+}
+
+func bbTime(ms int64) *time.Time {
+	if ms == 0 {
+		return nil
+	}
+	t := time.Unix(0, ms*int64(time.Millisecond))
+	return &t
+}
+
+func (p *BitbucketServerProvider) getUser(u bbUser) *User {
+	login := u.Name
+	name := u.DisplayName
+	email := u.EmailAddress
+	html := u.Links.href()
+	return &User{
+		ID:      &u.ID,
+		Login:   &login,
+		Name:    &name,
+		Email:   &email,
+		HTMLURL: &html,
+	}
+}
+
+func (p *BitbucketServerProvider) getPullRequest(v *bbPullRequest) *PullRequest {
+	state := constants.OpenState
+	if v.State != "OPEN" {
+		state = constants.ClosedState
+	}
+	id := int64(v.ID)
+	number := v.ID
+	html := v.Links.href()
+	merged := v.State == "MERGED"
+	mergeable, mergeableState := bitbucketMergeableState(v.Properties.MergeResult.Outcome)
+	comments := v.Properties.CommentCount
+
+	pr := &PullRequest{
+		ID:             &id,
+		Number:         &number,
+		State:          &state,
+		Title:          &v.Title,
+		Body:           &v.Description,
+		Draft:          &v.Draft,
+		CreatedAt:      bbTime(v.CreatedDate),
+		UpdatedAt:      bbTime(v.UpdatedDate),
+		ClosedAt:       bbTime(v.ClosedDate),
+		User:           p.getUser(v.Author.User),
+		Merged:         &merged,
+		Mergeable:      &mergeable,
+		MergeableState: &mergeableState,
+		Comments:       &comments,
+		URL:            &html,
+		HTMLURL:        &html,
+		Head:           &PullRequestBranch{Ref: &v.FromRef.DisplayID, SHA: &v.FromRef.LatestCommit},
+		Base:           &PullRequestBranch{Ref: &v.ToRef.DisplayID, SHA: &v.ToRef.LatestCommit},
+	}
+	if merged {
+		pr.MergedAt = pr.ClosedAt
+	}
+	for _, r := range v.Reviewers {
+		if bitbucketReviewState(r.Status) == "" {
+			pr.RequestedReviewers = append(pr.RequestedReviewers, p.getUser(r.User))
+		}
+	}
+	return pr
+}
+
+func (p *BitbucketServerProvider) getIssueComment(c *bbComment) *IssueComment {
+	return &IssueComment{
+		ID:        &c.ID,
+		Body:      &c.Text,
+		User:      p.getUser(c.Author),
+		CreatedAt: bbTime(c.CreatedDate),
+		UpdatedAt: bbTime(c.UpdatedDate),
+	}
+}
+
+// repoPath returns the API path of a repository. Personal repositories belong to a project named "~user".
+func (p *BitbucketServerProvider) repoPath(r Repo) string {
+	return fmt.Sprintf("/projects/%s/repos/%s", url.PathEscape(r.Organization), url.PathEscape(r.Project))
+}
+
+func (p *BitbucketServerProvider) prPath(sp SearchParams) string {
+	return fmt.Sprintf("%s/pull-requests/%d", p.repoPath(sp.Repo), sp.IssueNumber)
+}
+
+// do calls an API endpoint, encoding in as the request body and decoding the response into out
+func (p *BitbucketServerProvider) do(ctx context.Context, method string, path string, q url.Values, in interface{}, out interface{}) (*Response, error) {
+	b, err := p.raw(ctx, method, "/rest/api/1.0"+path, q, in)
+	if err != nil {
+		return &Response{}, err
+	}
+	if out != nil && len(b) > 0 {
+		if err := json.Unmarshal(b, out); err != nil {
+			return &Response{}, fmt.Errorf("decode %s: %w", path, err)
+		}
+	}
+	return &Response{}, nil
+}
+
+func (p *BitbucketServerProvider) raw(ctx context.Context, method string, path string, q url.Values, in interface{}) ([]byte, error) {
+	u := *p.base
+	u.Path += path
+	u.RawQuery = q.Encode()
+
+	var body *bytes.Buffer
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(b)
+	} else {
+		body = &bytes.Buffer{}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, bitbucketError(method, u.Path, resp.StatusCode, b)
+	}
+	return b, nil
+}
+
+// bitbucketError returns the messages of a failed API call
+func bitbucketError(method string, path string, code int, body []byte) error {
+	var e struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	msgs := []string{}
+	if json.Unmarshal(body, &e) == nil {
+		for _, m := range e.Errors {
+			msgs = append(msgs, m.Message)
+		}
+	}
+	if len(msgs) == 0 {
+		return &StatusError{StatusCode: code, Message: fmt.Sprintf("%s %s: %d", method, path, code)}
+	}
+	return &StatusError{StatusCode: code, Message: fmt.Sprintf("%s %s: %d: %s", method, path, code, strings.Join(msgs, "; "))}
+}
+
+// page returns a single page of a paged API, starting at an offset
+func (p *BitbucketServerProvider) page(ctx context.Context, path string, q url.Values, start int) (*bbPage, *Response, error) {
+	if q == nil {
+		q = url.Values{}
+	}
+	q.Set("start", strconv.Itoa(start))
+	q.Set("limit", "100")
+
+	pg := &bbPage{}
+	if _, err := p.do(ctx, http.MethodGet, path, q, nil, pg); err != nil {
+		return nil, &Response{}, err
+	}
+
+	r := &Response{}
+	if !pg.IsLastPage {
+		r.NextPage = pg.NextPageStart
+	}
+	return pg, r, nil
+}
+
+// each calls fn with every value of a paged API
+func (p *BitbucketServerProvider) each(ctx context.Context, path string, q url.Values, fn func(json.RawMessage) error) (*Response, error) {
+	start := 0
+	for {
+		pg, r, err := p.page(ctx, path, q, start)
+		if err != nil {
+			return r, err
+		}
+		for _, v := range pg.Values {
+			if err := fn(v); err != nil {
+				return r, err
+			}
+		}
+		if r.NextPage == 0 {
+			return r, nil
+		}
+		start = r.NextPage
+	}
+}
+
+// activities returns the activities of a pull request, oldest first
+func (p *BitbucketServerProvider) activities(ctx context.Context, sp SearchParams) ([]*bbActivity, *Response, error) {
+	as := []*bbActivity{}
+	r, err := p.each(ctx, p.prPath(sp)+"/activities", nil, func(v json.RawMessage) error {
+		a := &bbActivity{}
+		as = append(as, a)
+		return json.Unmarshal(v, a)
+	})
+	sort.Slice(as, func(i, j int) bool { return as[i].CreatedDate < as[j].CreatedDate })
+	return as, r, err
+}
+
+// comments returns the comments of a pull request, and their replies, which are either inline or not
+func (p *BitbucketServerProvider) comments(ctx context.Context, sp SearchParams, inline bool) ([]*bbComment, *Response, error) {
+	as, r, err := p.activities(ctx, sp)
+	if err != nil {
+		return nil, r, err
+	}
+
+	cs := []*bbComment{}
+	var walk func(c *bbComment)
+	walk = func(c *bbComment) {
+		cs = append(cs, c)
+		for _, reply := range c.Comments {
+			walk(reply)
+		}
+	}
+
+	for _, a := range as {
+		if a.Action != "COMMENTED" || a.CommentAction != "ADDED" || a.Comment == nil {
+			continue
+		}
+		anchored := len(a.CommentAnchor) > 0 && string(a.CommentAnchor) != "null"
+		if anchored != inline {
+			continue
+		}
+		walk(a.Comment)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].CreatedDate < cs[j].CreatedDate })
+	return cs, r, nil
+}
+
+func (p *BitbucketServerProvider) getPR(ctx context.Context, sp SearchParams) (*bbPullRequest, *Response, error) {
+	v := &bbPullRequest{}
+	r, err := p.do(ctx, http.MethodGet, p.prPath(sp), nil, nil, v)
+	return v, r, err
+}
+
+// IssuesListByRepo returns no issues, as Bitbucket Server tracks issues in Jira
+func (p *BitbucketServerProvider) IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// IssuesListComments returns the general comments of a pull request, as Bitbucket Server has no issues
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp297
+func (p *BitbucketServerProvider) IssuesListComments(ctx context.Context, sp SearchParams) ([]*IssueComment, *Response, error) {
+	cs, r, err := p.comments(ctx, sp, false)
+	if err != nil {
+		return nil, r, err
+	}
+	is := []*IssueComment{}
+	for _, c := range cs {
+		is = append(is, p.getIssueComment(c))
+	}
+	return is, r, nil
+}
+
+// IssuesListIssueTimeline returns the pushes, merges, declines and reopenings of a pull request
+func (p *BitbucketServerProvider) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) ([]*Timeline, *Response, error) {
+	as, r, err := p.activities(ctx, sp)
+	if err != nil {
+		return nil, r, err
+	}
+
+	ts := []*Timeline{}
+	for _, a := range as {
+		ev := bitbucketTimelineEvent(a.Action)
+		if ev == "" {
+			continue
+		}
+		a := a
+		t := &Timeline{
+			ID:        &a.ID,
+			Event:     &ev,
+			Actor:     p.getUser(a.User),
+			CreatedAt: bbTime(a.CreatedDate),
+		}
+		if a.FromHash != "" {
+			t.CommitID = &a.FromHash
+		}
+		ts = append(ts, t)
+	}
+	return ts, r, nil
+}
+
+// IssuesListSubIssues returns no sub-issues, as Bitbucket Server has no issues
+func (p *BitbucketServerProvider) IssuesListSubIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// EpicsList returns no epics, as Bitbucket Server has no issues
+func (p *BitbucketServerProvider) EpicsList(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// EpicsListComments returns no comments, as Bitbucket Server has no epics
+func (p *BitbucketServerProvider) EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) ([]*IssueComment, *Response, error) {
+	return []*IssueComment{}, &Response{}, nil
+}
+
+// EpicsListIssues returns no issues, as Bitbucket Server has no epics
+func (p *BitbucketServerProvider) EpicsListIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// PullRequestsList returns a page of pull requests, most recently updated first. Pages are addressed by offset.
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp284
+func (p *BitbucketServerProvider) PullRequestsList(ctx context.Context, sp SearchParams) ([]*PullRequest, *Response, error) {
+	state := sp.PullRequestListOptions.State
+	q := url.Values{}
+	q.Set("order", "NEWEST")
+	q.Set("state", "ALL")
+	if state == constants.OpenState || state == constants.OpenedState {
+		q.Set("state", "OPEN")
+	}
+
+	pg, r, err := p.page(ctx, p.repoPath(sp.Repo)+"/pull-requests", q, sp.PullRequestListOptions.Page)
+	if err != nil {
+		return nil, r, err
+	}
+
+	prs := []*PullRequest{}
+	for _, raw := range pg.Values {
+		v := &bbPullRequest{}
+		if err := json.Unmarshal(raw, v); err != nil {
+			return nil, r, err
+		}
+		// There is no state for every closed pull request, so open ones are skipped instead
+		if state == constants.ClosedState && v.State == "OPEN" {
+			continue
+		}
+		prs = append(prs, p.getPullRequest(v))
+	}
+	return prs, r, nil
+}
+
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp292
+func (p *BitbucketServerProvider) PullRequestsGet(ctx context.Context, sp SearchParams) (*PullRequest, *Response, error) {
+	v, r, err := p.getPR(ctx, sp)
+	if err != nil {
+		return nil, r, err
+	}
+	return p.getPullRequest(v), r, nil
+}
+
+// PullRequestsListComments returns the inline comments of a pull request, and their replies
+func (p *BitbucketServerProvider) PullRequestsListComments(ctx context.Context, sp SearchParams) ([]*PullRequestComment, *Response, error) {
+	cs, r, err := p.comments(ctx, sp, true)
+	if err != nil {
+		return nil, r, err
+	}
+	prcs := []*PullRequestComment{}
+	for _, c := range cs {
+		prcs = append(prcs, &PullRequestComment{
+			ID:        &c.ID,
+			Body:      &c.Text,
+			User:      p.getUser(c.Author),
+			CreatedAt: bbTime(c.CreatedDate),
+			UpdatedAt: bbTime(c.UpdatedDate),
+		})
+	}
+	return prcs, r, nil
+}
+
+// PullRequestsListReviews returns a review for each reviewer who has approved a pull request or marked it as needing work.
+// Bitbucket Server only keeps the current status of each reviewer, so it is dated by their latest matching activity.
+func (p *BitbucketServerProvider) PullRequestsListReviews(ctx context.Context, sp SearchParams) ([]*PullRequestReview, *Response, error) {
+	v, r, err := p.getPR(ctx, sp)
+	if err != nil {
+		return nil, r, err
+	}
+	as, r, err := p.activities(ctx, sp)
+	if err != nil {
+		return nil, r, err
+	}
+
+	reviewed := map[string]*time.Time{}
+	for _, a := range as {
+		if a.Action == "APPROVED" || a.Action == "REVIEWED" {
+			reviewed[a.User.Name] = bbTime(a.CreatedDate)
+		}
+	}
+
+	rs := []*PullRequestReview{}
+	for _, rv := range v.Reviewers {
+		state := bitbucketReviewState(rv.Status)
+		if state == "" {
+			continue
+		}
+		rv := rv
+		rs = append(rs, &PullRequestReview{
+			User:        p.getUser(rv.User),
+			State:       &state,
+			CommitID:    &rv.LastReviewedCommit,
+			SubmittedAt: reviewed[rv.User.Name],
+		})
+	}
+
+	// The last review decides the review state
+	sort.SliceStable(rs, func(i, j int) bool { return rs[i].GetSubmittedAt().Before(rs[j].GetSubmittedAt()) })
+	return rs, r, nil
+}
+
+// IssuesCreateComment returns an error, as Bitbucket Server has no issues
+func (p *BitbucketServerProvider) IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return nil, &Response{}, errNoBitbucketIssues
+}
+
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp299
+func (p *BitbucketServerProvider) PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	c := &bbComment{}
+	r, err := p.do(ctx, http.MethodPost, p.prPath(sp)+"/comments", nil, map[string]string{"text": body}, c)
+	if err != nil {
+		return nil, r, err
+	}
+	return p.getIssueComment(c), r, nil
+}
+
+// IssuesEditComment returns an error, as Bitbucket Server has no issues
+func (p *BitbucketServerProvider) IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return nil, &Response{}, errNoBitbucketIssues
+}
+
+// PullRequestsEditComment replaces the text of a comment, which requires its current version
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp302
+func (p *BitbucketServerProvider) PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	path := fmt.Sprintf("%s/comments/%d", p.prPath(sp), id)
+	c := &bbComment{}
+	if r, err := p.do(ctx, http.MethodGet, path, nil, nil, c); err != nil {
+		return nil, r, err
+	}
+
+	in := map[string]interface{}{"text": body, "version": c.Version}
+	r, err := p.do(ctx, http.MethodPut, path, nil, in, c)
+	if err != nil {
+		return nil, r, err
+	}
+	return p.getIssueComment(c), r, nil
+}
+
+// IssuesEdit returns an error, as Bitbucket Server has no issues
+func (p *BitbucketServerProvider) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return &Response{}, errNoBitbucketIssues
+}
+
+// PullRequestsEdit declines or reopens a pull request. Assignees are added as reviewers, as Bitbucket Server has no assignees.
+func (p *BitbucketServerProvider) PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	switch {
+	case len(e.AddLabels) > 0 || len(e.RemoveLabels) > 0:
+		return &Response{}, errors.New("Bitbucket Server pull requests have no labels")
+	case e.Milestone != "":
+		return &Response{}, errors.New("Bitbucket Server pull requests have no milestones")
+	case e.Locked != nil:
+		return &Response{}, errors.New("Bitbucket Server pull requests can not be locked")
+	}
+
+	if len(e.AddAssignees) > 0 {
+		if r, err := p.PullRequestsRequestReviewers(ctx, sp, e.AddAssignees); err != nil {
+			return r, err
+		}
+	}
+
+	if e.State == "" {
+		return &Response{}, nil
+	}
+
+	v, r, err := p.getPR(ctx, sp)
+	if err != nil {
+		return r, err
+	}
+
+	// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp290
+	action := "reopen"
+	if e.State == constants.ClosedState {
+		action = "decline"
+	}
+	if (action == "decline") == (v.State != "OPEN") {
+		return r, nil
+	}
+	q := url.Values{"version": []string{strconv.Itoa(v.Version)}}
+	return p.do(ctx, http.MethodPost, p.prPath(sp)+"/"+action, q, nil, nil)
+}
+
+// PullRequestsRequestReviewers adds reviewers to a pull request, which requires its current version
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp293
+func (p *BitbucketServerProvider) PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error) {
+	v, r, err := p.getPR(ctx, sp)
+	if err != nil {
+		return r, err
+	}
+
+	type reviewer struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+
+	seen := map[string]bool{}
+	rs := []reviewer{}
+	for _, login := range reviewers {
+		seen[login] = true
+	}
+	for _, rv := range v.Reviewers {
+		if !seen[rv.User.Name] {
+			reviewers = append(reviewers, rv.User.Name)
+		}
+	}
+	for _, login := range reviewers {
+		rv := reviewer{}
+		rv.User.Name = login
+		rs = append(rs, rv)
+	}
+
+	in := map[string]interface{}{
+		"version":     v.Version,
+		"title":       v.Title,
+		"description": v.Description,
+		"reviewers":   rs,
+	}
+	return p.do(ctx, http.MethodPut, p.prPath(sp), nil, in, nil)
+}
+
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp295
+func (p *BitbucketServerProvider) PullRequestsListFiles(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	files := []string{}
+	r, err := p.each(ctx, p.prPath(sp)+"/changes", nil, func(v json.RawMessage) error {
+		var c struct {
+			Path struct {
+				ToString string `json:"toString"`
+			} `json:"path"`
+		}
+		if err := json.Unmarshal(v, &c); err != nil {
+			return err
+		}
+		files = append(files, c.Path.ToString)
+		return nil
+	})
+	return files, r, err
+}
+
+// PullRequestsCommitsBehind returns how many commits on the target branch are missing from the source branch
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp222
+func (p *BitbucketServerProvider) PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (int, *Response, error) {
+	q := url.Values{}
+	q.Set("since", pr.GetHead().GetSHA())
+	q.Set("until", pr.GetBase().GetSHA())
+
+	n := 0
+	r, err := p.each(ctx, p.repoPath(sp.Repo)+"/commits", q, func(json.RawMessage) error {
+		n++
+		return nil
+	})
+	return n, r, err
+}
+
+// PullRequestsUnresolvedThreads returns how many tasks of a pull request are open
+func (p *BitbucketServerProvider) PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (int, *Response, error) {
+	v, r, err := p.getPR(ctx, sp)
+	if err != nil {
+		return 0, r, err
+	}
+	return v.Properties.OpenTaskCount, r, nil
+}
+
+// ReposGetFile returns the contents of a file on the default branch
+func (p *BitbucketServerProvider) ReposGetFile(ctx context.Context, sp SearchParams, path string) ([]byte, *Response, error) {
+	b, err := p.raw(ctx, http.MethodGet, "/rest/api/1.0"+p.repoPath(sp.Repo)+"/raw/"+strings.TrimPrefix(path, "/"), url.Values{}, nil)
+	return b, &Response{}, err
+}
+
+// ReposListLabels returns no labels, as Bitbucket Server pull requests have none
+func (p *BitbucketServerProvider) ReposListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	return []string{}, &Response{}, nil
+}
+
+// ReposListMilestones returns no milestones, as Bitbucket Server has none
+func (p *BitbucketServerProvider) ReposListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	return []string{}, &Response{}, nil
+}
+
+// GroupsIsMember returns whether a user has been granted a permission on a project, which is the closest thing Bitbucket Server has to an organization.
+// Listing project permissions requires the token to have project admin access.
+// https://docs.atlassian.com/bitbucket-server/rest/7.21.0/bitbucket-rest.html#idp170
+func (p *BitbucketServerProvider) GroupsIsMember(ctx context.Context, group string, user string) (bool, *Response, error) {
+	q := url.Values{}
+	q.Set("filter", user)
+
+	found := false
+	r, err := p.each(ctx, "/projects/"+url.PathEscape(group)+"/permissions/users", q, func(v json.RawMessage) error {
+		var perm struct {
+			User bbUser `json:"user"`
+		}
+		if err := json.Unmarshal(v, &perm); err != nil {
+			return err
+		}
+		if perm.User.Name == user {
+			found = true
+		}
+		return nil
+	})
+	return found, r, err
+}
+
+// AlertsList returns no alerts, as Bitbucket Server has no security alerts
+func (p *BitbucketServerProvider) AlertsList(ctx context.Context, sp SearchParams, kind string) ([]*Alert, *Response, error) {
+	return []*Alert{}, &Response{}, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitbucketServerRepo(t *testing.T) {
+	u, _ := url.Parse("https://bitbucket.example.com/bitbucket/projects/KEY/repos/slug/pull-requests/12")
+	r, ok := BitbucketServerRepo(u)
+	assert.True(t, ok)
+	assert.Equal(t, Repo{Host: "bitbucket.example.com", Organization: "KEY", Project: "slug"}, r)
+
+	u, _ = url.Parse("https://bitbucket.example.com/users/alice/repos/dotfiles")
+	r, ok = BitbucketServerRepo(u)
+	assert.True(t, ok)
+	assert.Equal(t, "~alice", r.Organization)
+
+	u, _ = url.Parse("https://github.com/org/repo")
+	_, ok = BitbucketServerRepo(u)
+	assert.False(t, ok)
+}
+
+func TestBitbucketServer_PullRequestsListReviews(t *testing.T) {
+	pr := `{"id": 12, "version": 3, "state": "OPEN", "reviewers": [
+		{"user": {"name": "alice"}, "status": "APPROVED", "lastReviewedCommit": "abc"},
+		{"user": {"name": "bob"}, "status": "NEEDS_WORK", "lastReviewedCommit": "abc"},
+		{"user": {"name": "carol"}, "status": "UNAPPROVED"}
+	]}`
+	activities := `{"isLastPage": true, "values": [
+		{"id": 3, "createdDate": 3000, "user": {"name": "alice"}, "action": "APPROVED"},
+		{"id": 2, "createdDate": 2000, "user": {"name": "bob"}, "action": "REVIEWED"},
+		{"id": 1, "createdDate": 1000, "user": {"name": "alice"}, "action": "OPENED"}
+	]}`
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/1.0/projects/KEY/repos/slug/pull-requests/12":
+			w.Write([]byte(pr))
+		case "/rest/api/1.0/projects/KEY/repos/slug/pull-requests/12/activities":
+			w.Write([]byte(activities))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	p, err := NewBitbucketServer(s.URL, "token")
+	assert.Nil(t, err)
+
+	sp := SearchParams{Repo: Repo{Organization: "KEY", Project: "slug"}, IssueNumber: 12}
+	rs, _, err := p.PullRequestsListReviews(context.Background(), sp)
+	assert.Nil(t, err)
+
+	// carol has not reviewed, and alice approved after bob asked for changes
+	assert.Equal(t, 2, len(rs))
+	assert.Equal(t, "bob", rs[0].GetUser().GetLogin())
+	assert.Equal(t, "CHANGES_REQUESTED", rs[0].GetState())
+	assert.Equal(t, "alice", rs[1].GetUser().GetLogin())
+	assert.Equal(t, "APPROVED", rs[1].GetState())
+
+	gp, _, err := p.PullRequestsGet(context.Background(), sp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(gp.RequestedReviewers))
+	assert.Equal(t, "open", gp.GetState())
+}
@@ -40,6 +40,16 @@ type Filter struct {
 	milestoneRegex  *regexp.Regexp
 	milestoneNegate bool
 
+	// RawArea matches the OWNERS areas of items, such as sig/node
+	RawArea    string `yaml:"area,omitempty"`
+	areaRegex  *regexp.Regexp
+	areaNegate bool
+
+	// RawAuthor is a login or @group whose items match, or with a leading ! do not match
+	RawAuthor    string `yaml:"author,omitempty"`
+	authors      map[string]bool
+	authorNegate bool
+
 	Created            string `yaml:"created,omitempty"`
 	Updated            string `yaml:"updated,omitempty"`
 	Closed             string `yaml:"closed,omitempty"`
@@ -53,6 +63,55 @@ type Filter struct {
 	ClosedComments     string `yaml:"comments-while-closed,omitempty"`
 	ClosedCommenters   string `yaml:"commenters-while-closed,omitempty"`
 	State              string `yaml:"state,omitempty"`
+
+	// TasksComplete is the percentage of task list items which are checked, such as "<100%"
+	TasksComplete string `yaml:"tasks-complete,omitempty"`
+
+	// HasParent and HasChildren match issues which are, or track, sub-issues: "true" or "false"
+	HasParent   string `yaml:"has-parent,omitempty"`
+	HasChildren string `yaml:"has-children,omitempty"`
+
+	// Blocked matches items which say they are blocked by, or depend on, an open item: "true" or "false"
+	Blocked string `yaml:"blocked,omitempty"`
+
+	// MergeReady matches PRs which would merge cleanly as is, and do not have auto-merge enabled: "true" or "false"
+	MergeReady string `yaml:"merge-ready,omitempty"`
+
+	// CommitsBehind is the range of how many commits PRs are behind their base branch, such as ">100"
+	CommitsBehind string `yaml:"commits-behind,omitempty"`
+
+	// UnresolvedThreads is the range of how many review threads of PRs are unresolved, such as ">0"
+	UnresolvedThreads string `yaml:"unresolved-threads,omitempty"`
+
+	// Score is the range of scores given by the external scoring service, such as ">0.8"
+	Score string `yaml:"score,omitempty"`
+
+	// RawScoreLabel matches the labels given by the external scoring service
+	RawScoreLabel    string `yaml:"score-label,omitempty"`
+	scoreLabelRegex  *regexp.Regexp
+	scoreLabelNegate bool
+
+	// RawTriageState matches the name of the configured triage state items are in, such as needs-info
+	RawTriageState    string `yaml:"triage-state,omitempty"`
+	triageStateRegex  *regexp.Regexp
+	triageStateNegate bool
+
+	// TriageStateChanged is the elapsed time since items entered their triage state, such as "+14d"
+	TriageStateChanged string `yaml:"triage-state-changed,omitempty"`
+
+	// RawResponders is a login or @group whose comments count as responses for Responded, rather than any member's
+	RawResponders string `yaml:"responders,omitempty"`
+	responders    map[string]bool
+
+	// ReviewRequested is how long a review request has been outstanding, such as "+7d"
+	ReviewRequested string `yaml:"review-requested,omitempty"`
+
+	// RawReviewers is a login or @group whose outstanding review requests match, rather than anyone's
+	RawReviewers string `yaml:"reviewers,omitempty"`
+	reviewers    map[string]bool
+
+	// Use is the name of a filter macro, which is replaced by the filters it defines when the config is loaded
+	Use string `yaml:"use,omitempty"`
 }
 
 // LoadLabelRegex loads a new label regex
@@ -143,6 +202,113 @@ func (f *Filter) MilestoneNegate() bool {
 	return f.milestoneNegate
 }
 
+// LoadAreaRegex loads a new area regex
+func (f *Filter) LoadAreaRegex() error {
+	r, negateState := negativeMatch(f.RawArea)
+
+	re, err := regex(r)
+	if err != nil {
+		return err
+	}
+
+	f.areaRegex = re
+	f.areaNegate = negateState
+	return nil
+}
+
+func (f *Filter) AreaRegex() *regexp.Regexp {
+	return f.areaRegex
+}
+
+func (f *Filter) AreaNegate() bool {
+	return f.areaNegate
+}
+
+// LoadScoreLabelRegex loads a new score label regex
+func (f *Filter) LoadScoreLabelRegex() error {
+	r, negateState := negativeMatch(f.RawScoreLabel)
+
+	re, err := regex(r)
+	if err != nil {
+		return err
+	}
+
+	f.scoreLabelRegex = re
+	f.scoreLabelNegate = negateState
+	return nil
+}
+
+func (f *Filter) ScoreLabelRegex() *regexp.Regexp {
+	return f.scoreLabelRegex
+}
+
+func (f *Filter) ScoreLabelNegate() bool {
+	return f.scoreLabelNegate
+}
+
+// LoadTriageStateRegex loads a new triage state regex
+func (f *Filter) LoadTriageStateRegex() error {
+	r, negateState := negativeMatch(f.RawTriageState)
+
+	re, err := regex(r)
+	if err != nil {
+		return err
+	}
+
+	f.triageStateRegex = re
+	f.triageStateNegate = negateState
+	return nil
+}
+
+func (f *Filter) TriageStateRegex() *regexp.Regexp {
+	return f.triageStateRegex
+}
+
+func (f *Filter) TriageStateNegate() bool {
+	return f.triageStateNegate
+}
+
+// LoadAuthors loads the users matched by the author filter, once any group has been expanded
+func (f *Filter) LoadAuthors(users []string) {
+	_, f.authorNegate = negativeMatch(f.RawAuthor)
+	f.authors = userSet(users)
+}
+
+func (f *Filter) Authors() map[string]bool {
+	return f.authors
+}
+
+func (f *Filter) AuthorNegate() bool {
+	return f.authorNegate
+}
+
+// LoadResponders loads the users whose comments count as responses, once any group has been expanded
+func (f *Filter) LoadResponders(users []string) {
+	f.responders = userSet(users)
+}
+
+func (f *Filter) Responders() map[string]bool {
+	return f.responders
+}
+
+// LoadReviewers loads the users whose outstanding review requests match, once any group has been expanded
+func (f *Filter) LoadReviewers(users []string) {
+	f.reviewers = userSet(users)
+}
+
+func (f *Filter) Reviewers() map[string]bool {
+	return f.reviewers
+}
+
+// userSet returns a set of users
+func userSet(users []string) map[string]bool {
+	set := map[string]bool{}
+	for _, u := range users {
+		set[u] = true
+	}
+	return set
+}
+
 // negativeMatch parses a match string and returns the underlying string and negation bool
 func negativeMatch(s string) (string, bool) {
 	if strings.HasPrefix(s, "!") {
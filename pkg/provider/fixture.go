@@ -0,0 +1,456 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// errReplayWrite is returned by write requests while replaying fixtures
+var errReplayWrite = errors.New("writes are not possible while replaying fixtures")
+
+// fixtureRequest identifies a read request, leaving out fields which depend on the current time
+type fixtureRequest struct {
+	Method      string
+	Repo        Repo
+	State       string
+	UpdateAge   time.Duration
+	IssueNumber int
+	Args        []interface{} `json:",omitempty"`
+
+	IssueListByRepoOptions   IssueListByRepoOptions
+	IssueListCommentsOptions IssueListCommentsOptions
+	ListOptions              ListOptions
+	PullRequestListOptions   PullRequestListOptions
+}
+
+// fixture is a recorded response to a read request
+type fixture struct {
+	Request  fixtureRequest
+	Result   json.RawMessage
+	Response *Response
+	Error    string `json:",omitempty"`
+}
+
+// fixturePath returns the path a request is recorded to within a directory
+func fixturePath(dir string, method string, sp SearchParams, args ...interface{}) (string, fixtureRequest, error) {
+	req := fixtureRequest{
+		Method:                   method,
+		Repo:                     sp.Repo,
+		State:                    sp.State,
+		UpdateAge:                sp.UpdateAge,
+		IssueNumber:              sp.IssueNumber,
+		Args:                     args,
+		IssueListByRepoOptions:   sp.IssueListByRepoOptions,
+		IssueListCommentsOptions: sp.IssueListCommentsOptions,
+		ListOptions:              sp.ListOptions,
+		PullRequestListOptions:   sp.PullRequestListOptions,
+	}
+	req.IssueListByRepoOptions.Since = time.Time{}
+	req.IssueListCommentsOptions.Since = nil
+
+	bs, err := json.Marshal(req)
+	if err != nil {
+		return "", req, fmt.Errorf("marshal: %w", err)
+	}
+	name := fmt.Sprintf("%s-%x.json", method, sha256.Sum256(bs))
+	if sp.Repo.Organization != "" {
+		name = fmt.Sprintf("%s-%s-%s", sp.Repo.Organization, sp.Repo.Project, name)
+	}
+	return filepath.Join(dir, name), req, nil
+}
+
+// Recorder is a provider which saves the responses to read requests as fixtures, for a Replayer to serve later
+type Recorder struct {
+	p   Provider
+	dir string
+}
+
+// NewRecorder returns a provider which records the read requests of another to a directory
+func NewRecorder(p Provider, dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+	return &Recorder{p: p, dir: dir}, nil
+}
+
+// record saves the response to a read request
+func (r *Recorder) record(method string, sp SearchParams, result interface{}, resp *Response, err error, args ...interface{}) {
+	path, req, perr := fixturePath(r.dir, method, sp, args...)
+	if perr != nil {
+		klog.Errorf("fixture path: %v", perr)
+		return
+	}
+
+	f := fixture{Request: req, Response: resp}
+	if err != nil {
+		f.Error = err.Error()
+	}
+
+	f.Result, perr = json.Marshal(result)
+	if perr != nil {
+		klog.Errorf("marshal %s result: %v", method, perr)
+		return
+	}
+
+	bs, perr := json.MarshalIndent(f, "", "  ")
+	if perr != nil {
+		klog.Errorf("marshal %s fixture: %v", method, perr)
+		return
+	}
+
+	if perr := ioutil.WriteFile(path, bs, 0o644); perr != nil {
+		klog.Errorf("write fixture: %v", perr)
+	}
+}
+
+func (r *Recorder) IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	is, resp, err := r.p.IssuesListByRepo(ctx, sp)
+	r.record("IssuesListByRepo", sp, is, resp, err)
+	return is, resp, err
+}
+
+func (r *Recorder) IssuesListComments(ctx context.Context, sp SearchParams) ([]*IssueComment, *Response, error) {
+	cs, resp, err := r.p.IssuesListComments(ctx, sp)
+	r.record("IssuesListComments", sp, cs, resp, err)
+	return cs, resp, err
+}
+
+func (r *Recorder) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) ([]*Timeline, *Response, error) {
+	ts, resp, err := r.p.IssuesListIssueTimeline(ctx, sp)
+	r.record("IssuesListIssueTimeline", sp, ts, resp, err)
+	return ts, resp, err
+}
+
+func (r *Recorder) IssuesListSubIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	is, resp, err := r.p.IssuesListSubIssues(ctx, sp)
+	r.record("IssuesListSubIssues", sp, is, resp, err)
+	return is, resp, err
+}
+
+func (r *Recorder) EpicsList(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	is, resp, err := r.p.EpicsList(ctx, sp)
+	r.record("EpicsList", sp, is, resp, err)
+	return is, resp, err
+}
+
+func (r *Recorder) EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) ([]*IssueComment, *Response, error) {
+	cs, resp, err := r.p.EpicsListComments(ctx, sp, epic)
+	r.record("EpicsListComments", sp, cs, resp, err)
+	return cs, resp, err
+}
+
+func (r *Recorder) EpicsListIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	is, resp, err := r.p.EpicsListIssues(ctx, sp)
+	r.record("EpicsListIssues", sp, is, resp, err)
+	return is, resp, err
+}
+
+func (r *Recorder) PullRequestsList(ctx context.Context, sp SearchParams) ([]*PullRequest, *Response, error) {
+	prs, resp, err := r.p.PullRequestsList(ctx, sp)
+	r.record("PullRequestsList", sp, prs, resp, err)
+	return prs, resp, err
+}
+
+func (r *Recorder) PullRequestsGet(ctx context.Context, sp SearchParams) (*PullRequest, *Response, error) {
+	pr, resp, err := r.p.PullRequestsGet(ctx, sp)
+	r.record("PullRequestsGet", sp, pr, resp, err)
+	return pr, resp, err
+}
+
+func (r *Recorder) PullRequestsListComments(ctx context.Context, sp SearchParams) ([]*PullRequestComment, *Response, error) {
+	cs, resp, err := r.p.PullRequestsListComments(ctx, sp)
+	r.record("PullRequestsListComments", sp, cs, resp, err)
+	return cs, resp, err
+}
+
+func (r *Recorder) PullRequestsListReviews(ctx context.Context, sp SearchParams) ([]*PullRequestReview, *Response, error) {
+	rs, resp, err := r.p.PullRequestsListReviews(ctx, sp)
+	r.record("PullRequestsListReviews", sp, rs, resp, err)
+	return rs, resp, err
+}
+
+func (r *Recorder) PullRequestsListFiles(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	fs, resp, err := r.p.PullRequestsListFiles(ctx, sp)
+	r.record("PullRequestsListFiles", sp, fs, resp, err)
+	return fs, resp, err
+}
+
+func (r *Recorder) PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (int, *Response, error) {
+	n, resp, err := r.p.PullRequestsCommitsBehind(ctx, sp, pr)
+	r.record("PullRequestsCommitsBehind", sp, n, resp, err)
+	return n, resp, err
+}
+
+func (r *Recorder) PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (int, *Response, error) {
+	n, resp, err := r.p.PullRequestsUnresolvedThreads(ctx, sp)
+	r.record("PullRequestsUnresolvedThreads", sp, n, resp, err)
+	return n, resp, err
+}
+
+func (r *Recorder) ReposGetFile(ctx context.Context, sp SearchParams, path string) ([]byte, *Response, error) {
+	bs, resp, err := r.p.ReposGetFile(ctx, sp, path)
+	r.record("ReposGetFile", sp, bs, resp, err, path)
+	return bs, resp, err
+}
+
+func (r *Recorder) ReposListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	ls, resp, err := r.p.ReposListLabels(ctx, sp)
+	r.record("ReposListLabels", sp, ls, resp, err)
+	return ls, resp, err
+}
+
+func (r *Recorder) ReposListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	ms, resp, err := r.p.ReposListMilestones(ctx, sp)
+	r.record("ReposListMilestones", sp, ms, resp, err)
+	return ms, resp, err
+}
+
+func (r *Recorder) GroupsIsMember(ctx context.Context, group string, user string) (bool, *Response, error) {
+	ok, resp, err := r.p.GroupsIsMember(ctx, group, user)
+	r.record("GroupsIsMember", SearchParams{}, ok, resp, err, group, user)
+	return ok, resp, err
+}
+
+func (r *Recorder) AlertsList(ctx context.Context, sp SearchParams, kind string) ([]*Alert, *Response, error) {
+	as, resp, err := r.p.AlertsList(ctx, sp, kind)
+	r.record("AlertsList", sp, as, resp, err, kind)
+	return as, resp, err
+}
+
+// Writes are passed through, but not recorded
+
+func (r *Recorder) IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return r.p.IssuesCreateComment(ctx, sp, body)
+}
+
+func (r *Recorder) PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return r.p.PullRequestsCreateComment(ctx, sp, body)
+}
+
+func (r *Recorder) IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return r.p.IssuesEditComment(ctx, sp, id, body)
+}
+
+func (r *Recorder) PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return r.p.PullRequestsEditComment(ctx, sp, id, body)
+}
+
+func (r *Recorder) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return r.p.IssuesEdit(ctx, sp, e)
+}
+
+func (r *Recorder) PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return r.p.PullRequestsEdit(ctx, sp, e)
+}
+
+func (r *Recorder) PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error) {
+	return r.p.PullRequestsRequestReviewers(ctx, sp, reviewers)
+}
+
+// Replayer is a provider which serves read requests from fixtures saved by a Recorder, without network access
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer returns a provider which replays the fixtures within a directory
+func NewReplayer(dir string) (*Replayer, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("fixtures: %w", err)
+	}
+	return &Replayer{dir: dir}, nil
+}
+
+// replay loads the recorded response to a read request into result
+func (r *Replayer) replay(method string, sp SearchParams, result interface{}, args ...interface{}) (*Response, error) {
+	path, _, err := fixturePath(r.dir, method, sp, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for %s on %s/%s: %w", method, sp.Repo.Organization, sp.Repo.Project, err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(bs, &f); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(f.Result, result); err != nil {
+		return nil, fmt.Errorf("unmarshal %s result: %w", path, err)
+	}
+
+	if f.Response == nil {
+		f.Response = &Response{}
+	}
+	if f.Error != "" {
+		return f.Response, errors.New(f.Error)
+	}
+	return f.Response, nil
+}
+
+func (r *Replayer) IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	var is []*Issue
+	resp, err := r.replay("IssuesListByRepo", sp, &is)
+	return is, resp, err
+}
+
+func (r *Replayer) IssuesListComments(ctx context.Context, sp SearchParams) ([]*IssueComment, *Response, error) {
+	var cs []*IssueComment
+	resp, err := r.replay("IssuesListComments", sp, &cs)
+	return cs, resp, err
+}
+
+func (r *Replayer) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) ([]*Timeline, *Response, error) {
+	var ts []*Timeline
+	resp, err := r.replay("IssuesListIssueTimeline", sp, &ts)
+	return ts, resp, err
+}
+
+func (r *Replayer) IssuesListSubIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	var is []*Issue
+	resp, err := r.replay("IssuesListSubIssues", sp, &is)
+	return is, resp, err
+}
+
+func (r *Replayer) EpicsList(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	var is []*Issue
+	resp, err := r.replay("EpicsList", sp, &is)
+	return is, resp, err
+}
+
+func (r *Replayer) EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) ([]*IssueComment, *Response, error) {
+	var cs []*IssueComment
+	resp, err := r.replay("EpicsListComments", sp, &cs)
+	return cs, resp, err
+}
+
+func (r *Replayer) EpicsListIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	var is []*Issue
+	resp, err := r.replay("EpicsListIssues", sp, &is)
+	return is, resp, err
+}
+
+func (r *Replayer) PullRequestsList(ctx context.Context, sp SearchParams) ([]*PullRequest, *Response, error) {
+	var prs []*PullRequest
+	resp, err := r.replay("PullRequestsList", sp, &prs)
+	return prs, resp, err
+}
+
+func (r *Replayer) PullRequestsGet(ctx context.Context, sp SearchParams) (*PullRequest, *Response, error) {
+	var pr *PullRequest
+	resp, err := r.replay("PullRequestsGet", sp, &pr)
+	return pr, resp, err
+}
+
+func (r *Replayer) PullRequestsListComments(ctx context.Context, sp SearchParams) ([]*PullRequestComment, *Response, error) {
+	var cs []*PullRequestComment
+	resp, err := r.replay("PullRequestsListComments", sp, &cs)
+	return cs, resp, err
+}
+
+func (r *Replayer) PullRequestsListReviews(ctx context.Context, sp SearchParams) ([]*PullRequestReview, *Response, error) {
+	var rs []*PullRequestReview
+	resp, err := r.replay("PullRequestsListReviews", sp, &rs)
+	return rs, resp, err
+}
+
+func (r *Replayer) PullRequestsListFiles(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	var fs []string
+	resp, err := r.replay("PullRequestsListFiles", sp, &fs)
+	return fs, resp, err
+}
+
+func (r *Replayer) PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (int, *Response, error) {
+	var n int
+	resp, err := r.replay("PullRequestsCommitsBehind", sp, &n)
+	return n, resp, err
+}
+
+func (r *Replayer) PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (int, *Response, error) {
+	var n int
+	resp, err := r.replay("PullRequestsUnresolvedThreads", sp, &n)
+	return n, resp, err
+}
+
+func (r *Replayer) ReposGetFile(ctx context.Context, sp SearchParams, path string) ([]byte, *Response, error) {
+	var bs []byte
+	resp, err := r.replay("ReposGetFile", sp, &bs, path)
+	return bs, resp, err
+}
+
+func (r *Replayer) ReposListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	var ls []string
+	resp, err := r.replay("ReposListLabels", sp, &ls)
+	return ls, resp, err
+}
+
+func (r *Replayer) ReposListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	var ms []string
+	resp, err := r.replay("ReposListMilestones", sp, &ms)
+	return ms, resp, err
+}
+
+func (r *Replayer) GroupsIsMember(ctx context.Context, group string, user string) (bool, *Response, error) {
+	var ok bool
+	resp, err := r.replay("GroupsIsMember", SearchParams{}, &ok, group, user)
+	return ok, resp, err
+}
+
+func (r *Replayer) AlertsList(ctx context.Context, sp SearchParams, kind string) ([]*Alert, *Response, error) {
+	var as []*Alert
+	resp, err := r.replay("AlertsList", sp, &as, kind)
+	return as, resp, err
+}
+
+func (r *Replayer) IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return nil, nil, errReplayWrite
+}
+
+func (r *Replayer) PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return nil, nil, errReplayWrite
+}
+
+func (r *Replayer) IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return nil, nil, errReplayWrite
+}
+
+func (r *Replayer) PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return nil, nil, errReplayWrite
+}
+
+func (r *Replayer) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return nil, errReplayWrite
+}
+
+func (r *Replayer) PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return nil, errReplayWrite
+}
+
+func (r *Replayer) PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error) {
+	return nil, errReplayWrite
+}
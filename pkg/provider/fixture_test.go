@@ -0,0 +1,77 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubProvider struct {
+	Provider
+	issues []*Issue
+}
+
+func (s *stubProvider) IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return s.issues, &Response{NextPage: 2}, nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	title := "flaky test"
+	number := 42
+	stub := &stubProvider{issues: []*Issue{{Number: &number, Title: &title}}}
+
+	rec, err := NewRecorder(stub, dir)
+	if err != nil {
+		t.Fatalf("recorder: %v", err)
+	}
+
+	ctx := context.Background()
+	sp := SearchParams{Repo: Repo{Organization: "google", Project: "triage-party"}, State: "open"}
+	sp.IssueListByRepoOptions.Since = time.Now().Add(-1 * time.Hour)
+	if _, _, err := rec.IssuesListByRepo(ctx, sp); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	rep, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("replayer: %v", err)
+	}
+
+	// The time a request is made at should not affect which fixture it matches
+	sp.IssueListByRepoOptions.Since = time.Now()
+	got, resp, err := rep.IssuesListByRepo(ctx, sp)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.NextPage)
+	assert.Equal(t, stub.issues, got)
+
+	sp.State = "closed"
+	_, _, err = rep.IssuesListByRepo(ctx, sp)
+	assert.Error(t, err)
+
+	_, err = rep.IssuesEdit(ctx, sp, IssueEdit{})
+	assert.Equal(t, errReplayWrite, err)
+}
@@ -18,13 +18,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v33/github"
+	"github.com/google/triage-party/pkg/constants"
 	"golang.org/x/oauth2"
 )
 
 type GitHubProvider struct {
 	client *github.Client
+	// ts is where tokens come from, to tell what kind of token is in use
+	ts oauth2.TokenSource
+	// throttle pauses requests after a secondary rate limit
+	throttle *throttle
 }
 
 func (p *GitHubProvider) getListOptions(m ListOptions) github.ListOptions {
@@ -126,35 +134,51 @@ func (p *GitHubProvider) IssuesListComments(ctx context.Context, sp SearchParams
 	return
 }
 
-func (p *GitHubProvider) getIssuesListIssueTimelineOptions(sp SearchParams) *github.ListOptions {
-	return &github.ListOptions{
-		PerPage: sp.ListOptions.PerPage,
+func (p *GitHubProvider) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) ([]*Timeline, *Response, error) {
+	// go-github's timeline events do not include requested reviewers, so decode them directly
+	u := fmt.Sprintf("repos/%s/%s/issues/%d/timeline", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	if sp.ListOptions.PerPage > 0 {
+		u = fmt.Sprintf("%s?per_page=%d&page=%d", u, sp.ListOptions.PerPage, sp.ListOptions.Page)
 	}
+	req, err := p.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.mockingbird-preview+json")
+
+	var ts []*Timeline
+	gr, err := p.client.Do(ctx, req, &ts)
+	return ts, p.getResponse(gr), err
 }
 
-func (p *GitHubProvider) getIssueTimeline(i []*github.Timeline) []*Timeline {
-	r := make([]*Timeline, len(i))
-	for k, v := range i {
-		m := Timeline{}
-		b, err := json.Marshal(v)
-		if err != nil {
-			fmt.Println(err)
-		}
-		err = json.Unmarshal(b, &m)
-		if err != nil {
-			fmt.Println(err)
-		}
-		r[k] = &m
+// IssuesListSubIssues returns the sub-issues an issue tracks
+func (p *GitHubProvider) IssuesListSubIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	// go-github does not support sub-issues yet
+	u := fmt.Sprintf("repos/%s/%s/issues/%d/sub_issues", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	if sp.ListOptions.PerPage > 0 {
+		u = fmt.Sprintf("%s?per_page=%d&page=%d", u, sp.ListOptions.PerPage, sp.ListOptions.Page)
 	}
-	return r
+	req, err := p.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var gi []*github.Issue
+	gr, err := p.client.Do(ctx, req, &gi)
+	return p.getIssues(gi), p.getResponse(gr), err
 }
 
-func (p *GitHubProvider) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) (i []*Timeline, r *Response, err error) {
-	opt := p.getIssuesListIssueTimelineOptions(sp)
-	it, ir, err := p.client.Issues.ListIssueTimeline(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, opt)
-	i = p.getIssueTimeline(it)
-	r = p.getResponse(ir)
-	return
+// EpicsList returns nothing, as GitHub has no epics
+func (p *GitHubProvider) EpicsList(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+func (p *GitHubProvider) EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) ([]*IssueComment, *Response, error) {
+	return []*IssueComment{}, &Response{}, nil
+}
+
+func (p *GitHubProvider) EpicsListIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
 }
 
 func (p *GitHubProvider) getPullRequestsListOptions(sp SearchParams) *github.PullRequestListOptions {
@@ -267,17 +291,489 @@ func (p *GitHubProvider) PullRequestsListReviews(ctx context.Context, sp SearchP
 	return
 }
 
+func (p *GitHubProvider) getIssueComment(i *github.IssueComment) *IssueComment {
+	r := IssueComment{}
+	b, err := json.Marshal(i)
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = json.Unmarshal(b, &r)
+	if err != nil {
+		fmt.Println(err)
+	}
+	return &r
+}
+
+func (p *GitHubProvider) IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (i *IssueComment, r *Response, err error) {
+	gc, gr, err := p.client.Issues.CreateComment(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, &github.IssueComment{Body: &body})
+	i = p.getIssueComment(gc)
+	r = p.getResponse(gr)
+	return
+}
+
+// GitHub treats the conversation on a pull request as an issue comment
+func (p *GitHubProvider) PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return p.IssuesCreateComment(ctx, sp, body)
+}
+
+func (p *GitHubProvider) IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (i *IssueComment, r *Response, err error) {
+	gc, gr, err := p.client.Issues.EditComment(ctx, sp.Repo.Organization, sp.Repo.Project, id, &github.IssueComment{Body: &body})
+	i = p.getIssueComment(gc)
+	r = p.getResponse(gr)
+	return
+}
+
+func (p *GitHubProvider) PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return p.IssuesEditComment(ctx, sp, id, body)
+}
+
+// milestoneNumber returns the number of a milestone with the given title
+func (p *GitHubProvider) milestoneNumber(ctx context.Context, sp SearchParams, title string) (int, *github.Response, error) {
+	opt := &github.MilestoneListOptions{State: "all", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		ms, gr, err := p.client.Issues.ListMilestones(ctx, sp.Repo.Organization, sp.Repo.Project, opt)
+		if err != nil {
+			return 0, gr, err
+		}
+		for _, m := range ms {
+			if m.GetTitle() == title {
+				return m.GetNumber(), gr, nil
+			}
+		}
+		if gr.NextPage == 0 {
+			return 0, gr, fmt.Errorf("unknown milestone: %q", title)
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+func (p *GitHubProvider) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	var gr *github.Response
+	var err error
+
+	if len(e.AddLabels) > 0 {
+		_, gr, err = p.client.Issues.AddLabelsToIssue(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, e.AddLabels)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+	}
+
+	for _, l := range e.RemoveLabels {
+		gr, err = p.client.Issues.RemoveLabelForIssue(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, l)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+	}
+
+	if len(e.AddAssignees) > 0 {
+		_, gr, err = p.client.Issues.AddAssignees(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, e.AddAssignees)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+	}
+
+	ir := &github.IssueRequest{}
+	changed := false
+	if e.State != "" {
+		ir.State = &e.State
+		changed = true
+	}
+	if e.Milestone != "" {
+		n, gr, err := p.milestoneNumber(ctx, sp, e.Milestone)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+		ir.Milestone = &n
+		changed = true
+	}
+
+	if changed {
+		_, gr, err = p.client.Issues.Edit(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, ir)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+	}
+
+	if e.Locked != nil {
+		if *e.Locked {
+			gr, err = p.client.Issues.Lock(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, &github.LockIssueOptions{LockReason: e.LockReason})
+		} else {
+			gr, err = p.client.Issues.Unlock(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+		}
+	}
+
+	return p.getResponse(gr), err
+}
+
+// GitHub edits the issue-side of a pull request for labels, assignees, milestones and locking
+func (p *GitHubProvider) PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return p.IssuesEdit(ctx, sp, e)
+}
+
+// Reviewers in the form of "org/team" are requested as teams
+func (p *GitHubProvider) PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error) {
+	rr := github.ReviewersRequest{}
+	for _, r := range reviewers {
+		if strings.Contains(r, "/") {
+			rr.TeamReviewers = append(rr.TeamReviewers, r[strings.LastIndex(r, "/")+1:])
+			continue
+		}
+		rr.Reviewers = append(rr.Reviewers, r)
+	}
+
+	_, gr, err := p.client.PullRequests.RequestReviewers(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, rr)
+	return p.getResponse(gr), err
+}
+
+func (p *GitHubProvider) PullRequestsListFiles(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	files := []string{}
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		fs, gr, err := p.client.PullRequests.ListFiles(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, f := range fs {
+			files = append(files, f.GetFilename())
+		}
+		if gr.NextPage == 0 {
+			return files, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// PullRequestsCommitsBehind returns how many commits the head of a pull request is behind its base branch
+func (p *GitHubProvider) PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (int, *Response, error) {
+	base, head := pr.GetBase().GetLabel(), pr.GetHead().GetLabel()
+	if base == "" || head == "" {
+		return 0, &Response{}, fmt.Errorf("PR #%d has no base or head branch", pr.GetNumber())
+	}
+
+	cc, gr, err := p.client.Repositories.CompareCommits(ctx, sp.Repo.Organization, sp.Repo.Project, base, head)
+	if err != nil {
+		return 0, p.getResponse(gr), err
+	}
+	return cc.GetBehindBy(), p.getResponse(gr), nil
+}
+
+// reviewThreadsQuery lists the resolution state of the review threads of a pull request
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        nodes { isResolved }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type reviewThreadsResponse struct {
+	Data struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewThreads struct {
+					Nodes []struct {
+						IsResolved bool `json:"isResolved"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// PullRequestsUnresolvedThreads returns how many review threads of a pull request are unresolved
+func (p *GitHubProvider) PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (int, *Response, error) {
+	// Resolution state is only available through GraphQL, which is served from /graphql, or /api/graphql for GitHub Enterprise
+	vars := map[string]interface{}{"owner": sp.Repo.Organization, "name": sp.Repo.Project, "number": sp.IssueNumber}
+
+	n := 0
+	var gr *github.Response
+	for {
+		req, err := p.client.NewRequest("POST", "../graphql", &graphQLRequest{Query: reviewThreadsQuery, Variables: vars})
+		if err != nil {
+			return 0, nil, err
+		}
+
+		var rt reviewThreadsResponse
+		gr, err = p.client.Do(ctx, req, &rt)
+		if err != nil {
+			return 0, p.getResponse(gr), err
+		}
+		if len(rt.Errors) > 0 {
+			return 0, p.getResponse(gr), fmt.Errorf("graphql: %s", rt.Errors[0].Message)
+		}
+
+		threads := rt.Data.Repository.PullRequest.ReviewThreads
+		for _, t := range threads.Nodes {
+			if !t.IsResolved {
+				n++
+			}
+		}
+
+		if !threads.PageInfo.HasNextPage {
+			break
+		}
+		vars["cursor"] = threads.PageInfo.EndCursor
+	}
+	return n, p.getResponse(gr), nil
+}
+
+// ReposGetFile returns the contents of a file on the default branch
+func (p *GitHubProvider) ReposGetFile(ctx context.Context, sp SearchParams, path string) ([]byte, *Response, error) {
+	fc, _, gr, err := p.client.Repositories.GetContents(ctx, sp.Repo.Organization, sp.Repo.Project, path, nil)
+	if err != nil {
+		return nil, p.getResponse(gr), err
+	}
+	if fc == nil {
+		return nil, p.getResponse(gr), fmt.Errorf("%s is not a file", path)
+	}
+
+	c, err := fc.GetContent()
+	return []byte(c), p.getResponse(gr), err
+}
+
+// ReposListLabels returns the names of a repository's labels
+func (p *GitHubProvider) ReposListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	names := []string{}
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		ls, gr, err := p.client.Issues.ListLabels(ctx, sp.Repo.Organization, sp.Repo.Project, opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, l := range ls {
+			names = append(names, l.GetName())
+		}
+		if gr.NextPage == 0 {
+			return names, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// ReposListMilestones returns the titles of a repository's open milestones
+func (p *GitHubProvider) ReposListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	titles := []string{}
+	opt := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		ms, gr, err := p.client.Issues.ListMilestones(ctx, sp.Repo.Organization, sp.Repo.Project, opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, m := range ms {
+			titles = append(titles, m.GetTitle())
+		}
+		if gr.NextPage == 0 {
+			return titles, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// GroupsIsMember returns whether a user is a member of an organization, or of a team in the form of "org/team"
+func (p *GitHubProvider) GroupsIsMember(ctx context.Context, group string, user string) (bool, *Response, error) {
+	parts := strings.SplitN(group, "/", 2)
+	if len(parts) == 1 {
+		ok, gr, err := p.client.Organizations.IsMember(ctx, group, user)
+		return ok, p.getResponse(gr), err
+	}
+
+	m, gr, err := p.client.Teams.GetTeamMembershipBySlug(ctx, parts[0], parts[1], user)
+	if gr != nil && gr.StatusCode == http.StatusNotFound {
+		return false, p.getResponse(gr), nil
+	}
+	if err != nil {
+		return false, p.getResponse(gr), err
+	}
+	return m.GetState() == "active", p.getResponse(gr), nil
+}
+
+// githubAlert is the union of the fields used from Dependabot, code scanning, and secret scanning alerts
+type githubAlert struct {
+	Number    int       `json:"number"`
+	HTMLURL   string    `json:"html_url"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Dependabot
+	Dependency struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+	} `json:"dependency"`
+	SecurityAdvisory struct {
+		Summary  string `json:"summary"`
+		Severity string `json:"severity"`
+	} `json:"security_advisory"`
+	FixedAt         *time.Time `json:"fixed_at"`
+	DismissedAt     *time.Time `json:"dismissed_at"`
+	AutoDismissedAt *time.Time `json:"auto_dismissed_at"`
+
+	// Code scanning
+	Rule struct {
+		Description           string `json:"description"`
+		Severity              string `json:"severity"`
+		SecuritySeverityLevel string `json:"security_severity_level"`
+	} `json:"rule"`
+	MostRecentInstance struct {
+		Location struct {
+			Path string `json:"path"`
+		} `json:"location"`
+	} `json:"most_recent_instance"`
+
+	// Secret scanning
+	SecretTypeDisplayName string     `json:"secret_type_display_name"`
+	Resolution            string     `json:"resolution"`
+	ResolvedAt            *time.Time `json:"resolved_at"`
+}
+
+// alert returns the provider neutral form of an alert
+func (a *githubAlert) alert(kind string) *Alert {
+	r := &Alert{
+		Kind:      kind,
+		Number:    a.Number,
+		HTMLURL:   a.HTMLURL,
+		State:     constants.OpenState,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+
+	switch kind {
+	case DependabotAlert:
+		r.Title = fmt.Sprintf("%s: %s", a.Dependency.Package.Name, a.SecurityAdvisory.Summary)
+		r.Severity = a.SecurityAdvisory.Severity
+	case CodeScanningAlert:
+		r.Title = a.Rule.Description
+		if a.MostRecentInstance.Location.Path != "" {
+			r.Title = fmt.Sprintf("%s in %s", r.Title, a.MostRecentInstance.Location.Path)
+		}
+		r.Severity = a.Rule.SecuritySeverityLevel
+		if r.Severity == "" {
+			r.Severity = a.Rule.Severity
+		}
+	case SecretScanningAlert:
+		r.Title = fmt.Sprintf("%s exposed", a.SecretTypeDisplayName)
+	}
+
+	if a.State == constants.OpenState {
+		return r
+	}
+
+	r.State = constants.ClosedState
+	r.Resolution = a.State
+	if a.Resolution != "" {
+		r.Resolution = a.Resolution
+	}
+	for _, t := range []*time.Time{a.FixedAt, a.DismissedAt, a.AutoDismissedAt, a.ResolvedAt} {
+		if t != nil {
+			r.ClosedAt = *t
+			break
+		}
+	}
+	return r
+}
+
+// AlertsList returns a repository's security alerts of a kind, in any state unless sp.State is open
+func (p *GitHubProvider) AlertsList(ctx context.Context, sp SearchParams, kind string) ([]*Alert, *Response, error) {
+	// go-github does not support Dependabot or secret scanning alerts yet
+	u := fmt.Sprintf("repos/%s/%s/%s/alerts?per_page=100", sp.Repo.Organization, sp.Repo.Project, kind)
+	if sp.State == constants.OpenState {
+		u += "&state=open"
+	}
+
+	alerts := []*Alert{}
+	page := 0
+	for {
+		req, err := p.client.NewRequest("GET", fmt.Sprintf("%s&page=%d", u, page), nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var gas []*githubAlert
+		gr, err := p.client.Do(ctx, req, &gas)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, a := range gas {
+			alerts = append(alerts, a.alert(kind))
+		}
+		if gr.NextPage == 0 {
+			return alerts, p.getResponse(gr), nil
+		}
+		page = gr.NextPage
+	}
+}
+
 func NewGitHub(ctx context.Context, token string, url string) (Provider, error) {
-	o := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+	return NewGitHubFromSource(ctx, oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
-	))
+	), url)
+}
+
+// NewGitHubFromSource returns a GitHub provider which authenticates using tokens from a source, such as a rotated secret
+func NewGitHubFromSource(ctx context.Context, ts oauth2.TokenSource, url string) (Provider, error) {
+	o := oauth2.NewClient(ctx, ts)
+	th := newThrottle(o.Transport)
+	o.Transport = th
 
 	if url != "" {
 		client, err := github.NewEnterpriseClient(url, url, o)
 		if err != nil {
 			return nil, fmt.Errorf("NewEnterpriseClient: %v", err)
 		}
-		return &GitHubProvider{client: client}, nil
+		return &GitHubProvider{client: client, ts: ts, throttle: th}, nil
+	}
+	return &GitHubProvider{client: github.NewClient(o), ts: ts, throttle: th}, nil
+}
+
+// PausedUntil returns when requests may be made again after a secondary rate limit, or a zero time if they are not paused
+func (p *GitHubProvider) PausedUntil() time.Time {
+	if p.throttle == nil {
+		return time.Time{}
+	}
+	return p.throttle.PausedUntil()
+}
+
+// TokenInfo describes the token in use. Reading the rate limit does not count against it.
+func (p *GitHubProvider) TokenInfo(ctx context.Context) (*TokenInfo, error) {
+	t, err := p.ts.Token()
+	if err != nil {
+		return nil, fmt.Errorf("token: %w", err)
+	}
+
+	_, gr, err := p.client.RateLimits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rate limits: %w", err)
+	}
+
+	ti := &TokenInfo{
+		Type: GitHubTokenType(t.AccessToken),
+		Rate: p.getResponse(gr).Rate,
+	}
+	// Fine-grained tokens do not send this header at all
+	if _, ok := gr.Header["X-Oauth-Scopes"]; ok {
+		ti.Scopes = parseScopes(gr.Header.Get("X-OAuth-Scopes"))
+	}
+	if exp := gr.Header.Get("GitHub-Authentication-Token-Expiration"); exp != "" {
+		ti.Expires, err = parseTokenExpiration(exp)
+		if err != nil {
+			return ti, err
+		}
 	}
-	return &GitHubProvider{client: github.NewClient(o)}, nil
+	return ti, nil
 }
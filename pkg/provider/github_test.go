@@ -15,7 +15,11 @@
 package provider
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGitHub_GetResponse(t *testing.T) {
@@ -33,9 +37,14 @@ func TestGitHub_GetIssueComments(t *testing.T) {
 	p.getIssueComments(nil)
 }
 
-func TestGitHub_GetIssueTimeline(t *testing.T) {
-	p := GitHubProvider{}
-	p.getIssueTimeline(nil)
+func TestGitHub_IssueTimeline(t *testing.T) {
+	raw := `[{"event": "review_requested", "created_at": "2021-03-01T00:00:00Z", "requested_reviewer": {"login": "alice"}}]`
+
+	var ts []*Timeline
+	if err := json.Unmarshal([]byte(raw), &ts); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	assert.Equal(t, "alice", ts[0].GetRequestedReviewer().GetLogin())
 }
 
 func TestGitHub_GetPullRequestsList(t *testing.T) {
@@ -57,3 +66,28 @@ func TestGitHub_GetPullRequestsListReviews(t *testing.T) {
 	p := GitHubProvider{}
 	p.getPullRequestsListReviews(nil)
 }
+
+func TestGitHub_Alert(t *testing.T) {
+	raw := `{
+		"number": 7,
+		"state": "dismissed",
+		"html_url": "https://github.com/org/repo/security/dependabot/7",
+		"created_at": "2021-03-01T00:00:00Z",
+		"updated_at": "2021-03-05T00:00:00Z",
+		"dismissed_at": "2021-03-05T00:00:00Z",
+		"dependency": {"package": {"name": "lodash"}},
+		"security_advisory": {"summary": "Prototype pollution", "severity": "high"}
+	}`
+
+	var ga githubAlert
+	if err := json.Unmarshal([]byte(raw), &ga); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	a := ga.alert(DependabotAlert)
+	assert.Equal(t, "lodash: Prototype pollution", a.Title)
+	assert.Equal(t, "high", a.Severity)
+	assert.Equal(t, "closed", a.State)
+	assert.Equal(t, "dismissed", a.Resolution)
+	assert.Equal(t, time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC), a.ClosedAt)
+}
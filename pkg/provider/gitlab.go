@@ -17,11 +17,14 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/google/triage-party/pkg/constants"
 	"github.com/xanzy/go-gitlab"
+	"golang.org/x/oauth2"
 )
 
 type GitLabProvider struct {
@@ -36,6 +39,38 @@ func NewGitLab(token string) (Provider, error) {
 	return &GitLabProvider{client: cl}, nil
 }
 
+// NewGitLabFromSource returns a GitLab provider which authenticates using tokens from a source, such as a rotated secret
+func NewGitLabFromSource(ts oauth2.TokenSource) (Provider, error) {
+	t, err := ts.Token()
+	if err != nil {
+		return nil, fmt.Errorf("token: %v", err)
+	}
+
+	hc := &http.Client{Transport: &gitLabTokenTransport{source: ts, base: http.DefaultTransport}}
+	cl, err := gitlab.NewClient(t.AccessToken, gitlab.WithHTTPClient(hc))
+	if err != nil {
+		return nil, fmt.Errorf("client: %v", err)
+	}
+	return &GitLabProvider{client: cl}, nil
+}
+
+// gitLabTokenTransport replaces the token of each request with the current one from a source
+type gitLabTokenTransport struct {
+	source oauth2.TokenSource
+	base   http.RoundTripper
+}
+
+func (t *gitLabTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("token: %w", err)
+	}
+
+	r := req.Clone(req.Context())
+	r.Header.Set("PRIVATE-TOKEN", tok.AccessToken)
+	return t.base.RoundTrip(r)
+}
+
 func (p *GitLabProvider) getListProjectIssuesOptions(sp SearchParams) *gitlab.ListProjectIssuesOptions {
 	var state *string
 	if sp.IssueListByRepoOptions.State == constants.OpenState {
@@ -138,6 +173,9 @@ func (p *GitLabProvider) getResponse(i *gitlab.Response) *Response {
 
 // https://docs.gitlab.com/ee/api/issues.html#list-project-issues
 func (p *GitLabProvider) IssuesListByRepo(ctx context.Context, sp SearchParams) (i []*Issue, r *Response, err error) {
+	if sp.Repo.AllProjects {
+		return p.issuesListByGroup(ctx, sp)
+	}
 	opt := p.getListProjectIssuesOptions(sp)
 	is, gr, err := p.client.Issues.ListProjectIssues(p.getProjectId(sp.Repo), opt)
 	i = p.getIssues(is)
@@ -145,6 +183,20 @@ func (p *GitLabProvider) IssuesListByRepo(ctx context.Context, sp SearchParams)
 	return
 }
 
+// https://docs.gitlab.com/ee/api/issues.html#list-group-issues
+func (p *GitLabProvider) issuesListByGroup(ctx context.Context, sp SearchParams) (i []*Issue, r *Response, err error) {
+	po := p.getListProjectIssuesOptions(sp)
+	opt := &gitlab.ListGroupIssuesOptions{
+		ListOptions:  po.ListOptions,
+		State:        po.State,
+		CreatedAfter: po.CreatedAfter,
+	}
+	is, gr, err := p.client.Issues.ListGroupIssues(p.getGroupId(sp.Repo), opt)
+	i = p.getIssues(is)
+	r = p.getResponse(gr)
+	return
+}
+
 func (p *GitLabProvider) getListIssueNotesOptions(sp SearchParams) *gitlab.ListIssueNotesOptions {
 	return &gitlab.ListIssueNotesOptions{
 		ListOptions: p.getListOptions(sp.IssueListCommentsOptions.ListOptions),
@@ -166,7 +218,9 @@ func (p *GitLabProvider) getUserFromNote(i *gitlab.Note) *User {
 func (p *GitLabProvider) getIssueComments(i []*gitlab.Note) []*IssueComment {
 	r := make([]*IssueComment, len(i))
 	for k, v := range i {
+		id := int64(v.ID)
 		m := &IssueComment{
+			ID:        &id,
 			User:      p.getUserFromNote(v),
 			Body:      &v.Body,
 			CreatedAt: v.CreatedAt,
@@ -264,6 +318,7 @@ func (p *GitLabProvider) getPullRequest(v *gitlab.MergeRequest) *PullRequest {
 		return nil
 	}
 	id := int64(v.ID)
+	mergeable, mergeableState := gitlabMergeableState(v)
 	m := &PullRequest{
 		Assignee:  p.getUserFromBasicUser(v.Assignee, true),
 		User:      p.getUserFromBasicUser(v.Author, false),
@@ -278,10 +333,27 @@ func (p *GitLabProvider) getPullRequest(v *gitlab.MergeRequest) *PullRequest {
 		Number:    &v.IID,
 		Milestone: p.getMilestone(v.Milestone),
 		HTMLURL:   &v.WebURL,
+
+		Mergeable:      &mergeable,
+		MergeableState: &mergeableState,
 	}
 	return m
 }
 
+// gitlabMergeableState maps the merge and pipeline status of a merge request to GitHub's mergeable states
+func gitlabMergeableState(v *gitlab.MergeRequest) (bool, string) {
+	switch {
+	case v.HasConflicts || v.MergeStatus == "cannot_be_merged":
+		return false, "dirty"
+	case v.MergeStatus != "can_be_merged":
+		return false, "unknown"
+	case v.Pipeline != nil && v.Pipeline.Status != "success":
+		return true, "unstable"
+	default:
+		return true, "clean"
+	}
+}
+
 func (p *GitLabProvider) getPullRequests(i []*gitlab.MergeRequest) []*PullRequest {
 	r := make([]*PullRequest, len(i))
 	for k, v := range i {
@@ -291,8 +363,117 @@ func (p *GitLabProvider) getPullRequests(i []*gitlab.MergeRequest) []*PullReques
 	return r
 }
 
+// IssuesListSubIssues returns no sub-issues, as GitLab only nests work items within epics
+func (p *GitLabProvider) IssuesListSubIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// EpicsList returns the epics of a group, as issues
+// https://docs.gitlab.com/ee/api/epics.html#list-epics-for-a-group
+func (p *GitLabProvider) EpicsList(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	opt := &gitlab.ListGroupEpicsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	if sp.State == constants.OpenedState || sp.State == constants.OpenState {
+		s := constants.OpenedState
+		opt.State = &s
+	}
+
+	is := []*Issue{}
+	for {
+		es, gr, err := p.client.Epics.ListGroupEpics(p.getGroupId(sp.Repo), opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, e := range es {
+			is = append(is, p.getEpic(e))
+		}
+		if gr.NextPage == 0 {
+			return is, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+func (p *GitLabProvider) getEpic(e *gitlab.Epic) *Issue {
+	id := int64(e.ID)
+	i := &Issue{
+		ID:        &id,
+		Number:    &e.IID,
+		Title:     &e.Title,
+		Body:      &e.Description,
+		State:     &e.State,
+		HTMLURL:   &e.WebURL,
+		URL:       &e.WebURL,
+		Comments:  &e.UserNotesCount,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+	}
+	if e.Author != nil {
+		aid := int64(e.Author.ID)
+		i.User = &User{
+			ID:        &aid,
+			Name:      &e.Author.Name,
+			Login:     &e.Author.Username,
+			AvatarURL: &e.Author.AvatarURL,
+			HTMLURL:   &e.Author.WebURL,
+		}
+	}
+	for _, l := range e.Labels {
+		l := l
+		i.Labels = append(i.Labels, &Label{Name: &l})
+	}
+	return i
+}
+
+// EpicsListComments returns the notes of an epic, which are addressed by its ID rather than its IID
+// https://docs.gitlab.com/ee/api/notes.html#list-all-epic-notes
+func (p *GitLabProvider) EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) ([]*IssueComment, *Response, error) {
+	cs := []*IssueComment{}
+	opt := &gitlab.ListEpicNotesOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		ns, gr, err := p.client.Notes.ListEpicNotes(p.getGroupId(sp.Repo), int(epic.GetID()), opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		cs = append(cs, p.getIssueComments(ns)...)
+		if gr.NextPage == 0 {
+			return cs, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// EpicsListIssues returns the child issues of an epic
+// https://docs.gitlab.com/ee/api/epic_issues.html#list-issues-for-an-epic
+func (p *GitLabProvider) EpicsListIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	is := []*Issue{}
+	opt := &gitlab.ListOptions{PerPage: 100}
+	for {
+		gi, gr, err := p.client.EpicIssues.ListEpicIssues(p.getGroupId(sp.Repo), sp.IssueNumber, opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		is = append(is, p.getIssues(gi)...)
+		if gr.NextPage == 0 {
+			return is, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
 func (p *GitLabProvider) PullRequestsList(ctx context.Context, sp SearchParams) (i []*PullRequest, r *Response, err error) {
 	opt := p.getListProjectMergeRequestsOptions(sp)
+	if sp.Repo.AllProjects {
+		// https://docs.gitlab.com/ee/api/merge_requests.html#list-group-merge-requests
+		gopt := &gitlab.ListGroupMergeRequestsOptions{
+			ListOptions: opt.ListOptions,
+			Sort:        opt.Sort,
+			OrderBy:     opt.OrderBy,
+			State:       opt.State,
+		}
+		in, gr, err := p.client.MergeRequests.ListGroupMergeRequests(p.getGroupId(sp.Repo), gopt)
+		return p.getPullRequests(in), p.getResponse(gr), err
+	}
+
 	in, gr, err := p.client.MergeRequests.ListProjectMergeRequests(p.getProjectId(sp.Repo), opt)
 	i = p.getPullRequests(in)
 	r = p.getResponse(gr)
@@ -333,30 +514,447 @@ func (p *GitLabProvider) PullRequestsListComments(ctx context.Context, sp Search
 	return
 }
 
-func (p *GitLabProvider) getPullRequestReviews(i *gitlab.MergeRequestApprovals) []*PullRequestReview {
+// getPullRequestReviews returns the current approvals of a merge request, dated by the system notes which recorded them,
+// along with reviewers whose latest review requested changes. Reviews are returned oldest first, as GitHub does.
+func (p *GitLabProvider) getPullRequestReviews(i *gitlab.MergeRequestApprovals, notes []*gitlab.Note) []*PullRequestReview {
 	if i == nil {
 		return nil
 	}
-	r := make([]*PullRequestReview, len(i.ApprovedBy))
-	state := "APPROVED"
-	for k, v := range i.ApprovedBy {
+
+	approved := map[string]*time.Time{}
+	changes := map[string]*gitlab.Note{}
+	for _, n := range notes {
+		if !n.System {
+			continue
+		}
+		switch n.Body {
+		case "approved this merge request":
+			approved[n.Author.Username] = n.CreatedAt
+		case "requested changes":
+			changes[n.Author.Username] = n
+		}
+	}
+
+	r := []*PullRequestReview{}
+	for _, v := range i.ApprovedBy {
+		state := "APPROVED"
 		m := &PullRequestReview{
-			User:  p.getUserFromBasicUser(v.User, false),
-			State: &state,
+			User:        p.getUserFromBasicUser(v.User, false),
+			State:       &state,
+			SubmittedAt: approved[v.User.Username],
 		}
-		r[k] = m
+		delete(changes, v.User.Username)
+		r = append(r, m)
 	}
+
+	for _, n := range changes {
+		state := "CHANGES_REQUESTED"
+		r = append(r, &PullRequestReview{
+			User:        p.getUserFromNote(n),
+			State:       &state,
+			SubmittedAt: n.CreatedAt,
+		})
+	}
+
+	sort.SliceStable(r, func(a, b int) bool { return r[a].GetSubmittedAt().Before(r[b].GetSubmittedAt()) })
 	return r
 }
 
+// PullRequestsListReviews returns the reviews of a merge request, which costs a request for its approvals and one per page of notes
 func (p *GitLabProvider) PullRequestsListReviews(ctx context.Context, sp SearchParams) (i []*PullRequestReview, r *Response, err error) {
-	// TODO need to clarify
 	in, gr, err := p.client.MergeRequests.GetMergeRequestApprovals(p.getProjectId(sp.Repo), sp.IssueNumber)
-	i = p.getPullRequestReviews(in)
+	if err != nil {
+		return nil, p.getResponse(gr), err
+	}
+
+	notes := []*gitlab.Note{}
+	opt := &gitlab.ListMergeRequestNotesOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		ns, ngr, err := p.client.Notes.ListMergeRequestNotes(p.getProjectId(sp.Repo), sp.IssueNumber, opt)
+		if err != nil {
+			return nil, p.getResponse(ngr), err
+		}
+		notes = append(notes, ns...)
+		gr = ngr
+
+		if ngr.NextPage == 0 {
+			break
+		}
+		opt.Page = ngr.NextPage
+	}
+
+	return p.getPullRequestReviews(in, notes), p.getResponse(gr), nil
+}
+
+func (p *GitLabProvider) getIssueComment(v *gitlab.Note) *IssueComment {
+	if v == nil {
+		return nil
+	}
+	return p.getIssueComments([]*gitlab.Note{v})[0]
+}
+
+// https://docs.gitlab.com/ce/api/notes.html#create-new-issue-note
+func (p *GitLabProvider) IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (i *IssueComment, r *Response, err error) {
+	opt := &gitlab.CreateIssueNoteOptions{Body: &body}
+	in, gr, err := p.client.Notes.CreateIssueNote(p.getProjectId(sp.Repo), sp.IssueNumber, opt)
+	i = p.getIssueComment(in)
+	r = p.getResponse(gr)
+	return
+}
+
+// https://docs.gitlab.com/ce/api/notes.html#create-new-merge-request-note
+func (p *GitLabProvider) PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (i *IssueComment, r *Response, err error) {
+	opt := &gitlab.CreateMergeRequestNoteOptions{Body: &body}
+	in, gr, err := p.client.Notes.CreateMergeRequestNote(p.getProjectId(sp.Repo), sp.IssueNumber, opt)
+	i = p.getIssueComment(in)
+	r = p.getResponse(gr)
+	return
+}
+
+// https://docs.gitlab.com/ce/api/notes.html#modify-existing-issue-note
+func (p *GitLabProvider) IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (i *IssueComment, r *Response, err error) {
+	opt := &gitlab.UpdateIssueNoteOptions{Body: &body}
+	in, gr, err := p.client.Notes.UpdateIssueNote(p.getProjectId(sp.Repo), sp.IssueNumber, int(id), opt)
+	i = p.getIssueComment(in)
 	r = p.getResponse(gr)
 	return
 }
 
+// https://docs.gitlab.com/ce/api/notes.html#modify-existing-merge-request-note
+func (p *GitLabProvider) PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (i *IssueComment, r *Response, err error) {
+	opt := &gitlab.UpdateMergeRequestNoteOptions{Body: &body}
+	in, gr, err := p.client.Notes.UpdateMergeRequestNote(p.getProjectId(sp.Repo), sp.IssueNumber, int(id), opt)
+	i = p.getIssueComment(in)
+	r = p.getResponse(gr)
+	return
+}
+
+// https://docs.gitlab.com/ce/api/issues.html#edit-issue
+func (p *GitLabProvider) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	opt := &gitlab.UpdateIssueOptions{}
+	if len(e.AddLabels) > 0 {
+		l := gitlab.Labels(e.AddLabels)
+		opt.AddLabels = &l
+	}
+	if len(e.RemoveLabels) > 0 {
+		l := gitlab.Labels(e.RemoveLabels)
+		opt.RemoveLabels = &l
+	}
+	if e.State != "" {
+		opt.StateEvent = gitlabStateEvent(e.State)
+	}
+	if e.Milestone != "" {
+		id, gr, err := p.milestoneID(sp, e.Milestone)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+		opt.MilestoneID = &id
+	}
+	if e.Locked != nil {
+		opt.DiscussionLocked = e.Locked
+	}
+	if len(e.AddAssignees) > 0 {
+		i, gr, err := p.client.Issues.GetIssue(p.getProjectId(sp.Repo), sp.IssueNumber)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+		for _, a := range i.Assignees {
+			opt.AssigneeIDs = append(opt.AssigneeIDs, a.ID)
+		}
+		ids, gr, err := p.userIDs(e.AddAssignees)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+		opt.AssigneeIDs = append(opt.AssigneeIDs, ids...)
+	}
+
+	_, gr, err := p.client.Issues.UpdateIssue(p.getProjectId(sp.Repo), sp.IssueNumber, opt)
+	return p.getResponse(gr), err
+}
+
+// https://docs.gitlab.com/ce/api/merge_requests.html#update-mr
+func (p *GitLabProvider) PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	opt := &gitlab.UpdateMergeRequestOptions{}
+
+	// The merge request API only supports replacing the full set of labels and assignees
+	if len(e.AddLabels) > 0 || len(e.RemoveLabels) > 0 || len(e.AddAssignees) > 0 {
+		mr, gr, err := p.client.MergeRequests.GetMergeRequest(p.getProjectId(sp.Repo), sp.IssueNumber, &gitlab.GetMergeRequestsOptions{})
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+
+		if len(e.AddLabels) > 0 || len(e.RemoveLabels) > 0 {
+			l := gitlab.Labels(editLabels(mr.Labels, e.AddLabels, e.RemoveLabels))
+			opt.Labels = &l
+		}
+
+		if len(e.AddAssignees) > 0 {
+			for _, a := range mr.Assignees {
+				opt.AssigneeIDs = append(opt.AssigneeIDs, a.ID)
+			}
+			ids, gr, err := p.userIDs(e.AddAssignees)
+			if err != nil {
+				return p.getResponse(gr), err
+			}
+			opt.AssigneeIDs = append(opt.AssigneeIDs, ids...)
+		}
+	}
+	if e.State != "" {
+		opt.StateEvent = gitlabStateEvent(e.State)
+	}
+	if e.Milestone != "" {
+		id, gr, err := p.milestoneID(sp, e.Milestone)
+		if err != nil {
+			return p.getResponse(gr), err
+		}
+		opt.MilestoneID = &id
+	}
+	if e.Locked != nil {
+		opt.DiscussionLocked = e.Locked
+	}
+
+	_, gr, err := p.client.MergeRequests.UpdateMergeRequest(p.getProjectId(sp.Repo), sp.IssueNumber, opt)
+	return p.getResponse(gr), err
+}
+
+// The merge request API in this client does not support reviewers, so they are requested with a quick action
+// https://docs.gitlab.com/ee/user/project/quick_actions.html
+func (p *GitLabProvider) PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error) {
+	body := "/assign_reviewer"
+	for _, r := range reviewers {
+		body += " @" + r
+	}
+	_, gr, err := p.client.Notes.CreateMergeRequestNote(p.getProjectId(sp.Repo), sp.IssueNumber, &gitlab.CreateMergeRequestNoteOptions{Body: &body})
+	return p.getResponse(gr), err
+}
+
+// https://docs.gitlab.com/ce/api/merge_requests.html#get-single-mr-changes
+func (p *GitLabProvider) PullRequestsListFiles(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	mr, gr, err := p.client.MergeRequests.GetMergeRequestChanges(p.getProjectId(sp.Repo), sp.IssueNumber)
+	if err != nil {
+		return nil, p.getResponse(gr), err
+	}
+
+	files := []string{}
+	for _, c := range mr.Changes {
+		files = append(files, c.NewPath)
+	}
+	return files, p.getResponse(gr), nil
+}
+
+// PullRequestsCommitsBehind returns how many commits the source branch of a merge request is behind its target branch
+func (p *GitLabProvider) PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (int, *Response, error) {
+	include := true
+	opt := &gitlab.GetMergeRequestsOptions{IncludeDivergedCommitsCount: &include}
+	mr, gr, err := p.client.MergeRequests.GetMergeRequest(p.getProjectId(sp.Repo), sp.IssueNumber, opt)
+	if err != nil {
+		return 0, p.getResponse(gr), err
+	}
+	return mr.DivergedCommitsCount, p.getResponse(gr), nil
+}
+
+// PullRequestsUnresolvedThreads returns how many resolvable discussions of a merge request are unresolved
+// https://docs.gitlab.com/ce/api/discussions.html#list-project-merge-request-discussion-items
+func (p *GitLabProvider) PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (int, *Response, error) {
+	n := 0
+	opt := &gitlab.ListMergeRequestDiscussionsOptions{PerPage: 100}
+	for {
+		ds, gr, err := p.client.Discussions.ListMergeRequestDiscussions(p.getProjectId(sp.Repo), sp.IssueNumber, opt)
+		if err != nil {
+			return 0, p.getResponse(gr), err
+		}
+
+		for _, d := range ds {
+			// A discussion is resolved once all of its resolvable notes are
+			for _, note := range d.Notes {
+				if note.Resolvable && !note.Resolved {
+					n++
+					break
+				}
+			}
+		}
+
+		if gr.NextPage == 0 {
+			return n, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// ReposGetFile returns the contents of a file on the default branch
+func (p *GitLabProvider) ReposGetFile(ctx context.Context, sp SearchParams, path string) ([]byte, *Response, error) {
+	ref := "HEAD"
+	b, gr, err := p.client.RepositoryFiles.GetRawFile(p.getProjectId(sp.Repo), path, &gitlab.GetRawFileOptions{Ref: &ref})
+	return b, p.getResponse(gr), err
+}
+
+// ReposListLabels returns the names of a project's labels
+// https://docs.gitlab.com/ce/api/labels.html#list-labels
+func (p *GitLabProvider) ReposListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	if sp.Repo.AllProjects {
+		return p.groupsListLabels(ctx, sp)
+	}
+
+	names := []string{}
+	opt := &gitlab.ListLabelsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		ls, gr, err := p.client.Labels.ListLabels(p.getProjectId(sp.Repo), opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, l := range ls {
+			names = append(names, l.Name)
+		}
+		if gr.NextPage == 0 {
+			return names, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// groupsListLabels returns the names of a group's labels
+// https://docs.gitlab.com/ce/api/group_labels.html#list-group-labels
+func (p *GitLabProvider) groupsListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	names := []string{}
+	opt := &gitlab.ListGroupLabelsOptions{PerPage: 100}
+	for {
+		ls, gr, err := p.client.GroupLabels.ListGroupLabels(p.getGroupId(sp.Repo), opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, l := range ls {
+			names = append(names, l.Name)
+		}
+		if gr.NextPage == 0 {
+			return names, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// ReposListMilestones returns the titles of a project's active milestones
+// https://docs.gitlab.com/ce/api/milestones.html#list-project-milestones
+func (p *GitLabProvider) ReposListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	if sp.Repo.AllProjects {
+		return p.groupsListMilestones(ctx, sp)
+	}
+
+	titles := []string{}
+	state := "active"
+	opt := &gitlab.ListMilestonesOptions{State: &state, ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		ms, gr, err := p.client.Milestones.ListMilestones(p.getProjectId(sp.Repo), opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, m := range ms {
+			titles = append(titles, m.Title)
+		}
+		if gr.NextPage == 0 {
+			return titles, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// groupsListMilestones returns the titles of a group's active milestones
+// https://docs.gitlab.com/ce/api/group_milestones.html#list-group-milestones
+func (p *GitLabProvider) groupsListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	titles := []string{}
+	opt := &gitlab.ListGroupMilestonesOptions{State: "active", ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		ms, gr, err := p.client.GroupMilestones.ListGroupMilestones(p.getGroupId(sp.Repo), opt)
+		if err != nil {
+			return nil, p.getResponse(gr), err
+		}
+		for _, m := range ms {
+			titles = append(titles, m.Title)
+		}
+		if gr.NextPage == 0 {
+			return titles, p.getResponse(gr), nil
+		}
+		opt.Page = gr.NextPage
+	}
+}
+
+// GroupsIsMember returns whether a user is a direct or inherited member of a group or subgroup
+// https://docs.gitlab.com/ce/api/members.html#list-all-members-of-a-group-or-project-including-inherited-members
+func (p *GitLabProvider) GroupsIsMember(ctx context.Context, group string, user string) (bool, *Response, error) {
+	ms, gr, err := p.client.Groups.ListAllGroupMembers(group, &gitlab.ListGroupMembersOptions{Query: &user})
+	if err != nil {
+		return false, p.getResponse(gr), err
+	}
+
+	for _, m := range ms {
+		if m.Username == user {
+			return true, p.getResponse(gr), nil
+		}
+	}
+	return false, p.getResponse(gr), nil
+}
+
+// AlertsList returns no alerts, as GitLab's vulnerability reports require an Ultimate license
+func (p *GitLabProvider) AlertsList(ctx context.Context, sp SearchParams, kind string) ([]*Alert, *Response, error) {
+	return []*Alert{}, &Response{}, nil
+}
+
+// userIDs resolves usernames to GitLab user IDs
+func (p *GitLabProvider) userIDs(logins []string) ([]int, *gitlab.Response, error) {
+	ids := []int{}
+	for _, l := range logins {
+		login := l
+		us, gr, err := p.client.Users.ListUsers(&gitlab.ListUsersOptions{Username: &login})
+		if err != nil {
+			return nil, gr, err
+		}
+		if len(us) == 0 {
+			return nil, gr, fmt.Errorf("unknown user: %q", l)
+		}
+		ids = append(ids, us[0].ID)
+	}
+	return ids, nil, nil
+}
+
+// milestoneID resolves a milestone title to a GitLab milestone ID
+func (p *GitLabProvider) milestoneID(sp SearchParams, title string) (int, *gitlab.Response, error) {
+	ms, gr, err := p.client.Milestones.ListMilestones(p.getProjectId(sp.Repo), &gitlab.ListMilestonesOptions{Title: &title})
+	if err != nil {
+		return 0, gr, err
+	}
+	if len(ms) == 0 {
+		return 0, gr, fmt.Errorf("unknown milestone: %q", title)
+	}
+	return ms[0].ID, gr, nil
+}
+
+// gitlabStateEvent returns the state event which transitions an item into a state
+func gitlabStateEvent(state string) *string {
+	ev := "reopen"
+	if state == constants.ClosedState {
+		ev = "close"
+	}
+	return &ev
+}
+
+// editLabels returns a set of labels with additions and removals applied
+func editLabels(current []string, add []string, remove []string) []string {
+	removed := map[string]bool{}
+	for _, l := range remove {
+		removed[l] = true
+	}
+
+	seen := map[string]bool{}
+	ls := []string{}
+	for _, l := range append(current, add...) {
+		if removed[l] || seen[l] {
+			continue
+		}
+		seen[l] = true
+		ls = append(ls, l)
+	}
+	return ls
+}
+
 // https://gitlab.com/gitlab-org/gitlab-foss/-/issues/28342#note_23852124
 func (p *GitLabProvider) getProjectId(repo Repo) string {
 	var u string
@@ -367,3 +965,11 @@ func (p *GitLabProvider) getProjectId(repo Repo) string {
 	}
 	return u
 }
+
+// getGroupId returns the path of a group, including any subgroups
+func (p *GitLabProvider) getGroupId(repo Repo) string {
+	if repo.Group != "" {
+		return repo.Organization + "/" + repo.Group
+	}
+	return repo.Organization
+}
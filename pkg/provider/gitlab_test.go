@@ -16,6 +16,10 @@ package provider
 
 import (
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xanzy/go-gitlab"
 )
 
 func TestGitLab_GetResponse(t *testing.T) {
@@ -50,5 +54,46 @@ func TestGitLab_GetPullRequestComments(t *testing.T) {
 
 func TestGitLab_GetPullRequestReviews(t *testing.T) {
 	p := GitLabProvider{}
-	p.getPullRequestReviews(nil)
+	p.getPullRequestReviews(nil, nil)
+}
+
+func TestGitLab_GetPullRequestReviewStates(t *testing.T) {
+	p := GitLabProvider{}
+	t1 := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	note := func(user string, body string, at time.Time) *gitlab.Note {
+		n := &gitlab.Note{System: true, Body: body, CreatedAt: &at}
+		n.Author.Username = user
+		return n
+	}
+
+	approvals := &gitlab.MergeRequestApprovals{ApprovedBy: []*gitlab.MergeRequestApproverUser{{User: &gitlab.BasicUser{Username: "alice"}}}}
+	notes := []*gitlab.Note{
+		note("bob", "requested changes", t1),
+		note("alice", "requested changes", t1),
+		note("alice", "approved this merge request", t2),
+		{Body: "approved this merge request"},
+	}
+
+	rs := p.getPullRequestReviews(approvals, notes)
+	assert.Equal(t, 2, len(rs))
+	assert.Equal(t, "bob", rs[0].GetUser().GetLogin())
+	assert.Equal(t, "CHANGES_REQUESTED", rs[0].GetState())
+	assert.Equal(t, "alice", rs[1].GetUser().GetLogin())
+	assert.Equal(t, "APPROVED", rs[1].GetState())
+	assert.Equal(t, t2, rs[1].GetSubmittedAt())
+}
+
+func TestGitLab_GetIssueComment(t *testing.T) {
+	p := GitLabProvider{}
+	p.getIssueComment(nil)
+}
+
+func TestEditLabels(t *testing.T) {
+	got := editLabels([]string{"a", "b"}, []string{"c", "a"}, []string{"b"})
+	assert.Equal(t, []string{"a", "c"}, got)
+
+	got = editLabels([]string{"a"}, nil, []string{"a"})
+	assert.Equal(t, []string{}, got)
 }
@@ -0,0 +1,583 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+)
+
+// errNoLaunchpadPullRequests is returned for pull request changes, as only Launchpad bugs are supported
+var errNoLaunchpadPullRequests = errors.New("Launchpad merge proposals are not supported")
+
+// launchpadAPIURL is the root of the Launchpad web service
+var launchpadAPIURL = "https://api.launchpad.net/devel"
+
+// launchpadOpenStatuses are the statuses of bug tasks which are not complete
+var launchpadOpenStatuses = []string{"New", "Incomplete", "Confirmed", "Triaged", "In Progress", "Fix Committed"}
+
+// launchpadClosedStatuses are the statuses of bug tasks which are complete
+var launchpadClosedStatuses = []string{"Invalid", "Won't Fix", "Expired", "Opinion", "Fix Released"}
+
+// LaunchpadProvider reads bugs from the Launchpad web service. Repositories are projects, such as
+// https://bugs.launchpad.net/my-project, or source packages, such as https://bugs.launchpad.net/ubuntu/+source/systemd
+type LaunchpadProvider struct {
+	client *http.Client
+	url    string
+
+	// Credentials sign requests with OAuth 1.0, and are only needed to make changes
+	consumer string
+	token    string
+	secret   string
+}
+
+// NewLaunchpad returns a Launchpad provider. The token is in the form of consumer-key:token:token-secret, or empty to read anonymously.
+func NewLaunchpad(token string) (Provider, error) {
+	p := &LaunchpadProvider{client: http.DefaultClient, url: launchpadAPIURL}
+	if token == "" {
+		return p, nil
+	}
+
+	parts := strings.Split(token, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token: expected consumer-key:token:token-secret, got %d parts", len(parts))
+	}
+	p.consumer, p.token, p.secret = parts[0], parts[1], parts[2]
+	return p, nil
+}
+
+// LaunchpadRepo returns the repository a Launchpad bugs URL refers to, such as https://bugs.launchpad.net/my-project/+bug/12
+func LaunchpadRepo(u *url.URL) (Repo, bool) {
+	if u.Host != constants.LaunchpadProviderHost {
+		return Repo{}, false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[1] == "+source":
+		return Repo{Host: u.Host, Organization: parts[0], Group: parts[1], Project: parts[2]}, true
+	case parts[0] != "":
+		return Repo{Host: u.Host, Organization: parts[0], Project: parts[0]}, true
+	}
+	return Repo{}, false
+}
+
+type lpBugTask struct {
+	SelfLink     string     `json:"self_link"`
+	WebLink      string     `json:"web_link"`
+	BugLink      string     `json:"bug_link"`
+	Status       string     `json:"status"`
+	Importance   string     `json:"importance"`
+	AssigneeLink string     `json:"assignee_link"`
+	OwnerLink    string     `json:"owner_link"`
+	IsComplete   bool       `json:"is_complete"`
+	DateCreated  *time.Time `json:"date_created"`
+	DateClosed   *time.Time `json:"date_closed"`
+}
+
+type lpBug struct {
+	ID              int        `json:"id"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	Tags            []string   `json:"tags"`
+	MessageCount    int        `json:"message_count"`
+	OwnerLink       string     `json:"owner_link"`
+	DateCreated     *time.Time `json:"date_created"`
+	DateLastUpdated *time.Time `json:"date_last_updated"`
+}
+
+type lpMessage struct {
+	SelfLink    string     `json:"self_link"`
+	Content     string     `json:"content"`
+	OwnerLink   string     `json:"owner_link"`
+	DateCreated *time.Time `json:"date_created"`
+}
+
+type lpActivity struct {
+	DateChanged *time.Time `json:"datechanged"`
+	PersonLink  string     `json:"person_link"`
+	WhatChanged string     `json:"whatchanged"`
+	OldValue    string     `json:"oldvalue"`
+	NewValue    string     `json:"newvalue"`
+}
+
+// lpCollection is a page of entries
+type lpCollection struct {
+	Entries            []json.RawMessage `json:"entries"`
+	NextCollectionLink string            `json:"next_collection_link"`
+}
+
+// launchpadLabels returns the labels of a bug: its tags, and its status and importance, such as "status/triaged"
+func launchpadLabels(t *lpBugTask, b *lpBug) []*Label {
+	names := append([]string{}, b.Tags...)
+	for _, l := range []string{"status/" + t.Status, "importance/" + t.Importance} {
+		names = append(names, strings.ToLower(strings.ReplaceAll(l, " ", "-")))
+	}
+
+	ls := []*Label{}
+	for _, n := range names {
+		n := n
+		ls = append(ls, &Label{Name: &n})
+	}
+	return ls
+}
+
+// launchpadTimelineEvent maps a bug activity to a GitHub timeline event
+func launchpadTimelineEvent(a *lpActivity) string {
+	switch {
+	case a.WhatChanged == "tags":
+		if len(strings.Fields(a.NewValue)) >= len(strings.Fields(a.OldValue)) {
+			return "labeled"
+		}
+		return "unlabeled"
+	case strings.HasSuffix(a.WhatChanged, "assignee"):
+		if a.NewValue == "" {
+			return "unassigned"
+		}
+		return "assigned"
+	case strings.HasSuffix(a.WhatChanged, "status"):
+		was, is := launchpadComplete(a.OldValue), launchpadComplete(a.NewValue)
+		switch {
+		case is && !was:
+			return "closed"
+		case was && !is:
+			return "reopened"
+		}
+	}
+	return ""
+}
+
+func launchpadComplete(status string) bool {
+	for _, s := range launchpadClosedStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// getUser returns the person a link such as https://api.launchpad.net/devel/~alice refers to
+func (p *LaunchpadProvider) getUser(link string) *User {
+	if link == "" {
+		return nil
+	}
+	login := link[strings.LastIndex(link, "~")+1:]
+	html := "https://launchpad.net/~" + login
+	return &User{Login: &login, HTMLURL: &html}
+}
+
+func (p *LaunchpadProvider) getIssue(t *lpBugTask, b *lpBug) *Issue {
+	id := int64(b.ID)
+	state := constants.OpenState
+	if t.IsComplete {
+		state = constants.ClosedState
+	}
+	// The first message of a bug is its description
+	comments := b.MessageCount - 1
+	if comments < 0 {
+		comments = 0
+	}
+
+	i := &Issue{
+		ID:        &id,
+		Number:    &b.ID,
+		State:     &state,
+		Title:     &b.Title,
+		Body:      &b.Description,
+		User:      p.getUser(b.OwnerLink),
+		Labels:    launchpadLabels(t, b),
+		Comments:  &comments,
+		CreatedAt: b.DateCreated,
+		UpdatedAt: b.DateLastUpdated,
+		ClosedAt:  t.DateClosed,
+		URL:       &t.WebLink,
+		HTMLURL:   &t.WebLink,
+	}
+	if a := p.getUser(t.AssigneeLink); a != nil {
+		i.Assignee = a
+		i.Assignees = []*User{a}
+	}
+	return i
+}
+
+// target returns the API path of a project or source package
+func (p *LaunchpadProvider) target(r Repo) string {
+	if r.Group == "+source" {
+		return "/" + r.Organization + "/+source/" + r.Project
+	}
+	return "/" + r.Organization
+}
+
+// authorize signs a request with OAuth 1.0, using the plaintext method Launchpad supports
+// https://help.launchpad.net/API/SigningRequests
+func (p *LaunchpadProvider) authorize(req *http.Request) {
+	if p.token == "" {
+		return
+	}
+	req.Header.Set("Authorization", fmt.Sprintf(
+		`OAuth realm="https://api.launchpad.net/", oauth_consumer_key=%q, oauth_token=%q, oauth_signature_method="PLAINTEXT", oauth_signature=%q, oauth_timestamp="%d", oauth_nonce="%d", oauth_version="1.0"`,
+		url.QueryEscape(p.consumer), url.QueryEscape(p.token), "&"+url.QueryEscape(p.secret), time.Now().Unix(), rand.Int63()))
+}
+
+// do calls the web service at a path or absolute link, decoding the response into out
+func (p *LaunchpadProvider) do(ctx context.Context, method string, link string, body io.Reader, contentType string, out interface{}) error {
+	if !strings.HasPrefix(link, "https://") && !strings.HasPrefix(link, "http://") {
+		link = p.url + link
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, link, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	p.authorize(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("%s %s: %d: %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(b)))}
+	}
+	if out == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (p *LaunchpadProvider) get(ctx context.Context, link string, out interface{}) error {
+	return p.do(ctx, http.MethodGet, link, nil, "", out)
+}
+
+// patch changes the fields of an entry
+func (p *LaunchpadProvider) patch(ctx context.Context, link string, fields map[string]interface{}) error {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return p.do(ctx, http.MethodPatch, link, bytes.NewReader(b), "application/json", nil)
+}
+
+// post calls a named operation
+func (p *LaunchpadProvider) post(ctx context.Context, link string, form url.Values) error {
+	return p.do(ctx, http.MethodPost, link, strings.NewReader(form.Encode()), "application/x-www-form-urlencoded", nil)
+}
+
+// each calls fn with every entry of a collection
+func (p *LaunchpadProvider) each(ctx context.Context, link string, fn func(json.RawMessage) (bool, error)) error {
+	for link != "" {
+		var c lpCollection
+		if err := p.get(ctx, link, &c); err != nil {
+			return err
+		}
+		for _, e := range c.Entries {
+			more, err := fn(e)
+			if err != nil || !more {
+				return err
+			}
+		}
+		link = c.NextCollectionLink
+	}
+	return nil
+}
+
+func (p *LaunchpadProvider) bugPath(sp SearchParams) string {
+	return fmt.Sprintf("/bugs/%d", sp.IssueNumber)
+}
+
+// task returns the task of a bug for the repository it was found in
+func (p *LaunchpadProvider) task(sp SearchParams) string {
+	return fmt.Sprintf("%s/+bug/%d", p.target(sp.Repo), sp.IssueNumber)
+}
+
+// IssuesListByRepo returns the bugs of a project, most recently updated first.
+// Bug tasks lack the description and tags of their bug, so listing costs a request per bug.
+// https://launchpad.net/+apidoc/devel.html#bug_target-searchTasks
+func (p *LaunchpadProvider) IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	opt := sp.IssueListByRepoOptions
+	q := url.Values{}
+	q.Set("ws.op", "searchTasks")
+	q.Set("order_by", "-date_last_updated")
+	if !opt.Since.IsZero() {
+		q.Set("modified_since", opt.Since.UTC().Format(time.RFC3339))
+	}
+
+	var statuses []string
+	switch opt.State {
+	case constants.ClosedState:
+		statuses = launchpadClosedStatuses
+	case "all":
+		statuses = append(append(statuses, launchpadOpenStatuses...), launchpadClosedStatuses...)
+	default:
+		statuses = launchpadOpenStatuses
+	}
+	for _, s := range statuses {
+		q.Add("status", s)
+	}
+
+	is := []*Issue{}
+	err := p.each(ctx, p.target(sp.Repo)+"?"+q.Encode(), func(e json.RawMessage) (bool, error) {
+		t := &lpBugTask{}
+		if err := json.Unmarshal(e, t); err != nil {
+			return false, err
+		}
+		b := &lpBug{}
+		if err := p.get(ctx, t.BugLink, b); err != nil {
+			return false, err
+		}
+		is = append(is, p.getIssue(t, b))
+		return true, nil
+	})
+	return is, &Response{}, err
+}
+
+// IssuesListComments returns the messages of a bug, other than the first, which is its description
+func (p *LaunchpadProvider) IssuesListComments(ctx context.Context, sp SearchParams) ([]*IssueComment, *Response, error) {
+	cs := []*IssueComment{}
+	first := true
+	err := p.each(ctx, p.bugPath(sp)+"/messages", func(e json.RawMessage) (bool, error) {
+		if first {
+			first = false
+			return true, nil
+		}
+		m := &lpMessage{}
+		if err := json.Unmarshal(e, m); err != nil {
+			return false, err
+		}
+		id, _ := strconv.ParseInt(m.SelfLink[strings.LastIndex(m.SelfLink, "/")+1:], 10, 64)
+		cs = append(cs, &IssueComment{
+			ID:        &id,
+			Body:      &m.Content,
+			User:      p.getUser(m.OwnerLink),
+			CreatedAt: m.DateCreated,
+			UpdatedAt: m.DateCreated,
+		})
+		return true, nil
+	})
+	return cs, &Response{}, err
+}
+
+// IssuesListIssueTimeline returns the tag, assignment and status changes of a bug
+func (p *LaunchpadProvider) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) ([]*Timeline, *Response, error) {
+	ts := []*Timeline{}
+	err := p.each(ctx, p.bugPath(sp)+"/activity", func(e json.RawMessage) (bool, error) {
+		a := &lpActivity{}
+		if err := json.Unmarshal(e, a); err != nil {
+			return false, err
+		}
+		ev := launchpadTimelineEvent(a)
+		if ev != "" {
+			ts = append(ts, &Timeline{Event: &ev, Actor: p.getUser(a.PersonLink), CreatedAt: a.DateChanged})
+		}
+		return true, nil
+	})
+	return ts, &Response{}, err
+}
+
+// IssuesListSubIssues returns no sub-issues, as bugs can not be nested
+func (p *LaunchpadProvider) IssuesListSubIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// EpicsList returns no epics, as Launchpad has none
+func (p *LaunchpadProvider) EpicsList(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// EpicsListComments returns no comments, as Launchpad has no epics
+func (p *LaunchpadProvider) EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) ([]*IssueComment, *Response, error) {
+	return []*IssueComment{}, &Response{}, nil
+}
+
+// EpicsListIssues returns no issues, as Launchpad has no epics
+func (p *LaunchpadProvider) EpicsListIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// PullRequestsList returns no pull requests, as only bugs are supported
+func (p *LaunchpadProvider) PullRequestsList(ctx context.Context, sp SearchParams) ([]*PullRequest, *Response, error) {
+	return []*PullRequest{}, &Response{}, nil
+}
+
+func (p *LaunchpadProvider) PullRequestsGet(ctx context.Context, sp SearchParams) (*PullRequest, *Response, error) {
+	return nil, &Response{}, errNoLaunchpadPullRequests
+}
+
+func (p *LaunchpadProvider) PullRequestsListComments(ctx context.Context, sp SearchParams) ([]*PullRequestComment, *Response, error) {
+	return []*PullRequestComment{}, &Response{}, nil
+}
+
+func (p *LaunchpadProvider) PullRequestsListReviews(ctx context.Context, sp SearchParams) ([]*PullRequestReview, *Response, error) {
+	return []*PullRequestReview{}, &Response{}, nil
+}
+
+// IssuesCreateComment adds a message to a bug
+// https://launchpad.net/+apidoc/devel.html#bug-newMessage
+func (p *LaunchpadProvider) IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	form := url.Values{"ws.op": {"newMessage"}, "content": {body}}
+	if err := p.post(ctx, p.bugPath(sp), form); err != nil {
+		return nil, &Response{}, err
+	}
+	now := time.Now()
+	return &IssueComment{Body: &body, CreatedAt: &now, UpdatedAt: &now}, &Response{}, nil
+}
+
+func (p *LaunchpadProvider) PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return nil, &Response{}, errNoLaunchpadPullRequests
+}
+
+// IssuesEditComment returns an error, as bug messages can not be edited
+func (p *LaunchpadProvider) IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return nil, &Response{}, errors.New("Launchpad bug messages can not be edited")
+}
+
+func (p *LaunchpadProvider) PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return nil, &Response{}, errNoLaunchpadPullRequests
+}
+
+// IssuesEdit tags, assigns, closes or reopens a bug. Closed bugs are marked as Won't Fix, and reopened ones as New.
+func (p *LaunchpadProvider) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	if e.Locked != nil {
+		return &Response{}, errors.New("Launchpad bugs can not be locked")
+	}
+
+	if len(e.AddLabels) > 0 || len(e.RemoveLabels) > 0 {
+		b := &lpBug{}
+		if err := p.get(ctx, p.bugPath(sp), b); err != nil {
+			return &Response{}, err
+		}
+		tags := editLabels(b.Tags, e.AddLabels, e.RemoveLabels)
+		if err := p.patch(ctx, p.bugPath(sp), map[string]interface{}{"tags": tags}); err != nil {
+			return &Response{}, err
+		}
+	}
+
+	fields := map[string]interface{}{}
+	if len(e.AddAssignees) > 0 {
+		// Each bug task has a single assignee
+		fields["assignee_link"] = p.url + "/~" + e.AddAssignees[0]
+	}
+	if e.Milestone != "" {
+		m := struct {
+			SelfLink string `json:"self_link"`
+		}{}
+		q := url.Values{"ws.op": {"getMilestone"}, "name": {e.Milestone}}
+		if err := p.get(ctx, "/"+sp.Repo.Organization+"?"+q.Encode(), &m); err != nil {
+			return &Response{}, err
+		}
+		if m.SelfLink == "" {
+			return &Response{}, fmt.Errorf("unknown milestone: %q", e.Milestone)
+		}
+		fields["milestone_link"] = m.SelfLink
+	}
+	switch e.State {
+	case constants.ClosedState:
+		fields["status"] = "Won't Fix"
+	case constants.OpenState:
+		fields["status"] = "New"
+	}
+
+	if len(fields) == 0 {
+		return &Response{}, nil
+	}
+	return &Response{}, p.patch(ctx, p.task(sp), fields)
+}
+
+func (p *LaunchpadProvider) PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return &Response{}, errNoLaunchpadPullRequests
+}
+
+func (p *LaunchpadProvider) PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error) {
+	return &Response{}, errNoLaunchpadPullRequests
+}
+
+func (p *LaunchpadProvider) PullRequestsListFiles(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	return nil, &Response{}, errNoLaunchpadPullRequests
+}
+
+func (p *LaunchpadProvider) PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (int, *Response, error) {
+	return 0, &Response{}, errNoLaunchpadPullRequests
+}
+
+func (p *LaunchpadProvider) PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (int, *Response, error) {
+	return 0, &Response{}, errNoLaunchpadPullRequests
+}
+
+// ReposGetFile returns an error, as bug trackers are not backed by a repository
+func (p *LaunchpadProvider) ReposGetFile(ctx context.Context, sp SearchParams, path string) ([]byte, *Response, error) {
+	return nil, &Response{}, fmt.Errorf("%s: Launchpad projects have no files", path)
+}
+
+// ReposListLabels returns the official bug tags of a project or distribution
+func (p *LaunchpadProvider) ReposListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	var t struct {
+		OfficialBugTags []string `json:"official_bug_tags"`
+	}
+	if err := p.get(ctx, "/"+sp.Repo.Organization, &t); err != nil {
+		return nil, &Response{}, err
+	}
+	return t.OfficialBugTags, &Response{}, nil
+}
+
+// ReposListMilestones returns the names of a project's active milestones
+func (p *LaunchpadProvider) ReposListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	names := []string{}
+	err := p.each(ctx, "/"+sp.Repo.Organization+"/active_milestones", func(e json.RawMessage) (bool, error) {
+		var m struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(e, &m); err != nil {
+			return false, err
+		}
+		names = append(names, m.Name)
+		return true, nil
+	})
+	return names, &Response{}, err
+}
+
+// GroupsIsMember returns whether a person is a direct or indirect member of a team
+// https://launchpad.net/+apidoc/devel.html#person-inTeam
+func (p *LaunchpadProvider) GroupsIsMember(ctx context.Context, group string, user string) (bool, *Response, error) {
+	q := url.Values{"ws.op": {"inTeam"}, "team": {p.url + "/~" + strings.TrimPrefix(group, "~")}}
+	var member bool
+	err := p.get(ctx, "/~"+user+"?"+q.Encode(), &member)
+	return member, &Response{}, err
+}
+
+// AlertsList returns no alerts, as Launchpad has no security alerts
+func (p *LaunchpadProvider) AlertsList(ctx context.Context, sp SearchParams, kind string) ([]*Alert, *Response, error) {
+	return []*Alert{}, &Response{}, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLaunchpadRepo(t *testing.T) {
+	u, _ := url.Parse("https://bugs.launchpad.net/ubuntu/+source/systemd/+bug/12")
+	r, ok := LaunchpadRepo(u)
+	assert.True(t, ok)
+	assert.Equal(t, Repo{Host: "bugs.launchpad.net", Organization: "ubuntu", Group: "+source", Project: "systemd"}, r)
+
+	u, _ = url.Parse("https://bugs.launchpad.net/my-project")
+	r, ok = LaunchpadRepo(u)
+	assert.True(t, ok)
+	assert.Equal(t, Repo{Host: "bugs.launchpad.net", Organization: "my-project", Project: "my-project"}, r)
+
+	u, _ = url.Parse("https://github.com/org/repo")
+	_, ok = LaunchpadRepo(u)
+	assert.False(t, ok)
+}
+
+func TestLaunchpadTimelineEvent(t *testing.T) {
+	assert.Equal(t, "closed", launchpadTimelineEvent(&lpActivity{WhatChanged: "my-project: status", OldValue: "New", NewValue: "Fix Released"}))
+	assert.Equal(t, "reopened", launchpadTimelineEvent(&lpActivity{WhatChanged: "my-project: status", OldValue: "Invalid", NewValue: "Confirmed"}))
+	assert.Equal(t, "", launchpadTimelineEvent(&lpActivity{WhatChanged: "my-project: status", OldValue: "New", NewValue: "Triaged"}))
+	assert.Equal(t, "unlabeled", launchpadTimelineEvent(&lpActivity{WhatChanged: "tags", OldValue: "a b", NewValue: "a"}))
+	assert.Equal(t, "assigned", launchpadTimelineEvent(&lpActivity{WhatChanged: "my-project: assignee", NewValue: "Alice (alice)"}))
+}
+
+func TestLaunchpad_IssuesListByRepo(t *testing.T) {
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/my-project":
+			assert.Equal(t, "searchTasks", r.URL.Query().Get("ws.op"))
+			assert.Contains(t, r.URL.Query()["status"], "Triaged")
+			fmt.Fprintf(w, `{"entries": [{"web_link": "https://bugs.launchpad.net/my-project/+bug/7", "bug_link": "%s/bugs/7",
+				"status": "Triaged", "importance": "High", "assignee_link": "%s/~bob", "is_complete": false}]}`, s.URL, s.URL)
+		case "/bugs/7":
+			fmt.Fprintf(w, `{"id": 7, "title": "crash", "description": "it crashes", "tags": ["regression"], "message_count": 3,
+				"owner_link": "%s/~alice", "date_created": "2020-06-01T10:00:00.123456+00:00", "date_last_updated": "2020-06-02T10:00:00+00:00"}`, s.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	p := &LaunchpadProvider{client: s.Client(), url: s.URL}
+	sp := SearchParams{Repo: Repo{Organization: "my-project", Project: "my-project"}}
+	is, _, err := p.IssuesListByRepo(context.Background(), sp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(is))
+
+	i := is[0]
+	assert.Equal(t, 7, i.GetNumber())
+	assert.Equal(t, "open", i.GetState())
+	assert.Equal(t, "alice", i.GetUser().GetLogin())
+	assert.Equal(t, "bob", i.GetAssignee().GetLogin())
+	assert.Equal(t, 2, i.GetComments())
+
+	labels := []string{}
+	for _, l := range i.Labels {
+		labels = append(labels, l.GetName())
+	}
+	assert.Equal(t, []string{"regression", "status/triaged", "importance/high"}, labels)
+}
@@ -55,6 +55,8 @@ type Repo struct {
 	Project      string
 	Host         string
 	Group        string
+	// AllProjects is set for GitLab groups, whose items are listed across every project beneath them
+	AllProjects bool
 }
 
 type SearchParams struct {
@@ -76,6 +78,22 @@ type SearchParams struct {
 	PullRequestListOptions   PullRequestListOptions
 }
 
+// IssueEdit describes changes to make to an issue or pull request. Empty fields are left unchanged.
+type IssueEdit struct {
+	AddLabels    []string
+	RemoveLabels []string
+	AddAssignees []string
+
+	// State is either "open" or "closed"
+	State string
+	// Milestone is the title of the milestone to move the item into
+	Milestone string
+	// Locked locks or unlocks the conversation
+	Locked *bool
+	// LockReason is why a conversation is being locked: "off-topic", "too heated", "resolved" or "spam"
+	LockReason string
+}
+
 // IssueListByRepoOptions specifies the optional parameters to the
 // IssuesService.ListByRepo method.
 type IssueListByRepoOptions struct {
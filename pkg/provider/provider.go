@@ -16,10 +16,11 @@ package provider
 
 import (
 	"context"
-	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/google/triage-party/pkg/secret"
 	"k8s.io/klog/v2"
 )
 
@@ -27,10 +28,30 @@ type Provider interface {
 	IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error)
 	IssuesListComments(ctx context.Context, sp SearchParams) ([]*IssueComment, *Response, error)
 	IssuesListIssueTimeline(ctx context.Context, sp SearchParams) ([]*Timeline, *Response, error)
+	IssuesListSubIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error)
+	EpicsList(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error)
+	EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) ([]*IssueComment, *Response, error)
+	EpicsListIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error)
 	PullRequestsList(ctx context.Context, sp SearchParams) ([]*PullRequest, *Response, error)
 	PullRequestsGet(ctx context.Context, sp SearchParams) (*PullRequest, *Response, error)
 	PullRequestsListComments(ctx context.Context, sp SearchParams) ([]*PullRequestComment, *Response, error)
 	PullRequestsListReviews(ctx context.Context, sp SearchParams) ([]*PullRequestReview, *Response, error)
+
+	IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error)
+	PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error)
+	IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error)
+	PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error)
+	IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error)
+	PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error)
+	PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error)
+	PullRequestsListFiles(ctx context.Context, sp SearchParams) ([]string, *Response, error)
+	PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (int, *Response, error)
+	PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (int, *Response, error)
+	ReposGetFile(ctx context.Context, sp SearchParams, path string) ([]byte, *Response, error)
+	ReposListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error)
+	ReposListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error)
+	GroupsIsMember(ctx context.Context, group string, user string) (bool, *Response, error)
+	AlertsList(ctx context.Context, sp SearchParams, kind string) ([]*Alert, *Response, error)
 }
 
 type Config struct {
@@ -40,14 +61,14 @@ type Config struct {
 	GitLabTokenPath string
 }
 
+// ReadToken reads a token from a file or secret manager reference, falling back to an environment variable
 func ReadToken(path string, envVar string) string {
 	if path != "" {
-		t, err := ioutil.ReadFile(path)
+		token, err := secret.Read(context.Background(), path)
 		if err != nil {
-			klog.Exitf("unable to read token file: %v", err)
+			klog.Exitf("unable to read token: %v", err)
 		}
-		token := strings.TrimSpace(string(t))
-		klog.Infof("loaded %d byte %s token from %s", len(token), path)
+		klog.Infof("loaded %d byte token from %s", len(token), path)
 		return token
 	}
 
@@ -55,7 +76,21 @@ func ReadToken(path string, envVar string) string {
 	if token == "" {
 		klog.Warningf("No token found in environment variable %s (empty)", envVar)
 	} else {
-		klog.Infof("loaded %d byte %s token from %s", len(token), envVar)
+		klog.Infof("loaded %d byte token from %s", len(token), envVar)
 	}
 	return token
 }
+
+// WatchToken is like ReadToken, but re-reads tokens from files and secret managers every interval to pick up rotations
+func WatchToken(ctx context.Context, path string, envVar string, interval time.Duration) *secret.Secret {
+	if path == "" {
+		return secret.Static(ReadToken(path, envVar))
+	}
+
+	s, err := secret.Watch(ctx, path, interval)
+	if err != nil {
+		klog.Exitf("unable to read token: %v", err)
+	}
+	klog.Infof("loaded %d byte token from %s, re-reading every %s", len(s.Value()), path, interval)
+	return s
+}
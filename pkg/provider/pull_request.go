@@ -68,8 +68,8 @@ type PullRequest struct {
 	//RequestedTeams []*Team `json:"requested_teams,omitempty"`
 	//
 	//Links *PRLinks           `json:"_links,omitempty"`
-	//Head  *PullRequestBranch `json:"head,omitempty"`
-	//Base  *PullRequestBranch `json:"base,omitempty"`
+	Head *PullRequestBranch `json:"head,omitempty"`
+	Base *PullRequestBranch `json:"base,omitempty"`
 
 	// ActiveLockReason is populated only when LockReason is provided while locking the pull request.
 	// Possible values are: "off-topic", "too heated", "resolved", and "spam".
@@ -93,12 +93,12 @@ func (p *PullRequest) GetAuthorAssociation() string {
 }
 
 // GetBase returns the Base field.
-//func (p *PullRequest) GetBase() *PullRequestBranch {
-//	if p == nil {
-//		return nil
-//	}
-//	return p.Base
-//}
+func (p *PullRequest) GetBase() *PullRequestBranch {
+	if p == nil {
+		return nil
+	}
+	return p.Base
+}
 
 // GetBody returns the Body field if it's non-nil, zero value otherwise.
 func (p *PullRequest) GetBody() string {
@@ -141,12 +141,12 @@ func (p *PullRequest) GetDraft() bool {
 }
 
 // GetHead returns the Head field.
-//func (p *PullRequest) GetHead() *PullRequestBranch {
-//	if p == nil {
-//		return nil
-//	}
-//	return p.Head
-//}
+func (p *PullRequest) GetHead() *PullRequestBranch {
+	if p == nil {
+		return nil
+	}
+	return p.Head
+}
 
 // GetHTMLURL returns the HTMLURL field if it's non-nil, zero value otherwise.
 func (p *PullRequest) GetHTMLURL() string {
@@ -172,6 +172,30 @@ func (p *PullRequest) GetMerged() bool {
 	return *p.Merged
 }
 
+// GetMergeable returns the Mergeable field if it's non-nil, zero value otherwise.
+func (p *PullRequest) GetMergeable() bool {
+	if p == nil || p.Mergeable == nil {
+		return false
+	}
+	return *p.Mergeable
+}
+
+// GetMergeableState returns the MergeableState field if it's non-nil, zero value otherwise.
+func (p *PullRequest) GetMergeableState() string {
+	if p == nil || p.MergeableState == nil {
+		return ""
+	}
+	return *p.MergeableState
+}
+
+// GetMergedAt returns the MergedAt field if it's non-nil, zero value otherwise.
+func (p *PullRequest) GetMergedAt() time.Time {
+	if p == nil || p.MergedAt == nil {
+		return time.Time{}
+	}
+	return *p.MergedAt
+}
+
 // GetMergedBy returns the MergedBy field.
 func (p *PullRequest) GetMergedBy() *User {
 	if p == nil {
@@ -239,3 +263,34 @@ func (p *PullRequest) GetUser() *User {
 func (p PullRequest) String() string {
 	return Stringify(p)
 }
+
+// PullRequestBranch represents a base or head branch in a GitHub pull request.
+type PullRequestBranch struct {
+	Label *string `json:"label,omitempty"`
+	Ref   *string `json:"ref,omitempty"`
+	SHA   *string `json:"sha,omitempty"`
+}
+
+// GetLabel returns the Label field if it's non-nil, zero value otherwise.
+func (p *PullRequestBranch) GetLabel() string {
+	if p == nil || p.Label == nil {
+		return ""
+	}
+	return *p.Label
+}
+
+// GetRef returns the Ref field if it's non-nil, zero value otherwise.
+func (p *PullRequestBranch) GetRef() string {
+	if p == nil || p.Ref == nil {
+		return ""
+	}
+	return *p.Ref
+}
+
+// GetSHA returns the SHA field if it's non-nil, zero value otherwise.
+func (p *PullRequestBranch) GetSHA() string {
+	if p == nil || p.SHA == nil {
+		return ""
+	}
+	return *p.SHA
+}
@@ -47,6 +47,14 @@ func (p *PullRequestReview) GetState() string {
 	return *p.State
 }
 
+// GetUser returns the User field.
+func (p *PullRequestReview) GetUser() *User {
+	if p == nil {
+		return nil
+	}
+	return p.User
+}
+
 // GetSubmittedAt returns the SubmittedAt field if it's non-nil, zero value otherwise.
 func (p *PullRequestReview) GetSubmittedAt() time.Time {
 	if p == nil || p.SubmittedAt == nil {
@@ -0,0 +1,386 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/xanzy/go-gitlab"
+	"k8s.io/klog/v2"
+)
+
+// retryReserve is how many retries may be made before any requests have earned them, and maxRetryTokens how many may be saved up
+const (
+	retryReserve   = 10
+	maxRetryTokens = 100
+)
+
+// RetryPolicy is how failed read requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is how many times a request is made in all, so 1 disables retries
+	MaxAttempts int
+	// InitialBackoff is how long to wait before the first retry, doubling for each one after it up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter is the fraction of each backoff which is randomized, from 0 to 1, so that retries are spread out
+	Jitter float64
+	// Budget is the fraction of requests which may be retries, so that an outage is not made worse
+	Budget float64
+}
+
+// DefaultRetryPolicy retries transient errors twice, within a budget of 10% more requests
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+	Jitter:         0.5,
+	Budget:         0.1,
+}
+
+// backoff returns how long to wait before a retry, numbered from 1
+func (rp RetryPolicy) backoff(retry int, random float64) time.Duration {
+	d := rp.InitialBackoff
+	for i := 1; i < retry && d < rp.MaxBackoff; i++ {
+		d *= 2
+	}
+	if rp.MaxBackoff > 0 && d > rp.MaxBackoff {
+		d = rp.MaxBackoff
+	}
+	return d - time.Duration(rp.Jitter*random*float64(d))
+}
+
+// StatusError is a failed API call, carrying its HTTP status code
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// retryable returns whether an error is transient, such as a network error or a server error
+func retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Rate limits are waited out elsewhere: retrying only makes them last longer
+	var sle *SecondaryRateLimitError
+	var rle *github.RateLimitError
+	var ale *github.AbuseRateLimitError
+	if errors.As(err, &sle) || errors.As(err, &rle) || errors.As(err, &ale) {
+		return false
+	}
+
+	var ghe *github.ErrorResponse
+	if errors.As(err, &ghe) {
+		return ghe.Response != nil && ghe.Response.StatusCode >= 500
+	}
+	var gle *gitlab.ErrorResponse
+	if errors.As(err, &gle) {
+		return gle.Response != nil && gle.Response.StatusCode >= 500
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode >= 500
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Retrier is a provider which retries the transient errors of read requests made to another. Writes are never retried.
+type Retrier struct {
+	p Provider
+
+	mu     sync.Mutex
+	policy RetryPolicy
+	// tokens is how many retries may be made now: each request earns a fraction of one, and each retry spends one
+	tokens float64
+}
+
+// NewRetrier returns a provider which retries the read requests of another
+func NewRetrier(p Provider, policy RetryPolicy) *Retrier {
+	return &Retrier{p: p, policy: policy, tokens: retryReserve}
+}
+
+// SetPolicy changes how requests are retried, such as after the configuration is reloaded
+func (r *Retrier) SetPolicy(policy RetryPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+}
+
+// Policy returns how requests are retried
+func (r *Retrier) Policy() RetryPolicy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.policy
+}
+
+// earn adds the retries a request earns to the budget
+func (r *Retrier) earn() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens += r.policy.Budget
+	if r.tokens > maxRetryTokens {
+		r.tokens = maxRetryTokens
+	}
+}
+
+// spend takes a retry from the budget, returning false if none are left
+func (r *Retrier) spend() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// do makes a request, retrying transient errors according to the policy
+func (r *Retrier) do(ctx context.Context, method string, f func() error) error {
+	pol := r.Policy()
+	r.earn()
+
+	err := f()
+	for attempt := 2; attempt <= pol.MaxAttempts && retryable(err); attempt++ {
+		if !r.spend() {
+			klog.Warningf("%s failed, but the retry budget is exhausted: %v", method, err)
+			return err
+		}
+
+		wait := pol.backoff(attempt-1, rand.Float64())
+		klog.Warningf("%s failed (attempt %d of %d), retrying in %s: %v", method, attempt-1, pol.MaxAttempts, wait, err)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		err = f()
+	}
+	return err
+}
+
+// PausedUntil returns when the wrapped provider may be used again, if it pauses requests
+func (r *Retrier) PausedUntil() time.Time {
+	if p, ok := r.p.(Pauser); ok {
+		return p.PausedUntil()
+	}
+	return time.Time{}
+}
+
+// TokenInfo describes the token of the wrapped provider, if it can
+func (r *Retrier) TokenInfo(ctx context.Context) (*TokenInfo, error) {
+	if ti, ok := r.p.(TokenInspector); ok {
+		return ti.TokenInfo(ctx)
+	}
+	return nil, fmt.Errorf("the provider can not describe its token")
+}
+
+func (r *Retrier) IssuesListByRepo(ctx context.Context, sp SearchParams) (is []*Issue, resp *Response, err error) {
+	err = r.do(ctx, "IssuesListByRepo", func() error {
+		is, resp, err = r.p.IssuesListByRepo(ctx, sp)
+		return err
+	})
+	return is, resp, err
+}
+
+func (r *Retrier) IssuesListComments(ctx context.Context, sp SearchParams) (cs []*IssueComment, resp *Response, err error) {
+	err = r.do(ctx, "IssuesListComments", func() error {
+		cs, resp, err = r.p.IssuesListComments(ctx, sp)
+		return err
+	})
+	return cs, resp, err
+}
+
+func (r *Retrier) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) (ts []*Timeline, resp *Response, err error) {
+	err = r.do(ctx, "IssuesListIssueTimeline", func() error {
+		ts, resp, err = r.p.IssuesListIssueTimeline(ctx, sp)
+		return err
+	})
+	return ts, resp, err
+}
+
+func (r *Retrier) IssuesListSubIssues(ctx context.Context, sp SearchParams) (is []*Issue, resp *Response, err error) {
+	err = r.do(ctx, "IssuesListSubIssues", func() error {
+		is, resp, err = r.p.IssuesListSubIssues(ctx, sp)
+		return err
+	})
+	return is, resp, err
+}
+
+func (r *Retrier) EpicsList(ctx context.Context, sp SearchParams) (is []*Issue, resp *Response, err error) {
+	err = r.do(ctx, "EpicsList", func() error {
+		is, resp, err = r.p.EpicsList(ctx, sp)
+		return err
+	})
+	return is, resp, err
+}
+
+func (r *Retrier) EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) (cs []*IssueComment, resp *Response, err error) {
+	err = r.do(ctx, "EpicsListComments", func() error {
+		cs, resp, err = r.p.EpicsListComments(ctx, sp, epic)
+		return err
+	})
+	return cs, resp, err
+}
+
+func (r *Retrier) EpicsListIssues(ctx context.Context, sp SearchParams) (is []*Issue, resp *Response, err error) {
+	err = r.do(ctx, "EpicsListIssues", func() error {
+		is, resp, err = r.p.EpicsListIssues(ctx, sp)
+		return err
+	})
+	return is, resp, err
+}
+
+func (r *Retrier) PullRequestsList(ctx context.Context, sp SearchParams) (prs []*PullRequest, resp *Response, err error) {
+	err = r.do(ctx, "PullRequestsList", func() error {
+		prs, resp, err = r.p.PullRequestsList(ctx, sp)
+		return err
+	})
+	return prs, resp, err
+}
+
+func (r *Retrier) PullRequestsGet(ctx context.Context, sp SearchParams) (pr *PullRequest, resp *Response, err error) {
+	err = r.do(ctx, "PullRequestsGet", func() error {
+		pr, resp, err = r.p.PullRequestsGet(ctx, sp)
+		return err
+	})
+	return pr, resp, err
+}
+
+func (r *Retrier) PullRequestsListComments(ctx context.Context, sp SearchParams) (cs []*PullRequestComment, resp *Response, err error) {
+	err = r.do(ctx, "PullRequestsListComments", func() error {
+		cs, resp, err = r.p.PullRequestsListComments(ctx, sp)
+		return err
+	})
+	return cs, resp, err
+}
+
+func (r *Retrier) PullRequestsListReviews(ctx context.Context, sp SearchParams) (rs []*PullRequestReview, resp *Response, err error) {
+	err = r.do(ctx, "PullRequestsListReviews", func() error {
+		rs, resp, err = r.p.PullRequestsListReviews(ctx, sp)
+		return err
+	})
+	return rs, resp, err
+}
+
+func (r *Retrier) IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return r.p.IssuesCreateComment(ctx, sp, body)
+}
+
+func (r *Retrier) PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return r.p.PullRequestsCreateComment(ctx, sp, body)
+}
+
+func (r *Retrier) IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return r.p.IssuesEditComment(ctx, sp, id, body)
+}
+
+func (r *Retrier) PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return r.p.PullRequestsEditComment(ctx, sp, id, body)
+}
+
+func (r *Retrier) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return r.p.IssuesEdit(ctx, sp, e)
+}
+
+func (r *Retrier) PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return r.p.PullRequestsEdit(ctx, sp, e)
+}
+
+func (r *Retrier) PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error) {
+	return r.p.PullRequestsRequestReviewers(ctx, sp, reviewers)
+}
+
+func (r *Retrier) PullRequestsListFiles(ctx context.Context, sp SearchParams) (fs []string, resp *Response, err error) {
+	err = r.do(ctx, "PullRequestsListFiles", func() error {
+		fs, resp, err = r.p.PullRequestsListFiles(ctx, sp)
+		return err
+	})
+	return fs, resp, err
+}
+
+func (r *Retrier) PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (n int, resp *Response, err error) {
+	err = r.do(ctx, "PullRequestsCommitsBehind", func() error {
+		n, resp, err = r.p.PullRequestsCommitsBehind(ctx, sp, pr)
+		return err
+	})
+	return n, resp, err
+}
+
+func (r *Retrier) PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (n int, resp *Response, err error) {
+	err = r.do(ctx, "PullRequestsUnresolvedThreads", func() error {
+		n, resp, err = r.p.PullRequestsUnresolvedThreads(ctx, sp)
+		return err
+	})
+	return n, resp, err
+}
+
+func (r *Retrier) ReposGetFile(ctx context.Context, sp SearchParams, path string) (bs []byte, resp *Response, err error) {
+	err = r.do(ctx, "ReposGetFile", func() error {
+		bs, resp, err = r.p.ReposGetFile(ctx, sp, path)
+		return err
+	})
+	return bs, resp, err
+}
+
+func (r *Retrier) ReposListLabels(ctx context.Context, sp SearchParams) (ls []string, resp *Response, err error) {
+	err = r.do(ctx, "ReposListLabels", func() error {
+		ls, resp, err = r.p.ReposListLabels(ctx, sp)
+		return err
+	})
+	return ls, resp, err
+}
+
+func (r *Retrier) ReposListMilestones(ctx context.Context, sp SearchParams) (ms []string, resp *Response, err error) {
+	err = r.do(ctx, "ReposListMilestones", func() error {
+		ms, resp, err = r.p.ReposListMilestones(ctx, sp)
+		return err
+	})
+	return ms, resp, err
+}
+
+func (r *Retrier) GroupsIsMember(ctx context.Context, group string, user string) (ok bool, resp *Response, err error) {
+	err = r.do(ctx, "GroupsIsMember", func() error {
+		ok, resp, err = r.p.GroupsIsMember(ctx, group, user)
+		return err
+	})
+	return ok, resp, err
+}
+
+func (r *Retrier) AlertsList(ctx context.Context, sp SearchParams, kind string) (as []*Alert, resp *Response, err error) {
+	err = r.do(ctx, "AlertsList", func() error {
+		as, resp, err = r.p.AlertsList(ctx, sp, kind)
+		return err
+	})
+	return as, resp, err
+}
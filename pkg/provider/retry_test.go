@@ -0,0 +1,119 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyProvider struct {
+	Provider
+	errs  []error
+	calls int
+}
+
+func (f *flakyProvider) IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return nil, nil, f.errs[f.calls-1]
+	}
+	return []*Issue{{}}, &Response{}, nil
+}
+
+func (f *flakyProvider) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	f.calls++
+	return nil, f.errs[0]
+}
+
+func TestRetryable(t *testing.T) {
+	var tests = []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{&StatusError{StatusCode: 502, Message: "bad gateway"}, true},
+		{&StatusError{StatusCode: 404, Message: "not found"}, false},
+		{fmt.Errorf("list: %w", &StatusError{StatusCode: 503}), true},
+		{&SecondaryRateLimitError{Until: time.Now()}, false},
+		{io.ErrUnexpectedEOF, true},
+		{fmt.Errorf("invalid repo"), false},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, retryable(tc.err), "%v", tc.err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	rp := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Jitter: 0.5}
+	assert.Equal(t, time.Second, rp.backoff(1, 0))
+	assert.Equal(t, 4*time.Second, rp.backoff(3, 0))
+	assert.Equal(t, 5*time.Second, rp.backoff(10, 0))
+	assert.Equal(t, 2500*time.Millisecond, rp.backoff(10, 1))
+}
+
+func TestRetrier(t *testing.T) {
+	ctx := context.Background()
+	rp := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Budget: 0.1}
+	unavailable := &StatusError{StatusCode: 503, Message: "unavailable"}
+
+	f := &flakyProvider{errs: []error{unavailable, unavailable}}
+	is, _, err := NewRetrier(f, rp).IssuesListByRepo(ctx, SearchParams{})
+	assert.NoError(t, err)
+	assert.Len(t, is, 1)
+	assert.Equal(t, 3, f.calls)
+
+	f = &flakyProvider{errs: []error{unavailable, unavailable, unavailable}}
+	_, _, err = NewRetrier(f, rp).IssuesListByRepo(ctx, SearchParams{})
+	assert.Equal(t, unavailable, err)
+	assert.Equal(t, 3, f.calls, "gives up after max attempts")
+
+	f = &flakyProvider{errs: []error{&StatusError{StatusCode: 404, Message: "not found"}}}
+	_, _, err = NewRetrier(f, rp).IssuesListByRepo(ctx, SearchParams{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, f.calls, "client errors are not retried")
+
+	f = &flakyProvider{errs: []error{unavailable}}
+	_, err = NewRetrier(f, rp).IssuesEdit(ctx, SearchParams{}, IssueEdit{})
+	assert.Equal(t, unavailable, err)
+	assert.Equal(t, 1, f.calls, "writes are not retried")
+}
+
+func TestRetryBudget(t *testing.T) {
+	ctx := context.Background()
+	rp := RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Budget: 0}
+	unavailable := &StatusError{StatusCode: 503, Message: "unavailable"}
+
+	errs := []error{}
+	for i := 0; i <= 2*retryReserve; i++ {
+		errs = append(errs, unavailable)
+	}
+	f := &flakyProvider{errs: errs}
+	r := NewRetrier(f, rp)
+	for i := 0; i < retryReserve; i++ {
+		r.IssuesListByRepo(ctx, SearchParams{})
+	}
+	assert.Equal(t, 2*retryReserve, f.calls, "the reserve is spent on retries")
+
+	_, _, err := r.IssuesListByRepo(ctx, SearchParams{})
+	assert.Equal(t, unavailable, err)
+	assert.Equal(t, 2*retryReserve+1, f.calls, "no retries once the budget is spent")
+}
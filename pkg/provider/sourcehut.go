@@ -0,0 +1,642 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"golang.org/x/oauth2"
+)
+
+// errNoSourceHutPullRequests is returned for pull request changes, as SourceHut reviews patches on mailing lists instead
+var errNoSourceHutPullRequests = errors.New("SourceHut trackers have no pull requests")
+
+// sourceHutAPIURL is the GraphQL endpoint of todo.sr.ht
+var sourceHutAPIURL = "https://" + constants.SourceHutProviderHost + "/query"
+
+// SourceHutProvider reads tickets from the GraphQL API of todo.sr.ht. Repositories are trackers, owned by a ~user.
+type SourceHutProvider struct {
+	client *http.Client
+	url    string
+}
+
+// NewSourceHut returns a todo.sr.ht provider, authenticating with a personal access token
+func NewSourceHut(token string) (Provider, error) {
+	return NewSourceHutFromSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+}
+
+// NewSourceHutFromSource returns a todo.sr.ht provider which authenticates using tokens from a source, such as a rotated secret
+func NewSourceHutFromSource(ts oauth2.TokenSource) (Provider, error) {
+	if _, err := ts.Token(); err != nil {
+		return nil, fmt.Errorf("token: %v", err)
+	}
+	return &SourceHutProvider{client: oauth2.NewClient(context.Background(), ts), url: sourceHutAPIURL}, nil
+}
+
+type srhtEntity struct {
+	CanonicalName string `json:"canonicalName"`
+}
+
+type srhtTicket struct {
+	ID        int          `json:"id"`
+	Created   time.Time    `json:"created"`
+	Updated   time.Time    `json:"updated"`
+	Subject   string       `json:"subject"`
+	Body      string       `json:"body"`
+	Status    string       `json:"status"`
+	Submitter srhtEntity   `json:"submitter"`
+	Assignees []srhtEntity `json:"assignees"`
+	Labels    []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Events struct {
+		Results []srhtEvent `json:"results"`
+	} `json:"events"`
+}
+
+type srhtEvent struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+	Changes []struct {
+		EventType string `json:"eventType"`
+
+		// Comment
+		Author *srhtEntity `json:"author"`
+		Text   string      `json:"text"`
+
+		// LabelUpdate
+		Labeler *srhtEntity `json:"labeler"`
+		Label   *struct {
+			Name string `json:"name"`
+		} `json:"label"`
+
+		// StatusChange
+		Editor    *srhtEntity `json:"editor"`
+		NewStatus string      `json:"newStatus"`
+
+		// Assignment
+		Assigner *srhtEntity `json:"assigner"`
+		Assignee *srhtEntity `json:"assignee"`
+	} `json:"changes"`
+}
+
+const srhtTicketFields = `
+fragment ticket on Ticket {
+  id created updated subject body status
+  submitter { canonicalName }
+  assignees { canonicalName }
+  labels { name }
+  events { results { changes { eventType } } }
+}`
+
+const srhtTicketsQuery = `query($owner: String!, $tracker: String!, $cursor: Cursor) {
+  trackerByOwner(owner: $owner, tracker: $tracker) {
+    tickets(cursor: $cursor) { results { ...ticket } cursor }
+  }
+}` + srhtTicketFields
+
+const srhtEventsQuery = `query($owner: String!, $tracker: String!, $id: Int!, $cursor: Cursor) {
+  trackerByOwner(owner: $owner, tracker: $tracker) {
+    ticket(id: $id) {
+      events(cursor: $cursor) {
+        results {
+          id created
+          changes {
+            eventType
+            ... on Comment { author { canonicalName } text }
+            ... on LabelUpdate { labeler { canonicalName } label { name } }
+            ... on StatusChange { editor { canonicalName } newStatus }
+            ... on Assignment { assigner { canonicalName } assignee { canonicalName } }
+          }
+        }
+        cursor
+      }
+    }
+  }
+}`
+
+const srhtTrackerQuery = `query($owner: String!, $tracker: String!, $cursor: Cursor) {
+  trackerByOwner(owner: $owner, tracker: $tracker) {
+    id
+    labels(cursor: $cursor) { results { id name } cursor }
+  }
+}`
+
+// sourceHutState maps the status of a ticket to a GitHub state: every status but resolved is open
+func sourceHutState(status string) string {
+	if status == "RESOLVED" {
+		return constants.ClosedState
+	}
+	return constants.OpenState
+}
+
+// sourceHutTimelineEvent maps a ticket event to a GitHub timeline event
+func sourceHutTimelineEvent(eventType string, newStatus string) string {
+	switch eventType {
+	case "LABEL_ADDED":
+		return "labeled"
+	case "LABEL_REMOVED":
+		return "unlabeled"
+	case "ASSIGNED_USER":
+		return "assigned"
+	case "UNASSIGNED_USER":
+		return "unassigned"
+	case "STATUS_CHANGE":
+		if newStatus == "RESOLVED" {
+			return "closed"
+		}
+		return "reopened"
+	default:
+		return ""
+	}
+}
+
+// query runs a GraphQL query, decoding its data into out
+func (p *SourceHutProvider) query(ctx context.Context, q string, vars map[string]interface{}, out interface{}) error {
+	b, err := json.Marshal(&graphQLRequest{Query: q, Variables: vars})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var r struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		if resp.StatusCode >= 300 {
+			return &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("graphql: %d: %v", resp.StatusCode, err)}
+		}
+		return fmt.Errorf("graphql: %d: %w", resp.StatusCode, err)
+	}
+	if len(r.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", r.Errors[0].Message)
+	}
+	if resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("graphql: %d", resp.StatusCode)}
+	}
+	return json.Unmarshal(r.Data, out)
+}
+
+func (p *SourceHutProvider) vars(r Repo) map[string]interface{} {
+	return map[string]interface{}{"owner": r.Organization, "tracker": r.Project}
+}
+
+func (p *SourceHutProvider) getUser(e srhtEntity) *User {
+	login := strings.TrimPrefix(e.CanonicalName, "~")
+	html := "https://sr.ht/" + e.CanonicalName
+	return &User{Login: &login, HTMLURL: &html}
+}
+
+func (p *SourceHutProvider) getIssue(r Repo, t *srhtTicket) *Issue {
+	id := int64(t.ID)
+	number := t.ID
+	state := sourceHutState(t.Status)
+	html := fmt.Sprintf("https://%s/%s/%s/%d", constants.SourceHutProviderHost, r.Organization, r.Project, t.ID)
+
+	comments := 0
+	for _, ev := range t.Events.Results {
+		for _, c := range ev.Changes {
+			if c.EventType == "COMMENT" {
+				comments++
+			}
+		}
+	}
+
+	i := &Issue{
+		ID:        &id,
+		Number:    &number,
+		State:     &state,
+		Title:     &t.Subject,
+		Body:      &t.Body,
+		User:      p.getUser(t.Submitter),
+		Comments:  &comments,
+		CreatedAt: &t.Created,
+		UpdatedAt: &t.Updated,
+		URL:       &html,
+		HTMLURL:   &html,
+	}
+	// Tickets do not record when they were resolved, so the last update is the closest estimate
+	if state == constants.ClosedState {
+		i.ClosedAt = &t.Updated
+	}
+	for _, l := range t.Labels {
+		name := l.Name
+		i.Labels = append(i.Labels, &Label{Name: &name})
+	}
+	for _, a := range t.Assignees {
+		i.Assignees = append(i.Assignees, p.getUser(a))
+	}
+	if len(i.Assignees) > 0 {
+		i.Assignee = i.Assignees[0]
+	}
+	return i
+}
+
+// IssuesListByRepo returns the tickets of a tracker, most recently updated first, stopping at those older than Since
+func (p *SourceHutProvider) IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	opt := sp.IssueListByRepoOptions
+	vars := p.vars(sp.Repo)
+
+	is := []*Issue{}
+	for {
+		var r struct {
+			TrackerByOwner *struct {
+				Tickets struct {
+					Results []*srhtTicket `json:"results"`
+					Cursor  *string       `json:"cursor"`
+				} `json:"tickets"`
+			} `json:"trackerByOwner"`
+		}
+		if err := p.query(ctx, srhtTicketsQuery, vars, &r); err != nil {
+			return nil, &Response{}, err
+		}
+		if r.TrackerByOwner == nil {
+			return nil, &Response{}, fmt.Errorf("no such tracker: %s/%s", sp.Repo.Organization, sp.Repo.Project)
+		}
+
+		for _, t := range r.TrackerByOwner.Tickets.Results {
+			if !opt.Since.IsZero() && t.Updated.Before(opt.Since) {
+				return is, &Response{}, nil
+			}
+			if opt.State != "" && opt.State != "all" && sourceHutState(t.Status) != opt.State {
+				continue
+			}
+			is = append(is, p.getIssue(sp.Repo, t))
+		}
+
+		if r.TrackerByOwner.Tickets.Cursor == nil {
+			return is, &Response{}, nil
+		}
+		vars["cursor"] = *r.TrackerByOwner.Tickets.Cursor
+	}
+}
+
+// events returns the events of a ticket, oldest first
+func (p *SourceHutProvider) events(ctx context.Context, sp SearchParams) ([]srhtEvent, error) {
+	vars := p.vars(sp.Repo)
+	vars["id"] = sp.IssueNumber
+
+	evs := []srhtEvent{}
+	for {
+		var r struct {
+			TrackerByOwner *struct {
+				Ticket *struct {
+					Events struct {
+						Results []srhtEvent `json:"results"`
+						Cursor  *string     `json:"cursor"`
+					} `json:"events"`
+				} `json:"ticket"`
+			} `json:"trackerByOwner"`
+		}
+		if err := p.query(ctx, srhtEventsQuery, vars, &r); err != nil {
+			return nil, err
+		}
+		if r.TrackerByOwner == nil || r.TrackerByOwner.Ticket == nil {
+			return nil, fmt.Errorf("no such ticket: %s/%s#%d", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+		}
+
+		evs = append(evs, r.TrackerByOwner.Ticket.Events.Results...)
+		if r.TrackerByOwner.Ticket.Events.Cursor == nil {
+			break
+		}
+		vars["cursor"] = *r.TrackerByOwner.Ticket.Events.Cursor
+	}
+
+	// Events are listed newest first
+	for i, j := 0, len(evs)-1; i < j; i, j = i+1, j-1 {
+		evs[i], evs[j] = evs[j], evs[i]
+	}
+	return evs, nil
+}
+
+// IssuesListComments returns the comments of a ticket
+func (p *SourceHutProvider) IssuesListComments(ctx context.Context, sp SearchParams) ([]*IssueComment, *Response, error) {
+	evs, err := p.events(ctx, sp)
+	if err != nil {
+		return nil, &Response{}, err
+	}
+
+	cs := []*IssueComment{}
+	for _, ev := range evs {
+		ev := ev
+		for _, c := range ev.Changes {
+			if c.EventType != "COMMENT" || c.Author == nil {
+				continue
+			}
+			text := c.Text
+			cs = append(cs, &IssueComment{
+				ID:        &ev.ID,
+				Body:      &text,
+				User:      p.getUser(*c.Author),
+				CreatedAt: &ev.Created,
+				UpdatedAt: &ev.Created,
+			})
+		}
+	}
+	return cs, &Response{}, nil
+}
+
+// IssuesListIssueTimeline returns the label, assignment and status changes of a ticket
+func (p *SourceHutProvider) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) ([]*Timeline, *Response, error) {
+	evs, err := p.events(ctx, sp)
+	if err != nil {
+		return nil, &Response{}, err
+	}
+
+	ts := []*Timeline{}
+	for _, ev := range evs {
+		ev := ev
+		for _, c := range ev.Changes {
+			name := sourceHutTimelineEvent(c.EventType, c.NewStatus)
+			if name == "" {
+				continue
+			}
+			t := &Timeline{ID: &ev.ID, Event: &name, CreatedAt: &ev.Created}
+			for _, actor := range []*srhtEntity{c.Labeler, c.Editor, c.Assigner} {
+				if actor != nil {
+					t.Actor = p.getUser(*actor)
+				}
+			}
+			if c.Label != nil {
+				l := c.Label.Name
+				t.Label = &Label{Name: &l}
+			}
+			if c.Assignee != nil {
+				t.Assignee = p.getUser(*c.Assignee)
+			}
+			ts = append(ts, t)
+		}
+	}
+	return ts, &Response{}, nil
+}
+
+// IssuesListSubIssues returns no sub-issues, as tickets can not be nested
+func (p *SourceHutProvider) IssuesListSubIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// EpicsList returns no epics, as SourceHut has none
+func (p *SourceHutProvider) EpicsList(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// EpicsListComments returns no comments, as SourceHut has no epics
+func (p *SourceHutProvider) EpicsListComments(ctx context.Context, sp SearchParams, epic *Issue) ([]*IssueComment, *Response, error) {
+	return []*IssueComment{}, &Response{}, nil
+}
+
+// EpicsListIssues returns no issues, as SourceHut has no epics
+func (p *SourceHutProvider) EpicsListIssues(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error) {
+	return []*Issue{}, &Response{}, nil
+}
+
+// PullRequestsList returns no pull requests, as SourceHut reviews patches on mailing lists
+func (p *SourceHutProvider) PullRequestsList(ctx context.Context, sp SearchParams) ([]*PullRequest, *Response, error) {
+	return []*PullRequest{}, &Response{}, nil
+}
+
+func (p *SourceHutProvider) PullRequestsGet(ctx context.Context, sp SearchParams) (*PullRequest, *Response, error) {
+	return nil, &Response{}, errNoSourceHutPullRequests
+}
+
+func (p *SourceHutProvider) PullRequestsListComments(ctx context.Context, sp SearchParams) ([]*PullRequestComment, *Response, error) {
+	return []*PullRequestComment{}, &Response{}, nil
+}
+
+func (p *SourceHutProvider) PullRequestsListReviews(ctx context.Context, sp SearchParams) ([]*PullRequestReview, *Response, error) {
+	return []*PullRequestReview{}, &Response{}, nil
+}
+
+// tracker returns the ID of a tracker, and the IDs of its labels by name
+func (p *SourceHutProvider) tracker(ctx context.Context, r Repo) (int, map[string]int, error) {
+	vars := p.vars(r)
+	labels := map[string]int{}
+	for {
+		var resp struct {
+			TrackerByOwner *struct {
+				ID     int `json:"id"`
+				Labels struct {
+					Results []struct {
+						ID   int    `json:"id"`
+						Name string `json:"name"`
+					} `json:"results"`
+					Cursor *string `json:"cursor"`
+				} `json:"labels"`
+			} `json:"trackerByOwner"`
+		}
+		if err := p.query(ctx, srhtTrackerQuery, vars, &resp); err != nil {
+			return 0, nil, err
+		}
+		t := resp.TrackerByOwner
+		if t == nil {
+			return 0, nil, fmt.Errorf("no such tracker: %s/%s", r.Organization, r.Project)
+		}
+		for _, l := range t.Labels.Results {
+			labels[l.Name] = l.ID
+		}
+		if t.Labels.Cursor == nil {
+			return t.ID, labels, nil
+		}
+		vars["cursor"] = *t.Labels.Cursor
+	}
+}
+
+// IssuesCreateComment comments on a ticket
+func (p *SourceHutProvider) IssuesCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	tid, _, err := p.tracker(ctx, sp.Repo)
+	if err != nil {
+		return nil, &Response{}, err
+	}
+
+	q := `mutation($tracker: Int!, $ticket: Int!, $text: String!) {
+  submitComment(trackerId: $tracker, ticketId: $ticket, input: {text: $text}) { id created }
+}`
+	var r struct {
+		SubmitComment srhtEvent `json:"submitComment"`
+	}
+	vars := map[string]interface{}{"tracker": tid, "ticket": sp.IssueNumber, "text": body}
+	if err := p.query(ctx, q, vars, &r); err != nil {
+		return nil, &Response{}, err
+	}
+	ev := r.SubmitComment
+	return &IssueComment{ID: &ev.ID, Body: &body, CreatedAt: &ev.Created, UpdatedAt: &ev.Created}, &Response{}, nil
+}
+
+func (p *SourceHutProvider) PullRequestsCreateComment(ctx context.Context, sp SearchParams, body string) (*IssueComment, *Response, error) {
+	return nil, &Response{}, errNoSourceHutPullRequests
+}
+
+// IssuesEditComment returns an error, as the API can not edit comments
+func (p *SourceHutProvider) IssuesEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return nil, &Response{}, errors.New("SourceHut comments can not be edited")
+}
+
+func (p *SourceHutProvider) PullRequestsEditComment(ctx context.Context, sp SearchParams, id int64, body string) (*IssueComment, *Response, error) {
+	return nil, &Response{}, errNoSourceHutPullRequests
+}
+
+// IssuesEdit labels, assigns, resolves or reopens a ticket. Tickets are resolved as closed, and reopened as reported.
+func (p *SourceHutProvider) IssuesEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	switch {
+	case e.Milestone != "":
+		return &Response{}, errors.New("SourceHut tickets have no milestones")
+	case e.Locked != nil:
+		return &Response{}, errors.New("SourceHut tickets can not be locked")
+	}
+
+	tid, labels, err := p.tracker(ctx, sp.Repo)
+	if err != nil {
+		return &Response{}, err
+	}
+	vars := func() map[string]interface{} {
+		return map[string]interface{}{"tracker": tid, "ticket": sp.IssueNumber}
+	}
+
+	for _, add := range []bool{true, false} {
+		names := e.RemoveLabels
+		mutation := "unlabelTicket"
+		if add {
+			names = e.AddLabels
+			mutation = "labelTicket"
+		}
+		for _, name := range names {
+			id, ok := labels[name]
+			if !ok {
+				return &Response{}, fmt.Errorf("unknown label: %q", name)
+			}
+			v := vars()
+			v["label"] = id
+			q := fmt.Sprintf(`mutation($tracker: Int!, $ticket: Int!, $label: Int!) { %s(trackerId: $tracker, ticketId: $ticket, labelId: $label) { id } }`, mutation)
+			if err := p.query(ctx, q, v, &struct{}{}); err != nil {
+				return &Response{}, fmt.Errorf("%s %q: %w", mutation, name, err)
+			}
+		}
+	}
+
+	for _, login := range e.AddAssignees {
+		var u struct {
+			User *struct {
+				ID int `json:"id"`
+			} `json:"user"`
+		}
+		if err := p.query(ctx, `query($name: String!) { user(username: $name) { id } }`, map[string]interface{}{"name": strings.TrimPrefix(login, "~")}, &u); err != nil {
+			return &Response{}, err
+		}
+		if u.User == nil {
+			return &Response{}, fmt.Errorf("unknown user: %q", login)
+		}
+		v := vars()
+		v["user"] = u.User.ID
+		if err := p.query(ctx, `mutation($tracker: Int!, $ticket: Int!, $user: Int!) { assignUser(trackerId: $tracker, ticketId: $ticket, userId: $user) { id } }`, v, &struct{}{}); err != nil {
+			return &Response{}, fmt.Errorf("assign %q: %w", login, err)
+		}
+	}
+
+	if e.State != "" {
+		v := vars()
+		v["status"], v["resolution"] = "REPORTED", "UNRESOLVED"
+		if e.State == constants.ClosedState {
+			v["status"], v["resolution"] = "RESOLVED", "CLOSED"
+		}
+		q := `mutation($tracker: Int!, $ticket: Int!, $status: TicketStatus!, $resolution: TicketResolution) {
+  updateTicketStatus(trackerId: $tracker, ticketId: $ticket, input: {status: $status, resolution: $resolution}) { id }
+}`
+		if err := p.query(ctx, q, v, &struct{}{}); err != nil {
+			return &Response{}, err
+		}
+	}
+	return &Response{}, nil
+}
+
+func (p *SourceHutProvider) PullRequestsEdit(ctx context.Context, sp SearchParams, e IssueEdit) (*Response, error) {
+	return &Response{}, errNoSourceHutPullRequests
+}
+
+func (p *SourceHutProvider) PullRequestsRequestReviewers(ctx context.Context, sp SearchParams, reviewers []string) (*Response, error) {
+	return &Response{}, errNoSourceHutPullRequests
+}
+
+func (p *SourceHutProvider) PullRequestsListFiles(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	return nil, &Response{}, errNoSourceHutPullRequests
+}
+
+func (p *SourceHutProvider) PullRequestsCommitsBehind(ctx context.Context, sp SearchParams, pr *PullRequest) (int, *Response, error) {
+	return 0, &Response{}, errNoSourceHutPullRequests
+}
+
+func (p *SourceHutProvider) PullRequestsUnresolvedThreads(ctx context.Context, sp SearchParams) (int, *Response, error) {
+	return 0, &Response{}, errNoSourceHutPullRequests
+}
+
+// ReposGetFile returns an error, as trackers are not backed by a repository
+func (p *SourceHutProvider) ReposGetFile(ctx context.Context, sp SearchParams, path string) ([]byte, *Response, error) {
+	return nil, &Response{}, fmt.Errorf("%s: SourceHut trackers have no files", path)
+}
+
+// ReposListLabels returns the names of a tracker's labels
+func (p *SourceHutProvider) ReposListLabels(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	_, labels, err := p.tracker(ctx, sp.Repo)
+	if err != nil {
+		return nil, &Response{}, err
+	}
+	names := []string{}
+	for name := range labels {
+		names = append(names, name)
+	}
+	return names, &Response{}, nil
+}
+
+// ReposListMilestones returns no milestones, as SourceHut has none
+func (p *SourceHutProvider) ReposListMilestones(ctx context.Context, sp SearchParams) ([]string, *Response, error) {
+	return []string{}, &Response{}, nil
+}
+
+// GroupsIsMember returns whether a user is the owner named by a group, as SourceHut has no organizations
+func (p *SourceHutProvider) GroupsIsMember(ctx context.Context, group string, user string) (bool, *Response, error) {
+	return strings.TrimPrefix(group, "~") == strings.TrimPrefix(user, "~"), &Response{}, nil
+}
+
+// AlertsList returns no alerts, as SourceHut has no security alerts
+func (p *SourceHutProvider) AlertsList(ctx context.Context, sp SearchParams, kind string) ([]*Alert, *Response, error) {
+	return []*Alert{}, &Response{}, nil
+}
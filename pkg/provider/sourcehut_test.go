@@ -0,0 +1,74 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceHut_IssuesListByRepo(t *testing.T) {
+	pages := []string{
+		`{"data": {"trackerByOwner": {"tickets": {"cursor": "next", "results": [
+			{"id": 3, "created": "2020-06-01T00:00:00Z", "updated": "2020-06-03T00:00:00Z", "subject": "crash", "status": "REPORTED",
+			 "submitter": {"canonicalName": "~alice"}, "assignees": [{"canonicalName": "~bob"}], "labels": [{"name": "bug"}],
+			 "events": {"results": [{"changes": [{"eventType": "CREATED"}]}, {"changes": [{"eventType": "COMMENT"}]}]}},
+			{"id": 2, "created": "2020-05-01T00:00:00Z", "updated": "2020-06-02T00:00:00Z", "subject": "typo", "status": "RESOLVED",
+			 "submitter": {"canonicalName": "~carol"}}
+		]}}}}`,
+		`{"data": {"trackerByOwner": {"tickets": {"cursor": null, "results": [
+			{"id": 1, "created": "2020-01-01T00:00:00Z", "updated": "2020-01-01T00:00:00Z", "subject": "old", "status": "CONFIRMED",
+			 "submitter": {"canonicalName": "~alice"}}
+		]}}}}`,
+	}
+
+	n := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(pages[n]))
+		n++
+	}))
+	defer s.Close()
+
+	p := &SourceHutProvider{client: s.Client(), url: s.URL}
+	sp := SearchParams{Repo: Repo{Organization: "~owner", Project: "tracker"}}
+	sp.IssueListByRepoOptions.State = "open"
+	sp.IssueListByRepoOptions.Since = time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	is, _, err := p.IssuesListByRepo(context.Background(), sp)
+	assert.Nil(t, err)
+
+	// The resolved ticket is closed, and the last one was updated before Since
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, len(is))
+	i := is[0]
+	assert.Equal(t, 3, i.GetNumber())
+	assert.Equal(t, "open", i.GetState())
+	assert.Equal(t, "alice", i.GetUser().GetLogin())
+	assert.Equal(t, "bob", i.GetAssignee().GetLogin())
+	assert.Equal(t, 1, i.GetComments())
+	assert.Equal(t, "https://todo.sr.ht/~owner/tracker/3", i.GetHTMLURL())
+}
+
+func TestSourceHutTimelineEvent(t *testing.T) {
+	assert.Equal(t, "closed", sourceHutTimelineEvent("STATUS_CHANGE", "RESOLVED"))
+	assert.Equal(t, "reopened", sourceHutTimelineEvent("STATUS_CHANGE", "REPORTED"))
+	assert.Equal(t, "labeled", sourceHutTimelineEvent("LABEL_ADDED", ""))
+	assert.Equal(t, "", sourceHutTimelineEvent("COMMENT", ""))
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// defaultSecondaryPause is how long to pause for when a secondary rate limit response does not say how long to wait
+const defaultSecondaryPause = time.Minute
+
+// maxSecondaryPause is the longest pause, however often secondary rate limits are hit
+const maxSecondaryPause = 30 * time.Minute
+
+// SecondaryRateLimitError is returned for requests made while a provider asked us to slow down
+type SecondaryRateLimitError struct {
+	Until time.Time
+}
+
+func (e *SecondaryRateLimitError) Error() string {
+	return fmt.Sprintf("secondary rate limit: paused until %s", e.Until.Format(time.RFC3339))
+}
+
+// Pauser is implemented by providers which may pause requests, such as after hitting a secondary rate limit
+type Pauser interface {
+	// PausedUntil returns when requests may be made again, or a zero time if they are not paused
+	PausedUntil() time.Time
+}
+
+// throttle pauses all requests through it after a secondary rate limit response, rather than making the ban longer
+type throttle struct {
+	base http.RoundTripper
+
+	mu    sync.Mutex
+	until time.Time
+	// strikes is how many secondary rate limits were hit in a row, for backing off when no Retry-After is given
+	strikes int
+}
+
+func newThrottle(base http.RoundTripper) *throttle {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &throttle{base: base}
+}
+
+// PausedUntil returns when requests may be made again, or a zero time if they are not paused
+func (t *throttle) PausedUntil() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if time.Now().After(t.until) {
+		return time.Time{}
+	}
+	return t.until
+}
+
+// RoundTrip makes a request, unless paused
+func (t *throttle) RoundTrip(req *http.Request) (*http.Response, error) {
+	if until := t.PausedUntil(); !until.IsZero() {
+		return nil, &SecondaryRateLimitError{Until: until}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests) {
+		if err == nil {
+			t.mu.Lock()
+			t.strikes = 0
+			t.mu.Unlock()
+		}
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if !isSecondaryRateLimit(resp, body) {
+		return resp, nil
+	}
+
+	t.mu.Lock()
+	t.strikes++
+	wait := secondaryPause(resp.Header.Get("Retry-After"), t.strikes)
+	t.until = time.Now().Add(wait)
+	t.mu.Unlock()
+
+	klog.Errorf("%s hit a secondary rate limit: pausing requests to %s for %s", req.URL.Path, req.URL.Host, wait)
+	return resp, nil
+}
+
+// isSecondaryRateLimit returns whether a response is to slow down, rather than being forbidden or out of primary quota
+func isSecondaryRateLimit(resp *http.Response, body []byte) bool {
+	if resp.Header.Get("Retry-After") != "" {
+		return true
+	}
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse detection")
+}
+
+// secondaryPause returns how long to pause after hitting a secondary rate limit, honoring Retry-After if given
+func secondaryPause(retryAfter string, strikes int) time.Duration {
+	if secs, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(retryAfter); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	d := defaultSecondaryPause
+	for i := 1; i < strikes && d < maxSecondaryPause; i++ {
+		d *= 2
+	}
+	if d > maxSecondaryPause {
+		d = maxSecondaryPause
+	}
+	return d
+}
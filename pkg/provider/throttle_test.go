@@ -0,0 +1,70 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+		case "/secondary":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	c := &http.Client{Transport: newThrottle(nil)}
+	th := c.Transport.(*throttle)
+
+	resp, err := c.Get(srv.URL + "/forbidden")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.True(t, th.PausedUntil().IsZero(), "forbidden is not a secondary rate limit")
+
+	resp, err = c.Get(srv.URL + "/secondary")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	until := th.PausedUntil()
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), until, 5*time.Second)
+
+	_, err = c.Get(srv.URL + "/ok")
+	var sle *SecondaryRateLimitError
+	assert.True(t, errors.As(err, &sle), "got %v", err)
+	assert.Equal(t, 2, calls, "paused requests must not be sent")
+}
+
+func TestSecondaryPause(t *testing.T) {
+	assert.Equal(t, 30*time.Second, secondaryPause("30", 1))
+	assert.Equal(t, time.Minute, secondaryPause("", 1))
+	assert.Equal(t, 4*time.Minute, secondaryPause("", 3))
+	assert.Equal(t, maxSecondaryPause, secondaryPause("", 20))
+}
@@ -115,6 +115,10 @@ type Timeline struct {
 	// The 'id', 'actor', and 'url' for the source of a reference from another issue.
 	// Only provided for 'cross-referenced' events.
 	Source *Source `json:"source,omitempty"`
+
+	// The User object whose review was requested. Only provided for
+	// 'review_requested' and 'review_request_removed' events.
+	RequestedReviewer *User `json:"requested_reviewer,omitempty"`
 }
 
 // GetActor returns the Actor field.
@@ -173,6 +177,14 @@ func (t *Timeline) GetSource() *Source {
 	return t.Source
 }
 
+// GetRequestedReviewer returns the RequestedReviewer field.
+func (t *Timeline) GetRequestedReviewer() *User {
+	if t == nil {
+		return nil
+	}
+	return t.RequestedReviewer
+}
+
 // GetURL returns the URL field if it's non-nil, zero value otherwise.
 func (t *Timeline) GetURL() string {
 	if t == nil || t.URL == nil {
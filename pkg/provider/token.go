@@ -0,0 +1,86 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of GitHub tokens, as told apart by their prefix
+const (
+	ClassicToken     = "classic"
+	FineGrainedToken = "fine-grained"
+	OAuthToken       = "oauth"
+	AppToken         = "app"
+	UnknownToken     = "unknown"
+)
+
+// TokenInfo describes the token a provider authenticates with
+type TokenInfo struct {
+	Type string `json:"type"`
+	// Scopes are only reported for classic and OAuth tokens: fine-grained tokens have per-repository permissions instead
+	Scopes []string `json:"scopes"`
+	// Expires is zero for tokens which do not expire
+	Expires time.Time `json:"expires"`
+	Rate    Rate      `json:"rate"`
+}
+
+// TokenInspector is implemented by providers which can describe their token
+type TokenInspector interface {
+	TokenInfo(ctx context.Context) (*TokenInfo, error)
+}
+
+// GitHubTokenType returns the kind of a GitHub token
+func GitHubTokenType(token string) string {
+	switch {
+	case strings.HasPrefix(token, "github_pat_"):
+		return FineGrainedToken
+	case strings.HasPrefix(token, "ghp_"):
+		return ClassicToken
+	case strings.HasPrefix(token, "gho_"), strings.HasPrefix(token, "ghu_"):
+		return OAuthToken
+	case strings.HasPrefix(token, "ghs_"):
+		return AppToken
+	case len(token) == 40:
+		// Tokens issued before April 2021 are 40 hex characters, without a prefix
+		return ClassicToken
+	default:
+		return UnknownToken
+	}
+}
+
+// parseTokenExpiration parses the GitHub-Authentication-Token-Expiration header
+func parseTokenExpiration(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown token expiration format: %q", s)
+}
+
+// parseScopes parses the X-OAuth-Scopes header
+func parseScopes(s string) []string {
+	scopes := []string{}
+	for _, sc := range strings.Split(s, ",") {
+		if sc = strings.TrimSpace(sc); sc != "" {
+			scopes = append(scopes, sc)
+		}
+	}
+	return scopes
+}
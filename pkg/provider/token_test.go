@@ -0,0 +1,57 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitHubTokenType(t *testing.T) {
+	tests := map[string]string{
+		"github_pat_11ABCDEFG0123456789_abcdef": FineGrainedToken,
+		"ghp_abcdef0123456789":                  ClassicToken,
+		strings.Repeat("a", 40):                 ClassicToken,
+		"gho_abcdef":                            OAuthToken,
+		"ghs_abcdef":                            AppToken,
+		"something":                             UnknownToken,
+	}
+	for token, want := range tests {
+		assert.Equal(t, want, GitHubTokenType(token), token)
+	}
+}
+
+func TestParseTokenExpiration(t *testing.T) {
+	want := time.Date(2026, 11, 1, 7, 56, 20, 0, time.UTC)
+
+	got, err := parseTokenExpiration("2026-11-01 07:56:20 UTC")
+	assert.NoError(t, err)
+	assert.True(t, want.Equal(got), got)
+
+	got, err = parseTokenExpiration("2026-11-01 08:56:20 +0100")
+	assert.NoError(t, err)
+	assert.True(t, want.Equal(got), got)
+
+	_, err = parseTokenExpiration("soon")
+	assert.Error(t, err)
+}
+
+func TestParseScopes(t *testing.T) {
+	assert.Equal(t, []string{"repo", "read:org"}, parseScopes("repo, read:org"))
+	assert.Equal(t, []string{}, parseScopes(""))
+}
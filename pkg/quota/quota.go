@@ -0,0 +1,189 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quota keeps track of how provider rate limits are consumed
+package quota
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// maxHours is how many hours of usage are kept
+const maxHours = 48
+
+// maxRefreshes is how many collection refreshes are kept
+const maxRefreshes = 200
+
+// Usage is how much of the rate limit of a provider host was used within an hour
+type Usage struct {
+	Host string    `json:"host"`
+	Hour time.Time `json:"hour"`
+	// Requests is how many requests Triage Party made
+	Requests int `json:"requests"`
+	// Consumed is how far the remaining rate limit fell, which includes requests made by anything else sharing the token
+	Consumed int `json:"consumed"`
+	// Limit and Remaining are as last observed within the hour
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+}
+
+// Refresh is how many requests a collection refresh made to each provider host
+type Refresh struct {
+	Collection string         `json:"collection"`
+	Started    time.Time      `json:"started"`
+	Duration   time.Duration  `json:"duration"`
+	Requests   map[string]int `json:"requests"`
+}
+
+// Total returns how many requests a refresh made to all provider hosts
+func (r *Refresh) Total() int {
+	n := 0
+	for _, c := range r.Requests {
+		n += c
+	}
+	return n
+}
+
+// Log records rate limit observations
+type Log struct {
+	mu        sync.Mutex
+	hours     []*Usage
+	last      map[string]provider.Rate
+	totals    map[string]int
+	refreshes []*Refresh
+}
+
+// New returns an empty log
+func New() *Log {
+	return &Log{
+		last:   map[string]provider.Rate{},
+		totals: map[string]int{},
+	}
+}
+
+// Observe records a request to a provider host, and the rate limit it reported
+func (l *Log) Observe(host string, r provider.Rate) {
+	l.observe(host, r, time.Now())
+}
+
+func (l *Log) observe(host string, r provider.Rate, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u := l.usage(host, now.Truncate(time.Hour))
+	u.Requests++
+	l.totals[host]++
+
+	// Providers which do not report a quota leave it empty
+	if r.Limit == 0 && r.Remaining == 0 {
+		return
+	}
+
+	// Responses may arrive out of order, so only count falls within the same window
+	if prev, ok := l.last[host]; ok && prev.Reset.Equal(r.Reset.Time) && r.Remaining < prev.Remaining {
+		u.Consumed += prev.Remaining - r.Remaining
+	}
+	l.last[host] = r
+	u.Limit = r.Limit
+	u.Remaining = r.Remaining
+}
+
+// usage returns the usage of a host within an hour, adding it if need be
+func (l *Log) usage(host string, hour time.Time) *Usage {
+	for i := len(l.hours) - 1; i >= 0; i-- {
+		u := l.hours[i]
+		if u.Hour.Before(hour) {
+			break
+		}
+		if u.Host == host && u.Hour.Equal(hour) {
+			return u
+		}
+	}
+
+	u := &Usage{Host: host, Hour: hour}
+	l.hours = append(l.hours, u)
+
+	cutoff := hour.Add(-maxHours * time.Hour)
+	for len(l.hours) > 0 && l.hours[0].Hour.Before(cutoff) {
+		l.hours = l.hours[1:]
+	}
+	return u
+}
+
+// Totals returns how many requests have been made to each provider host
+func (l *Log) Totals() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ts := map[string]int{}
+	for h, n := range l.totals {
+		ts[h] = n
+	}
+	return ts
+}
+
+// RecordRefresh records the requests made by a collection refresh, given the totals from when it started
+func (l *Log) RecordRefresh(id string, started time.Time, before map[string]int) {
+	after := l.Totals()
+
+	r := &Refresh{Collection: id, Started: started, Duration: time.Since(started), Requests: map[string]int{}}
+	for h, n := range after {
+		if d := n - before[h]; d > 0 {
+			r.Requests[h] = d
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refreshes = append(l.refreshes, r)
+	if len(l.refreshes) > maxRefreshes {
+		l.refreshes = l.refreshes[len(l.refreshes)-maxRefreshes:]
+	}
+}
+
+// Hourly returns the usage of each provider host by hour, oldest first
+func (l *Log) Hourly() []*Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	us := []*Usage{}
+	for _, u := range l.hours {
+		c := *u
+		us = append(us, &c)
+	}
+	sort.SliceStable(us, func(i, j int) bool {
+		if !us[i].Hour.Equal(us[j].Hour) {
+			return us[i].Hour.Before(us[j].Hour)
+		}
+		return us[i].Host < us[j].Host
+	})
+	return us
+}
+
+// Refreshes returns the most recent collection refreshes, newest first
+func (l *Log) Refreshes() []*Refresh {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rs := []*Refresh{}
+	for i := len(l.refreshes) - 1; i >= 0; i-- {
+		rs = append(rs, l.refreshes[i])
+	}
+	return rs
+}
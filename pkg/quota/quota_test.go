@@ -0,0 +1,66 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func rate(remaining int, reset time.Time) provider.Rate {
+	return provider.Rate{Limit: 5000, Remaining: remaining, Reset: provider.Timestamp{Time: reset}}
+}
+
+func TestObserve(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	reset := t0.Add(30 * time.Minute)
+
+	l := New()
+	l.observe("github.com", rate(4000, reset), t0)
+	// another client sharing the token made 8 requests in between
+	l.observe("github.com", rate(3990, reset), t0.Add(time.Minute))
+	l.observe("github.com", rate(3989, reset), t0.Add(2*time.Minute))
+	// the rate limit reset, which is not consumption
+	l.observe("github.com", rate(4999, reset.Add(time.Hour)), t0.Add(40*time.Minute))
+	l.observe("gitlab.com", provider.Rate{}, t0)
+
+	want := []*Usage{
+		{Host: "github.com", Hour: t0.Truncate(time.Hour), Requests: 3, Consumed: 11, Limit: 5000, Remaining: 3989},
+		{Host: "gitlab.com", Hour: t0.Truncate(time.Hour), Requests: 1},
+		{Host: "github.com", Hour: t0.Truncate(time.Hour).Add(time.Hour), Requests: 1, Limit: 5000, Remaining: 4999},
+	}
+	assert.Equal(t, want, l.Hourly())
+	assert.Equal(t, map[string]int{"github.com": 4, "gitlab.com": 1}, l.Totals())
+}
+
+func TestRecordRefresh(t *testing.T) {
+	l := New()
+	l.Observe("github.com", provider.Rate{})
+
+	before := l.Totals()
+	l.Observe("github.com", provider.Rate{})
+	l.Observe("github.com", provider.Rate{})
+	l.RecordRefresh("daily", time.Now(), before)
+	l.RecordRefresh("weekly", time.Now(), l.Totals())
+
+	rs := l.Refreshes()
+	assert.Equal(t, 2, len(rs))
+	assert.Equal(t, "weekly", rs[0].Collection)
+	assert.Equal(t, 0, rs[0].Total())
+	assert.Equal(t, map[string]int{"github.com": 2}, rs[1].Requests)
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// Handoff summarizes a triage shift for whoever is on duty next
+type Handoff struct {
+	Created time.Time
+	Shift   *triage.Shift
+	// Next is the following shift, if any
+	Next *triage.Shift
+
+	// Actions are changes recorded during the shift, oldest first
+	Actions []*persist.AuditEntry
+	// ByMember is how many actions were requested by the member on duty
+	ByMember int
+
+	// Responded are items the member on duty responded to during the shift
+	Responded []*hubbub.Conversation
+
+	// Pending are open items awaiting a member response, longest waiting first
+	Pending      []*hubbub.Conversation
+	PendingTotal int
+}
+
+// Handoff generates a handoff report for a shift
+func (r *Reporter) Handoff(ctx context.Context, s *triage.Shift) (*Handoff, error) {
+	rs := r.party.Settings().Rotation
+	if rs == nil {
+		return nil, fmt.Errorf("no rotation is configured")
+	}
+
+	ids, err := r.collectionIDs(rs.Collections)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Handoff{Created: time.Now(), Shift: s, Next: r.party.OnDuty(s.End)}
+
+	if r.audit != nil {
+		days := int(time.Since(s.Start).Hours()/24) + 1
+		es := r.audit.Entries(days)
+		for i := len(es) - 1; i >= 0; i-- {
+			e := es[i]
+			if e.Time.Before(s.Start) || !e.Time.Before(s.End) {
+				continue
+			}
+			h.Actions = append(h.Actions, e)
+			if e.Actor == s.Member {
+				h.ByMember++
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		cr := r.updater.Lookup(ctx, id, true)
+		if cr == nil {
+			return nil, fmt.Errorf("no results for collection %q", id)
+		}
+
+		for _, rr := range cr.RuleResults {
+			for _, co := range rr.Items {
+				if seen[co.URL] {
+					continue
+				}
+				seen[co.URL] = true
+
+				if t, ok := co.LatestResponses[s.Member]; ok && !t.Before(s.Start) && t.Before(s.End) {
+					h.Responded = append(h.Responded, co)
+				}
+				if (co.State == constants.OpenState || co.State == constants.OpenedState) && co.Tags[tag.Recv] {
+					h.Pending = append(h.Pending, co)
+				}
+			}
+		}
+	}
+
+	sort.Slice(h.Pending, func(i, j int) bool { return h.Pending[i].CurrentHoldTime > h.Pending[j].CurrentHoldTime })
+	h.PendingTotal = len(h.Pending)
+	if len(h.Pending) > defaultTop {
+		h.Pending = h.Pending[:defaultTop]
+	}
+	return h, nil
+}
+
+// publishHandoff generates a handoff report for a shift which has ended, and delivers it
+func (r *Reporter) publishHandoff(ctx context.Context, s *triage.Shift, next *triage.Shift) error {
+	rs := r.party.Settings().Rotation
+	if rs == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, err := r.Handoff(ctx, s)
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+	h.Next = next
+
+	md, err := HandoffMarkdown(h)
+	if err != nil {
+		return fmt.Errorf("markdown: %w", err)
+	}
+	html, err := HandoffHTML(h)
+	if err != nil {
+		return fmt.Errorf("html: %w", err)
+	}
+
+	if rs.Output != "" {
+		base := fmt.Sprintf("handoff-%s-%s", s.Member, s.Start.UTC().Format("20060102T150405Z"))
+		if err := write(rs.Output, base, md, html); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+		klog.Infof("wrote handoff for %s to %s", s.Member, rs.Output)
+	}
+
+	if len(rs.Email) > 0 {
+		if r.mailer == nil {
+			return fmt.Errorf("rotation has e-mail recipients, but no SMTP server is configured")
+		}
+		subject := fmt.Sprintf("Triage handoff from %s: %s", s.Member, s.End.Format("2006-01-02"))
+		if err := r.mailer.Send(rs.Email, subject, string(md), string(html)); err != nil {
+			return fmt.Errorf("mail: %w", err)
+		}
+		klog.Infof("mailed handoff for %s to %v", s.Member, rs.Email)
+	}
+	return nil
+}
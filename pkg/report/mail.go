@@ -0,0 +1,117 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+)
+
+// MailConfig is how to configure a new mailer
+type MailConfig struct {
+	// Addr is the SMTP server, as host:port
+	Addr     string
+	From     string
+	User     string
+	Password string
+}
+
+// Mailer sends reports via SMTP
+type Mailer struct {
+	addr     string
+	from     string
+	user     string
+	password string
+}
+
+// NewMailer returns a new mailer
+func NewMailer(cfg MailConfig) *Mailer {
+	return &Mailer{
+		addr:     cfg.Addr,
+		from:     cfg.From,
+		user:     cfg.User,
+		password: cfg.Password,
+	}
+}
+
+// Send mails a message with both plain text and HTML bodies
+func (m *Mailer) Send(to []string, subject string, text string, html string) error {
+	msg, err := message(m.from, to, subject, text, html)
+	if err != nil {
+		return fmt.Errorf("message: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.user != "" {
+		host, _, err := net.SplitHostPort(m.addr)
+		if err != nil {
+			return fmt.Errorf("smtp address: %w", err)
+		}
+		auth = smtp.PlainAuth("", m.user, m.password, host)
+	}
+
+	if err := smtp.SendMail(m.addr, auth, m.from, to, msg); err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	return nil
+}
+
+// message builds a multipart/alternative e-mail
+func message(from string, to []string, subject string, text string, html string) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	for _, p := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.NewReplacer("\r", "", "\n", " ").Replace(subject)))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var funcs = map[string]interface{}{
+	"days":   days,
+	"signed": signed,
+	"date":   date,
+	"escape": escapeMarkdown,
+}
+
+var markdownTmpl = template.Must(template.New("markdown").Funcs(funcs).Parse(`# {{ .Name }}
+
+Generated {{ date .Created }}
+{{ if or (index .Sections "counts") (index .Sections "trends") }}
+## Rules
+
+| Collection | Rule | Items |{{ if index .Sections "trends" }} Since {{ date .TrendSince }} |{{ end }}
+|---|---|---|{{ if index .Sections "trends" }}---|{{ end }}
+{{- range .Rules }}
+| {{ escape .Collection }} | {{ escape .Name }} | {{ .Count }} |{{ if index $.Sections "trends" }} {{ if .HasPrevious }}{{ signed .Change }}{{ else }}n/a{{ end }} |{{ end }}
+{{- end }}
+{{ end }}
+{{- if index .Sections "stale" }}
+## Least recently updated
+
+{{ range .Stale }}* [{{ escape .Title }}]({{ .URL }}), updated {{ days .Updated }} ago
+{{ else }}No open items
+{{ end }}
+{{- end }}
+{{- if index .Sections "sla" }}
+## Response SLA
+
+{{ if .Open }}{{ printf "%.0f" .SLAPercent }}% of open items ({{ .WithinSLA }} of {{ .Open }}) have waited less than {{ printf "%.1f" .SLA.Hours }} hours for a member response.
+{{ else }}No open items
+{{ end }}
+{{- end }}`))
+
+var htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(`<html>
+<body>
+<h1>{{ .Name }}</h1>
+<p>Generated {{ date .Created }}</p>
+{{ if or (index .Sections "counts") (index .Sections "trends") }}
+<h2>Rules</h2>
+<table>
+<tr><th>Collection</th><th>Rule</th><th>Items</th>{{ if index .Sections "trends" }}<th>Since {{ date .TrendSince }}</th>{{ end }}</tr>
+{{- range .Rules }}
+<tr><td>{{ .Collection }}</td><td>{{ .Name }}</td><td>{{ .Count }}</td>{{ if index $.Sections "trends" }}<td>{{ if .HasPrevious }}{{ signed .Change }}{{ else }}n/a{{ end }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+{{ end }}
+{{- if index .Sections "stale" }}
+<h2>Least recently updated</h2>
+<ul>
+{{- range .Stale }}
+<li><a href="{{ .URL }}">{{ .Title }}</a>, updated {{ days .Updated }} ago</li>
+{{- else }}
+<li>No open items</li>
+{{- end }}
+</ul>
+{{ end }}
+{{- if index .Sections "sla" }}
+<h2>Response SLA</h2>
+{{ if .Open }}<p>{{ printf "%.0f" .SLAPercent }}% of open items ({{ .WithinSLA }} of {{ .Open }}) have waited less than {{ printf "%.1f" .SLA.Hours }} hours for a member response.</p>
+{{ else }}<p>No open items</p>
+{{ end }}
+{{- end }}
+</body>
+</html>`))
+
+var handoffMarkdownTmpl = template.Must(template.New("handoff-markdown").Funcs(funcs).Parse(`# Triage handoff from {{ .Shift.Member }}
+
+Shift {{ date .Shift.Start }} to {{ date .Shift.End }}{{ if .Next }}, handing off to {{ .Next.Member }}{{ end }}
+
+## Covered
+
+{{ .Shift.Member }} responded to {{ len .Responded }} items, and requested {{ .ByMember }} of {{ len .Actions }} recorded changes.
+{{ range .Responded }}
+* [{{ escape .Title }}]({{ .URL }})
+{{- end }}
+{{ if .Actions }}
+| Time | Actor | Action | Target |
+|---|---|---|---|
+{{- range .Actions }}
+| {{ .Time.Format "2006-01-02 15:04" }} | {{ escape .Actor }} | {{ escape .Action }} | {{ .Target }} |
+{{- end }}
+{{ end }}
+## Pending
+
+{{ range .Pending }}* [{{ escape .Title }}]({{ .URL }}), waiting {{ printf "%.0f" .CurrentHoldTime.Hours }} hours
+{{ else }}Nothing is awaiting a member response
+{{ end }}
+{{- if gt .PendingTotal (len .Pending) }}
+{{ .PendingTotal }} items in total are awaiting a member response.
+{{ end }}`))
+
+var handoffHTMLTmpl = htmltemplate.Must(htmltemplate.New("handoff-html").Funcs(funcs).Parse(`<html>
+<body>
+<h1>Triage handoff from {{ .Shift.Member }}</h1>
+<p>Shift {{ date .Shift.Start }} to {{ date .Shift.End }}{{ if .Next }}, handing off to {{ .Next.Member }}{{ end }}</p>
+<h2>Covered</h2>
+<p>{{ .Shift.Member }} responded to {{ len .Responded }} items, and requested {{ .ByMember }} of {{ len .Actions }} recorded changes.</p>
+<ul>
+{{- range .Responded }}
+<li><a href="{{ .URL }}">{{ .Title }}</a></li>
+{{- end }}
+</ul>
+{{ if .Actions }}
+<table>
+<tr><th>Time</th><th>Actor</th><th>Action</th><th>Target</th></tr>
+{{- range .Actions }}
+<tr><td>{{ .Time.Format "2006-01-02 15:04" }}</td><td>{{ .Actor }}</td><td>{{ .Action }}</td><td><a href="{{ .Target }}">{{ .Target }}</a></td></tr>
+{{- end }}
+</table>
+{{ end }}
+<h2>Pending</h2>
+<ul>
+{{- range .Pending }}
+<li><a href="{{ .URL }}">{{ .Title }}</a>, waiting {{ printf "%.0f" .CurrentHoldTime.Hours }} hours</li>
+{{- else }}
+<li>Nothing is awaiting a member response</li>
+{{- end }}
+</ul>
+{{ if gt .PendingTotal (len .Pending) }}<p>{{ .PendingTotal }} items in total are awaiting a member response.</p>{{ end }}
+</body>
+</html>`))
+
+// Markdown renders a report as Markdown
+func Markdown(rep *Report) ([]byte, error) {
+	var bs bytes.Buffer
+	err := markdownTmpl.Execute(&bs, rep)
+	return bs.Bytes(), err
+}
+
+// HTML renders a report as HTML
+func HTML(rep *Report) ([]byte, error) {
+	var bs bytes.Buffer
+	err := htmlTmpl.Execute(&bs, rep)
+	return bs.Bytes(), err
+}
+
+// HandoffMarkdown renders a handoff report as Markdown
+func HandoffMarkdown(h *Handoff) ([]byte, error) {
+	var bs bytes.Buffer
+	err := handoffMarkdownTmpl.Execute(&bs, h)
+	return bs.Bytes(), err
+}
+
+// HandoffHTML renders a handoff report as HTML
+func HandoffHTML(h *Handoff) ([]byte, error) {
+	var bs bytes.Buffer
+	err := handoffHTMLTmpl.Execute(&bs, h)
+	return bs.Bytes(), err
+}
+
+// days returns how many days ago a time was
+func days(t time.Time) string {
+	return fmt.Sprintf("%.0fd", time.Since(t).Hours()/24)
+}
+
+// signed formats a change with an explicit sign
+func signed(n int) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func date(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// escapeMarkdown escapes characters which would break Markdown tables and links
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", `\|`, "[", `\[`, "]", `\]`, "\n", " ").Replace(s)
+}
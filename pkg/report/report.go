@@ -0,0 +1,449 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report generates summaries of collections on a schedule
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/audit"
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/history"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/google/triage-party/pkg/updater"
+	"k8s.io/klog/v2"
+)
+
+// Sections which a report may include
+const (
+	Counts = "counts"
+	Trends = "trends"
+	Stale  = "stale"
+	SLA    = "sla"
+)
+
+var allSections = []string{Counts, Trends, Stale, SLA}
+
+const (
+	// defaultTop is how many stale items are listed by default
+	defaultTop = 10
+	// defaultTrend is the period counts are compared against by default
+	defaultTrend = 7 * 24 * time.Hour
+	// checkInterval is how often schedules are checked
+	checkInterval = 30 * time.Second
+)
+
+// Config is how to configure a new reporter
+type Config struct {
+	Party   *triage.Party
+	Updater *updater.Updater
+	// History is used for trends, and may be nil
+	History *history.Store
+	// Audit is used for rotation handoffs, and may be nil
+	Audit *audit.Log
+	// Mailer is used to e-mail reports, and may be nil
+	Mailer *Mailer
+	// Leader returns whether this replica publishes scheduled reports, when replicas share a cache. nil always publishes.
+	Leader func() bool
+}
+
+// Reporter generates reports
+type Reporter struct {
+	party   *triage.Party
+	updater *updater.Updater
+	history *history.Store
+	audit   *audit.Log
+	mailer  *Mailer
+	leader  func() bool
+	mu      sync.Mutex
+}
+
+// New returns a new reporter
+func New(cfg Config) *Reporter {
+	return &Reporter{
+		party:   cfg.Party,
+		updater: cfg.Updater,
+		history: cfg.History,
+		audit:   cfg.Audit,
+		mailer:  cfg.Mailer,
+		leader:  cfg.Leader,
+	}
+}
+
+// Report is a generated summary
+type Report struct {
+	ID      string
+	Name    string
+	Created time.Time
+
+	Sections map[string]bool
+
+	Rules []*RuleSummary
+	// TrendSince is the time which counts are compared against
+	TrendSince time.Time
+
+	// Stale are the least recently updated open items
+	Stale []*hubbub.Conversation
+
+	// SLA is how long items may wait for a member response
+	SLA        time.Duration
+	Open       int
+	WithinSLA  int
+	SLAPercent float64
+}
+
+// RuleSummary is the state of a rule, and how it has changed
+type RuleSummary struct {
+	Collection string
+	ID         string
+	Name       string
+	Count      int
+	// Previous is the count at the start of the trend period, if known
+	Previous    int
+	HasPrevious bool
+	Change      int
+}
+
+// options are parsed report settings
+type options struct {
+	triage.ReportSettings
+	schedule *schedule
+	trend    time.Duration
+	sla      time.Duration
+	sections map[string]bool
+}
+
+// parseOptions parses report settings, filling in defaults
+func parseOptions(rs triage.ReportSettings, defaultSLA time.Duration) (*options, error) {
+	if rs.ID == "" {
+		return nil, fmt.Errorf("report has no id")
+	}
+
+	loc := time.UTC
+	if rs.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(rs.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%s: timezone: %w", rs.ID, err)
+		}
+	}
+
+	s, err := parseSchedule(rs.Schedule, loc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: schedule: %w", rs.ID, err)
+	}
+
+	o := &options{ReportSettings: rs, schedule: s, trend: defaultTrend, sla: defaultSLA, sections: map[string]bool{}}
+	if o.Name == "" {
+		o.Name = o.ID
+	}
+	if o.Top == 0 {
+		o.Top = defaultTop
+	}
+
+	if rs.Trend != "" {
+		if o.trend, _, _ = hubbub.ParseDuration(rs.Trend); o.trend <= 0 {
+			return nil, fmt.Errorf("%s: invalid trend: %q", rs.ID, rs.Trend)
+		}
+	}
+	if rs.SLA != "" {
+		if o.sla, _, _ = hubbub.ParseDuration(rs.SLA); o.sla <= 0 {
+			return nil, fmt.Errorf("%s: invalid sla: %q", rs.ID, rs.SLA)
+		}
+	}
+
+	sections := rs.Sections
+	if len(sections) == 0 {
+		sections = allSections
+	}
+	for _, s := range sections {
+		known := false
+		for _, a := range allSections {
+			if s == a {
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("%s: unknown section %q, expected one of %v", rs.ID, s, allSections)
+		}
+		o.sections[s] = true
+	}
+	return o, nil
+}
+
+// Check returns an error if any report in a configuration is invalid
+func Check(tp *triage.Party) error {
+	cols, err := tp.ListCollections()
+	if err != nil {
+		return fmt.Errorf("collections: %w", err)
+	}
+	known := map[string]bool{}
+	for _, c := range cols {
+		known[c.ID] = true
+	}
+
+	seen := map[string]bool{}
+	for _, rs := range tp.Settings().Reports {
+		if _, err := parseOptions(rs, tp.FirstResponseSLO()); err != nil {
+			return err
+		}
+		if seen[rs.ID] {
+			return fmt.Errorf("%s: report id is used more than once", rs.ID)
+		}
+		seen[rs.ID] = true
+
+		for _, id := range rs.Collections {
+			if !known[id] {
+				return fmt.Errorf("%s: unknown collection %q", rs.ID, id)
+			}
+		}
+		if rs.Output == "" && len(rs.Email) == 0 {
+			return fmt.Errorf("%s: report has neither an output nor an e-mail recipient", rs.ID)
+		}
+	}
+
+	if rs := tp.Settings().Rotation; rs != nil {
+		for _, id := range rs.Collections {
+			if !known[id] {
+				return fmt.Errorf("rotation: unknown collection %q", id)
+			}
+		}
+	}
+	return nil
+}
+
+// lookup returns the options for a configured report
+func (r *Reporter) lookup(id string) (*options, error) {
+	for _, rs := range r.party.Settings().Reports {
+		if rs.ID == id {
+			return parseOptions(rs, r.party.FirstResponseSLO())
+		}
+	}
+	return nil, fmt.Errorf("no report with id %q", id)
+}
+
+// Run publishes reports whenever they are scheduled, until the context is cancelled
+func (r *Reporter) Run(ctx context.Context) {
+	last := time.Now()
+	shift := r.party.OnDuty(last)
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if r.leader != nil && !r.leader() {
+				shift = r.party.OnDuty(now)
+				last = now
+				continue
+			}
+
+			for _, rs := range r.party.Settings().Reports {
+				o, err := parseOptions(rs, r.party.FirstResponseSLO())
+				if err != nil {
+					klog.Errorf("report: %v", err)
+					continue
+				}
+
+				if next := o.schedule.next(last); next.IsZero() || next.After(now) {
+					continue
+				}
+
+				if err := r.publish(ctx, o); err != nil {
+					klog.Errorf("report %s: %v", o.ID, err)
+				}
+			}
+
+			cur := r.party.OnDuty(now)
+			if shift != nil && (cur == nil || !cur.Start.Equal(shift.Start)) {
+				if err := r.publishHandoff(ctx, shift, cur); err != nil {
+					klog.Errorf("handoff for %s: %v", shift.Member, err)
+				}
+			}
+			shift = cur
+			last = now
+		}
+	}
+}
+
+// Publish generates a report and delivers it to its output and recipients
+func (r *Reporter) Publish(ctx context.Context, id string) error {
+	o, err := r.lookup(id)
+	if err != nil {
+		return err
+	}
+	return r.publish(ctx, o)
+}
+
+func (r *Reporter) publish(ctx context.Context, o *options) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep, err := r.generate(ctx, o)
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+
+	md, err := Markdown(rep)
+	if err != nil {
+		return fmt.Errorf("markdown: %w", err)
+	}
+	html, err := HTML(rep)
+	if err != nil {
+		return fmt.Errorf("html: %w", err)
+	}
+
+	if o.Output != "" {
+		base := fmt.Sprintf("%s-%s", rep.ID, rep.Created.UTC().Format("20060102T150405Z"))
+		if err := write(o.Output, base, md, html); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+		klog.Infof("wrote report %s to %s", o.ID, o.Output)
+	}
+
+	if len(o.Email) > 0 {
+		if r.mailer == nil {
+			return fmt.Errorf("report has e-mail recipients, but no SMTP server is configured")
+		}
+		subject := fmt.Sprintf("%s: %s", rep.Name, rep.Created.Format("2006-01-02"))
+		if err := r.mailer.Send(o.Email, subject, string(md), string(html)); err != nil {
+			return fmt.Errorf("mail: %w", err)
+		}
+		klog.Infof("mailed report %s to %v", o.ID, o.Email)
+	}
+	return nil
+}
+
+// Generate generates a configured report
+func (r *Reporter) Generate(ctx context.Context, id string) (*Report, error) {
+	o, err := r.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.generate(ctx, o)
+}
+
+// collectionIDs returns the collections to summarize, defaulting to those which are not hidden
+func (r *Reporter) collectionIDs(ids []string) ([]string, error) {
+	if len(ids) > 0 {
+		return ids, nil
+	}
+
+	cols, err := r.party.ListCollections()
+	if err != nil {
+		return nil, fmt.Errorf("collections: %w", err)
+	}
+	for _, c := range cols {
+		if !c.Hidden {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *Reporter) generate(ctx context.Context, o *options) (*Report, error) {
+	ids, err := r.collectionIDs(o.Collections)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rep := &Report{
+		ID:         o.ID,
+		Name:       o.Name,
+		Created:    now,
+		Sections:   o.sections,
+		TrendSince: now.Add(-o.trend),
+		SLA:        o.sla,
+	}
+
+	seen := map[string]bool{}
+	items := []*hubbub.Conversation{}
+
+	for _, id := range ids {
+		cr := r.updater.Lookup(ctx, id, true)
+		if cr == nil {
+			return nil, fmt.Errorf("no results for collection %q", id)
+		}
+
+		for _, rr := range cr.RuleResults {
+			rs := &RuleSummary{Collection: cr.Collection.Name, ID: rr.Rule.ID, Name: rr.Rule.Name, Count: len(rr.Items)}
+			if r.history != nil {
+				if sn := r.history.At(rr.Rule.ID, rep.TrendSince); sn != nil {
+					rs.Previous = sn.Count
+					rs.HasPrevious = true
+					rs.Change = rs.Count - rs.Previous
+				}
+			}
+			rep.Rules = append(rep.Rules, rs)
+
+			for _, co := range rr.Items {
+				if !seen[co.URL] {
+					seen[co.URL] = true
+					items = append(items, co)
+				}
+			}
+		}
+	}
+
+	open := []*hubbub.Conversation{}
+	for _, co := range items {
+		if co.State == constants.OpenState || co.State == constants.OpenedState {
+			open = append(open, co)
+		}
+	}
+
+	sort.Slice(open, func(i, j int) bool { return open[i].Updated.Before(open[j].Updated) })
+	rep.Stale = open
+	if len(rep.Stale) > o.Top {
+		rep.Stale = rep.Stale[:o.Top]
+	}
+
+	rep.Open = len(open)
+	for _, co := range open {
+		if co.CurrentHoldTime <= o.sla {
+			rep.WithinSLA++
+		}
+	}
+	if rep.Open > 0 {
+		rep.SLAPercent = float64(rep.WithinSLA) / float64(rep.Open) * 100
+	}
+	return rep, nil
+}
+
+// write writes the Markdown and HTML renderings of a report into a directory, such as a mounted bucket
+func write(dir string, base string, md []byte, html []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	for ext, bs := range map[string][]byte{".md": md, ".html": html} {
+		if err := ioutil.WriteFile(filepath.Join(dir, base+ext), bytes.TrimSpace(bs), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
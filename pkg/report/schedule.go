@@ -0,0 +1,167 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule is a parsed five-field cron expression: minute, hour, day of month, month and day of week
+type schedule struct {
+	minute, hour, dom, month, dow map[int]bool
+	// domAny and dowAny track wildcards, as cron matches either day field when both are restricted
+	domAny, dowAny bool
+	loc            *time.Location
+}
+
+// fieldRange is the range of values a cron field may take
+type fieldRange struct {
+	name     string
+	min, max int
+}
+
+var fields = []fieldRange{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// parseSchedule parses a cron expression, evaluated within a location
+func parseSchedule(expr string, loc *time.Location) (*schedule, error) {
+	fs := strings.Fields(expr)
+	if len(fs) != len(fields) {
+		return nil, fmt.Errorf("%q: expected %d fields, got %d", expr, len(fields), len(fs))
+	}
+
+	sets := []map[int]bool{}
+	for i, f := range fs {
+		set, err := parseField(f, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %s: %w", expr, fields[i].name, err)
+		}
+		sets = append(sets, set)
+	}
+
+	// Both 0 and 7 mean Sunday
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return &schedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fs[2] == "*",
+		dowAny: fs[4] == "*",
+		loc:    loc,
+	}, nil
+}
+
+// parseField parses a comma-separated list of values, ranges and steps, such as 1-5 or */15
+func parseField(f string, r fieldRange) (map[int]bool, error) {
+	max := r.max
+	if r.name == "day of week" {
+		max = 7
+	}
+
+	set := map[int]bool{}
+	for _, part := range strings.Split(f, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i != -1 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = s
+			part = part[:i]
+		}
+
+		lo, hi := r.min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = v, v
+		}
+
+		if lo < r.min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is outside of %d-%d", part, r.min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// dayMatches returns whether a day matches the day of month and day of week fields
+func (s *schedule) dayMatches(t time.Time) bool {
+	dom := s.dom[t.Day()]
+	dow := s.dow[int(t.Weekday())]
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next returns the first time after t which the schedule matches, or the zero time if there is none within 5 years
+func (s *schedule) next(t time.Time) time.Time {
+	t = t.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.month[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.hour[t.Hour()] {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if !s.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scoring fetches priority scores for conversations from an external service
+package scoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/secret"
+)
+
+// Config is how to configure a new scoring client
+type Config struct {
+	// URL is an endpoint which accepts a conversation as JSON, and returns its score
+	URL string
+	// Token is an optional API token secret reference, sent as a bearer token
+	Token string
+}
+
+// Client fetches scores from an external service
+type Client struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// New returns a new scoring client
+func New(cfg Config) *Client {
+	return &Client{
+		url:    cfg.URL,
+		token:  cfg.Token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Score posts a conversation to the service, and returns the score and labels it responds with
+func (c *Client) Score(ctx context.Context, co *hubbub.Conversation) (*hubbub.Score, error) {
+	body, err := json.Marshal(co)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.token != "" {
+		token, err := secret.Read(ctx, c.token)
+		if err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rb, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("post %s: %s: %s", c.url, resp.Status, rb)
+	}
+
+	s := &hubbub.Score{}
+	if err := json.Unmarshal(rb, s); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	return s, nil
+}
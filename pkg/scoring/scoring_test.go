@@ -0,0 +1,54 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scoring
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		co := &hubbub.Conversation{}
+		if err := json.NewDecoder(r.Body).Decode(co); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if co.ID != 7 {
+			http.Error(w, "unknown item", http.StatusNotFound)
+			return
+		}
+
+		resp := map[string]interface{}{"score": 0.9, "labels": []string{"regression"}}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(Config{URL: srv.URL})
+	s, err := c.Score(context.Background(), &hubbub.Conversation{ID: 7})
+	assert.Nil(t, err)
+	assert.Equal(t, &hubbub.Score{Value: 0.9, Labels: []string{"regression"}}, s)
+
+	_, err = c.Score(context.Background(), &hubbub.Conversation{ID: 8})
+	assert.NotNil(t, err)
+}
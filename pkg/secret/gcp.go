@@ -0,0 +1,75 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+const gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// readGCP reads a secret version using application default credentials: https://cloud.google.com/secret-manager/docs/reference/rest/v1/projects.secrets.versions/access
+func readGCP(ctx context.Context, name string) (string, error) {
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	cl, err := google.DefaultClient(ctx, gcpScope)
+	if err != nil {
+		return "", fmt.Errorf("google credentials: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://secretmanager.googleapis.com/v1/"+name+":access", nil)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := cl.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("get: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secret manager returned %s: %s", resp.Status, body)
+	}
+
+	var sv struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &sv); err != nil {
+		return "", fmt.Errorf("unmarshal: %w", err)
+	}
+
+	bs, err := base64.StdEncoding.DecodeString(sv.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("decode: %w", err)
+	}
+	return string(bs), nil
+}
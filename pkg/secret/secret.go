@@ -0,0 +1,177 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secret reads secrets from files or external secret managers, re-reading them to pick up rotations
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// VaultScheme references a HashiCorp Vault secret, such as vault://secret/data/triage-party#github
+	VaultScheme = "vault://"
+	// GCPScheme references a Google Secret Manager secret, such as gcpsm://projects/p/secrets/github-token
+	GCPScheme = "gcpsm://"
+	// AWSScheme references an AWS Secrets Manager secret, such as awssm://triage-party/github-token
+	AWSScheme = "awssm://"
+	// FileScheme references a file, which is also assumed for references without a scheme
+	FileScheme = "file://"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// IsReference returns whether a string references a secret manager, rather than a file
+func IsReference(ref string) bool {
+	for _, s := range []string{VaultScheme, GCPScheme, AWSScheme} {
+		if strings.HasPrefix(ref, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read returns the current value of a secret. A #field suffix selects a field from a secret stored as JSON.
+func Read(ctx context.Context, ref string) (string, error) {
+	loc, field := ref, ""
+	if i := strings.LastIndex(ref, "#"); i != -1 && IsReference(ref) {
+		loc, field = ref[:i], ref[i+1:]
+	}
+
+	var v string
+	var err error
+
+	switch {
+	case strings.HasPrefix(loc, VaultScheme):
+		v, err = readVault(ctx, strings.TrimPrefix(loc, VaultScheme), field)
+		field = ""
+	case strings.HasPrefix(loc, GCPScheme):
+		v, err = readGCP(ctx, strings.TrimPrefix(loc, GCPScheme))
+	case strings.HasPrefix(loc, AWSScheme):
+		v, err = readAWS(ctx, strings.TrimPrefix(loc, AWSScheme))
+	default:
+		var bs []byte
+		bs, err = ioutil.ReadFile(strings.TrimPrefix(loc, FileScheme))
+		v = string(bs)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if field != "" {
+		v, err = jsonField(v, field)
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// jsonField returns a field from a JSON object
+func jsonField(s string, field string) (string, error) {
+	m := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return "", fmt.Errorf("unmarshal %q: %w", field, err)
+	}
+	v, ok := m[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found", field)
+	}
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q is a %T, not a string", field, v)
+	}
+	return str, nil
+}
+
+// Secret is a secret which is re-read periodically, so that rotations take effect without a restart
+type Secret struct {
+	ref   string
+	mu    sync.RWMutex
+	value string
+}
+
+// Static returns a secret which never changes
+func Static(value string) *Secret {
+	return &Secret{value: value}
+}
+
+// Watch reads a secret, and re-reads it every interval until the context is cancelled
+func Watch(ctx context.Context, ref string, interval time.Duration) (*Secret, error) {
+	v, err := Read(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", ref, err)
+	}
+
+	s := &Secret{ref: ref, value: v}
+	if interval > 0 {
+		go s.refresh(ctx, interval)
+	}
+	return s, nil
+}
+
+// refresh re-reads the secret every interval, keeping the previous value on failure
+func (s *Secret) refresh(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		v, err := Read(ctx, s.ref)
+		if err != nil {
+			klog.Errorf("re-read %s: %v", s.ref, err)
+			continue
+		}
+		if v == "" {
+			klog.Errorf("re-read %s: secret is empty, keeping the previous value", s.ref)
+			continue
+		}
+
+		s.mu.Lock()
+		if v != s.value {
+			klog.Infof("secret %s was rotated", s.ref)
+			s.value = v
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Value returns the current value of the secret
+func (s *Secret) Value() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
+
+// Token returns the secret as an OAuth2 token, so that a Secret may be used as an oauth2.TokenSource
+func (s *Secret) Token() (*oauth2.Token, error) {
+	// oauth2 caches tokens until they expire, so a short expiry makes rotations take effect
+	return &oauth2.Token{AccessToken: s.Value(), Expiry: time.Now().Add(time.Minute)}, nil
+}
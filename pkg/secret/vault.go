@@ -0,0 +1,146 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// VaultAddrEnvVar is the address of the Vault server
+	VaultAddrEnvVar = "VAULT_ADDR"
+	// VaultTokenEnvVar is a Vault token to authenticate with
+	VaultTokenEnvVar = "VAULT_TOKEN"
+	// VaultRoleEnvVar is a role to log in with using the Kubernetes auth method, when no token is set
+	VaultRoleEnvVar = "VAULT_ROLE"
+	// VaultNamespaceEnvVar is the Vault Enterprise namespace to use
+	VaultNamespaceEnvVar = "VAULT_NAMESPACE"
+
+	// serviceAccountTokenPath is where Kubernetes mounts the pod's service account token
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+type vaultResponse struct {
+	Data map[string]interface{} `json:"data"`
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// readVault reads a field from a KV secret: https://www.vaultproject.io/api-docs/secret/kv
+func readVault(ctx context.Context, path string, field string) (string, error) {
+	addr := strings.TrimSuffix(os.Getenv(VaultAddrEnvVar), "/")
+	if addr == "" {
+		return "", fmt.Errorf("%s is not set", VaultAddrEnvVar)
+	}
+
+	token, err := vaultToken(ctx, addr)
+	if err != nil {
+		return "", fmt.Errorf("vault login: %w", err)
+	}
+
+	vr, err := vaultDo(ctx, http.MethodGet, addr+"/v1/"+strings.TrimPrefix(path, "/"), token, nil)
+	if err != nil {
+		return "", err
+	}
+
+	data := vr.Data
+	// KV version 2 nests the secret within data.data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("vault secret %s has %d fields: select one with #field", path, len(data))
+		}
+		for k := range data {
+			field = k
+		}
+	}
+
+	v, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string field %q", path, field)
+	}
+	return v, nil
+}
+
+// vaultToken returns the configured token, or logs in using the Kubernetes auth method
+func vaultToken(ctx context.Context, addr string) (string, error) {
+	if t := os.Getenv(VaultTokenEnvVar); t != "" {
+		return t, nil
+	}
+
+	role := os.Getenv(VaultRoleEnvVar)
+	if role == "" {
+		return "", fmt.Errorf("neither %s nor %s is set", VaultTokenEnvVar, VaultRoleEnvVar)
+	}
+
+	jwt, err := ioutil.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("service account token: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{"role": role, "jwt": strings.TrimSpace(string(jwt))})
+	if err != nil {
+		return "", fmt.Errorf("marshal: %w", err)
+	}
+
+	vr, err := vaultDo(ctx, http.MethodPost, addr+"/v1/auth/kubernetes/login", "", body)
+	if err != nil {
+		return "", err
+	}
+	return vr.Auth.ClientToken, nil
+}
+
+// vaultDo makes a Vault API request
+func vaultDo(ctx context.Context, method string, url string, token string, body []byte) (*vaultResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if ns := os.Getenv(VaultNamespaceEnvVar); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	vr := &vaultResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(vr); err != nil {
+		return nil, fmt.Errorf("vault returned %s: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(vr.Errors, ", "))
+	}
+	return vr, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/apitoken"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/triage"
+)
+
+// secretHeaders are request headers which carry credentials, and must not be logged
+var secretHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// redactHeaders returns a copy of request headers which is safe to log
+func redactHeaders(hs http.Header) http.Header {
+	c := hs.Clone()
+	for _, k := range secretHeaders {
+		if _, ok := c[k]; ok {
+			c[k] = []string{"REDACTED"}
+		}
+	}
+	return c
+}
+
+// token returns the API token a request was made with. bad is true if a token was presented, but is not valid.
+func (h *Handlers) token(r *http.Request) (t *persist.APIToken, bad bool) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return nil, false
+	}
+
+	if h.tokens == nil {
+		return nil, true
+	}
+
+	t = h.tokens.Verify(strings.TrimPrefix(auth, "Bearer "))
+	return t, t == nil
+}
+
+// user returns the authenticated user making a request, or an empty string if anonymous
+func (h *Handlers) user(r *http.Request) string {
+	return h.identity(r).User
+}
+
+// identity returns who is making a request, as far as it has been authenticated
+func (h *Handlers) identity(r *http.Request) access.Identity {
+	if t, _ := h.token(r); t != nil {
+		return access.Identity{User: t.Owner}
+	}
+
+	if h.sso != nil {
+		if s := h.sso.Session(r); s != nil {
+			return access.Identity{User: s.User, IdPGroups: s.Groups}
+		}
+	}
+
+	if h.userHeader == "" {
+		return access.Identity{}
+	}
+	return access.Identity{User: r.Header.Get(h.userHeader)}
+}
+
+// allowed returns whether a request has a level of access to a collection, or the site if id is empty
+func (h *Handlers) allowed(r *http.Request, id string, l access.Level) bool {
+	if h.readOnly && l > access.View {
+		return false
+	}
+
+	if l == access.Act && !h.allowActions {
+		return false
+	}
+
+	t, bad := h.token(r)
+	if bad {
+		return false
+	}
+
+	if t != nil {
+		// Tokens can not administer the site, and only write tokens may act
+		if l == access.Admin || (l == access.Act && t.Scope != apitoken.WriteScope) {
+			return false
+		}
+	}
+
+	if h.access == nil {
+		return l == access.View
+	}
+	return h.access.Allowed(r.Context(), h.identity(r), id, l)
+}
+
+// visible returns the collections a request may view
+func (h *Handlers) visible(r *http.Request, cols []triage.Collection) []triage.Collection {
+	vs := []triage.Collection{}
+	for _, c := range cols {
+		if h.allowed(r, c.ID, access.View) {
+			vs = append(vs, c)
+		}
+	}
+	return vs
+}
+
+// latest returns the collections a request may view, or only the one with an ID, and their latest results
+func (h *Handlers) latest(r *http.Request, id string) ([]triage.Collection, []*triage.CollectionResult, error) {
+	cols, err := h.party.ListCollections()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	visible := []triage.Collection{}
+	for _, c := range h.visible(r, cols) {
+		if id == "" || c.ID == id {
+			visible = append(visible, c)
+		}
+	}
+
+	results := []*triage.CollectionResult{}
+	for _, c := range visible {
+		if cr := h.updater.Cached(c.ID); cr != nil {
+			results = append(results, cr)
+		}
+	}
+	return visible, results, nil
+}
+
+// inCollection returns an error if any URL is not an item within the last results of a collection
+func (h *Handlers) inCollection(id string, urls []string) error {
+	result := h.updater.Cached(id)
+	if result == nil {
+		return fmt.Errorf("no results for %q", id)
+	}
+
+	seen := map[string]bool{}
+	for _, rr := range result.RuleResults {
+		for _, co := range rr.Items {
+			seen[co.URL] = true
+		}
+	}
+
+	for _, u := range urls {
+		if !seen[u] {
+			return fmt.Errorf("%s is not within %q", u, id)
+		}
+	}
+	return nil
+}
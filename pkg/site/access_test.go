@@ -0,0 +1,36 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	hs := http.Header{}
+	hs.Set("Authorization", "Bearer tp_secret")
+	hs.Set("Cookie", "session=secret")
+	hs.Set("User-Agent", "curl")
+
+	got := redactHeaders(hs)
+	assert.Equal(t, "REDACTED", got.Get("Authorization"))
+	assert.Equal(t, "REDACTED", got.Get("Cookie"))
+	assert.Equal(t, "curl", got.Get("User-Agent"))
+	assert.Empty(t, got.Get("Proxy-Authorization"), "absent headers are not added")
+	assert.Equal(t, "Bearer tp_secret", hs.Get("Authorization"), "the request is not changed")
+}
@@ -0,0 +1,376 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/action"
+	"github.com/google/triage-party/pkg/audit"
+	"k8s.io/klog/v2"
+)
+
+// bulkRequest is the JSON body of a bulk action request
+type bulkRequest struct {
+	// Collection is the collection the items were selected from
+	Collection string   `json:"collection"`
+	URLs       []string `json:"urls"`
+	Add        []string `json:"add"`
+	Remove     []string `json:"remove"`
+	// Response is the ID of a canned response to post
+	Response string `json:"response"`
+	// Milestone is the title of a milestone to move items into
+	Milestone string `json:"milestone"`
+	// TriageState is the name of a triage state to move items into
+	TriageState string `json:"triage_state"`
+	// Reviewers to request. If empty, reviewers are resolved from CODEOWNERS.
+	Reviewers []string `json:"reviewers"`
+	// Lock locks (true) or unlocks (false) conversations, with an optional reason
+	Lock   bool   `json:"lock"`
+	Reason string `json:"reason"`
+	// Canonical is the item that others are closed as duplicates of
+	Canonical string `json:"canonical"`
+	// Confirm is the number of items the user confirmed changing, required for large bulk actions
+	Confirm int `json:"confirm"`
+}
+
+// ruleRequest is the JSON body of a request to act on all items within a rule
+type ruleRequest struct {
+	Collection string `json:"collection"`
+	Rule       string `json:"rule"`
+	// Confirm is the number of items the user confirmed changing, required for large rules
+	Confirm int `json:"confirm"`
+}
+
+// bulkResponse is the JSON response to a bulk action request
+type bulkResponse struct {
+	Results []action.Result `json:"results"`
+}
+
+// actionContext returns a context which attributes changes to the requesting user
+func (h *Handlers) actionContext(r *http.Request) context.Context {
+	return audit.WithActor(r.Context(), h.actor(r))
+}
+
+// actor returns who made a request, as far as it has been authenticated, or their address
+func (h *Handlers) actor(r *http.Request) string {
+	if t, _ := h.token(r); t != nil {
+		return fmt.Sprintf("%s (token %q)", t.Owner, t.Name)
+	}
+
+	return h.rateKey(r)
+}
+
+// rateKey returns who a request is rate limited as: its authenticated user, or else its address
+func (h *Handlers) rateKey(r *http.Request) string {
+	if u := h.user(r); u != "" {
+		return u
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// decodeBulk validates and decodes a bulk action request
+func (h *Handlers) decodeBulk(w http.ResponseWriter, r *http.Request) (*bulkRequest, bool) {
+	klog.Infof("%s %s: %v", r.Method, r.URL.Path, redactHeaders(r.Header))
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	if h.actions == nil || !h.allowActions {
+		http.Error(w, "actions are not enabled", http.StatusNotImplemented)
+		return nil, false
+	}
+
+	br := &bulkRequest{}
+	if err := json.NewDecoder(r.Body).Decode(br); err != nil {
+		http.Error(w, fmt.Sprintf("decode: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if len(br.URLs) == 0 {
+		http.Error(w, "no items selected", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if !h.allowed(r, br.Collection, access.Act) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, false
+	}
+
+	if err := h.inCollection(br.Collection, br.URLs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := h.limiter.confirmed(len(br.URLs), br.Confirm); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
+		return nil, false
+	}
+
+	if err := h.limiter.allow(h.rateKey(r), len(br.URLs)); err != nil {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return nil, false
+	}
+	return br, true
+}
+
+// writeBulk writes the results of a bulk action
+func writeBulk(w http.ResponseWriter, rs []action.Result, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(bulkResponse{Results: rs}); err != nil {
+		klog.Errorf("encode: %v", err)
+	}
+}
+
+// EditLabels adds or removes labels from a list of items
+func (h *Handlers) EditLabels() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		br, ok := h.decodeBulk(w, r)
+		if !ok {
+			return
+		}
+
+		rs, err := h.actions.EditLabels(h.actionContext(r), br.URLs, br.Add, br.Remove)
+		writeBulk(w, rs, err)
+	}
+}
+
+// SetMilestone moves a list of items into a milestone
+func (h *Handlers) SetMilestone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		br, ok := h.decodeBulk(w, r)
+		if !ok {
+			return
+		}
+
+		rs, err := h.actions.SetMilestone(h.actionContext(r), br.URLs, br.Milestone)
+		writeBulk(w, rs, err)
+	}
+}
+
+// SetTriageState moves a list of items into a triage state
+func (h *Handlers) SetTriageState() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		br, ok := h.decodeBulk(w, r)
+		if !ok {
+			return
+		}
+
+		rs, err := h.actions.SetTriageState(h.actionContext(r), br.URLs, br.TriageState)
+		writeBulk(w, rs, err)
+	}
+}
+
+// RequestReviewers requests reviews on a list of pull requests
+func (h *Handlers) RequestReviewers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		br, ok := h.decodeBulk(w, r)
+		if !ok {
+			return
+		}
+
+		rs, err := h.actions.RequestReviewers(h.actionContext(r), br.URLs, br.Reviewers)
+		writeBulk(w, rs, err)
+	}
+}
+
+// Lock locks or unlocks the conversation on a list of items
+func (h *Handlers) Lock() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		br, ok := h.decodeBulk(w, r)
+		if !ok {
+			return
+		}
+
+		rs, err := h.actions.SetLocked(h.actionContext(r), br.URLs, br.Lock, br.Reason)
+		writeBulk(w, rs, err)
+	}
+}
+
+// PostResponse posts a canned response to a list of items
+func (h *Handlers) PostResponse() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		br, ok := h.decodeBulk(w, r)
+		if !ok {
+			return
+		}
+
+		rs, err := h.actions.PostResponse(h.actionContext(r), br.URLs, br.Response)
+		writeBulk(w, rs, err)
+	}
+}
+
+// CloseAsDuplicate closes a list of items as duplicates of a canonical item
+func (h *Handlers) CloseAsDuplicate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		br, ok := h.decodeBulk(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.inCollection(br.Collection, []string{br.Canonical}); err != nil {
+			http.Error(w, fmt.Sprintf("canonical: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		rs, err := h.actions.CloseAsDuplicate(h.actionContext(r), br.URLs, br.Canonical)
+		writeBulk(w, rs, err)
+	}
+}
+
+// MarkNotSimilar marks a list of items as never similar to one another
+func (h *Handlers) MarkNotSimilar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		br, ok := h.decodeBulk(w, r)
+		if !ok {
+			return
+		}
+
+		if len(br.URLs) < 2 {
+			http.Error(w, "select at least two items", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.actions.MarkNotSimilar(h.actionContext(r), br.URLs); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rs := []action.Result{}
+		for _, u := range br.URLs {
+			rs = append(rs, action.Result{URL: u})
+		}
+		writeBulk(w, rs, nil)
+	}
+}
+
+// CreateJiraIssue creates a Jira issue from a conversation
+func (h *Handlers) CreateJiraIssue() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("%s %s: %v", r.Method, r.URL.Path, redactHeaders(r.Header))
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if h.actions == nil || !h.allowActions || !h.actions.JiraEnabled() {
+			http.Error(w, "jira is not configured", http.StatusNotImplemented)
+			return
+		}
+
+		url := r.FormValue("url")
+		collection := r.FormValue("collection")
+		if !h.allowed(r, collection, access.Act) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		if err := h.inCollection(collection, []string{url}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := h.limiter.allow(h.rateKey(r), 1); err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		ji, err := h.actions.CreateJiraIssue(h.actionContext(r), url)
+		if err != nil {
+			klog.Errorf("create jira issue for %q: %v", url, err)
+			// The Jira issue may exist even if we failed to comment on the conversation
+			if ji == nil {
+				http.Error(w, fmt.Sprintf("create jira issue: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ji); err != nil {
+			klog.Errorf("encode: %v", err)
+		}
+	}
+}
+
+// AutoAssign assigns unassigned items in a rule, according to its auto-assign policy
+func (h *Handlers) AutoAssign() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("%s %s: %v", r.Method, r.URL.Path, redactHeaders(r.Header))
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if h.actions == nil || !h.allowActions {
+			http.Error(w, "actions are not enabled", http.StatusNotImplemented)
+			return
+		}
+
+		rr := &ruleRequest{}
+		if err := json.NewDecoder(r.Body).Decode(rr); err != nil {
+			http.Error(w, fmt.Sprintf("decode: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if !h.allowed(r, rr.Collection, access.Act) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		result := h.updater.Cached(rr.Collection)
+		if result == nil {
+			http.Error(w, fmt.Sprintf("no results for %q", rr.Collection), http.StatusNotFound)
+			return
+		}
+
+		for _, o := range result.RuleResults {
+			if o.Rule.ID != rr.Rule {
+				continue
+			}
+			if err := h.limiter.confirmed(len(o.Items), rr.Confirm); err != nil {
+				http.Error(w, err.Error(), http.StatusPreconditionRequired)
+				return
+			}
+			if err := h.limiter.allow(h.rateKey(r), len(o.Items)); err != nil {
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			rs, err := h.actions.AutoAssign(h.actionContext(r), o.Rule, o.Items)
+			writeBulk(w, rs, err)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("rule %q not found in %q", rr.Rule, rr.Collection), http.StatusNotFound)
+	}
+}
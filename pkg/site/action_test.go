@@ -0,0 +1,66 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/triage-party/pkg/action"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateKey(t *testing.T) {
+	h := newTestHandlers(t, &Config{UserHeader: "X-Forwarded-User"})
+
+	r := httptest.NewRequest(http.MethodPost, "/action/labels", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "192.0.2.1", h.rateKey(r))
+
+	r.Header.Set("X-Forwarded-Email", "alice@example.com")
+	assert.Equal(t, "192.0.2.1", h.rateKey(r), "only the configured user header is trusted")
+
+	r.Header.Set("X-Forwarded-User", "alice")
+	assert.Equal(t, "alice", h.rateKey(r))
+}
+
+func TestAutoAssignLimits(t *testing.T) {
+	h := newTestHandlers(t, &Config{
+		UserHeader:   "X-Forwarded-User",
+		AllowActions: true,
+		RateLimits:   RateLimits{PerUser: 2, ConfirmAbove: 2},
+	})
+	h.actions = action.New(action.Config{Party: h.party, Cache: h.cache})
+	addIssues(t, h, 3)
+
+	assign := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/action/assign", strings.NewReader(body))
+		r.Header.Set("X-Forwarded-User", "alice")
+		w := httptest.NewRecorder()
+		h.AutoAssign()(w, r)
+		return w
+	}
+
+	w := assign(`{"collection": "public", "rule": "open"}`)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code, "assigning 3 items requires confirmation")
+
+	w = assign(`{"collection": "public", "rule": "open", "confirm": 2}`)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code, "confirmation must match the number of items")
+
+	w = assign(`{"collection": "public", "rule": "open", "confirm": 3}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "3 items is more than alice may change per minute")
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/google/triage-party/pkg/access"
+	"k8s.io/klog/v2"
+)
+
+// defaultAuditDays is how many days of the audit log are shown by default
+const defaultAuditDays = 7
+
+// maxAuditDays is the most days of the audit log which can be shown at once
+const maxAuditDays = 90
+
+// Audit shows changes made through Triage Party
+func (h *Handlers) Audit() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays": toDays,
+	}
+	t := template.Must(template.New("audit").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "audit.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		if h.audit == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !h.allowed(r, "", access.Admin) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		days, err := strconv.Atoi(r.URL.Query().Get("days"))
+		if err != nil || days < 1 {
+			days = defaultAuditDays
+		}
+		if days > maxAuditDays {
+			days = maxAuditDays
+		}
+
+		sts, err := h.party.ListCollections()
+		if err != nil {
+			klog.Errorf("collections: %v", err)
+		}
+
+		p := &Page{
+			Version:      VERSION,
+			Prefix:       h.prefix,
+			SiteName:     h.siteName,
+			Title:        "Audit log",
+			Collections:  h.visible(r, sts),
+			Status:       h.updater.Status(),
+			AuditEntries: h.audit.Entries(days),
+			AuditDays:    days,
+		}
+
+		if err := t.ExecuteTemplate(w, "base", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
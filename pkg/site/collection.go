@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/google/triage-party/pkg/access"
 	"k8s.io/klog/v2"
 )
 
@@ -45,7 +46,7 @@ func (h *Handlers) Collection() http.HandlerFunc {
 	))
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		klog.Infof("GET %s: %v", r.URL.Path, r.Header)
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
 
 		id := strings.TrimPrefix(r.URL.Path, "/s/")
 		playerChoices := []string{"Select a player"}
@@ -62,6 +63,11 @@ func (h *Handlers) Collection() http.HandlerFunc {
 			playerNums = append(playerNums, i+1)
 		}
 
+		if !h.allowed(r, id, access.View) {
+			http.NotFound(w, r)
+			return
+		}
+
 		p, err := h.collectionPage(r.Context(), id, isRefresh(r))
 		if err != nil {
 			http.Error(w, fmt.Sprintf("collection page for %q: %v", id, err), 500)
@@ -70,6 +76,12 @@ func (h *Handlers) Collection() http.HandlerFunc {
 			return
 		}
 
+		p.Collections = h.visible(r, p.Collections)
+		if !h.allowed(r, id, access.Act) {
+			p.ActionsEnabled = false
+			p.JiraEnabled = false
+		}
+
 		result := p.CollectionResult
 
 		if player > 0 && players > 1 {
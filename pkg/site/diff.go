@@ -0,0 +1,179 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// defaultDiffSince is how far back changes are shown by default
+const defaultDiffSince = "7d"
+
+// CollectionDiff is how the rules of a collection have changed since a previous time
+type CollectionDiff struct {
+	Since time.Time   `json:"since"`
+	Rules []*RuleDiff `json:"rules"`
+}
+
+// RuleDiff is how the items matching a rule have changed
+type RuleDiff struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Known is false if no snapshot of the rule exists for the previous time
+	Known bool `json:"known"`
+	// Entered are items which match now, but did not before
+	Entered []*DiffItem `json:"entered"`
+	// Resolved are items which no longer match because they were closed
+	Resolved []*DiffItem `json:"resolved"`
+	// Left are items which no longer match, but are still open
+	Left []*DiffItem `json:"left"`
+}
+
+// DiffItem is an item which entered or left a rule
+type DiffItem struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// Diff shows which conversations entered, left or were resolved within a collection since a previous time
+func (h *Handlers) Diff() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays": toDays,
+	}
+	t := template.Must(template.New("diff").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "diff.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		id := strings.TrimPrefix(r.URL.Path, "/diff/")
+		if h.history == nil || !h.allowed(r, id, access.View) {
+			http.NotFound(w, r)
+			return
+		}
+
+		sinceParam := r.URL.Query().Get("since")
+		if sinceParam == "" {
+			sinceParam = defaultDiffSince
+		}
+		since, err := parseSince(sinceParam, time.Now())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("since: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.collectionPage(r.Context(), id, false)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("collection page for %q: %v", id, err), http.StatusInternalServerError)
+			klog.Errorf("page: %v", err)
+			return
+		}
+
+		d := h.collectionDiff(p.CollectionResult.RuleResults, since)
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(d); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+			return
+		}
+
+		p.Collections = h.visible(r, p.Collections)
+		p.Title = fmt.Sprintf("%s: changes", p.Collection.Name)
+		p.Diff = d
+		p.DiffSince = sinceParam
+
+		if err := t.ExecuteTemplate(w, "base", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
+
+// parseSince parses a previous time: either a date, a timestamp, or a duration ago such as 7d
+func parseSince(s string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+
+	d, _, _ := hubbub.ParseDuration(s)
+	if d <= 0 {
+		return time.Time{}, fmt.Errorf("%q is neither a date nor a duration", s)
+	}
+	return now.Add(-d), nil
+}
+
+// collectionDiff compares rule results against their snapshots at a previous time
+func (h *Handlers) collectionDiff(rrs []*triage.RuleResult, since time.Time) *CollectionDiff {
+	d := &CollectionDiff{Since: since}
+
+	for _, rr := range rrs {
+		rd := &RuleDiff{ID: rr.Rule.ID, Name: rr.Rule.Name, Entered: []*DiffItem{}, Resolved: []*DiffItem{}, Left: []*DiffItem{}}
+		d.Rules = append(d.Rules, rd)
+
+		sn := h.history.At(rr.Rule.ID, since)
+		if sn == nil {
+			continue
+		}
+		rd.Known = true
+
+		before := map[string]bool{}
+		for _, url := range sn.Items {
+			before[url] = true
+		}
+
+		now := map[string]bool{}
+		for _, co := range rr.Items {
+			now[co.URL] = true
+			if !before[co.URL] {
+				rd.Entered = append(rd.Entered, &DiffItem{URL: co.URL, Title: co.Title})
+			}
+		}
+
+		for _, url := range sn.Items {
+			if now[url] {
+				continue
+			}
+			di := &DiffItem{URL: url, Title: url}
+			co := h.party.LookupConversation(url)
+			if co != nil {
+				di.Title = co.Title
+			}
+			if co != nil && co.State != constants.OpenState && co.State != constants.OpenedState {
+				rd.Resolved = append(rd.Resolved, di)
+			} else {
+				rd.Left = append(rd.Left, di)
+			}
+		}
+	}
+	return d
+}
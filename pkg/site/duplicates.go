@@ -0,0 +1,167 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/hubbub"
+	"k8s.io/klog/v2"
+)
+
+// Cluster is a group of conversations which are similar to one another
+type Cluster struct {
+	// Canonical is the suggested original, which is the oldest item
+	Canonical *ClusterItem   `json:"canonical"`
+	Items     []*ClusterItem `json:"items"`
+}
+
+// ClusterItem is a conversation within a cluster
+type ClusterItem struct {
+	URL     string    `json:"url"`
+	ID      int       `json:"id"`
+	Repo    string    `json:"repo"`
+	Title   string    `json:"title"`
+	Type    string    `json:"type"`
+	State   string    `json:"state"`
+	Created time.Time `json:"created"`
+}
+
+// Duplicates groups the conversations of a collection into similarity clusters, as HTML or as JSON if requested
+func (h *Handlers) Duplicates() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays":    toDays,
+		"roughTime": roughTime,
+	}
+	t := template.Must(template.New("duplicates").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "duplicates.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		id := strings.TrimPrefix(r.URL.Path, "/duplicates/")
+		if !h.allowed(r, id, access.View) {
+			http.NotFound(w, r)
+			return
+		}
+
+		p, err := h.collectionPage(r.Context(), id, false)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("collection page for %q: %v", id, err), http.StatusInternalServerError)
+			klog.Errorf("page: %v", err)
+			return
+		}
+
+		cs := clusters(uniqueItems(p.CollectionResult.RuleResults))
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(cs); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+			return
+		}
+
+		p.Collections = h.visible(r, p.Collections)
+		if !h.allowed(r, id, access.Act) {
+			p.ActionsEnabled = false
+		}
+		p.Title = fmt.Sprintf("%s: duplicates", p.Collection.Name)
+		p.Clusters = cs
+
+		if err := t.ExecuteTemplate(w, "base", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
+
+// clusters groups conversations which are linked by similarity, largest clusters first
+func clusters(items []*hubbub.Conversation) []*Cluster {
+	byURL := map[string]*hubbub.Conversation{}
+	for _, co := range items {
+		byURL[co.URL] = co
+	}
+
+	// Union-find over similarity links between the items
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(u string) string {
+		if parent[u] == "" || parent[u] == u {
+			return u
+		}
+		parent[u] = find(parent[u])
+		return parent[u]
+	}
+
+	for _, co := range items {
+		for _, s := range co.Similar {
+			if byURL[s.URL] == nil {
+				continue
+			}
+			a, b := find(co.URL), find(s.URL)
+			if a != b {
+				parent[a] = b
+			}
+		}
+	}
+
+	groups := map[string][]*hubbub.Conversation{}
+	roots := []string{}
+	for _, co := range items {
+		root := find(co.URL)
+		if groups[root] == nil {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], co)
+	}
+
+	cs := []*Cluster{}
+	for _, root := range roots {
+		g := groups[root]
+		if len(g) < 2 {
+			continue
+		}
+
+		sort.Slice(g, func(i, j int) bool { return g[i].Created.Before(g[j].Created) })
+		c := &Cluster{}
+		for _, co := range g {
+			c.Items = append(c.Items, &ClusterItem{
+				URL:     co.URL,
+				ID:      co.ID,
+				Repo:    co.Organization + "/" + co.Project,
+				Title:   co.Title,
+				Type:    co.Type,
+				State:   co.State,
+				Created: co.Created,
+			})
+		}
+		c.Canonical = c.Items[0]
+		cs = append(cs, c)
+	}
+
+	sort.SliceStable(cs, func(i, j int) bool { return len(cs[i].Items) > len(cs[j].Items) })
+	return cs
+}
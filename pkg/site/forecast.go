@@ -0,0 +1,94 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/triage-party/pkg/history"
+	"github.com/google/triage-party/pkg/triage"
+)
+
+// forecastWindow is how much history trends are fitted to
+const forecastWindow = 28 * 24 * time.Hour
+
+// stableWeeklyChange is the weekly change below which a rule is considered stable
+const stableWeeklyChange = 0.5
+
+// Forecast projects the size of a rule at current rates
+type Forecast struct {
+	Collection string `json:"collection"`
+	Rule       string `json:"rule"`
+	Name       string `json:"name"`
+	Current    int    `json:"current"`
+	// PerWeek is how many items the rule gains each week, negative when it is shrinking
+	PerWeek float64 `json:"per_week"`
+	// DoublesInDays is how long until the rule has twice as many items, if it is growing
+	DoublesInDays float64 `json:"doubles_in_days,omitempty"`
+	// ClearsInDays is how long until the rule is empty, if it is shrinking
+	ClearsInDays float64 `json:"clears_in_days,omitempty"`
+}
+
+// forecasts projects the size of the rules within a set of results
+func (h *Handlers) forecasts(results []*triage.CollectionResult) []*Forecast {
+	fs := []*Forecast{}
+	if h.history == nil {
+		return fs
+	}
+
+	now := time.Now()
+	for _, cr := range results {
+		for _, rr := range cr.RuleResults {
+			f := forecast(len(rr.Items), h.history.Trend(rr.Rule.ID, now.Add(-forecastWindow), now))
+			if f == nil {
+				continue
+			}
+			f.Collection = cr.Collection.ID
+			f.Rule = rr.Rule.ID
+			f.Name = rr.Rule.Name
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+// forecast projects a rule with a current number of items along a trend
+func forecast(current int, tr *history.Trend) *Forecast {
+	if tr == nil {
+		return nil
+	}
+
+	f := &Forecast{Current: current, PerWeek: tr.PerDay * 7}
+	if math.Abs(f.PerWeek) < stableWeeklyChange || current == 0 {
+		return f
+	}
+
+	if tr.PerDay > 0 {
+		f.DoublesInDays = float64(current) / tr.PerDay
+	} else {
+		f.ClearsInDays = float64(current) / -tr.PerDay
+	}
+	return f
+}
+
+// approxDays formats a number of days roughly, in days or weeks
+func approxDays(days float64) string {
+	if days < 14 {
+		return fmt.Sprintf("%.0f days", math.Max(days, 1))
+	}
+	return fmt.Sprintf("%.0f weeks", days/7)
+}
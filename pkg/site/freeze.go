@@ -0,0 +1,116 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"k8s.io/klog/v2"
+)
+
+// assetRe matches links to static assets, which templates refer to from the root rather than the prefix
+var assetRe = regexp.MustCompile(`(href|src)="/(static|third_party)/`)
+
+// Freeze renders each visible collection as static HTML and JSON files within dir, for hosting without a server
+func (h *Handlers) Freeze(dir string) ([]string, error) {
+	sts, err := h.party.ListCollections()
+	if err != nil {
+		return nil, fmt.Errorf("list collections: %w", err)
+	}
+
+	collection := h.Collection()
+	kanban := h.Kanban()
+	written := []string{}
+	first := ""
+
+	for _, s := range sts {
+		if s.Hidden {
+			continue
+		}
+		if first == "" {
+			first = s.ID
+		}
+
+		pages := map[string]http.HandlerFunc{"/s/" + s.ID: collection}
+		if s.Display == "kanban" {
+			pages["/k/"+s.ID] = kanban
+		}
+
+		for path, fn := range pages {
+			file := filepath.Join(dir, filepath.FromSlash(path), "index.html")
+			if err := h.freezePage(fn, path, file); err != nil {
+				return written, err
+			}
+			written = append(written, file)
+		}
+
+		result := h.updater.Cached(s.ID)
+		if result == nil {
+			return written, fmt.Errorf("no results for %q", s.ID)
+		}
+
+		bs, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return written, fmt.Errorf("encode %q: %w", s.ID, err)
+		}
+
+		file := filepath.Join(dir, "s", s.ID+".json")
+		if err := ioutil.WriteFile(file, bs, 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, file)
+	}
+
+	if first == "" {
+		return written, fmt.Errorf("no visible collections")
+	}
+
+	// Static hosts can not redirect, so the index page does so instead
+	target := html.EscapeString(fmt.Sprintf("%s/s/%s/", h.prefix, first))
+	index := fmt.Sprintf(`<!DOCTYPE html><meta http-equiv="refresh" content="0; url=%s"><a href="%s">%s</a>`, target, target, html.EscapeString(h.siteName))
+	file := filepath.Join(dir, "index.html")
+	if err := ioutil.WriteFile(file, []byte(index), 0o644); err != nil {
+		return written, err
+	}
+	return append(written, file), nil
+}
+
+// freezePage renders a page handler to a file
+func (h *Handlers) freezePage(fn http.HandlerFunc, path string, file string) error {
+	klog.Infof("freezing %s to %s", path, file)
+	rec := httptest.NewRecorder()
+	fn(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("%s: status %d: %s", path, rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.Bytes()
+	if h.prefix != "" {
+		body = assetRe.ReplaceAll(body, []byte(`$1="`+h.prefix+`/$2/`))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, body, 0o644)
+}
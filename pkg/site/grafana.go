@@ -0,0 +1,142 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// grafanaMetric is a single value exposed to Grafana
+type grafanaMetric struct {
+	Value float64
+	Time  time.Time
+}
+
+// grafanaQuery is the subset of a Grafana simple-json query we support
+type grafanaQuery struct {
+	Targets []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+// grafanaSeries is a Grafana simple-json timeseries response
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// Grafana implements the Grafana simple-json datasource API
+// https://grafana.com/grafana/plugins/grafana-simple-json-datasource
+func (h *Handlers) Grafana() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.V(1).Infof("%s %s", r.Method, r.URL.Path)
+
+		ms, err := h.grafanaMetrics(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("metrics: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		var resp interface{}
+		switch strings.TrimPrefix(r.URL.Path, "/grafana") {
+		case "", "/":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "/search":
+			names := []string{}
+			for k := range ms {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			resp = names
+		case "/query":
+			q := grafanaQuery{}
+			if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+				http.Error(w, fmt.Sprintf("decode: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			series := []grafanaSeries{}
+			for _, t := range q.Targets {
+				m, ok := ms[t.Target]
+				if !ok {
+					continue
+				}
+				series = append(series, grafanaSeries{
+					Target:     t.Target,
+					Datapoints: [][2]float64{{m.Value, float64(m.Time.UnixNano() / int64(time.Millisecond))}},
+				})
+			}
+			resp = series
+		case "/annotations":
+			resp = []interface{}{}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			klog.Errorf("encode: %v", err)
+		}
+	}
+}
+
+// grafanaMetrics returns the current value of all metrics visible to a request, keyed by name
+func (h *Handlers) grafanaMetrics(r *http.Request) (map[string]grafanaMetric, error) {
+	cols, err := h.party.ListCollections()
+	if err != nil {
+		return nil, fmt.Errorf("list collections: %w", err)
+	}
+
+	ms := map[string]grafanaMetric{}
+	for _, c := range h.visible(r, cols) {
+		r := h.updater.Cached(c.ID)
+		if r == nil || r.RuleResults == nil {
+			continue
+		}
+		collectionMetrics(ms, r)
+	}
+	return ms, nil
+}
+
+// collectionMetrics adds metrics for a collection and its rules
+func collectionMetrics(ms map[string]grafanaMetric, r *triage.CollectionResult) {
+	id := r.Collection.ID
+	add := func(name string, v float64) {
+		ms[name] = grafanaMetric{Value: v, Time: r.Created}
+	}
+
+	add(id, float64(r.Total))
+	add(id+":issues", float64(r.TotalIssues))
+	add(id+":prs", float64(r.TotalPullRequests))
+	add(id+":avg-age-days", r.AvgAge.Hours()/24)
+	add(id+":avg-wait-days", r.AvgCurrentHold.Hours()/24)
+
+	for _, rr := range r.RuleResults {
+		rid := id + "/" + rr.Rule.ID
+		add(rid, float64(len(rr.Items)))
+		add(rid+":avg-age-days", rr.AvgAge.Hours()/24)
+		add(rid+":avg-wait-days", rr.AvgCurrentHold.Hours()/24)
+	}
+}
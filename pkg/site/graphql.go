@@ -0,0 +1,616 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/google/triage-party/pkg/triage"
+	graphql "github.com/graph-gophers/graphql-go"
+	"k8s.io/klog/v2"
+)
+
+// graphqlSchema describes the data served by the GraphQL endpoint. Times are RFC 3339 strings.
+const graphqlSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+	# Collections the caller may view, or only the one with an ID
+	collections(id: String): [Collection!]!
+	# Conversations within the collections the caller may view, once each
+	conversations(collection: String, rule: String, filter: ConversationFilter, first: Int): [Conversation!]!
+	# Tags of the conversations within the collections the caller may view, most common first
+	tags(collection: String): [TagCount!]!
+	# Statistics across the collections the caller may view
+	stats(collection: String): Stats!
+}
+
+input ConversationFilter {
+	# Repository, as org/project
+	repo: String
+	# issue or pull_request
+	type: String
+	state: String
+	label: String
+	tag: String
+	author: String
+	assignee: String
+	milestone: String
+	triageState: String
+}
+
+type Collection {
+	id: String!
+	name: String!
+	description: String!
+	hidden: Boolean!
+	# Results are only present once the collection has been executed
+	updated: String
+	total: Int!
+	totalIssues: Int!
+	totalPullRequests: Int!
+	rules: [RuleResult!]!
+}
+
+type RuleResult {
+	rule: Rule!
+	total: Int!
+	avgAgeDays: Float!
+	conversations(filter: ConversationFilter, first: Int): [Conversation!]!
+}
+
+type Rule {
+	id: String!
+	name: String!
+	type: String!
+	resolution: String!
+	repos: [String!]!
+}
+
+type Conversation {
+	url: String!
+	id: Int!
+	title: String!
+	type: String!
+	state: String!
+	repo: String!
+	author: String!
+	assignees: [String!]!
+	labels: [String!]!
+	milestone: String!
+	tags: [Tag!]!
+	areas: [String!]!
+	reviewState: String!
+	triageState: String!
+	score: Float
+	created: String!
+	updated: String!
+	closed: String
+	commentsTotal: Int!
+	reactionsTotal: Int!
+}
+
+type Tag {
+	id: String!
+	description: String!
+}
+
+type TagCount {
+	tag: Tag!
+	count: Int!
+}
+
+type Stats {
+	firstResponseByRepo: [Distribution!]!
+	firstResponseByRule: [Distribution!]!
+	reviewLatencyByRepo: [ReviewLatency!]!
+	reviewLatencyByGroup: [ReviewLatency!]!
+}
+
+type Distribution {
+	name: String!
+	total: Int!
+	count: Int!
+	medianHours: Float!
+	p90Hours: Float!
+	meanHours: Float!
+}
+
+type ReviewLatency {
+	name: String!
+	firstReview: Distribution!
+	approvalToMerge: Distribution!
+}
+`
+
+// maxGraphQLDepth bounds how deeply queries may nest
+const maxGraphQLDepth = 8
+
+// graphqlRequestKey is the context key of the HTTP request a query was made with
+type graphqlRequestKey struct{}
+
+// graphqlRequest is the JSON body of a GraphQL request
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQL serves collections, rules, conversations, tags and statistics to GraphQL queries
+func (h *Handlers) GraphQL() http.HandlerFunc {
+	schema := graphql.MustParseSchema(graphqlSchema, &graphqlQuery{h: h}, graphql.MaxDepth(maxGraphQLDepth))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("%s %s: %v", r.Method, r.URL.Path, redactHeaders(r.Header))
+
+		if !h.allowed(r, "", access.View) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		gr := &graphqlRequest{}
+		switch r.Method {
+		case http.MethodGet:
+			gr.Query = r.URL.Query().Get("query")
+			gr.OperationName = r.URL.Query().Get("operationName")
+			if v := r.URL.Query().Get("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &gr.Variables); err != nil {
+					http.Error(w, fmt.Sprintf("variables: %v", err), http.StatusBadRequest)
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(gr); err != nil {
+				http.Error(w, fmt.Sprintf("decode: %v", err), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "GET or POST required", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), graphqlRequestKey{}, r)
+		resp := schema.Exec(ctx, gr.Query, gr.OperationName, gr.Variables)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			klog.Errorf("encode: %v", err)
+		}
+	}
+}
+
+// graphqlQuery resolves the root of GraphQL queries
+type graphqlQuery struct {
+	h *Handlers
+}
+
+// results returns the latest results of the collections a query may view, or only the one with an ID
+func (q *graphqlQuery) results(ctx context.Context, id *string) ([]triage.Collection, []*triage.CollectionResult, error) {
+	r, ok := ctx.Value(graphqlRequestKey{}).(*http.Request)
+	if !ok {
+		return nil, nil, fmt.Errorf("no request in context")
+	}
+
+	want := ""
+	if id != nil {
+		want = *id
+	}
+	return q.h.latest(r, want)
+}
+
+// Collections resolves the collections a query may view
+func (q *graphqlQuery) Collections(ctx context.Context, args struct{ ID *string }) ([]*graphqlCollection, error) {
+	cols, _, err := q.results(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	gcs := []*graphqlCollection{}
+	for _, c := range cols {
+		gcs = append(gcs, &graphqlCollection{c: c, cr: q.h.updater.Cached(c.ID)})
+	}
+	return gcs, nil
+}
+
+// Conversations resolves the conversations within the collections a query may view
+func (q *graphqlQuery) Conversations(ctx context.Context, args struct {
+	Collection *string
+	Rule       *string
+	Filter     *graphqlFilter
+	First      *int32
+}) ([]*graphqlConversation, error) {
+	_, results, err := q.results(ctx, args.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := []*hubbub.Conversation{}
+	seen := map[string]bool{}
+	for _, cr := range results {
+		for _, rr := range cr.RuleResults {
+			if args.Rule != nil && rr.Rule.ID != *args.Rule {
+				continue
+			}
+			for _, co := range rr.Items {
+				if !seen[co.URL] {
+					seen[co.URL] = true
+					cs = append(cs, co)
+				}
+			}
+		}
+	}
+	return filterConversations(cs, args.Filter, args.First), nil
+}
+
+// Tags resolves how many conversations within the collections a query may view have each tag
+func (q *graphqlQuery) Tags(ctx context.Context, args struct{ Collection *string }) ([]*graphqlTagCount, error) {
+	_, results, err := q.results(ctx, args.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[tag.Tag]int{}
+	seen := map[string]bool{}
+	for _, cr := range results {
+		for _, rr := range cr.RuleResults {
+			for _, co := range rr.Items {
+				if seen[co.URL] {
+					continue
+				}
+				seen[co.URL] = true
+				for t, ok := range co.Tags {
+					if ok {
+						counts[t]++
+					}
+				}
+			}
+		}
+	}
+
+	tcs := []*graphqlTagCount{}
+	for t, n := range counts {
+		tcs = append(tcs, &graphqlTagCount{t: t, n: n})
+	}
+	sort.Slice(tcs, func(i, j int) bool {
+		if tcs[i].n != tcs[j].n {
+			return tcs[i].n > tcs[j].n
+		}
+		return tcs[i].t.ID < tcs[j].t.ID
+	})
+	return tcs, nil
+}
+
+// Stats resolves statistics across the collections a query may view
+func (q *graphqlQuery) Stats(ctx context.Context, args struct{ Collection *string }) (*graphqlStats, error) {
+	_, results, err := q.results(ctx, args.Collection)
+	if err != nil {
+		return nil, err
+	}
+	return &graphqlStats{st: computeStats(results, q.h.party.MemberGroupsOf)}, nil
+}
+
+// graphqlFilter is the ConversationFilter input of GraphQL queries
+type graphqlFilter struct {
+	Repo        *string
+	Type        *string
+	State       *string
+	Label       *string
+	Tag         *string
+	Author      *string
+	Assignee    *string
+	Milestone   *string
+	TriageState *string
+}
+
+// matches returns whether a conversation matches every field set in the filter
+func (f *graphqlFilter) matches(co *hubbub.Conversation) bool {
+	if f == nil {
+		return true
+	}
+
+	eq := func(want *string, got string) bool {
+		return want == nil || strings.EqualFold(*want, got)
+	}
+
+	if !eq(f.Repo, co.Organization+"/"+co.Project) || !eq(f.Type, co.Type) || !eq(f.State, co.State) ||
+		!eq(f.Author, co.Author.GetLogin()) || !eq(f.Milestone, co.Milestone.GetTitle()) || !eq(f.TriageState, co.TriageState) {
+		return false
+	}
+
+	if f.Label != nil {
+		found := false
+		for _, l := range co.Labels {
+			if strings.EqualFold(l.GetName(), *f.Label) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.Assignee != nil {
+		found := false
+		for _, u := range co.Assignees {
+			if strings.EqualFold(u.GetLogin(), *f.Assignee) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.Tag != nil {
+		found := false
+		for t, ok := range co.Tags {
+			if ok && t.ID == *f.Tag {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterConversations returns the conversations matching a filter, up to first if set
+func filterConversations(cs []*hubbub.Conversation, f *graphqlFilter, first *int32) []*graphqlConversation {
+	gcs := []*graphqlConversation{}
+	for _, co := range cs {
+		if first != nil && len(gcs) >= int(*first) {
+			break
+		}
+		if f.matches(co) {
+			gcs = append(gcs, &graphqlConversation{co: co})
+		}
+	}
+	return gcs
+}
+
+// graphqlTime formats a time for GraphQL, or returns nil if it is unset
+func graphqlTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
+
+// graphqlCollection resolves a collection, and its latest results if it has been executed
+type graphqlCollection struct {
+	c  triage.Collection
+	cr *triage.CollectionResult
+}
+
+func (c *graphqlCollection) ID() string          { return c.c.ID }
+func (c *graphqlCollection) Name() string        { return c.c.Name }
+func (c *graphqlCollection) Description() string { return c.c.Description }
+func (c *graphqlCollection) Hidden() bool        { return c.c.Hidden }
+
+func (c *graphqlCollection) Updated() *string {
+	if c.cr == nil {
+		return nil
+	}
+	return graphqlTime(c.cr.Created)
+}
+
+func (c *graphqlCollection) Total() int32 {
+	if c.cr == nil {
+		return 0
+	}
+	return int32(c.cr.Total)
+}
+
+func (c *graphqlCollection) TotalIssues() int32 {
+	if c.cr == nil {
+		return 0
+	}
+	return int32(c.cr.TotalIssues)
+}
+
+func (c *graphqlCollection) TotalPullRequests() int32 {
+	if c.cr == nil {
+		return 0
+	}
+	return int32(c.cr.TotalPullRequests)
+}
+
+func (c *graphqlCollection) Rules() []*graphqlRuleResult {
+	rrs := []*graphqlRuleResult{}
+	if c.cr == nil {
+		return rrs
+	}
+	for _, rr := range c.cr.RuleResults {
+		rrs = append(rrs, &graphqlRuleResult{rr: rr})
+	}
+	return rrs
+}
+
+// graphqlRuleResult resolves the result of a rule within a collection
+type graphqlRuleResult struct {
+	rr *triage.RuleResult
+}
+
+func (r *graphqlRuleResult) Rule() *graphqlRule  { return &graphqlRule{r: r.rr.Rule} }
+func (r *graphqlRuleResult) Total() int32        { return int32(len(r.rr.Items)) }
+func (r *graphqlRuleResult) AvgAgeDays() float64 { return r.rr.AvgAge.Hours() / 24 }
+
+func (r *graphqlRuleResult) Conversations(args struct {
+	Filter *graphqlFilter
+	First  *int32
+}) []*graphqlConversation {
+	return filterConversations(r.rr.Items, args.Filter, args.First)
+}
+
+// graphqlRule resolves a rule
+type graphqlRule struct {
+	r triage.Rule
+}
+
+func (r *graphqlRule) ID() string         { return r.r.ID }
+func (r *graphqlRule) Name() string       { return r.r.Name }
+func (r *graphqlRule) Type() string       { return r.r.Type }
+func (r *graphqlRule) Resolution() string { return r.r.Resolution }
+func (r *graphqlRule) Repos() []string    { return append([]string{}, r.r.Repos...) }
+
+// graphqlConversation resolves a conversation
+type graphqlConversation struct {
+	co *hubbub.Conversation
+}
+
+func (c *graphqlConversation) URL() string           { return c.co.URL }
+func (c *graphqlConversation) ID() int32             { return int32(c.co.ID) }
+func (c *graphqlConversation) Title() string         { return c.co.Title }
+func (c *graphqlConversation) Type() string          { return c.co.Type }
+func (c *graphqlConversation) State() string         { return c.co.State }
+func (c *graphqlConversation) Repo() string          { return c.co.Organization + "/" + c.co.Project }
+func (c *graphqlConversation) Author() string        { return c.co.Author.GetLogin() }
+func (c *graphqlConversation) Milestone() string     { return c.co.Milestone.GetTitle() }
+func (c *graphqlConversation) ReviewState() string   { return c.co.ReviewState }
+func (c *graphqlConversation) TriageState() string   { return c.co.TriageState }
+func (c *graphqlConversation) Created() string       { return c.co.Created.Format(time.RFC3339) }
+func (c *graphqlConversation) Updated() string       { return c.co.Updated.Format(time.RFC3339) }
+func (c *graphqlConversation) Closed() *string       { return graphqlTime(c.co.ClosedAt) }
+func (c *graphqlConversation) CommentsTotal() int32  { return int32(c.co.CommentsTotal) }
+func (c *graphqlConversation) ReactionsTotal() int32 { return int32(c.co.ReactionsTotal) }
+func (c *graphqlConversation) Areas() []string       { return append([]string{}, c.co.Areas...) }
+
+func (c *graphqlConversation) Assignees() []string {
+	ls := []string{}
+	for _, u := range c.co.Assignees {
+		ls = append(ls, u.GetLogin())
+	}
+	return ls
+}
+
+func (c *graphqlConversation) Labels() []string {
+	ls := []string{}
+	for _, l := range c.co.Labels {
+		ls = append(ls, l.GetName())
+	}
+	return ls
+}
+
+func (c *graphqlConversation) Tags() []*graphqlTag {
+	ts := []*graphqlTag{}
+	for t, ok := range c.co.Tags {
+		if ok {
+			ts = append(ts, &graphqlTag{t: t})
+		}
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].t.ID < ts[j].t.ID })
+	return ts
+}
+
+func (c *graphqlConversation) Score() *float64 {
+	if c.co.Score == nil {
+		return nil
+	}
+	return &c.co.Score.Value
+}
+
+// graphqlTag resolves a tag
+type graphqlTag struct {
+	t tag.Tag
+}
+
+func (t *graphqlTag) ID() string          { return t.t.ID }
+func (t *graphqlTag) Description() string { return t.t.Desc }
+
+// graphqlTagCount resolves how many conversations have a tag
+type graphqlTagCount struct {
+	t tag.Tag
+	n int
+}
+
+func (t *graphqlTagCount) Tag() *graphqlTag { return &graphqlTag{t: t.t} }
+func (t *graphqlTagCount) Count() int32     { return int32(t.n) }
+
+// graphqlStats resolves statistics
+type graphqlStats struct {
+	st *Stats
+}
+
+func (s *graphqlStats) FirstResponseByRepo() []*graphqlDistribution {
+	return graphqlDistributions(s.st.FirstResponseByRepo)
+}
+
+func (s *graphqlStats) FirstResponseByRule() []*graphqlDistribution {
+	return graphqlDistributions(s.st.FirstResponseByRule)
+}
+
+func (s *graphqlStats) ReviewLatencyByRepo() []*graphqlReviewLatency {
+	return graphqlReviewLatencies(s.st.ReviewLatencyByRepo)
+}
+
+func (s *graphqlStats) ReviewLatencyByGroup() []*graphqlReviewLatency {
+	return graphqlReviewLatencies(s.st.ReviewLatencyByGroup)
+}
+
+// graphqlDistribution resolves a distribution of durations
+type graphqlDistribution struct {
+	d *Distribution
+}
+
+func graphqlDistributions(ds []*Distribution) []*graphqlDistribution {
+	gds := []*graphqlDistribution{}
+	for _, d := range ds {
+		gds = append(gds, &graphqlDistribution{d: d})
+	}
+	return gds
+}
+
+func (d *graphqlDistribution) Name() string         { return d.d.Name }
+func (d *graphqlDistribution) Total() int32         { return int32(d.d.Total) }
+func (d *graphqlDistribution) Count() int32         { return int32(d.d.Count) }
+func (d *graphqlDistribution) MedianHours() float64 { return d.d.MedianHours }
+func (d *graphqlDistribution) P90Hours() float64    { return d.d.P90Hours }
+func (d *graphqlDistribution) MeanHours() float64   { return d.d.MeanHours }
+
+// graphqlReviewLatency resolves the review latency of a repository or member group
+type graphqlReviewLatency struct {
+	l *ReviewLatency
+}
+
+func graphqlReviewLatencies(ls []*ReviewLatency) []*graphqlReviewLatency {
+	gls := []*graphqlReviewLatency{}
+	for _, l := range ls {
+		gls = append(gls, &graphqlReviewLatency{l: l})
+	}
+	return gls
+}
+
+func (l *graphqlReviewLatency) Name() string { return l.l.Name }
+
+func (l *graphqlReviewLatency) FirstReview() *graphqlDistribution {
+	return &graphqlDistribution{d: l.l.FirstReview}
+}
+
+func (l *graphqlReviewLatency) ApprovalToMerge() *graphqlDistribution {
+	return &graphqlDistribution{d: l.l.ApprovalToMerge}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// graphqlResponse is the JSON response to the queries of TestGraphQL
+type graphqlResponse struct {
+	Data struct {
+		Collections []struct {
+			ID    string `json:"id"`
+			Total int    `json:"total"`
+			Rules []struct {
+				Conversations []struct {
+					URL string `json:"url"`
+				} `json:"conversations"`
+			} `json:"rules"`
+		} `json:"collections"`
+		Conversations []struct {
+			URL string `json:"url"`
+		} `json:"conversations"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func TestGraphQL(t *testing.T) {
+	h := newTestHandlers(t, &Config{UserHeader: "X-Forwarded-User"})
+	addIssues(t, h, 3)
+	handler := h.GraphQL()
+
+	query := func(user string, q string) *graphqlResponse {
+		body, err := json.Marshal(graphqlRequest{Query: q})
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		if user != "" {
+			r.Header.Set("X-Forwarded-User", user)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		assert.Equal(t, http.StatusOK, w.Code, q)
+
+		resp := &graphqlResponse{}
+		if err := json.NewDecoder(w.Body).Decode(resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		assert.Empty(t, resp.Errors, q)
+		return resp
+	}
+
+	resp := query("", `{ collections { id total } }`)
+	if assert.Len(t, resp.Data.Collections, 1, "anonymous callers only see public collections") {
+		assert.Equal(t, "public", resp.Data.Collections[0].ID)
+		assert.Equal(t, 3, resp.Data.Collections[0].Total)
+	}
+
+	resp = query("alice", `{ collections { id } }`)
+	assert.Len(t, resp.Data.Collections, 2)
+
+	resp = query("", `{ collections(id: "private") { id } }`)
+	assert.Empty(t, resp.Data.Collections, "restricted collections are indistinguishable from missing ones")
+
+	resp = query("", `{ conversations(collection: "private") { url } }`)
+	assert.Empty(t, resp.Data.Conversations)
+
+	resp = query("alice", `{ conversations(collection: "private") { url } }`)
+	assert.Len(t, resp.Data.Conversations, 3)
+
+	resp = query("", `{ conversations(first: 2) { url } }`)
+	assert.Len(t, resp.Data.Conversations, 2)
+
+	resp = query("", `{ collections { rules { conversations(first: 1) { url } } } }`)
+	if assert.Len(t, resp.Data.Collections, 1) && assert.Len(t, resp.Data.Collections[0].Rules, 1) {
+		assert.Len(t, resp.Data.Collections[0].Rules[0].Conversations, 1)
+	}
+}
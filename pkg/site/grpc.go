@@ -0,0 +1,286 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/api"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/triage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"k8s.io/klog/v2"
+)
+
+// TenantMetadata is the gRPC metadata key selecting the tenant a call is for
+const TenantMetadata = "tenant"
+
+// GRPCServer serves the latest results of sites to gRPC calls
+type GRPCServer struct {
+	api.UnimplementedTriagePartyServer
+
+	sites map[string]*Handlers
+}
+
+// NewGRPCServer returns a gRPC server for sites, which calls select by their tenant name
+func NewGRPCServer(sites []*Handlers) *GRPCServer {
+	s := &GRPCServer{sites: map[string]*Handlers{}}
+	for _, h := range sites {
+		s.sites[strings.TrimPrefix(h.prefix, "/")] = h
+	}
+	return s
+}
+
+// site returns the site a call is for, and an HTTP request carrying the credentials of the call for access checks
+func (s *GRPCServer) site(ctx context.Context) (*Handlers, *http.Request, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	tenant := ""
+	if v := md.Get(TenantMetadata); len(v) > 0 {
+		tenant = v[0]
+	}
+
+	h, ok := s.sites[tenant]
+	if !ok {
+		return nil, nil, status.Errorf(codes.NotFound, "unknown tenant %q", tenant)
+	}
+
+	// Only API tokens authenticate calls: unlike requests, calls do not pass through the proxy which sets --user-header,
+	// so any other metadata is supplied by the caller and must not be trusted.
+	r := (&http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/"}, Header: http.Header{}}).WithContext(ctx)
+	for _, v := range md.Get("authorization") {
+		r.Header.Add("Authorization", v)
+	}
+
+	t, bad := h.token(r)
+	if bad {
+		return nil, nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if t == nil && h.sso != nil {
+		return nil, nil, status.Error(codes.Unauthenticated, "token required")
+	}
+
+	if !h.allowed(r, "", access.View) {
+		return nil, nil, status.Error(codes.PermissionDenied, "forbidden")
+	}
+	return h, r, nil
+}
+
+// ListCollections returns the collections the caller may view, without their conversations
+func (s *GRPCServer) ListCollections(ctx context.Context, req *api.ListCollectionsRequest) (*api.ListCollectionsResponse, error) {
+	h, r, err := s.site(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, _, err := h.latest(r, "")
+	if err != nil {
+		klog.Errorf("latest: %v", err)
+		return nil, status.Errorf(codes.Internal, "collections: %v", err)
+	}
+
+	resp := &api.ListCollectionsResponse{}
+	for _, c := range cols {
+		resp.Collections = append(resp.Collections, protoCollection(c, h.updater.Cached(c.ID), false))
+	}
+	return resp, nil
+}
+
+// GetCollection returns a collection, and the latest results of its rules
+func (s *GRPCServer) GetCollection(ctx context.Context, req *api.GetCollectionRequest) (*api.Collection, error) {
+	h, r, err := s.site(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	cols, _, err := h.latest(r, req.GetId())
+	if err != nil {
+		klog.Errorf("latest: %v", err)
+		return nil, status.Errorf(codes.Internal, "collections: %v", err)
+	}
+
+	// Collections which may not be viewed are indistinguishable from those which do not exist
+	if len(cols) == 0 {
+		return nil, status.Errorf(codes.NotFound, "collection %q not found", req.GetId())
+	}
+	return protoCollection(cols[0], h.updater.Cached(cols[0].ID), req.GetConversations()), nil
+}
+
+// ListConversations streams the conversations within the collections the caller may view, once each
+func (s *GRPCServer) ListConversations(req *api.ListConversationsRequest, stream api.TriageParty_ListConversationsServer) error {
+	h, r, err := s.site(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	_, results, err := h.latest(r, req.GetCollection())
+	if err != nil {
+		klog.Errorf("latest: %v", err)
+		return status.Errorf(codes.Internal, "collections: %v", err)
+	}
+
+	f := grpcFilter(req.GetFilter())
+	sent := 0
+	seen := map[string]bool{}
+	for _, cr := range results {
+		for _, rr := range cr.RuleResults {
+			if req.GetRule() != "" && rr.Rule.ID != req.GetRule() {
+				continue
+			}
+			for _, co := range rr.Items {
+				if seen[co.URL] || !f.matches(co) {
+					continue
+				}
+				seen[co.URL] = true
+
+				if req.GetLimit() > 0 && sent >= int(req.GetLimit()) {
+					return nil
+				}
+				if err := stream.Send(protoConversation(co)); err != nil {
+					return err
+				}
+				sent++
+			}
+		}
+	}
+	return nil
+}
+
+// grpcFilter returns the equivalent of a gRPC conversation filter, ignoring unset fields
+func grpcFilter(f *api.ConversationFilter) *graphqlFilter {
+	if f == nil {
+		return nil
+	}
+
+	set := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+
+	return &graphqlFilter{
+		Repo:        set(f.GetRepo()),
+		Type:        set(f.GetType()),
+		State:       set(f.GetState()),
+		Label:       set(f.GetLabel()),
+		Tag:         set(f.GetTag()),
+		Author:      set(f.GetAuthor()),
+		Assignee:    set(f.GetAssignee()),
+		Milestone:   set(f.GetMilestone()),
+		TriageState: set(f.GetTriageState()),
+	}
+}
+
+// protoTime converts a time, returning nil if it is unset
+func protoTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// protoCollection converts a collection, and its latest results if it has been executed
+func protoCollection(c triage.Collection, cr *triage.CollectionResult, conversations bool) *api.Collection {
+	pc := &api.Collection{
+		Id:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+		Hidden:      c.Hidden,
+	}
+	if cr == nil {
+		return pc
+	}
+
+	pc.Updated = protoTime(cr.Created)
+	pc.Total = int32(cr.Total)
+	pc.TotalIssues = int32(cr.TotalIssues)
+	pc.TotalPullRequests = int32(cr.TotalPullRequests)
+
+	for _, rr := range cr.RuleResults {
+		prr := &api.RuleResult{
+			Rule: &api.Rule{
+				Id:         rr.Rule.ID,
+				Name:       rr.Rule.Name,
+				Type:       rr.Rule.Type,
+				Resolution: rr.Rule.Resolution,
+				Repos:      append([]string{}, rr.Rule.Repos...),
+			},
+			Total:      int32(len(rr.Items)),
+			AvgAgeDays: rr.AvgAge.Hours() / 24,
+		}
+		if conversations {
+			for _, co := range rr.Items {
+				prr.Conversations = append(prr.Conversations, protoConversation(co))
+			}
+		}
+		pc.Rules = append(pc.Rules, prr)
+	}
+	return pc
+}
+
+// protoConversation converts a conversation
+func protoConversation(co *hubbub.Conversation) *api.Conversation {
+	pc := &api.Conversation{
+		Url:            co.URL,
+		Id:             int64(co.ID),
+		Title:          co.Title,
+		Type:           co.Type,
+		State:          co.State,
+		Repo:           co.Organization + "/" + co.Project,
+		Author:         co.Author.GetLogin(),
+		Milestone:      co.Milestone.GetTitle(),
+		Areas:          append([]string{}, co.Areas...),
+		ReviewState:    co.ReviewState,
+		TriageState:    co.TriageState,
+		Created:        protoTime(co.Created),
+		Updated:        protoTime(co.Updated),
+		Closed:         protoTime(co.ClosedAt),
+		CommentsTotal:  int32(co.CommentsTotal),
+		ReactionsTotal: int32(co.ReactionsTotal),
+	}
+
+	for _, u := range co.Assignees {
+		pc.Assignees = append(pc.Assignees, u.GetLogin())
+	}
+	for _, l := range co.Labels {
+		pc.Labels = append(pc.Labels, l.GetName())
+	}
+	for t, ok := range co.Tags {
+		if ok {
+			pc.Tags = append(pc.Tags, &api.Tag{Id: t.ID, Description: t.Desc})
+		}
+	}
+	sort.Slice(pc.Tags, func(i, j int) bool { return pc.Tags[i].Id < pc.Tags[j].Id })
+
+	if co.Score != nil {
+		pc.Score = wrapperspb.Double(co.Score.Value)
+	}
+	return pc
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/triage-party/pkg/api"
+	"github.com/google/triage-party/pkg/apitoken"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// listCollections returns the IDs of the collections a call with metadata may list
+func listCollections(s *GRPCServer, md ...string) ([]string, codes.Code) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(md...))
+	resp, err := s.ListCollections(ctx, &api.ListCollectionsRequest{})
+	if err != nil {
+		return nil, status.Code(err)
+	}
+
+	ids := []string{}
+	for _, c := range resp.GetCollections() {
+		ids = append(ids, c.GetId())
+	}
+	return ids, codes.OK
+}
+
+func TestGRPCAccess(t *testing.T) {
+	h := newTestHandlers(t, &Config{UserHeader: "X-Forwarded-User"})
+	s := NewGRPCServer([]*Handlers{h})
+
+	secret, _, err := h.tokens.Issue("bot", "alice", apitoken.ReadScope)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	ids, code := listCollections(s)
+	assert.Equal(t, codes.OK, code)
+	assert.Equal(t, []string{"public"}, ids, "anonymous calls see public collections")
+
+	ids, code = listCollections(s, "x-forwarded-user", "alice")
+	assert.Equal(t, codes.OK, code)
+	assert.Equal(t, []string{"public"}, ids, "the user header is not trusted from calls")
+
+	ids, code = listCollections(s, "authorization", "Bearer "+secret)
+	assert.Equal(t, codes.OK, code)
+	assert.Equal(t, []string{"public", "private"}, ids)
+
+	_, code = listCollections(s, "authorization", "Bearer tp_bogus")
+	assert.Equal(t, codes.Unauthenticated, code)
+
+	_, code = listCollections(s, TenantMetadata, "other")
+	assert.Equal(t, codes.NotFound, code)
+}
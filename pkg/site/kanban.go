@@ -24,6 +24,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/triage-party/pkg/access"
 	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/provider"
 
@@ -132,6 +133,11 @@ func (h *Handlers) Kanban() http.HandlerFunc {
 		id := strings.TrimPrefix(r.URL.Path, "/k/")
 		milestoneID := getInt(r.URL, "milestone", -1)
 
+		if !h.allowed(r, id, access.View) {
+			http.NotFound(w, r)
+			return
+		}
+
 		p, err := h.collectionPage(r.Context(), id, isRefresh(r))
 		if err != nil {
 			http.Error(w, fmt.Sprintf("collection page for %q: %v", id, err), 500)
@@ -139,6 +145,12 @@ func (h *Handlers) Kanban() http.HandlerFunc {
 			return
 		}
 
+		p.Collections = h.visible(r, p.Collections)
+		if !h.allowed(r, id, access.Act) {
+			p.ActionsEnabled = false
+			p.JiraEnabled = false
+		}
+
 		if p.CollectionResult.RuleResults != nil {
 			chosen, milestones := milestoneChoices(p.CollectionResult.RuleResults, milestoneID)
 			klog.Infof("milestones chosen: %d, choices: %+v", milestoneID, milestones)
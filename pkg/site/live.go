@@ -0,0 +1,82 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/access"
+	"k8s.io/klog/v2"
+)
+
+// liveKeepalive is how often a comment is sent to idle live streams, so that proxies do not close them
+const liveKeepalive = 30 * time.Second
+
+// Live streams changes to the results of a collection as server-sent events
+func (h *Handlers) Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		id := strings.TrimPrefix(r.URL.Path, "/live/")
+		if h.live == nil || !h.allowed(r, id, access.View) {
+			http.NotFound(w, r)
+			return
+		}
+
+		f, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		events, cancel := h.live.Subscribe(id)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		f.Flush()
+
+		tick := time.NewTicker(liveKeepalive)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-tick.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+				f.Flush()
+			case e := <-events:
+				bs, err := json.Marshal(e)
+				if err != nil {
+					klog.Errorf("encode: %v", err)
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: update\ndata: %s\n\n", bs); err != nil {
+					return
+				}
+				f.Flush()
+			}
+		}
+	}
+}
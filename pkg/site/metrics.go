@@ -0,0 +1,120 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// Metrics exposes rule and SLO metrics in the Prometheus text exposition format
+func (h *Handlers) Metrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.V(1).Infof("%s %s", r.Method, r.URL.Path)
+
+		cols, err := h.party.ListCollections()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("collections: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		results := []*triage.CollectionResult{}
+		for _, c := range h.visible(r, cols) {
+			if cr := h.updater.Cached(c.ID); cr != nil {
+				results = append(results, cr)
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		bw := bufio.NewWriter(w)
+		writeMetrics(bw, results, h.sloStatuses(results))
+		if err := bw.Flush(); err != nil {
+			klog.Errorf("flush: %v", err)
+		}
+	}
+}
+
+// metric is a Prometheus gauge
+type metric struct {
+	name string
+	help string
+	// samples are values by label set, in order
+	samples []sample
+}
+
+type sample struct {
+	labels string
+	value  float64
+}
+
+func (m *metric) add(value float64, kv ...string) {
+	ls := []string{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		ls = append(ls, fmt.Sprintf(`%s="%s"`, kv[i], escapeLabel(kv[i+1])))
+	}
+	m.samples = append(m.samples, sample{labels: strings.Join(ls, ","), value: value})
+}
+
+// escapeLabel escapes a label value for the Prometheus text format
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writeMetrics writes gauges for rule results and SLOs
+func writeMetrics(w *bufio.Writer, results []*triage.CollectionResult, slos []*SLOStatus) {
+	items := &metric{name: "triage_party_rule_items", help: "Items matching a rule"}
+	age := &metric{name: "triage_party_rule_avg_age_seconds", help: "Average age of items matching a rule"}
+	wait := &metric{name: "triage_party_rule_avg_wait_seconds", help: "Average time items matching a rule have waited for a member response"}
+
+	for _, cr := range results {
+		for _, rr := range cr.RuleResults {
+			labels := []string{"collection", cr.Collection.ID, "rule", rr.Rule.ID}
+			items.add(float64(len(rr.Items)), labels...)
+			age.add(rr.AvgAge.Seconds(), labels...)
+			wait.add(rr.AvgCurrentHold.Seconds(), labels...)
+		}
+	}
+
+	objective := &metric{name: "triage_party_slo_objective_ratio", help: "Share of items which should leave a rule within its SLO target"}
+	target := &metric{name: "triage_party_slo_target_seconds", help: "How long items may remain in a rule"}
+	attainment := &metric{name: "triage_party_slo_attainment_ratio", help: "Share of items which left a rule within its SLO target, over the SLO window"}
+	budget := &metric{name: "triage_party_slo_error_budget_remaining_ratio", help: "Share of the SLO error budget remaining, negative once overspent"}
+	bad := &metric{name: "triage_party_slo_bad_items", help: "Items which missed a rule's SLO target, over the SLO window"}
+
+	for _, s := range slos {
+		labels := []string{"collection", s.Collection, "rule", s.Rule}
+		objective.add(s.Objective/100, labels...)
+		target.add(s.TargetHours*3600, labels...)
+		attainment.add(s.Attainment/100, labels...)
+		budget.add(s.ErrorBudgetRemaining/100, labels...)
+		bad.add(float64(s.Bad), labels...)
+	}
+
+	for _, m := range []*metric{items, age, wait, objective, target, attainment, budget, bad} {
+		if len(m.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", m.name)
+		for _, s := range m.samples {
+			fmt.Fprintf(w, "%s{%s} %g\n", m.name, s.labels, s.value)
+		}
+	}
+}
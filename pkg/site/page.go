@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"html/template"
+	"strings"
 	"time"
 
 	"github.com/google/triage-party/pkg/hubbub"
@@ -73,6 +74,7 @@ func (h *Handlers) collectionPage(ctx context.Context, id string, refresh bool)
 	p := &Page{
 		ID:               s.ID,
 		Version:          VERSION,
+		Prefix:           h.prefix,
 		SiteName:         h.siteName,
 		Title:            s.Name,
 		Collection:       s,
@@ -84,6 +86,12 @@ func (h *Handlers) collectionPage(ctx context.Context, id string, refresh bool)
 		UniqueItems:      unique,
 		ResultAge:        time.Since(result.OldestInput),
 		Status:           h.updater.Status(),
+		JiraEnabled:      h.allowActions && h.actions != nil && h.actions.JiraEnabled(),
+		ActionsEnabled:   h.allowActions && h.actions != nil,
+		LiveUpdates:      h.live != nil,
+		ConfirmAbove:     h.limiter.limits.ConfirmAbove,
+		Responses:        h.party.ListResponses(),
+		TriageStates:     h.party.TriageStates(),
 	}
 
 	if result.RuleResults == nil {
@@ -93,6 +101,15 @@ func (h *Handlers) collectionPage(ctx context.Context, id string, refresh bool)
 		p.Stale = true
 	}
 
+	if ws := h.tokenWarnings(ctx); len(ws) > 0 {
+		p.addNotification(strings.Join(ws, "; "))
+	}
+
+	if host, until := h.party.PausedUntil(); !until.IsZero() {
+		p.addNotification(fmt.Sprintf("%s asked us to slow down: refreshes are paused until %s, so displayed data may be out of date.", host, until.Format("15:04:05 MST")))
+		p.Stale = true
+	}
+
 	if result.Collection != nil && result.Collection.Velocity != "" {
 		p.VelocityStats = h.updater.Lookup(ctx, result.Collection.Velocity, false)
 	} else {
@@ -128,3 +145,12 @@ func uniqueItems(results []*triage.RuleResult) []*hubbub.Conversation {
 	}
 	return items
 }
+
+// addNotification adds a plain text message to the notification of a page
+func (p *Page) addNotification(msg string) {
+	msg = template.HTMLEscapeString(msg)
+	if p.Notification != "" {
+		msg = string(p.Notification) + "<br>" + msg
+	}
+	p.Notification = template.HTML(msg)
+}
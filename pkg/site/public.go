@@ -0,0 +1,78 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"path/filepath"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// publicHealth returns backlog health for the repositories of collections which anonymous users may view, as the
+// public page is served to anyone
+func (h *Handlers) publicHealth(ctx context.Context) ([]*triage.RepoHealth, error) {
+	cols, err := h.party.ListCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*triage.CollectionResult{}
+	for _, c := range cols {
+		if h.access != nil && !h.access.Allowed(ctx, access.Identity{}, c.ID, access.View) {
+			continue
+		}
+		if cr := h.updater.Cached(c.ID); cr != nil {
+			results = append(results, cr)
+		}
+	}
+	return h.party.BacklogHealth(visibleConversations(results, h.party.Conversations()))
+}
+
+// Public shows anonymized backlog health, without revealing rules or collections.
+func (h *Handlers) Public() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays": toDays,
+	}
+	t := template.Must(template.New("public").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "public.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		hs, err := h.publicHealth(r.Context())
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		p := &Page{
+			Version:  VERSION,
+			SiteName: h.siteName,
+			Title:    "Backlog health",
+			Health:   hs,
+		}
+
+		if err := t.ExecuteTemplate(w, "public", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// publicConfig has a collection anyone may view, and one of another repository only alice may view
+const publicConfig = `
+settings:
+  name: test
+  public:
+    bug-label: bug
+collections:
+  - id: public
+    name: Public
+    rules: [open]
+  - id: private
+    name: Private
+    rules: [secret]
+    access:
+      view:
+        users: [alice]
+rules:
+  open:
+    name: Open
+    type: issue
+    repos: [https://github.com/org/repo]
+    filters:
+      - state: open
+  secret:
+    name: Secret
+    type: issue
+    repos: [https://github.com/org/secret]
+    filters:
+      - state: open
+`
+
+func TestPublicHealth(t *testing.T) {
+	h := newConfigHandlers(t, &Config{}, publicConfig)
+	addRepoIssues(t, h, "repo", 2)
+	addRepoIssues(t, h, "secret", 3)
+
+	hs, err := h.publicHealth(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, hs, 1, "repositories of restricted collections are not shown") {
+		assert.Equal(t, "org/repo", hs[0].Repo)
+		assert.Equal(t, 2, hs[0].OpenIssues)
+	}
+}
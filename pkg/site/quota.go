@@ -0,0 +1,194 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/quota"
+	"k8s.io/klog/v2"
+)
+
+// QuotaUsage is how provider rate limits have been consumed
+type QuotaUsage struct {
+	Hosts []string `json:"hosts"`
+	// Hours are the usage of each host by hour, newest first
+	Hours []*QuotaHour `json:"hours"`
+	// Collections summarize the requests made by the refreshes of each collection
+	Collections []*CollectionQuota `json:"collections"`
+	// Refreshes are the most recent collection refreshes, newest first
+	Refreshes []*quota.Refresh `json:"refreshes"`
+	// Max is the most used by any host within an hour, for scaling bars
+	Max int `json:"max"`
+}
+
+// QuotaHour is the usage of each host within an hour
+type QuotaHour struct {
+	Hour time.Time `json:"hour"`
+	// Usage is in the same order as the hosts, with empty usage for hosts which were not used
+	Usage []*quota.Usage `json:"usage"`
+}
+
+// CollectionQuota summarizes the requests made by the refreshes of a collection
+type CollectionQuota struct {
+	Collection string        `json:"collection"`
+	Refreshes  int           `json:"refreshes"`
+	Requests   int           `json:"requests"`
+	Mean       float64       `json:"mean"`
+	Max        int           `json:"max"`
+	Last       time.Time     `json:"last"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// quotaUsage arranges rate limit usage by hour, host and collection
+func quotaUsage(hourly []*quota.Usage, refreshes []*quota.Refresh) *QuotaUsage {
+	qu := &QuotaUsage{}
+
+	seen := map[string]bool{}
+	for _, u := range hourly {
+		if !seen[u.Host] {
+			seen[u.Host] = true
+			qu.Hosts = append(qu.Hosts, u.Host)
+		}
+	}
+	sort.Strings(qu.Hosts)
+
+	byHour := map[time.Time]map[string]*quota.Usage{}
+	for _, u := range hourly {
+		if byHour[u.Hour] == nil {
+			byHour[u.Hour] = map[string]*quota.Usage{}
+			qu.Hours = append(qu.Hours, &QuotaHour{Hour: u.Hour})
+		}
+		byHour[u.Hour][u.Host] = u
+		if used := usedQuota(u); used > qu.Max {
+			qu.Max = used
+		}
+	}
+	sort.Slice(qu.Hours, func(i, j int) bool { return qu.Hours[i].Hour.After(qu.Hours[j].Hour) })
+
+	for _, qh := range qu.Hours {
+		for _, host := range qu.Hosts {
+			u := byHour[qh.Hour][host]
+			if u == nil {
+				u = &quota.Usage{Host: host, Hour: qh.Hour}
+			}
+			qh.Usage = append(qh.Usage, u)
+		}
+	}
+
+	byCollection := map[string]*CollectionQuota{}
+	for _, r := range refreshes {
+		cq := byCollection[r.Collection]
+		if cq == nil {
+			cq = &CollectionQuota{Collection: r.Collection}
+			byCollection[r.Collection] = cq
+			qu.Collections = append(qu.Collections, cq)
+		}
+		n := r.Total()
+		cq.Refreshes++
+		cq.Requests += n
+		if n > cq.Max {
+			cq.Max = n
+		}
+		// refreshes are newest first
+		if cq.Last.IsZero() {
+			cq.Last = r.Started
+			cq.Duration = r.Duration
+		}
+	}
+	for _, cq := range qu.Collections {
+		cq.Mean = float64(cq.Requests) / float64(cq.Refreshes)
+	}
+	sort.SliceStable(qu.Collections, func(i, j int) bool { return qu.Collections[i].Requests > qu.Collections[j].Requests })
+
+	qu.Refreshes = refreshes
+	return qu
+}
+
+// usedQuota is how much of a rate limit was used: as consumed if the provider reports it, otherwise as requested
+func usedQuota(u *quota.Usage) int {
+	if u.Consumed > u.Requests {
+		return u.Consumed
+	}
+	return u.Requests
+}
+
+// barWidth styles a bar to be as wide as n is a part of max
+func barWidth(n int, max int) template.CSS {
+	if max == 0 {
+		return "width: 0%"
+	}
+	return template.CSS(fmt.Sprintf("width: %.0f%%", 100*float64(n)/float64(max)))
+}
+
+// RateLimits charts how provider rate limits have been consumed per hour, host and collection refresh
+func (h *Handlers) RateLimits() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"barWidth":      barWidth,
+		"usedQuota":     usedQuota,
+		"HumanDuration": humanDuration,
+	}
+	t := template.Must(template.New("quota").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "quota.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		if !h.allowed(r, "", access.Admin) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		q := h.party.Quota()
+		qu := quotaUsage(q.Hourly(), q.Refreshes())
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(qu); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+			return
+		}
+
+		sts, err := h.party.ListCollections()
+		if err != nil {
+			klog.Errorf("collections: %v", err)
+		}
+
+		p := &Page{
+			Version:     VERSION,
+			Prefix:      h.prefix,
+			SiteName:    h.siteName,
+			Title:       "Rate limits",
+			Collections: h.visible(r, sts),
+			Status:      h.updater.Status(),
+			Quota:       qu,
+		}
+
+		if err := t.ExecuteTemplate(w, "base", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimits restricts how many items may be changed through the site. Zero values are unlimited.
+type RateLimits struct {
+	// PerUser is how many items each user may change per minute
+	PerUser int
+	// Global is how many items all users may change per minute
+	Global int
+	// ConfirmAbove requires bulk actions on more items than this to be explicitly confirmed
+	ConfirmAbove int
+}
+
+// limiter enforces rate limits
+type limiter struct {
+	limits RateLimits
+	global *rate.Limiter
+
+	mu    sync.Mutex
+	users map[string]*rate.Limiter
+}
+
+func newLimiter(rl RateLimits) *limiter {
+	l := &limiter{limits: rl, users: map[string]*rate.Limiter{}}
+	if rl.Global > 0 {
+		l.global = perMinute(rl.Global)
+	}
+	return l
+}
+
+// perMinute returns a limiter allowing n events per minute, all at once if need be
+func perMinute(n int) *rate.Limiter {
+	return rate.NewLimiter(rate.Every(time.Minute/time.Duration(n)), n)
+}
+
+// confirmed returns an error if changing n items requires confirmation, and confirm does not match it
+func (l *limiter) confirmed(n int, confirm int) error {
+	if ca := l.limits.ConfirmAbove; ca > 0 && n > ca && confirm != n {
+		return fmt.Errorf("changing more than %d items requires confirmation", ca)
+	}
+	return nil
+}
+
+// allow returns an error if a user may not change n items now
+func (l *limiter) allow(user string, n int) error {
+	now := time.Now()
+
+	var ur *rate.Reservation
+	if l.limits.PerUser > 0 {
+		if n > l.limits.PerUser {
+			return fmt.Errorf("%d items is more than the limit of %d per minute", n, l.limits.PerUser)
+		}
+
+		l.mu.Lock()
+		ul, ok := l.users[user]
+		if !ok {
+			ul = perMinute(l.limits.PerUser)
+			l.users[user] = ul
+		}
+		l.mu.Unlock()
+
+		ur = ul.ReserveN(now, n)
+		if d := ur.DelayFrom(now); d > 0 {
+			ur.CancelAt(now)
+			return fmt.Errorf("%s may change %d items per minute: try again in %s", user, l.limits.PerUser, d.Round(time.Second))
+		}
+	}
+
+	if l.global != nil {
+		if n > l.limits.Global {
+			cancel(ur, now)
+			return fmt.Errorf("%d items is more than the limit of %d per minute", n, l.limits.Global)
+		}
+
+		gr := l.global.ReserveN(now, n)
+		if d := gr.DelayFrom(now); d > 0 {
+			gr.CancelAt(now)
+			cancel(ur, now)
+			return fmt.Errorf("too many changes are being made: try again in %s", d.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// cancel returns the tokens of a reservation, if one was made
+func cancel(r *rate.Reservation, now time.Time) {
+	if r != nil {
+		r.CancelAt(now)
+	}
+}
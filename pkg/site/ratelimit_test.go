@@ -0,0 +1,50 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterPerUser(t *testing.T) {
+	l := newLimiter(RateLimits{PerUser: 5})
+
+	assert.NoError(t, l.allow("alice", 3))
+	assert.Error(t, l.allow("alice", 3), "alice has 2 changes left this minute")
+	assert.NoError(t, l.allow("alice", 2))
+	assert.NoError(t, l.allow("bob", 5), "users are limited separately")
+	assert.Error(t, l.allow("carol", 6), "more than the limit is never allowed")
+}
+
+func TestLimiterGlobal(t *testing.T) {
+	l := newLimiter(RateLimits{PerUser: 5, Global: 8})
+
+	assert.NoError(t, l.allow("alice", 5))
+	assert.Error(t, l.allow("bob", 5), "only 3 changes are left across all users")
+	assert.NoError(t, l.allow("bob", 3), "rejected changes do not count against bob")
+}
+
+func TestLimiterConfirmed(t *testing.T) {
+	l := newLimiter(RateLimits{ConfirmAbove: 2})
+
+	assert.NoError(t, l.confirmed(2, 0))
+	assert.Error(t, l.confirmed(3, 0))
+	assert.Error(t, l.confirmed(3, 2))
+	assert.NoError(t, l.confirmed(3, 3))
+
+	assert.NoError(t, newLimiter(RateLimits{}).confirmed(100, 0), "confirmation is not required by default")
+}
@@ -0,0 +1,150 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// providerCheckInterval is how often providers are checked, so that frequent probes do not spend the rate limit
+const providerCheckInterval = time.Minute
+
+// Check is the outcome of a single readiness check
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// Readiness is the outcome of all readiness checks
+type Readiness struct {
+	Ready  bool     `json:"ready"`
+	Checks []*Check `json:"checks"`
+}
+
+// readiness caches the last provider checks
+type readiness struct {
+	mu        sync.Mutex
+	checked   time.Time
+	providers []*triage.ProviderStatus
+	err       error
+}
+
+// providers returns provider statuses, checking them again if the last check is too old
+func (h *Handlers) providers(ctx context.Context) ([]*triage.ProviderStatus, error) {
+	rd := h.readiness
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	if time.Since(rd.checked) > providerCheckInterval {
+		rd.providers, rd.err = h.party.CheckProviders(ctx)
+		rd.checked = time.Now()
+	}
+	return rd.providers, rd.err
+}
+
+// readinessChecks checks the providers, the persistence backend and the age of results
+func (h *Handlers) readinessChecks(ctx context.Context) *Readiness {
+	rd := &Readiness{Ready: true}
+	add := func(name string, ok bool, format string, args ...interface{}) {
+		rd.Checks = append(rd.Checks, &Check{Name: name, OK: ok, Detail: fmt.Sprintf(format, args...)})
+		if !ok {
+			rd.Ready = false
+		}
+	}
+
+	ps, err := h.providers(ctx)
+	if err != nil {
+		add("providers", false, "%v", err)
+	}
+	for _, p := range ps {
+		name := "provider " + p.Host
+		switch {
+		case p.Err != nil:
+			add(name, false, "%s: %v", p.Repo, p.Err)
+		case p.Rate.Limit > 0 && p.Rate.Remaining == 0:
+			add(name, false, "rate limit exhausted until %s", p.Rate.Reset.Format(time.RFC3339))
+		case p.Rate.Limit > 0:
+			add(name, true, "%d of %d requests remaining until %s", p.Rate.Remaining, p.Rate.Limit, p.Rate.Reset.Format(time.RFC3339))
+		default:
+			add(name, true, "reachable")
+		}
+	}
+
+	if h.cache != nil {
+		if err := h.cache.Ping(); err != nil {
+			add("persist", false, "%s: %v", h.cache, err)
+		} else {
+			add("persist", true, "%s", h.cache)
+		}
+	}
+
+	id, age, ok := h.updater.Stalest()
+	switch {
+	case !ok && id == "":
+		add("results", false, "no collections")
+	case !ok:
+		add("results", false, "%q has no results yet", id)
+	case h.readyAge > 0 && age > h.readyAge:
+		add("results", false, "%q results are %s old, older than %s", id, age.Round(time.Second), h.readyAge)
+	default:
+		add("results", true, "oldest results are %q, %s old", id, age.Round(time.Second))
+	}
+	return rd
+}
+
+// writeReadiness writes readiness as JSON, failing the request if not ready
+func writeReadiness(w http.ResponseWriter, rd *Readiness) {
+	w.Header().Set("Content-Type", "application/json")
+	if !rd.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(rd); err != nil {
+		klog.Errorf("encode: %v", err)
+	}
+}
+
+// Readyz returns whether the site is usable: providers are reachable with rate limit to spare, the persistence backend is reachable, and results are fresh
+func (h *Handlers) Readyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReadiness(w, h.readinessChecks(r.Context()))
+	}
+}
+
+// ReadyzAll returns whether every site is usable, naming each check after the site's prefix
+func ReadyzAll(hs []*Handlers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		all := &Readiness{Ready: true}
+		for _, h := range hs {
+			rd := h.readinessChecks(r.Context())
+			for _, c := range rd.Checks {
+				if h.prefix != "" {
+					c.Name = fmt.Sprintf("%s %s", h.prefix, c.Name)
+				}
+			}
+			all.Checks = append(all.Checks, rd.Checks...)
+			all.Ready = all.Ready && rd.Ready
+		}
+		writeReadiness(w, all)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// defaultReleaseDays is how far back the release view lists merged & closed items by default
+const defaultReleaseDays = 14
+
+// Release is the state of a milestone's items, for deciding whether it is ready to ship
+type Release struct {
+	Milestone *provider.Milestone `json:"milestone"`
+	// Blockers are open issues, and pull requests with changes requested
+	Blockers []*hubbub.Conversation `json:"blockers"`
+	// AwaitingReview are open pull requests waiting on a reviewer
+	AwaitingReview []*hubbub.Conversation `json:"awaiting_review"`
+	// ReadyToMerge are approved pull requests
+	ReadyToMerge []*hubbub.Conversation `json:"ready_to_merge"`
+	// Done are items merged or closed within Days
+	Done []*hubbub.Conversation `json:"done"`
+	Days int                    `json:"days"`
+}
+
+// releaseMilestones returns the milestones of a set of items, soonest due first
+func releaseMilestones(items []*hubbub.Conversation) []*provider.Milestone {
+	mmap := map[int]*provider.Milestone{}
+	for _, co := range items {
+		if co.Milestone.GetNumber() != 0 {
+			mmap[co.Milestone.GetNumber()] = co.Milestone
+		}
+	}
+
+	ms := []*provider.Milestone{}
+	for _, m := range mmap {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].GetDueOn().Before(ms[j].GetDueOn()) })
+	return ms
+}
+
+// releaseView sorts the items within a milestone by what they need before the release
+func releaseView(items []*hubbub.Conversation, m *provider.Milestone, days int) *Release {
+	rel := &Release{Milestone: m, Days: days}
+	since := time.Now().AddDate(0, 0, -days)
+
+	for _, co := range items {
+		if co.Milestone.GetNumber() != m.GetNumber() {
+			continue
+		}
+
+		if co.State != constants.OpenState && co.State != constants.OpenedState {
+			done := co.ClosedAt
+			if !co.Merged.IsZero() {
+				done = co.Merged
+			}
+			if done.After(since) {
+				rel.Done = append(rel.Done, co)
+			}
+			continue
+		}
+
+		if co.Type != hubbub.PullRequest {
+			rel.Blockers = append(rel.Blockers, co)
+			continue
+		}
+
+		switch co.ReviewState {
+		case hubbub.Approved:
+			rel.ReadyToMerge = append(rel.ReadyToMerge, co)
+		case hubbub.ChangesRequested:
+			rel.Blockers = append(rel.Blockers, co)
+		default:
+			rel.AwaitingReview = append(rel.AwaitingReview, co)
+		}
+	}
+
+	for _, cs := range [][]*hubbub.Conversation{rel.Blockers, rel.AwaitingReview, rel.ReadyToMerge} {
+		sort.Slice(cs, func(i, j int) bool { return cs[i].Created.Before(cs[j].Created) })
+	}
+	sort.Slice(rel.Done, func(i, j int) bool { return rel.Done[i].ClosedAt.After(rel.Done[j].ClosedAt) })
+	return rel
+}
+
+// Release shows the state of a milestone within a collection, as HTML or as JSON if requested
+func (h *Handlers) Release() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays":    toDays,
+		"RoughTime": roughTime,
+		"Avatar":    avatar,
+		"Class":     className,
+	}
+	t := template.Must(template.New("release").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "release.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		id := strings.TrimPrefix(r.URL.Path, "/release/")
+		if !h.allowed(r, id, access.View) {
+			http.NotFound(w, r)
+			return
+		}
+
+		p, err := h.collectionPage(r.Context(), id, isRefresh(r))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("collection page for %q: %v", id, err), http.StatusInternalServerError)
+			klog.Errorf("page: %v", err)
+			return
+		}
+
+		items := uniqueItems(p.CollectionResult.RuleResults)
+		ms := releaseMilestones(items)
+
+		var chosen *provider.Milestone
+		if n := getInt(r.URL, "milestone", 0); n > 0 {
+			for _, m := range ms {
+				if m.GetNumber() == n {
+					chosen = m
+				}
+			}
+		} else if chosen = currentMilestone(ms); chosen == nil && len(ms) > 0 {
+			chosen = ms[len(ms)-1]
+		}
+
+		var rel *Release
+		if chosen != nil {
+			rel = releaseView(items, chosen, getInt(r.URL, "days", defaultReleaseDays))
+		}
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(rel); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+			return
+		}
+
+		p.Collections = h.visible(r, p.Collections)
+		p.Title = fmt.Sprintf("%s: release", p.Collection.Name)
+		p.Release = rel
+		p.Milestone = chosen
+		p.SelectorVar = "milestone"
+		for _, m := range ms {
+			p.SelectorOptions = append(p.SelectorOptions, Choice{
+				Value:    m.GetNumber(),
+				Text:     fmt.Sprintf("%s (%s)", m.GetTitle(), m.GetDueOn().Format("2006-01-02")),
+				Selected: m == chosen,
+			})
+		}
+
+		if err := t.ExecuteTemplate(w, "base", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/triage-party/pkg/access"
+	"k8s.io/klog/v2"
+)
+
+// Reviewers suggests the least-loaded qualified reviewer for each unreviewed PR of a collection, as HTML or as JSON if requested
+func (h *Handlers) Reviewers() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays":    toDays,
+		"roughTime": roughTime,
+	}
+	t := template.Must(template.New("reviewers").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "reviewers.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		id := strings.TrimPrefix(r.URL.Path, "/reviewers/")
+		if !h.allowed(r, id, access.View) {
+			http.NotFound(w, r)
+			return
+		}
+
+		p, err := h.collectionPage(r.Context(), id, false)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("collection page for %q: %v", id, err), http.StatusInternalServerError)
+			klog.Errorf("page: %v", err)
+			return
+		}
+
+		ss := h.actions.SuggestReviewers(r.Context(), uniqueItems(p.CollectionResult.RuleResults))
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(ss); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+			return
+		}
+
+		p.Collections = h.visible(r, p.Collections)
+		if !h.allowed(r, id, access.Act) {
+			p.ActionsEnabled = false
+		}
+		p.Title = fmt.Sprintf("%s: reviewer suggestions", p.Collection.Name)
+		p.Suggestions = ss
+
+		if err := t.ExecuteTemplate(w, "base", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
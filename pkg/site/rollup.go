@@ -0,0 +1,102 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"path/filepath"
+
+	"github.com/google/triage-party/pkg/federation"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// Summary serves a JSON summary of the backlog, which federated instances roll up
+func (h *Handlers) Summary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.V(1).Infof("%s %s", r.Method, r.URL.Path)
+
+		cols, err := h.party.ListCollections()
+		if err != nil {
+			klog.Errorf("collections: %v", err)
+		}
+
+		results := []*triage.CollectionResult{}
+		for _, c := range h.visible(r, cols) {
+			if cr := h.updater.Cached(c.ID); cr != nil {
+				results = append(results, cr)
+			}
+		}
+
+		s := federation.Summarize(h.siteName, results, h.party.FirstResponseSLO())
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s); err != nil {
+			klog.Errorf("encode: %v", err)
+		}
+	}
+}
+
+// Rollup shows the combined backlog of federated instances, as HTML or as JSON if requested
+func (h *Handlers) Rollup() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays":    toDays,
+		"fromHours": fromHours,
+		"roughTime": roughTime,
+	}
+	t := template.Must(template.New("rollup").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "rollup.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		if h.federation == nil || len(h.party.Settings().Federation) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		ru := h.federation.Rollup()
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(ru); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+			return
+		}
+
+		cols, err := h.party.ListCollections()
+		if err != nil {
+			klog.Errorf("collections: %v", err)
+		}
+
+		p := &Page{
+			Version:     VERSION,
+			Prefix:      h.prefix,
+			SiteName:    h.siteName,
+			Title:       "Rollup",
+			Collections: h.visible(r, cols),
+			Status:      h.updater.Status(),
+			Rollup:      ru,
+		}
+
+		if err := t.ExecuteTemplate(w, "base", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/hubbub"
+	"k8s.io/klog/v2"
+)
+
+// SimilarItems is an item along with the items similar to it, most similar first
+type SimilarItems struct {
+	URL     string                        `json:"url"`
+	Title   string                        `json:"title"`
+	Similar []*hubbub.RelatedConversation `json:"similar"`
+}
+
+// Similar returns the similar items of a collection's items as JSON, or of a single item if ?url= is passed
+func (h *Handlers) Similar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		id := strings.TrimPrefix(r.URL.Path, "/similar/")
+		if !h.allowed(r, id, access.View) {
+			http.NotFound(w, r)
+			return
+		}
+
+		result := h.updater.Cached(id)
+		if result == nil {
+			http.Error(w, fmt.Sprintf("no results for %q", id), http.StatusServiceUnavailable)
+			return
+		}
+
+		url := r.URL.Query().Get("url")
+		sis := []*SimilarItems{}
+		for _, co := range uniqueItems(result.RuleResults) {
+			if url != "" && co.URL != url {
+				continue
+			}
+			if url == "" && len(co.Similar) == 0 {
+				continue
+			}
+
+			similar := co.Similar
+			if similar == nil {
+				similar = []*hubbub.RelatedConversation{}
+			}
+			sis = append(sis, &SimilarItems{URL: co.URL, Title: co.Title, Similar: similar})
+		}
+
+		if url != "" && len(sis) == 0 {
+			http.Error(w, fmt.Sprintf("%s is not within %q", url, id), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		var v interface{} = sis
+		if url != "" {
+			v = sis[0]
+		}
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			klog.Errorf("encode: %v", err)
+		}
+	}
+}
@@ -28,9 +28,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/triage-party/pkg/persist"
 	"github.com/google/triage-party/pkg/provider"
 
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/action"
+	"github.com/google/triage-party/pkg/apitoken"
+	"github.com/google/triage-party/pkg/audit"
+	"github.com/google/triage-party/pkg/federation"
+	"github.com/google/triage-party/pkg/history"
 	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/live"
+	"github.com/google/triage-party/pkg/sso"
 	"github.com/google/triage-party/pkg/triage"
 	"github.com/google/triage-party/pkg/updater"
 
@@ -76,27 +85,88 @@ type Config struct {
 	WarnAge       time.Duration
 	Updater       *updater.Updater
 	Party         *triage.Party
+	Actions       *action.Executor
+	Audit         *audit.Log
+	Access        *access.Checker
+	Tokens        *apitoken.Store
+	History       *history.Store
+	// Federation rolls up other instances, and may be nil
+	Federation *federation.Federation
+	SSO        *sso.Authenticator
+	// Live pushes changes to open collection pages, and may be nil
+	Live *live.Hub
+	// UserHeader is the request header an authenticating proxy sets to the user's login
+	UserHeader string
+	// Prefix is the path the site is served under, such as /frontend
+	Prefix string
+	// ReadOnly disables all write actions and administrative pages
+	ReadOnly bool
+	// AllowActions lets authenticated users change items through the site and API. Off by default, as changes are made with the instance's token.
+	AllowActions bool
+	// RateLimits restricts how many items users may change
+	RateLimits RateLimits
+	// Cache is checked for connectivity by the readiness endpoint, and may be nil
+	Cache persist.Cacher
+	// ReadyMaxAge is how old results may be before the readiness endpoint fails (0 to ignore)
+	ReadyMaxAge time.Duration
+	// TokenExpiryWarning is how long before a provider token expires to start warning about it
+	TokenExpiryWarning time.Duration
 }
 
 func New(c *Config) *Handlers {
 	return &Handlers{
-		baseDir:   c.BaseDirectory,
-		updater:   c.Updater,
-		party:     c.Party,
-		siteName:  c.Name,
-		warnAge:   c.WarnAge,
-		startTime: time.Now(),
+		baseDir:      c.BaseDirectory,
+		updater:      c.Updater,
+		party:        c.Party,
+		actions:      c.Actions,
+		audit:        c.Audit,
+		access:       c.Access,
+		tokens:       c.Tokens,
+		history:      c.History,
+		federation:   c.Federation,
+		live:         c.Live,
+		sso:          c.SSO,
+		userHeader:   c.UserHeader,
+		prefix:       c.Prefix,
+		readOnly:     c.ReadOnly,
+		allowActions: c.AllowActions,
+		limiter:      newLimiter(c.RateLimits),
+		cache:        c.Cache,
+		readyAge:     c.ReadyMaxAge,
+		readiness:    &readiness{},
+		tokenCheck:   &tokenCheck{warnBefore: c.TokenExpiryWarning},
+		siteName:     c.Name,
+		warnAge:      c.WarnAge,
+		startTime:    time.Now(),
 	}
 }
 
 // Handlers is a mix of config and client interfaces to connect with.
 type Handlers struct {
-	baseDir   string
-	updater   *updater.Updater
-	party     *triage.Party
-	siteName  string
-	warnAge   time.Duration
-	startTime time.Time
+	baseDir    string
+	updater    *updater.Updater
+	party      *triage.Party
+	actions    *action.Executor
+	audit      *audit.Log
+	access     *access.Checker
+	tokens     *apitoken.Store
+	history    *history.Store
+	federation *federation.Federation
+	live       *live.Hub
+	sso        *sso.Authenticator
+	userHeader string
+	prefix     string
+	readOnly   bool
+	// allowActions lets authenticated users change items
+	allowActions bool
+	limiter      *limiter
+	cache        persist.Cacher
+	readyAge     time.Duration
+	readiness    *readiness
+	tokenCheck   *tokenCheck
+	siteName     string
+	warnAge      time.Duration
+	startTime    time.Time
 }
 
 // Root redirects to leaderboard.
@@ -107,13 +177,20 @@ func (h *Handlers) Root() http.HandlerFunc {
 			klog.Errorf("collections: %v", err)
 			return
 		}
-		http.Redirect(w, r, fmt.Sprintf("/s/%s", sts[0].ID), http.StatusSeeOther)
+
+		sts = h.visible(r, sts)
+		if len(sts) == 0 {
+			http.Error(w, "no collections are visible to you", http.StatusForbidden)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("%s/s/%s", h.prefix, sts[0].ID), http.StatusSeeOther)
 	}
 }
 
 // Page are values that are passed into the renderer
 type Page struct {
 	Version      string
+	Prefix       string
 	SiteName     string
 	ID           string
 	Title        string
@@ -156,6 +233,39 @@ type Page struct {
 	VelocityStats *triage.CollectionResult
 	GetVars       string
 	Status        string
+
+	JiraEnabled    bool
+	ActionsEnabled bool
+	// LiveUpdates is whether the page should listen for changes to its collection
+	LiveUpdates bool
+	// ConfirmAbove is how many items a bulk action may change before it must be explicitly confirmed
+	ConfirmAbove int
+	Responses    []triage.Response
+	TriageStates []hubbub.TriageState
+
+	Health []*triage.RepoHealth
+
+	AuditEntries []*persist.AuditEntry
+	AuditDays    int
+
+	APITokens []*persist.APIToken
+
+	Stats *Stats
+
+	Quota *QuotaUsage
+
+	Diff      *CollectionDiff
+	DiffSince string
+
+	Rollup *federation.Rollup
+
+	Clusters []*Cluster
+
+	Release *Release
+
+	Suggestions []*action.Suggestion
+
+	User string
 }
 
 // Choice is a selector choice
@@ -307,6 +417,9 @@ func roughTime(t time.Time) string {
 }
 
 func avatar(u *provider.User) template.HTML {
+	if u == nil {
+		return ""
+	}
 	return template.HTML(fmt.Sprintf(`<a href="%s" title="%s"><img src="%s" width="20" height="20"></a>`, u.GetHTMLURL(), u.GetLogin(), u.GetAvatarURL()))
 }
 
@@ -343,7 +456,7 @@ func (h *Handlers) Healthz() http.HandlerFunc {
 // Threadz returns a threadz page
 func (h *Handlers) Threadz() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		klog.Infof("GET %s: %v", r.URL.Path, r.Header)
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
 		w.WriteHeader(http.StatusOK)
 		w.Write(stack())
 	}
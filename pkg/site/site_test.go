@@ -0,0 +1,113 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/access"
+	"github.com/google/triage-party/pkg/apitoken"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/google/triage-party/pkg/updater"
+)
+
+// testConfig has a collection anyone may view, and one only alice may view
+const testConfig = `
+settings:
+  name: test
+  repos: [https://github.com/org/repo]
+collections:
+  - id: public
+    name: Public
+    rules: [open]
+  - id: private
+    name: Private
+    rules: [open]
+    access:
+      view:
+        users: [alice]
+rules:
+  open:
+    name: Open
+    type: issue
+    filters:
+      - state: open
+    auto-assign:
+      members: [alice]
+`
+
+// newTestHandlers returns handlers for testConfig, completing c with an in-memory backend
+func newTestHandlers(t *testing.T, c *Config) *Handlers {
+	return newConfigHandlers(t, c, testConfig)
+}
+
+// newConfigHandlers returns handlers for a configuration, completing c with an in-memory backend
+func newConfigHandlers(t *testing.T, c *Config, config string) *Handlers {
+	cache, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("memory: %v", err)
+	}
+	if err := cache.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	p, err := triage.New(triage.Config{Cache: cache, GitHubToken: "unused"})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := p.Load(strings.NewReader(config)); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	c.Party = p
+	c.Cache = cache
+	c.Updater = updater.New(updater.Config{Party: p})
+	c.Access = access.New(access.Config{Party: p})
+	c.Tokens = apitoken.New(apitoken.Config{Cache: cache})
+	return New(c)
+}
+
+// addIssues caches n open issues in the repository of testConfig, and evaluates the collections against them
+func addIssues(t *testing.T, h *Handlers, n int) {
+	addRepoIssues(t, h, "repo", n)
+}
+
+// addRepoIssues caches n open issues in a project of the org organization, and evaluates the collections against them
+func addRepoIssues(t *testing.T, h *Handlers, project string, n int) {
+	open := "open"
+	login := "carol"
+	created := time.Now().Add(-24 * time.Hour)
+
+	is := []*provider.Issue{}
+	for i := 1; i <= n; i++ {
+		number := i
+		title := fmt.Sprintf("issue %d", i)
+		url := fmt.Sprintf("https://github.com/org/%s/issues/%d", project, i)
+		is = append(is, &provider.Issue{Number: &number, Title: &title, State: &open, URL: &url, HTMLURL: &url, User: &provider.User{Login: &login}, CreatedAt: &created, UpdatedAt: &created})
+	}
+
+	key := hubbub.RepoKey(provider.Repo{Organization: "org", Project: project}) + "-open-issues"
+	if err := h.cache.Set(key, &persist.Blob{Issues: is}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	h.updater.Reevaluate(context.Background())
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"time"
+
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// SLOStatus is the attainment of a rule's service level objective
+type SLOStatus struct {
+	Collection string `json:"collection"`
+	Rule       string `json:"rule"`
+	Name       string `json:"name"`
+
+	TargetHours float64 `json:"target_hours"`
+	// Objective is the percentage of items which should leave the rule within the target
+	Objective   float64 `json:"objective"`
+	WindowHours float64 `json:"window_hours"`
+
+	// Good items left the rule within the target
+	Good int `json:"good"`
+	// Bad items left after the target, or have remained for longer than it
+	Bad int `json:"bad"`
+	// Pending items are still in the rule, but are within the target
+	Pending int `json:"pending"`
+
+	// Attainment is the percentage of good items
+	Attainment float64 `json:"attainment"`
+	// ErrorBudgetRemaining is the percentage of the error budget left, which is negative once it has been overspent
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+}
+
+// sloStatuses evaluates the SLOs of the rules within a set of results
+func (h *Handlers) sloStatuses(results []*triage.CollectionResult) []*SLOStatus {
+	ss := []*SLOStatus{}
+	if h.history == nil {
+		return ss
+	}
+
+	now := time.Now()
+	for _, cr := range results {
+		for _, rr := range cr.RuleResults {
+			if rr.Rule.SLO == nil {
+				continue
+			}
+
+			target, objective, window, err := rr.Rule.SLO.Parse()
+			if err != nil {
+				klog.Errorf("rule %q slo: %v", rr.Rule.ID, err)
+				continue
+			}
+
+			left, staying := h.history.Stays(rr.Rule.ID, now.Add(-window), now)
+			s := evaluateSLO(left, staying, target, objective)
+			s.Collection = cr.Collection.ID
+			s.Rule = rr.Rule.ID
+			s.Name = rr.Rule.Name
+			s.WindowHours = window.Hours()
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// evaluateSLO computes attainment and the remaining error budget from how long items stayed in a rule
+func evaluateSLO(left []time.Duration, staying []time.Duration, target time.Duration, objective float64) *SLOStatus {
+	s := &SLOStatus{TargetHours: target.Hours(), Objective: objective}
+
+	for _, d := range left {
+		if d <= target {
+			s.Good++
+		} else {
+			s.Bad++
+		}
+	}
+	for _, d := range staying {
+		if d <= target {
+			s.Pending++
+		} else {
+			s.Bad++
+		}
+	}
+
+	s.Attainment = 100
+	s.ErrorBudgetRemaining = 100
+
+	total := s.Good + s.Bad
+	if total == 0 {
+		return s
+	}
+
+	s.Attainment = float64(s.Good) / float64(total) * 100
+	allowed := float64(total) * (100 - objective) / 100
+	s.ErrorBudgetRemaining = (1 - float64(s.Bad)/allowed) * 100
+	return s
+}
@@ -0,0 +1,600 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// Stats are statistics computed across the rules visible to a user
+type Stats struct {
+	// FirstResponseByRepo is the time to first response, per repository
+	FirstResponseByRepo []*Distribution `json:"first_response_by_repo"`
+	// FirstResponseByRule is the time to first response, per collection/rule
+	FirstResponseByRule []*Distribution `json:"first_response_by_rule"`
+	// ReviewLatencyByRepo is the review latency of pull requests, per repository
+	ReviewLatencyByRepo []*ReviewLatency `json:"review_latency_by_repo"`
+	// ReviewLatencyByGroup is the review latency of pull requests, per member group of the reviewer
+	ReviewLatencyByGroup []*ReviewLatency `json:"review_latency_by_group"`
+	// Contributors are new and returning contributors, per month
+	Contributors []*ContributorMonth `json:"contributors"`
+	// Newcomers is how quickly first-time contributors hear back
+	Newcomers *NewcomerResponse `json:"newcomers"`
+	// LabelAging is the age distribution of open conversations, per label
+	LabelAging *LabelAging `json:"label_aging"`
+	// Velocity is how many conversations were opened and closed each week, per repository
+	Velocity []*RepoVelocity `json:"velocity"`
+	// TimeInRule is how long items stayed in each collection/rule before leaving it
+	TimeInRule []*Distribution `json:"time_in_rule"`
+	// SLOs are the service level objectives of rules
+	SLOs []*SLOStatus `json:"slos"`
+	// Forecasts project the size of rules at current rates
+	Forecasts []*Forecast `json:"forecasts"`
+}
+
+// RepoVelocity is the weekly open and close rate of a repository
+type RepoVelocity struct {
+	Repo  string      `json:"repo"`
+	Weeks []*WeekRate `json:"weeks"`
+}
+
+// WeekRate counts the conversations opened and closed within a week
+type WeekRate struct {
+	// Start is the Monday the week begins on, in UTC
+	Start  string `json:"start"`
+	Opened int    `json:"opened"`
+	Closed int    `json:"closed"`
+	// Net is how much the backlog grew
+	Net int `json:"net"`
+}
+
+// LabelAging buckets open conversations by label and age
+type LabelAging struct {
+	Buckets []string    `json:"buckets"`
+	Labels  []*LabelAge `json:"labels"`
+	// Max is the largest count in any bucket, for shading
+	Max int `json:"max"`
+}
+
+// LabelAge counts the open conversations with a label in each age bucket
+type LabelAge struct {
+	Label  string `json:"label"`
+	Counts []int  `json:"counts"`
+	Total  int    `json:"total"`
+}
+
+// ageBuckets are the upper bounds of the label aging buckets, with anything older falling into a final bucket
+var ageBuckets = []struct {
+	name string
+	max  time.Duration
+}{
+	{"<1w", 7 * 24 * time.Hour},
+	{"<1m", 30 * 24 * time.Hour},
+	{"<3m", 90 * 24 * time.Hour},
+}
+
+// defaultVelocityWeeks and maxVelocityWeeks bound how many weeks of velocity are returned
+const (
+	defaultVelocityWeeks = 12
+	maxVelocityWeeks     = 104
+)
+
+// timeInRuleWindow is how far back snapshots are examined for the time items spend in rules
+const timeInRuleWindow = 30 * 24 * time.Hour
+
+// maxAgingLabels is how many labels are shown in the label aging heatmap
+const maxAgingLabels = 50
+
+// ContributorMonth counts the non-members who opened issues or pull requests in a month
+type ContributorMonth struct {
+	Month string `json:"month"`
+	// New contributors had not opened anything before this month
+	New       int `json:"new"`
+	Returning int `json:"returning"`
+}
+
+// NewcomerResponse measures responses to the first pull request of each contributor
+type NewcomerResponse struct {
+	SLOHours float64 `json:"slo_hours"`
+	// PullRequests excludes those which are still within the SLO without a response
+	PullRequests int     `json:"pull_requests"`
+	WithinSLO    int     `json:"within_slo"`
+	Ratio        float64 `json:"ratio"`
+}
+
+// maxContributorMonths is how many months of contributor statistics are shown
+const maxContributorMonths = 12
+
+// ReviewLatency summarizes how long pull requests wait on reviewers
+type ReviewLatency struct {
+	Name string `json:"name"`
+	// FirstReview is the time from a review being requested to the first review
+	FirstReview *Distribution `json:"first_review"`
+	// ApprovalToMerge is the time from the final approval to merge
+	ApprovalToMerge *Distribution `json:"approval_to_merge"`
+}
+
+// noGroup is how reviewers which are not in any member group are grouped
+const noGroup = "(no group)"
+
+// Distribution summarizes a set of durations
+type Distribution struct {
+	Name string `json:"name"`
+	// Total is how many items were considered
+	Total int `json:"total"`
+	// Count is how many items have a duration, for instance because they were responded to
+	Count       int     `json:"count"`
+	MedianHours float64 `json:"median_hours"`
+	P90Hours    float64 `json:"p90_hours"`
+	MeanHours   float64 `json:"mean_hours"`
+}
+
+// Stats shows statistics, as HTML or as JSON if requested
+func (h *Handlers) Stats() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays":     toDays,
+		"fromHours":  fromHours,
+		"percent":    percent,
+		"heat":       heat,
+		"approxDays": approxDays,
+	}
+	t := template.Must(template.New("stats").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "stats.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("GET %s: %v", r.URL.Path, redactHeaders(r.Header))
+
+		cols, err := h.party.ListCollections()
+		if err != nil {
+			klog.Errorf("collections: %v", err)
+		}
+		cols = h.visible(r, cols)
+
+		results := []*triage.CollectionResult{}
+		for _, c := range cols {
+			if cr := h.updater.Cached(c.ID); cr != nil {
+				results = append(results, cr)
+			}
+		}
+		st := computeStats(results, h.party.MemberGroupsOf)
+		cs := visibleConversations(results, h.party.Conversations())
+		st.Contributors = contributors(cs, maxContributorMonths)
+		st.Newcomers = newcomers(cs, h.party.FirstResponseSLO(), time.Now())
+		st.LabelAging = labelAging(cs, time.Now(), maxAgingLabels)
+
+		weeks := getInt(r.URL, "weeks", defaultVelocityWeeks)
+		if weeks < 1 || weeks > maxVelocityWeeks {
+			weeks = defaultVelocityWeeks
+		}
+		st.Velocity = velocity(cs, time.Now(), weeks)
+
+		st.SLOs = h.sloStatuses(results)
+		st.Forecasts = h.forecasts(results)
+
+		if h.history != nil {
+			for _, cr := range results {
+				for _, rr := range cr.RuleResults {
+					left, staying := h.history.Stays(rr.Rule.ID, time.Now().Add(-timeInRuleWindow), time.Now())
+					d := distribution(cr.Collection.ID+"/"+rr.Rule.ID, left)
+					d.Total = len(left) + len(staying)
+					st.TimeInRule = append(st.TimeInRule, d)
+				}
+			}
+		}
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(st); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+			return
+		}
+
+		p := &Page{
+			Version:     VERSION,
+			Prefix:      h.prefix,
+			SiteName:    h.siteName,
+			Title:       "Statistics",
+			Collections: cols,
+			Status:      h.updater.Status(),
+			Stats:       st,
+		}
+
+		if err := t.ExecuteTemplate(w, "base", p); err != nil {
+			klog.Errorf("tmpl: %v", err)
+			return
+		}
+	}
+}
+
+// wantsJSON returns whether a request asked for a JSON response
+func wantsJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// computeStats computes statistics for a set of collection results, grouping reviewers by member group
+func computeStats(results []*triage.CollectionResult, groupsOf func(string) []string) *Stats {
+	st := &Stats{}
+
+	byRepo := map[string][]*hubbub.Conversation{}
+	seen := map[string]bool{}
+
+	for _, cr := range results {
+		for _, rr := range cr.RuleResults {
+			st.FirstResponseByRule = append(st.FirstResponseByRule, firstResponse(cr.Collection.ID+"/"+rr.Rule.ID, rr.Items))
+			for _, co := range rr.Items {
+				if seen[co.URL] {
+					continue
+				}
+				seen[co.URL] = true
+				repo := co.Organization + "/" + co.Project
+				byRepo[repo] = append(byRepo[repo], co)
+			}
+		}
+	}
+
+	repos := []string{}
+	for repo := range byRepo {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	byGroup := map[string][]*hubbub.Conversation{}
+	for _, repo := range repos {
+		cs := byRepo[repo]
+		st.FirstResponseByRepo = append(st.FirstResponseByRepo, firstResponse(repo, cs))
+		st.ReviewLatencyByRepo = append(st.ReviewLatencyByRepo, reviewLatency(repo, cs))
+
+		for _, co := range cs {
+			if co.Type != hubbub.PullRequest || co.FirstReviewer == "" {
+				continue
+			}
+			gs := groupsOf(co.FirstReviewer)
+			if len(gs) == 0 {
+				gs = []string{noGroup}
+			}
+			for _, g := range gs {
+				byGroup[g] = append(byGroup[g], co)
+			}
+		}
+	}
+
+	groups := []string{}
+	for g := range byGroup {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		st.ReviewLatencyByGroup = append(st.ReviewLatencyByGroup, reviewLatency(g, byGroup[g]))
+	}
+	return st
+}
+
+// reviewLatency returns the review latency distributions for pull requests
+func reviewLatency(name string, cs []*hubbub.Conversation) *ReviewLatency {
+	total := 0
+	review := []time.Duration{}
+	merge := []time.Duration{}
+
+	for _, co := range cs {
+		if co.Type != hubbub.PullRequest {
+			continue
+		}
+		total++
+		if !co.FirstReview.IsZero() && co.FirstReview.After(co.ReviewRequested) {
+			review = append(review, co.FirstReview.Sub(co.ReviewRequested))
+		}
+		if !co.Approved.IsZero() && !co.Merged.IsZero() {
+			merge = append(merge, co.Merged.Sub(co.Approved))
+		}
+	}
+
+	rl := &ReviewLatency{Name: name, FirstReview: distribution(name, review), ApprovalToMerge: distribution(name, merge)}
+	rl.FirstReview.Total = total
+	rl.ApprovalToMerge.Total = total
+	return rl
+}
+
+// firstResponse returns the distribution of time to first member response for items authored by non-members
+func firstResponse(name string, cs []*hubbub.Conversation) *Distribution {
+	total := 0
+	ds := []time.Duration{}
+	for _, co := range cs {
+		if co.SelfInflicted {
+			continue
+		}
+		total++
+		if !co.FirstMemberResponse.IsZero() {
+			ds = append(ds, co.FirstMemberResponse.Sub(co.Created))
+		}
+	}
+
+	d := distribution(name, ds)
+	d.Total = total
+	return d
+}
+
+// distribution summarizes a set of durations
+func distribution(name string, ds []time.Duration) *Distribution {
+	d := &Distribution{Name: name, Total: len(ds), Count: len(ds)}
+	if len(ds) == 0 {
+		return d
+	}
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	var sum time.Duration
+	for _, x := range ds {
+		sum += x
+	}
+
+	d.MedianHours = percentile(ds, 50).Hours()
+	d.P90Hours = percentile(ds, 90).Hours()
+	d.MeanHours = (sum / time.Duration(len(ds))).Hours()
+	return d
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	i := (len(ds)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return ds[i]
+}
+
+// visibleConversations returns the conversations within repositories which appear in a set of results
+func visibleConversations(results []*triage.CollectionResult, all []*hubbub.Conversation) []*hubbub.Conversation {
+	repos := map[string]bool{}
+	for _, cr := range results {
+		for _, rr := range cr.RuleResults {
+			for _, co := range rr.Items {
+				repos[co.Organization+"/"+co.Project] = true
+			}
+		}
+	}
+
+	cs := []*hubbub.Conversation{}
+	for _, co := range all {
+		if repos[co.Organization+"/"+co.Project] {
+			cs = append(cs, co)
+		}
+	}
+	return cs
+}
+
+// contributor returns the login of a conversation author who is neither a member nor a bot
+func contributor(co *hubbub.Conversation) string {
+	if co.SelfInflicted || co.Author == nil || strings.EqualFold(co.Author.GetType(), "bot") {
+		return ""
+	}
+	return co.Author.GetLogin()
+}
+
+// contributors returns new and returning contributor counts for the most recent months, oldest first
+func contributors(cs []*hubbub.Conversation, months int) []*ContributorMonth {
+	first := map[string]string{}
+	active := map[string]map[string]bool{}
+
+	for _, co := range cs {
+		login := contributor(co)
+		if login == "" {
+			continue
+		}
+		m := co.Created.UTC().Format("2006-01")
+		if first[login] == "" || m < first[login] {
+			first[login] = m
+		}
+		if active[m] == nil {
+			active[m] = map[string]bool{}
+		}
+		active[m][login] = true
+	}
+
+	ms := []string{}
+	for m := range active {
+		ms = append(ms, m)
+	}
+	sort.Strings(ms)
+	if len(ms) > months {
+		ms = ms[len(ms)-months:]
+	}
+
+	out := []*ContributorMonth{}
+	for _, m := range ms {
+		cm := &ContributorMonth{Month: m}
+		for login := range active[m] {
+			if first[login] == m {
+				cm.New++
+			} else {
+				cm.Returning++
+			}
+		}
+		out = append(out, cm)
+	}
+	return out
+}
+
+// newcomers returns how many first pull requests by contributors were responded to within the SLO
+func newcomers(cs []*hubbub.Conversation, slo time.Duration, now time.Time) *NewcomerResponse {
+	firstPR := map[string]*hubbub.Conversation{}
+	for _, co := range cs {
+		login := contributor(co)
+		if login == "" || co.Type != hubbub.PullRequest {
+			continue
+		}
+		if f := firstPR[login]; f == nil || co.Created.Before(f.Created) {
+			firstPR[login] = co
+		}
+	}
+
+	nr := &NewcomerResponse{SLOHours: slo.Hours()}
+	for _, co := range firstPR {
+		responded := !co.FirstMemberResponse.IsZero()
+		if !responded && now.Sub(co.Created) < slo {
+			continue
+		}
+		nr.PullRequests++
+		if responded && co.FirstMemberResponse.Sub(co.Created) <= slo {
+			nr.WithinSLO++
+		}
+	}
+
+	if nr.PullRequests > 0 {
+		nr.Ratio = float64(nr.WithinSLO) / float64(nr.PullRequests)
+	}
+	return nr
+}
+
+// labelAging returns the age distribution of open conversations per label, most rotten first
+func labelAging(cs []*hubbub.Conversation, now time.Time, max int) *LabelAging {
+	la := &LabelAging{}
+	for _, b := range ageBuckets {
+		la.Buckets = append(la.Buckets, b.name)
+	}
+	la.Buckets = append(la.Buckets, "older")
+
+	byLabel := map[string]*LabelAge{}
+	for _, co := range cs {
+		if co.State != constants.OpenState && co.State != constants.OpenedState {
+			continue
+		}
+
+		b := len(ageBuckets)
+		for i, ab := range ageBuckets {
+			if now.Sub(co.Created) < ab.max {
+				b = i
+				break
+			}
+		}
+
+		for _, l := range co.Labels {
+			a := byLabel[l.GetName()]
+			if a == nil {
+				a = &LabelAge{Label: l.GetName(), Counts: make([]int, len(la.Buckets))}
+				byLabel[l.GetName()] = a
+			}
+			a.Counts[b]++
+			a.Total++
+		}
+	}
+
+	for _, a := range byLabel {
+		la.Labels = append(la.Labels, a)
+	}
+
+	// Labels with the most old items first
+	sort.Slice(la.Labels, func(i, j int) bool {
+		a, b := la.Labels[i], la.Labels[j]
+		for k := len(a.Counts) - 1; k >= 0; k-- {
+			if a.Counts[k] != b.Counts[k] {
+				return a.Counts[k] > b.Counts[k]
+			}
+		}
+		return a.Label < b.Label
+	})
+	if len(la.Labels) > max {
+		la.Labels = la.Labels[:max]
+	}
+
+	for _, a := range la.Labels {
+		for _, c := range a.Counts {
+			if c > la.Max {
+				la.Max = c
+			}
+		}
+	}
+	return la
+}
+
+// weekStart returns the start of the week containing a time: Monday 00:00 UTC
+func weekStart(t time.Time) time.Time {
+	t = t.UTC().Truncate(24 * time.Hour)
+	return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+// velocity returns the conversations opened and closed per week and repository, for the most recent weeks, oldest first
+func velocity(cs []*hubbub.Conversation, now time.Time, weeks int) []*RepoVelocity {
+	first := weekStart(now).AddDate(0, 0, -7*(weeks-1))
+	index := func(t time.Time) int {
+		if t.IsZero() || t.Before(first) || t.After(now) {
+			return -1
+		}
+		return int(weekStart(t).Sub(first).Hours() / (24 * 7))
+	}
+
+	byRepo := map[string]*RepoVelocity{}
+	for _, co := range cs {
+		repo := co.Organization + "/" + co.Project
+		rv := byRepo[repo]
+		if rv == nil {
+			rv = &RepoVelocity{Repo: repo}
+			for i := 0; i < weeks; i++ {
+				rv.Weeks = append(rv.Weeks, &WeekRate{Start: first.AddDate(0, 0, 7*i).Format("2006-01-02")})
+			}
+			byRepo[repo] = rv
+		}
+
+		if i := index(co.Created); i >= 0 {
+			rv.Weeks[i].Opened++
+			rv.Weeks[i].Net++
+		}
+		if i := index(co.ClosedAt); i >= 0 {
+			rv.Weeks[i].Closed++
+			rv.Weeks[i].Net--
+		}
+	}
+
+	rvs := []*RepoVelocity{}
+	for _, rv := range byRepo {
+		rvs = append(rvs, rv)
+	}
+	sort.Slice(rvs, func(i, j int) bool { return rvs[i].Repo < rvs[j].Repo })
+	return rvs
+}
+
+// heat returns a background color whose intensity reflects a count relative to the maximum
+func heat(n int, max int) template.CSS {
+	if n == 0 || max == 0 {
+		return ""
+	}
+	return template.CSS(fmt.Sprintf("background-color: rgba(255, 56, 96, %.2f)", 0.1+0.8*float64(n)/float64(max)))
+}
+
+// fromHours converts hours to a duration
+func fromHours(h float64) time.Duration {
+	return time.Duration(h * float64(time.Hour))
+}
+
+// percent formats a ratio as a percentage
+func percent(f float64) string {
+	return fmt.Sprintf("%.0f%%", f*100)
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// TokenHealth describes the token of a provider host
+type TokenHealth struct {
+	Host      string    `json:"host"`
+	Type      string    `json:"type"`
+	Scopes    []string  `json:"scopes"`
+	Expires   time.Time `json:"expires,omitempty"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	OK        bool      `json:"ok"`
+	// Warning is set when the token is about to expire, or the rate limit is nearly exhausted
+	Warning string `json:"warning,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// tokenCheck caches the last token checks
+type tokenCheck struct {
+	mu         sync.Mutex
+	checked    time.Time
+	tokens     []*triage.TokenStatus
+	warnBefore time.Duration
+}
+
+// checkTokens returns token statuses, checking them again if the last check is too old
+func (h *Handlers) checkTokens(ctx context.Context) []*triage.TokenStatus {
+	tc := h.tokenCheck
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if time.Since(tc.checked) > providerCheckInterval {
+		tc.tokens = h.party.CheckTokens(ctx)
+		tc.checked = time.Now()
+	}
+	return tc.tokens
+}
+
+// tokenHealth describes the health of each provider token
+func (h *Handlers) tokenHealth(ctx context.Context, now time.Time) []*TokenHealth {
+	ths := []*TokenHealth{}
+	for _, ts := range h.checkTokens(ctx) {
+		ths = append(ths, describeToken(ts, h.tokenCheck.warnBefore, now))
+	}
+	return ths
+}
+
+// describeToken describes the health of a provider token
+func describeToken(ts *triage.TokenStatus, warnBefore time.Duration, now time.Time) *TokenHealth {
+	th := &TokenHealth{Host: ts.Host, OK: true}
+	if ts.Err != nil {
+		th.OK = false
+		th.Error = ts.Err.Error()
+	}
+	if ts.Info == nil {
+		return th
+	}
+
+	ti := ts.Info
+	th.Type = ti.Type
+	th.Scopes = ti.Scopes
+	th.Expires = ti.Expires
+	th.Limit = ti.Rate.Limit
+	th.Remaining = ti.Rate.Remaining
+	th.Reset = ti.Rate.Reset.Time
+
+	switch {
+	case !ti.Expires.IsZero() && !now.Before(ti.Expires):
+		th.OK = false
+		th.Warning = fmt.Sprintf("%s token expired on %s", ti.Type, ti.Expires.Format("2006-01-02"))
+	case !ti.Expires.IsZero() && ti.Expires.Sub(now) < warnBefore:
+		th.Warning = fmt.Sprintf("%s token expires in %s, on %s", ti.Type, humanDuration(ti.Expires.Sub(now)), ti.Expires.Format("2006-01-02"))
+	case ti.Rate.Limit > 0 && ti.Rate.Remaining < ti.Rate.Limit/10:
+		th.Warning = fmt.Sprintf("only %d of %d requests remaining until %s", ti.Rate.Remaining, ti.Rate.Limit, ti.Rate.Reset.Format(time.RFC3339))
+	}
+	return th
+}
+
+// tokenWarnings returns warnings about provider tokens, for display on every page
+func (h *Handlers) tokenWarnings(ctx context.Context) []string {
+	ws := []string{}
+	for _, th := range h.tokenHealth(ctx, time.Now()) {
+		if th.Warning != "" {
+			ws = append(ws, fmt.Sprintf("%s: %s", th.Host, th.Warning))
+		}
+	}
+	return ws
+}
+
+// TokenHealthz shows the type, scopes, expiry and remaining rate limit of provider tokens, failing if any are unusable
+func (h *Handlers) TokenHealthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ths := h.tokenHealth(r.Context(), time.Now())
+
+		status := http.StatusOK
+		for _, th := range ths {
+			if !th.OK {
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			if err := json.NewEncoder(w).Encode(ths); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		if len(ths) == 0 {
+			fmt.Fprintln(w, "no provider tokens can be described")
+		}
+		for _, th := range ths {
+			fmt.Fprintf(w, "%s: %s\n", th.Host, tokenSummary(th))
+		}
+	}
+}
+
+// tokenSummary summarizes the health of a token on a single line
+func tokenSummary(th *TokenHealth) string {
+	parts := []string{}
+	if th.Type != "" {
+		parts = append(parts, th.Type+" token")
+	}
+	switch {
+	case th.Type == provider.FineGrainedToken:
+		parts = append(parts, "per-repository permissions")
+	case th.Scopes != nil && len(th.Scopes) == 0:
+		parts = append(parts, "no scopes")
+	case th.Scopes != nil:
+		parts = append(parts, "scopes "+strings.Join(th.Scopes, ","))
+	}
+	if th.Expires.IsZero() {
+		parts = append(parts, "no expiry")
+	} else {
+		parts = append(parts, "expires "+th.Expires.Format(time.RFC3339))
+	}
+	if th.Limit > 0 {
+		parts = append(parts, fmt.Sprintf("%d of %d requests remaining until %s", th.Remaining, th.Limit, th.Reset.Format(time.RFC3339)))
+	}
+	if th.Warning != "" {
+		parts = append(parts, "WARNING: "+th.Warning)
+	}
+	if th.Error != "" {
+		parts = append(parts, "ERROR: "+th.Error)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+
+	"github.com/google/triage-party/pkg/access"
+	"k8s.io/klog/v2"
+)
+
+// tokenRequest is the JSON body of a request to issue or revoke an API token. Tokens act as the user who issues them.
+type tokenRequest struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+}
+
+// tokenResponse is the JSON response to issuing an API token
+type tokenResponse struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// Tokens lists, issues and revokes API tokens
+func (h *Handlers) Tokens() http.HandlerFunc {
+	fmap := template.FuncMap{
+		"toDays": toDays,
+	}
+	t := template.Must(template.New("tokens").Funcs(fmap).ParseFiles(
+		filepath.Join(h.baseDir, "tokens.tmpl"),
+		filepath.Join(h.baseDir, "base.tmpl"),
+	))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("%s %s: %v", r.Method, r.URL.Path, redactHeaders(r.Header))
+
+		if h.tokens == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !h.allowed(r, "", access.Admin) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			sts, err := h.party.ListCollections()
+			if err != nil {
+				klog.Errorf("collections: %v", err)
+			}
+
+			p := &Page{
+				Version:     VERSION,
+				Prefix:      h.prefix,
+				SiteName:    h.siteName,
+				Title:       "API tokens",
+				Collections: h.visible(r, sts),
+				Status:      h.updater.Status(),
+				APITokens:   h.tokens.List(),
+				User:        h.user(r),
+			}
+
+			if err := t.ExecuteTemplate(w, "base", p); err != nil {
+				klog.Errorf("tmpl: %v", err)
+			}
+		case http.MethodPost:
+			tr := &tokenRequest{}
+			if err := json.NewDecoder(r.Body).Decode(tr); err != nil {
+				http.Error(w, fmt.Sprintf("decode: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			owner := h.user(r)
+			if owner == "" {
+				http.Error(w, "tokens may only be issued by a signed-in user, whom they act as", http.StatusForbidden)
+				return
+			}
+
+			secret, at, err := h.tokens.Issue(tr.Name, owner, tr.Scope)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			h.audit.Record(h.actionContext(r), "issue token", at.ID, fmt.Sprintf("name:%q owner:%q scope:%s", at.Name, at.Owner, at.Scope), nil)
+
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(tokenResponse{ID: at.ID, Secret: secret}); err != nil {
+				klog.Errorf("encode: %v", err)
+			}
+		case http.MethodDelete:
+			tr := &tokenRequest{}
+			if err := json.NewDecoder(r.Body).Decode(tr); err != nil {
+				http.Error(w, fmt.Sprintf("decode: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			err := h.tokens.Revoke(tr.ID)
+			h.audit.Record(h.actionContext(r), "revoke token", tr.ID, "", err)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "GET, POST or DELETE required", http.StatusMethodNotAllowed)
+		}
+	}
+}
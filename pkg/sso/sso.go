@@ -0,0 +1,462 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sso authenticates users against an OpenID Connect identity provider
+package sso
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// LoginPath starts the login flow
+	LoginPath = "/auth/login"
+	// CallbackPath is where the identity provider redirects back to
+	CallbackPath = "/auth/callback"
+	// LogoutPath clears the session
+	LogoutPath = "/auth/logout"
+
+	sessionCookie = "tp_session"
+	stateCookie   = "tp_oidc_state"
+
+	defaultGroupsClaim = "groups"
+	defaultSessionTTL  = 12 * time.Hour
+)
+
+// Config is how to configure a new authenticator
+type Config struct {
+	// Issuer is the OIDC issuer URL, such as https://accounts.google.com
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the externally visible URL of CallbackPath
+	RedirectURL string
+	// UserClaim is the ID token claim to use as the user name, defaulting to preferred_username, then email
+	UserClaim string
+	// GroupsClaim is the ID token claim listing the user's groups
+	GroupsClaim string
+	// Scopes are requested in addition to openid, profile and email
+	Scopes []string
+	// SessionKey signs session cookies. If empty, a random key is used, and sessions do not survive restarts.
+	SessionKey []byte
+	// SessionTTL is how long a login lasts for
+	SessionTTL time.Duration
+	// Prefixes are the paths sites are served under besides the root, such as /team, whose public pages need no login
+	Prefixes []string
+	// VerifyToken returns whether a request bears a valid API token, which needs no login
+	VerifyToken func(*http.Request) bool
+}
+
+// Session is an authenticated user
+type Session struct {
+	User    string    `json:"u"`
+	Groups  []string  `json:"g,omitempty"`
+	Expires time.Time `json:"e"`
+}
+
+// Authenticator handles OIDC logins and sessions
+type Authenticator struct {
+	issuer      string
+	clientID    string
+	userClaim   string
+	groupsClaim string
+	key         []byte
+	ttl         time.Duration
+	oauth       *oauth2.Config
+	prefixes    []string
+	verifyToken func(*http.Request) bool
+}
+
+// discovery is the subset of the OIDC discovery document we use
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// New returns a new authenticator, fetching the provider configuration of the issuer
+func New(ctx context.Context, cfg Config) (*Authenticator, error) {
+	d, err := discover(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discover: %w", err)
+	}
+
+	key := cfg.SessionKey
+	if len(key) == 0 {
+		klog.Warningf("no session key configured: sessions will not survive restarts")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("session key: %w", err)
+		}
+	}
+
+	a := &Authenticator{
+		issuer:      d.Issuer,
+		clientID:    cfg.ClientID,
+		userClaim:   cfg.UserClaim,
+		groupsClaim: cfg.GroupsClaim,
+		key:         key,
+		ttl:         cfg.SessionTTL,
+		prefixes:    cfg.Prefixes,
+		verifyToken: cfg.VerifyToken,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     oauth2.Endpoint{AuthURL: d.AuthorizationEndpoint, TokenURL: d.TokenEndpoint},
+			Scopes:       append([]string{"openid", "profile", "email"}, cfg.Scopes...),
+		},
+	}
+
+	if a.groupsClaim == "" {
+		a.groupsClaim = defaultGroupsClaim
+	}
+	if a.ttl == 0 {
+		a.ttl = defaultSessionTTL
+	}
+	return a, nil
+}
+
+// discover fetches the OIDC discovery document of an issuer
+func discover(ctx context.Context, issuer string) (*discovery, error) {
+	u := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+
+	d := &discovery{}
+	if err := json.Unmarshal(b, d); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	if d.Issuer != strings.TrimSuffix(issuer, "/") && d.Issuer != issuer {
+		return nil, fmt.Errorf("issuer mismatch: %q != %q", d.Issuer, issuer)
+	}
+	return d, nil
+}
+
+// Session returns the session of a request, or nil if it has none
+func (a *Authenticator) Session(r *http.Request) *Session {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+
+	b, ok := a.verify(c.Value)
+	if !ok {
+		return nil
+	}
+
+	s := &Session{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil
+	}
+	if time.Now().After(s.Expires) {
+		return nil
+	}
+	return s
+}
+
+// Require wraps a handler, sending requests without a session to the identity provider
+func (a *Authenticator) Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.public(r) || a.Session(r) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Scripts and API clients can't follow a login redirect
+		if r.Method != http.MethodGet || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			http.Error(w, "login required", http.StatusUnauthorized)
+			return
+		}
+
+		http.Redirect(w, r, LoginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+	})
+}
+
+// publicPaths may be served without a session, under the root or any prefix
+var publicPaths = map[string]bool{
+	"/healthz": true,
+	"/health":  true,
+	"/readyz":  true,
+	"/public":  true,
+}
+
+// public returns whether a request may be served without a session
+func (a *Authenticator) public(r *http.Request) bool {
+	p := r.URL.Path
+	for _, prefix := range []string{"/auth/", "/static/", "/third_party/"} {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+
+	for _, prefix := range append([]string{""}, a.prefixes...) {
+		if strings.HasPrefix(p, prefix) && publicPaths[strings.TrimPrefix(p, prefix)] {
+			return true
+		}
+	}
+
+	// The site checks the token again, to apply its scope
+	if a.verifyToken != nil && strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		return a.verifyToken(r)
+	}
+	return false
+}
+
+// Login redirects to the identity provider
+func (a *Authenticator) Login() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			http.Error(w, "random failure", http.StatusInternalServerError)
+			return
+		}
+		state := hex.EncodeToString(b)
+
+		next := r.URL.Query().Get("next")
+		// Only allow local redirects
+		if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+			next = "/"
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookie,
+			Value:    a.sign([]byte(state + " " + next)),
+			Path:     "/auth/",
+			MaxAge:   600,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, a.oauth.AuthCodeURL(state), http.StatusFound)
+	}
+}
+
+// Callback completes a login
+func (a *Authenticator) Callback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if e := r.URL.Query().Get("error"); e != "" {
+			http.Error(w, fmt.Sprintf("login failed: %s", e), http.StatusForbidden)
+			return
+		}
+
+		c, err := r.Cookie(stateCookie)
+		if err != nil {
+			http.Error(w, "login expired, please try again", http.StatusBadRequest)
+			return
+		}
+
+		b, ok := a.verify(c.Value)
+		if !ok {
+			http.Error(w, "invalid login state", http.StatusBadRequest)
+			return
+		}
+
+		parts := strings.SplitN(string(b), " ", 2)
+		if len(parts) != 2 || !hmac.Equal([]byte(parts[0]), []byte(r.URL.Query().Get("state"))) {
+			http.Error(w, "invalid login state", http.StatusBadRequest)
+			return
+		}
+
+		s, err := a.exchange(r.Context(), r.URL.Query().Get("code"))
+		if err != nil {
+			klog.Errorf("oidc login: %v", err)
+			http.Error(w, "login failed", http.StatusForbidden)
+			return
+		}
+
+		sb, err := json.Marshal(s)
+		if err != nil {
+			http.Error(w, "marshal failure", http.StatusInternalServerError)
+			return
+		}
+
+		klog.Infof("%s logged in, groups: %v", s.User, s.Groups)
+		http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/auth/", MaxAge: -1})
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    a.sign(sb),
+			Path:     "/",
+			Expires:  s.Expires,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, parts[1], http.StatusFound)
+	}
+}
+
+// Logout clears the session
+func (a *Authenticator) Logout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+		fmt.Fprintln(w, "You have been logged out.")
+	}
+}
+
+// exchange trades an authorization code for a session
+func (a *Authenticator) exchange(ctx context.Context, code string) (*Session, error) {
+	tok, err := a.oauth.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("exchange: %w", err)
+	}
+
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("no id_token in token response")
+	}
+
+	claims, err := a.claims(raw)
+	if err != nil {
+		return nil, fmt.Errorf("id token: %w", err)
+	}
+
+	user := claimString(claims, a.userClaim, "preferred_username", "email", "sub")
+	if user == "" {
+		return nil, fmt.Errorf("id token has no user claim")
+	}
+
+	return &Session{
+		User:    user,
+		Groups:  claimStrings(claims[a.groupsClaim]),
+		Expires: time.Now().Add(a.ttl),
+	}, nil
+}
+
+// claims validates an ID token, and returns its claims.
+//
+// The signature is not checked: the token was received directly from the token endpoint over TLS,
+// which OpenID Connect Core 1.0 section 3.1.3.7 permits in place of signature validation.
+func (a *Authenticator) claims(raw string) (map[string]interface{}, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	if iss, _ := claims["iss"].(string); iss != a.issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", iss)
+	}
+
+	audOK := false
+	for _, aud := range claimStrings(claims["aud"]) {
+		if aud == a.clientID {
+			audOK = true
+		}
+	}
+	if !audOK {
+		return nil, fmt.Errorf("token is not for client %q", a.clientID)
+	}
+
+	exp, _ := claims["exp"].(float64)
+	if time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	return claims, nil
+}
+
+// claimString returns the first non-empty string claim of a list of names
+func claimString(claims map[string]interface{}, names ...string) string {
+	for _, n := range names {
+		if s, ok := claims[n].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// claimStrings returns a claim that may be a string or a list of strings
+func claimStrings(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		ss := []string{}
+		for _, i := range t {
+			if s, ok := i.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+		return ss
+	}
+	return nil
+}
+
+// sign returns a value with an HMAC appended
+func (a *Authenticator) sign(b []byte) string {
+	m := hmac.New(sha256.New, a.key)
+	m.Write(b)
+	return base64.RawURLEncoding.EncodeToString(b) + "." + base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// verify checks the HMAC of a signed value, and returns its contents
+func (a *Authenticator) verify(v string) ([]byte, bool) {
+	parts := strings.SplitN(v, ".", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+
+	m := hmac.New(sha256.New, a.key)
+	m.Write(b)
+	return b, hmac.Equal(sig, m.Sum(nil))
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sso
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublic(t *testing.T) {
+	a := &Authenticator{
+		prefixes:    []string{"/team"},
+		verifyToken: func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer tp_valid" },
+	}
+
+	tests := []struct {
+		path   string
+		bearer string
+		want   bool
+	}{
+		{path: "/healthz", want: true},
+		{path: "/team/readyz", want: true},
+		{path: "/team/public", want: true},
+		{path: "/static/tp.css", want: true},
+		{path: "/healthz/token", want: false},
+		{path: "/threadz", want: false},
+		{path: "/s/public", want: false},
+		{path: "/diff/healthz", want: false},
+		{path: "/other/healthz", want: false},
+		{path: "/s/daily", bearer: "tp_valid", want: true},
+		{path: "/s/daily", bearer: "tp_invalid", want: false},
+		{path: "/threadz", bearer: "anything", want: false},
+	}
+
+	for _, tc := range tests {
+		r := httptest.NewRequest("GET", tc.path, nil)
+		if tc.bearer != "" {
+			r.Header.Set("Authorization", "Bearer "+tc.bearer)
+		}
+		assert.Equal(t, tc.want, a.public(r), "%s with token %q", tc.path, tc.bearer)
+	}
+}
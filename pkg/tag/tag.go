@@ -32,16 +32,22 @@ var (
 	Closed        = Tag{ID: "closed", Desc: "This item has been closed"}
 	OpenMilestone = Tag{ID: "open-milestone", Desc: "The issue is associated to an open milestone"}
 	Similar       = Tag{ID: "similar", Desc: "Title appears similar to another PR or issue"}
+	FixPending    = Tag{ID: "fix-pending", Desc: "An open PR appears to address this issue"}
 	Merged        = Tag{ID: "merged", Desc: "PR was merged"}
 	Draft         = Tag{ID: "draft", Desc: "Draft PR"}
+	Blocked       = Tag{ID: "blocked", Desc: "Blocked by an item which is still open"}
+	Behind        = Tag{ID: "behind", Desc: "PR is behind its base branch"}
+	Unresolved    = Tag{ID: "unresolved-threads", Desc: "PR has review threads which are unresolved"}
+	StateConflict = Tag{ID: "state-conflict", Desc: "Has the labels of more than one triage state"}
 
 	// Comment-based tags
-	Commented       = Tag{ID: "commented", Desc: "A project member has commented on this", NeedsComments: true}
-	Send            = Tag{ID: "send", Desc: "A project member commented more recently than the author", NeedsComments: true}
-	Recv            = Tag{ID: "recv", Desc: "The author commented more recently than a project member", NeedsComments: true}
-	RecvQ           = Tag{ID: "recv-q", Desc: "The author has asked a question since the last project member commented", NeedsComments: true}
-	AuthorLast      = Tag{ID: "author-last", Desc: "The last commenter was the original author", NeedsComments: true}
-	AssigneeUpdated = Tag{ID: "assignee-updated", Desc: "Issue has been updated by its assignee", NeedsComments: true}
+	Commented         = Tag{ID: "commented", Desc: "A project member has commented on this", NeedsComments: true}
+	Send              = Tag{ID: "send", Desc: "A project member commented more recently than the author", NeedsComments: true}
+	Recv              = Tag{ID: "recv", Desc: "The author commented more recently than a project member", NeedsComments: true}
+	RecvQ             = Tag{ID: "recv-q", Desc: "The author has asked a question since the last project member commented", NeedsComments: true}
+	AuthorLast        = Tag{ID: "author-last", Desc: "The last commenter was the original author", NeedsComments: true}
+	AssigneeUpdated   = Tag{ID: "assignee-updated", Desc: "Issue has been updated by its assignee", NeedsComments: true}
+	MentionUnanswered = Tag{ID: "mention-unanswered", Desc: "A maintainer was recently @-mentioned, and has not responded since", NeedsComments: true}
 
 	// Timeline-based tags
 	XrefApproved            = Tag{ID: "pr-approved", Desc: "Last review was an approval", NeedsTimeline: true}
@@ -50,6 +56,12 @@ var (
 	XrefNewCommits          = Tag{ID: "pr-new-commits", Desc: "PR has commits since the last review", NeedsTimeline: true}
 	XrefPushedAfterApproval = Tag{ID: "pr-pushed-after-approval", Desc: "PR was pushed to after approval", NeedsTimeline: true}
 	XrefUnreviewed          = Tag{ID: "pr-unreviewed", Desc: "PR has never been reviewed", NeedsTimeline: true}
+	ReadyForReview          = Tag{ID: "ready-for-review", Desc: "PR was marked ready for review after being a draft", NeedsTimeline: true}
+	AutoMerge               = Tag{ID: "auto-merge", Desc: "PR will be merged automatically once requirements are met", NeedsTimeline: true}
+	Deployed                = Tag{ID: "deployed", Desc: "PR has been deployed to an environment", NeedsTimeline: true}
+	ForcePushed             = Tag{ID: "force-pushed", Desc: "PR was force-pushed since it was last reviewed", NeedsTimeline: true}
+	Transferred             = Tag{ID: "transferred", Desc: "Issue was transferred from another repository", NeedsTimeline: true}
+	InvalidTransition       = Tag{ID: "invalid-transition", Desc: "Moved between triage states in a way which is not allowed", NeedsTimeline: true}
 
 	// Review-based tags
 	Approved            = Tag{ID: "approved", Desc: "Last review was an approval", NeedsReviews: true}
@@ -58,6 +70,7 @@ var (
 	NewCommits          = Tag{ID: "new-commits", Desc: "PR has commits since the last review", NeedsReviews: true}
 	PushedAfterApproval = Tag{ID: "pushed-after-approval", Desc: "PR was pushed to after approval", NeedsReviews: true}
 	Unreviewed          = Tag{ID: "unreviewed", Desc: "PR has never been reviewed", NeedsReviews: true}
+	MergeReady          = Tag{ID: "merge-ready", Desc: "PR is approved, mergeable and passing checks, without auto-merge enabled", NeedsReviews: true}
 
 	// Special
 	None = Tag{ID: "none", Desc: "No tag matched", NeedsComments: true, NeedsReviews: true, NeedsTimeline: true}
@@ -68,14 +81,18 @@ var Tags = map[Tag]bool{
 	Closed:                  true,
 	OpenMilestone:           true,
 	Similar:                 true,
+	FixPending:              true,
 	Merged:                  true,
 	Draft:                   true,
+	Blocked:                 true,
+	StateConflict:           true,
 	Commented:               true,
 	Send:                    true,
 	Recv:                    true,
 	RecvQ:                   true,
 	AuthorLast:              true,
 	AssigneeUpdated:         true,
+	MentionUnanswered:       true,
 	Approved:                true,
 	ReviewedWithComment:     true,
 	ChangesRequested:        true,
@@ -88,6 +105,15 @@ var Tags = map[Tag]bool{
 	XrefNewCommits:          true,
 	XrefPushedAfterApproval: true,
 	XrefUnreviewed:          true,
+	ReadyForReview:          true,
+	AutoMerge:               true,
+	Deployed:                true,
+	Behind:                  true,
+	Unresolved:              true,
+	ForcePushed:             true,
+	Transferred:             true,
+	MergeReady:              true,
+	InvalidTransition:       true,
 }
 
 func RoleLast(role string) Tag {
@@ -0,0 +1,59 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// closedVariant returns a rule which matches closed items instead of open ones
+func closedVariant(t Rule) Rule {
+	fs := []provider.Filter{}
+	for _, f := range t.Filters {
+		if f.State != "" {
+			continue
+		}
+		fs = append(fs, f)
+	}
+	t.Filters = append(fs, provider.Filter{State: "closed"})
+	return t
+}
+
+// BackfillRule returns the conversations a rule matches now, along with conversations closed since a time which match it otherwise.
+// As filters are evaluated against the current state of each conversation, this only approximates what the rule matched in the past.
+func (p *Party) BackfillRule(ctx context.Context, t Rule, since time.Time, newerThan time.Time) ([]*hubbub.Conversation, error) {
+	sp := provider.SearchParams{NewerThan: newerThan}
+
+	rr, err := p.ExecuteRule(ctx, sp, t, nil)
+	if err != nil {
+		return nil, err
+	}
+	cs := rr.Items
+
+	cr, err := p.ExecuteRule(ctx, sp, closedVariant(t), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, co := range cr.Items {
+		if co.ClosedAt.After(since) {
+			cs = append(cs, co)
+		}
+	}
+	return cs, nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+)
+
+// BusinessHours describes working time
+type BusinessHours struct {
+	// Timezone is a time zone name, such as America/New_York. Defaults to UTC.
+	Timezone string `yaml:"timezone,omitempty"`
+	// Start and End are the working hours of a day, such as 09:00 and 17:00. Defaults to the whole day.
+	Start string `yaml:"start,omitempty"`
+	End   string `yaml:"end,omitempty"`
+	// Days are the working days, such as [mon, tue, wed, thu, fri], which is the default
+	Days []string `yaml:"days,omitempty"`
+
+	// Holidays are dates which are not worked, such as 2020-12-25
+	Holidays []string `yaml:"holidays,omitempty"`
+	// HolidayCalendar is the path or URL of an iCalendar (ICS) file, whose events are not worked
+	HolidayCalendar string `yaml:"holiday-calendar,omitempty"`
+}
+
+// calendar returns the calendar for business hours
+func (bh *BusinessHours) calendar() (*hubbub.Calendar, error) {
+	if bh == nil {
+		return hubbub.DefaultCalendar(), nil
+	}
+
+	c, err := hubbub.NewCalendar(bh.Timezone, bh.Start, bh.End, bh.Days)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range bh.Holidays {
+		if _, err := time.Parse(hubbub.DateFormat, h); err != nil {
+			return nil, fmt.Errorf("holiday %q: %w", h, err)
+		}
+		c.Holidays[h] = true
+	}
+
+	if bh.HolidayCalendar != "" {
+		days, err := readICS(bh.HolidayCalendar)
+		if err != nil {
+			return nil, fmt.Errorf("holiday-calendar: %w", err)
+		}
+		for _, d := range days {
+			c.Holidays[d] = true
+		}
+	}
+	return c, nil
+}
+
+// Absence declares when a member is out of the office
+type Absence struct {
+	Member string `yaml:"member"`
+	// From and Until are the first and last days away, such as 2020-12-21 and 2021-01-04
+	From  string `yaml:"from,omitempty"`
+	Until string `yaml:"until,omitempty"`
+	// Calendar is the path or URL of an iCalendar (ICS) file, whose events are days away
+	Calendar string `yaml:"calendar,omitempty"`
+}
+
+// addAbsences marks the days members are out of the office within a calendar
+func addAbsences(c *hubbub.Calendar, as []Absence) error {
+	for _, a := range as {
+		if a.Member == "" {
+			return fmt.Errorf("absence without a member")
+		}
+
+		if a.From != "" || a.Until != "" {
+			from, err := time.Parse(hubbub.DateFormat, a.From)
+			if err != nil {
+				return fmt.Errorf("%s from: %w", a.Member, err)
+			}
+			until := from
+			if a.Until != "" {
+				if until, err = time.Parse(hubbub.DateFormat, a.Until); err != nil {
+					return fmt.Errorf("%s until: %w", a.Member, err)
+				}
+			}
+			for d := from; !d.After(until); d = d.AddDate(0, 0, 1) {
+				c.AddAbsence(a.Member, d.Format(hubbub.DateFormat))
+			}
+		}
+
+		if a.Calendar != "" {
+			days, err := readICS(a.Calendar)
+			if err != nil {
+				return fmt.Errorf("%s calendar: %w", a.Member, err)
+			}
+			for _, d := range days {
+				c.AddAbsence(a.Member, d)
+			}
+		}
+	}
+	return nil
+}
+
+// readICS returns the dates of the events within an iCalendar file or URL
+func readICS(src string) ([]string, error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		resp, err := (&http.Client{Timeout: 30 * time.Second}).Get(src)
+		if err != nil {
+			return nil, fmt.Errorf("get: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s returned %s", src, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, fmt.Errorf("open: %w", err)
+		}
+		r = f
+	}
+	defer r.Close()
+
+	return parseICS(r)
+}
+
+// parseICS returns the dates covered by events within an iCalendar stream. Recurrence rules are not supported.
+func parseICS(r io.Reader) ([]string, error) {
+	days := []string{}
+	var start, end time.Time
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.SplitN(parts[0], ";", 2)[0]
+
+		switch name {
+		case "BEGIN":
+			if parts[1] == "VEVENT" {
+				start, end = time.Time{}, time.Time{}
+			}
+		case "DTSTART", "DTEND":
+			// Only the date matters: 20201225 or 20201225T000000Z
+			v := parts[1]
+			if len(v) < 8 {
+				return nil, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			t, err := time.Parse("20060102", v[:8])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if name == "DTSTART" {
+				start = t
+			} else {
+				end = t
+			}
+		case "END":
+			if parts[1] != "VEVENT" || start.IsZero() {
+				continue
+			}
+			// DTEND of an all-day event is exclusive
+			if end.Before(start.AddDate(0, 0, 1)) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				days = append(days, d.Format(hubbub.DateFormat))
+			}
+		}
+	}
+	return days, s.Err()
+}
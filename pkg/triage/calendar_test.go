@@ -0,0 +1,41 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseICS(t *testing.T) {
+	ics := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Christmas
+DTSTART;VALUE=DATE:20201225
+DTEND;VALUE=DATE:20201226
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Year-end freeze
+DTSTART;VALUE=DATE:20201230
+DTEND;VALUE=DATE:20210102
+END:VEVENT
+END:VCALENDAR
+`
+	days, err := parseICS(strings.NewReader(ics))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2020-12-25", "2020-12-30", "2020-12-31", "2021-01-01"}, days)
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// Threshold is the most items a rule may match before a check fails
+type Threshold struct {
+	RuleID string
+	Max    int
+}
+
+// ThresholdResult is the outcome of checking a threshold
+type ThresholdResult struct {
+	Threshold
+	Name  string
+	Count int
+}
+
+// Exceeded returns true if the rule matched more items than allowed
+func (r *ThresholdResult) Exceeded() bool {
+	return r.Count > r.Max
+}
+
+// ParseThreshold parses a threshold in the form of <rule>=<max>, such as "release-blockers=0"
+func ParseThreshold(s string) (Threshold, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return Threshold{}, fmt.Errorf("%q is not in the form of <rule>=<max>", s)
+	}
+
+	max, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || max < 0 {
+		return Threshold{}, fmt.Errorf("%q: max must be a non-negative number", s)
+	}
+	return Threshold{RuleID: strings.TrimSpace(parts[0]), Max: max}, nil
+}
+
+// Thresholds returns the configured thresholds for rule IDs, or for every rule which sets max if none are given
+func (p *Party) Thresholds(ids []string) ([]Threshold, error) {
+	if len(ids) == 0 {
+		rs, err := p.ListRules()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			if r.Max != nil {
+				ids = append(ids, r.ID)
+			}
+		}
+		sort.Strings(ids)
+	}
+
+	ts := []Threshold{}
+	for _, id := range ids {
+		r, err := p.LookupRule(id)
+		if err != nil {
+			return nil, err
+		}
+		if r.Max == nil {
+			return nil, fmt.Errorf("rule %q has no max configured", id)
+		}
+		ts = append(ts, Threshold{RuleID: id, Max: *r.Max})
+	}
+	return ts, nil
+}
+
+// CheckThresholds evaluates the rule of each threshold once, counting the items it matches
+func (p *Party) CheckThresholds(ctx context.Context, ts []Threshold, newerThan time.Time) ([]*ThresholdResult, error) {
+	rs := []*ThresholdResult{}
+	for _, t := range ts {
+		r, err := p.LookupRule(t.RuleID)
+		if err != nil {
+			return nil, err
+		}
+
+		rr, err := p.ExecuteRule(ctx, provider.SearchParams{NewerThan: newerThan}, r, nil)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", t.RuleID, err)
+		}
+
+		rs = append(rs, &ThresholdResult{Threshold: t, Name: r.Name, Count: len(rr.Items)})
+	}
+	return rs, nil
+}
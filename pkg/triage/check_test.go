@@ -0,0 +1,59 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholds(t *testing.T) {
+	th, err := ParseThreshold("release-blockers=0")
+	assert.Nil(t, err)
+	assert.Equal(t, Threshold{RuleID: "release-blockers", Max: 0}, th)
+
+	for _, s := range []string{"release-blockers", "=1", "release-blockers=-1", "release-blockers=many"} {
+		_, err := ParseThreshold(s)
+		assert.NotNil(t, err, s)
+	}
+
+	p, err := New(Config{GitHubToken: "token"})
+	assert.Nil(t, err)
+	assert.Nil(t, p.Load(strings.NewReader(`
+settings:
+  repos: [https://github.com/org/a]
+collections:
+  - id: c
+    rules: [blockers, bugs]
+rules:
+  blockers:
+    max: 0
+    filters: [{label: release-blocker}]
+  bugs:
+    filters: [{label: bug}]
+`)))
+
+	ts, err := p.Thresholds(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []Threshold{{RuleID: "blockers", Max: 0}}, ts)
+
+	_, err = p.Thresholds([]string{"bugs"})
+	assert.NotNil(t, err)
+
+	assert.True(t, (&ThresholdResult{Threshold: th, Count: 1}).Exceeded())
+	assert.False(t, (&ThresholdResult{Threshold: th}).Exceeded())
+}
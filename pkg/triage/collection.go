@@ -35,6 +35,9 @@ type Collection struct {
 	Hidden       bool     `yaml:"hidden,omitempty"`
 	UsedForStats bool     `yaml:"used_for_statistics,omitempty"`
 
+	// Access restricts who may see this collection, overriding the site-wide policy
+	Access *AccessPolicy `yaml:"access,omitempty"`
+
 	// Kanban option
 	Display  string `yaml:"display"`
 	Overflow int    `yaml:"overflow"`
@@ -155,11 +158,16 @@ func avgDayDuration(total float64, count int) time.Duration {
 
 // ListCollections a fully resolved collections
 func (p *Party) ListCollections() ([]Collection, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.collections, nil
 }
 
 // Return a fully resolved collection
 func (p *Party) LookupCollection(id string) (Collection, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	for _, s := range p.collections {
 		if s.ID == id {
 			return s, nil
@@ -0,0 +1,63 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envRe matches ${VAR} and ${VAR:-default} references, as well as $${ escapes
+var envRe = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// commentRe matches YAML comment lines, which are not interpolated
+var commentRe = regexp.MustCompile(`^\s*#`)
+
+// interpolate replaces environment variable references within a config
+func interpolate(bs []byte) ([]byte, error) {
+	var missing []string
+	lines := bytes.SplitAfter(bs, []byte("\n"))
+	for i, l := range lines {
+		if commentRe.Match(l) {
+			continue
+		}
+
+		lines[i] = envRe.ReplaceAllFunc(l, func(m []byte) []byte {
+			if string(m) == "$${" {
+				return []byte("${")
+			}
+
+			sm := envRe.FindSubmatch(m)
+			v, ok := os.LookupEnv(string(sm[1]))
+			// As in the shell, a default replaces an empty value, but only an unset variable is an error
+			if len(sm[2]) > 0 && v == "" {
+				return sm[3]
+			}
+			if ok {
+				return []byte(v)
+			}
+
+			missing = append(missing, string(sm[1]))
+			return m
+		})
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined environment variables: %v", missing)
+	}
+	return bytes.Join(lines, nil), nil
+}
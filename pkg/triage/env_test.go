@@ -0,0 +1,59 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	os.Setenv("TP_TEST_REPO", "https://github.com/org/repo")
+	os.Setenv("TP_TEST_EMPTY", "")
+	defer os.Unsetenv("TP_TEST_REPO")
+	defer os.Unsetenv("TP_TEST_EMPTY")
+
+	var tests = []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "repos: [${TP_TEST_REPO}]", want: "repos: [https://github.com/org/repo]"},
+		{in: "lead: ${TP_TEST_UNSET:-alice}", want: "lead: alice"},
+		{in: "lead: ${TP_TEST_EMPTY:-alice}", want: "lead: alice"},
+		{in: "lead: ${TP_TEST_UNSET:-}", want: "lead: "},
+		{in: "lead: ${TP_TEST_UNSET}", wantErr: true},
+		{in: "lead: ${TP_TEST_EMPTY}", want: "lead: "},
+		{in: "title: $${TP_TEST_REPO}", want: "title: ${TP_TEST_REPO}"},
+		{in: "title: ^fix$", want: "title: ^fix$"},
+		{in: "title: cost$$", want: "title: cost$$"},
+		{in: "# set ${TP_TEST_UNSET} to override", want: "# set ${TP_TEST_UNSET} to override"},
+		{in: "  # costs $$${TP_TEST_REPO}", want: "  # costs $$${TP_TEST_REPO}"},
+		{in: "repos: [${TP_TEST_REPO}] # not ${TP_TEST_UNSET:-here}", want: "repos: [https://github.com/org/repo] # not here"},
+		{in: "# ${TP_TEST_UNSET}\nrepos: [${TP_TEST_REPO}]\n", want: "# ${TP_TEST_UNSET}\nrepos: [https://github.com/org/repo]\n"},
+	}
+
+	for _, tc := range tests {
+		got, err := interpolate([]byte(tc.in))
+		if tc.wantErr {
+			assert.Error(t, err, tc.in)
+			continue
+		}
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, string(got), tc.in)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+)
+
+// resolveExtends returns rules with the fields they inherit via extends filled in
+func resolveExtends(raw map[string]Rule) (map[string]Rule, error) {
+	resolved := map[string]Rule{}
+	for id := range raw {
+		if _, err := resolveRule(raw, resolved, id, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// resolveRule resolves the inheritance chain of a single rule
+func resolveRule(raw map[string]Rule, resolved map[string]Rule, id string, visiting map[string]bool) (Rule, error) {
+	if r, ok := resolved[id]; ok {
+		return r, nil
+	}
+
+	r, ok := raw[id]
+	if !ok {
+		return r, fmt.Errorf("rule %q is undefined", id)
+	}
+
+	if r.Extends != "" {
+		if visiting[id] {
+			return r, fmt.Errorf("rule %q extends itself", id)
+		}
+		visiting[id] = true
+
+		base, err := resolveRule(raw, resolved, r.Extends, visiting)
+		if err != nil {
+			return r, fmt.Errorf("%q extends: %w", id, err)
+		}
+		r = inherit(base, r)
+	}
+
+	resolved[id] = r
+	return r, nil
+}
+
+// inherit returns a rule with unset fields taken from its base, and filters merged into the base filters
+func inherit(base Rule, r Rule) Rule {
+	if r.Resolution == "" {
+		r.Resolution = base.Resolution
+	}
+	if r.Name == "" {
+		r.Name = base.Name
+	}
+	if len(r.Repos) == 0 {
+		r.Repos = base.Repos
+	}
+	if r.Type == "" {
+		r.Type = base.Type
+	}
+	if r.Comment == "" {
+		r.Comment = base.Comment
+	}
+	if r.Stale == nil {
+		r.Stale = base.Stale
+	}
+	if r.AutoAssign == nil {
+		r.AutoAssign = base.AutoAssign
+	}
+	if r.SLO == nil {
+		r.SLO = base.SLO
+	}
+	if len(r.Escalation) == 0 {
+		r.Escalation = base.Escalation
+	}
+	if r.Max == nil {
+		r.Max = base.Max
+	}
+	if r.Sort == "" {
+		r.Sort = base.Sort
+	}
+	if len(r.Overrides) == 0 {
+		r.Overrides = base.Overrides
+	}
+
+	r.Filters = mergeFilters(base.Filters, r.Filters)
+	return r
+}
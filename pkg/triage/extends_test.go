@@ -0,0 +1,41 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveExtends(t *testing.T) {
+	raw := map[string]Rule{
+		"stale":      {Name: "Stale", Type: "issue", Filters: []provider.Filter{{RawTag: "!commented"}, {Updated: "+30d"}}},
+		"stale-bugs": {Extends: "stale", Name: "Stale bugs", Filters: []provider.Filter{{RawLabel: "kind/bug"}}},
+		"old-bugs":   {Extends: "stale-bugs", Filters: []provider.Filter{{Updated: "+90d"}}},
+	}
+
+	rs, err := resolveExtends(raw)
+	assert.Nil(t, err)
+	assert.Equal(t, "Stale bugs", rs["stale-bugs"].Name)
+	assert.Equal(t, "issue", rs["stale-bugs"].Type)
+	assert.Equal(t, []provider.Filter{{RawTag: "!commented"}, {Updated: "+30d"}, {RawLabel: "kind/bug"}}, rs["stale-bugs"].Filters)
+	assert.Equal(t, []provider.Filter{{RawTag: "!commented"}, {Updated: "+90d"}, {RawLabel: "kind/bug"}}, rs["old-bugs"].Filters)
+
+	raw["stale"] = Rule{Extends: "old-bugs"}
+	_, err = resolveExtends(raw)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// groupPrefix marks a reference to a member group, such as @maintainers
+const groupPrefix = "@"
+
+// groupResolver expands references to named member groups, tracking which were used
+type groupResolver struct {
+	groups map[string][]string
+	used   map[string]bool
+}
+
+func newGroupResolver(groups map[string][]string) *groupResolver {
+	return &groupResolver{groups: groups, used: map[string]bool{}}
+}
+
+// expand replaces group references within a list of users with their members
+func (g *groupResolver) expand(users []string) ([]string, error) {
+	return g.expandWith(users, map[string]bool{})
+}
+
+func (g *groupResolver) expandWith(users []string, expanding map[string]bool) ([]string, error) {
+	out := []string{}
+	seen := map[string]bool{}
+
+	for _, u := range users {
+		if !strings.HasPrefix(u, groupPrefix) {
+			if !seen[u] {
+				out = append(out, u)
+				seen[u] = true
+			}
+			continue
+		}
+
+		name := strings.TrimPrefix(u, groupPrefix)
+		members, ok := g.groups[name]
+		if !ok {
+			return nil, fmt.Errorf("member group %q is undefined", name)
+		}
+		if expanding[name] {
+			return nil, fmt.Errorf("member group %q includes itself", name)
+		}
+		g.used[name] = true
+
+		expanding[name] = true
+		ms, err := g.expandWith(members, expanding)
+		delete(expanding, name)
+		if err != nil {
+			return nil, fmt.Errorf("member group %q: %w", name, err)
+		}
+
+		for _, m := range ms {
+			if !seen[m] {
+				out = append(out, m)
+				seen[m] = true
+			}
+		}
+	}
+	return out, nil
+}
+
+// unused returns the groups which were never referenced
+func (g *groupResolver) unused() []string {
+	unused := []string{}
+	for name := range g.groups {
+		if !g.used[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// membership returns the groups each user belongs to, including through nested groups
+func membership(groups map[string][]string) map[string][]string {
+	m := map[string][]string{}
+	names := []string{}
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g := newGroupResolver(groups)
+	for _, name := range names {
+		users, err := g.expand([]string{groupPrefix + name})
+		if err != nil {
+			continue
+		}
+		for _, u := range users {
+			m[u] = append(m[u], name)
+		}
+	}
+	return m
+}
+
+// MemberGroupsOf returns the names of the member groups a user belongs to
+func (p *Party) MemberGroupsOf(user string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return membership(p.settings.MemberGroups)[user]
+}
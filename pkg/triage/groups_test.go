@@ -0,0 +1,47 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupResolver(t *testing.T) {
+	g := newGroupResolver(map[string][]string{
+		"maintainers": {"alice", "bob"},
+		"oncall":      {"carol", "@maintainers"},
+		"bots":        {"robot"},
+	})
+
+	users, err := g.expand([]string{"dave", "@oncall", "alice"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dave", "carol", "alice", "bob"}, users)
+	assert.Equal(t, []string{"bots"}, g.unused())
+
+	_, err = g.expand([]string{"@typo"})
+	assert.NotNil(t, err)
+}
+
+func TestMembership(t *testing.T) {
+	m := membership(map[string][]string{
+		"maintainers": {"alice", "bob"},
+		"oncall":      {"carol", "@maintainers"},
+	})
+	assert.Equal(t, []string{"maintainers", "oncall"}, m["alice"])
+	assert.Equal(t, []string{"oncall"}, m["carol"])
+	assert.Nil(t, m["dave"])
+}
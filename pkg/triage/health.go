@@ -0,0 +1,122 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+)
+
+// defaultBugLabel matches common bug labels, such as "bug" or "kind/bug"
+const defaultBugLabel = `(^|/)bug$`
+
+// RepoHealth is an anonymized summary of a repository backlog
+type RepoHealth struct {
+	Repo string
+
+	OpenIssues       int
+	OpenPullRequests int
+	OpenBugs         int
+
+	// MedianWait is the median time open items have waited for a member response
+	MedianWait time.Duration
+
+	// ResponseSLA is how long items may wait before missing the SLA
+	ResponseSLA time.Duration
+	// SLAAttainment is the percentage of open items currently within the SLA
+	SLAAttainment float64
+}
+
+// BacklogHealth returns per-repository health statistics for the open conversations within cs
+func (p *Party) BacklogHealth(cs []*hubbub.Conversation) ([]*RepoHealth, error) {
+	ps := p.Settings().Public
+	if ps == nil {
+		return nil, fmt.Errorf("public page is not configured")
+	}
+
+	bugRe, err := regexp.Compile(defaultBugLabel)
+	if ps.BugLabel != "" {
+		bugRe, err = regexp.Compile(ps.BugLabel)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bug-label: %w", err)
+	}
+
+	var sla time.Duration
+	if ps.ResponseSLA != "" {
+		sla, _, _ = hubbub.ParseDuration(ps.ResponseSLA)
+	}
+
+	return repoHealth(cs, bugRe, sla), nil
+}
+
+// repoHealth summarizes conversations by repository
+func repoHealth(cs []*hubbub.Conversation, bugRe *regexp.Regexp, sla time.Duration) []*RepoHealth {
+	byRepo := map[string]*RepoHealth{}
+	waits := map[string][]time.Duration{}
+
+	for _, co := range cs {
+		if co.State != constants.OpenState && co.State != constants.OpenedState {
+			continue
+		}
+
+		repo := fmt.Sprintf("%s/%s", co.Organization, co.Project)
+		h := byRepo[repo]
+		if h == nil {
+			h = &RepoHealth{Repo: repo, ResponseSLA: sla}
+			byRepo[repo] = h
+		}
+
+		if co.Type == hubbub.PullRequest {
+			h.OpenPullRequests++
+		} else {
+			h.OpenIssues++
+			for _, l := range co.Labels {
+				if bugRe.MatchString(l.GetName()) {
+					h.OpenBugs++
+					break
+				}
+			}
+		}
+
+		waits[repo] = append(waits[repo], co.CurrentHoldTime)
+	}
+
+	hs := []*RepoHealth{}
+	for repo, h := range byRepo {
+		ws := waits[repo]
+		sort.Slice(ws, func(i, j int) bool { return ws[i] < ws[j] })
+		h.MedianWait = ws[len(ws)/2]
+
+		if sla > 0 {
+			within := 0
+			for _, w := range ws {
+				if w <= sla {
+					within++
+				}
+			}
+			h.SLAAttainment = float64(within) / float64(len(ws)) * 100
+		}
+		hs = append(hs, h)
+	}
+
+	sort.Slice(hs, func(i, j int) bool { return hs[i].Repo < hs[j].Repo })
+	return hs
+}
@@ -0,0 +1,306 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigChange describes a rule, collection or settings which differ between two configurations
+type ConfigChange struct {
+	// Kind is "rule", "collection" or "settings"
+	Kind string
+	ID   string
+	// Change is "added", "removed" or "changed"
+	Change string
+	// Fields are the YAML keys which changed
+	Fields []string
+}
+
+// RuleImpact is how the conversations matched by a rule differ between two configurations
+type RuleImpact struct {
+	ID      string
+	Before  int
+	After   int
+	Added   []string
+	Removed []string
+}
+
+// Move counts conversations which left one rule and entered another. An empty rule ID means no rule at all.
+type Move struct {
+	From string
+	To   string
+	URLs []string
+}
+
+// changedFields returns the YAML keys of struct fields which differ between two values of the same type
+func changedFields(a interface{}, b interface{}) []string {
+	va := reflect.ValueOf(a)
+	vb := reflect.ValueOf(b)
+	fields := []string{}
+
+	for i := 0; i < va.NumField(); i++ {
+		f := va.Type().Field(i)
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		ya, err := yaml.Marshal(va.Field(i).Interface())
+		if err != nil {
+			continue
+		}
+		yb, err := yaml.Marshal(vb.Field(i).Interface())
+		if err != nil {
+			continue
+		}
+		if string(ya) != string(yb) {
+			fields = append(fields, key)
+		}
+	}
+	return fields
+}
+
+// DiffConfigs returns how the settings, collections and rules of two loaded configurations differ
+func DiffConfigs(prev *Party, next *Party) ([]*ConfigChange, error) {
+	cs := []*ConfigChange{}
+
+	if fs := changedFields(prev.Settings(), next.Settings()); len(fs) > 0 {
+		cs = append(cs, &ConfigChange{Kind: "settings", Change: "changed", Fields: fs})
+	}
+
+	oc, err := prev.ListCollections()
+	if err != nil {
+		return nil, err
+	}
+	nc, err := next.ListCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	ocs := map[string]interface{}{}
+	for _, c := range oc {
+		ocs[c.ID] = c
+	}
+	ncs := map[string]interface{}{}
+	for _, c := range nc {
+		ncs[c.ID] = c
+	}
+	cs = append(cs, diffByID("collection", ocs, ncs)...)
+
+	or, err := prev.ListRules()
+	if err != nil {
+		return nil, err
+	}
+	nr, err := next.ListRules()
+	if err != nil {
+		return nil, err
+	}
+
+	ors := map[string]interface{}{}
+	for _, r := range or {
+		ors[r.ID] = r
+	}
+	nrs := map[string]interface{}{}
+	for _, r := range nr {
+		nrs[r.ID] = r
+	}
+	return append(cs, diffByID("rule", ors, nrs)...), nil
+}
+
+// diffByID compares two sets of structs by ID
+func diffByID(kind string, prev map[string]interface{}, next map[string]interface{}) []*ConfigChange {
+	ids := []string{}
+	for id := range prev {
+		ids = append(ids, id)
+	}
+	for id := range next {
+		if _, ok := prev[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	cs := []*ConfigChange{}
+	for _, id := range ids {
+		o, inOld := prev[id]
+		n, inNew := next[id]
+		switch {
+		case !inNew:
+			cs = append(cs, &ConfigChange{Kind: kind, ID: id, Change: "removed"})
+		case !inOld:
+			cs = append(cs, &ConfigChange{Kind: kind, ID: id, Change: "added"})
+		default:
+			if fs := changedFields(o, n); len(fs) > 0 {
+				cs = append(cs, &ConfigChange{Kind: kind, ID: id, Change: "changed", Fields: fs})
+			}
+		}
+	}
+	return cs
+}
+
+// ruleMatches returns the URLs matched by each rule within a collection
+func (p *Party) ruleMatches(ctx context.Context, newerThan time.Time) (map[string][]string, error) {
+	sts, err := p.ListCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	ms := map[string][]string{}
+	for _, s := range sts {
+		for _, id := range s.RuleIDs {
+			if _, ok := ms[id]; ok {
+				continue
+			}
+
+			t, err := p.LookupRule(id)
+			if err != nil {
+				return nil, err
+			}
+
+			rr, err := p.ExecuteRule(ctx, provider.SearchParams{NewerThan: newerThan}, t, nil)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", id, err)
+			}
+
+			ms[id] = []string{}
+			for _, co := range rr.Items {
+				ms[id] = append(ms[id], co.URL)
+			}
+		}
+	}
+	return ms, nil
+}
+
+// Impact evaluates the rules of two configurations, using cached data newer than a time, and returns how their matches differ
+func Impact(ctx context.Context, prev *Party, next *Party, newerThan time.Time) ([]*RuleImpact, []*Move, error) {
+	before, err := prev.ruleMatches(ctx, newerThan)
+	if err != nil {
+		return nil, nil, fmt.Errorf("old configuration: %w", err)
+	}
+
+	after, err := next.ruleMatches(ctx, newerThan)
+	if err != nil {
+		return nil, nil, fmt.Errorf("new configuration: %w", err)
+	}
+
+	is, ms := compareMatches(before, after)
+	return is, ms, nil
+}
+
+// compareMatches compares the URLs matched by rules before and after a change
+func compareMatches(before map[string][]string, after map[string][]string) ([]*RuleImpact, []*Move) {
+	ids := map[string]bool{}
+	inBefore := map[string]map[string]bool{}
+	inAfter := map[string]map[string]bool{}
+
+	index := func(ms map[string][]string, in map[string]map[string]bool) {
+		for id, urls := range ms {
+			ids[id] = true
+			for _, u := range urls {
+				if in[u] == nil {
+					in[u] = map[string]bool{}
+				}
+				in[u][id] = true
+			}
+		}
+	}
+	index(before, inBefore)
+	index(after, inAfter)
+
+	is := []*RuleImpact{}
+	for id := range ids {
+		ri := &RuleImpact{ID: id, Before: len(before[id]), After: len(after[id])}
+		for _, u := range after[id] {
+			if !inBefore[u][id] {
+				ri.Added = append(ri.Added, u)
+			}
+		}
+		for _, u := range before[id] {
+			if !inAfter[u][id] {
+				ri.Removed = append(ri.Removed, u)
+			}
+		}
+		if len(ri.Added) > 0 || len(ri.Removed) > 0 || ri.Before != ri.After {
+			sort.Strings(ri.Added)
+			sort.Strings(ri.Removed)
+			is = append(is, ri)
+		}
+	}
+	sort.Slice(is, func(i, j int) bool { return is[i].ID < is[j].ID })
+
+	urls := map[string]bool{}
+	for u := range inBefore {
+		urls[u] = true
+	}
+	for u := range inAfter {
+		urls[u] = true
+	}
+
+	moves := map[[2]string]*Move{}
+	for u := range urls {
+		left := []string{}
+		for id := range inBefore[u] {
+			if !inAfter[u][id] {
+				left = append(left, id)
+			}
+		}
+		entered := []string{}
+		for id := range inAfter[u] {
+			if !inBefore[u][id] {
+				entered = append(entered, id)
+			}
+		}
+
+		// Conversations which only lost or gained rules move from or to no rule if they have none left
+		if len(entered) == 0 && len(left) > 0 && len(inAfter[u]) == 0 {
+			entered = []string{""}
+		}
+		if len(left) == 0 && len(entered) > 0 && len(inBefore[u]) == 0 {
+			left = []string{""}
+		}
+
+		for _, from := range left {
+			for _, to := range entered {
+				k := [2]string{from, to}
+				if moves[k] == nil {
+					moves[k] = &Move{From: from, To: to}
+				}
+				moves[k].URLs = append(moves[k].URLs, u)
+			}
+		}
+	}
+
+	ms := []*Move{}
+	for _, m := range moves {
+		sort.Strings(m.URLs)
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if len(ms[i].URLs) != len(ms[j].URLs) {
+			return len(ms[i].URLs) > len(ms[j].URLs)
+		}
+		return ms[i].From+" "+ms[i].To < ms[j].From+" "+ms[j].To
+	})
+	return is, ms
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareMatches(t *testing.T) {
+	before := map[string][]string{"untriaged": {"a", "b", "c"}, "stale": {"d"}}
+	after := map[string][]string{"untriaged": {"a"}, "needs-review": {"b", "c"}, "stale": {"d", "e"}}
+
+	is, ms := compareMatches(before, after)
+	assert.Equal(t, []*RuleImpact{
+		{ID: "needs-review", Before: 0, After: 2, Added: []string{"b", "c"}},
+		{ID: "stale", Before: 1, After: 2, Added: []string{"e"}},
+		{ID: "untriaged", Before: 3, After: 1, Removed: []string{"b", "c"}},
+	}, is)
+	assert.Equal(t, []*Move{
+		{From: "untriaged", To: "needs-review", URLs: []string{"b", "c"}},
+		{From: "", To: "stale", URLs: []string{"e"}},
+	}, ms)
+
+	cs := diffByID("rule",
+		map[string]interface{}{"a": Rule{Name: "A"}, "b": Rule{Name: "B"}},
+		map[string]interface{}{"a": Rule{Name: "A2", Type: "issue"}, "c": Rule{Name: "C"}})
+	assert.Equal(t, []*ConfigChange{
+		{Kind: "rule", ID: "a", Change: "changed", Fields: []string{"name", "type"}},
+		{Kind: "rule", ID: "b", Change: "removed"},
+		{Kind: "rule", ID: "c", Change: "added"},
+	}, cs)
+}
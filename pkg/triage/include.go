@@ -0,0 +1,140 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+
+	"github.com/google/triage-party/pkg/provider"
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+)
+
+// LoadFile loads a YAML config from a file. Includes are relative to the directory of the file.
+func (p *Party) LoadFile(path string) error {
+	return p.LoadFiles([]string{path})
+}
+
+// readConfig parses a YAML config read from path, or from elsewhere if path is empty, merging in the files it includes.
+// Every file read is added to seen.
+func readConfig(bs []byte, path string, seen map[string]bool) (*diskConfig, error) {
+	if path == "" {
+		return readIncludes(bs, ".", nil, map[string]bool{}, seen)
+	}
+	return readIncludes(bs, filepath.Dir(path), []string{path}, map[string]bool{}, seen)
+}
+
+// readIncludes parses a YAML config, merging in the files it includes.
+// stack is the chain of files which included this one, and merged the files already merged into the top-level config.
+func readIncludes(bs []byte, dir string, stack []string, merged map[string]bool, seen map[string]bool) (*diskConfig, error) {
+	bs, err := interpolate(bs)
+	if err != nil {
+		return nil, err
+	}
+
+	dc := &diskConfig{}
+	if err := yaml.Unmarshal(bs, &dc); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	for _, pattern := range dc.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("include %q: %w", pattern, err)
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("include %q: no such file", pattern)
+		}
+		// The directory is watched too, so that files added to or removed from the match trigger a reload
+		seen[filepath.Dir(pattern)] = true
+
+		for _, path := range paths {
+			for _, s := range stack {
+				if s == path {
+					return nil, fmt.Errorf("include %q: includes itself", path)
+				}
+			}
+			// A file included by several others, such as shared macros, is merged once
+			if merged[path] {
+				continue
+			}
+			merged[path] = true
+			seen[path] = true
+
+			ibs, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("include: %w", err)
+			}
+			klog.Infof("%d bytes read from %s", len(ibs), path)
+
+			inc, err := readIncludes(ibs, filepath.Dir(path), append(stack, path), merged, seen)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+
+			if err := mergeConfig(dc, inc); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	return dc, nil
+}
+
+// mergeConfig merges the rules, macros, collections and responses of an included config into dc
+func mergeConfig(dc *diskConfig, inc *diskConfig) error {
+	if !reflect.DeepEqual(inc.Settings, Settings{}) {
+		return fmt.Errorf("settings may only be set in the top-level config")
+	}
+
+	if dc.RawRules == nil {
+		dc.RawRules = map[string]Rule{}
+	}
+	for id, r := range inc.RawRules {
+		if _, ok := dc.RawRules[id]; ok {
+			return fmt.Errorf("rule %q is already defined", id)
+		}
+		dc.RawRules[id] = r
+	}
+
+	if dc.Responses == nil {
+		dc.Responses = map[string]Response{}
+	}
+	for id, r := range inc.Responses {
+		if _, ok := dc.Responses[id]; ok {
+			return fmt.Errorf("response %q is already defined", id)
+		}
+		dc.Responses[id] = r
+	}
+
+	if dc.Macros == nil {
+		dc.Macros = map[string][]provider.Filter{}
+	}
+	for name, fs := range inc.Macros {
+		if _, ok := dc.Macros[name]; ok {
+			return fmt.Errorf("macro %q is already defined", name)
+		}
+		dc.Macros[name] = fs
+	}
+
+	dc.RawCollections = append(dc.RawCollections, inc.RawCollections...)
+	return nil
+}
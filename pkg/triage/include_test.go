@@ -0,0 +1,135 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles writes files to a temporary directory, returning it
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "include")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return dir
+}
+
+// ruleIDs returns the IDs of the rules within a config, sorted
+func ruleIDs(dc *diskConfig) []string {
+	ids := []string{}
+	for id := range dc.RawRules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestIncludeGlob(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml":     "include: [rules/*.yaml]\nrules: {top: {name: Top}}\n",
+		"rules/bugs.yaml": "rules: {bugs: {name: Bugs}}\n",
+		"rules/prs.yaml":  "rules: {prs: {name: PRs}}\n",
+		"rules/README.md": "not YAML",
+		"other/skip.yaml": "rules: {skip: {name: Skip}}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	dc, seen, err := readFiles([]string{filepath.Join(dir, "config.yaml")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bugs", "prs", "top"}, ruleIDs(dc))
+	assert.True(t, seen[filepath.Join(dir, "rules", "prs.yaml")], "included files are watched")
+
+	dir = writeFiles(t, map[string]string{"config.yaml": "include: [rules/*.yaml]\n"})
+	defer os.RemoveAll(dir)
+	_, _, err = readFiles([]string{filepath.Join(dir, "config.yaml")})
+	assert.Error(t, err, "a pattern matching nothing is an error")
+}
+
+func TestIncludeRelative(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"team/config.yaml":     "include: [rules/bugs.yaml]\n",
+		"team/rules/bugs.yaml": "include: [../../shared/prs.yaml]\nrules: {bugs: {name: Bugs}}\n",
+		"shared/prs.yaml":      "rules: {prs: {name: PRs}}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	dc, _, err := readFiles([]string{filepath.Join(dir, "team", "config.yaml")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bugs", "prs"}, ruleIDs(dc), "includes are relative to the file including them")
+}
+
+func TestIncludeDiamond(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": "include: [a.yaml, b.yaml]\n",
+		"a.yaml":      "include: [macros.yaml]\nrules: {a: {name: A}}\n",
+		"b.yaml":      "include: [macros.yaml]\nrules: {b: {name: B}}\n",
+		"macros.yaml": "rules: {shared: {name: Shared}}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	dc, _, err := readFiles([]string{filepath.Join(dir, "config.yaml")})
+	assert.NoError(t, err, "a file may be included by several others")
+	assert.Equal(t, []string{"a", "b", "shared"}, ruleIDs(dc))
+}
+
+func TestIncludeErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"self", map[string]string{"config.yaml": "include: [config.yaml]\n"}, "includes itself"},
+		{"cycle", map[string]string{
+			"config.yaml": "include: [a.yaml]\n",
+			"a.yaml":      "include: [b.yaml]\n",
+			"b.yaml":      "include: [a.yaml]\n",
+		}, "includes itself"},
+		{"settings", map[string]string{
+			"config.yaml": "include: [a.yaml]\n",
+			"a.yaml":      "settings: {name: included}\n",
+		}, "settings may only be set in the top-level config"},
+		{"redefined", map[string]string{
+			"config.yaml": "include: [a.yaml]\nrules: {bugs: {name: Bugs}}\n",
+			"a.yaml":      "rules: {bugs: {name: Other}}\n",
+		}, "already defined"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeFiles(t, tc.files)
+			defer os.RemoveAll(dir)
+
+			_, _, err := readFiles([]string{filepath.Join(dir, "config.yaml")})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/triage-party/pkg/provider"
+	"gopkg.in/yaml.v2"
+)
+
+// lint returns warnings about rules which are unused, can never match, or are shadowed by an earlier rule
+func lint(collections []Collection, rules map[string]Rule) []string {
+	warnings := []string{}
+
+	used := map[string]bool{}
+	for _, c := range collections {
+		for _, id := range c.RuleIDs {
+			used[id] = true
+		}
+	}
+
+	ids := []string{}
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if !used[id] {
+			warnings = append(warnings, fmt.Sprintf("rule %q is not used by any collection", id))
+		}
+		if why := contradiction(rules[id].Filters); why != "" {
+			warnings = append(warnings, fmt.Sprintf("rule %q can never match: %s", id, why))
+		}
+	}
+
+	for _, c := range collections {
+		for i, id := range c.RuleIDs {
+			r, ok := rules[id]
+			if !ok {
+				continue
+			}
+			for _, prev := range c.RuleIDs[:i] {
+				p, ok := rules[prev]
+				if ok && prev != id && shadows(p, r) {
+					warnings = append(warnings, fmt.Sprintf("collection %q: rule %q is shadowed by the earlier rule %q, so all of its items are duplicates", c.ID, id, prev))
+					break
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// contradiction describes why a set of filters can never match, or returns "" if it may
+func contradiction(fs []provider.Filter) string {
+	required := map[string]bool{}
+	excluded := map[string]bool{}
+	states := map[string]bool{}
+
+	for _, f := range fs {
+		for kind, raw := range map[string]string{"label": f.RawLabel, "tag": f.RawTag, "title": f.RawTitle, "milestone": f.RawMilestone, "author": f.RawAuthor} {
+			if raw == "" {
+				continue
+			}
+			v, negate := negativeFilter(raw)
+			key := kind + " " + v
+			if negate {
+				excluded[key] = true
+			} else {
+				required[key] = true
+			}
+			if required[key] && excluded[key] {
+				return fmt.Sprintf("%s %q is both required and excluded", kind, v)
+			}
+		}
+
+		if f.State != "" && f.State != "all" {
+			states[f.State] = true
+		}
+	}
+
+	if len(states) > 1 {
+		list := []string{}
+		for s := range states {
+			list = append(list, s)
+		}
+		sort.Strings(list)
+		return fmt.Sprintf("state must be each of %s", strings.Join(list, ", "))
+	}
+	return ""
+}
+
+// negativeFilter parses a filter value with an optional leading !
+func negativeFilter(s string) (string, bool) {
+	if strings.HasPrefix(s, "!") {
+		return s[1:], true
+	}
+	return s, false
+}
+
+// shadows returns whether every item matched by rule b is also matched by rule a, because a's filters are a subset of b's
+func shadows(a Rule, b Rule) bool {
+	if a.Type != b.Type || strings.Join(a.Repos, ",") != strings.Join(b.Repos, ",") {
+		return false
+	}
+	if len(a.Overrides) > 0 || len(b.Overrides) > 0 {
+		return false
+	}
+
+	bfs := map[string]bool{}
+	for _, f := range b.Filters {
+		bfs[filterString(f)] = true
+	}
+
+	for _, f := range a.Filters {
+		if !bfs[filterString(f)] {
+			return false
+		}
+	}
+	return true
+}
+
+// filterString returns a canonical representation of a filter
+func filterString(f provider.Filter) string {
+	bs, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Sprintf("%+v", f)
+	}
+	return string(bs)
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	rules := map[string]Rule{
+		"recv":          {Type: "issue", Filters: []provider.Filter{{RawTag: "recv"}}},
+		"question-recv": {Type: "issue", Filters: []provider.Filter{{RawTag: "recv"}, {RawLabel: "question"}}},
+		"bug-recv":      {Type: "pull_request", Filters: []provider.Filter{{RawTag: "recv"}, {RawLabel: "bug"}}},
+		"impossible":    {Filters: []provider.Filter{{RawLabel: "bug"}, {RawLabel: "!bug"}}},
+		"unused":        {},
+	}
+	collections := []Collection{{ID: "c", RuleIDs: []string{"recv", "question-recv", "bug-recv", "impossible"}}}
+
+	assert.Equal(t, []string{
+		`rule "impossible" can never match: label "bug" is both required and excluded`,
+		`rule "unused" is not used by any collection`,
+		`collection "c": rule "question-recv" is shadowed by the earlier rule "recv", so all of its items are duplicates`,
+	}, lint(collections, rules))
+}
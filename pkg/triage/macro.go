@@ -0,0 +1,87 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// expandMacros replaces filters which use a macro with the filters it defines, and returns any unused macros
+func expandMacros(raw map[string]Rule, macros map[string][]provider.Filter) (map[string]Rule, []string, error) {
+	used := map[string]bool{}
+	rules := map[string]Rule{}
+
+	for id, r := range raw {
+		fs, err := expandFilters(r.Filters, macros, used, map[string]bool{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("rule %q: %w", id, err)
+		}
+		r.Filters = fs
+
+		overrides := []RuleOverride{}
+		for _, o := range r.Overrides {
+			o.Filters, err = expandFilters(o.Filters, macros, used, map[string]bool{})
+			if err != nil {
+				return nil, nil, fmt.Errorf("rule %q override: %w", id, err)
+			}
+			overrides = append(overrides, o)
+		}
+		if len(overrides) > 0 {
+			r.Overrides = overrides
+		}
+		rules[id] = r
+	}
+
+	unused := []string{}
+	for name := range macros {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return rules, unused, nil
+}
+
+// expandFilters expands the macros used within a list of filters, which may themselves use macros
+func expandFilters(fs []provider.Filter, macros map[string][]provider.Filter, used map[string]bool, expanding map[string]bool) ([]provider.Filter, error) {
+	out := []provider.Filter{}
+	for _, f := range fs {
+		if f.Use == "" {
+			out = append(out, f)
+			continue
+		}
+
+		mfs, ok := macros[f.Use]
+		if !ok {
+			return nil, fmt.Errorf("macro %q is undefined", f.Use)
+		}
+		if expanding[f.Use] {
+			return nil, fmt.Errorf("macro %q uses itself", f.Use)
+		}
+		used[f.Use] = true
+
+		expanding[f.Use] = true
+		efs, err := expandFilters(mfs, macros, used, expanding)
+		delete(expanding, f.Use)
+		if err != nil {
+			return nil, fmt.Errorf("macro %q: %w", f.Use, err)
+		}
+		out = append(out, efs...)
+	}
+	return out, nil
+}
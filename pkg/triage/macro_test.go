@@ -0,0 +1,42 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMacros(t *testing.T) {
+	macros := map[string][]provider.Filter{
+		"needs-member-response": {{RawTag: "recv"}, {Responded: "+3d"}},
+		"bugs":                  {{RawLabel: "kind/bug"}, {Use: "needs-member-response"}},
+		"unused":                {{State: "closed"}},
+	}
+	raw := map[string]Rule{
+		"bugs": {Filters: []provider.Filter{{Use: "bugs"}, {Created: "+1w"}}},
+	}
+
+	rs, unused, err := expandMacros(raw, macros)
+	assert.Nil(t, err)
+	assert.Equal(t, []provider.Filter{{RawLabel: "kind/bug"}, {RawTag: "recv"}, {Responded: "+3d"}, {Created: "+1w"}}, rs["bugs"].Filters)
+	assert.Equal(t, []string{"unused"}, unused)
+
+	raw["typo"] = Rule{Filters: []provider.Filter{{Use: "bgus"}}}
+	_, _, err = expandMacros(raw, macros)
+	assert.NotNil(t, err)
+}
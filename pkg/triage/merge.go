@@ -0,0 +1,201 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// ConfigPaths is a flag listing configuration sources, which may be repeated or comma-separated
+type ConfigPaths []string
+
+func (c *ConfigPaths) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *ConfigPaths) Set(v string) error {
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			*c = append(*c, p)
+		}
+	}
+	return nil
+}
+
+// LoadFiles loads and merges configuration sources, each a file or a directory of YAML files.
+// Later sources take precedence over earlier ones.
+func (p *Party) LoadFiles(paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no configuration paths")
+	}
+
+	dc, seen, err := readFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	if err := p.load(dc); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paths = paths
+	p.files = stampFiles(seen)
+	return nil
+}
+
+// readFiles reads and merges configuration sources, returning the files and directories which were read
+func readFiles(paths []string) (*diskConfig, map[string]bool, error) {
+	var dc *diskConfig
+	seen := map[string]bool{}
+
+	for _, path := range paths {
+		files, dir, err := configFiles(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Directories are watched too, so that added or removed files trigger a reload
+		if dir != "" {
+			seen[dir] = true
+		}
+
+		for _, f := range files {
+			abs, err := filepath.Abs(f)
+			if err != nil {
+				return nil, nil, fmt.Errorf("abs: %w", err)
+			}
+			if seen[abs] {
+				return nil, nil, fmt.Errorf("%s: loaded twice", abs)
+			}
+			seen[abs] = true
+
+			bs, err := ioutil.ReadFile(abs)
+			if err != nil {
+				return nil, nil, fmt.Errorf("read: %w", err)
+			}
+
+			src, err := readConfig(bs, abs, seen)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", abs, err)
+			}
+
+			if dc == nil {
+				dc = src
+				continue
+			}
+			klog.Infof("overlaying %s", abs)
+			overlayConfig(dc, src)
+		}
+	}
+	return dc, seen, nil
+}
+
+// configFiles returns the files of a configuration source: the path itself, or the YAML files within a directory, along with the directory
+func configFiles(path string) ([]string, string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("stat: %w", err)
+	}
+	if !fi.IsDir() {
+		return []string{path}, "", nil
+	}
+
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("abs: %w", err)
+	}
+
+	files := []string{}
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		fs, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, "", fmt.Errorf("glob: %w", err)
+		}
+		files = append(files, fs...)
+	}
+	if len(files) == 0 {
+		return nil, "", fmt.Errorf("%s: no YAML files found", path)
+	}
+	sort.Strings(files)
+	return files, dir, nil
+}
+
+// overlayConfig merges a configuration source into dc, replacing settings, collections, rules, macros and responses it redefines
+func overlayConfig(dc *diskConfig, src *diskConfig) {
+	overlaySettings(reflect.ValueOf(&dc.Settings).Elem(), reflect.ValueOf(src.Settings))
+
+	for _, c := range src.RawCollections {
+		replaced := false
+		for i := range dc.RawCollections {
+			if dc.RawCollections[i].ID == c.ID {
+				dc.RawCollections[i] = c
+				replaced = true
+			}
+		}
+		if !replaced {
+			dc.RawCollections = append(dc.RawCollections, c)
+		}
+	}
+
+	if dc.RawRules == nil {
+		dc.RawRules = map[string]Rule{}
+	}
+	for id, r := range src.RawRules {
+		dc.RawRules[id] = r
+	}
+
+	if dc.Responses == nil {
+		dc.Responses = map[string]Response{}
+	}
+	for id, r := range src.Responses {
+		dc.Responses[id] = r
+	}
+
+	if dc.Macros == nil {
+		dc.Macros = map[string][]provider.Filter{}
+	}
+	for name, fs := range src.Macros {
+		dc.Macros[name] = fs
+	}
+}
+
+// overlaySettings replaces fields of dst which are set in src. Map entries are merged rather than replaced.
+func overlaySettings(dst reflect.Value, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		sf := src.Field(i)
+		if sf.IsZero() {
+			continue
+		}
+
+		df := dst.Field(i)
+		if sf.Kind() == reflect.Map && !df.IsNil() {
+			for _, k := range sf.MapKeys() {
+				df.SetMapIndex(k, sf.MapIndex(k))
+			}
+			continue
+		}
+		df.Set(sf)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverlayConfig(t *testing.T) {
+	dc := &diskConfig{
+		Settings: Settings{
+			Name:         "platform",
+			Repos:        []string{"https://github.com/org/a"},
+			MemberGroups: map[string][]string{"bots": {"robot"}},
+		},
+		RawCollections: []Collection{{ID: "daily", RuleIDs: []string{"untriaged"}}},
+		RawRules:       map[string]Rule{"untriaged": {Name: "Untriaged"}},
+	}
+
+	overlayConfig(dc, &diskConfig{
+		Settings: Settings{
+			Name:         "team",
+			MemberGroups: map[string][]string{"oncall": {"alice"}},
+		},
+		RawCollections: []Collection{{ID: "daily", RuleIDs: []string{"mine"}}, {ID: "team"}},
+		RawRules:       map[string]Rule{"untriaged": {Name: "Team untriaged"}, "mine": {Name: "Mine"}},
+	})
+
+	assert.Equal(t, "team", dc.Settings.Name)
+	assert.Equal(t, []string{"https://github.com/org/a"}, dc.Settings.Repos)
+	assert.Equal(t, map[string][]string{"bots": {"robot"}, "oncall": {"alice"}}, dc.Settings.MemberGroups)
+	assert.Equal(t, []Collection{{ID: "daily", RuleIDs: []string{"mine"}}, {ID: "team"}}, dc.RawCollections)
+	assert.Equal(t, "Team untriaged", dc.RawRules["untriaged"].Name)
+	assert.Equal(t, "Mine", dc.RawRules["mine"].Name)
+}
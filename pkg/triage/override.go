@@ -0,0 +1,104 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// RuleOverride replaces parts of a rule for particular repositories
+type RuleOverride struct {
+	// Repos are the repository URLs the override applies to
+	Repos []string `yaml:"repos"`
+	// Filters replace rule filters which set the same fields, and are otherwise added
+	Filters []provider.Filter `yaml:"filters,omitempty"`
+	// Stale replaces the stale policy of the rule
+	Stale *StalePolicy `yaml:"stale,omitempty"`
+}
+
+// ForRepo returns the rule with any overrides for a repository applied
+func (r Rule) ForRepo(repo provider.Repo) Rule {
+	for _, o := range r.Overrides {
+		if !o.appliesTo(repo) {
+			continue
+		}
+
+		r.Filters = mergeFilters(r.Filters, o.Filters)
+		if o.Stale != nil {
+			r.Stale = o.Stale
+		}
+	}
+	return r
+}
+
+// appliesTo returns whether an override applies to a repository
+func (o RuleOverride) appliesTo(repo provider.Repo) bool {
+	for _, u := range o.Repos {
+		or, err := parseRepo(u)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(or.Host, repo.Host) && strings.EqualFold(or.Organization, repo.Organization) &&
+			strings.EqualFold(or.Group, repo.Group) && strings.EqualFold(or.Project, repo.Project) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFilters replaces base filters with overrides that set the same thresholds, and appends the rest
+func mergeFilters(base []provider.Filter, overrides []provider.Filter) []provider.Filter {
+	merged := append([]provider.Filter{}, base...)
+	for _, o := range overrides {
+		key := filterKey(o)
+		replaced := false
+		for i, f := range merged {
+			if key != "" && filterKey(f) == key {
+				merged[i] = o
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+// filterKey returns the names of the fields a filter sets, such as "created".
+// Regular expression filters may be combined, so an empty key is returned for them.
+func filterKey(f provider.Filter) string {
+	if f.RawLabel != "" || f.RawTag != "" || f.RawTitle != "" || f.RawMilestone != "" || f.RawAuthor != "" {
+		return ""
+	}
+
+	v := reflect.ValueOf(f)
+	t := v.Type()
+
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("yaml")
+		if tag == "" || v.Field(i).Kind() != reflect.String || v.Field(i).String() == "" {
+			continue
+		}
+		names = append(names, strings.Split(tag, ",")[0])
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
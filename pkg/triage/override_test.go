@@ -0,0 +1,42 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForRepo(t *testing.T) {
+	r := Rule{
+		Filters: []provider.Filter{{RawTag: "recv"}, {Created: "+1w"}},
+		Stale:   &StalePolicy{WarnAfter: "30d", CloseAfter: "7d"},
+		Overrides: []RuleOverride{{
+			Repos:   []string{"https://github.com/org/docs"},
+			Filters: []provider.Filter{{Created: "+4w"}, {RawLabel: "!wontfix"}},
+			Stale:   &StalePolicy{WarnAfter: "90d", CloseAfter: "14d"},
+		}},
+	}
+
+	docs := r.ForRepo(provider.Repo{Host: "github.com", Organization: "org", Project: "docs"})
+	assert.Equal(t, []provider.Filter{{RawTag: "recv"}, {Created: "+4w"}, {RawLabel: "!wontfix"}}, docs.Filters)
+	assert.Equal(t, "90d", docs.Stale.WarnAfter)
+
+	code := r.ForRepo(provider.Repo{Host: "github.com", Organization: "org", Project: "code"})
+	assert.Equal(t, r.Filters, code.Filters)
+	assert.Equal(t, "30d", code.Stale.WarnAfter)
+}
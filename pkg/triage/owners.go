@@ -0,0 +1,247 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+)
+
+// defaultAreaPrefixes are the OWNERS labels which are areas by default
+var defaultAreaPrefixes = []string{"sig/", "area/"}
+
+// OwnersSettings maps items to areas, such as SIGs, using Kubernetes-style OWNERS files
+type OwnersSettings struct {
+	// Repo is the repository OWNERS files are read from, defaulting to the first repository
+	Repo string `yaml:"repo,omitempty"`
+	// Dirs are the directories whose OWNERS files declare areas, defaulting to the repository root
+	Dirs []string `yaml:"dirs,omitempty"`
+	// LabelPrefixes are which OWNERS labels are areas, defaulting to sig/ and area/
+	LabelPrefixes []string `yaml:"label-prefixes,omitempty"`
+	// Collection generates a collection for each area
+	Collection *AreaCollection `yaml:"collection,omitempty"`
+}
+
+// AreaCollection describes the collection generated for each area
+type AreaCollection struct {
+	// Rules are the IDs of the rules each area's collection is generated from, restricted to the area
+	Rules  []string `yaml:"rules"`
+	Dedup  bool     `yaml:"dedup,omitempty"`
+	Hidden bool     `yaml:"hidden,omitempty"`
+}
+
+// ownersFile is a Kubernetes-style OWNERS file
+type ownersFile struct {
+	Approvers []string                `yaml:"approvers,omitempty"`
+	Reviewers []string                `yaml:"reviewers,omitempty"`
+	Labels    []string                `yaml:"labels,omitempty"`
+	Filters   map[string]ownersFilter `yaml:"filters,omitempty"`
+}
+
+// ownersFilter applies to files matching a pattern within an OWNERS file
+type ownersFilter struct {
+	Approvers []string `yaml:"approvers,omitempty"`
+	Reviewers []string `yaml:"reviewers,omitempty"`
+	Labels    []string `yaml:"labels,omitempty"`
+}
+
+// ownersAliases is a Kubernetes-style OWNERS_ALIASES file
+type ownersAliases struct {
+	Aliases map[string][]string `yaml:"aliases"`
+}
+
+// parseOwners parses an OWNERS file, expanding aliases. Filters which apply to every file (".*") are merged in.
+func parseOwners(data []byte, aliases map[string][]string) (ownersFile, error) {
+	of := ownersFile{}
+	if err := yaml.Unmarshal(data, &of); err != nil {
+		return of, err
+	}
+
+	if all, ok := of.Filters[".*"]; ok {
+		of.Approvers = append(of.Approvers, all.Approvers...)
+		of.Reviewers = append(of.Reviewers, all.Reviewers...)
+		of.Labels = append(of.Labels, all.Labels...)
+	}
+
+	of.Approvers = expandAliases(of.Approvers, aliases)
+	of.Reviewers = expandAliases(of.Reviewers, aliases)
+	return of, nil
+}
+
+// expandAliases replaces aliases with their members
+func expandAliases(users []string, aliases map[string][]string) []string {
+	out := []string{}
+	for _, u := range users {
+		if members, ok := aliases[u]; ok {
+			out = append(out, members...)
+			continue
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+// isArea returns whether an OWNERS label is an area
+func isArea(label string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(label, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// readAreas reads the areas declared by OWNERS files
+func (p *Party) readAreas(ctx context.Context, o *OwnersSettings, repos []string) ([]hubbub.Area, error) {
+	repo := o.Repo
+	if repo == "" {
+		if len(repos) == 0 {
+			return nil, fmt.Errorf("no repository to read OWNERS files from")
+		}
+		repo = repos[0]
+	}
+
+	r, err := parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := p.Provider(r.Host)
+	if pr == nil {
+		return nil, fmt.Errorf("no token configured for %s", r.Host)
+	}
+	sp := provider.SearchParams{Repo: r}
+
+	aliases := ownersAliases{}
+	if data, _, err := pr.ReposGetFile(ctx, sp, "OWNERS_ALIASES"); err != nil {
+		klog.V(1).Infof("no OWNERS_ALIASES in %s: %v", repo, err)
+	} else if err := yaml.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("OWNERS_ALIASES: %w", err)
+	}
+
+	prefixes := o.LabelPrefixes
+	if len(prefixes) == 0 {
+		prefixes = defaultAreaPrefixes
+	}
+
+	dirs := o.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{""}
+	}
+
+	byLabel := map[string]*hubbub.Area{}
+	for _, d := range dirs {
+		d = strings.Trim(d, "/")
+		data, _, err := pr.ReposGetFile(ctx, sp, path.Join(d, "OWNERS"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Join(d, "OWNERS"), err)
+		}
+
+		of, err := parseOwners(data, aliases.Aliases)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Join(d, "OWNERS"), err)
+		}
+
+		for _, l := range of.Labels {
+			if !isArea(l, prefixes) {
+				continue
+			}
+			a := byLabel[l]
+			if a == nil {
+				a = &hubbub.Area{Label: l, Organization: r.Organization, Project: r.Project}
+				byLabel[l] = a
+			}
+			a.Dirs = append(a.Dirs, d)
+			a.Approvers = appendUnique(a.Approvers, of.Approvers)
+			a.Reviewers = appendUnique(a.Reviewers, of.Reviewers)
+		}
+	}
+
+	areas := []hubbub.Area{}
+	for _, a := range byLabel {
+		areas = append(areas, *a)
+	}
+	sort.Slice(areas, func(i, j int) bool { return areas[i].Label < areas[j].Label })
+	return areas, nil
+}
+
+// appendUnique appends the users which are not already listed
+func appendUnique(users []string, more []string) []string {
+	seen := map[string]bool{}
+	for _, u := range users {
+		seen[u] = true
+	}
+	for _, u := range more {
+		if !seen[u] {
+			seen[u] = true
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// areaID returns the collection ID of an area, such as sig-node
+func areaID(label string) string {
+	return strings.ReplaceAll(label, "/", "-")
+}
+
+// areaCollections generates a collection for each area, from rules restricted to the area, which are added to raw
+func areaCollections(areas []hubbub.Area, ac *AreaCollection, raw map[string]Rule, existing []Collection) ([]Collection, error) {
+	ids := map[string]bool{}
+	for _, c := range existing {
+		ids[c.ID] = true
+	}
+
+	cs := []Collection{}
+	for _, a := range areas {
+		c := Collection{
+			ID:          areaID(a.Label),
+			Name:        a.Label,
+			Description: fmt.Sprintf("Items in %s, as declared by OWNERS files", a.Label),
+			Dedup:       ac.Dedup,
+			Hidden:      ac.Hidden,
+		}
+		if ids[c.ID] {
+			return nil, fmt.Errorf("area %q: collection %q already exists", a.Label, c.ID)
+		}
+
+		for _, id := range ac.Rules {
+			rid := fmt.Sprintf("%s-%s", id, c.ID)
+			base, ok := raw[id]
+			if !ok {
+				return nil, fmt.Errorf("area %q: unknown rule %q", a.Label, id)
+			}
+
+			r := base
+			r.ID = rid
+			r.Name = fmt.Sprintf("%s: %s", base.Name, a.Label)
+			r.Filters = append(append([]provider.Filter{}, base.Filters...), provider.Filter{RawArea: "^" + regexp.QuoteMeta(a.Label) + "$"})
+
+			raw[rid] = r
+			c.RuleIDs = append(c.RuleIDs, rid)
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOwners(t *testing.T) {
+	data := []byte(`approvers:
+- sig-node-approvers
+- alice
+reviewers:
+- bob
+labels:
+- sig/node
+filters:
+  ".*":
+    labels:
+    - area/kubelet
+  "\\.md$":
+    labels:
+    - kind/documentation
+`)
+	of, err := parseOwners(data, map[string][]string{"sig-node-approvers": {"carol", "dave"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"carol", "dave", "alice"}, of.Approvers)
+	assert.Equal(t, []string{"bob"}, of.Reviewers)
+	assert.Equal(t, []string{"sig/node", "area/kubelet"}, of.Labels)
+}
+
+func TestAreaCollections(t *testing.T) {
+	raw := map[string]Rule{"triage": {Name: "Needs triage", Filters: []provider.Filter{{RawLabel: "!triage/accepted"}}}}
+	areas := []hubbub.Area{{Label: "sig/node"}}
+
+	cs, err := areaCollections(areas, &AreaCollection{Rules: []string{"triage"}}, raw, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "sig-node", cs[0].ID)
+	assert.Equal(t, []string{"triage-sig-node"}, cs[0].RuleIDs)
+	assert.Equal(t, "Needs triage: sig/node", raw["triage-sig-node"].Name)
+	assert.Equal(t, `^sig/node$`, raw["triage-sig-node"].Filters[1].RawArea)
+	assert.Len(t, raw["triage"].Filters, 1)
+
+	_, err = areaCollections(areas, &AreaCollection{Rules: []string{"triage"}}, raw, []Collection{{ID: "sig-node"}})
+	assert.NotNil(t, err)
+}
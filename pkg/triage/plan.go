@@ -0,0 +1,327 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// planPageSize is how many items are fetched per page when listing
+const planPageSize = 100
+
+// RepoSample counts the items in a repository that a refresh may need to fetch
+type RepoSample struct {
+	URL string
+
+	// Open is how many open issues and pull requests there are
+	Open int
+	// OpenPullRequests is how many of the open items are pull requests
+	OpenPullRequests int
+	// Closed is how many issues and pull requests were closed within the closed lookback
+	Closed int
+	// UpdatedPerDay is how many items were updated in the last day
+	UpdatedPerDay int
+}
+
+// repoUsage is how the configured rules search a repository
+type repoUsage struct {
+	issues       bool
+	pullRequests bool
+	closed       bool
+	// visible is set if any search is for a collection which is displayed
+	visible bool
+}
+
+// RequestEstimate is the estimated number of API requests for a repository
+type RequestEstimate struct {
+	RepoSample
+
+	ListPages int
+	Comments  int
+	Timelines int
+	Reviews   int
+
+	// ColdStart is how many requests are made with an empty cache
+	ColdStart int
+	// PerRefresh is how many requests are made per refresh, once the cache is warm
+	PerRefresh int
+	// PerHour is how many requests are made per hour, once the cache is warm
+	PerHour int
+}
+
+// RequestPlan estimates the API requests made by a configuration
+type RequestPlan struct {
+	Refresh time.Duration
+	Repos   []*RequestEstimate
+
+	ColdStart  int
+	PerRefresh int
+	PerHour    int
+
+	// Rate is the rate limit reported for the GitHub token, if any
+	Rate provider.Rate
+	// MinRefresh is the shortest refresh interval which stays within the rate limit
+	MinRefresh time.Duration
+}
+
+// pages returns how many pages it takes to list n items
+func pages(n int) int {
+	if n <= 0 {
+		return 1
+	}
+	return (n + planPageSize - 1) / planPageSize
+}
+
+// estimateRequests estimates the API requests made for a repository, refreshed every interval
+func estimateRequests(u repoUsage, s RepoSample, refresh time.Duration) *RequestEstimate {
+	e := &RequestEstimate{RepoSample: s}
+
+	openPRs := s.OpenPullRequests
+	openIssues := s.Open - openPRs
+	closedPRs := 0
+	closedIssues := 0
+	if u.closed && s.Open > 0 {
+		closedPRs = s.Closed * openPRs / s.Open
+		closedIssues = s.Closed - closedPRs
+	} else if u.closed {
+		closedIssues = s.Closed
+	}
+
+	items := 0
+	if u.issues {
+		// Issue listings include pull requests
+		e.ListPages += pages(s.Open)
+		e.Comments += openIssues
+		if u.visible {
+			e.Timelines += openIssues
+		}
+		items += openIssues
+
+		if u.closed {
+			e.ListPages += pages(s.Closed)
+			e.Comments += closedIssues
+			items += closedIssues
+		}
+	}
+
+	if u.pullRequests {
+		// Pull request comments are fetched from both the issue and review comment APIs
+		e.ListPages += pages(openPRs)
+		e.Comments += openPRs * 2
+		e.Timelines += openPRs
+		if u.visible {
+			e.Reviews += openPRs
+		}
+		items += openPRs
+
+		if u.closed {
+			e.ListPages += pages(closedPRs)
+			e.Comments += closedPRs * 2
+			items += closedPRs
+		}
+	}
+
+	perItem := e.Comments + e.Timelines + e.Reviews
+	e.ColdStart = e.ListPages + perItem
+
+	// Once cached, only items which were updated since the last refresh are fetched again
+	changed := 1.0
+	if items > 0 {
+		changed = math.Min(1, float64(s.UpdatedPerDay)*refresh.Hours()/24/float64(items))
+	}
+	e.PerRefresh = e.ListPages + int(math.Ceil(float64(perItem)*changed))
+	if refresh > 0 {
+		e.PerHour = int(math.Ceil(float64(e.PerRefresh) * float64(time.Hour) / float64(refresh)))
+	}
+	return e
+}
+
+// minRefresh returns the shortest refresh interval for which estimates stay within limit requests per hour
+func minRefresh(es []*RequestEstimate, refresh time.Duration, limit int) time.Duration {
+	if limit <= 0 || refresh <= 0 {
+		return 0
+	}
+
+	// Listing costs the same each refresh, while fetching updated items depends on how often items change
+	lists := 0
+	updates := 0.0
+	for _, e := range es {
+		lists += e.ListPages
+		updates += float64(e.PerRefresh-e.ListPages) * float64(time.Hour) / float64(refresh)
+	}
+
+	spare := float64(limit) - updates
+	if spare <= 0 {
+		return 0
+	}
+	return time.Duration(float64(lists) / spare * float64(time.Hour)).Round(time.Second)
+}
+
+// repoUsages returns how each repository is searched by the rules of displayed or collected collections
+func (p *Party) repoUsages() (map[string]*repoUsage, error) {
+	cs, err := p.ListCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	us := map[string]*repoUsage{}
+	for _, c := range cs {
+		hidden := c.Hidden && c.UsedForStats
+		for _, id := range c.RuleIDs {
+			t, err := p.LookupRule(id)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, url := range t.Repos {
+				r, err := parseRepo(url)
+				if err != nil {
+					return nil, err
+				}
+
+				u := us[url]
+				if u == nil {
+					u = &repoUsage{}
+					us[url] = u
+				}
+
+				switch t.Type {
+				case hubbub.Issue:
+					u.issues = true
+				case hubbub.PullRequest:
+					u.pullRequests = true
+				default:
+					u.issues = true
+					u.pullRequests = true
+				}
+
+				if hubbub.NeedsClosed(t.ForRepo(r).Filters) {
+					u.closed = true
+				}
+				if !hidden {
+					u.visible = true
+				}
+			}
+		}
+	}
+	return us, nil
+}
+
+// countItems returns how many items a listing has, by asking for one item per page
+func countItems(is int, resp *provider.Response) int {
+	if resp != nil && resp.LastPage > 0 {
+		return resp.LastPage
+	}
+	return is
+}
+
+// SampleRepo counts the open, recently closed and recently updated items in a repository
+func (p *Party) SampleRepo(ctx context.Context, url string, closedAge time.Duration) (*RepoSample, provider.Rate, error) {
+	var rate provider.Rate
+
+	r, err := parseRepo(url)
+	if err != nil {
+		return nil, rate, err
+	}
+
+	pr := p.Provider(r.Host)
+	if pr == nil {
+		return nil, rate, fmt.Errorf("no token configured for %s", r.Host)
+	}
+
+	s := &RepoSample{URL: url}
+	sp := provider.SearchParams{Repo: r}
+	one := provider.ListOptions{PerPage: 1}
+
+	sp.IssueListByRepoOptions = provider.IssueListByRepoOptions{State: "open", ListOptions: one}
+	is, resp, err := pr.IssuesListByRepo(ctx, sp)
+	if err != nil {
+		return nil, rate, fmt.Errorf("open issues: %w", err)
+	}
+	s.Open = countItems(len(is), resp)
+
+	sp.PullRequestListOptions = provider.PullRequestListOptions{State: "open", ListOptions: one}
+	prs, resp, err := pr.PullRequestsList(ctx, sp)
+	if err != nil {
+		return nil, rate, fmt.Errorf("open pull requests: %w", err)
+	}
+	s.OpenPullRequests = countItems(len(prs), resp)
+
+	if closedAge > 0 {
+		sp.IssueListByRepoOptions = provider.IssueListByRepoOptions{State: "closed", Since: time.Now().Add(-closedAge), ListOptions: one}
+		is, resp, err = pr.IssuesListByRepo(ctx, sp)
+		if err != nil {
+			return nil, rate, fmt.Errorf("closed issues: %w", err)
+		}
+		s.Closed = countItems(len(is), resp)
+	}
+
+	sp.IssueListByRepoOptions = provider.IssueListByRepoOptions{State: "all", Since: time.Now().Add(-24 * time.Hour), ListOptions: one}
+	is, resp, err = pr.IssuesListByRepo(ctx, sp)
+	if err != nil {
+		return nil, rate, fmt.Errorf("updated issues: %w", err)
+	}
+	s.UpdatedPerDay = countItems(len(is), resp)
+
+	if resp != nil {
+		rate = resp.Rate
+	}
+	return s, rate, nil
+}
+
+// PlanRequests estimates the API requests made to refresh all collections every interval
+func (p *Party) PlanRequests(ctx context.Context, refresh time.Duration) (*RequestPlan, error) {
+	us, err := p.repoUsages()
+	if err != nil {
+		return nil, err
+	}
+
+	closedAge := p.engineConfig().MaxClosedUpdateAge
+	rp := &RequestPlan{Refresh: refresh}
+
+	for url, u := range us {
+		age := time.Duration(0)
+		if u.closed {
+			age = closedAge
+		}
+
+		s, rate, err := p.SampleRepo(ctx, url, age)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", url, err)
+		}
+
+		if r, _ := parseRepo(url); r.Host != constants.GitLabProviderHost && rate.Limit > 0 {
+			rp.Rate = rate
+		}
+
+		e := estimateRequests(*u, *s, refresh)
+		rp.Repos = append(rp.Repos, e)
+		rp.ColdStart += e.ColdStart
+		rp.PerRefresh += e.PerRefresh
+		rp.PerHour += e.PerHour
+	}
+
+	sort.Slice(rp.Repos, func(i, j int) bool { return rp.Repos[i].PerHour > rp.Repos[j].PerHour })
+	rp.MinRefresh = minRefresh(rp.Repos, refresh, rp.Rate.Limit)
+	return rp, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateRequests(t *testing.T) {
+	u := repoUsage{issues: true, pullRequests: true, visible: true}
+	s := RepoSample{URL: "https://github.com/org/a", Open: 250, OpenPullRequests: 50, UpdatedPerDay: 24}
+
+	e := estimateRequests(u, s, time.Hour)
+	assert.Equal(t, 4, e.ListPages)
+	assert.Equal(t, 300, e.Comments)
+	assert.Equal(t, 250, e.Timelines)
+	assert.Equal(t, 50, e.Reviews)
+	assert.Equal(t, 604, e.ColdStart)
+	assert.Equal(t, 7, e.PerRefresh)
+	assert.Equal(t, 7, e.PerHour)
+
+	assert.Equal(t, 2*time.Hour, minRefresh([]*RequestEstimate{e}, time.Hour, 5))
+	assert.Equal(t, time.Duration(0), minRefresh([]*RequestEstimate{e}, time.Hour, 3))
+
+	hidden := estimateRequests(repoUsage{issues: true}, s, time.Hour)
+	assert.Equal(t, 0, hidden.Timelines)
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/triage-party/pkg/provider"
+	"gopkg.in/yaml.v2"
+)
+
+// QueryID is the ID of the rule and collection which an ad-hoc query is loaded as
+const QueryID = "adhoc-query"
+
+// Query is an ad-hoc rule, which is not part of the configuration
+type Query struct {
+	// Repos defaults to the configured repos
+	Repos []string
+	// Type is issue, pull_request or alert, defaulting to issues and pull requests
+	Type    string
+	Filters []provider.Filter
+}
+
+// ParseFilter parses a filter as it is written in rules, such as "label: bug" or "updated: +30d"
+func ParseFilter(s string) (provider.Filter, error) {
+	f := provider.Filter{}
+	if err := yaml.UnmarshalStrict([]byte(s), &f); err != nil {
+		return f, fmt.Errorf("filter %q: %w", s, err)
+	}
+	if reflect.DeepEqual(f, provider.Filter{}) {
+		return f, fmt.Errorf("filter %q: expected a field and value, such as \"label: bug\"", s)
+	}
+	return f, nil
+}
+
+// LoadQuery loads configuration sources, if any, along with an ad-hoc query as the rule QueryID
+func (p *Party) LoadQuery(paths []string, q Query) error {
+	dc := &diskConfig{}
+	if len(paths) > 0 {
+		var err error
+		if dc, _, err = readFiles(paths); err != nil {
+			return err
+		}
+	}
+
+	overlayConfig(dc, &diskConfig{
+		RawCollections: []Collection{{ID: QueryID, Name: "Query", RuleIDs: []string{QueryID}, Hidden: true}},
+		RawRules: map[string]Rule{
+			QueryID: {Name: "Query", Repos: q.Repos, Type: q.Type, Filters: q.Filters},
+		},
+	})
+	return p.load(dc)
+}
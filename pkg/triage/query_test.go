@@ -0,0 +1,45 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	f, err := ParseFilter("label: bug")
+	assert.Nil(t, err)
+	assert.Equal(t, "bug", f.RawLabel)
+
+	_, err = ParseFilter("lable: bug")
+	assert.NotNil(t, err)
+	_, err = ParseFilter("bug")
+	assert.NotNil(t, err)
+
+	p, err := New(Config{GitHubToken: "token"})
+	assert.Nil(t, err)
+
+	updated, err := ParseFilter("updated: +30d")
+	assert.Nil(t, err)
+	assert.Nil(t, p.LoadQuery(nil, Query{Repos: []string{"https://github.com/org/a"}, Filters: []provider.Filter{f, updated}}))
+
+	r, err := p.LookupRule(QueryID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://github.com/org/a"}, r.Repos)
+	assert.Len(t, r.Filters, 2)
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// fileStamp identifies a version of a file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// stampFiles returns the current version of a set of files
+func stampFiles(paths map[string]bool) map[string]fileStamp {
+	stamps := map[string]fileStamp{}
+	for path := range paths {
+		// os.Stat follows symlinks, so that Kubernetes ConfigMap updates are noticed
+		fi, err := os.Stat(path)
+		if err != nil {
+			stamps[path] = fileStamp{}
+			continue
+		}
+		stamps[path] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	return stamps
+}
+
+// sources returns the configuration sources, and the files they were read from when last loaded or stamped
+func (p *Party) sources() ([]string, map[string]fileStamp) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.paths, p.files
+}
+
+// restamp records the current version of the loaded configuration files, so that only later changes are noticed
+func (p *Party) restamp() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files = stampFiles(keys(p.files))
+}
+
+// changed returns whether any loaded configuration file has changed on disk
+func (p *Party) changed() bool {
+	_, files := p.sources()
+	for path, old := range files {
+		cur := stampFiles(map[string]bool{path: true})[path]
+		if cur != old {
+			klog.Infof("%s has changed", path)
+			return true
+		}
+	}
+	return false
+}
+
+// Reload reloads the configuration from disk if it has changed, and returns whether it did.
+// If the new configuration is invalid, the previous one remains in use.
+func (p *Party) Reload() (bool, error) {
+	paths, _ := p.sources()
+	if len(paths) == 0 {
+		return false, fmt.Errorf("configuration was not loaded from a file")
+	}
+
+	if !p.changed() {
+		return false, nil
+	}
+
+	if err := p.LoadFiles(paths); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Watch reloads the configuration whenever it changes on disk, calling fn after every successful reload
+func (p *Party) Watch(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		paths, _ := p.sources()
+		reloaded, err := p.Reload()
+		if err != nil {
+			klog.Errorf("reload %s: %v (keeping the previous configuration)", paths, err)
+			// Avoid logging the same error on every tick
+			p.restamp()
+			continue
+		}
+
+		if reloaded {
+			klog.Infof("reloaded configuration from %s", paths)
+			fn()
+		}
+	}
+}
+
+// keys returns the paths of a set of file stamps
+func keys(stamps map[string]fileStamp) map[string]bool {
+	ks := map[string]bool{}
+	for k := range stamps {
+		ks[k] = true
+	}
+	return ks
+}
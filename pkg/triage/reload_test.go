@@ -0,0 +1,188 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// reloadConfig is a configuration whose similarity threshold and collection name may be varied
+const reloadConfig = `
+settings:
+  repos: [https://github.com/org/a]
+  min_similarity: %s
+collections:
+  - id: c
+    name: %s
+    rules: [open]
+rules:
+  open:
+    filters:
+      - state: open
+`
+
+// writeConfig writes a configuration file, moving its modification time forward so that changes are noticed
+func writeConfig(t *testing.T, path string, content string) {
+	mt := time.Now()
+	if fi, err := os.Stat(path); err == nil {
+		mt = fi.ModTime().Add(time.Second)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Chtimes(path, mt, mt); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+// newReloadParty returns a party loaded from a configuration file within a temporary directory
+func newReloadParty(t *testing.T) (*Party, string, func()) {
+	dir, err := ioutil.TempDir("", "reload")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(t, path, fmt.Sprintf(reloadConfig, "0.5", "Before"))
+
+	p, err := New(Config{GitHubToken: "token"})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := p.LoadFile(path); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return p, path, func() { os.RemoveAll(dir) }
+}
+
+// collectionName returns the name of the collection within reloadConfig
+func collectionName(t *testing.T, p *Party) string {
+	c, err := p.LookupCollection("c")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	return c.Name
+}
+
+func TestReload(t *testing.T) {
+	p, path, cleanup := newReloadParty(t)
+	defer cleanup()
+
+	reloaded, err := p.Reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded, "nothing has changed")
+
+	writeConfig(t, path, fmt.Sprintf(reloadConfig, "0.5", "After"))
+	reloaded, err = p.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, "After", collectionName(t, p))
+
+	writeConfig(t, path, "collections: [")
+	reloaded, err = p.Reload()
+	assert.Error(t, err)
+	assert.False(t, reloaded)
+	assert.Equal(t, "After", collectionName(t, p), "an invalid configuration is not swapped in")
+}
+
+func TestReloadKeepsEngine(t *testing.T) {
+	p, path, cleanup := newReloadParty(t)
+	defer cleanup()
+	engine := p.engine
+
+	writeConfig(t, path, fmt.Sprintf(reloadConfig, "0.5", "Renamed"))
+	_, err := p.Reload()
+	assert.NoError(t, err)
+	assert.Same(t, engine, p.engine, "the engine is kept when its configuration is unchanged")
+
+	writeConfig(t, path, fmt.Sprintf(reloadConfig, "0.7", "Renamed"))
+	_, err = p.Reload()
+	assert.NoError(t, err)
+	assert.True(t, engine != p.engine, "changing the similarity threshold replaces the engine")
+}
+
+func TestWatch(t *testing.T) {
+	p, path, cleanup := newReloadParty(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloads := make(chan bool, 10)
+	go p.Watch(ctx, 10*time.Millisecond, func() { reloads <- true })
+
+	writeConfig(t, path, "collections: [")
+	assert.Eventually(t, func() bool { return !p.changed() }, time.Second, 10*time.Millisecond, "files are restamped after a failed load")
+	assert.Len(t, reloads, 0)
+	assert.Equal(t, "Before", collectionName(t, p))
+
+	writeConfig(t, path, fmt.Sprintf(reloadConfig, "0.5", "After"))
+	select {
+	case <-reloads:
+	case <-time.After(time.Second):
+		t.Fatalf("configuration was not reloaded")
+	}
+	assert.Equal(t, "After", collectionName(t, p))
+}
+
+func TestReloadIncludeGlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rules := filepath.Join(dir, "rules")
+	if err := os.Mkdir(rules, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	// included returns a file defining a collection of one rule
+	included := func(id string) string {
+		return fmt.Sprintf("collections:\n  - id: %s\n    name: %s\n    rules: [%s]\nrules:\n  %s:\n    filters:\n      - state: open\n", id, id, id, id)
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(t, path, "settings:\n  repos: [https://github.com/org/a]\ninclude: [rules/*.yaml]\n")
+	writeConfig(t, filepath.Join(rules, "a.yaml"), included("a"))
+
+	p, err := New(Config{GitHubToken: "token"})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := p.LoadFile(path); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	writeConfig(t, filepath.Join(rules, "b.yaml"), included("b"))
+	// Move the directory's modification time forward, in case the file system records it coarsely
+	mt := time.Now().Add(time.Second)
+	if err := os.Chtimes(rules, mt, mt); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	reloaded, err := p.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded, "a new file matching an include is noticed")
+	_, err = p.LookupCollection("b")
+	assert.NoError(t, err)
+}
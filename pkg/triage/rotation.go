@@ -0,0 +1,108 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+)
+
+// defaultShift is how long each member of a rotation is on duty by default
+const defaultShift = 7 * 24 * time.Hour
+
+// RotationSettings declares who is on triage duty, in turn
+type RotationSettings struct {
+	// Members take turns being on duty, in order
+	Members []string `yaml:"members"`
+	// Start is when the first member's first shift begins, such as 2020-06-01 or 2020-06-01T09:00:00Z
+	Start string `yaml:"start"`
+	// Shift is how long each member is on duty, such as 1w
+	Shift string `yaml:"shift,omitempty"`
+
+	// Collections are summarized in handoff reports, defaulting to those which are not hidden
+	Collections []string `yaml:"collections,omitempty"`
+	// Output is a directory which handoff reports are written to
+	Output string `yaml:"output,omitempty"`
+	// Email is who handoff reports are mailed to
+	Email []string `yaml:"email,omitempty"`
+}
+
+// Shift is a period of triage duty
+type Shift struct {
+	Member string
+	Start  time.Time
+	End    time.Time
+}
+
+// parse returns when the rotation starts, and how long each shift is
+func (rs *RotationSettings) parse() (time.Time, time.Duration, error) {
+	if len(rs.Members) == 0 {
+		return time.Time{}, 0, fmt.Errorf("no members")
+	}
+
+	var start time.Time
+	var err error
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if start, err = time.Parse(layout, rs.Start); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid start: %q", rs.Start)
+	}
+
+	shift := defaultShift
+	if rs.Shift != "" {
+		if shift, _, _ = hubbub.ParseDuration(rs.Shift); shift <= 0 {
+			return time.Time{}, 0, fmt.Errorf("invalid shift: %q", rs.Shift)
+		}
+	}
+	return start, shift, nil
+}
+
+// shiftAt returns the shift which covers a time, or nil if the rotation has not started yet
+func (rs *RotationSettings) shiftAt(t time.Time) *Shift {
+	start, length, err := rs.parse()
+	if err != nil || t.Before(start) {
+		return nil
+	}
+
+	n := int64(t.Sub(start) / length)
+	begin := start.Add(time.Duration(n) * length)
+	return &Shift{
+		Member: rs.Members[n%int64(len(rs.Members))],
+		Start:  begin,
+		End:    begin.Add(length),
+	}
+}
+
+// OnDuty returns the triage shift covering a time, or nil if there is none
+func (p *Party) OnDuty(t time.Time) *Shift {
+	rs := p.Settings().Rotation
+	if rs == nil {
+		return nil
+	}
+	return rs.shiftAt(t)
+}
+
+// OnDutyMember returns who is on triage duty at a time, if anyone
+func (p *Party) OnDutyMember(t time.Time) string {
+	if s := p.OnDuty(t); s != nil {
+		return s.Member
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotation(t *testing.T) {
+	rs := &RotationSettings{Members: []string{"alice", "bob", "carol"}, Start: "2026-01-05", Shift: "1w"}
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, rs.shiftAt(start.Add(-time.Hour)))
+	assert.Equal(t, &Shift{Member: "alice", Start: start, End: start.AddDate(0, 0, 7)}, rs.shiftAt(start.Add(time.Hour)))
+	assert.Equal(t, "bob", rs.shiftAt(start.AddDate(0, 0, 7)).Member)
+	assert.Equal(t, "alice", rs.shiftAt(start.AddDate(0, 0, 22)).Member)
+
+	_, _, err := (&RotationSettings{Members: []string{"alice"}, Start: "soon"}).parse()
+	assert.NotNil(t, err)
+}
@@ -18,9 +18,11 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/provider"
 
 	"github.com/google/triage-party/pkg/hubbub"
@@ -28,6 +30,19 @@ import (
 	"k8s.io/klog/v2"
 )
 
+const (
+	// RoundRobinStrategy assigns members in turn
+	RoundRobinStrategy = "round-robin"
+	// LoadStrategy assigns the member with the fewest open assignments
+	LoadStrategy = "load"
+
+	// ScoreSort orders items by the score given by the external scoring service, highest first
+	ScoreSort = "score"
+
+	// AssigneeTarget notifies the assignees of an item, when used in an escalation level
+	AssigneeTarget = "assignee"
+)
+
 // Rule is a logical triage group
 type Rule struct {
 	ID         string
@@ -36,6 +51,113 @@ type Rule struct {
 	Repos      []string          `yaml:"repos,omitempty"`
 	Type       string            `yaml:"type,omitempty"`
 	Filters    []provider.Filter `yaml:"filters"`
+
+	// Extends is the ID of a rule to inherit unset fields and filters from
+	Extends string `yaml:"extends,omitempty"`
+
+	// Comment is posted to matching items when comment posting is enabled
+	Comment string `yaml:"comment,omitempty"`
+
+	// Stale warns about, and then closes, inactive items matching this rule
+	Stale *StalePolicy `yaml:"stale,omitempty"`
+
+	// AutoAssign assigns unassigned items matching this rule to members
+	AutoAssign *AutoAssignPolicy `yaml:"auto-assign,omitempty"`
+
+	// SLO is an objective for how quickly items leave this rule
+	SLO *SLOPolicy `yaml:"slo,omitempty"`
+
+	// Escalation notifies people in turn the longer items remain in this rule
+	Escalation []EscalationLevel `yaml:"escalation,omitempty"`
+
+	// Max is how many items may match this rule before a threshold check fails
+	Max *int `yaml:"max,omitempty"`
+
+	// Sort is how matching items are ordered: "score", or by default as the provider returns them
+	Sort string `yaml:"sort,omitempty"`
+
+	// Overrides replace filters or the stale policy for particular repositories
+	Overrides []RuleOverride `yaml:"overrides,omitempty"`
+}
+
+// AutoAssignPolicy describes how to pick assignees for unassigned items
+type AutoAssignPolicy struct {
+	// Strategy is either "round-robin" or "load", which picks the member with the fewest open assignments
+	Strategy string `yaml:"strategy,omitempty"`
+	// Members to assign, defaults to the members setting
+	Members []string `yaml:"members,omitempty"`
+}
+
+// StalePolicy describes when to warn about and close inactive items
+type StalePolicy struct {
+	// WarnAfter is how long an item must be inactive before a warning is posted, such as 60d
+	WarnAfter string `yaml:"warn-after"`
+	// CloseAfter is how long after the warning to close the item if there is no further activity
+	CloseAfter string `yaml:"close-after"`
+
+	WarnMessage  string `yaml:"warn-message,omitempty"`
+	CloseMessage string `yaml:"close-message,omitempty"`
+
+	// ExemptLabels are labels which exclude an item from the policy
+	ExemptLabels []string `yaml:"exempt-labels,omitempty"`
+}
+
+const (
+	// defaultSLOPercent is the share of items which should meet an SLO target by default
+	defaultSLOPercent = 95
+	// defaultSLOWindow is the period SLOs are measured over by default
+	defaultSLOWindow = 28 * 24 * time.Hour
+)
+
+// SLOPolicy is a service level objective for how long items may remain in a rule
+type SLOPolicy struct {
+	// Target is how long items may remain in the rule, such as 3d
+	Target string `yaml:"target"`
+	// Percent is the share of items which should leave the rule within the target, such as 95
+	Percent float64 `yaml:"percent,omitempty"`
+	// Window is the rolling period the SLO is measured over, such as 28d
+	Window string `yaml:"window,omitempty"`
+}
+
+// Parse returns the target, objective percentage and window of an SLO, filling in defaults
+func (s *SLOPolicy) Parse() (time.Duration, float64, time.Duration, error) {
+	target, _, _ := hubbub.ParseDuration(s.Target)
+	if target <= 0 {
+		return 0, 0, 0, fmt.Errorf("invalid target: %q", s.Target)
+	}
+
+	percent := s.Percent
+	if percent == 0 {
+		percent = defaultSLOPercent
+	}
+	if percent < 0 || percent >= 100 {
+		return 0, 0, 0, fmt.Errorf("percent must be between 0 and 100, exclusive: %v", s.Percent)
+	}
+
+	window := defaultSLOWindow
+	if s.Window != "" {
+		if window, _, _ = hubbub.ParseDuration(s.Window); window <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid window: %q", s.Window)
+		}
+	}
+	return target, percent, window, nil
+}
+
+// EscalationLevel is a step of an escalation chain, which fires once per item
+type EscalationLevel struct {
+	// After is how long an item must have been in the rule before this level fires, such as 7d
+	After string `yaml:"after"`
+	// Notify are logins or @groups to mention in a comment, or "assignee" for the assignees of the item
+	Notify []string `yaml:"notify,omitempty"`
+	// Webhook is a URL to post a Slack-compatible message to, such as the incoming webhook of an org channel
+	Webhook string `yaml:"webhook,omitempty"`
+	Message string `yaml:"message,omitempty"`
+}
+
+// Duration returns how long an item must have been in the rule before the level fires
+func (l EscalationLevel) Duration() time.Duration {
+	d, _, _ := hubbub.ParseDuration(l.After)
+	return d
 }
 
 type RuleResult struct {
@@ -53,6 +175,9 @@ type RuleResult struct {
 
 	Duplicates map[string]bool
 
+	// AgeBuckets is how many items fall into each age range, from newest to oldest
+	AgeBuckets []AgeBucket
+
 	// OldestInput is the timestamp of the oldest input data
 	OldestInput time.Time
 
@@ -60,6 +185,55 @@ type RuleResult struct {
 	Created time.Time
 }
 
+// AgeBucket is how many items of a rule were created within an age range
+type AgeBucket struct {
+	Name  string
+	Count int
+	// Percent is the share of items in this range
+	Percent float64
+}
+
+// ageRanges are the upper bounds of the age buckets, the last of which is unbounded
+var ageRanges = []struct {
+	name string
+	max  time.Duration
+}{
+	{name: "<1w", max: 7 * 24 * time.Hour},
+	{name: "1-4w", max: 28 * 24 * time.Hour},
+	{name: "1-3m", max: 90 * 24 * time.Hour},
+	{name: "older", max: 0},
+}
+
+// ageBuckets counts how many conversations fall into each age range
+func ageBuckets(cs []*hubbub.Conversation, now time.Time) []AgeBucket {
+	bs := make([]AgeBucket, len(ageRanges))
+	for i, r := range ageRanges {
+		bs[i].Name = r.name
+	}
+
+	total := 0
+	for _, c := range cs {
+		if c == nil {
+			continue
+		}
+		age := now.Sub(c.Created)
+		for i, r := range ageRanges {
+			if r.max == 0 || age < r.max {
+				bs[i].Count++
+				break
+			}
+		}
+		total++
+	}
+
+	if total > 0 {
+		for i := range bs {
+			bs[i].Percent = float64(bs[i].Count) * 100 / float64(total)
+		}
+	}
+	return bs
+}
+
 // SummarizeRuleResult adds together statistics about a pool of conversations
 func SummarizeRuleResult(t Rule, cs []*hubbub.Conversation, seen map[string]*Rule) *RuleResult {
 	r := &RuleResult{
@@ -117,6 +291,7 @@ func SummarizeRuleResult(t Rule, cs []*hubbub.Conversation, seen map[string]*Rul
 	r.AvgCurrentHold = avgDayDuration(r.TotalCurrentHoldDays, count)
 	r.AvgAccumulatedHold = avgDayDuration(r.TotalAccumulatedHoldDays, count)
 	r.Created = time.Now()
+	r.AgeBuckets = ageBuckets(cs, r.Created)
 	return r
 }
 
@@ -125,6 +300,7 @@ func (p *Party) ExecuteRule(ctx context.Context, sp provider.SearchParams, t Rul
 	klog.V(1).Infof("executing rule %q for results newer than %s", t.ID, logu.STime(sp.NewerThan))
 	rcs := []*hubbub.Conversation{}
 	oldest := time.Now()
+	newerThan := sp.NewerThan
 
 	for _, repoUrl := range t.Repos {
 		r, err := parseRepo(repoUrl)
@@ -138,15 +314,24 @@ func (p *Party) ExecuteRule(ctx context.Context, sp provider.SearchParams, t Rul
 		var cs []*hubbub.Conversation
 
 		sp.Repo = r
-		sp.Filters = t.Filters
+		sp.NewerThan = p.shardNewerThan(repoUrl, newerThan)
+		sp.Filters = t.ForRepo(r).Filters
+		if f, ok := p.virtualFilter(repoUrl); ok {
+			sp.Filters = append(append([]provider.Filter{}, sp.Filters...), f)
+		}
 
+		e := p.eng()
 		switch t.Type {
 		case hubbub.Issue:
-			cs, ts, err = p.engine.SearchIssues(ctx, sp)
+			cs, ts, err = e.SearchIssues(ctx, sp)
 		case hubbub.PullRequest:
-			cs, ts, err = p.engine.SearchPullRequests(ctx, sp)
+			cs, ts, err = e.SearchPullRequests(ctx, sp)
+		case hubbub.Alert:
+			cs, ts, err = e.SearchAlerts(ctx, sp)
+		case hubbub.Epic:
+			cs, ts, err = e.SearchEpics(ctx, sp)
 		default:
-			cs, ts, err = p.engine.SearchAny(ctx, sp)
+			cs, ts, err = e.SearchAny(ctx, sp)
 		}
 
 		if err != nil {
@@ -159,14 +344,35 @@ func (p *Party) ExecuteRule(ctx context.Context, sp provider.SearchParams, t Rul
 		}
 	}
 
+	if t.Sort == ScoreSort {
+		sortByScore(rcs)
+	}
+
 	klog.V(1).Infof("rule %q matched %d items", t.ID, len(rcs))
 	rr := SummarizeRuleResult(t, rcs, seen)
 	rr.OldestInput = oldest
 	return rr, nil
 }
 
+// sortByScore orders conversations by score, highest first, with unscored conversations last
+func sortByScore(cs []*hubbub.Conversation) {
+	sort.SliceStable(cs, func(i, j int) bool {
+		if cs[i].Score == nil || cs[j].Score == nil {
+			return cs[j].Score == nil && cs[i].Score != nil
+		}
+		return cs[i].Score.Value > cs[j].Score.Value
+	})
+}
+
 // Return a fully resolved rule
 func (p *Party) LookupRule(id string) (Rule, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.lookupRule(id)
+}
+
+// lookupRule returns a fully resolved rule, with p.mu held
+func (p *Party) lookupRule(id string) (Rule, error) {
 	t, ok := p.rules[id]
 	if !ok {
 		return t, fmt.Errorf("rule %q is undefined - typo?", id)
@@ -184,9 +390,12 @@ func (p *Party) LookupRule(id string) (Rule, error) {
 
 // ListRules fully resolved rules
 func (p *Party) ListRules() ([]Rule, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	ts := []Rule{}
 	for k := range p.rules {
-		s, err := p.LookupRule(k)
+		s, err := p.lookupRule(k)
 		if err != nil {
 			return ts, err
 		}
@@ -199,6 +408,9 @@ func (p *Party) ListRules() ([]Rule, error) {
 // rawURL should be a valid url with host like https://github.com/org/repo
 // or https://gitlab.com/org/repo
 // or https://gitlab.com/org/group/repo
+// or https://gitlab.com/groups/org/subgroup, for every project in a GitLab group
+// or https://bitbucket.example.com/projects/KEY/repos/repo, for Bitbucket Server
+// or https://bugs.launchpad.net/project, or https://bugs.launchpad.net/ubuntu/+source/package, for Launchpad
 func parseRepo(rawURL string) (r provider.Repo, err error) {
 	u, err := url.Parse(rawURL)
 	if err != nil {
@@ -208,6 +420,15 @@ func parseRepo(rawURL string) (r provider.Repo, err error) {
 		err = fmt.Errorf("Provided string %s is not a valid URL", rawURL)
 		return
 	}
+	if strings.HasPrefix(u.Path, "/groups/") {
+		return parseGroup(u)
+	}
+	if br, ok := provider.BitbucketServerRepo(u); ok {
+		return br, nil
+	}
+	if lr, ok := provider.LaunchpadRepo(u); ok {
+		return lr, nil
+	}
 	parts := strings.Split(u.Path, "/")
 	if len(parts) != 3 && len(parts) != 4 {
 		// gitlab may have https://gitlab.com/organization/group/repo
@@ -231,3 +452,21 @@ func parseRepo(rawURL string) (r provider.Repo, err error) {
 
 	return
 }
+
+// parseGroup returns a repository matching every project beneath a GitLab group
+func parseGroup(u *url.URL) (provider.Repo, error) {
+	if u.Host != constants.GitLabProviderHost {
+		return provider.Repo{}, fmt.Errorf("%s: only GitLab groups may be queried", u)
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(u.Path, "/groups/"), "/"), "/")
+	if parts[0] == "" {
+		return provider.Repo{}, fmt.Errorf("%s: expected a group name", u)
+	}
+	return provider.Repo{
+		Host:         u.Host,
+		Organization: parts[0],
+		Group:        strings.Join(parts[1:], "/"),
+		AllProjects:  true,
+	}, nil
+}
@@ -16,7 +16,10 @@ package triage
 
 import (
 	"testing"
+	"time"
 
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,4 +49,57 @@ func TestParseRepo(t *testing.T) {
 	assert.Equal(t, org, r.Organization)
 	assert.Equal(t, repo, r.Project)
 	assert.Equal(t, group, r.Group)
+
+	r, err = parseRepo("https://gitlab.com/groups/org/group/sub")
+	assert.Nil(t, err)
+	assert.Equal(t, provider.Repo{Host: "gitlab.com", Organization: "org", Group: "group/sub", AllProjects: true}, r)
+
+	r, err = parseRepo("https://bitbucket.example.com/projects/KEY/repos/repo")
+	assert.Nil(t, err)
+	assert.Equal(t, provider.Repo{Host: "bitbucket.example.com", Organization: "KEY", Project: "repo"}, r)
+
+	r, err = parseRepo("https://bugs.launchpad.net/my-project")
+	assert.Nil(t, err)
+	assert.Equal(t, provider.Repo{Host: "bugs.launchpad.net", Organization: "my-project", Project: "my-project"}, r)
+
+	_, err = parseRepo("https://github.com/groups/org")
+	assert.NotNil(t, err)
+}
+
+func TestSLOPolicyParse(t *testing.T) {
+	target, percent, window, err := (&SLOPolicy{Target: "3d"}).Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, 72*time.Hour, target)
+	assert.Equal(t, 95.0, percent)
+	assert.Equal(t, 28*24*time.Hour, window)
+
+	_, percent, window, err = (&SLOPolicy{Target: "1h", Percent: 99.5, Window: "1w"}).Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, 99.5, percent)
+	assert.Equal(t, 7*24*time.Hour, window)
+
+	for _, bad := range []*SLOPolicy{{}, {Target: "3d", Percent: 100}, {Target: "3d", Window: "soon"}} {
+		_, _, _, err := bad.Parse()
+		assert.NotNil(t, err)
+	}
+}
+
+func TestAgeBuckets(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	cs := []*hubbub.Conversation{
+		{Created: now.Add(-2 * day)},
+		{Created: now.Add(-10 * day)},
+		{Created: now.Add(-20 * day)},
+		{Created: now.Add(-400 * day)},
+	}
+
+	got := ageBuckets(cs, now)
+	want := []AgeBucket{
+		{Name: "<1w", Count: 1, Percent: 25},
+		{Name: "1-4w", Count: 2, Percent: 50},
+		{Name: "1-3m", Count: 0, Percent: 0},
+		{Name: "older", Count: 1, Percent: 25},
+	}
+	assert.Equal(t, want, got)
 }
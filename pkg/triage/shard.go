@@ -0,0 +1,30 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"time"
+)
+
+// shardNewerThan relaxes how new data must be for repositories another replica is responsible for, so that the data it stored in the shared cache is used rather than fetched again
+func (p *Party) shardNewerThan(repoURL string, newerThan time.Time) time.Time {
+	if p.owns == nil || p.owns(repoURL) {
+		return newerThan
+	}
+	if cutoff := time.Now().Add(-p.shardMaxAge); newerThan.After(cutoff) {
+		return cutoff
+	}
+	return newerThan
+}
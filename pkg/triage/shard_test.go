@@ -0,0 +1,37 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardNewerThan(t *testing.T) {
+	now := time.Now()
+	p := &Party{
+		owns:        func(repo string) bool { return repo == "https://github.com/org/mine" },
+		shardMaxAge: time.Hour,
+	}
+
+	assert.Equal(t, now, p.shardNewerThan("https://github.com/org/mine", now))
+	assert.WithinDuration(t, now.Add(-time.Hour), p.shardNewerThan("https://github.com/org/theirs", now), time.Second)
+	assert.Equal(t, time.Time{}, p.shardNewerThan("https://github.com/org/theirs", time.Time{}), "stale data is already accepted")
+
+	p.owns = nil
+	assert.Equal(t, now, p.shardNewerThan("https://github.com/org/theirs", now))
+}
@@ -0,0 +1,302 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// kindLabelRe matches labels which classify an issue
+	kindLabelRe = regexp.MustCompile(`(?i)^(kind|type)/|^(bug|enhancement|feature|question|documentation|docs)$`)
+	// triageLabelRe matches labels which mark an issue as needing triage
+	triageLabelRe = regexp.MustCompile(`(?i)^(needs[- ]triage|triage/needed|untriaged|triage)$`)
+	// priorityLabelRe matches labels which prioritize an issue
+	priorityLabelRe = regexp.MustCompile(`(?i)^(priority/|priority: )|^p[0-4]$`)
+	// exemptLabelRe matches labels which exempt an item from going stale
+	exemptLabelRe = regexp.MustCompile(`(?i)frozen|pinned|keep-open|long-term|security`)
+)
+
+const (
+	// starterStale is how long items are inactive before the starter configuration considers them stale
+	starterStale = 90 * 24 * time.Hour
+	// starterMaxItems is how many open items to sample when profiling a repository
+	starterMaxItems = 500
+)
+
+// RepoProfile summarizes a repository, to generate a starter configuration from
+type RepoProfile struct {
+	URL        string
+	Labels     []string
+	Milestones []string
+
+	OpenIssues       int
+	OpenPullRequests int
+	// Unlabeled is how many open issues have no labels
+	Unlabeled int
+	// Inactive is how many open items have not been updated for 90 days
+	Inactive int
+}
+
+// ProfileRepo inspects a repository's labels, milestones and open items
+func (p *Party) ProfileRepo(ctx context.Context, repoURL string) (*RepoProfile, error) {
+	r, err := parseRepo(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := p.Provider(r.Host)
+	if pr == nil {
+		return nil, fmt.Errorf("no token configured for %s", r.Host)
+	}
+
+	rp := &RepoProfile{URL: repoURL}
+	sp := provider.SearchParams{Repo: r}
+
+	rp.Labels, _, err = pr.ReposListLabels(ctx, sp)
+	if err != nil {
+		return nil, fmt.Errorf("labels: %w", err)
+	}
+
+	rp.Milestones, _, err = pr.ReposListMilestones(ctx, sp)
+	if err != nil {
+		return nil, fmt.Errorf("milestones: %w", err)
+	}
+
+	sp.IssueListByRepoOptions = provider.IssueListByRepoOptions{
+		State:       "open",
+		ListOptions: provider.ListOptions{PerPage: 100},
+	}
+
+	seen := 0
+	for seen < starterMaxItems {
+		is, resp, err := pr.IssuesListByRepo(ctx, sp)
+		if err != nil {
+			return nil, fmt.Errorf("issues: %w", err)
+		}
+
+		for _, i := range is {
+			seen++
+			if i.IsPullRequest() {
+				rp.OpenPullRequests++
+			} else {
+				rp.OpenIssues++
+				if len(i.Labels) == 0 {
+					rp.Unlabeled++
+				}
+			}
+			if time.Since(i.GetUpdatedAt()) > starterStale {
+				rp.Inactive++
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		sp.IssueListByRepoOptions.ListOptions.Page = resp.NextPage
+	}
+
+	return rp, nil
+}
+
+// starterConfig is a configuration which omits unset fields, so that it is easy to read and edit
+type starterConfig struct {
+	Settings    starterSettings        `yaml:"settings"`
+	Collections []starterCollection    `yaml:"collections"`
+	Rules       map[string]starterRule `yaml:"rules"`
+}
+
+type starterSettings struct {
+	Name        string   `yaml:"name"`
+	Repos       []string `yaml:"repos"`
+	MemberRoles []string `yaml:"member-roles"`
+}
+
+type starterCollection struct {
+	ID          string   `yaml:"id"`
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description,omitempty"`
+	Display     string   `yaml:"display,omitempty"`
+	Overflow    int      `yaml:"overflow,omitempty"`
+	Dedup       bool     `yaml:"dedup,omitempty"`
+	Rules       []string `yaml:"rules"`
+}
+
+type starterRule struct {
+	Name       string            `yaml:"name"`
+	Resolution string            `yaml:"resolution,omitempty"`
+	Type       string            `yaml:"type,omitempty"`
+	Filters    []provider.Filter `yaml:"filters"`
+}
+
+// StarterConfig returns a starter configuration for a repository, using its labels to build rules
+func StarterConfig(rp RepoProfile) ([]byte, error) {
+	r, err := parseRepo(rp.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := matchingLabels(rp.Labels, kindLabelRe)
+	triage := matchingLabels(rp.Labels, triageLabelRe)
+	priorities := matchingLabels(rp.Labels, priorityLabelRe)
+	exempt := matchingLabels(rp.Labels, exemptLabelRe)
+
+	rules := map[string]Rule{}
+
+	untriaged := Rule{
+		Name:       "Untriaged issues",
+		Resolution: "Add a kind label, or close the issue",
+		Type:       "issue",
+	}
+	switch {
+	case len(triage) > 0:
+		untriaged.Filters = []provider.Filter{{RawLabel: labelRegex(triage[:1])}}
+	case len(kinds) > 0:
+		untriaged.Filters = []provider.Filter{{RawLabel: "!" + labelRegex(kinds)}}
+	default:
+		untriaged.Name = "Unlabeled issues"
+		untriaged.Resolution = "Add a label, or close the issue"
+		untriaged.Filters = []provider.Filter{{RawLabel: "!.*"}}
+	}
+	rules["issue-untriaged"] = untriaged
+
+	rules["issue-needs-comment"] = Rule{
+		Name:       "Unresponded, older than 3 days",
+		Resolution: "Respond to the author",
+		Type:       "issue",
+		Filters: []provider.Filter{
+			{RawTag: "!commented"},
+			{RawTag: "recv"},
+			{Created: "+3d"},
+		},
+	}
+
+	rules["pr-needs-review"] = Rule{
+		Name:       "Pull requests: needs review",
+		Resolution: "Review the pull request",
+		Type:       "pull_request",
+		Filters: []provider.Filter{
+			{RawTitle: "!.*(WIP|wip).*"},
+			{RawTag: "!draft"},
+			{RawTag: "(new-commits|unreviewed)"},
+		},
+	}
+
+	daily := []string{"issue-untriaged", "issue-needs-comment", "pr-needs-review"}
+
+	if len(priorities) > 0 && len(kinds) > 0 {
+		rules["issue-needs-priority"] = Rule{
+			Name:       "Issues: needs priority",
+			Resolution: "Add a priority label",
+			Type:       "issue",
+			Filters: []provider.Filter{
+				{RawLabel: labelRegex(kinds)},
+				{RawLabel: "!" + labelRegex(priorities)},
+			},
+		}
+		daily = append(daily, "issue-needs-priority")
+	}
+
+	// Avoid a wall of stale items on repositories with a long tail of inactive ones
+	staleAfter := "+90d"
+	if total := rp.OpenIssues + rp.OpenPullRequests; total > 0 && rp.Inactive*2 > total {
+		staleAfter = "+365d"
+	}
+
+	for _, t := range []struct{ id, name, kind string }{
+		{"issue-stale", "Issues: stale", "issue"},
+		{"pr-stale", "Pull requests: stale", "pull_request"},
+	} {
+		fs := []provider.Filter{{Updated: staleAfter}, {Responded: "+30d"}}
+		if len(exempt) > 0 {
+			fs = append(fs, provider.Filter{RawLabel: "!" + labelRegex(exempt)})
+		}
+		rules[t.id] = Rule{
+			Name:       t.name,
+			Resolution: "Comment, or close",
+			Type:       t.kind,
+			Filters:    fs,
+		}
+	}
+
+	collections := []starterCollection{
+		{ID: "daily", Name: "Daily Triage", Description: "Items to process every day", Rules: daily, Dedup: true},
+		{ID: "stale", Name: "Stale", Description: "Items which may be closed", Rules: []string{"issue-stale", "pr-stale"}},
+	}
+
+	if len(rp.Milestones) > 0 {
+		rules["milestone"] = Rule{
+			Name:    "In an open milestone",
+			Filters: []provider.Filter{{RawTag: "open-milestone"}},
+		}
+		collections = append(collections, starterCollection{
+			ID:       "milestone",
+			Name:     "Milestone",
+			Rules:    []string{"milestone"},
+			Display:  "kanban",
+			Overflow: 3,
+			Dedup:    true,
+		})
+	}
+
+	sc := starterConfig{
+		Settings: starterSettings{
+			Name:        r.Project,
+			Repos:       []string{rp.URL},
+			MemberRoles: []string{"owner", "member", "collaborator"},
+		},
+		Collections: collections,
+		Rules:       map[string]starterRule{},
+	}
+	for id, r := range rules {
+		sc.Rules[id] = starterRule{Name: r.Name, Resolution: r.Resolution, Type: r.Type, Filters: r.Filters}
+	}
+
+	bs, err := yaml.Marshal(sc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	header := fmt.Sprintf("# Starter configuration for %s, based on %d labels, %d open milestones, %d open issues (%d unlabeled) and %d open pull requests.\n# See docs/config.md to adjust it.\n---\n",
+		rp.URL, len(rp.Labels), len(rp.Milestones), rp.OpenIssues, rp.Unlabeled, rp.OpenPullRequests)
+	return append([]byte(header), bs...), nil
+}
+
+// matchingLabels returns the labels which match a regular expression
+func matchingLabels(labels []string, re *regexp.Regexp) []string {
+	found := []string{}
+	for _, l := range labels {
+		if re.MatchString(l) {
+			found = append(found, l)
+		}
+	}
+	return found
+}
+
+// labelRegex returns a label filter matching any of a list of labels
+func labelRegex(labels []string) string {
+	quoted := []string{}
+	for _, l := range labels {
+		quoted = append(quoted, regexp.QuoteMeta(l))
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
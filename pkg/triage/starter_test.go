@@ -0,0 +1,43 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestStarterConfig(t *testing.T) {
+	rp := RepoProfile{
+		URL:        "https://github.com/org/project",
+		Labels:     []string{"kind/bug", "kind/feature", "priority/p0", "lifecycle/frozen", "good first issue"},
+		OpenIssues: 10,
+		Inactive:   2,
+	}
+
+	bs, err := StarterConfig(rp)
+	assert.Nil(t, err)
+
+	dc := diskConfig{}
+	assert.Nil(t, yaml.Unmarshal(bs, &dc))
+	assert.Equal(t, []string{"https://github.com/org/project"}, dc.Settings.Repos)
+	assert.Equal(t, []provider.Filter{{RawLabel: "!^(kind/bug|kind/feature)$"}}, dc.RawRules["issue-untriaged"].Filters)
+	assert.Equal(t, []provider.Filter{{Updated: "+90d"}, {Responded: "+30d"}, {RawLabel: "!^(lifecycle/frozen)$"}}, dc.RawRules["issue-stale"].Filters)
+	assert.Equal(t, []string{"issue-untriaged", "issue-needs-comment", "pr-needs-review", "issue-needs-priority"}, dc.RawCollections[0].RuleIDs)
+	assert.Equal(t, 2, len(dc.RawCollections))
+}
@@ -19,12 +19,25 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/quota"
 
+	"github.com/google/triage-party/pkg/embedding"
 	"github.com/google/triage-party/pkg/hubbub"
 	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/scoring"
+	"golang.org/x/oauth2"
 	"gopkg.in/yaml.v2"
 	"k8s.io/klog/v2"
 )
@@ -38,19 +51,79 @@ type Config struct {
 	GitHubAPIURL string
 	GitHubToken  string
 	GitLabToken  string
+
+	// BitbucketServerURL is the address of an on-premise Bitbucket Server, such as https://bitbucket.example.com
+	BitbucketServerURL   string
+	BitbucketServerToken string
+
+	// SourceHutToken is a personal access token for todo.sr.ht
+	SourceHutToken string
+
+	// LaunchpadToken is an OAuth token in the form of consumer-key:token:token-secret. Launchpad bugs are read anonymously without one.
+	LaunchpadToken string
+
+	// GitHubTokenSource and GitLabTokenSource take precedence over static tokens, allowing them to be rotated
+	GitHubTokenSource          oauth2.TokenSource
+	GitLabTokenSource          oauth2.TokenSource
+	BitbucketServerTokenSource oauth2.TokenSource
+	SourceHutTokenSource       oauth2.TokenSource
+
+	// ClosedAge is the minimum age of closed items to fetch, such as for backfills
+	ClosedAge time.Duration
+
+	// Record saves provider responses to this directory, and Replay serves them from it instead of the network
+	Record string
+	Replay string
+
+	// Owns returns whether this replica is responsible for fetching a repository, when replicas share a cache. nil owns every repository.
+	Owns func(repoURL string) bool
+	// ShardMaxAge is how old cached data may be for repositories another replica is responsible for
+	ShardMaxAge time.Duration
 }
 
 type Party struct {
+	// mu guards the loaded configuration and engine, which may be swapped by Reload
+	mu sync.RWMutex
+
 	engine        *hubbub.Engine
+	engineKey     string
 	settings      Settings
 	collections   []Collection
+	responses     map[string]Response
+	warnings      []string
+	calendar      *hubbub.Calendar
+	areas         []hubbub.Area
 	cache         persist.Cacher
 	rules         map[string]Rule
 	reposOverride []string
 	debug         map[int]bool
+	closedAge     time.Duration
+
+	// virtualFilters restrict items to each virtual repository, by name
+	virtualFilters map[string]provider.Filter
+
+	// paths and files are the config sources, and every file they include, if loaded from disk
+	paths []string
+	files map[string]fileStamp
 
 	github provider.Provider
 	gitlab provider.Provider
+
+	// bitbucket serves repositories on bitbucketHost
+	bitbucket     provider.Provider
+	bitbucketHost string
+
+	sourcehut provider.Provider
+	launchpad provider.Provider
+
+	// quota records how provider rate limits are consumed
+	quota *quota.Log
+
+	// retriers wrap each provider, and are told of retry settings as they are loaded
+	retriers []*provider.Retrier
+
+	owns        func(repoURL string) bool
+	shardMaxAge time.Duration
 }
 
 func New(cfg Config) (*Party, error) {
@@ -58,25 +131,92 @@ func New(cfg Config) (*Party, error) {
 		cache:         cfg.Cache,
 		reposOverride: cfg.Repos,
 		debug:         map[int]bool{},
+		closedAge:     cfg.ClosedAge,
+		bitbucketHost: provider.BitbucketServerHost(cfg.BitbucketServerURL),
+		quota:         quota.New(),
+		owns:          cfg.Owns,
+		shardMaxAge:   cfg.ShardMaxAge,
 	}
 
 	var err error
-	if cfg.GitLabToken != "" {
+	if cfg.Replay != "" {
+		if err := p.replay(cfg.Replay); err != nil {
+			return nil, err
+		}
+	} else if cfg.GitLabTokenSource != nil {
+		p.gitlab, err = provider.NewGitLabFromSource(cfg.GitLabTokenSource)
+		if err != nil {
+			return p, fmt.Errorf("gitlab: %v", err)
+		}
+	} else if cfg.GitLabToken != "" {
 		p.gitlab, err = provider.NewGitLab(cfg.GitLabToken)
 		if err != nil {
 			return p, fmt.Errorf("gitlab: %v", err)
 		}
 	}
 
-	if cfg.GitHubToken != "" {
+	if cfg.Replay != "" {
+		// providers were set up above
+	} else if cfg.GitHubTokenSource != nil {
+		p.github, err = provider.NewGitHubFromSource(context.Background(), cfg.GitHubTokenSource, cfg.GitHubAPIURL)
+		if err != nil {
+			return p, fmt.Errorf("github: %v", err)
+		}
+	} else if cfg.GitHubToken != "" {
 		p.github, err = provider.NewGitHub(context.Background(), cfg.GitHubToken, cfg.GitHubAPIURL)
 		if err != nil {
 			return p, fmt.Errorf("github: %v", err)
 		}
 	}
 
-	if p.gitlab == nil && p.github == nil {
-		return nil, fmt.Errorf("You need to pass a token for GitHub or GitLab")
+	if cfg.Replay != "" || cfg.BitbucketServerURL == "" {
+		// no Bitbucket Server, or it was set up above
+	} else if cfg.BitbucketServerTokenSource != nil {
+		p.bitbucket, err = provider.NewBitbucketServerFromSource(cfg.BitbucketServerURL, cfg.BitbucketServerTokenSource)
+		if err != nil {
+			return p, fmt.Errorf("bitbucket server: %v", err)
+		}
+	} else if cfg.BitbucketServerToken != "" {
+		p.bitbucket, err = provider.NewBitbucketServer(cfg.BitbucketServerURL, cfg.BitbucketServerToken)
+		if err != nil {
+			return p, fmt.Errorf("bitbucket server: %v", err)
+		}
+	}
+
+	if cfg.Replay != "" {
+		// providers were set up above
+	} else if cfg.SourceHutTokenSource != nil {
+		p.sourcehut, err = provider.NewSourceHutFromSource(cfg.SourceHutTokenSource)
+		if err != nil {
+			return p, fmt.Errorf("sourcehut: %v", err)
+		}
+	} else if cfg.SourceHutToken != "" {
+		p.sourcehut, err = provider.NewSourceHut(cfg.SourceHutToken)
+		if err != nil {
+			return p, fmt.Errorf("sourcehut: %v", err)
+		}
+	}
+
+	if p.gitlab == nil && p.github == nil && p.bitbucket == nil && p.sourcehut == nil && p.launchpad == nil && cfg.LaunchpadToken == "" {
+		return nil, fmt.Errorf("You need to pass a token for GitHub, GitLab, Bitbucket Server, SourceHut or Launchpad")
+	}
+
+	if cfg.Replay == "" {
+		// Public bugs may be read without a token, so that they can be triaged alongside a mirror
+		p.launchpad, err = provider.NewLaunchpad(cfg.LaunchpadToken)
+		if err != nil {
+			return p, fmt.Errorf("launchpad: %v", err)
+		}
+	}
+
+	if cfg.Record != "" {
+		if err := p.record(cfg.Record); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Replay == "" {
+		p.retry()
 	}
 
 	for _, n := range cfg.DebugNumbers {
@@ -88,23 +228,399 @@ func New(cfg Config) (*Party, error) {
 	return p, nil
 }
 
+// record wraps the providers so that their responses are saved to dir
+func (p *Party) record(dir string) error {
+	var err error
+	if p.github != nil {
+		p.github, err = provider.NewRecorder(p.github, filepath.Join(dir, "github"))
+		if err != nil {
+			return fmt.Errorf("github recorder: %w", err)
+		}
+	}
+	if p.gitlab != nil {
+		p.gitlab, err = provider.NewRecorder(p.gitlab, filepath.Join(dir, "gitlab"))
+		if err != nil {
+			return fmt.Errorf("gitlab recorder: %w", err)
+		}
+	}
+	if p.bitbucket != nil {
+		p.bitbucket, err = provider.NewRecorder(p.bitbucket, filepath.Join(dir, "bitbucket-server"))
+		if err != nil {
+			return fmt.Errorf("bitbucket server recorder: %w", err)
+		}
+	}
+	if p.sourcehut != nil {
+		p.sourcehut, err = provider.NewRecorder(p.sourcehut, filepath.Join(dir, "sourcehut"))
+		if err != nil {
+			return fmt.Errorf("sourcehut recorder: %w", err)
+		}
+	}
+	if p.launchpad != nil {
+		p.launchpad, err = provider.NewRecorder(p.launchpad, filepath.Join(dir, "launchpad"))
+		if err != nil {
+			return fmt.Errorf("launchpad recorder: %w", err)
+		}
+	}
+	klog.Infof("recording provider responses to %s", dir)
+	return nil
+}
+
+// retry wraps the providers so that transient failures of their read requests are retried
+func (p *Party) retry() {
+	for _, pr := range []*provider.Provider{&p.github, &p.gitlab, &p.bitbucket, &p.sourcehut, &p.launchpad} {
+		if *pr == nil {
+			continue
+		}
+		r := provider.NewRetrier(*pr, provider.DefaultRetryPolicy)
+		p.retriers = append(p.retriers, r)
+		*pr = r
+	}
+}
+
+// replay sets up providers which serve the responses saved by record, without network access
+func (p *Party) replay(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	if r, err := provider.NewReplayer(filepath.Join(dir, "github")); err == nil {
+		p.github = r
+	}
+	if r, err := provider.NewReplayer(filepath.Join(dir, "gitlab")); err == nil {
+		p.gitlab = r
+	}
+	if r, err := provider.NewReplayer(filepath.Join(dir, "bitbucket-server")); err == nil && p.bitbucketHost != "" {
+		p.bitbucket = r
+	}
+	if r, err := provider.NewReplayer(filepath.Join(dir, "sourcehut")); err == nil {
+		p.sourcehut = r
+	}
+	if r, err := provider.NewReplayer(filepath.Join(dir, "launchpad")); err == nil {
+		p.launchpad = r
+	}
+	if p.github == nil && p.gitlab == nil && p.bitbucket == nil && p.sourcehut == nil && p.launchpad == nil {
+		return fmt.Errorf("replay: no recordings found in %s", dir)
+	}
+	klog.Infof("replaying provider responses from %s", dir)
+	return nil
+}
+
 type Settings struct {
 	Name          string   `yaml:"name"`
 	Repos         []string `yaml:"repos"`
 	MinSimilarity float64  `yaml:"min_similarity"`
 	MemberRoles   []string `yaml:"member-roles"`
 	Members       []string `yaml:"members"`
+	// MemberGroups are named lists of users, which may be referenced as @name wherever users are listed
+	MemberGroups map[string][]string `yaml:"member-groups,omitempty"`
+
+	// BusinessHours is the working time used by business durations, such as +2bd
+	BusinessHours *BusinessHours `yaml:"business-hours,omitempty"`
+
+	// OutOfOffice lists when members are away, so that they are not expected to respond or be assigned work
+	OutOfOffice []Absence `yaml:"out-of-office,omitempty"`
+
+	// Similarity tunes how similar items are found
+	Similarity *SimilaritySettings `yaml:"similarity,omitempty"`
+
+	// FirstResponseSLO is how soon members aim to respond to non-members, such as 2d
+	FirstResponseSLO string `yaml:"first-response-slo,omitempty"`
+
+	// Reports are summaries generated on a schedule
+	Reports []ReportSettings `yaml:"reports,omitempty"`
+
+	// Owners maps items to areas, such as SIGs, using Kubernetes-style OWNERS files
+	Owners *OwnersSettings `yaml:"owners,omitempty"`
+
+	// VirtualRepos are slices of monorepos, by name, which are referenced as the repository URL with a #name suffix
+	VirtualRepos map[string]VirtualRepo `yaml:"virtual-repos,omitempty"`
+
+	// Rotation declares who is on triage duty, in turn
+	Rotation *RotationSettings `yaml:"rotation,omitempty"`
+
+	// Federation lists other Triage Party instances to roll up
+	Federation []FederatedInstance `yaml:"federation,omitempty"`
+
+	// Scoring configures an external service which gives items a priority score
+	Scoring *ScoringSettings `yaml:"scoring,omitempty"`
+
+	// Mentions lists maintainers and teams whose @-mentions are expected to be answered
+	Mentions *MentionSettings `yaml:"mentions,omitempty"`
+
+	// TriageStates are the named steps of triage, such as new or needs-info, and which may follow which
+	TriageStates []TriageState `yaml:"triage-states,omitempty"`
+
+	// Retry tunes how transient provider failures, such as 5xx responses, are retried
+	Retry *RetrySettings `yaml:"retry,omitempty"`
+
+	Jira   JiraSettings    `yaml:"jira,omitempty"`
+	Public *PublicSettings `yaml:"public,omitempty"`
+	Access *AccessPolicy   `yaml:"access,omitempty"`
+}
+
+// AccessPolicy restricts who may see collections, act on their items, or administer the site
+type AccessPolicy struct {
+	View Principals `yaml:"view,omitempty"`
+	// Act defaults to View if empty
+	Act Principals `yaml:"act,omitempty"`
+	// Admin is only used within settings, and defaults to Act if empty
+	Admin Principals `yaml:"admin,omitempty"`
+}
+
+// Principals is a set of users and groups. An empty set includes everyone.
+type Principals struct {
+	Users []string `yaml:"users,omitempty"`
+	// Groups are organizations, or teams in the form of org/team. Prefix with a host to use a provider other than GitHub, such as gitlab.com/group.
+	Groups []string `yaml:"groups,omitempty"`
+	// IdPGroups are groups asserted by the OIDC identity provider at login
+	IdPGroups []string `yaml:"idp-groups,omitempty"`
+}
+
+// Empty returns whether no users or groups are listed
+func (ps Principals) Empty() bool {
+	return len(ps.Users) == 0 && len(ps.Groups) == 0 && len(ps.IdPGroups) == 0
+}
+
+// PublicSettings configures the anonymized public backlog health page
+type PublicSettings struct {
+	// BugLabel is a regular expression matching labels used for bugs
+	BugLabel string `yaml:"bug-label,omitempty"`
+	// ResponseSLA is how long items may wait for a member response, such as 3d
+	ResponseSLA string `yaml:"response-sla,omitempty"`
+}
+
+// RetrySettings tunes how transient provider failures are retried, overriding provider.DefaultRetryPolicy
+type RetrySettings struct {
+	// MaxAttempts is how many times a request is made in all, so 1 disables retries
+	MaxAttempts int `yaml:"max-attempts,omitempty"`
+	// InitialBackoff is how long to wait before the first retry, such as 1s, doubling for each one after it
+	InitialBackoff string `yaml:"initial-backoff,omitempty"`
+	// MaxBackoff is the longest wait between retries, such as 30s
+	MaxBackoff string `yaml:"max-backoff,omitempty"`
+	// Jitter is the fraction of each backoff which is randomized, from 0 to 1
+	Jitter *float64 `yaml:"jitter,omitempty"`
+	// Budget is the fraction of requests which may be retries, such as 0.1
+	Budget *float64 `yaml:"budget,omitempty"`
+}
+
+// policy returns the retry policy, with defaults for anything unset
+func (s *RetrySettings) policy() provider.RetryPolicy {
+	rp := provider.DefaultRetryPolicy
+	if s == nil {
+		return rp
+	}
+	if s.MaxAttempts > 0 {
+		rp.MaxAttempts = s.MaxAttempts
+	}
+	if d, _, _ := hubbub.ParseDuration(s.InitialBackoff); d > 0 {
+		rp.InitialBackoff = d
+	}
+	if d, _, _ := hubbub.ParseDuration(s.MaxBackoff); d > 0 {
+		rp.MaxBackoff = d
+	}
+	if s.Jitter != nil {
+		rp.Jitter = *s.Jitter
+	}
+	if s.Budget != nil {
+		rp.Budget = *s.Budget
+	}
+	return rp
+}
+
+// check validates retry settings
+func (s *RetrySettings) check() error {
+	if s.MaxAttempts < 0 {
+		return fmt.Errorf("max-attempts must not be negative: %d", s.MaxAttempts)
+	}
+	for name, v := range map[string]string{"initial-backoff": s.InitialBackoff, "max-backoff": s.MaxBackoff} {
+		if v == "" {
+			continue
+		}
+		if d, _, _ := hubbub.ParseDuration(v); d <= 0 {
+			return fmt.Errorf("invalid %s: %q", name, v)
+		}
+	}
+	if s.Jitter != nil && (*s.Jitter < 0 || *s.Jitter > 1) {
+		return fmt.Errorf("jitter must be between 0 and 1: %v", *s.Jitter)
+	}
+	if s.Budget != nil && *s.Budget < 0 {
+		return fmt.Errorf("budget must not be negative: %v", *s.Budget)
+	}
+	return nil
+}
+
+// SimilaritySettings tunes how similar items are found
+type SimilaritySettings struct {
+	// Algorithm is how titles are compared: dice, tokens or tfidf
+	Algorithm string `yaml:"algorithm,omitempty"`
+	// MinScore is how similar two titles must be, from 0 to 1, overriding min_similarity
+	MinScore float64 `yaml:"min-score,omitempty"`
+	// MaxResults is how many similar items to list per item
+	MaxResults int `yaml:"max-results,omitempty"`
+	// Scope is which repositories similar items may be found in: all, organization or repo
+	Scope string `yaml:"scope,omitempty"`
+	// ExcludeTitles are regular expressions of titles which are never similar, such as recurring reports
+	ExcludeTitles []string `yaml:"exclude-titles,omitempty"`
+	// TraceWeight is how much matching stack traces count for against titles, from 0 to 1
+	TraceWeight *float64 `yaml:"trace-weight,omitempty"`
+	// Weights are how much titles, bodies and labels count for, defaulting to titles alone
+	Weights *SimilarityWeights `yaml:"weights,omitempty"`
+	// ProjectWeights override Weights for repositories, by URL
+	ProjectWeights map[string]SimilarityWeights `yaml:"project-weights,omitempty"`
+	// RecomputeInterval is the least time between recomputing similarity from the same search, such as 10m
+	RecomputeInterval string `yaml:"recompute-interval,omitempty"`
+	// Embeddings configures the service used by the embeddings algorithm
+	Embeddings *EmbeddingSettings `yaml:"embeddings,omitempty"`
+}
+
+// SimilarityWeights are how much each field of items counts for when comparing them
+type SimilarityWeights struct {
+	Title  float64 `yaml:"title,omitempty"`
+	Body   float64 `yaml:"body,omitempty"`
+	Labels float64 `yaml:"labels,omitempty"`
+}
+
+// check returns an error if the weights can not be used
+func (w SimilarityWeights) check() error {
+	if w.Title < 0 || w.Body < 0 || w.Labels < 0 {
+		return fmt.Errorf("weights must not be negative: %+v", w)
+	}
+	if w.Title+w.Body+w.Labels == 0 {
+		return fmt.Errorf("at least one weight must be set")
+	}
+	return nil
+}
+
+// EmbeddingSettings configures an external embedding service
+type EmbeddingSettings struct {
+	// URL is an endpoint which accepts OpenAI-style embedding requests
+	URL string `yaml:"url"`
+	// Model is passed to the service, if set
+	Model string `yaml:"model,omitempty"`
+	// Token is an optional API token secret reference, such as a file path or vault://secret/data/tp#embeddings
+	Token string `yaml:"token,omitempty"`
+	// IncludeBody embeds bodies as well as titles
+	IncludeBody bool `yaml:"include-body,omitempty"`
+}
+
+// MentionSettings lists maintainers and teams whose @-mentions are expected to be answered
+type MentionSettings struct {
+	// Maintainers are logins or @groups who are expected to respond when mentioned
+	Maintainers []string `yaml:"maintainers,omitempty"`
+	// Teams are teams, such as org/team, and the logins or @groups who may respond on their behalf
+	Teams map[string][]string `yaml:"teams,omitempty"`
+	// Within is how recent a mention must be to be considered, such as 7d. The default is 14d.
+	Within string `yaml:"within,omitempty"`
+}
+
+// defaultMentionsWithin is how recent a mention must be to be considered, if not configured
+const defaultMentionsWithin = 14 * 24 * time.Hour
+
+// mentions returns the mentions understood by the engine, once groups have been expanded
+func (ms *MentionSettings) mentions() hubbub.Mentions {
+	m := hubbub.Mentions{Within: defaultMentionsWithin, Responders: map[string][]string{}}
+	if ms == nil {
+		return m
+	}
+
+	if ms.Within != "" {
+		m.Within, _, _ = hubbub.ParseDuration(ms.Within)
+	}
+	for _, login := range ms.Maintainers {
+		m.Responders[strings.ToLower(login)] = []string{login}
+	}
+	for team, logins := range ms.Teams {
+		m.Responders[strings.ToLower(team)] = logins
+	}
+	return m
+}
+
+// TriageState is a named step of triage, which items are in when they have its label
+type TriageState struct {
+	Name string `yaml:"name"`
+	// Label marks items in this state. At most one state may omit it, which is then the state of items without any state label.
+	Label string `yaml:"label,omitempty"`
+	// Next are the names of the states which items may move to from this one
+	Next []string `yaml:"next,omitempty"`
+}
+
+// ScoringSettings configures an external priority scoring service
+type ScoringSettings struct {
+	// URL is an endpoint which accepts an item as JSON, and returns its score and labels
+	URL string `yaml:"url"`
+	// Token is an optional API token secret reference, such as a file path or vault://secret/data/tp#scoring
+	Token string `yaml:"token,omitempty"`
+}
+
+// FederatedInstance is another Triage Party instance, whose backlog is rolled up into this one
+type FederatedInstance struct {
+	// Name is shown in the rollup, such as the team which runs the instance
+	Name string `yaml:"name"`
+	// URL is the base URL of the instance, including any prefix
+	URL string `yaml:"url"`
+	// Token is an optional API token secret reference, such as a file path or vault://secret/data/tp#team-a
+	Token string `yaml:"token,omitempty"`
+}
+
+// ReportSettings configures a summary which is generated on a schedule
+type ReportSettings struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name,omitempty"`
+	// Schedule is a cron expression, such as "0 9 * * 1" for Mondays at 09:00
+	Schedule string `yaml:"schedule"`
+	// Timezone is the location the schedule is evaluated in, such as America/New_York
+	Timezone string `yaml:"timezone,omitempty"`
+	// Collections to summarize, defaulting to those which are not hidden
+	Collections []string `yaml:"collections,omitempty"`
+	// Sections to include: counts, trends, stale and sla, defaulting to all
+	Sections []string `yaml:"sections,omitempty"`
+	// Top is how many stale items to list
+	Top int `yaml:"top,omitempty"`
+	// Trend is the period that counts are compared against, such as 7d
+	Trend string `yaml:"trend,omitempty"`
+	// SLA is how long items may wait for a member response, defaulting to the first response SLO
+	SLA string `yaml:"sla,omitempty"`
+	// Output is a directory or http(s) URL which reports are written to
+	Output string `yaml:"output,omitempty"`
+	// Email is who the report is mailed to
+	Email []string `yaml:"email,omitempty"`
+}
+
+// JiraSettings configures how Jira tickets are created from conversations
+type JiraSettings struct {
+	// URL is the base URL of the Jira instance
+	URL string `yaml:"url,omitempty"`
+	// Project is the default Jira project key
+	Project string `yaml:"project,omitempty"`
+	// Projects maps repository URLs to Jira project keys
+	Projects map[string]string `yaml:"projects,omitempty"`
+	// IssueType is the default Jira issue type
+	IssueType string `yaml:"issue-type,omitempty"`
+	// IssueTypes maps labels to Jira issue types
+	IssueTypes map[string]string `yaml:"issue-types,omitempty"`
+}
+
+// Response is a canned comment which can be posted from the UI
+type Response struct {
+	ID   string `yaml:"-"`
+	Name string `yaml:"name"`
+	// Body is a Go template, evaluated against the conversation it is posted to
+	Body string `yaml:"body"`
 }
 
 // diskConfig is the on-disk configuration
 type diskConfig struct {
-	Settings       Settings        `yaml:"settings"`
-	RawCollections []Collection    `yaml:"collections"`
-	RawRules       map[string]Rule `yaml:"rules"`
+	// Include lists files (or glob patterns) whose rules, collections and responses are merged in
+	Include        []string            `yaml:"include,omitempty"`
+	Settings       Settings            `yaml:"settings"`
+	RawCollections []Collection        `yaml:"collections"`
+	RawRules       map[string]Rule     `yaml:"rules"`
+	Responses      map[string]Response `yaml:"responses,omitempty"`
+	// Macros are named lists of filters, which rules reference with "use"
+	Macros map[string][]provider.Filter `yaml:"macros,omitempty"`
 }
 
-// newEngine configures a new search engine based on our loaded configs
-func (p *Party) newEngine() *hubbub.Engine {
+// engineConfig returns the search engine configuration for our loaded configs
+func (p *Party) engineConfig() hubbub.Config {
 	roles := p.settings.MemberRoles
 
 	if len(roles) == 0 && len(p.settings.Members) == 0 {
@@ -116,7 +632,7 @@ func (p *Party) newEngine() *hubbub.Engine {
 	}
 
 	// Why calculate here? So we can share a closed cache among all queries
-	maxClosedUpdateAge := time.Duration(0)
+	maxClosedUpdateAge := p.closedAge
 	for _, r := range p.rules {
 		ca := closedAge(r.Filters)
 		if ca > maxClosedUpdateAge {
@@ -132,16 +648,66 @@ func (p *Party) newEngine() *hubbub.Engine {
 		MinSimilarity:      p.settings.MinSimilarity,
 		MemberRoles:        roles,
 		Members:            p.settings.Members,
+		TraceWeight:        hubbub.DefaultTraceWeight,
+		Areas:              p.areas,
+		TriageStates:       triageStates(p.settings.TriageStates),
+		Mentions:           p.settings.Mentions.mentions(),
 
 		GitLab: p.gitlab,
 		GitHub: p.github,
+
+		BitbucketServer:     p.bitbucket,
+		BitbucketServerHost: p.bitbucketHost,
+		SourceHut:           p.sourcehut,
+		Launchpad:           p.launchpad,
+		Quota:               p.quota,
+	}
+
+	if s := p.settings.Similarity; s != nil {
+		hc.SimilarityAlgorithm = s.Algorithm
+		hc.MaxSimilar = s.MaxResults
+		hc.SimilarityScope = s.Scope
+		hc.ExcludeTitles = s.ExcludeTitles
+		if s.TraceWeight != nil {
+			hc.TraceWeight = *s.TraceWeight
+		}
+		if s.Weights != nil {
+			hc.Weights = hubbub.SimilarityWeights(*s.Weights)
+		}
+		if len(s.ProjectWeights) > 0 {
+			hc.ProjectWeights = map[string]hubbub.SimilarityWeights{}
+			for repo, w := range s.ProjectWeights {
+				hc.ProjectWeights[repo] = hubbub.SimilarityWeights(w)
+			}
+		}
+		if s.RecomputeInterval != "" {
+			hc.SimilarityInterval, _, _ = hubbub.ParseDuration(s.RecomputeInterval)
+		}
+		if e := s.Embeddings; e != nil && s.Algorithm == hubbub.EmbeddingSimilarity {
+			hc.Embedder = embedding.New(embedding.Config{URL: e.URL, Model: e.Model, Token: e.Token})
+			hc.EmbedBodies = e.IncludeBody
+		}
+		if s.MinScore > 0 {
+			hc.MinSimilarity = s.MinScore
+		}
 	}
 
-	klog.Infof("New hubbub with config: %+v", hc)
-	return hubbub.New(hc)
+	if s := p.settings.Scoring; s != nil {
+		hc.Scorer = scoring.New(scoring.Config{URL: s.URL, Token: s.Token})
+	}
+
+	hc.Calendar = p.calendar
+	return hc
+}
+
+// eng returns the search engine
+func (p *Party) eng() *hubbub.Engine {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.engine
 }
 
-// Load loads a YAML config from a reader
+// Load loads a YAML config from a reader. Includes are relative to the working directory.
 func (p *Party) Load(r io.Reader) error {
 	bs, err := ioutil.ReadAll(r)
 	if err != nil {
@@ -149,12 +715,15 @@ func (p *Party) Load(r io.Reader) error {
 	}
 	klog.Infof("%d bytes read from config", len(bs))
 
-	dc := &diskConfig{}
-	err = yaml.Unmarshal(bs, &dc)
+	dc, err := readConfig(bs, "", map[string]bool{})
 	if err != nil {
-		return fmt.Errorf("unmarshal: %w", err)
+		return err
 	}
+	return p.load(dc)
+}
 
+// load validates a parsed config, and swaps it in
+func (p *Party) load(dc *diskConfig) error {
 	if len(dc.RawCollections) == 0 {
 		return fmt.Errorf("no collections found after unmarshal")
 	}
@@ -163,20 +732,239 @@ func (p *Party) Load(r io.Reader) error {
 		return fmt.Errorf("no rules found after unmarshal")
 	}
 
-	rules, err := processRules(dc.RawRules)
+	raw, unused, err := expandMacros(dc.RawRules, dc.Macros)
+	if err != nil {
+		return fmt.Errorf("macros: %w", err)
+	}
+
+	groups := newGroupResolver(dc.Settings.MemberGroups)
+	dc.Settings.Members, err = groups.expand(dc.Settings.Members)
+	if err != nil {
+		return fmt.Errorf("members: %w", err)
+	}
+
+	if ms := dc.Settings.Mentions; ms != nil {
+		ms.Maintainers, err = groups.expand(ms.Maintainers)
+		if err != nil {
+			return fmt.Errorf("mentions: %w", err)
+		}
+		for team, logins := range ms.Teams {
+			if !strings.Contains(team, "/") {
+				return fmt.Errorf("mentions: team %q must be in the form of org/team", team)
+			}
+			ms.Teams[team], err = groups.expand(logins)
+			if err != nil {
+				return fmt.Errorf("mentions: %s: %w", team, err)
+			}
+		}
+		if d, _, _ := hubbub.ParseDuration(ms.Within); ms.Within != "" && d <= 0 {
+			return fmt.Errorf("mentions: invalid within duration: %q", ms.Within)
+		}
+	}
+
+	if rs := dc.Settings.Rotation; rs != nil {
+		rs.Members, err = groups.expand(rs.Members)
+		if err != nil {
+			return fmt.Errorf("rotation: %w", err)
+		}
+		if _, _, err := rs.parse(); err != nil {
+			return fmt.Errorf("rotation: %w", err)
+		}
+	}
+
+	if s := dc.Settings.Similarity; s != nil {
+		if err := hubbub.CheckSimilarityAlgorithm(s.Algorithm); err != nil {
+			return fmt.Errorf("similarity: %w", err)
+		}
+		if err := hubbub.CheckSimilarityScope(s.Scope); err != nil {
+			return fmt.Errorf("similarity: %w", err)
+		}
+		for _, t := range s.ExcludeTitles {
+			if _, err := regexp.Compile(t); err != nil {
+				return fmt.Errorf("similarity: exclude-titles: %w", err)
+			}
+		}
+		if s.Algorithm == hubbub.EmbeddingSimilarity && (s.Embeddings == nil || s.Embeddings.URL == "") {
+			return fmt.Errorf("similarity: the embeddings algorithm requires an embeddings url")
+		}
+		if s.MinScore < 0 || s.MinScore > 1 {
+			return fmt.Errorf("similarity: min-score must be between 0 and 1: %v", s.MinScore)
+		}
+		if w := s.TraceWeight; w != nil && (*w < 0 || *w > 1) {
+			return fmt.Errorf("similarity: trace-weight must be between 0 and 1: %v", *w)
+		}
+		if s.Weights != nil {
+			if err := s.Weights.check(); err != nil {
+				return fmt.Errorf("similarity: %w", err)
+			}
+		}
+		for repo, w := range s.ProjectWeights {
+			if _, err := parseRepo(repo); err != nil {
+				return fmt.Errorf("similarity: project-weights: %w", err)
+			}
+			if err := w.check(); err != nil {
+				return fmt.Errorf("similarity: project-weights: %s: %w", repo, err)
+			}
+		}
+		if s.RecomputeInterval != "" {
+			if d, _, _ := hubbub.ParseDuration(s.RecomputeInterval); d <= 0 {
+				return fmt.Errorf("similarity: invalid recompute-interval: %q", s.RecomputeInterval)
+			}
+		}
+		if s.MaxResults < 0 {
+			return fmt.Errorf("similarity: max-results must not be negative: %d", s.MaxResults)
+		}
+	}
+
+	for i, fi := range dc.Settings.Federation {
+		if fi.Name == "" || fi.URL == "" {
+			return fmt.Errorf("federation instance %d: name and url are required", i)
+		}
+	}
+
+	if s := dc.Settings.Scoring; s != nil && s.URL == "" {
+		return fmt.Errorf("scoring: url is required")
+	}
+
+	if s := dc.Settings.Retry; s != nil {
+		if err := s.check(); err != nil {
+			return fmt.Errorf("retry: %w", err)
+		}
+	}
+
+	if err := hubbub.CheckTriageStates(triageStates(dc.Settings.TriageStates)); err != nil {
+		return fmt.Errorf("triage-states: %w", err)
+	}
+
+	var areas []hubbub.Area
+	lintCollections := dc.RawCollections
+	if o := dc.Settings.Owners; o != nil {
+		repos := p.reposOverride
+		if len(repos) == 0 {
+			repos = dc.Settings.Repos
+		}
+		areas, err = p.readAreas(context.Background(), o, repos)
+		if err != nil {
+			return fmt.Errorf("owners: %w", err)
+		}
+		klog.Infof("%d areas found in OWNERS files", len(areas))
+
+		if o.Collection != nil {
+			cs, err := areaCollections(areas, o.Collection, raw, dc.RawCollections)
+			if err != nil {
+				return fmt.Errorf("owners: %w", err)
+			}
+			dc.RawCollections = append(dc.RawCollections, cs...)
+			// The rules areas are generated from need not be used directly
+			lintCollections = append(dc.RawCollections, Collection{ID: "owners", RuleIDs: o.Collection.Rules})
+		}
+	}
+
+	vareas, vfilters, err := virtualAreas(dc.Settings.VirtualRepos)
+	if err != nil {
+		return fmt.Errorf("virtual-repos: %w", err)
+	}
+	areas = append(areas, vareas...)
+
+	rules, err := processRules(raw, groups)
 	if err != nil {
 		return fmt.Errorf("rule processing: %w", err)
 	}
 
-	p.collections = dc.RawCollections
-	p.rules = rules
-	p.settings = dc.Settings
+	warnings := []string{}
+	for _, m := range unused {
+		warnings = append(warnings, fmt.Sprintf("macro %q is unused", m))
+	}
+	for _, g := range groups.unused() {
+		warnings = append(warnings, fmt.Sprintf("member group %q is unused", g))
+	}
+	warnings = append(warnings, lint(lintCollections, rules)...)
+	for _, w := range warnings {
+		klog.Warningf("config: %s", w)
+	}
+
+	// Validate a candidate, so that a bad reload leaves the running config untouched
+	np := &Party{
+		cache:         p.cache,
+		reposOverride: p.reposOverride,
+		debug:         p.debug,
+		github:        p.github,
+		gitlab:        p.gitlab,
+		bitbucket:     p.bitbucket,
+		bitbucketHost: p.bitbucketHost,
+		sourcehut:     p.sourcehut,
+		launchpad:     p.launchpad,
+		quota:         p.quota,
+		owns:          p.owns,
+		shardMaxAge:   p.shardMaxAge,
+		collections:   dc.RawCollections,
+		rules:         rules,
+		responses:     map[string]Response{},
+		settings:      dc.Settings,
+		areas:         areas,
+
+		virtualFilters: vfilters,
+	}
+	for id, resp := range dc.Responses {
+		resp.ID = id
+		if resp.Name == "" {
+			resp.Name = id
+		}
+		np.responses[id] = resp
+	}
 
-	p.logLoaded()
-	if err := p.validateLoadedConfig(); err != nil {
+	np.logLoaded()
+	if err := np.validateLoadedConfig(); err != nil {
 		return fmt.Errorf("validate config: %w", err)
 	}
-	p.engine = p.newEngine()
+
+	np.calendar, err = np.settings.BusinessHours.calendar()
+	if err != nil {
+		return fmt.Errorf("business-hours: %w", err)
+	}
+
+	if err := addAbsences(np.calendar, np.settings.OutOfOffice); err != nil {
+		return fmt.Errorf("out-of-office: %w", err)
+	}
+
+	if _, err := np.settings.firstResponseSLO(); err != nil {
+		return fmt.Errorf("first-response-slo: %w", err)
+	}
+
+	hc := np.engineConfig()
+	key := fmt.Sprintf("%v %v %v %s %d %s %q %v %v %v %v %v %v %s", hc.Repos, hc.MaxClosedUpdateAge, hc.MinSimilarity, hc.SimilarityAlgorithm, hc.MaxSimilar, hc.SimilarityScope, hc.ExcludeTitles, hc.TraceWeight, hc.SimilarityInterval, hc.Weights, hc.ProjectWeights, hc.MemberRoles, hc.Members, hc.Calendar)
+	key += fmt.Sprintf(" %+v", hc.Areas)
+	if s := np.settings.Similarity; s != nil && s.Embeddings != nil {
+		key += fmt.Sprintf(" %+v", *s.Embeddings)
+	}
+	if s := np.settings.Scoring; s != nil {
+		key += fmt.Sprintf(" %+v", *s)
+	}
+	key += fmt.Sprintf(" %+v %+v", hc.TriageStates, hc.Mentions)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.collections = np.collections
+	p.rules = np.rules
+	p.responses = np.responses
+	p.settings = np.settings
+	p.warnings = warnings
+	p.calendar = np.calendar
+	p.areas = np.areas
+	p.virtualFilters = np.virtualFilters
+
+	rp := np.settings.Retry.policy()
+	for _, r := range p.retriers {
+		r.SetPolicy(rp)
+	}
+
+	// Keep the engine, along with its in-memory state, unless its configuration changed
+	if p.engine == nil || key != p.engineKey {
+		klog.Infof("New hubbub with config: %+v", hc)
+		p.engine = hubbub.New(hc)
+		p.engineKey = key
+	}
 	return nil
 }
 
@@ -239,6 +1027,91 @@ func (p *Party) validateLoadedConfig() error {
 				return fmt.Errorf("lookup rule %q: %w", tid, err)
 			}
 
+			if r.Comment != "" {
+				if _, err := template.New(tid).Parse(r.Comment); err != nil {
+					return fmt.Errorf("rule %q comment: %w", tid, err)
+				}
+			}
+
+			stales := []*StalePolicy{r.Stale}
+			for _, o := range r.Overrides {
+				for _, repo := range o.Repos {
+					if _, err := parseRepo(repo); err != nil {
+						return fmt.Errorf("rule %q override: invalid repo URL %q", tid, repo)
+					}
+				}
+				stales = append(stales, o.Stale)
+			}
+
+			for _, sp := range stales {
+				if sp == nil {
+					continue
+				}
+				if r.Type == hubbub.Alert {
+					return fmt.Errorf("rule %q stale policy: alerts can not be closed by Triage Party", tid)
+				}
+				for _, d := range []string{sp.WarnAfter, sp.CloseAfter} {
+					if pd, _, _ := hubbub.ParseDuration(d); pd <= 0 {
+						return fmt.Errorf("rule %q stale policy: invalid duration %q", tid, d)
+					}
+				}
+			}
+
+			if r.SLO != nil {
+				if _, _, _, err := r.SLO.Parse(); err != nil {
+					return fmt.Errorf("rule %q slo: %w", tid, err)
+				}
+			}
+
+			if err := checkEscalation(r); err != nil {
+				return fmt.Errorf("rule %q escalation: %w", tid, err)
+			}
+
+			for _, repo := range r.Repos {
+				if err := p.checkVirtualRepo(repo); err != nil {
+					return fmt.Errorf("rule %q: %w", tid, err)
+				}
+			}
+
+			if r.Max != nil && *r.Max < 0 {
+				return fmt.Errorf("rule %q max: must not be negative", tid)
+			}
+
+			switch r.Sort {
+			case "", ScoreSort:
+			default:
+				return fmt.Errorf("rule %q: unknown sort %q", tid, r.Sort)
+			}
+			if r.Sort == ScoreSort && p.settings.Scoring == nil {
+				return fmt.Errorf("rule %q: sorting by score requires scoring settings", tid)
+			}
+
+			if r.Type == hubbub.Alert && (r.Comment != "" || r.AutoAssign != nil) {
+				return fmt.Errorf("rule %q: alerts can not be commented on or assigned", tid)
+			}
+
+			if r.Type == hubbub.Epic {
+				if r.Comment != "" || r.AutoAssign != nil || r.Stale != nil || len(r.Escalation) > 0 {
+					return fmt.Errorf("rule %q: epics can not be commented on, assigned or closed", tid)
+				}
+				for _, repo := range r.Repos {
+					if gr, err := parseRepo(repo); err == nil && !gr.AllProjects {
+						return fmt.Errorf("rule %q: epics belong to GitLab groups, but %q is not one", tid, repo)
+					}
+				}
+			}
+
+			if r.AutoAssign != nil {
+				switch r.AutoAssign.Strategy {
+				case "", RoundRobinStrategy, LoadStrategy:
+				default:
+					return fmt.Errorf("rule %q auto-assign: unknown strategy %q", tid, r.AutoAssign.Strategy)
+				}
+				if len(r.AutoAssign.Members) == 0 && len(p.settings.Members) == 0 {
+					return fmt.Errorf("rule %q auto-assign: no members to assign", tid)
+				}
+			}
+
 			seenRule[tid] = &r
 			filters += len(r.Filters)
 		}
@@ -248,6 +1121,12 @@ func (p *Party) validateLoadedConfig() error {
 		return fmt.Errorf("No 'filters' found in the configuration")
 	}
 
+	for id, r := range p.responses {
+		if _, err := template.New(id).Parse(r.Body); err != nil {
+			return fmt.Errorf("response %q: %w", id, err)
+		}
+	}
+
 	// validate that requested repos map to known providers
 	repos := p.settings.Repos
 	if len(p.reposOverride) > 0 {
@@ -259,6 +1138,9 @@ func (p *Party) validateLoadedConfig() error {
 		if err != nil {
 			return fmt.Errorf("invalid repo URL %q", repo)
 		}
+		if err := p.checkVirtualRepo(repo); err != nil {
+			return err
+		}
 	}
 
 	klog.Infof("configuration defines %d filters - looking good!", filters)
@@ -285,65 +1167,461 @@ func (p *Party) logLoaded() {
 	klog.V(2).Infof("Loaded Rules:\n%s", s)
 }
 
-// processRules precaches regular expressions
-func processRules(raw map[string]Rule) (map[string]Rule, error) {
+// processRules precaches regular expressions, and expands member groups
+func processRules(raw map[string]Rule, groups *groupResolver) (map[string]Rule, error) {
 	rules := map[string]Rule{}
 
+	raw, err := resolveExtends(raw)
+	if err != nil {
+		return rules, err
+	}
+
 	for id, t := range raw {
 		rules[id] = t
-		newfs := []provider.Filter{}
 
-		for _, f := range raw[id].Filters {
-			if f.RawLabel != "" {
-				err := f.LoadLabelRegex()
-				if err != nil {
-					return rules, fmt.Errorf("%q label: %w", id, err)
-				}
-			}
+		newfs, err := loadFilters(id, t.Filters, groups)
+		if err != nil {
+			return rules, err
+		}
 
-			if f.RawTag != "" {
-				err := f.LoadTagRegex()
-				if err != nil {
-					return rules, fmt.Errorf("%q tag: %w", id, err)
-				}
+		overrides := []RuleOverride{}
+		for _, o := range t.Overrides {
+			o.Filters, err = loadFilters(id, o.Filters, groups)
+			if err != nil {
+				return rules, fmt.Errorf("override: %w", err)
 			}
+			overrides = append(overrides, o)
+		}
 
-			if f.RawTitle != "" {
-				err := f.LoadTitleRegex()
-				if err != nil {
-					return rules, fmt.Errorf("%q title: %w", id, err)
-				}
+		if t.AutoAssign != nil {
+			aa := *t.AutoAssign
+			aa.Members, err = groups.expand(aa.Members)
+			if err != nil {
+				return rules, fmt.Errorf("%q auto-assign: %w", id, err)
 			}
+			t.AutoAssign = &aa
+		}
 
-			if f.RawMilestone != "" {
-				err := f.LoadMilestoneRegex()
+		if len(t.Escalation) > 0 {
+			levels := []EscalationLevel{}
+			for _, l := range t.Escalation {
+				l.Notify, err = expandNotify(l.Notify, groups)
 				if err != nil {
-					return rules, fmt.Errorf("%q milestone: %w", id, err)
+					return rules, fmt.Errorf("%q escalation: %w", id, err)
 				}
+				levels = append(levels, l)
 			}
-
-			newfs = append(newfs, f)
+			t.Escalation = levels
 		}
 
 		rules[id] = Rule{
 			ID:         t.ID,
+			Extends:    t.Extends,
 			Resolution: t.Resolution,
 			Name:       t.Name,
 			Repos:      t.Repos,
 			Type:       t.Type,
 			Filters:    newfs,
+			Comment:    t.Comment,
+			Stale:      t.Stale,
+			AutoAssign: t.AutoAssign,
+			SLO:        t.SLO,
+			Escalation: t.Escalation,
+			Max:        t.Max,
+			Sort:       t.Sort,
+			Overrides:  overrides,
 		}
 	}
 
 	return rules, nil
 }
 
+// expandNotify expands the groups notified by an escalation level, keeping the assignee target as is
+func expandNotify(notify []string, groups *groupResolver) ([]string, error) {
+	users := []string{}
+	assignee := false
+	for _, n := range notify {
+		if n == AssigneeTarget {
+			assignee = true
+			continue
+		}
+		users = append(users, n)
+	}
+
+	users, err := groups.expand(users)
+	if err != nil {
+		return nil, err
+	}
+	if assignee {
+		users = append([]string{AssigneeTarget}, users...)
+	}
+	return users, nil
+}
+
+// checkEscalation returns an error if the escalation chain of a rule can not be used
+func checkEscalation(r Rule) error {
+	if len(r.Escalation) > 0 && r.Type == hubbub.Alert {
+		return fmt.Errorf("alerts can not be commented on")
+	}
+
+	var last time.Duration
+	for i, l := range r.Escalation {
+		d := l.Duration()
+		if d <= 0 {
+			return fmt.Errorf("level %d: invalid after %q", i+1, l.After)
+		}
+		if d <= last {
+			return fmt.Errorf("level %d: after %q must be later than the previous level", i+1, l.After)
+		}
+		last = d
+
+		if len(l.Notify) == 0 && l.Webhook == "" {
+			return fmt.Errorf("level %d: nobody to notify", i+1)
+		}
+		if l.Webhook != "" {
+			if u, err := url.Parse(l.Webhook); err != nil || u.Host == "" {
+				return fmt.Errorf("level %d: invalid webhook URL %q", i+1, l.Webhook)
+			}
+		}
+	}
+	return nil
+}
+
+// loadFilters precaches the regular expressions and users of a rule's filters
+func loadFilters(id string, fs []provider.Filter, groups *groupResolver) ([]provider.Filter, error) {
+	newfs := []provider.Filter{}
+
+	for _, f := range fs {
+		if f.RawLabel != "" {
+			err := f.LoadLabelRegex()
+			if err != nil {
+				return nil, fmt.Errorf("%q label: %w", id, err)
+			}
+		}
+
+		if f.RawTag != "" {
+			err := f.LoadTagRegex()
+			if err != nil {
+				return nil, fmt.Errorf("%q tag: %w", id, err)
+			}
+		}
+
+		if f.RawTitle != "" {
+			err := f.LoadTitleRegex()
+			if err != nil {
+				return nil, fmt.Errorf("%q title: %w", id, err)
+			}
+		}
+
+		if f.RawMilestone != "" {
+			err := f.LoadMilestoneRegex()
+			if err != nil {
+				return nil, fmt.Errorf("%q milestone: %w", id, err)
+			}
+		}
+
+		if f.RawTriageState != "" {
+			err := f.LoadTriageStateRegex()
+			if err != nil {
+				return nil, fmt.Errorf("%q triage-state: %w", id, err)
+			}
+		}
+
+		if f.RawScoreLabel != "" {
+			err := f.LoadScoreLabelRegex()
+			if err != nil {
+				return nil, fmt.Errorf("%q score-label: %w", id, err)
+			}
+		}
+
+		if f.RawArea != "" {
+			err := f.LoadAreaRegex()
+			if err != nil {
+				return nil, fmt.Errorf("%q area: %w", id, err)
+			}
+		}
+
+		if f.RawAuthor != "" {
+			author := strings.TrimPrefix(f.RawAuthor, "!")
+			users, err := groups.expand([]string{author})
+			if err != nil {
+				return nil, fmt.Errorf("%q author: %w", id, err)
+			}
+			f.LoadAuthors(users)
+		}
+
+		for k, v := range map[string]string{"has-parent": f.HasParent, "has-children": f.HasChildren, "blocked": f.Blocked, "merge-ready": f.MergeReady} {
+			if v != "" && v != "true" && v != "false" {
+				return nil, fmt.Errorf("%q %s: must be true or false, got %q", id, k, v)
+			}
+		}
+
+		if f.RawResponders != "" {
+			users, err := groups.expand([]string{f.RawResponders})
+			if err != nil {
+				return nil, fmt.Errorf("%q responders: %w", id, err)
+			}
+			f.LoadResponders(users)
+		}
+
+		if f.RawReviewers != "" {
+			users, err := groups.expand([]string{f.RawReviewers})
+			if err != nil {
+				return nil, fmt.Errorf("%q reviewers: %w", id, err)
+			}
+			f.LoadReviewers(users)
+		}
+
+		newfs = append(newfs, f)
+	}
+
+	return newfs, nil
+}
+
 // ConversationsTotal returns the number of conversations we've seen so far
 func (p *Party) ConversationsTotal() int {
-	return p.engine.ConversationsTotal()
+	return p.eng().ConversationsTotal()
+}
+
+// ListResponses returns canned responses, sorted by name
+func (p *Party) ListResponses() []Response {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	rs := []Response{}
+	for _, r := range p.responses {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Name < rs[j].Name })
+	return rs
+}
+
+// LookupResponse returns a canned response by ID
+func (p *Party) LookupResponse(id string) (Response, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	r, ok := p.responses[id]
+	if !ok {
+		return r, fmt.Errorf("response %q is undefined", id)
+	}
+	return r, nil
+}
+
+// Warnings returns problems with the loaded configuration which are not severe enough to fail loading it
+func (p *Party) Warnings() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.warnings
+}
+
+// Quota returns how provider rate limits have been consumed
+func (p *Party) Quota() *quota.Log {
+	return p.quota
+}
+
+// Settings returns the loaded site-wide settings
+func (p *Party) Settings() Settings {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.settings
+}
+
+// Away returns whether a member is out of the office at a time
+func (p *Party) Away(login string, t time.Time) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.calendar.Away(login, t)
+}
+
+// triageStates converts configured triage states into those understood by the engine
+func triageStates(ts []TriageState) []hubbub.TriageState {
+	ss := []hubbub.TriageState{}
+	for _, t := range ts {
+		ss = append(ss, hubbub.TriageState{Name: t.Name, Label: t.Label, Next: t.Next})
+	}
+	return ss
+}
+
+// TriageStates returns the configured triage states
+func (p *Party) TriageStates() []hubbub.TriageState {
+	return triageStates(p.Settings().TriageStates)
+}
+
+// defaultFirstResponseSLO is the first response SLO if none is configured
+const defaultFirstResponseSLO = 48 * time.Hour
+
+// firstResponseSLO parses the first response SLO
+func (s Settings) firstResponseSLO() (time.Duration, error) {
+	if s.FirstResponseSLO == "" {
+		return defaultFirstResponseSLO, nil
+	}
+	d, _, _ := hubbub.ParseDuration(s.FirstResponseSLO)
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration: %q", s.FirstResponseSLO)
+	}
+	return d, nil
+}
+
+// FirstResponseSLO returns how soon members aim to respond to non-members
+func (p *Party) FirstResponseSLO() time.Duration {
+	d, err := p.Settings().firstResponseSLO()
+	if err != nil {
+		return defaultFirstResponseSLO
+	}
+	return d
+}
+
+// Provider returns the data source provider for a hostname
+func (p *Party) Provider(host string) provider.Provider {
+	if host == constants.GitLabProviderHost {
+		return p.gitlab
+	}
+	if host == constants.SourceHutProviderHost {
+		return p.sourcehut
+	}
+	if host == constants.LaunchpadProviderHost {
+		return p.launchpad
+	}
+	if host != "" && host == p.bitbucketHost {
+		return p.bitbucket
+	}
+	return p.github
+}
+
+// CheckRepo returns an error if a repository can not be read with the configured tokens
+func (p *Party) CheckRepo(ctx context.Context, repoURL string) error {
+	r, err := parseRepo(repoURL)
+	if err != nil {
+		return err
+	}
+
+	pr := p.Provider(r.Host)
+	if pr == nil {
+		return fmt.Errorf("no token configured for %s", r.Host)
+	}
+
+	sp := provider.SearchParams{
+		Repo: r,
+		IssueListByRepoOptions: provider.IssueListByRepoOptions{
+			State:       "all",
+			ListOptions: provider.ListOptions{PerPage: 1},
+		},
+	}
+	_, _, err = pr.IssuesListByRepo(ctx, sp)
+	return err
+}
+
+// ProviderStatus is whether a provider host could be read with the configured token
+type ProviderStatus struct {
+	Host string
+	Repo string
+	Rate provider.Rate
+	Err  error
+}
+
+// CheckProviders reads one repository per provider host, to report reachability and remaining rate limits
+func (p *Party) CheckProviders(ctx context.Context) ([]*ProviderStatus, error) {
+	rs, err := p.ListRules()
+	if err != nil {
+		return nil, err
+	}
+
+	repos := []string{}
+	for _, r := range rs {
+		repos = append(repos, r.Repos...)
+	}
+	sort.Strings(repos)
+
+	seen := map[string]bool{}
+	ss := []*ProviderStatus{}
+	for _, url := range repos {
+		r, err := parseRepo(url)
+		if err != nil || seen[r.Host] {
+			continue
+		}
+		seen[r.Host] = true
+
+		s := &ProviderStatus{Host: r.Host, Repo: url}
+		ss = append(ss, s)
+
+		pr := p.Provider(r.Host)
+		if pr == nil {
+			s.Err = fmt.Errorf("no token configured for %s", r.Host)
+			continue
+		}
+
+		sp := provider.SearchParams{
+			Repo: r,
+			IssueListByRepoOptions: provider.IssueListByRepoOptions{
+				State:       "all",
+				ListOptions: provider.ListOptions{PerPage: 1},
+			},
+		}
+		_, resp, err := pr.IssuesListByRepo(ctx, sp)
+		if resp != nil {
+			s.Rate = resp.Rate
+		}
+		s.Err = err
+	}
+	return ss, nil
+}
+
+// PausedUntil returns the provider host which paused requests for longest, such as after a secondary rate limit, and until when. until is zero if no requests are paused.
+func (p *Party) PausedUntil() (host string, until time.Time) {
+	hosts := []string{constants.GitHubProviderHost, constants.GitLabProviderHost, constants.SourceHutProviderHost, constants.LaunchpadProviderHost}
+	if p.bitbucketHost != "" {
+		hosts = append(hosts, p.bitbucketHost)
+	}
+	for _, h := range hosts {
+		pr, ok := p.Provider(h).(provider.Pauser)
+		if !ok {
+			continue
+		}
+		if t := pr.PausedUntil(); t.After(until) {
+			host, until = h, t
+		}
+	}
+	return host, until
+}
+
+// TokenStatus describes the token of a provider host
+type TokenStatus struct {
+	Host string
+	Info *provider.TokenInfo
+	Err  error
+}
+
+// CheckTokens describes the tokens of providers which can describe them
+func (p *Party) CheckTokens(ctx context.Context) []*TokenStatus {
+	ss := []*TokenStatus{}
+	for _, host := range []string{constants.GitHubProviderHost} {
+		ti, ok := p.Provider(host).(provider.TokenInspector)
+		if !ok {
+			continue
+		}
+		info, err := ti.TokenInfo(ctx)
+		ss = append(ss, &TokenStatus{Host: host, Info: info, Err: err})
+	}
+	return ss
+}
+
+// Conversations returns all conversations seen so far
+func (p *Party) Conversations() []*hubbub.Conversation {
+	return p.eng().Conversations()
+}
+
+// LookupConversation returns a previously seen conversation by URL
+func (p *Party) LookupConversation(url string) *hubbub.Conversation {
+	return p.eng().LookupConversation(url)
+}
+
+// ExcludeSimilar marks every pair of a list of items as never similar
+func (p *Party) ExcludeSimilar(urls []string, actor string) error {
+	return p.eng().ExcludeSimilar(urls, actor)
 }
 
 // Name returns the configured site name
 func (p *Party) Name() string {
-	return p.settings.Name
+	return p.Settings().Name
 }
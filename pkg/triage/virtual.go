@@ -0,0 +1,133 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// VirtualRepo is the slice of a monorepo which a team works on, referenced as the repository URL with a #name suffix
+type VirtualRepo struct {
+	// Repo is the URL of the repository the slice is part of
+	Repo string `yaml:"repo"`
+	// Paths are the directories whose pull requests belong to the slice
+	Paths []string `yaml:"paths,omitempty"`
+	// Labels are the area labels whose issues and pull requests belong to the slice
+	Labels []string `yaml:"labels"`
+}
+
+// virtualAreas returns the areas declared by virtual repositories, and the filter restricting items to each of them
+func virtualAreas(vrs map[string]VirtualRepo) ([]hubbub.Area, map[string]provider.Filter, error) {
+	names := []string{}
+	for name := range vrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	areas := []hubbub.Area{}
+	filters := map[string]provider.Filter{}
+	owner := map[string]string{}
+
+	for _, name := range names {
+		vr := vrs[name]
+		r, err := parseRepo(vr.Repo)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q: %w", name, err)
+		}
+		if r.AllProjects {
+			return nil, nil, fmt.Errorf("%q: %s is a group, not a repository", name, vr.Repo)
+		}
+		if len(vr.Labels) == 0 {
+			return nil, nil, fmt.Errorf("%q: at least one label is required, as issues are matched by label", name)
+		}
+
+		dirs := []string{}
+		for _, d := range vr.Paths {
+			dirs = append(dirs, strings.Trim(d, "/"))
+		}
+
+		quoted := []string{}
+		for _, l := range vr.Labels {
+			if other, ok := owner[l]; ok {
+				return nil, nil, fmt.Errorf("%q: label %q is already used by %q", name, l, other)
+			}
+			owner[l] = name
+			areas = append(areas, hubbub.Area{Label: l, Organization: r.Organization, Project: r.Project, Dirs: dirs})
+			quoted = append(quoted, regexp.QuoteMeta(l))
+		}
+
+		f := provider.Filter{RawArea: "^(" + strings.Join(quoted, "|") + ")$"}
+		if err := f.LoadAreaRegex(); err != nil {
+			return nil, nil, fmt.Errorf("%q: %w", name, err)
+		}
+		filters[name] = f
+	}
+	return areas, filters, nil
+}
+
+// virtualName returns the name of the virtual repository a repository URL references, if any
+func virtualName(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return ""
+	}
+	return u.Fragment
+}
+
+// checkVirtualRepo returns an error if a repository URL references an undefined virtual repository
+func (p *Party) checkVirtualRepo(repoURL string) error {
+	name := virtualName(repoURL)
+	if name == "" {
+		return nil
+	}
+
+	vr, ok := p.settings.VirtualRepos[name]
+	if !ok {
+		return fmt.Errorf("%q: virtual repo %q is undefined", repoURL, name)
+	}
+
+	r, err := parseRepo(repoURL)
+	if err != nil {
+		return err
+	}
+	vrr, err := parseRepo(vr.Repo)
+	if err != nil {
+		return err
+	}
+	if r != vrr {
+		return fmt.Errorf("%q: virtual repo %q is part of %s", repoURL, name, vr.Repo)
+	}
+	return nil
+}
+
+// virtualFilter returns the filter restricting items to the virtual repository a repository URL references
+func (p *Party) virtualFilter(repoURL string) (provider.Filter, bool) {
+	name := virtualName(repoURL)
+	if name == "" {
+		return provider.Filter{}, false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	f, ok := p.virtualFilters[name]
+	return f, ok
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVirtualRepos(t *testing.T) {
+	areas, filters, err := virtualAreas(map[string]VirtualRepo{
+		"api": {Repo: "https://github.com/org/mono", Paths: []string{"/services/api/"}, Labels: []string{"area/api", "area/proto"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []hubbub.Area{
+		{Label: "area/api", Organization: "org", Project: "mono", Dirs: []string{"services/api"}},
+		{Label: "area/proto", Organization: "org", Project: "mono", Dirs: []string{"services/api"}},
+	}, areas)
+	f := filters["api"]
+	assert.True(t, f.AreaRegex().MatchString("area/proto"))
+	assert.False(t, f.AreaRegex().MatchString("area/api-client"))
+
+	_, _, err = virtualAreas(map[string]VirtualRepo{
+		"a": {Repo: "https://github.com/org/mono", Labels: []string{"area/api"}},
+		"b": {Repo: "https://github.com/org/mono", Labels: []string{"area/api"}},
+	})
+	assert.NotNil(t, err)
+
+	p, err := New(Config{GitHubToken: "token"})
+	assert.Nil(t, err)
+	config := `
+settings:
+  repos: [https://github.com/org/mono]
+  virtual-repos:
+    api:
+      repo: https://github.com/org/mono
+      paths: [services/api]
+      labels: [area/api]
+collections:
+  - id: c
+    rules: [bugs]
+rules:
+  bugs:
+    repos: [%s]
+    filters: [{label: bug}]
+`
+	assert.Nil(t, p.Load(strings.NewReader(fmt.Sprintf(config, "https://github.com/org/mono#api"))))
+	_, ok := p.virtualFilter("https://github.com/org/mono#api")
+	assert.True(t, ok)
+	_, ok = p.virtualFilter("https://github.com/org/mono")
+	assert.False(t, ok)
+
+	assert.NotNil(t, p.Load(strings.NewReader(fmt.Sprintf(config, "https://github.com/org/mono#web"))))
+	assert.NotNil(t, p.Load(strings.NewReader(fmt.Sprintf(config, "https://github.com/org/other#api"))))
+}
@@ -32,10 +32,14 @@ const minFlushAge = 5 * time.Second
 
 type PFunc = func() error
 
+// Hook is called with the result of every collection update
+type Hook = func(context.Context, *triage.CollectionResult)
+
 type Config struct {
 	Party      *triage.Party
 	MinRefresh time.Duration
 	MaxRefresh time.Duration
+	Hooks      []Hook
 }
 
 func New(cfg Config) *Updater {
@@ -50,6 +54,7 @@ func New(cfg Config) *Updater {
 		loopEvery:         250 * time.Millisecond,
 		mutex:             &sync.Mutex{},
 		startTime:         time.Time{},
+		hooks:             cfg.Hooks,
 	}
 }
 
@@ -66,6 +71,7 @@ type Updater struct {
 	loopEvery         time.Duration
 	mutex             *sync.Mutex
 	updateCycles      int
+	hooks             []Hook
 
 	state string
 }
@@ -102,6 +108,31 @@ func (u *Updater) Lookup(ctx context.Context, id string, blocking bool) *triage.
 	return r
 }
 
+// Cached returns cached results without counting as a request
+func (u *Updater) Cached(id string) *triage.CollectionResult {
+	return u.cache[id]
+}
+
+// Stalest returns the collection with the oldest results, and how old they are. ok is false if a collection has no results yet.
+func (u *Updater) Stalest() (id string, age time.Duration, ok bool) {
+	sts, err := u.party.ListCollections()
+	if err != nil {
+		return "", 0, false
+	}
+
+	for _, s := range sts {
+		r := u.cache[s.ID]
+		if r == nil {
+			return s.ID, 0, false
+		}
+		if a := time.Since(r.OldestInput); a > age || id == "" {
+			id = s.ID
+			age = a
+		}
+	}
+	return id, age, true
+}
+
 func (u *Updater) ForceRefresh(ctx context.Context, id string) *triage.CollectionResult {
 	defer u.recordAccess(id)
 
@@ -210,12 +241,19 @@ func (u *Updater) update(ctx context.Context, s triage.Collection, newerThan tim
 	u.state = fmt.Sprintf("updating %s to %s", s.ID, logu.STime(newerThan))
 
 	klog.Infof(">>> updating %q with data newer than %s >>>", s.ID, logu.STime(newerThan))
+	q := u.party.Quota()
+	before := q.Totals()
 	r, err := u.party.ExecuteCollection(ctx, s, newerThan)
+	q.RecordRefresh(s.ID, start, before)
 	if err != nil {
 		return err
 	}
 	u.cache[s.ID] = r
 	klog.Infof("<<< updated %q to %s (oldest input: %s, duration: %s) <<<", s.ID, logu.STime(r.Created), logu.STime(r.OldestInput), time.Since(start))
+
+	for _, h := range u.hooks {
+		h(ctx, r)
+	}
 	return nil
 }
 
@@ -240,6 +278,22 @@ func (u *Updater) RefreshCollection(ctx context.Context, id string, newerThan ti
 	return true, err
 }
 
+// Reevaluate re-runs every collection against previously fetched data, such as after a configuration reload
+func (u *Updater) Reevaluate(ctx context.Context) {
+	sts, err := u.party.ListCollections()
+	if err != nil {
+		klog.Errorf("list collections: %v", err)
+		return
+	}
+
+	for _, s := range sts {
+		// A zero newerThan accepts cached data, avoiding a refetch
+		if _, err := u.RefreshCollection(ctx, s.ID, time.Time{}, true); err != nil {
+			klog.Errorf("%s failed to update: %v", s.ID, err)
+		}
+	}
+}
+
 // Run once, optionally forcing an update
 func (u *Updater) RunOnce(ctx context.Context, force bool) (bool, error) {
 	updated := false
@@ -305,6 +359,12 @@ func (u *Updater) Loop(ctx context.Context) error {
 	ticker := time.NewTicker(u.loopEvery)
 	defer ticker.Stop()
 	for range ticker.C {
+		// Keep serving cached results rather than prolonging a ban
+		if host, until := u.party.PausedUntil(); !until.IsZero() {
+			u.state = fmt.Sprintf("paused until %s: %s secondary rate limit", until.Format(time.RFC3339), host)
+			continue
+		}
+
 		_, err := u.RunOnce(ctx, false)
 		if err != nil {
 			klog.Errorf("err: %v", err)